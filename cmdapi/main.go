@@ -2,30 +2,68 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"neighbourhood/internal/api"
+	"neighbourhood/internal/audit"
 	"neighbourhood/internal/auth"
 	"neighbourhood/internal/config"
 	"neighbourhood/internal/database"
 	"neighbourhood/internal/integrations"
+	"neighbourhood/internal/jobs"
+	"neighbourhood/internal/keys"
+	"neighbourhood/internal/lifecycle"
 	"neighbourhood/internal/mcp"
 	"neighbourhood/internal/middleware"
+	"neighbourhood/internal/outbox"
+	"neighbourhood/internal/rbac"
+	"neighbourhood/internal/workflow"
+
+	"github.com/redis/go-redis/v9"
 )
 
+// loadConfig reads the YAML file named by CONFIG_FILE, falling back to the
+// environment alone when CONFIG_FILE is unset or the file does not exist.
+func loadConfig() (*config.Config, error) {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return config.Load()
+	}
+	cfg, err := config.LoadFromFile(path)
+	var notFound *config.FileNotFoundError
+	if errors.As(err, &notFound) {
+		log.Printf("WARNING: %v; using environment variables only", err)
+		return config.Load()
+	}
+	return cfg, err
+}
+
 func main() {
 	// 0. Load Configuration
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
 		log.Fatalf("Configuration error: %v", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		if cfg.Server.Env == "production" {
+			log.Fatalf("Misconfigured providers; fix or disable them (<NAME>_ENABLED=false):\n%v", err)
+		}
+		log.Printf("WARNING: misconfigured providers will fail at runtime:\n%v", err)
+	}
 	log.Printf("Starting NeighbourHood Integration Platform in %s mode", cfg.Server.Env)
+	if active := cfg.Features.Active(); len(active) > 0 {
+		log.Printf("Feature flags enabled: %s", strings.Join(active, ", "))
+	} else {
+		log.Println("Feature flags enabled: none")
+	}
 
 	// 1. Set Working Directory to Project Root
 	if err := setProjectRoot(); err != nil {
@@ -42,82 +80,280 @@ func main() {
 		log.Fatalf("CRITICAL: ./webpages/index.html not found in %s. Check project structure.", wd)
 	}
 
-	// 2. Initialize Database
+	// 2. Initialize Database. /health/ready reports 503 until migrations
+	// succeed.
+	dbReady := false
+	ready := &readiness{}
 	if err := database.InitDB(); err != nil {
 		log.Printf("WARNING: Failed to initialize database: %v", err)
 		log.Println("Server running in OFFLINE mode (No Database). Some features may be limited.")
+		ready.markReady()
 	} else {
 		defer database.DB.Close()
+		dbReady = true
 
 		// Run Migrations
-		if err := database.RunMigrations(); err != nil {
+		if err := ready.migrate(database.RunMigrations); err != nil {
 			log.Printf("WARNING: Failed to run migrations: %v", err)
+			log.Println("Server is not ready; /health/ready will report 503.")
 		}
 	}
 
 	// 3. Register Integration Providers
+	integrations.VerboseLogging = cfg.Server.ProviderVerboseLogging
+	timeouts := make(map[integrations.IntegrationType]time.Duration, len(cfg.Server.ProviderTimeouts))
+	for name, d := range cfg.Server.ProviderTimeouts {
+		timeouts[integrations.IntegrationType(name)] = d
+	}
+	integrations.SetActionTimeouts(cfg.Server.ProviderTimeout, timeouts)
 	registerProviders(cfg)
 
 	// 4. Setup API Handler
 	apiHandler := api.NewHandler()
+	apiHandler.SetWorkflowConfig(cfg.Workflow)
+	apiHandler.SetStrictJSON(cfg.Server.StrictJSON)
+	apiHandler.SetRedirectAllowlist(cfg.Auth.RedirectAllowlist)
+
+	// Execution events go through the outbox so they survive delivery failures.
+	var events interface {
+		outbox.Publisher
+		outbox.Store
+	} = outbox.NewMemoryStore()
+	if dbReady {
+		events = outbox.NewSQLStore(database.DB)
+	}
+	apiHandler.SetEventPublisher(events)
+
+	// With a database, each execution and its event commit together.
+	if dbReady {
+		apiHandler.SetExecutionHistory(integrations.NewSQLExecutionHistory(database.DB))
+	}
+
+	// Async actions are persisted so queued jobs survive a restart.
+	if dbReady {
+		apiHandler.SetJobStore(jobs.NewSQLStore(database.DB))
+	}
+
+	// Change-poll watermarks are persisted so polls resume after a restart.
+	if dbReady {
+		if p, err := integrations.GetProvider(integrations.IntegrationAirtable); err == nil {
+			if airtable, ok := p.(*integrations.AirtableProvider); ok {
+				airtable.Watermarks = integrations.NewSQLWatermarkStore(database.DB)
+			}
+		}
+	}
+
+	// Workspace roles are managed by the auth service in the shared database.
+	if dbReady {
+		apiHandler.SetRoleStore(rbac.NewSQLStore(database.DB))
+		apiHandler.SetPlanStore(rbac.NewSQLPlanStore(database.DB))
+	}
+
+	// Provider tokens are stored in the database when it is up, encrypted
+	// under per-user keys when master keys are set.
+	var connections integrations.ConnectionStore = integrations.NewMemoryConnectionStore()
+	if dbReady {
+		connections = integrations.NewSQLConnectionStore(database.DB)
+	}
+	var tokenCipher auth.TokenCipher
+	if cfg.Auth.TokenEncryptionKeys != "" {
+		ring, err := keys.ParseKeyring(cfg.Auth.TokenEncryptionKeys)
+		if err != nil {
+			log.Fatalf("Invalid TOKEN_ENCRYPTION_KEYS: %v", err)
+		}
+		var keyStore keys.KeyStore = keys.NewMemoryKeyStore()
+		if dbReady {
+			keyStore = keys.NewSQLKeyStore(database.DB)
+		}
+		encryptor := keys.NewUserEncryptor(ring, keyStore)
+		if n, err := encryptor.RewrapAll(context.Background()); err != nil {
+			log.Printf("WARNING: Failed to re-wrap data keys: %v", err)
+		} else if n > 0 {
+			log.Printf("Re-wrapped %d data keys under master key %s", n, ring.CurrentID())
+		}
+		connections = integrations.NewEncryptedConnectionStore(connections, encryptor)
+		tokenCipher = encryptor
+	}
+	apiHandler.SetConnectionStore(connections)
+
+	var deliverer outbox.Deliverer = outbox.LogDeliverer
+	if cfg.Events.WebhookURL != "" {
+		deliverer = &outbox.HTTPDeliverer{URL: cfg.Events.WebhookURL, Secret: cfg.Events.WebhookSecret}
+	}
+	// Background workers run under one lifecycle so shutdown can drain them.
+	workers := lifecycle.New()
+	dispatcher := outbox.NewDispatcher(events, deliverer)
+	workers.Go("outbox dispatcher", func(ctx context.Context) { dispatcher.Run(ctx, 5*time.Second) })
+	workers.Go("job queue", apiHandler.RunJobs)
+	apiHandler.SetWorkers(workers.Go)
 
 	// 5. Setup OAuth Handler
 	oauthHandler := auth.NewOAuthHandler(cfg)
+	workers.Go("oauth state cleanup", oauthHandler.RunStateCleanup)
+	// Logins are linked to stored users only with a database; OFFLINE mode
+	// still issues session tokens.
+	if dbReady {
+		oauthHandler.SetUserStore(auth.NewSQLUserStore(database.DB, tokenCipher))
+	}
+	var (
+		accounts auth.AccountStore = auth.NewMemoryAccountStore()
+		auditLog auth.AuditLog     = auth.NewMemoryAuditLog()
+	)
+	if dbReady {
+		accounts, auditLog = auth.NewSQLAccountStore(database.DB), auth.NewSQLAuditLog(database.DB)
+	}
+	impersonator := auth.NewImpersonator(cfg.Auth.JWTSecret, accounts, auditLog)
+	apiHandler.SetAccountStore(accounts)
+	apiHandler.SetEffectiveConfig(cfg)
+
+	// Audit exports are signed so auditors can detect tampering. Without a
+	// configured key they stay disabled in production.
+	switch {
+	case cfg.Auth.AuditSigningKey != "":
+		signer, err := audit.ParseSigningKey(cfg.Auth.AuditSigningKey)
+		if err != nil {
+			log.Fatalf("Invalid AUDIT_SIGNING_KEY: %v", err)
+		}
+		log.Printf("Signing audit exports with key %s (published at /.well-known/audit-keys.json)", signer.KeyID())
+		apiHandler.SetAuditExport(accounts, auditLog, signer)
+	case cfg.Server.Env != "production":
+		signer, err := audit.GenerateSigner()
+		if err != nil {
+			log.Fatalf("Failed to generate audit signing key: %v", err)
+		}
+		log.Printf("WARNING: AUDIT_SIGNING_KEY is not set; signing audit exports with throwaway key %s", signer.KeyID())
+		apiHandler.SetAuditExport(accounts, auditLog, signer)
+	default:
+		log.Println("AUDIT_SIGNING_KEY is not set; audit export is disabled")
+	}
 
-	// 6. Setup Router
+	// 6. Setup Router. Each route lists the methods it serves, which CORS
+	// preflights advertise.
 	mux := http.NewServeMux()
+	routes := middleware.NewRoutes(mux)
 
 	// Health Check
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	routes.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprint(w, `{"status":"ok"}`)
-	})
+	}, http.MethodGet)
+	routes.HandleFunc("/health/ready", ready.handler, http.MethodGet)
+	routes.HandleFunc("/health/providers", providerHealthHandler(cfg), http.MethodGet)
 
 	// Static Files
 	fs := http.FileServer(http.Dir("./webpages/static"))
-	mux.Handle("/static/", http.StripPrefix("/static/", fs))
+	routes.Handle("/static/", http.StripPrefix("/static/", fs), http.MethodGet)
 
 	// Home/Dashboard
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	routes.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/" {
 			http.NotFound(w, r)
 			return
 		}
 		http.ServeFile(w, r, "./webpages/index.html")
-	})
+	}, http.MethodGet)
 
 	// Auth Routes
-	mux.HandleFunc("/auth/login", auth.LoginHandler)
+	routes.HandleFunc("/auth/login", auth.LoginHandler, http.MethodPost)
+	routes.Handle("/auth/impersonate", impersonator, http.MethodPost)
 
 	// OAuth Routes
-	mux.HandleFunc("/auth/google/login", oauthHandler.GoogleLoginHandler)
-	mux.HandleFunc("/auth/google/callback", oauthHandler.GoogleCallbackHandler)
-	mux.HandleFunc("/auth/github/login", oauthHandler.GitHubLoginHandler)
-	mux.HandleFunc("/auth/github/callback", oauthHandler.GitHubCallbackHandler)
+	routes.HandleFunc("/auth/google/login", oauthHandler.GoogleLoginHandler, http.MethodGet)
+	routes.HandleFunc("/auth/google/callback", oauthHandler.GoogleCallbackHandler, http.MethodGet)
+	routes.HandleFunc("/auth/github/login", oauthHandler.GitHubLoginHandler, http.MethodGet)
+	routes.HandleFunc("/auth/github/callback", oauthHandler.GitHubCallbackHandler, http.MethodGet)
+	// Integration OAuth callbacks carry no bearer token; the state bound by
+	// /api/integration/authurl identifies the user instead.
+	routes.HandleFunc("/integrations/callback", apiHandler.IntegrationCallback, http.MethodGet)
 
 	// API Gateway routes for integrations and workflows
-	mux.HandleFunc("/api/integrations", apiHandler.ListIntegrations)
-	mux.HandleFunc("/api/integration/authurl", apiHandler.GetIntegrationAuthURL)
-	mux.HandleFunc("/api/integration/execute", apiHandler.ExecuteIntegrationAction)
-	mux.HandleFunc("/api/workflow/execute", apiHandler.ExecuteWorkflow)
+	routes.HandleFunc("/api/integrations", apiHandler.ListIntegrations, http.MethodGet)
+	routes.HandleFunc("/api/integration/actions", apiHandler.ListIntegrationActions, http.MethodGet)
+	routes.HandleFunc("/api/integration/authurl", apiHandler.GetIntegrationAuthURL, http.MethodPost)
+	routes.HandleFunc("/api/integration/execute", apiHandler.ExecuteIntegrationAction, http.MethodPost)
+	routes.HandleFunc("/api/integrations/batch", apiHandler.ExecuteIntegrationBatch, http.MethodPost)
+	routes.HandleFunc("/api/integration/connect-token", apiHandler.ConnectToken, http.MethodPost)
+	routes.HandleFunc("/api/integration/disconnect", apiHandler.DisconnectIntegration, http.MethodPost)
+	routes.HandleFunc("/api/integration/history.csv", apiHandler.ExportHistoryCSV, http.MethodGet)
+	routes.HandleFunc("/api/workflow/execute", apiHandler.ExecuteWorkflow, http.MethodPost)
+	routes.HandleFunc("/api/workflow/execute/async", apiHandler.ExecuteWorkflowAsync, http.MethodPost)
+	routes.HandleFunc("/api/workflow/status", apiHandler.WorkflowStatus, http.MethodGet)
+	routes.HandleFunc("/api/workflow/cancel", apiHandler.CancelWorkflow, http.MethodPost)
+	routes.HandleFunc("/api/workflow/test", apiHandler.TestWorkflow, http.MethodPost)
+	routes.HandleFunc("/api/workflow/preview", apiHandler.PreviewWorkflow, http.MethodPost)
+	routes.HandleFunc("/api/jobs/", apiHandler.GetJob, http.MethodGet)
+	routes.HandleFunc("/api/workspace/", apiHandler.WorkspacePermissions, http.MethodGet)
+	routes.HandleFunc("/api/consent", apiHandler.ListConsents, http.MethodGet)
+	routes.HandleFunc("/api/admin/audit/export", apiHandler.ExportAudit, http.MethodGet)
+	routes.HandleFunc("/api/admin/config", apiHandler.EffectiveConfig, http.MethodGet)
+	routes.HandleFunc("/.well-known/audit-keys.json", apiHandler.AuditKeys, http.MethodGet)
 
 	// MCP Routes
-	mux.HandleFunc("/mcp", mcp.Handler)
-
-	// 7. Apply Global Middleware (security headers → logging → CORS)
-	handler := middleware.Chain(mux,
-		middleware.SecurityHeaders,
-		middleware.Logger,
-		middleware.CORS,
-	)
-
-	// 8. Configure HTTP server with explicit timeouts and start with graceful shutdown
+	routes.HandleFunc("/mcp", mcp.Handler, http.MethodPost)
+
+	// 7. Apply Global Middleware (recovery → request ID → security headers → logging → CORS → auth → workspace)
+	profile := middleware.LoadSecurityProfile(cfg.Server.Env)
+	if err := profile.Validate(); err != nil {
+		log.Fatalf("Invalid security profile: %v", err)
+	}
+	profile.CORSMaxAge = cfg.Server.CORSMaxAge
+	log.Printf("Using %s security profile", profile.Name)
+	middleware.TrustForwardedFor = cfg.Server.TrustProxy
+	if cfg.Server.SlowRequestThreshold > 0 {
+		log.Printf("Logging requests slower than %v as slow", cfg.Server.SlowRequestThreshold)
+	}
+	chain := []func(http.Handler) http.Handler{
+		middleware.Recovery,
+		middleware.RequestID,
+		middleware.SecurityHeadersWithProfile(profile),
+		middleware.LoggerWithSlowThreshold(cfg.Server.SlowRequestThreshold),
+		middleware.CORSWithRoutes(profile, routes),
+		// The gateway and MCP endpoints act on a user's connections, so they
+		// require a session token; the development profile accepts any token.
+		middleware.AuthPaths(cfg.Auth.JWTSecret, profile.Name == "development", "/api/", "/mcp"),
+		middleware.Workspace,
+	}
+	// Redis-backed features are only enabled when REDIS_ADDR is configured.
+	if cfg.Redis.Addr != "" {
+		rdb := redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		defer rdb.Close()
+
+		// Policies were validated by config.Load.
+		rateLimitPolicy, _ := middleware.ParseFailurePolicy(cfg.Redis.RateLimitPolicy)
+		idempotencyPolicy, _ := middleware.ParseFailurePolicy(cfg.Redis.IdempotencyPolicy)
+		concurrencyPolicy, _ := middleware.ParseFailurePolicy(cfg.Redis.WorkflowConcurrencyPolicy)
+		if config.FlagEnabled(config.FlagRateLimiting) {
+			chain = append(chain, middleware.RedisRateLimiter(rdb, cfg.Server.RateLimitRPM, time.Minute, rateLimitPolicy))
+		}
+		chain = append(chain, middleware.Idempotency(rdb, 24*time.Hour, idempotencyPolicy))
+		// Counting in-flight workflows in Redis holds the per-user limit
+		// across replicas.
+		apiHandler.SetConcurrencyGate(workflow.NewRedisConcurrencyGate(rdb, time.Hour), concurrencyPolicy)
+		// Inline tokens of queued actions are shared so any replica can run
+		// the job.
+		apiHandler.SetJobTokenStore(jobs.NewRedisSecretStore(rdb))
+		log.Printf("Redis features enabled (rate limit: %s, idempotency: %s, workflow concurrency: %s)", rateLimitPolicy, idempotencyPolicy, concurrencyPolicy)
+	} else if config.FlagEnabled(config.FlagRateLimiting) {
+		chain = append(chain, middleware.RateLimiterWithCleanup(cfg.Server.RateLimitRPM, time.Minute, workers.Go))
+	}
+	if !config.FlagEnabled(config.FlagRateLimiting) {
+		log.Println("WARNING: rate limiting disabled by the rate_limiting feature flag")
+	}
+	handler := middleware.Chain(mux, chain...)
+
+	// 8. Configure HTTP server with explicit timeouts and start with graceful shutdown.
+	// Responses wait on provider actions, so the write deadline outlasts the
+	// slowest configured action timeout.
 	srv := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
 		Handler:      handler,
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: integrations.MaxActionTimeout() + 15*time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
@@ -140,227 +376,19 @@ func main() {
 		log.Println("Shutdown signal received, draining connections...")
 	}
 
+	// Stop taking requests first, so work they queue is still drained.
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatalf("Graceful shutdown failed: %v", err)
 	}
+	log.Println("Draining background workers...")
+	if err := workers.Shutdown(ctx); err != nil {
+		log.Fatalf("Graceful shutdown failed: %v", err)
+	}
 	log.Println("Server stopped cleanly")
 }
 
-// registerProviders registers all integration providers
-func registerProviders(cfg *config.Config) {
-	// Communication & Collaboration
-	if cfg.Providers.Slack.Enabled {
-		integrations.RegisterProvider(integrations.NewSlackProvider(
-			cfg.Providers.Slack.ClientID, cfg.Providers.Slack.ClientSecret, cfg.Providers.Slack.RedirectURL))
-		log.Println("✓ Registered Slack provider")
-	}
-	if cfg.Providers.MicrosoftTeams.Enabled {
-		integrations.RegisterProvider(integrations.NewMicrosoftTeamsProvider(
-			cfg.Providers.MicrosoftTeams.ClientID, cfg.Providers.MicrosoftTeams.ClientSecret, cfg.Providers.MicrosoftTeams.RedirectURL))
-		log.Println("✓ Registered Microsoft Teams provider")
-	}
-	if cfg.Providers.Zoom.Enabled {
-		integrations.RegisterProvider(integrations.NewZoomProvider(
-			cfg.Providers.Zoom.ClientID, cfg.Providers.Zoom.ClientSecret, cfg.Providers.Zoom.RedirectURL))
-		log.Println("✓ Registered Zoom provider")
-	}
-	if cfg.Providers.Discord.Enabled {
-		integrations.RegisterProvider(integrations.NewDiscordProvider(
-			cfg.Providers.Discord.ClientID, cfg.Providers.Discord.ClientSecret, cfg.Providers.Discord.RedirectURL))
-		log.Println("✓ Registered Discord provider")
-	}
-
-	// Email & Marketing
-	if cfg.Providers.Gmail.Enabled {
-		integrations.RegisterProvider(integrations.NewGmailProvider(
-			cfg.Providers.Gmail.ClientID, cfg.Providers.Gmail.ClientSecret, cfg.Providers.Gmail.RedirectURL))
-		log.Println("✓ Registered Gmail provider")
-	}
-	if cfg.Providers.SendGrid.Enabled {
-		integrations.RegisterProvider(integrations.NewSendGridProvider(
-			cfg.Providers.SendGrid.ClientID, cfg.Providers.SendGrid.ClientSecret, cfg.Providers.SendGrid.RedirectURL))
-		log.Println("✓ Registered SendGrid provider")
-	}
-	if cfg.Providers.Mailchimp.Enabled {
-		integrations.RegisterProvider(integrations.NewMailchimpProvider(
-			cfg.Providers.Mailchimp.ClientID, cfg.Providers.Mailchimp.ClientSecret, cfg.Providers.Mailchimp.RedirectURL))
-		log.Println("✓ Registered Mailchimp provider")
-	}
-	if cfg.Providers.Twilio.Enabled {
-		integrations.RegisterProvider(integrations.NewTwilioProvider(
-			cfg.Providers.Twilio.ClientID, cfg.Providers.Twilio.ClientSecret, cfg.Providers.Twilio.RedirectURL))
-		log.Println("✓ Registered Twilio provider")
-	}
-
-	// Project Management
-	if cfg.Providers.Jira.Enabled {
-		integrations.RegisterProvider(integrations.NewJiraProvider(
-			cfg.Providers.Jira.ClientID, cfg.Providers.Jira.ClientSecret, cfg.Providers.Jira.RedirectURL))
-		log.Println("✓ Registered Jira provider")
-	}
-	if cfg.Providers.Trello.Enabled {
-		integrations.RegisterProvider(integrations.NewTrelloProvider(
-			cfg.Providers.Trello.ClientID, cfg.Providers.Trello.ClientSecret, cfg.Providers.Trello.RedirectURL))
-		log.Println("✓ Registered Trello provider")
-	}
-	if cfg.Providers.Asana.Enabled {
-		integrations.RegisterProvider(integrations.NewAsanaProvider(
-			cfg.Providers.Asana.ClientID, cfg.Providers.Asana.ClientSecret, cfg.Providers.Asana.RedirectURL))
-		log.Println("✓ Registered Asana provider")
-	}
-	if cfg.Providers.Monday.Enabled {
-		integrations.RegisterProvider(integrations.NewMondayProvider(
-			cfg.Providers.Monday.ClientID, cfg.Providers.Monday.ClientSecret, cfg.Providers.Monday.RedirectURL))
-		log.Println("✓ Registered Monday.com provider")
-	}
-	if cfg.Providers.Notion.Enabled {
-		integrations.RegisterProvider(integrations.NewNotionProvider(
-			cfg.Providers.Notion.ClientID, cfg.Providers.Notion.ClientSecret, cfg.Providers.Notion.RedirectURL))
-		log.Println("✓ Registered Notion provider")
-	}
-	if cfg.Providers.ClickUp.Enabled {
-		integrations.RegisterProvider(integrations.NewClickUpProvider(
-			cfg.Providers.ClickUp.ClientID, cfg.Providers.ClickUp.ClientSecret, cfg.Providers.ClickUp.RedirectURL))
-		log.Println("✓ Registered ClickUp provider")
-	}
-
-	// CRM & Sales
-	if cfg.Providers.Salesforce.Enabled {
-		integrations.RegisterProvider(integrations.NewSalesforceProvider(
-			cfg.Providers.Salesforce.ClientID, cfg.Providers.Salesforce.ClientSecret, cfg.Providers.Salesforce.RedirectURL))
-		log.Println("✓ Registered Salesforce provider")
-	}
-	if cfg.Providers.HubSpot.Enabled {
-		integrations.RegisterProvider(integrations.NewHubSpotProvider(
-			cfg.Providers.HubSpot.ClientID, cfg.Providers.HubSpot.ClientSecret, cfg.Providers.HubSpot.RedirectURL))
-		log.Println("✓ Registered HubSpot provider")
-	}
-	if cfg.Providers.Zendesk.Enabled {
-		integrations.RegisterProvider(integrations.NewZendeskProvider(
-			cfg.Providers.Zendesk.ClientID, cfg.Providers.Zendesk.ClientSecret, cfg.Providers.Zendesk.RedirectURL))
-		log.Println("✓ Registered Zendesk provider")
-	}
-	if cfg.Providers.Intercom.Enabled {
-		integrations.RegisterProvider(integrations.NewIntercomProvider(
-			cfg.Providers.Intercom.ClientID, cfg.Providers.Intercom.ClientSecret, cfg.Providers.Intercom.RedirectURL))
-		log.Println("✓ Registered Intercom provider")
-	}
-	if cfg.Providers.Pipedrive.Enabled {
-		integrations.RegisterProvider(integrations.NewPipedriveProvider(
-			cfg.Providers.Pipedrive.ClientID, cfg.Providers.Pipedrive.ClientSecret, cfg.Providers.Pipedrive.RedirectURL))
-		log.Println("✓ Registered Pipedrive provider")
-	}
-
-	// Development & Code
-	if cfg.Providers.GitHub.Enabled {
-		integrations.RegisterProvider(integrations.NewGitHubProvider(
-			cfg.Providers.GitHub.ClientID, cfg.Providers.GitHub.ClientSecret, cfg.Providers.GitHub.RedirectURL))
-		log.Println("✓ Registered GitHub provider")
-	}
-	if cfg.Providers.GitLab.Enabled {
-		integrations.RegisterProvider(integrations.NewGitLabProvider(
-			cfg.Providers.GitLab.ClientID, cfg.Providers.GitLab.ClientSecret, cfg.Providers.GitLab.RedirectURL))
-		log.Println("✓ Registered GitLab provider")
-	}
-	if cfg.Providers.Bitbucket.Enabled {
-		integrations.RegisterProvider(integrations.NewBitbucketProvider(
-			cfg.Providers.Bitbucket.ClientID, cfg.Providers.Bitbucket.ClientSecret, cfg.Providers.Bitbucket.RedirectURL))
-		log.Println("✓ Registered Bitbucket provider")
-	}
-
-	// Storage & Documents
-	if cfg.Providers.Dropbox.Enabled {
-		integrations.RegisterProvider(integrations.NewDropboxProvider(
-			cfg.Providers.Dropbox.ClientID, cfg.Providers.Dropbox.ClientSecret, cfg.Providers.Dropbox.RedirectURL))
-		log.Println("✓ Registered Dropbox provider")
-	}
-	if cfg.Providers.GoogleDrive.Enabled {
-		integrations.RegisterProvider(integrations.NewGoogleDriveProvider(
-			cfg.Providers.GoogleDrive.ClientID, cfg.Providers.GoogleDrive.ClientSecret, cfg.Providers.GoogleDrive.RedirectURL))
-		log.Println("✓ Registered Google Drive provider")
-	}
-	if cfg.Providers.OneDrive.Enabled {
-		integrations.RegisterProvider(integrations.NewOneDriveProvider(
-			cfg.Providers.OneDrive.ClientID, cfg.Providers.OneDrive.ClientSecret, cfg.Providers.OneDrive.RedirectURL))
-		log.Println("✓ Registered OneDrive provider")
-	}
-	if cfg.Providers.Box.Enabled {
-		integrations.RegisterProvider(integrations.NewBoxProvider(
-			cfg.Providers.Box.ClientID, cfg.Providers.Box.ClientSecret, cfg.Providers.Box.RedirectURL))
-		log.Println("✓ Registered Box provider")
-	}
-
-	// Payment & E-commerce
-	if cfg.Providers.Stripe.Enabled {
-		integrations.RegisterProvider(integrations.NewStripeProvider(
-			cfg.Providers.Stripe.ClientID, cfg.Providers.Stripe.ClientSecret, cfg.Providers.Stripe.RedirectURL))
-		log.Println("✓ Registered Stripe provider")
-	}
-	if cfg.Providers.Shopify.Enabled {
-		integrations.RegisterProvider(integrations.NewShopifyProvider(
-			cfg.Providers.Shopify.ClientID, cfg.Providers.Shopify.ClientSecret, cfg.Providers.Shopify.RedirectURL))
-		log.Println("✓ Registered Shopify provider")
-	}
-	if cfg.Providers.PayPal.Enabled {
-		integrations.RegisterProvider(integrations.NewPayPalProvider(
-			cfg.Providers.PayPal.ClientID, cfg.Providers.PayPal.ClientSecret, cfg.Providers.PayPal.RedirectURL))
-		log.Println("✓ Registered PayPal provider")
-	}
-	if cfg.Providers.Square.Enabled {
-		integrations.RegisterProvider(integrations.NewSquareProvider(
-			cfg.Providers.Square.ClientID, cfg.Providers.Square.ClientSecret, cfg.Providers.Square.RedirectURL))
-		log.Println("✓ Registered Square provider")
-	}
-
-	// Data & Analytics
-	if cfg.Providers.Airtable.Enabled {
-		integrations.RegisterProvider(integrations.NewAirtableProvider(
-			cfg.Providers.Airtable.ClientID, cfg.Providers.Airtable.ClientSecret, cfg.Providers.Airtable.RedirectURL))
-		log.Println("✓ Registered Airtable provider")
-	}
-	if cfg.Providers.GoogleSheets.Enabled {
-		integrations.RegisterProvider(integrations.NewGoogleSheetsProvider(
-			cfg.Providers.GoogleSheets.ClientID, cfg.Providers.GoogleSheets.ClientSecret, cfg.Providers.GoogleSheets.RedirectURL))
-		log.Println("✓ Registered Google Sheets provider")
-	}
-	if cfg.Providers.Tableau.Enabled {
-		integrations.RegisterProvider(integrations.NewTableauProvider(
-			cfg.Providers.Tableau.ClientID, cfg.Providers.Tableau.ClientSecret, cfg.Providers.Tableau.RedirectURL))
-		log.Println("✓ Registered Tableau provider")
-	}
-	if cfg.Providers.MicrosoftExcel.Enabled {
-		integrations.RegisterProvider(integrations.NewMicrosoftExcelProvider(
-			cfg.Providers.MicrosoftExcel.ClientID, cfg.Providers.MicrosoftExcel.ClientSecret, cfg.Providers.MicrosoftExcel.RedirectURL))
-		log.Println("✓ Registered Microsoft Excel provider")
-	}
-
-	// Social Media
-	if cfg.Providers.Twitter.Enabled {
-		integrations.RegisterProvider(integrations.NewTwitterProvider(
-			cfg.Providers.Twitter.ClientID, cfg.Providers.Twitter.ClientSecret, cfg.Providers.Twitter.RedirectURL))
-		log.Println("✓ Registered Twitter provider")
-	}
-	if cfg.Providers.LinkedIn.Enabled {
-		integrations.RegisterProvider(integrations.NewLinkedInProvider(
-			cfg.Providers.LinkedIn.ClientID, cfg.Providers.LinkedIn.ClientSecret, cfg.Providers.LinkedIn.RedirectURL))
-		log.Println("✓ Registered LinkedIn provider")
-	}
-	if cfg.Providers.Facebook.Enabled {
-		integrations.RegisterProvider(integrations.NewFacebookProvider(
-			cfg.Providers.Facebook.ClientID, cfg.Providers.Facebook.ClientSecret, cfg.Providers.Facebook.RedirectURL))
-		log.Println("✓ Registered Facebook provider")
-	}
-	if cfg.Providers.Instagram.Enabled {
-		integrations.RegisterProvider(integrations.NewInstagramProvider(
-			cfg.Providers.Instagram.ClientID, cfg.Providers.Instagram.ClientSecret, cfg.Providers.Instagram.RedirectURL))
-		log.Println("✓ Registered Instagram provider")
-	}
-
-	log.Printf("Total providers registered: %d", len(integrations.Providers))
-}
-
 // setProjectRoot attempts to find the go.mod file and change the working directory to its location.
 func setProjectRoot() error {
 	_, err := os.Getwd()
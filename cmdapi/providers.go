@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+
+	"neighbourhood/internal/config"
+	"neighbourhood/internal/integrations"
+	"neighbourhood/internal/middleware"
+)
+
+// providerEntry describes one integration provider to register at startup.
+type providerEntry struct {
+	name    string // display name used in startup logs
+	enabled bool
+	build   func() integrations.Provider
+	// credentials maps each setting the provider needs to run, such as
+	// client_id, to its configured value.
+	credentials map[string]string
+}
+
+// missingCredentials returns the names of required settings left empty,
+// sorted.
+func (e providerEntry) missingCredentials() []string {
+	var missing []string
+	for name, value := range e.credentials {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// oauthEntry builds a providerEntry for the common case of a provider
+// constructed from OAuth client credentials.
+func oauthEntry[P integrations.Provider](name string, cfg config.ProviderConfig, newProvider func(clientID, clientSecret, redirectURL string) P) providerEntry {
+	return providerEntry{
+		name:    name,
+		enabled: cfg.Enabled,
+		build: func() integrations.Provider {
+			return newProvider(cfg.ClientID, cfg.ClientSecret, cfg.RedirectURL)
+		},
+		credentials: map[string]string{
+			"client_id":     cfg.ClientID,
+			"client_secret": cfg.ClientSecret,
+		},
+	}
+}
+
+// providerEntries lists every provider the gateway can register. Adding a
+// provider only needs a line here and its config.
+func providerEntries(cfg *config.Config) []providerEntry {
+	p := cfg.Providers
+	return []providerEntry{
+		// Communication & Collaboration
+		oauthEntry("Slack", p.Slack, func(clientID, clientSecret, redirectURL string) *integrations.SlackProvider {
+			slack := integrations.NewSlackProvider(clientID, clientSecret, redirectURL)
+			slack.Scopes = p.Slack.Scopes
+			return slack
+		}),
+		oauthEntry("Microsoft Teams", p.MicrosoftTeams, integrations.NewMicrosoftTeamsProvider),
+		oauthEntry("Zoom", p.Zoom, integrations.NewZoomProvider),
+		oauthEntry("Discord", p.Discord, integrations.NewDiscordProvider),
+
+		// Email & Marketing
+		oauthEntry("Gmail", p.Gmail, integrations.NewGmailProvider),
+		oauthEntry("SendGrid", p.SendGrid, integrations.NewSendGridProvider),
+		oauthEntry("Mailchimp", p.Mailchimp, integrations.NewMailchimpProvider),
+		oauthEntry("Twilio", p.Twilio, integrations.NewTwilioProvider),
+
+		// Project Management
+		oauthEntry("Jira", p.Jira, integrations.NewJiraProvider),
+		oauthEntry("Trello", p.Trello, integrations.NewTrelloProvider),
+		oauthEntry("Asana", p.Asana, integrations.NewAsanaProvider),
+		oauthEntry("Monday.com", p.Monday, integrations.NewMondayProvider),
+		oauthEntry("Notion", p.Notion, integrations.NewNotionProvider),
+		oauthEntry("ClickUp", p.ClickUp, integrations.NewClickUpProvider),
+
+		// CRM & Sales
+		oauthEntry("Salesforce", p.Salesforce, integrations.NewSalesforceProvider),
+		oauthEntry("HubSpot", p.HubSpot, integrations.NewHubSpotProvider),
+		oauthEntry("Zendesk", p.Zendesk, integrations.NewZendeskProvider),
+		oauthEntry("Intercom", p.Intercom, integrations.NewIntercomProvider),
+		oauthEntry("Pipedrive", p.Pipedrive, integrations.NewPipedriveProvider),
+
+		// Development & Code
+		oauthEntry("GitHub", p.GitHub, integrations.NewGitHubProvider),
+		oauthEntry("GitLab", p.GitLab, integrations.NewGitLabProvider),
+		oauthEntry("Bitbucket", p.Bitbucket, integrations.NewBitbucketProvider),
+
+		// Storage & Documents
+		oauthEntry("Dropbox", p.Dropbox, integrations.NewDropboxProvider),
+		oauthEntry("Google Drive", p.GoogleDrive, integrations.NewGoogleDriveProvider),
+		oauthEntry("OneDrive", p.OneDrive, integrations.NewOneDriveProvider),
+		oauthEntry("Box", p.Box, integrations.NewBoxProvider),
+
+		// Payment & E-commerce
+		oauthEntry("Stripe", p.Stripe, integrations.NewStripeProvider),
+		oauthEntry("Shopify", p.Shopify, integrations.NewShopifyProvider),
+		oauthEntry("PayPal", p.PayPal, integrations.NewPayPalProvider),
+		oauthEntry("Square", p.Square, integrations.NewSquareProvider),
+
+		// Data & Analytics
+		oauthEntry("Airtable", p.Airtable, integrations.NewAirtableProvider),
+		oauthEntry("Google Sheets", p.GoogleSheets, integrations.NewGoogleSheetsProvider),
+		oauthEntry("Tableau", p.Tableau, integrations.NewTableauProvider),
+		oauthEntry("Microsoft Excel", p.MicrosoftExcel, integrations.NewMicrosoftExcelProvider),
+
+		// Social Media
+		oauthEntry("Twitter", p.Twitter, integrations.NewTwitterProvider),
+		oauthEntry("LinkedIn", p.LinkedIn, integrations.NewLinkedInProvider),
+		oauthEntry("Facebook", p.Facebook, integrations.NewFacebookProvider),
+		oauthEntry("Instagram", p.Instagram, integrations.NewInstagramProvider),
+
+		// Automation
+		{
+			name:    "Webhook Forward",
+			enabled: p.WebhookForward.Enabled,
+			build: func() integrations.Provider {
+				return integrations.NewWebhookForwardProvider(
+					p.WebhookForward.TargetURL, p.WebhookForward.SigningSecret, p.WebhookForward.AllowPrivateNetworks)
+			},
+			credentials: map[string]string{"target_url": p.WebhookForward.TargetURL},
+		},
+	}
+}
+
+// mustRegister registers a provider and aborts startup if it is misconfigured.
+func mustRegister(p integrations.Provider) {
+	if err := integrations.RegisterProvider(p); err != nil {
+		log.Fatalf("Failed to register provider: %v", err)
+	}
+}
+
+// registerProviders registers all enabled integration providers
+func registerProviders(cfg *config.Config) {
+	for _, entry := range providerEntries(cfg) {
+		if !entry.enabled {
+			continue
+		}
+		mustRegister(entry.build())
+		log.Printf("✓ Registered %s provider", entry.name)
+	}
+
+	log.Printf("Total providers registered: %d", len(integrations.Providers))
+}
+
+// providerStatus reports whether an enabled provider has the configuration
+// it needs to serve requests.
+type providerStatus struct {
+	Ready   bool     `json:"ready"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// providerHealth returns the status of every enabled provider, keyed by
+// integration type.
+func providerHealth(cfg *config.Config) map[string]providerStatus {
+	statuses := make(map[string]providerStatus)
+	for _, entry := range providerEntries(cfg) {
+		if !entry.enabled {
+			continue
+		}
+		missing := entry.missingCredentials()
+		statuses[entry.build().Name()] = providerStatus{Ready: len(missing) == 0, Missing: missing}
+	}
+	return statuses
+}
+
+// providerHealthHandler serves GET /health/providers. It responds 503 when any
+// enabled provider is missing credentials, so a half-configured deploy fails
+// its readiness check instead of failing on first use.
+func providerHealthHandler(cfg *config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			middleware.HTTPError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		statuses := providerHealth(cfg)
+		status, code := "ok", http.StatusOK
+		for _, s := range statuses {
+			if !s.Ready {
+				status, code = "degraded", http.StatusServiceUnavailable
+				break
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":    status,
+			"providers": statuses,
+		})
+	}
+}
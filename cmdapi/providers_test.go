@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"neighbourhood/internal/config"
+	"neighbourhood/internal/integrations"
+)
+
+func resetRegistry() {
+	integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
+}
+
+func TestRegisterProviders_OnlyEnabled(t *testing.T) {
+	resetRegistry()
+	cfg := &config.Config{}
+	cfg.Providers.Slack = config.ProviderConfig{ClientID: "id", Enabled: true}
+	cfg.Providers.Jira = config.ProviderConfig{ClientID: "id", Enabled: true}
+
+	registerProviders(cfg)
+
+	if len(integrations.Providers) != 2 {
+		t.Fatalf("expected 2 registered providers, got %d", len(integrations.Providers))
+	}
+	for _, want := range []integrations.IntegrationType{integrations.IntegrationSlack, integrations.IntegrationJira} {
+		if _, err := integrations.GetProvider(want); err != nil {
+			t.Errorf("%s should be registered: %v", want, err)
+		}
+	}
+}
+
+func TestRegisterProviders_AllEnabled_RegistersEveryConfiguredProvider(t *testing.T) {
+	resetRegistry()
+	cfg := &config.Config{}
+	// Enable every provider in the config so a provider added to the config
+	// but missing from providerEntries fails this test.
+	providers := reflect.ValueOf(&cfg.Providers).Elem()
+	for i := 0; i < providers.NumField(); i++ {
+		providers.Field(i).FieldByName("Enabled").SetBool(true)
+	}
+
+	registerProviders(cfg)
+
+	if got, want := len(integrations.Providers), providers.NumField(); got != want {
+		t.Errorf("expected %d registered providers, got %d", want, got)
+	}
+}
+
+func TestProviderEntries_PassesCredentials(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.Slack = config.ProviderConfig{ClientID: "cid", ClientSecret: "sec", RedirectURL: "https://cb", Enabled: true}
+
+	for _, e := range providerEntries(cfg) {
+		if e.name != "Slack" {
+			continue
+		}
+		slack, ok := e.build().(*integrations.SlackProvider)
+		if !ok {
+			t.Fatalf("expected *SlackProvider, got %T", e.build())
+		}
+		if slack.ClientID != "cid" || slack.ClientSecret != "sec" || slack.RedirectURL != "https://cb" {
+			t.Errorf("credentials not passed through: %+v", slack)
+		}
+		return
+	}
+	t.Fatal("no Slack entry")
+}
+
+func TestProviderHealth_MissingClientID_NotReady(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.Slack = config.ProviderConfig{ClientID: "cid", ClientSecret: "sec", Enabled: true}
+	cfg.Providers.Jira = config.ProviderConfig{ClientSecret: "sec", Enabled: true}
+
+	rec := httptest.NewRecorder()
+	providerHealthHandler(cfg)(rec, httptest.NewRequest(http.MethodGet, "/health/providers", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+	var body struct {
+		Status    string                    `json:"status"`
+		Providers map[string]providerStatus `json:"providers"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Status != "degraded" || len(body.Providers) != 2 {
+		t.Fatalf("unexpected body %+v", body)
+	}
+	if !body.Providers["slack"].Ready {
+		t.Error("slack should be ready")
+	}
+	if jira := body.Providers["jira"]; jira.Ready || !reflect.DeepEqual(jira.Missing, []string{"client_id"}) {
+		t.Errorf("jira status = %+v, want not ready missing client_id", jira)
+	}
+}
+
+func TestProviderHealth_AllConfigured_OK(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Providers.Slack = config.ProviderConfig{ClientID: "cid", ClientSecret: "sec", Enabled: true}
+	cfg.Providers.Notion = config.ProviderConfig{ClientID: "cid", Enabled: false}
+
+	rec := httptest.NewRecorder()
+	providerHealthHandler(cfg)(rec, httptest.NewRequest(http.MethodGet, "/health/providers", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+)
+
+// readiness gates traffic on the database schema. It starts not ready and
+// becomes ready only once migrations have succeeded, so a load balancer
+// polling /health/ready never routes requests to a server whose queries
+// would run against a stale schema.
+type readiness struct {
+	ready atomic.Bool
+}
+
+// migrate runs the migrations and marks the server ready if they succeed.
+// After a failure the server stays not ready.
+func (rd *readiness) migrate(run func() error) error {
+	if err := run(); err != nil {
+		return err
+	}
+	rd.ready.Store(true)
+	return nil
+}
+
+// markReady marks the server ready without migrating, for OFFLINE mode where
+// there is no schema to wait for.
+func (rd *readiness) markReady() {
+	rd.ready.Store(true)
+}
+
+// handler serves GET /health/ready: 200 once ready, 503 until then.
+func (rd *readiness) handler(w http.ResponseWriter, r *http.Request) {
+	status, code := "ready", http.StatusOK
+	if !rd.ready.Load() {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"status": status})
+}
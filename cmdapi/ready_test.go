@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func readyStatus(rd *readiness) int {
+	rr := httptest.NewRecorder()
+	rd.handler(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	return rr.Code
+}
+
+func TestReadiness_FlipsAfterMigrations(t *testing.T) {
+	rd := &readiness{}
+	if got := readyStatus(rd); got != http.StatusServiceUnavailable {
+		t.Fatalf("before migrations: expected 503, got %d", got)
+	}
+
+	ran := false
+	if err := rd.migrate(func() error {
+		if got := readyStatus(rd); got != http.StatusServiceUnavailable {
+			t.Errorf("during migrations: expected 503, got %d", got)
+		}
+		ran = true
+		return nil
+	}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if !ran {
+		t.Fatal("migrations did not run")
+	}
+	if got := readyStatus(rd); got != http.StatusOK {
+		t.Errorf("after migrations: expected 200, got %d", got)
+	}
+}
+
+func TestReadiness_StaysUnavailableOnMigrationFailure(t *testing.T) {
+	rd := &readiness{}
+	boom := errors.New("relation already exists")
+	if err := rd.migrate(func() error { return boom }); !errors.Is(err, boom) {
+		t.Fatalf("expected the migration error, got %v", err)
+	}
+	if got := readyStatus(rd); got != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 after a failed migration, got %d", got)
+	}
+}
@@ -1,168 +1,1759 @@
 package api
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"neighbourhood/internal/audit"
+	"neighbourhood/internal/auth"
+	"neighbourhood/internal/config"
 	"neighbourhood/internal/consent"
 	"neighbourhood/internal/integrations"
+	"neighbourhood/internal/jobs"
 	"neighbourhood/internal/middleware"
+	"neighbourhood/internal/outbox"
+	"neighbourhood/internal/rbac"
 	"neighbourhood/internal/workflow"
 
 	"github.com/google/uuid"
 )
 
+// workflowRunKeyTTL is how long a successful workflow run can be replayed by
+// resubmitting its run key.
+const workflowRunKeyTTL = 24 * time.Hour
+
+// asyncRunTTL is how long a finished async workflow run can still be polled.
+const asyncRunTTL = 24 * time.Hour
+
+// maxBatchItems is the largest number of actions one batch request may run.
+const maxBatchItems = 50
+
+// batchParallelism is how many actions of a batch run at once.
+const batchParallelism = 8
+
 // maxRequestBodySize is the maximum number of bytes accepted from an HTTP
 // request body. Requests larger than this are rejected with 413.
 const maxRequestBodySize = 1 << 20 // 1 MiB
 
+// maxUploadBodySize is the body limit of requests to uploadActions, which
+// carry a file. It leaves room for base64 encoding.
+const maxUploadBodySize = 64 << 20 // 64 MiB
+
+// uploadActions are the "provider/action" pairs whose requests may exceed
+// maxRequestBodySize, up to maxUploadBodySize.
+var uploadActions = map[string]bool{
+	"onedrive/upload_file": true,
+}
+
 // Handler manages API routes and dependencies
 type Handler struct {
 	consentManager *consent.Manager
+	connections    integrations.ConnectionStore
+	history        integrations.ExecutionHistory
+	events         outbox.Publisher
+	engine         *workflow.WorkflowEngine
+	maxSteps       int
+	strictJSON     bool
+	runs           *workflow.RunCache
+	jobs           *jobs.Queue
+	asyncRuns      *workflow.AsyncRunner
+	workers        func(name string, fn func(ctx context.Context))
+	accounts       auth.AccountStore
+	roles          rbac.Store
+	plans          rbac.PlanStore
+	auditLog       auth.AuditLog   // impersonations included in the audit export
+	auditSigner    *audit.Signer   // nil disables the audit export
+	config         *config.Config  // nil disables the config dump
+	redirects      map[string]bool // allowlisted OAuth redirect overrides
+
+	// workflowGate caps each user's in-flight workflows at the limit
+	// workflowLimits gives for the acting workspace's plan. gatePolicy decides
+	// whether workflows run while the gate's backend is unreachable.
+	workflowGate   workflow.ConcurrencyGate
+	gatePolicy     middleware.FailurePolicy
+	workflowLimits config.WorkflowConfig
+
+	// jobTokens holds inline tokens for queued async actions, keyed by the
+	// job request's TokenRef, so a token is never written to the job store.
+	// A job whose token expired or was lost falls back to the stored
+	// connection.
+	jobTokens jobs.SecretStore
+
+	// pendingAuths binds each OAuth state handed out for connecting an
+	// integration to the user, workspace and redirect URL it was issued for,
+	// until IntegrationCallback consumes it.
+	pendingAuthsMu sync.Mutex
+	pendingAuths   map[string]pendingAuth
+}
+
+// integrationStateTTL is how long an integration OAuth state stays valid.
+const integrationStateTTL = 10 * time.Minute
+
+// pendingAuth is an integration authorization awaiting its callback.
+type pendingAuth struct {
+	provider    string
+	userID      string
+	workspaceID string
+	redirectURL string // empty uses the provider's configured redirect URL
+	expiresAt   time.Time
+}
+
+// NewHandler creates a new API handler
+func NewHandler() *Handler {
+	h := &Handler{
+		consentManager: consent.NewManager(),
+		connections:    integrations.NewMemoryConnectionStore(),
+		history:        integrations.NewMemoryExecutionHistory(),
+		events:         outbox.NewMemoryStore(),
+		engine:         workflow.NewWorkflowEngine(config.DefaultWorkflowConfig()),
+		maxSteps:       workflow.DefaultMaxSteps,
+		runs:           workflow.NewRunCache(workflowRunKeyTTL),
+		asyncRuns:      workflow.NewAsyncRunner(workflow.NewMemoryRunStore(asyncRunTTL)),
+		accounts:       auth.NewMemoryAccountStore(),
+		roles:          rbac.NewMemoryStore(),
+		plans:          rbac.NewMemoryPlanStore(),
+		workflowGate:   workflow.NewMemoryConcurrencyGate(),
+		gatePolicy:     middleware.FailOpen,
+		workflowLimits: config.DefaultWorkflowConfig(),
+		jobTokens:      jobs.NewMemorySecretStore(),
+		pendingAuths:   make(map[string]pendingAuth),
+	}
+	h.jobs = jobs.NewQueue(jobs.NewMemoryStore(), h.runJob)
+	return h
+}
+
+// SetConnectionStore replaces the store that provider tokens are kept in.
+func (h *Handler) SetConnectionStore(s integrations.ConnectionStore) {
+	h.connections = s
+}
+
+// SetWorkflowConfig replaces the engine that ExecuteWorkflow runs workflows
+// with, and adopts cfg.MaxSteps as the largest workflow accepted and cfg's
+// concurrent workflow limits.
+func (h *Handler) SetWorkflowConfig(cfg config.WorkflowConfig) {
+	h.engine = workflow.NewWorkflowEngine(cfg)
+	h.maxSteps = h.engine.MaxSteps
+	h.workflowLimits = cfg
+}
+
+// SetConcurrencyGate replaces the gate that limits each user's concurrent
+// workflows. policy decides whether workflows run when the gate fails.
+func (h *Handler) SetConcurrencyGate(g workflow.ConcurrencyGate, policy middleware.FailurePolicy) {
+	h.workflowGate, h.gatePolicy = g, policy
+}
+
+// SetPlanStore replaces the store workspace plans are read from.
+func (h *Handler) SetPlanStore(s rbac.PlanStore) {
+	h.plans = s
+}
+
+// SetMaxWorkflowSteps sets the largest workflow ExecuteWorkflow accepts.
+func (h *Handler) SetMaxWorkflowSteps(n int) {
+	h.maxSteps = n
+}
+
+// SetStrictJSON makes request decoding reject fields the endpoint does not
+// accept, so typos such as "provder" fail loudly instead of being ignored.
+func (h *Handler) SetStrictJSON(strict bool) {
+	h.strictJSON = strict
+}
+
+// SetRedirectAllowlist sets the OAuth redirect URLs a GetIntegrationAuthURL
+// request may ask for instead of the provider's configured one. Only exact
+// matches are allowed.
+func (h *Handler) SetRedirectAllowlist(urls []string) {
+	h.redirects = make(map[string]bool, len(urls))
+	for _, u := range urls {
+		h.redirects[u] = true
+	}
+}
+
+// SetRoleStore replaces the store workspace memberships are read from.
+func (h *Handler) SetRoleStore(s rbac.Store) {
+	h.roles = s
+}
+
+// SetEventPublisher replaces the outbox that execution events are written to.
+func (h *Handler) SetEventPublisher(p outbox.Publisher) {
+	h.events = p
+}
+
+// SetExecutionHistory replaces the store provider executions are recorded in.
+// A history that is also an eventRecorder writes each execution and its event
+// in one transaction.
+func (h *Handler) SetExecutionHistory(history integrations.ExecutionHistory) {
+	h.history = history
+}
+
+// eventRecorder is implemented by execution histories that can enqueue the
+// execution's outbox event atomically with the record itself.
+type eventRecorder interface {
+	RecordWithEvent(ctx context.Context, e integrations.Execution, topic string, payload interface{}) error
+}
+
+// SetJobStore replaces the store that async action jobs are persisted in.
+func (h *Handler) SetJobStore(s jobs.Store) {
+	h.jobs = jobs.NewQueue(s, h.runJob)
+}
+
+// SetJobTokenStore replaces the store that inline tokens of queued actions
+// are kept in until their job runs.
+func (h *Handler) SetJobTokenStore(s jobs.SecretStore) {
+	h.jobTokens = s
+}
+
+// SetWorkflowRunStore replaces the store that async workflow runs are
+// tracked in.
+func (h *Handler) SetWorkflowRunStore(s workflow.RunStore) {
+	h.asyncRuns = workflow.NewAsyncRunner(s)
+	if h.workers != nil {
+		h.asyncRuns.SetWorkers(h.workers)
+	}
+}
+
+// SetWorkers runs async workflows with spawn, such as lifecycle.Manager.Go,
+// so shutdown interrupts them and waits for their outcome to be saved.
+func (h *Handler) SetWorkers(spawn func(name string, fn func(ctx context.Context))) {
+	h.workers = spawn
+	h.asyncRuns.SetWorkers(spawn)
+}
+
+// SetAuditExport enables the signed audit export, checking callers'
+// permissions against accounts, including the impersonations in auditLog and
+// signing exports with signer.
+func (h *Handler) SetAuditExport(accounts auth.AccountStore, auditLog auth.AuditLog, signer *audit.Signer) {
+	h.accounts, h.auditLog, h.auditSigner = accounts, auditLog, signer
+}
+
+// SetAccountStore replaces the store admin endpoints check callers'
+// permissions against.
+func (h *Handler) SetAccountStore(accounts auth.AccountStore) {
+	h.accounts = accounts
+}
+
+// SetEffectiveConfig enables the admin config dump, which serves cfg
+// redacted.
+func (h *Handler) SetEffectiveConfig(cfg *config.Config) {
+	h.config = cfg
+}
+
+// RunJobs executes queued async actions until ctx is cancelled.
+func (h *Handler) RunJobs(ctx context.Context) {
+	h.jobs.Run(ctx, 5*time.Second)
+}
+
+// GetIntegrationAuthURL returns the OAuth URL for a provider and the state it
+// carries; a state is generated when the request has none. A request may set
+// redirect_url to an allowlisted URL to have the provider call back there
+// instead of its configured redirect URL. The state is bound to the caller,
+// workspace and redirect URL, which IntegrationCallback uses for the code
+// exchange.
+func (h *Handler) GetIntegrationAuthURL(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Provider    string `json:"provider"`
+		State       string `json:"state"`
+		RedirectURL string `json:"redirect_url"`
+	}
+
+	var req request
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	if req.Provider == "" {
+		respondError(w, "provider is required", http.StatusBadRequest)
+		return
+	}
+
+	provider, err := integrations.GetProvider(integrations.IntegrationType(req.Provider))
+	if err != nil {
+		respondError(w, "provider not found", http.StatusNotFound)
+		return
+	}
+
+	if req.RedirectURL != "" && !h.redirects[req.RedirectURL] {
+		respondError(w, "redirect_url is not allowlisted", http.StatusBadRequest)
+		return
+	}
+	if req.State == "" {
+		if req.State, err = newOAuthState(); err != nil {
+			middleware.Logf(r.Context(), "Failed to generate OAuth state: %v", err)
+			respondError(w, "failed to generate state", http.StatusInternalServerError)
+			return
+		}
+	}
+	authURL := provider.GetAuthURL(req.State)
+	if req.RedirectURL != "" {
+		if authURL, err = integrations.AuthURLWithRedirect(provider, req.State, req.RedirectURL); err != nil {
+			respondError(w, req.Provider+" does not support a redirect_url override", http.StatusBadRequest)
+			return
+		}
+	}
+	if !h.bindAuthState(req.State, pendingAuth{
+		provider:    req.Provider,
+		userID:      extractUserID(r).String(),
+		workspaceID: extractWorkspaceID(r),
+		redirectURL: req.RedirectURL,
+	}) {
+		respondError(w, "state is already in use", http.StatusConflict)
+		return
+	}
+	respondJSON(w, map[string]string{"url": authURL, "state": req.State}, http.StatusOK)
+}
+
+// bindAuthState records a pending authorization under state, evicting
+// expired ones. It returns false when state is already bound.
+func (h *Handler) bindAuthState(state string, pending pendingAuth) bool {
+	h.pendingAuthsMu.Lock()
+	defer h.pendingAuthsMu.Unlock()
+	now := time.Now()
+	for s, p := range h.pendingAuths {
+		if now.After(p.expiresAt) {
+			delete(h.pendingAuths, s)
+		}
+	}
+	if _, exists := h.pendingAuths[state]; exists {
+		return false
+	}
+	pending.expiresAt = now.Add(integrationStateTTL)
+	h.pendingAuths[state] = pending
+	return true
+}
+
+// consumeAuthState removes and returns the unexpired authorization bound to
+// state.
+func (h *Handler) consumeAuthState(state string) (pendingAuth, bool) {
+	h.pendingAuthsMu.Lock()
+	defer h.pendingAuthsMu.Unlock()
+	pending, ok := h.pendingAuths[state]
+	delete(h.pendingAuths, state) // consume once — replay protection
+	if !ok || time.Now().After(pending.expiresAt) {
+		return pendingAuth{}, false
+	}
+	return pending, true
+}
+
+// IntegrationCallback completes an integration's OAuth flow: the provider
+// redirects the browser here with code and state. The code is exchanged
+// using the redirect URL bound to the state and the token is stored as the
+// connection of the user and workspace that requested the auth URL. It is
+// served outside /api because the browser redirect carries no bearer token;
+// the single-use state stands in for it.
+func (h *Handler) IntegrationCallback(w http.ResponseWriter, r *http.Request) {
+	w, ok := readOnly(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		h.consumeAuthState(query.Get("state"))
+		respondError(w, "authorization was not granted: "+providerErr, http.StatusBadRequest)
+		return
+	}
+	pending, ok := h.consumeAuthState(query.Get("state"))
+	if !ok {
+		respondError(w, "invalid or expired state", http.StatusBadRequest)
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		respondError(w, "code is required", http.StatusBadRequest)
+		return
+	}
+	provider, err := integrations.GetProvider(integrations.IntegrationType(pending.provider))
+	if err != nil {
+		respondError(w, "provider not found", http.StatusNotFound)
+		return
+	}
+
+	ctx := r.Context()
+	if pending.redirectURL != "" {
+		ctx = integrations.WithRedirectURL(ctx, pending.redirectURL)
+	}
+	token, err := provider.ExchangeCode(ctx, code)
+	if err != nil {
+		middleware.Logf(r.Context(), "OAuth code exchange for %s failed: %v", pending.provider, err)
+		respondError(w, "could not complete authorization with "+pending.provider, http.StatusBadGateway)
+		return
+	}
+	err = h.connections.Save(r.Context(), &integrations.Connection{
+		UserID:      pending.userID,
+		WorkspaceID: pending.workspaceID,
+		Provider:    integrations.IntegrationType(pending.provider),
+		Token:       *token,
+	})
+	if err != nil {
+		middleware.Logf(r.Context(), "Failed to store %s connection: %v", pending.provider, err)
+		respondError(w, "failed to store connection", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{"provider": pending.provider, "connected": true}, http.StatusOK)
+}
+
+// ConnectToken stores a pasted API key for a provider that uses static keys
+// rather than OAuth, after verifying it with the provider.
+func (h *Handler) ConnectToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	type request struct {
+		Provider string `json:"provider"`
+		APIKey   string `json:"api_key"`
+	}
+
+	var req request
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	req.APIKey = strings.TrimSpace(req.APIKey)
+	if req.Provider == "" || req.APIKey == "" {
+		respondError(w, "provider and api_key are required", http.StatusBadRequest)
+		return
+	}
+
+	provider, err := integrations.GetProvider(integrations.IntegrationType(req.Provider))
+	if err != nil {
+		respondError(w, "provider not found", http.StatusNotFound)
+		return
+	}
+	tester, ok := provider.(integrations.ConnectionTester)
+	if !ok {
+		respondError(w, req.Provider+" does not support API key connections", http.StatusBadRequest)
+		return
+	}
+
+	token := integrations.Token{AccessToken: req.APIKey, TokenType: "api_key"}
+	identity, err := tester.TestConnection(providerContext(r), &token)
+	if err != nil {
+		if respondRateLimited(w, err) {
+			return
+		}
+		if errors.Is(err, integrations.ErrInvalidCredentials) {
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		middleware.Logf(r.Context(), "Connection test for %s failed: %v", req.Provider, err)
+		respondError(w, "could not verify key with "+req.Provider, http.StatusBadGateway)
+		return
+	}
+
+	userID := extractUserID(r)
+	err = h.connections.Save(r.Context(), &integrations.Connection{
+		UserID:      userID.String(),
+		WorkspaceID: extractWorkspaceID(r),
+		Provider:    integrations.IntegrationType(req.Provider),
+		Token:       token,
+	})
+	if err != nil {
+		middleware.Logf(r.Context(), "Failed to store %s connection: %v", req.Provider, err)
+		respondError(w, "failed to store connection", http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"provider":  req.Provider,
+		"connected": true,
+		"identity":  identity,
+	}, http.StatusOK)
+}
+
+// DisconnectIntegration deletes the caller's stored connection to a provider
+// in the acting workspace. Providers that support it also have the token
+// revoked first, unless the request sets "revoke": false; if revocation
+// fails the connection is kept so the client can retry.
+func (h *Handler) DisconnectIntegration(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Provider string `json:"provider"`
+		Revoke   *bool  `json:"revoke"`
+	}
+
+	var req request
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Provider == "" {
+		respondError(w, "provider is required", http.StatusBadRequest)
+		return
+	}
+
+	name := integrations.IntegrationType(req.Provider)
+	provider, err := integrations.GetProvider(name)
+	if err != nil {
+		respondError(w, "provider not found", http.StatusNotFound)
+		return
+	}
+
+	userID, workspaceID := extractUserID(r).String(), extractWorkspaceID(r)
+	conn, err := h.connections.Get(r.Context(), userID, workspaceID, name)
+	if errors.Is(err, integrations.ErrConnectionNotFound) {
+		respondError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		middleware.Logf(r.Context(), "Failed to load %s connection: %v", req.Provider, err)
+		respondError(w, "failed to load connection", http.StatusInternalServerError)
+		return
+	}
+
+	if revoker, ok := provider.(integrations.TokenRevoker); ok && (req.Revoke == nil || *req.Revoke) {
+		if err := revoker.RevokeToken(providerContext(r), &conn.Token); err != nil {
+			middleware.Logf(r.Context(), "Failed to revoke %s token: %v", req.Provider, err)
+			if respondRateLimited(w, err) {
+				return
+			}
+			respondError(w, "failed to revoke token with "+req.Provider, http.StatusBadGateway)
+			return
+		}
+	}
+
+	err = h.connections.Delete(r.Context(), userID, workspaceID, name)
+	if err != nil && !errors.Is(err, integrations.ErrConnectionNotFound) {
+		middleware.Logf(r.Context(), "Failed to delete %s connection: %v", req.Provider, err)
+		respondError(w, "failed to delete connection", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ExecuteIntegrationAction executes a single integration action. With
+// ?async=true the action is queued instead and the response carries a job ID
+// to poll at /api/jobs/{id}. A missing consent is 403; a failed action gets
+// the status executionStatus or respondRateLimited classifies it as.
+func (h *Handler) ExecuteIntegrationAction(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Provider string                 `json:"provider"`
+		Token    integrations.Token     `json:"token"`
+		Action   string                 `json:"action"`
+		Payload  map[string]interface{} `json:"payload"`
+	}
+
+	// The action is only known once the body is read, so any request may
+	// be as large as an upload; other actions are held to the usual limit.
+	body := &countingBody{ReadCloser: r.Body}
+	r.Body = body
+	var req request
+	if !h.decodeJSONLimit(w, r, &req, maxUploadBodySize) {
+		return
+	}
+	if body.n > maxRequestBodySize && !uploadActions[req.Provider+"/"+req.Action] {
+		respondError(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Validate required fields
+	if req.Provider == "" || req.Action == "" {
+		respondError(w, "provider and action are required", http.StatusBadRequest)
+		return
+	}
+
+	// Extract authenticated user ID from context (set by Auth middleware).
+	// Falls back to a sentinel UUID in dev/demo mode when auth is bypassed.
+	userID := extractUserID(r)
+	if err := h.consentManager.ValidateConsent(r.Context(), userID, req.Provider); err != nil {
+		h.respondConsentRequired(w, r, req.Provider, err)
+		return
+	}
+
+	provider, err := integrations.GetProvider(integrations.IntegrationType(req.Provider))
+	if err != nil {
+		respondError(w, "provider not found", http.StatusNotFound)
+		return
+	}
+
+	// The built-in help action only describes the provider, so it needs no token.
+	var token *integrations.Token
+	if req.Action != integrations.HelpAction {
+		token, err = h.resolveToken(r, userID, integrations.IntegrationType(req.Provider), &req.Token)
+		if err != nil {
+			respondError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	}
+
+	if async, _ := strconv.ParseBool(r.URL.Query().Get("async")); async {
+		h.enqueueAction(w, r, userID, asyncAction{Provider: req.Provider, Action: req.Action, Payload: req.Payload}, &req.Token)
+		return
+	}
+
+	ctx := providerContext(r)
+	if key := r.Header.Get(middleware.IdempotencyHeader); key != "" {
+		// Scoped like the middleware's keys, so users cannot collide.
+		ctx = integrations.WithIdempotencyKey(ctx, userID.String()+"/"+key)
+	}
+	start := time.Now()
+	result, err := integrations.ExecuteAction(ctx, provider, token, req.Action, req.Payload)
+	h.recordExecution(r.Context(), userID, extractWorkspaceID(r), "", req.Provider, req.Action, start, err)
+	if err != nil {
+		middleware.Logf(r.Context(), "Integration execution error: %v", err)
+		if respondRateLimited(w, err) {
+			return
+		}
+		if errors.Is(err, integrations.ErrUnknownAction) {
+			respondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		respondError(w, "execution failed: "+err.Error(), executionStatus(err))
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{"result": result}, http.StatusOK)
+}
+
+// batchItem is one action of an ExecuteIntegrationBatch request.
+type batchItem struct {
+	Provider string                 `json:"provider"`
+	Token    integrations.Token     `json:"token"`
+	Action   string                 `json:"action"`
+	Payload  map[string]interface{} `json:"payload"`
+}
+
+// batchResult is the outcome of the batch item at Index: Error is set when it
+// failed, and otherwise Result holds its result, which may be null.
+type batchResult struct {
+	Index  int         `json:"index"`
+	Result interface{} `json:"result"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// ExecuteIntegrationBatch runs up to maxBatchItems independent actions in one
+// round trip, batchParallelism at a time. Each item counts against the
+// caller's rate limit as a request of its own, and a batch larger than what
+// is left of the limit is rejected whole with 429. Each item is checked and
+// executed as ExecuteIntegrationAction would, but a failed item only sets the
+// error of its own result; the response is 200 with one result per item, in
+// order.
+func (h *Handler) ExecuteIntegrationBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var items []batchItem
+	if !h.decodeJSON(w, r, &items) {
+		return
+	}
+	if len(items) == 0 {
+		respondError(w, "batch must contain at least one action", http.StatusBadRequest)
+		return
+	}
+	if len(items) > maxBatchItems {
+		respondError(w, fmt.Sprintf("batch has %d actions; the limit is %d", len(items), maxBatchItems), http.StatusRequestEntityTooLarge)
+		return
+	}
+	// The request itself was already counted as one.
+	if ok, retryAfter := middleware.ChargeRateLimit(r, len(items)-1); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		respondError(w, fmt.Sprintf("batch of %d actions exceeds the remaining rate limit", len(items)), http.StatusTooManyRequests)
+		return
+	}
+
+	userID := extractUserID(r)
+	results := make([]batchResult, len(items))
+	workers := make(chan struct{}, batchParallelism)
+	var wg sync.WaitGroup
+	for i := range items {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+
+			results[i].Index = i
+			result, err := h.runBatchItem(r, userID, &items[i])
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Result = result
+		}(i)
+	}
+	wg.Wait()
+
+	respondJSON(w, map[string]interface{}{"results": results}, http.StatusOK)
+}
+
+// runBatchItem checks consent for item and executes it.
+func (h *Handler) runBatchItem(r *http.Request, userID uuid.UUID, item *batchItem) (interface{}, error) {
+	if item.Provider == "" || item.Action == "" {
+		return nil, errors.New("provider and action are required")
+	}
+	if err := h.consentManager.ValidateConsent(r.Context(), userID, item.Provider); err != nil {
+		return nil, fmt.Errorf("consent not granted: %w", err)
+	}
+	provider, err := integrations.GetProvider(integrations.IntegrationType(item.Provider))
+	if err != nil {
+		return nil, err
+	}
+
+	var token *integrations.Token
+	if item.Action != integrations.HelpAction {
+		token, err = h.resolveToken(r, userID, integrations.IntegrationType(item.Provider), &item.Token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	result, err := integrations.ExecuteAction(providerContext(r), provider, token, item.Action, item.Payload)
+	h.recordExecution(r.Context(), userID, extractWorkspaceID(r), "", item.Provider, item.Action, start, err)
+	if err != nil {
+		middleware.Logf(r.Context(), "Batch action %s.%s failed: %v", item.Provider, item.Action, err)
+		return nil, err
+	}
+	return result, nil
+}
+
+// asyncAction is the persisted request of an async action job.
+type asyncAction struct {
+	Provider string                 `json:"provider"`
+	Action   string                 `json:"action"`
+	Payload  map[string]interface{} `json:"payload"`
+	// TokenRef names the inline token held in Handler.jobTokens, if any.
+	TokenRef string `json:"token_ref,omitempty"`
+}
+
+// jobTokenTTL is how long a queued action's inline token is kept.
+const jobTokenTTL = time.Hour
+
+// enqueueAction queues req for the job workers and responds 202 with the job.
+func (h *Handler) enqueueAction(w http.ResponseWriter, r *http.Request, userID uuid.UUID, req asyncAction, inline *integrations.Token) {
+	if inline.AccessToken != "" {
+		req.TokenRef = uuid.NewString()
+		secret, err := json.Marshal(inline)
+		if err == nil {
+			err = h.jobTokens.Put(r.Context(), req.TokenRef, secret, jobTokenTTL)
+		}
+		if err != nil {
+			middleware.Logf(r.Context(), "Failed to store the token of %s.%s: %v", req.Provider, req.Action, err)
+			respondError(w, "failed to queue action", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	job, err := h.jobs.Enqueue(r.Context(), userID.String(), extractWorkspaceID(r), req)
+	if err != nil {
+		middleware.Logf(r.Context(), "Failed to enqueue %s.%s: %v", req.Provider, req.Action, err)
+		h.takeJobToken(r.Context(), req.TokenRef)
+		respondError(w, "failed to queue action", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", "/api/jobs/"+job.ID.String())
+	respondJSON(w, jobResponse(job), http.StatusAccepted)
+}
+
+// takeJobToken removes and returns the inline token stored under ref. It
+// returns nil when there is none, and an error when the store failed.
+func (h *Handler) takeJobToken(ctx context.Context, ref string) (*integrations.Token, error) {
+	if ref == "" {
+		return nil, nil
+	}
+	secret, err := h.jobTokens.Take(ctx, ref)
+	if errors.Is(err, jobs.ErrSecretNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load job token: %w", err)
+	}
+	var token integrations.Token
+	if err := json.Unmarshal(secret, &token); err != nil {
+		return nil, fmt.Errorf("decode job token: %w", err)
+	}
+	return &token, nil
+}
+
+// runJob executes a queued async action on behalf of the user who queued it.
+// Consent is checked again because it may have been revoked while queued.
+func (h *Handler) runJob(ctx context.Context, job jobs.Job) (interface{}, error) {
+	var req asyncAction
+	if err := json.Unmarshal(job.Request, &req); err != nil {
+		return nil, fmt.Errorf("decode job request: %w", err)
+	}
+	userID, err := uuid.Parse(job.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid job owner: %w", err)
+	}
+	inline, err := h.takeJobToken(ctx, req.TokenRef)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.consentManager.ValidateConsent(ctx, userID, req.Provider); err != nil {
+		return nil, fmt.Errorf("consent not granted: %w", err)
+	}
+	provider, err := integrations.GetProvider(integrations.IntegrationType(req.Provider))
+	if err != nil {
+		return nil, err
+	}
+
+	var token *integrations.Token
+	if req.Action != integrations.HelpAction {
+		if inline != nil {
+			token = inline
+		} else {
+			token, err = h.storedToken(ctx, userID, job.WorkspaceID, integrations.IntegrationType(req.Provider))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	start := time.Now()
+	ctx = integrations.WithIdempotencyKey(integrations.WithWorkspaceID(integrations.WithUserID(ctx, userID.String()), job.WorkspaceID), "job/"+job.ID.String())
+	result, err := integrations.ExecuteAction(ctx, provider, token, req.Action, req.Payload)
+	h.recordExecution(ctx, userID, job.WorkspaceID, "", req.Provider, req.Action, start, err)
+	return result, err
+}
+
+// GetJob reports the status of an async action and, once it has finished,
+// its result or error. Jobs are only visible to the user and workspace that
+// queued them.
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	w, ok := readOnly(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := uuid.Parse(strings.TrimPrefix(r.URL.Path, "/api/jobs/"))
+	if err != nil {
+		respondError(w, "job not found", http.StatusNotFound)
+		return
+	}
+	job, err := h.jobs.Get(r.Context(), id)
+	if err != nil && !errors.Is(err, jobs.ErrNotFound) {
+		middleware.Logf(r.Context(), "Failed to load job %s: %v", id, err)
+		respondError(w, "failed to load job", http.StatusInternalServerError)
+		return
+	}
+	if err != nil || job.UserID != extractUserID(r).String() || job.WorkspaceID != extractWorkspaceID(r) {
+		respondError(w, "job not found", http.StatusNotFound)
+		return
+	}
+
+	respondJSON(w, jobResponse(job), http.StatusOK)
+}
+
+// WorkspacePermissions answers GET /api/workspace/{id}/permissions with the
+// caller's effective permissions in the workspace: their role's defaults,
+// those it inherits and any custom grants. Workspace admins may pass user_id
+// to inspect another member.
+func (h *Handler) WorkspacePermissions(w http.ResponseWriter, r *http.Request) {
+	w, ok := readOnly(w, r)
+	if !ok {
+		return
+	}
+
+	workspaceID, found := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/workspace/"), "/permissions")
+	if !found || workspaceID == "" || strings.Contains(workspaceID, "/") {
+		respondError(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	callerID := extractUserID(r).String()
+	caller, err := h.roles.Membership(r.Context(), callerID, workspaceID)
+	if err != nil && !errors.Is(err, rbac.ErrNotMember) {
+		middleware.Logf(r.Context(), "Failed to load role of %s in workspace %s: %v", callerID, workspaceID, err)
+		respondError(w, "failed to load permissions", http.StatusInternalServerError)
+		return
+	}
+	if caller == nil {
+		respondError(w, "workspace not found", http.StatusNotFound)
+		return
+	}
+
+	member := caller
+	if userID := r.URL.Query().Get("user_id"); userID != "" && userID != callerID {
+		if caller.Role != rbac.RoleAdmin {
+			respondError(w, "only workspace admins can view another member's permissions", http.StatusForbidden)
+			return
+		}
+		member, err = h.roles.Membership(r.Context(), userID, workspaceID)
+		if errors.Is(err, rbac.ErrNotMember) {
+			respondError(w, "user is not a member of the workspace", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			middleware.Logf(r.Context(), "Failed to load role of %s in workspace %s: %v", userID, workspaceID, err)
+			respondError(w, "failed to load permissions", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"workspace_id": workspaceID,
+		"user_id":      member.UserID,
+		"role":         member.Role,
+		"permissions":  member.Effective(),
+	}, http.StatusOK)
+}
+
+// jobResponse is the API representation of job.
+func jobResponse(job *jobs.Job) map[string]interface{} {
+	resp := map[string]interface{}{
+		"job_id":     job.ID.String(),
+		"status":     job.Status,
+		"created_at": job.CreatedAt.UTC(),
+		"updated_at": job.UpdatedAt.UTC(),
+	}
+	if job.Result != nil {
+		resp["result"] = job.Result
+	}
+	if job.Error != "" {
+		resp["error"] = job.Error
+	}
+	return resp
+}
+
+// recordExecution appends an action invocation to the execution history.
+// runID is the workflow run that made the call, or empty for direct calls.
+// Failures to record are logged and never fail the request.
+func (h *Handler) recordExecution(ctx context.Context, userID uuid.UUID, workspaceID, runID, provider, action string, start time.Time, execErr error) {
+	status := integrations.ExecutionSucceeded
+	if execErr != nil {
+		status = integrations.ExecutionFailed
+	}
+	execution := integrations.Execution{
+		UserID:      userID.String(),
+		WorkspaceID: workspaceID,
+		Provider:    integrations.IntegrationType(provider),
+		Action:      action,
+		Status:      status,
+		Duration:    time.Since(start),
+		Timestamp:   start,
+
+		WorkflowRunID: runID,
+		ActorID:       middleware.ActorIDFromContext(ctx),
+	}
+	event := map[string]interface{}{
+		"user_id":      userID.String(),
+		"workspace_id": workspaceID,
+		"provider":     provider,
+		"action":       action,
+		"status":       status,
+		"timestamp":    start.UTC(),
+	}
+	if runID != "" {
+		event["workflow_run_id"] = runID
+	}
+	if execution.ActorID != "" {
+		event["actor_id"] = execution.ActorID
+	}
+
+	if recorder, ok := h.history.(eventRecorder); ok {
+		if err := recorder.RecordWithEvent(ctx, execution, executionTopic, event); err != nil {
+			middleware.Logf(ctx, "Failed to record execution history: %v", err)
+		}
+		return
+	}
+	if err := h.history.Record(ctx, execution); err != nil {
+		middleware.Logf(ctx, "Failed to record execution history: %v", err)
+	}
+	if err := h.events.Publish(ctx, executionTopic, event); err != nil {
+		middleware.Logf(ctx, "Failed to publish execution event: %v", err)
+	}
+}
+
+// executionTopic is the outbox topic of the event recorded per execution.
+const executionTopic = "integration.executed"
+
+// historyCSVHeader is the column order of the execution history export.
+var historyCSVHeader = []string{"timestamp", "provider", "action", "status", "duration_ms"}
+
+// ExportHistoryCSV streams the caller's execution history for the acting
+// workspace as CSV. The optional from and to query parameters bound the
+// range and accept RFC 3339 timestamps or YYYY-MM-DD dates; a date-only to
+// includes the whole day.
+func (h *Handler) ExportHistoryCSV(w http.ResponseWriter, r *http.Request) {
+	w, ok := readOnly(w, r)
+	if !ok {
+		return
+	}
+
+	from, err := parseHistoryTime(r.URL.Query().Get("from"), false)
+	if err != nil {
+		respondError(w, "invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseHistoryTime(r.URL.Query().Get("to"), true)
+	if err != nil {
+		respondError(w, "invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Rows are written as the history yields them and flushed every
+	// historyCSVFlushRows, so the export is never buffered whole. The 200 is
+	// sent with the first row, leaving a failure to read any row a 500.
+	cw := csv.NewWriter(w)
+	rc := http.NewResponseController(w)
+	rows := 0
+	start := func() error {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="history.csv"`)
+		w.WriteHeader(http.StatusOK)
+		return cw.Write(historyCSVHeader)
+	}
+	err = h.history.Each(r.Context(), extractUserID(r).String(), extractWorkspaceID(r), from, to, func(e integrations.Execution) error {
+		if rows == 0 {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		rows++
+		row := []string{
+			e.Timestamp.UTC().Format(time.RFC3339),
+			string(e.Provider),
+			e.Action,
+			e.Status,
+			strconv.FormatInt(e.Duration.Milliseconds(), 10),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		if rows%historyCSVFlushRows == 0 {
+			return flushCSV(cw, rc)
+		}
+		return nil
+	})
+	if err != nil {
+		if rows == 0 {
+			middleware.Logf(r.Context(), "Failed to list execution history: %v", err)
+			respondError(w, "failed to load history", http.StatusInternalServerError)
+			return
+		}
+		// Too late to change the status; the client gets a truncated file.
+		middleware.Logf(r.Context(), "Error streaming CSV history after %d rows: %v", rows, err)
+		return
+	}
+	if rows == 0 {
+		if err := start(); err != nil {
+			middleware.Logf(r.Context(), "Error writing CSV header: %v", err)
+			return
+		}
+	}
+	if err := flushCSV(cw, rc); err != nil {
+		middleware.Logf(r.Context(), "Error flushing CSV: %v", err)
+	}
+}
+
+// historyCSVFlushRows is how many rows ExportHistoryCSV writes between
+// flushes to the client.
+const historyCSVFlushRows = 100
+
+// flushCSV flushes cw's buffer and then the response itself. A writer that
+// cannot flush, such as a HEAD response, is left to send on return.
+func flushCSV(cw *csv.Writer, rc *http.ResponseController) error {
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// parseHistoryTime parses an RFC 3339 timestamp or a YYYY-MM-DD date. An empty
+// value yields the zero time (an open range). When endOfRange is set, a
+// date-only value is advanced to the start of the following day.
+func parseHistoryTime(value string, endOfRange bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC 3339 timestamp or YYYY-MM-DD date, got %q", value)
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// Audit export signature headers. The signature is base64; auditors fetch
+// the public key with that ID from AuditKeys rather than trusting the export.
+const (
+	AuditSignatureHeader = "X-Audit-Signature"
+	AuditAlgorithmHeader = "X-Audit-Signature-Algorithm"
+	AuditKeyIDHeader     = "X-Audit-Key-Id"
+)
+
+// ExportAudit returns the acting workspace's audit log as a canonical JSON
+// array with a detached signature in the X-Audit-Signature header: every
+// user's action executions, and the impersonations of the workspace's
+// members. The caller needs the audit:export permission and must belong to
+// the workspace, unless they are a platform admin. from and to bound the
+// range as for ExportHistoryCSV.
+func (h *Handler) ExportAudit(w http.ResponseWriter, r *http.Request) {
+	w, ok := readOnly(w, r)
+	if !ok {
+		return
+	}
+	if h.auditSigner == nil {
+		respondError(w, "audit export is not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	account, err := h.accounts.Account(r.Context(), extractUserID(r).String())
+	if err != nil && !errors.Is(err, auth.ErrUserNotFound) {
+		middleware.Logf(r.Context(), "Failed to load account for audit export: %v", err)
+		respondError(w, "failed to check permissions", http.StatusInternalServerError)
+		return
+	}
+	if account == nil || !account.Can(auth.PermissionAuditExport) {
+		respondError(w, "audit export requires the "+auth.PermissionAuditExport+" permission", http.StatusForbidden)
+		return
+	}
+	workspaceID := extractWorkspaceID(r)
+	if workspaceID == "" {
+		respondError(w, "audit export requires a workspace ("+middleware.WorkspaceHeader+" header)", http.StatusBadRequest)
+		return
+	}
+	if account.Role != auth.RoleAdmin {
+		member, err := h.roles.Membership(r.Context(), account.ID, workspaceID)
+		if err != nil && !errors.Is(err, rbac.ErrNotMember) {
+			middleware.Logf(r.Context(), "Failed to load role of %s in workspace %s: %v", account.ID, workspaceID, err)
+			respondError(w, "failed to check permissions", http.StatusInternalServerError)
+			return
+		}
+		if member == nil {
+			respondError(w, "audit export requires membership of the workspace", http.StatusForbidden)
+			return
+		}
+	}
+
+	from, err := parseHistoryTime(r.URL.Query().Get("from"), false)
+	if err != nil {
+		respondError(w, "invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseHistoryTime(r.URL.Query().Get("to"), true)
+	if err != nil {
+		respondError(w, "invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	executions, err := h.history.ListWorkspace(r.Context(), workspaceID, from, to)
+	if err != nil {
+		middleware.Logf(r.Context(), "Failed to list audit log: %v", err)
+		respondError(w, "failed to load audit log", http.StatusInternalServerError)
+		return
+	}
+	entries := make([]audit.Entry, len(executions))
+	for i, e := range executions {
+		entries[i] = audit.Entry{
+			Timestamp:     e.Timestamp,
+			UserID:        e.UserID,
+			WorkspaceID:   e.WorkspaceID,
+			Provider:      string(e.Provider),
+			Action:        e.Action,
+			Status:        e.Status,
+			DurationMS:    e.Duration.Milliseconds(),
+			WorkflowRunID: e.WorkflowRunID,
+			ActorID:       e.ActorID,
+		}
+	}
+	impersonations, err := h.workspaceImpersonations(r.Context(), workspaceID, from, to)
+	if err != nil {
+		middleware.Logf(r.Context(), "Failed to list impersonations: %v", err)
+		respondError(w, "failed to load audit log", http.StatusInternalServerError)
+		return
+	}
+	entries = append(entries, impersonations...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+
+	body, sig, err := h.auditSigner.Export(entries)
+	if err != nil {
+		middleware.Logf(r.Context(), "Failed to sign audit export: %v", err)
+		respondError(w, "failed to export audit log", http.StatusInternalServerError)
+		return
+	}
+	middleware.Logf(r.Context(), "Audit export of workspace %s (%d entries) by %s", workspaceID, len(entries), account.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.json"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set(AuditSignatureHeader, base64.StdEncoding.EncodeToString(sig))
+	w.Header().Set(AuditAlgorithmHeader, audit.Algorithm)
+	w.Header().Set(AuditKeyIDHeader, h.auditSigner.KeyID())
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// workspaceImpersonations returns audit entries for the impersonations in
+// [from, to) whose target is a member of workspaceID.
+func (h *Handler) workspaceImpersonations(ctx context.Context, workspaceID string, from, to time.Time) ([]audit.Entry, error) {
+	if h.auditLog == nil {
+		return nil, nil
+	}
+	records, err := h.auditLog.Impersonations(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	var entries []audit.Entry
+	for _, rec := range records {
+		member, err := h.roles.Membership(ctx, rec.TargetID, workspaceID)
+		if err != nil && !errors.Is(err, rbac.ErrNotMember) {
+			return nil, err
+		}
+		if member == nil {
+			continue
+		}
+		entries = append(entries, audit.Entry{
+			Timestamp:   rec.IssuedAt,
+			UserID:      rec.TargetID,
+			WorkspaceID: workspaceID,
+			Action:      "impersonate",
+			Status:      "issued",
+			DurationMS:  rec.ExpiresAt.Sub(rec.IssuedAt).Milliseconds(),
+			ActorID:     rec.ActorID,
+			Reason:      rec.Reason,
+		})
+	}
+	return entries, nil
 }
 
-// NewHandler creates a new API handler
-func NewHandler() *Handler {
-	return &Handler{
-		consentManager: consent.NewManager(),
+// AuditKeys publishes the audit export verification key as a JSON Web Key
+// Set, so auditors can pin it independently of any export.
+func (h *Handler) AuditKeys(w http.ResponseWriter, r *http.Request) {
+	w, ok := readOnly(w, r)
+	if !ok {
+		return
 	}
+	if h.auditSigner == nil {
+		respondError(w, "audit export is not configured", http.StatusServiceUnavailable)
+		return
+	}
+	respondJSON(w, map[string]interface{}{"keys": []map[string]string{h.auditSigner.JWK()}}, http.StatusOK)
 }
 
-// GetIntegrationAuthURL returns the OAuth URL for a provider
-func (h *Handler) GetIntegrationAuthURL(w http.ResponseWriter, r *http.Request) {
-	type request struct {
-		Provider string `json:"provider"`
-		State    string `json:"state"`
+// EffectiveConfig answers GET /api/admin/config with the running
+// configuration, secrets masked, and the providers actually registered; an
+// enabled provider missing credentials is not. Only admins holding
+// auth.PermissionConfigRead may read it.
+func (h *Handler) EffectiveConfig(w http.ResponseWriter, r *http.Request) {
+	w, ok := readOnly(w, r)
+	if !ok {
+		return
 	}
-
-	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
-	var req request
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "invalid request body", http.StatusBadRequest)
+	if h.config == nil {
+		respondError(w, "config dump is not configured", http.StatusServiceUnavailable)
 		return
 	}
 
-	if req.Provider == "" {
-		respondError(w, "provider is required", http.StatusBadRequest)
+	account, err := h.accounts.Account(r.Context(), extractUserID(r).String())
+	if err != nil && !errors.Is(err, auth.ErrUserNotFound) {
+		middleware.Logf(r.Context(), "Failed to load account for config dump: %v", err)
+		respondError(w, "failed to check permissions", http.StatusInternalServerError)
 		return
 	}
-
-	provider, err := integrations.GetProvider(integrations.IntegrationType(req.Provider))
-	if err != nil {
-		respondError(w, "provider not found", http.StatusNotFound)
+	if account == nil || account.Role != auth.RoleAdmin || !account.Can(auth.PermissionConfigRead) {
+		respondError(w, "the config dump requires the "+auth.PermissionConfigRead+" permission", http.StatusForbidden)
 		return
 	}
 
-	url := provider.GetAuthURL(req.State)
-	respondJSON(w, map[string]string{"url": url}, http.StatusOK)
+	registered := make([]string, 0, len(integrations.Providers))
+	for name := range integrations.Providers {
+		registered = append(registered, string(name))
+	}
+	sort.Strings(registered)
+
+	w.Header().Set("Cache-Control", "no-store")
+	respondJSON(w, map[string]interface{}{
+		"config":               h.config.Redacted(),
+		"registered_providers": registered,
+	}, http.StatusOK)
 }
 
-// ExecuteIntegrationAction executes a single integration action
-func (h *Handler) ExecuteIntegrationAction(w http.ResponseWriter, r *http.Request) {
+// ExecuteWorkflow executes a multi-step workflow. When a step fails after
+// others have succeeded the response is 207 with the results so far, null
+// for steps that did not finish, the error and the index of the failed step.
+func (h *Handler) ExecuteWorkflow(w http.ResponseWriter, r *http.Request) {
 	type request struct {
-		Provider string                 `json:"provider"`
-		Token    integrations.Token     `json:"token"`
-		Action   string                 `json:"action"`
-		Payload  map[string]interface{} `json:"payload"`
+		Workflow workflow.Workflow             `json:"workflow"`
+		Tokens   map[string]integrations.Token `json:"tokens"`
+		// RunKey optionally identifies the run; resubmitting the same key
+		// returns the original results instead of executing again.
+		RunKey string `json:"run_key"`
+		// Params, when set, are substituted into the workflow's
+		// "{{ params.<name> }}" placeholders as PreviewWorkflow does.
+		Params map[string]interface{} `json:"params"`
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
 	var req request
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "invalid request body", http.StatusBadRequest)
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	var ok bool
+	if req.Workflow, ok = resolveParams(w, req.Workflow, req.Params); !ok {
 		return
 	}
 
-	// Validate required fields
-	if req.Provider == "" || req.Action == "" {
-		respondError(w, "provider and action are required", http.StatusBadRequest)
+	// Validate workflow
+	if err := workflow.Validate(req.Workflow, h.maxSteps); err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Extract authenticated user ID from context (set by Auth middleware).
 	// Falls back to a sentinel UUID in dev/demo mode when auth is bypassed.
 	userID := extractUserID(r)
-	if err := h.consentManager.ValidateConsent(r.Context(), userID, req.Provider); err != nil {
-		respondError(w, "consent not granted: "+err.Error(), http.StatusForbidden)
+	tokens, ok := h.workflowTokens(w, r, userID, req.Workflow, req.Tokens)
+	if !ok {
 		return
 	}
 
-	provider, err := integrations.GetProvider(integrations.IntegrationType(req.Provider))
-	if err != nil {
-		respondError(w, "provider not found", http.StatusNotFound)
+	release, ok := h.acquireWorkflowSlot(w, r, userID)
+	if !ok {
 		return
 	}
+	defer release()
+
+	runID := uuid.NewString()
+	// Copy the shared engine so OnStep is per request while the copies share
+	// its parallelism limit.
+	engine := *h.engine
+	engine.MaxSteps = h.maxSteps
+	engine.OnStep = func(_ context.Context, step workflow.WorkflowStep, start time.Time, err error) {
+		h.recordExecution(r.Context(), userID, extractWorkspaceID(r), runID, string(step.Provider), step.Action, start, err)
+	}
+	// Keys are scoped to the caller so one user cannot replay another's run.
+	runKey := userID.String() + "|" + extractWorkspaceID(r) + "|" + req.RunKey
+	idempotencyKey := "run/" + runID
+	if req.RunKey != "" {
+		idempotencyKey = "run/" + runKey
+	}
+	run := func() ([]interface{}, error) {
+		return engine.Execute(integrations.WithIdempotencyKey(providerContext(r), idempotencyKey), req.Workflow, tokens)
+	}
 
-	result, err := provider.Execute(r.Context(), &req.Token, req.Action, req.Payload)
+	var results []interface{}
+	var replayed bool
+	var err error
+	if req.RunKey != "" {
+		results, replayed, err = h.runs.Do(runKey, run)
+	} else {
+		results, err = run()
+	}
+	resp := map[string]interface{}{"results": results}
 	if err != nil {
-		log.Printf("Integration execution error: %v", err)
-		respondError(w, "execution failed: "+err.Error(), http.StatusInternalServerError)
+		middleware.Logf(r.Context(), "Workflow execution error: %v", err)
+		// Once a step has succeeded the client must learn what already
+		// happened, so the partial results are returned with the failure.
+		var stepErr *workflow.StepError
+		if errors.As(err, &stepErr) && slices.ContainsFunc(results, func(res interface{}) bool { return res != nil }) {
+			resp["run_id"] = runID
+			resp["error"] = "workflow execution failed: " + err.Error()
+			resp["failed_step"] = stepErr.Step
+			if req.RunKey != "" {
+				resp["run_key"], resp["replayed"] = req.RunKey, false
+			}
+			respondJSON(w, resp, http.StatusMultiStatus)
+			return
+		}
+		if respondRateLimited(w, err) {
+			return
+		}
+		respondError(w, "workflow execution failed: "+err.Error(), executionStatus(err))
 		return
 	}
 
-	respondJSON(w, map[string]interface{}{"result": result}, http.StatusOK)
+	if !replayed {
+		resp["run_id"] = runID
+	}
+	if req.RunKey != "" {
+		resp["run_key"] = req.RunKey
+		resp["replayed"] = replayed
+	}
+	respondJSON(w, resp, http.StatusOK)
 }
 
-// ExecuteWorkflow executes a multi-step workflow
-func (h *Handler) ExecuteWorkflow(w http.ResponseWriter, r *http.Request) {
+// acquireWorkflowSlot takes one of userID's concurrent workflow slots, limited
+// by the plan of the acting workspace, and returns the func that frees it.
+// When the user is at their limit it responds 429. On failure the response
+// has been written.
+func (h *Handler) acquireWorkflowSlot(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (func(), bool) {
+	plan, err := h.plans.Plan(r.Context(), extractWorkspaceID(r))
+	if err != nil {
+		middleware.Logf(r.Context(), "Failed to load workspace plan, using the default workflow limit: %v", err)
+	}
+	limit := h.workflowLimits.MaxConcurrentFor(plan)
+
+	release, err := h.workflowGate.Acquire(r.Context(), userID.String(), limit)
+	switch {
+	case errors.Is(err, workflow.ErrTooManyConcurrent):
+		respondError(w, fmt.Sprintf("too many concurrent workflows: the limit is %d", limit), http.StatusTooManyRequests)
+		return nil, false
+	case err != nil && h.gatePolicy == middleware.FailClosed:
+		middleware.Logf(r.Context(), "Workflow concurrency gate unavailable: %v", err)
+		respondError(w, "service temporarily unavailable", http.StatusServiceUnavailable)
+		return nil, false
+	case err != nil:
+		middleware.Logf(r.Context(), "Workflow concurrency gate unavailable, running without a limit: %v", err)
+		return func() {}, true
+	}
+	return release, true
+}
+
+// workflowTokens checks the user has consented to every provider wf uses and
+// returns the tokens to run it with: those supplied inline, filled in from
+// the acting workspace's stored connections. Providers with no connection are
+// left for the engine to reject. On failure the response has been written.
+func (h *Handler) workflowTokens(w http.ResponseWriter, r *http.Request, userID uuid.UUID, wf workflow.Workflow, inline map[string]integrations.Token) (map[integrations.IntegrationType]*integrations.Token, bool) {
+	for _, step := range wf.Steps {
+		if step.Type == workflow.StepTypeTransform {
+			continue
+		}
+		if err := h.consentManager.ValidateConsent(r.Context(), userID, string(step.Provider)); err != nil {
+			h.respondConsentRequired(w, r, string(step.Provider), err)
+			return nil, false
+		}
+	}
+
+	// Convert tokens to correct type
+	tokens := make(map[integrations.IntegrationType]*integrations.Token)
+	for k, v := range inline {
+		token := v
+		tokens[integrations.IntegrationType(k)] = &token
+	}
+
+	for _, step := range wf.Steps {
+		if step.Type == workflow.StepTypeTransform {
+			continue
+		}
+		if _, ok := tokens[step.Provider]; ok {
+			continue
+		}
+		token, err := h.resolveToken(r, userID, step.Provider, nil)
+		if err == nil {
+			tokens[step.Provider] = token
+		} else if !errors.Is(err, integrations.ErrConnectionNotFound) {
+			respondError(w, err.Error(), http.StatusInternalServerError)
+			return nil, false
+		}
+	}
+	return tokens, true
+}
+
+// ExecuteWorkflowAsync starts a workflow in the background and responds 202
+// with a job ID at once, for workflows too long to hold a request open.
+// Progress and results are polled at /api/workflow/status?job_id=... and a
+// run is stopped with POST /api/workflow/cancel?job_id=....
+func (h *Handler) ExecuteWorkflowAsync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
 	type request struct {
 		Workflow workflow.Workflow             `json:"workflow"`
 		Tokens   map[string]integrations.Token `json:"tokens"`
+		Params   map[string]interface{}        `json:"params"`
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
 	var req request
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, "invalid request body", http.StatusBadRequest)
+	if !h.decodeJSON(w, r, &req) {
 		return
 	}
-
-	// Validate workflow
-	if len(req.Workflow.Steps) == 0 {
-		respondError(w, "workflow must have at least one step", http.StatusBadRequest)
+	var ok bool
+	if req.Workflow, ok = resolveParams(w, req.Workflow, req.Params); !ok {
+		return
+	}
+	if err := workflow.Validate(req.Workflow, h.maxSteps); err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Extract authenticated user ID from context (set by Auth middleware).
-	// Falls back to a sentinel UUID in dev/demo mode when auth is bypassed.
 	userID := extractUserID(r)
-	for _, step := range req.Workflow.Steps {
-		if err := h.consentManager.ValidateConsent(r.Context(), userID, string(step.Provider)); err != nil {
-			respondError(w, "consent not granted for "+string(step.Provider)+": "+err.Error(), http.StatusForbidden)
-			return
-		}
+	tokens, ok := h.workflowTokens(w, r, userID, req.Workflow, req.Tokens)
+	if !ok {
+		return
 	}
 
-	// Convert tokens to correct type
-	tokens := make(map[integrations.IntegrationType]*integrations.Token)
-	for k, v := range req.Tokens {
-		token := v
-		tokens[integrations.IntegrationType(k)] = &token
+	release, ok := h.acquireWorkflowSlot(w, r, userID)
+	if !ok {
+		return
+	}
+
+	workspaceID := extractWorkspaceID(r)
+	runID := uuid.NewString()
+	// The run outlives the request, so history is recorded without the
+	// request's cancellation.
+	recordCtx := context.WithoutCancel(r.Context())
+	engine := *h.engine
+	engine.MaxSteps = h.maxSteps
+	engine.OnStep = func(_ context.Context, step workflow.WorkflowStep, start time.Time, err error) {
+		h.recordExecution(recordCtx, userID, workspaceID, runID, string(step.Provider), step.Action, start, err)
+	}
+	// The slot is held until the background run ends.
+	engine.OnDone = release
+
+	ctx := integrations.WithIdempotencyKey(providerContext(r), "run/"+runID)
+	run, err := h.asyncRuns.Start(ctx, &engine, req.Workflow, tokens, userID.String(), workspaceID)
+	if err != nil {
+		release()
+		middleware.Logf(r.Context(), "Failed to start workflow run: %v", err)
+		respondError(w, "failed to start workflow", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", "/api/workflow/status?job_id="+run.ID)
+	respondJSON(w, run, http.StatusAccepted)
+}
+
+// WorkflowStatus reports the progress of an async workflow run: its status,
+// how many steps have finished and their results so far, and once it has
+// failed, the error. Runs are only visible to the user and workspace that
+// started them.
+func (h *Handler) WorkflowStatus(w http.ResponseWriter, r *http.Request) {
+	w, ok := readOnly(w, r)
+	if !ok {
+		return
+	}
+	run, ok := h.ownedRun(w, r)
+	if !ok {
+		return
+	}
+	respondJSON(w, run, http.StatusOK)
+}
+
+// CancelWorkflow stops an async workflow run. Steps in flight are interrupted
+// and no further steps start; the run's status becomes "cancelled" shortly
+// after. Cancelling a finished run is a 409.
+func (h *Handler) CancelWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	run, ok := h.ownedRun(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.asyncRuns.Cancel(r.Context(), run.ID)
+	switch {
+	case errors.Is(err, workflow.ErrRunFinished):
+		respondError(w, "workflow run already finished", http.StatusConflict)
+		return
+	case errors.Is(err, workflow.ErrRunNotFound):
+		respondError(w, "workflow run not found", http.StatusNotFound)
+		return
+	case err != nil:
+		middleware.Logf(r.Context(), "Failed to cancel workflow run %s: %v", run.ID, err)
+		respondError(w, "failed to cancel workflow", http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, map[string]interface{}{"job_id": run.ID, "cancelled": true}, http.StatusAccepted)
+}
+
+// ownedRun loads the async run named by the job_id query parameter,
+// responding 404 when it does not exist or belongs to another user or
+// workspace.
+func (h *Handler) ownedRun(w http.ResponseWriter, r *http.Request) (*workflow.AsyncRun, bool) {
+	id := r.URL.Query().Get("job_id")
+	if id == "" {
+		respondError(w, "job_id is required", http.StatusBadRequest)
+		return nil, false
+	}
+	run, err := h.asyncRuns.Get(r.Context(), id)
+	if err != nil && !errors.Is(err, workflow.ErrRunNotFound) {
+		middleware.Logf(r.Context(), "Failed to load workflow run %s: %v", id, err)
+		respondError(w, "failed to load workflow run", http.StatusInternalServerError)
+		return nil, false
+	}
+	if err != nil || run.UserID != extractUserID(r).String() || run.WorkspaceID != extractWorkspaceID(r) {
+		respondError(w, "workflow run not found", http.StatusNotFound)
+		return nil, false
+	}
+	return run, true
+}
+
+// TestWorkflow runs a workflow in a sandbox where every provider step is
+// answered from the supplied fixtures, keyed "provider.action", so a workflow
+// definition can be tested in CI without tokens or provider calls. Nothing is
+// executed against a provider, so no consent is required and no history is
+// recorded. A step with no fixture fails the run with 422.
+func (h *Handler) TestWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	type request struct {
+		Workflow workflow.Workflow `json:"workflow"`
+		Fixtures workflow.Fixtures `json:"fixtures"`
+	}
+
+	var req request
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+	if err := workflow.Validate(req.Workflow, h.maxSteps); err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	engine := workflow.NewWorkflowEngine()
-	results, err := engine.Execute(r.Context(), req.Workflow, tokens)
+	engine := workflow.NewSandboxEngine(req.Fixtures)
+	engine.MaxSteps = h.maxSteps
+	results, err := engine.Execute(r.Context(), req.Workflow, nil)
 	if err != nil {
-		log.Printf("Workflow execution error: %v", err)
-		respondError(w, "workflow execution failed: "+err.Error(), http.StatusInternalServerError)
+		respondJSON(w, map[string]interface{}{
+			"error":   "workflow test failed: " + err.Error(),
+			"results": results,
+		}, http.StatusUnprocessableEntity)
 		return
 	}
 
 	respondJSON(w, map[string]interface{}{"results": results}, http.StatusOK)
 }
 
+// PreviewWorkflow substitutes params into a workflow template and returns
+// the workflow as it would be executed, without running it. Step references
+// stay in place since they resolve only at run time. Missing params and step
+// references that can never resolve are listed under "unresolved" with 422.
+func (h *Handler) PreviewWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	type request struct {
+		Template workflow.Workflow      `json:"template"`
+		Params   map[string]interface{} `json:"params"`
+	}
+
+	var req request
+	if !h.decodeJSON(w, r, &req) {
+		return
+	}
+
+	resolved, err := workflow.Preview(req.Template, req.Params)
+	if respondUnresolved(w, err) {
+		return
+	}
+	if err := workflow.Validate(resolved, h.maxSteps); err != nil {
+		respondError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{"workflow": resolved}, http.StatusOK)
+}
+
+// resolveParams substitutes params into wf before it is executed. Without
+// params wf is returned unchanged; an unresolved reference is answered as
+// PreviewWorkflow answers it, and false is returned.
+func resolveParams(w http.ResponseWriter, wf workflow.Workflow, params map[string]interface{}) (workflow.Workflow, bool) {
+	if params == nil {
+		return wf, true
+	}
+	resolved, err := workflow.Preview(wf, params)
+	if respondUnresolved(w, err) {
+		return wf, false
+	}
+	return resolved, true
+}
+
+// respondUnresolved writes a 422 listing the references err reports as
+// unresolved, returning false if err is not an *workflow.UnresolvedError.
+func respondUnresolved(w http.ResponseWriter, err error) bool {
+	var unresolved *workflow.UnresolvedError
+	if !errors.As(err, &unresolved) {
+		return false
+	}
+	respondJSON(w, map[string]interface{}{
+		"error":      err.Error(),
+		"unresolved": unresolved.Refs,
+	}, http.StatusUnprocessableEntity)
+	return true
+}
+
+// ListConsents returns, for each provider the user has a consent record for,
+// the granted scopes, grant and expiry times, and the further scopes that
+// could still be requested.
+func (h *Handler) ListConsents(w http.ResponseWriter, r *http.Request) {
+	w, ok := readOnly(w, r)
+	if !ok {
+		return
+	}
+
+	consents, err := h.consentManager.List(r.Context(), extractUserID(r))
+	if err != nil {
+		middleware.Logf(r.Context(), "Failed to list consents: %v", err)
+		respondError(w, "failed to load consents", http.StatusInternalServerError)
+		return
+	}
+
+	out := make([]map[string]interface{}, 0, len(consents))
+	for _, c := range consents {
+		granted := c.Scopes
+		if granted == nil {
+			granted = []string{}
+		}
+		out = append(out, map[string]interface{}{
+			"provider":           c.Provider,
+			"status":             c.Status,
+			"purpose":            c.Purpose,
+			"granted_scopes":     granted,
+			"granted_at":         c.GrantedAt,
+			"expires_at":         c.ExpiresAt,
+			"requestable_scopes": consent.RequestableScopes(c.Provider, c.Scopes),
+		})
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"consents": out,
+		"total":    len(out),
+	}, http.StatusOK)
+}
+
 // ListIntegrations returns all available integrations, sorted by type for
 // deterministic output regardless of map iteration order.
 func (h *Handler) ListIntegrations(w http.ResponseWriter, r *http.Request) {
+	w, ok := readOnly(w, r)
+	if !ok {
+		return
+	}
+
+	// ?category matches the category exactly, ignoring case; ?search matches
+	// a substring of the type or name. Both must hold when both are given.
+	categoryFilter := strings.TrimSpace(r.URL.Query().Get("category"))
+	search := strings.TrimSpace(r.URL.Query().Get("search"))
+	needle := strings.ToLower(search)
+
 	integrationsList := make([]map[string]interface{}, 0, len(integrations.Providers))
 
 	for providerType := range integrations.Providers {
 		category, description := getProviderInfo(string(providerType))
+		name := formatProviderName(string(providerType))
+		if categoryFilter != "" && !strings.EqualFold(category, categoryFilter) {
+			continue
+		}
+		if needle != "" && !strings.Contains(strings.ToLower(string(providerType)), needle) &&
+			!strings.Contains(strings.ToLower(name), needle) {
+			continue
+		}
 		integrationsList = append(integrationsList, map[string]interface{}{
 			"type":        string(providerType),
-			"name":        formatProviderName(string(providerType)),
+			"name":        name,
 			"description": description,
 			"category":    category,
 		})
@@ -174,12 +1765,50 @@ func (h *Handler) ListIntegrations(w http.ResponseWriter, r *http.Request) {
 		return iType < jType
 	})
 
+	filters := map[string]string{}
+	if categoryFilter != "" {
+		filters["category"] = categoryFilter
+	}
+	if search != "" {
+		filters["search"] = search
+	}
 	respondJSON(w, map[string]interface{}{
 		"integrations": integrationsList,
+		"filters":      filters,
 		"total":        len(integrationsList),
 	}, http.StatusOK)
 }
 
+// ListIntegrationActions describes the actions a provider supports and the
+// payload fields each accepts, so clients can build forms for them.
+// Providers that do not describe their actions return an empty list.
+func (h *Handler) ListIntegrationActions(w http.ResponseWriter, r *http.Request) {
+	w, ok := readOnly(w, r)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSpace(r.URL.Query().Get("provider"))
+	if name == "" {
+		respondError(w, "provider is required", http.StatusBadRequest)
+		return
+	}
+	provider, err := integrations.GetProvider(integrations.IntegrationType(name))
+	if err != nil {
+		respondError(w, "provider not found", http.StatusNotFound)
+		return
+	}
+
+	actions := integrations.ActionsOf(provider)
+	if actions == nil {
+		actions = []integrations.ActionSpec{}
+	}
+	respondJSON(w, map[string]interface{}{
+		"provider": provider.Name(),
+		"actions":  actions,
+	}, http.StatusOK)
+}
+
 // formatProviderName formats provider type to display name
 func formatProviderName(providerType string) string {
 	names := map[string]string{
@@ -195,6 +1824,7 @@ func formatProviderName(providerType string) string {
 		"airtable": "Airtable", "google_sheets": "Google Sheets", "tableau": "Tableau",
 		"microsoft_excel": "Microsoft Excel",
 		"twitter":         "Twitter", "linkedin": "LinkedIn", "facebook": "Facebook", "instagram": "Instagram",
+		"webhook_forward": "Webhook Forward",
 	}
 	if name, ok := names[providerType]; ok {
 		return name
@@ -263,6 +1893,9 @@ func getProviderInfo(providerType string) (string, string) {
 		"linkedin":  {"Social Media", "Professional networking"},
 		"facebook":  {"Social Media", "Social networking platform"},
 		"instagram": {"Social Media", "Photo and video sharing"},
+
+		// Automation
+		"webhook_forward": {"Automation", "Forward JSON payloads to any HTTP endpoint"},
 	}
 
 	if data, ok := info[providerType]; ok {
@@ -285,16 +1918,222 @@ func extractUserID(r *http.Request) uuid.UUID {
 	return uuid.MustParse("00000000-0000-0000-0000-000000000001")
 }
 
+// extractWorkspaceID reads the acting workspace from the request context,
+// populated by middleware.Workspace. An empty string is the user's default
+// workspace.
+func extractWorkspaceID(r *http.Request) string {
+	workspaceID, _ := r.Context().Value(middleware.ContextKeyWorkspaceID).(string)
+	return workspaceID
+}
+
+// resolveToken returns the token to execute with. A token supplied inline in
+// the request is used as-is; otherwise the user's stored connection for the
+// acting workspace is looked up, so a token connected in one workspace is
+// never used while acting in another.
+func (h *Handler) resolveToken(r *http.Request, userID uuid.UUID, provider integrations.IntegrationType, inline *integrations.Token) (*integrations.Token, error) {
+	if inline != nil && inline.AccessToken != "" {
+		return inline, nil
+	}
+	return h.storedToken(r.Context(), userID, extractWorkspaceID(r), provider)
+}
+
+// storedToken returns the user's connected token for provider in workspaceID.
+func (h *Handler) storedToken(ctx context.Context, userID uuid.UUID, workspaceID string, provider integrations.IntegrationType) (*integrations.Token, error) {
+	conn, err := h.connections.Get(ctx, userID.String(), workspaceID, provider)
+	if err != nil {
+		return nil, err
+	}
+	return &conn.Token, nil
+}
+
+// DebugProviderLogHeader opts a single request in to verbose, redacted
+// logging of the provider calls it makes.
+const DebugProviderLogHeader = "X-Debug-Provider-Log"
+
+// providerContext returns the context to run provider calls with, enabling
+// verbose provider logging when the request carries DebugProviderLogHeader.
+func providerContext(r *http.Request) context.Context {
+	ctx := integrations.WithUserID(r.Context(), extractUserID(r).String())
+	ctx = integrations.WithWorkspaceID(ctx, extractWorkspaceID(r))
+	if on, _ := strconv.ParseBool(r.Header.Get(DebugProviderLogHeader)); on {
+		return integrations.WithVerboseLogging(ctx)
+	}
+	return ctx
+}
+
 // Helper functions
 
 func respondJSON(w http.ResponseWriter, data interface{}, status int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("Error encoding JSON response: %v", err)
+		ctx := context.WithValue(context.Background(), middleware.ContextKeyRequestID, w.Header().Get(middleware.RequestIDHeader))
+		middleware.Logf(ctx, "Error encoding JSON response: %v", err)
+	}
+}
+
+// readMethods is the Allow value for read-only endpoints.
+const readMethods = "GET, HEAD, OPTIONS"
+
+// headWriter drops the body of a HEAD response while keeping its headers.
+type headWriter struct{ http.ResponseWriter }
+
+func (headWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (w headWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// readOnly applies method handling shared by read-only endpoints: OPTIONS is
+// answered with Allow, other non-GET/HEAD methods get 405, and for HEAD the
+// returned writer discards the body. It reports whether the handler should
+// go on to write its response.
+func readOnly(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, bool) {
+	switch r.Method {
+	case http.MethodGet:
+		return w, true
+	case http.MethodHead:
+		return headWriter{w}, true
+	case http.MethodOptions:
+		w.Header().Set("Allow", readMethods)
+		w.WriteHeader(http.StatusNoContent)
+		return w, false
+	default:
+		w.Header().Set("Allow", readMethods)
+		respondError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return w, false
 	}
 }
 
+// decodeJSON decodes the size-limited request body into v, writing a 400 and
+// returning false on failure. In strict mode the error names any unknown field.
+func (h *Handler) decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	return h.decodeJSONLimit(w, r, v, maxRequestBodySize)
+}
+
+// decodeJSONLimit is decodeJSON with a body limit of limit bytes; a larger
+// body is a 413.
+func (h *Handler) decodeJSONLimit(w http.ResponseWriter, r *http.Request, v interface{}, limit int64) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	dec := json.NewDecoder(r.Body)
+	if h.strictJSON {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		// encoding/json has no typed error for unknown fields.
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			respondError(w, "unknown field "+field, http.StatusBadRequest)
+			return false
+		}
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondError(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return false
+		}
+		respondError(w, "invalid request body", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// countingBody counts the bytes read from a request body.
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+// respondError writes a JSON error body. When middleware.RequestID has set a
+// request ID on the response, the body carries it as "request_id" so a
+// reported error can be matched with the request's log lines.
 func respondError(w http.ResponseWriter, message string, status int) {
-	respondJSON(w, map[string]string{"error": message}, status)
+	body := map[string]string{"error": message}
+	if id := w.Header().Get(middleware.RequestIDHeader); id != "" {
+		body["request_id"] = id
+	}
+	respondJSON(w, body, status)
+}
+
+// respondConsentRequired writes the 403 for a missing consent. When the
+// provider is registered the body carries an auth URL, with a fresh state
+// bound as by GetIntegrationAuthURL, and the scopes to request, so the client
+// can prompt the user to connect at once.
+func (h *Handler) respondConsentRequired(w http.ResponseWriter, r *http.Request, provider string, err error) {
+	body := map[string]interface{}{
+		"error":    "consent not granted: " + err.Error(),
+		"provider": provider,
+	}
+	if id := middleware.RequestIDFromContext(r.Context()); id != "" {
+		body["request_id"] = id
+	}
+	if p, perr := integrations.GetProvider(integrations.IntegrationType(provider)); perr == nil {
+		state, serr := newOAuthState()
+		if serr != nil {
+			middleware.Logf(r.Context(), "Failed to generate OAuth state: %v", serr)
+		} else {
+			h.bindAuthState(state, pendingAuth{provider: provider, userID: extractUserID(r).String(), workspaceID: extractWorkspaceID(r)})
+			body["auth_url"] = p.GetAuthURL(state)
+			body["state"] = state
+			body["required_scopes"] = consent.ProviderScopes(provider)
+		}
+	}
+	respondJSON(w, body, http.StatusForbidden)
+}
+
+// newOAuthState returns a random, URL-safe OAuth state value.
+func newOAuthState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// executionStatus returns the HTTP status for a failed action. Requests
+// rejected before the provider was called are 400, or 401 without a token.
+// Failed provider calls use the typed error the provider's response was
+// mapped to: a provider 5xx is 502, or 503 when the provider itself answered
+// 503. Unrecognised failures are 500.
+func executionStatus(err error) int {
+	var upstream *integrations.UpstreamError
+	if errors.As(err, &upstream) && errors.Is(upstream.Kind, integrations.ErrProviderUnavailable) {
+		if upstream.Status == http.StatusServiceUnavailable {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusBadGateway
+	}
+	switch {
+	case errors.Is(err, integrations.ErrMissingToken), errors.Is(err, integrations.ErrInvalidCredentials):
+		return http.StatusUnauthorized
+	case errors.Is(err, integrations.ErrMissingField), errors.Is(err, integrations.ErrInvalidField),
+		errors.Is(err, integrations.ErrUnknownAction):
+		return http.StatusBadRequest
+	case errors.Is(err, integrations.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, integrations.ErrValidation):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// respondRateLimited writes a 429 with a Retry-After header when err wraps an
+// integrations.ErrRateLimited, so clients back off as the provider asked. It
+// reports whether a response was written.
+func respondRateLimited(w http.ResponseWriter, err error) bool {
+	var rl *integrations.ErrRateLimited
+	if !errors.As(err, &rl) {
+		return false
+	}
+	// Round up so clients never retry before the provider's window reopens.
+	secs := int((rl.RetryAfter + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	respondError(w, err.Error(), http.StatusTooManyRequests)
+	return true
 }
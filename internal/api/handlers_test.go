@@ -1,15 +1,30 @@
-﻿package api
+package api
 
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"neighbourhood/internal/audit"
+	"neighbourhood/internal/auth"
+	"neighbourhood/internal/config"
 	"neighbourhood/internal/integrations"
+	"neighbourhood/internal/middleware"
+	"neighbourhood/internal/outbox"
+	"neighbourhood/internal/rbac"
 )
 
 type fakeProvider struct{ name string }
@@ -71,6 +86,95 @@ func TestListIntegrations_ContainsRegisteredProvider(t *testing.T) {
 	}
 }
 
+// listIntegrations calls ListIntegrations with query and returns the listed
+// types, in order, and the decoded response.
+func listIntegrations(t *testing.T, h *Handler, query string) ([]string, map[string]interface{}) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	h.ListIntegrations(rr, httptest.NewRequest(http.MethodGet, "/integrations?"+query, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var out struct {
+		Integrations []struct {
+			Type string `json:"type"`
+		} `json:"integrations"`
+	}
+	var raw map[string]interface{}
+	body := rr.Body.Bytes()
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatalf("not valid JSON: %v", err)
+	}
+	json.Unmarshal(body, &raw)
+	types := []string{}
+	for _, i := range out.Integrations {
+		types = append(types, i.Type)
+	}
+	return types, raw
+}
+
+func regFilterFixtures() {
+	for _, name := range []integrations.IntegrationType{"slack", "discord", "github", "gitlab", "jira", "stripe"} {
+		reg(name)
+	}
+}
+
+func TestListIntegrations_FilterByCategory(t *testing.T) {
+	h := newHandler()
+	regFilterFixtures()
+	types, raw := listIntegrations(t, h, "category=communication")
+	if fmt.Sprint(types) != "[discord slack]" {
+		t.Errorf("types = %v, want [discord slack]", types)
+	}
+	if raw["total"] != float64(2) {
+		t.Errorf("total = %v, want 2", raw["total"])
+	}
+	if f, _ := raw["filters"].(map[string]interface{}); f["category"] != "communication" || f["search"] != nil {
+		t.Errorf("filters = %v", raw["filters"])
+	}
+}
+
+func TestListIntegrations_FilterBySearch(t *testing.T) {
+	h := newHandler()
+	regFilterFixtures()
+	// "Git" matches the github and gitlab types and the GitHub/GitLab names.
+	types, raw := listIntegrations(t, h, "search=Git")
+	if fmt.Sprint(types) != "[github gitlab]" {
+		t.Errorf("types = %v, want [github gitlab]", types)
+	}
+	if raw["total"] != float64(2) {
+		t.Errorf("total = %v, want 2", raw["total"])
+	}
+}
+
+func TestListIntegrations_FilterByCategoryAndSearch(t *testing.T) {
+	h := newHandler()
+	regFilterFixtures()
+	types, raw := listIntegrations(t, h, "category=Communication&search=SLA")
+	if fmt.Sprint(types) != "[slack]" {
+		t.Errorf("types = %v, want [slack]", types)
+	}
+	f, _ := raw["filters"].(map[string]interface{})
+	if f["category"] != "Communication" || f["search"] != "SLA" {
+		t.Errorf("filters = %v", raw["filters"])
+	}
+}
+
+func TestListIntegrations_FilterNoMatch(t *testing.T) {
+	h := newHandler()
+	regFilterFixtures()
+	types, raw := listIntegrations(t, h, "category=Payment&search=git")
+	if len(types) != 0 {
+		t.Errorf("types = %v, want none", types)
+	}
+	if list, ok := raw["integrations"].([]interface{}); !ok || len(list) != 0 {
+		t.Errorf("integrations = %v, want an empty list", raw["integrations"])
+	}
+	if raw["total"] != float64(0) {
+		t.Errorf("total = %v, want 0", raw["total"])
+	}
+}
+
 func TestGetIntegrationAuthURL_ValidProvider(t *testing.T) {
 	h := newHandler()
 	reg("slack")
@@ -125,6 +229,86 @@ func TestGetIntegrationAuthURL_InvalidJSON_Returns400(t *testing.T) {
 	}
 }
 
+func TestGetIntegrationAuthURL_RedirectOverride(t *testing.T) {
+	h := newHandler()
+	h.SetRedirectAllowlist([]string{"https://eu.example.com/oauth/callback"})
+	integrations.Providers["slack"] = integrations.NewSlackProvider("id", "secret", "https://app.example.com/oauth/callback")
+
+	authURL := func(redirect string) *httptest.ResponseRecorder {
+		body := `{"provider":"slack","state":"s","redirect_url":"` + redirect + `"}`
+		rr := httptest.NewRecorder()
+		h.GetIntegrationAuthURL(rr, httptest.NewRequest(http.MethodPost, "/integrations/auth-url", bytes.NewBufferString(body)))
+		return rr
+	}
+
+	rr := authURL("https://eu.example.com/oauth/callback")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for an allowlisted redirect, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var resp map[string]string
+	json.NewDecoder(rr.Body).Decode(&resp)
+	u, err := url.Parse(resp["url"])
+	if err != nil {
+		t.Fatalf("bad url %q: %v", resp["url"], err)
+	}
+	if got := u.Query().Get("redirect_uri"); got != "https://eu.example.com/oauth/callback" {
+		t.Errorf("redirect_uri = %q, want the override", got)
+	}
+	if u.Query().Get("state") != "s" || u.Query().Get("client_id") != "id" {
+		t.Errorf("other parameters were lost: %s", u)
+	}
+
+	for _, redirect := range []string{"https://evil.example.net/steal", "https://eu.example.com/oauth/callback/../x"} {
+		if rr := authURL(redirect); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", redirect, rr.Code)
+		}
+	}
+}
+
+func TestIntegrationCallback_ExchangesWithBoundRedirect(t *testing.T) {
+	var redirectURI string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		redirectURI = r.PostForm.Get("redirect_uri")
+		w.Write([]byte(`{"access_token":"gho_new","token_type":"bearer"}`))
+	}))
+	defer srv.Close()
+	h := newHandler()
+	h.SetRedirectAllowlist([]string{"https://eu.example.com/cb"})
+	integrations.Providers["github"] = &integrations.GitHubProvider{ClientID: "cid", ClientSecret: "secret", RedirectURL: "https://app.example.com/cb", TokenURL: srv.URL}
+
+	req := withWorkspace(httptest.NewRequest(http.MethodPost, "/api/integration/authurl", bytes.NewBufferString(`{"provider":"github","redirect_url":"https://eu.example.com/cb"}`)), "ws-a")
+	rr := httptest.NewRecorder()
+	h.GetIntegrationAuthURL(rr, req)
+	var issued map[string]string
+	json.NewDecoder(rr.Body).Decode(&issued)
+	if rr.Code != http.StatusOK || issued["state"] == "" {
+		t.Fatalf("auth url: %d %v", rr.Code, issued)
+	}
+
+	callback := func(state string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.IntegrationCallback(rr, httptest.NewRequest(http.MethodGet, "/integrations/callback?code=abc&state="+url.QueryEscape(state), nil))
+		return rr
+	}
+	if rr := callback(issued["state"]); rr.Code != http.StatusOK {
+		t.Fatalf("callback: expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if redirectURI != "https://eu.example.com/cb" {
+		t.Errorf("exchange sent redirect_uri %q, want the one bound to the state", redirectURI)
+	}
+	conn, err := h.connections.Get(context.Background(), extractUserID(req).String(), "ws-a", "github")
+	if err != nil || conn.Token.AccessToken != "gho_new" {
+		t.Errorf("connection = %+v, %v; want the exchanged token in ws-a", conn, err)
+	}
+
+	for _, state := range []string{issued["state"], "forged"} {
+		if rr := callback(state); rr.Code != http.StatusBadRequest {
+			t.Errorf("state %q: expected 400, got %d", state, rr.Code)
+		}
+	}
+}
+
 func TestExecuteIntegrationAction_InvalidJSON_Returns400(t *testing.T) {
 	h := newHandler()
 	req := httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString("{bad"))
@@ -178,6 +362,134 @@ func TestExecuteIntegrationAction_ValidRequest_Returns200(t *testing.T) {
 	}
 }
 
+func TestExecuteIntegrationAction_RecordsImpersonatingActor(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	body := "{\"provider\":\"slack\",\"action\":\"send_message\",\"token\":{\"access_token\":\"xoxb\"},\"payload\":{}}"
+	req := httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString(body))
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ContextKeyActorID, "admin-1"))
+	h.ExecuteIntegrationAction(httptest.NewRecorder(), req)
+
+	entries, _ := h.history.List(context.Background(), extractUserID(req).String(), "", time.Time{}, time.Time{})
+	if len(entries) != 1 || entries[0].ActorID != "admin-1" {
+		t.Errorf("history = %+v, want the execution attributed to admin-1", entries)
+	}
+}
+
+// txHistory records executions together with their events, as the SQL
+// history does in one transaction.
+type txHistory struct {
+	*integrations.MemoryExecutionHistory
+	topics []string
+}
+
+func (h *txHistory) RecordWithEvent(ctx context.Context, e integrations.Execution, topic string, _ interface{}) error {
+	h.topics = append(h.topics, topic)
+	return h.Record(ctx, e)
+}
+
+func TestExecuteIntegrationAction_RecordsEventWithExecution(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	history := &txHistory{MemoryExecutionHistory: integrations.NewMemoryExecutionHistory()}
+	h.SetExecutionHistory(history)
+	events := outbox.NewMemoryStore()
+	h.SetEventPublisher(events)
+
+	body := "{\"provider\":\"slack\",\"action\":\"send_message\",\"token\":{\"access_token\":\"xoxb\"},\"payload\":{}}"
+	req := httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString(body))
+	h.ExecuteIntegrationAction(httptest.NewRecorder(), req)
+
+	if len(history.topics) != 1 || history.topics[0] != "integration.executed" {
+		t.Errorf("events recorded with the execution = %v", history.topics)
+	}
+	if events.Pending() != 0 {
+		t.Errorf("event also published outside the transaction")
+	}
+}
+
+func TestExecuteIntegrationAction_LargeOneDriveUploadUsesSession(t *testing.T) {
+	h := newHandler()
+	var sessions, chunks int
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/createUploadSession"):
+			sessions++
+			fmt.Fprintf(w, `{"uploadUrl":%q}`, srv.URL+"/upload")
+		case r.URL.Path == "/upload":
+			chunks++
+			io.Copy(io.Discard, r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"item-1","name":"big.bin"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+	integrations.Providers["onedrive"] = &integrations.OneDriveProvider{APIBaseURL: srv.URL}
+	reg("slack")
+
+	// 5 MiB of content, over both the simple upload limit and the usual
+	// request body limit once base64 encoded.
+	content := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xAB}, 5<<20))
+	body := `{"provider":"onedrive","action":"upload_file","token":{"access_token":"t"},"payload":{"file_name":"big.bin","content_base64":"` + content + `"}}`
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, httptest.NewRequest(http.MethodPost, "/integrations/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%.200s", rr.Code, rr.Body.String())
+	}
+	if sessions != 1 || chunks == 0 {
+		t.Errorf("%d upload sessions and %d chunks, want the file sent through a session", sessions, chunks)
+	}
+
+	// Other actions keep the usual limit.
+	body = `{"provider":"slack","action":"send_message","token":{"access_token":"xoxb"},"payload":{"text":"` + content + `"}}`
+	rr = httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, httptest.NewRequest(http.MethodPost, "/integrations/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large non-upload request: expected 413, got %d", rr.Code)
+	}
+}
+
+func TestListIntegrations_Head_HeadersOnly(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	rr := httptest.NewRecorder()
+	h.ListIntegrations(rr, httptest.NewRequest(http.MethodHead, "/api/integrations", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if rr.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("expected JSON content type, got %q", rr.Header().Get("Content-Type"))
+	}
+	if rr.Body.Len() != 0 {
+		t.Errorf("expected empty body for HEAD, got %q", rr.Body.String())
+	}
+}
+
+func TestReadEndpoints_OptionsAndDisallowedMethods(t *testing.T) {
+	h := newHandler()
+	endpoints := map[string]http.HandlerFunc{
+		"/api/integrations":            h.ListIntegrations,
+		"/api/integration/history.csv": h.ExportHistoryCSV,
+		"/api/consent":                 h.ListConsents,
+	}
+	for path, handler := range endpoints {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodOptions, path, nil))
+		if rr.Code != http.StatusNoContent || rr.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+			t.Errorf("OPTIONS %s: got %d Allow=%q", path, rr.Code, rr.Header().Get("Allow"))
+		}
+
+		rr = httptest.NewRecorder()
+		handler(rr, httptest.NewRequest(http.MethodPost, path, nil))
+		if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Allow") == "" {
+			t.Errorf("POST %s: expected 405 with Allow, got %d Allow=%q", path, rr.Code, rr.Header().Get("Allow"))
+		}
+	}
+}
+
 func TestExecuteWorkflow_InvalidJSON_Returns400(t *testing.T) {
 	h := newHandler()
 	req := httptest.NewRequest(http.MethodPost, "/workflows/execute", bytes.NewBufferString("{bad"))
@@ -197,6 +509,139 @@ func TestExecuteWorkflow_EmptySteps_Returns400(t *testing.T) {
 		t.Errorf("expected 400, got %d", rr.Code)
 	}
 }
+func TestExecuteWorkflow_TooManySteps_Returns400(t *testing.T) {
+	h := newHandler()
+	h.SetMaxWorkflowSteps(2)
+	reg("slack")
+	step := "{\"provider\":\"slack\",\"action\":\"send_message\",\"payload\":{}}"
+	body := "{\"workflow\":{\"name\":\"Long\",\"steps\":[" + step + "," + step + "," + step + "]},\"tokens\":{\"slack\":{\"access_token\":\"xoxb\"}}}"
+	req := httptest.NewRequest(http.MethodPost, "/workflows/execute", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ExecuteWorkflow(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "too many steps") {
+		t.Errorf("expected step limit error, got %s", rr.Body.String())
+	}
+}
+func TestExecuteIntegrationAction_StrictJSON_UnknownFieldReported(t *testing.T) {
+	h := newHandler()
+	h.SetStrictJSON(true)
+	reg("slack")
+	body := `{"provder":"slack","action":"send_message"}`
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, httptest.NewRequest(http.MethodPost, "/api/integration/execute", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), `unknown field \"provder\"`) {
+		t.Errorf("expected the unknown field to be named, got %s", rr.Body.String())
+	}
+}
+
+func TestExecuteWorkflow_StrictJSON_NestedUnknownFieldReported(t *testing.T) {
+	h := newHandler()
+	h.SetStrictJSON(true)
+	body := `{"workflow":{"name":"Notify","stepz":[]}}`
+	rr := httptest.NewRecorder()
+	h.ExecuteWorkflow(rr, httptest.NewRequest(http.MethodPost, "/workflows/execute", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "stepz") {
+		t.Errorf("expected 400 naming stepz, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestExecuteIntegrationAction_LenientJSON_IgnoresUnknownField(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	body := `{"provider":"slack","action":"send_message","token":{"access_token":"xoxb"},"extra":1}`
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, httptest.NewRequest(http.MethodPost, "/api/integration/execute", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200 with strict decoding off, got %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+// countingProvider counts Execute calls.
+type countingProvider struct {
+	fakeProvider
+	calls int
+}
+
+func (p *countingProvider) Execute(_ context.Context, _ *integrations.Token, _ string, _ map[string]interface{}) (interface{}, error) {
+	p.calls++
+	return map[string]interface{}{"call": p.calls}, nil
+}
+
+func TestExecuteWorkflow_SameRunKey_ReturnsOriginalRun(t *testing.T) {
+	h := newHandler()
+	p := &countingProvider{fakeProvider: fakeProvider{name: "slack"}}
+	integrations.Providers["slack"] = p
+	body := `{"run_key":"order-42","workflow":{"name":"Notify","steps":[{"provider":"slack","action":"send_message","payload":{}}]},"tokens":{"slack":{"access_token":"xoxb"}}}`
+
+	var responses [2]map[string]interface{}
+	for i := range responses {
+		rr := httptest.NewRecorder()
+		h.ExecuteWorkflow(rr, httptest.NewRequest(http.MethodPost, "/workflows/execute", bytes.NewBufferString(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("run %d: expected 200, got %d body=%s", i, rr.Code, rr.Body.String())
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&responses[i]); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+
+	if p.calls != 1 {
+		t.Errorf("expected the workflow to execute once, got %d", p.calls)
+	}
+	if responses[0]["replayed"] != false || responses[1]["replayed"] != true {
+		t.Errorf("expected replayed false then true, got %v / %v", responses[0]["replayed"], responses[1]["replayed"])
+	}
+	first, _ := json.Marshal(responses[0]["results"])
+	second, _ := json.Marshal(responses[1]["results"])
+	if string(first) != string(second) {
+		t.Errorf("expected original results, got %s then %s", first, second)
+	}
+}
+
+func TestExecuteWorkflow_ExecutionsCarryRunID(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	reg("gmail")
+	body := `{"workflow":{"name":"Notify","steps":[{"provider":"slack","action":"send_message","payload":{}},{"provider":"gmail","action":"send_email","payload":{}}]},"tokens":{"slack":{"access_token":"xoxb"},"gmail":{"access_token":"ya29"}}}`
+	rr := httptest.NewRecorder()
+	h.ExecuteWorkflow(rr, httptest.NewRequest(http.MethodPost, "/workflows/execute", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		RunID string `json:"run_id"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || resp.RunID == "" {
+		t.Fatalf("expected a run_id, got %q (%v)", resp.RunID, err)
+	}
+
+	execs, err := h.history.ListRun(context.Background(), resp.RunID)
+	if err != nil {
+		t.Fatalf("ListRun: %v", err)
+	}
+	if len(execs) != 2 || execs[0].Provider != "slack" || execs[1].Provider != "gmail" {
+		t.Fatalf("expected the run's two provider calls, got %+v", execs)
+	}
+	for _, e := range execs {
+		if e.WorkflowRunID != resp.RunID || e.Status != integrations.ExecutionSucceeded {
+			t.Errorf("unexpected execution %+v", e)
+		}
+	}
+
+	// A direct action call is not attributed to any run.
+	direct := `{"provider":"slack","action":"send_message","token":{"access_token":"xoxb"}}`
+	h.ExecuteIntegrationAction(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/integration/execute", bytes.NewBufferString(direct)))
+	if execs, _ := h.history.ListRun(context.Background(), resp.RunID); len(execs) != 2 {
+		t.Errorf("direct call was attributed to the run: %+v", execs)
+	}
+}
+
 func TestExecuteWorkflow_ValidRequest_Returns200(t *testing.T) {
 	h := newHandler()
 	reg("slack")
@@ -208,6 +653,42 @@ func TestExecuteWorkflow_ValidRequest_Returns200(t *testing.T) {
 		t.Errorf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+// payloadProvider records the payload of its last call.
+type payloadProvider struct {
+	fakeProvider
+	payload map[string]interface{}
+}
+
+func (p *payloadProvider) Execute(_ context.Context, _ *integrations.Token, _ string, payload map[string]interface{}) (interface{}, error) {
+	p.payload = payload
+	return map[string]interface{}{"ok": true}, nil
+}
+
+func TestExecuteWorkflow_SubstitutesParams(t *testing.T) {
+	h := newHandler()
+	slack := &payloadProvider{fakeProvider: fakeProvider{name: "slack"}}
+	integrations.Providers["slack"] = slack
+	wf := `{"name":"Notify","steps":[{"provider":"slack","action":"send_message","payload":{"channel":"{{ params.channel }}"}}]}`
+
+	body := `{"workflow":` + wf + `,"tokens":{"slack":{"access_token":"xoxb"}},"params":{"channel":"#ops"}}`
+	rr := httptest.NewRecorder()
+	h.ExecuteWorkflow(rr, httptest.NewRequest(http.MethodPost, "/workflows/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if slack.payload["channel"] != "#ops" {
+		t.Errorf("payload = %v, want channel #ops", slack.payload)
+	}
+
+	body = `{"workflow":` + wf + `,"tokens":{"slack":{"access_token":"xoxb"}},"params":{}}`
+	rr = httptest.NewRecorder()
+	h.ExecuteWorkflow(rr, httptest.NewRequest(http.MethodPost, "/workflows/execute", strings.NewReader(body)))
+	if rr.Code != http.StatusUnprocessableEntity || !strings.Contains(rr.Body.String(), "params.channel") {
+		t.Errorf("missing param: expected 422 naming it, got %d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
 func TestExecuteWorkflow_UnknownProvider_NotOK(t *testing.T) {
 	h := newHandler()
 	body := "{\"workflow\":{\"name\":\"T\",\"steps\":[{\"provider\":\"ghost\",\"action\":\"act\",\"payload\":{}}]},\"tokens\":{\"ghost\":{\"access_token\":\"tk\"}}}"
@@ -247,3 +728,1609 @@ func TestErrorResponse_ContainsErrorField(t *testing.T) {
 		t.Error("error response should have error field")
 	}
 }
+
+func withWorkspace(req *http.Request, workspaceID string) *http.Request {
+	ctx := context.WithValue(req.Context(), middleware.ContextKeyWorkspaceID, workspaceID)
+	return req.WithContext(ctx)
+}
+
+func saveConnection(t *testing.T, h *Handler, workspaceID string, provider integrations.IntegrationType) {
+	t.Helper()
+	err := h.connections.Save(context.Background(), &integrations.Connection{
+		UserID:      extractUserID(httptest.NewRequest(http.MethodGet, "/", nil)).String(),
+		WorkspaceID: workspaceID,
+		Provider:    provider,
+		Token:       integrations.Token{AccessToken: "stored-" + workspaceID},
+	})
+	if err != nil {
+		t.Fatalf("save connection: %v", err)
+	}
+}
+
+// revokingProvider records the tokens it is asked to revoke and fails with err.
+type revokingProvider struct {
+	fakeProvider
+	revoked []string
+	err     error
+}
+
+func (p *revokingProvider) RevokeToken(_ context.Context, token *integrations.Token) error {
+	p.revoked = append(p.revoked, token.AccessToken)
+	return p.err
+}
+
+func disconnect(h *Handler, workspaceID, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.DisconnectIntegration(rr, withWorkspace(httptest.NewRequest(http.MethodPost, "/api/integration/disconnect", bytes.NewBufferString(body)), workspaceID))
+	return rr
+}
+
+func TestDisconnectIntegration_RevokesAndDeletes(t *testing.T) {
+	h := newHandler()
+	p := &revokingProvider{fakeProvider: fakeProvider{name: "github"}}
+	integrations.Providers["github"] = p
+	saveConnection(t, h, "ws-a", "github")
+	saveConnection(t, h, "ws-b", "github")
+
+	if rr := disconnect(h, "ws-a", `{"provider":"github"}`); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if len(p.revoked) != 1 || p.revoked[0] != "stored-ws-a" {
+		t.Errorf("revoked %v, want the ws-a token", p.revoked)
+	}
+	userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil)).String()
+	if _, err := h.connections.Get(context.Background(), userID, "ws-a", "github"); !errors.Is(err, integrations.ErrConnectionNotFound) {
+		t.Errorf("ws-a connection should be deleted, got %v", err)
+	}
+	if _, err := h.connections.Get(context.Background(), userID, "ws-b", "github"); err != nil {
+		t.Errorf("ws-b connection should remain: %v", err)
+	}
+
+	if rr := disconnect(h, "ws-a", `{"provider":"github"}`); rr.Code != http.StatusNotFound {
+		t.Errorf("disconnecting again: expected 404, got %d", rr.Code)
+	}
+}
+
+func TestDisconnectIntegration_RevokeFailureKeepsConnection(t *testing.T) {
+	h := newHandler()
+	p := &revokingProvider{fakeProvider: fakeProvider{name: "github"}, err: errors.New("github unreachable")}
+	integrations.Providers["github"] = p
+	saveConnection(t, h, "ws-a", "github")
+
+	if rr := disconnect(h, "ws-a", `{"provider":"github"}`); rr.Code != http.StatusBadGateway {
+		t.Fatalf("expected 502, got %d", rr.Code)
+	}
+	userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil)).String()
+	if _, err := h.connections.Get(context.Background(), userID, "ws-a", "github"); err != nil {
+		t.Fatalf("connection should be kept after a failed revocation: %v", err)
+	}
+
+	if rr := disconnect(h, "ws-a", `{"provider":"github","revoke":false}`); rr.Code != http.StatusNoContent {
+		t.Errorf("revoke=false: expected 204, got %d", rr.Code)
+	}
+	if len(p.revoked) != 1 {
+		t.Errorf("revoke=false must not call the provider, revoked %v", p.revoked)
+	}
+}
+
+func TestDisconnectIntegration_WithoutRevocationSupport(t *testing.T) {
+	h := newHandler()
+	reg("airtable")
+	saveConnection(t, h, "", "airtable")
+	if rr := disconnect(h, "", `{"provider":"airtable"}`); rr.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", rr.Code)
+	}
+	for body, want := range map[string]int{`{}`: http.StatusBadRequest, `{"provider":"nope"}`: http.StatusNotFound} {
+		if rr := disconnect(h, "", body); rr.Code != want {
+			t.Errorf("%s: expected %d, got %d", body, want, rr.Code)
+		}
+	}
+}
+
+func TestExecuteIntegrationAction_StoredToken_SameWorkspace_Returns200(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	saveConnection(t, h, "ws-a", "slack")
+	body := "{\"provider\":\"slack\",\"action\":\"send_message\",\"payload\":{}}"
+	req := withWorkspace(httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString(body)), "ws-a")
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestExecuteIntegrationAction_StoredToken_OtherWorkspace_Blocked(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	saveConnection(t, h, "ws-a", "slack")
+	body := "{\"provider\":\"slack\",\"action\":\"send_message\",\"payload\":{}}"
+	req := withWorkspace(httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString(body)), "ws-b")
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for cross-workspace token use, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "ws-b") {
+		t.Errorf("error should name the acting workspace, got %s", rr.Body.String())
+	}
+}
+
+func TestExecuteWorkflow_StoredToken_OtherWorkspace_Blocked(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	saveConnection(t, h, "ws-a", "slack")
+	body := "{\"workflow\":{\"name\":\"T\",\"steps\":[{\"provider\":\"slack\",\"action\":\"send\",\"payload\":{}}]},\"tokens\":{}}"
+	req := withWorkspace(httptest.NewRequest(http.MethodPost, "/workflows/execute", bytes.NewBufferString(body)), "ws-b")
+	rr := httptest.NewRecorder()
+	h.ExecuteWorkflow(rr, req)
+	if rr.Code == http.StatusOK {
+		t.Error("workflow should not use a token connected in another workspace")
+	}
+
+	req = withWorkspace(httptest.NewRequest(http.MethodPost, "/workflows/execute", bytes.NewBufferString(body)), "ws-a")
+	rr = httptest.NewRecorder()
+	h.ExecuteWorkflow(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected 200 in the connected workspace, got %d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestExportHistoryCSV_HeaderAndRow(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	body := "{\"provider\":\"slack\",\"action\":\"send_message\",\"token\":{\"access_token\":\"xoxb\"},\"payload\":{}}"
+	h.ExecuteIntegrationAction(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString(body)))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/integration/history.csv", nil)
+	rr := httptest.NewRecorder()
+	h.ExportHistoryCSV(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("expected text/csv content type, got %q", ct)
+	}
+
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected header and one row, got %q", rr.Body.String())
+	}
+	if lines[0] != "timestamp,provider,action,status,duration_ms" {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	fields := strings.Split(lines[1], ",")
+	if len(fields) != 5 || fields[1] != "slack" || fields[2] != "send_message" || fields[3] != "success" {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+}
+
+func TestExportHistoryCSV_DateRangeExcludesOutside(t *testing.T) {
+	h := newHandler()
+	userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil)).String()
+	for _, ts := range []string{"2024-01-01T10:00:00Z", "2024-02-01T10:00:00Z"} {
+		at, _ := time.Parse(time.RFC3339, ts)
+		_ = h.history.Record(context.Background(), integrations.Execution{
+			UserID: userID, Provider: "slack", Action: "send_message", Status: integrations.ExecutionSucceeded, Timestamp: at,
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/integration/history.csv?from=2024-01-15&to=2024-02-01", nil)
+	rr := httptest.NewRecorder()
+	h.ExportHistoryCSV(rr, req)
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[1], "2024-02-01T10:00:00Z,") {
+		t.Errorf("expected only the February row, got %q", rr.Body.String())
+	}
+}
+
+// streamHistory yields n executions through Each and records, before each
+// one, how many CSV lines had reached the response.
+type streamHistory struct {
+	*integrations.MemoryExecutionHistory
+	n     int
+	rr    *httptest.ResponseRecorder
+	lines []int
+	err   error
+}
+
+func (h *streamHistory) Each(_ context.Context, _, _ string, _, _ time.Time, fn func(integrations.Execution) error) error {
+	if h.err != nil {
+		return h.err
+	}
+	for i := 0; i < h.n; i++ {
+		h.lines = append(h.lines, strings.Count(h.rr.Body.String(), "\n"))
+		if err := fn(integrations.Execution{Provider: "slack", Action: "send_message", Status: integrations.ExecutionSucceeded}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportHistoryCSV_FlushesWhileStreaming(t *testing.T) {
+	h := newHandler()
+	rr := httptest.NewRecorder()
+	history := &streamHistory{MemoryExecutionHistory: integrations.NewMemoryExecutionHistory(), n: 250, rr: rr}
+	h.SetExecutionHistory(history)
+
+	h.ExportHistoryCSV(rr, httptest.NewRequest(http.MethodGet, "/api/integration/history.csv", nil))
+	// The header and first 100 rows reach the client before row 101 is read.
+	if !rr.Flushed || history.lines[100] != 101 || history.lines[200] != 201 {
+		t.Errorf("flushed=%v, lines before rows 101 and 201 = %d, %d; want 101, 201", rr.Flushed, history.lines[100], history.lines[200])
+	}
+	if got := strings.Count(rr.Body.String(), "\n"); got != 251 {
+		t.Errorf("got %d lines, want the header and 250 rows", got)
+	}
+}
+
+func TestExportHistoryCSV_ListError_Returns500(t *testing.T) {
+	h := newHandler()
+	h.SetExecutionHistory(&streamHistory{MemoryExecutionHistory: integrations.NewMemoryExecutionHistory(), err: errors.New("db down")})
+	rr := httptest.NewRecorder()
+	h.ExportHistoryCSV(rr, httptest.NewRequest(http.MethodGet, "/api/integration/history.csv", nil))
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rr.Code)
+	}
+}
+
+func TestExportHistoryCSV_InvalidDate_Returns400(t *testing.T) {
+	h := newHandler()
+	req := httptest.NewRequest(http.MethodGet, "/api/integration/history.csv?from=yesterday", nil)
+	rr := httptest.NewRecorder()
+	h.ExportHistoryCSV(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+// keyProvider accepts only the API key "good-key".
+type keyProvider struct{ fakeProvider }
+
+func (p *keyProvider) TestConnection(_ context.Context, token *integrations.Token) (*integrations.Identity, error) {
+	if token.AccessToken != "good-key" {
+		return nil, fmt.Errorf("rejected: %w", integrations.ErrInvalidCredentials)
+	}
+	return &integrations.Identity{Email: "ops@example.com"}, nil
+}
+
+func TestConnectToken_ValidKey_StoresConnection(t *testing.T) {
+	h := newHandler()
+	integrations.Providers["sendgrid"] = &keyProvider{fakeProvider{name: "sendgrid"}}
+	body := `{"provider":"sendgrid","api_key":" good-key "}`
+	rr := httptest.NewRecorder()
+	h.ConnectToken(rr, httptest.NewRequest(http.MethodPost, "/api/integration/connect-token", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "ops@example.com") {
+		t.Errorf("expected identity in response, got %s", rr.Body.String())
+	}
+
+	userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil))
+	conn, err := h.connections.Get(context.Background(), userID.String(), "", "sendgrid")
+	if err != nil || conn.Token.AccessToken != "good-key" {
+		t.Fatalf("expected stored key, got %+v, %v", conn, err)
+	}
+}
+
+func TestConnectToken_InvalidKey_Returns400AndStoresNothing(t *testing.T) {
+	h := newHandler()
+	integrations.Providers["sendgrid"] = &keyProvider{fakeProvider{name: "sendgrid"}}
+	body := `{"provider":"sendgrid","api_key":"bad-key"}`
+	rr := httptest.NewRecorder()
+	h.ConnectToken(rr, httptest.NewRequest(http.MethodPost, "/api/integration/connect-token", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d body=%s", rr.Code, rr.Body.String())
+	}
+
+	userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil))
+	if _, err := h.connections.Get(context.Background(), userID.String(), "", "sendgrid"); !errors.Is(err, integrations.ErrConnectionNotFound) {
+		t.Errorf("expected no stored connection, got %v", err)
+	}
+}
+
+func TestConnectToken_ProviderWithoutTester_Returns400(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	body := `{"provider":"slack","api_key":"xoxb"}`
+	rr := httptest.NewRecorder()
+	h.ConnectToken(rr, httptest.NewRequest(http.MethodPost, "/api/integration/connect-token", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+// rateLimitedProvider simulates a provider whose retries are exhausted on 429.
+type rateLimitedProvider struct{ fakeProvider }
+
+func (p *rateLimitedProvider) Execute(_ context.Context, _ *integrations.Token, _ string, _ map[string]interface{}) (interface{}, error) {
+	return nil, &integrations.ErrRateLimited{Provider: p.name, RetryAfter: 1500 * time.Millisecond}
+}
+
+func TestExecuteIntegrationAction_ProviderRateLimited_Returns429WithRetryAfter(t *testing.T) {
+	h := newHandler()
+	integrations.Providers["slack"] = &rateLimitedProvider{fakeProvider{name: "slack"}}
+	body := "{\"provider\":\"slack\",\"action\":\"send_message\",\"token\":{\"access_token\":\"xoxb\"},\"payload\":{}}"
+	req := httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, req)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After rounded up to 2, got %q", got)
+	}
+}
+
+// failingProvider returns err from every action.
+type failingProvider struct {
+	fakeProvider
+	err error
+}
+
+func (p *failingProvider) Execute(_ context.Context, _ *integrations.Token, _ string, _ map[string]interface{}) (interface{}, error) {
+	return nil, p.err
+}
+
+func TestExecuteIntegrationAction_UpstreamErrors_MapToStatus(t *testing.T) {
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"ok":false,"error":"invalid_auth"}`, http.StatusUnauthorized},
+		{`{"ok":false,"error":"channel_not_found"}`, http.StatusNotFound},
+		{`{"ok":false,"error":"no_text"}`, http.StatusBadRequest},
+		{`{"ok":false,"error":"fatal_error"}`, http.StatusInternalServerError},
+	} {
+		h := newHandler()
+		upstream := integrations.MapUpstreamError(integrations.IntegrationSlack, http.StatusOK, []byte(tc.body))
+		integrations.Providers["slack"] = &failingProvider{fakeProvider{name: "slack"}, upstream}
+		body := "{\"provider\":\"slack\",\"action\":\"send_message\",\"token\":{\"access_token\":\"xoxb\"},\"payload\":{}}"
+		req := httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ExecuteIntegrationAction(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.body, tc.want, rr.Code)
+		}
+	}
+}
+
+func TestExecuteIntegrationAction_RequestErrors_MapToStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		provider integrations.Provider
+		payload  string
+		want     int
+	}{
+		{"missing field", &integrations.AsanaProvider{}, `{"name":"x"}`, http.StatusBadRequest},
+		{"invalid field", &integrations.AsanaProvider{}, `{"parent_gid":12,"name":"x"}`, http.StatusBadRequest},
+		{"missing token", &failingProvider{fakeProvider{name: "asana"}, integrations.ErrMissingToken}, `{}`, http.StatusUnauthorized},
+		{"rate limited", &rateLimitedProvider{fakeProvider{name: "asana"}}, `{}`, http.StatusTooManyRequests},
+	} {
+		h := newHandler()
+		integrations.Providers["asana"] = tc.provider
+		body := `{"provider":"asana","action":"create_subtask","token":{"access_token":"t"},"payload":` + tc.payload + `}`
+		rr := httptest.NewRecorder()
+		h.ExecuteIntegrationAction(rr, httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString(body)))
+		if rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d body=%s", tc.name, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestExecuteIntegrationAction_ErrorClasses_MapToStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"missing field", fmt.Errorf("%w 'channel'", integrations.ErrMissingField), http.StatusBadRequest},
+		{"invalid field", fmt.Errorf("bad channel: %w", integrations.ErrInvalidField), http.StatusBadRequest},
+		{"unknown action", fmt.Errorf("%w: post", integrations.ErrUnknownAction), http.StatusBadRequest},
+		{"missing token", integrations.ErrMissingToken, http.StatusUnauthorized},
+		{"unauthorized", integrations.MapUpstreamError(integrations.IntegrationSlack, http.StatusUnauthorized, nil), http.StatusUnauthorized},
+		{"not found", integrations.MapUpstreamError(integrations.IntegrationSlack, http.StatusNotFound, nil), http.StatusNotFound},
+		{"rate limited", &integrations.ErrRateLimited{Provider: "slack"}, http.StatusTooManyRequests},
+		{"unclassified", errors.New("boom"), http.StatusInternalServerError},
+	} {
+		h := newHandler()
+		integrations.Providers["slack"] = &failingProvider{fakeProvider{name: "slack"}, tc.err}
+		body := `{"provider":"slack","action":"send_message","token":{"access_token":"xoxb"},"payload":{}}`
+		rr := httptest.NewRecorder()
+		h.ExecuteIntegrationAction(rr, httptest.NewRequest(http.MethodPost, "/api/integration/execute", bytes.NewBufferString(body)))
+		if rr.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.want, rr.Code)
+		}
+		var resp map[string]string
+		if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil || resp["error"] == "" {
+			t.Errorf("%s: expected an {\"error\": ...} body, got %v (%v)", tc.name, resp, err)
+		}
+	}
+}
+
+func TestExecuteIntegrationAction_ConsentCheckedBeforeProviderErrors(t *testing.T) {
+	h := newHandler()
+	integrations.Providers["slack"] = &failingProvider{fakeProvider{name: "slack"}, integrations.ErrMissingToken}
+	userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil))
+	c, err := h.consentManager.Grant(context.Background(), userID, "slack", "integration")
+	if err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	if err := h.consentManager.Revoke(context.Background(), c.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	body := `{"provider":"slack","action":"send_message","token":{"access_token":"xoxb"}}`
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, httptest.NewRequest(http.MethodPost, "/api/integration/execute", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected the consent 403, got %d", rr.Code)
+	}
+}
+
+func TestExecuteIntegrationAction_Provider5xx_MapsToGatewayStatus(t *testing.T) {
+	for _, tc := range []struct {
+		status int
+		want   int
+	}{
+		{http.StatusInternalServerError, http.StatusBadGateway},
+		{http.StatusBadGateway, http.StatusBadGateway},
+		{http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+	} {
+		h := newHandler()
+		upstream := integrations.MapUpstreamError(integrations.IntegrationSlack, tc.status, []byte("<html>error</html>"))
+		integrations.Providers["slack"] = &failingProvider{fakeProvider{name: "slack"}, upstream}
+		body := "{\"provider\":\"slack\",\"action\":\"send_message\",\"token\":{\"access_token\":\"xoxb\"},\"payload\":{}}"
+		req := httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		h.ExecuteIntegrationAction(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("upstream %d: expected %d, got %d", tc.status, tc.want, rr.Code)
+		}
+	}
+}
+
+func TestExecuteWorkflow_ProviderRateLimited_Returns429(t *testing.T) {
+	h := newHandler()
+	integrations.Providers["slack"] = &rateLimitedProvider{fakeProvider{name: "slack"}}
+	body := "{\"workflow\":{\"name\":\"T\",\"steps\":[{\"provider\":\"slack\",\"action\":\"send\",\"payload\":{}}]},\"tokens\":{\"slack\":{\"access_token\":\"x\"}}}"
+	req := httptest.NewRequest(http.MethodPost, "/workflows/execute", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ExecuteWorkflow(rr, req)
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After, got %d %q", rr.Code, rr.Header().Get("Retry-After"))
+	}
+}
+
+func TestListConsents_ReturnsGrantedScopesAndExpiry(t *testing.T) {
+	h := newHandler()
+	userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil))
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := h.consentManager.GrantScopes(context.Background(), userID, "slack", "integration", []string{"chat:write"}, &expires); err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ListConsents(rr, httptest.NewRequest(http.MethodGet, "/api/consent", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+
+	var resp struct {
+		Consents []struct {
+			Provider          string     `json:"provider"`
+			GrantedScopes     []string   `json:"granted_scopes"`
+			GrantedAt         *time.Time `json:"granted_at"`
+			ExpiresAt         *time.Time `json:"expires_at"`
+			RequestableScopes []string   `json:"requestable_scopes"`
+		} `json:"consents"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Consents) != 1 {
+		t.Fatalf("expected 1 consent, got %d", len(resp.Consents))
+	}
+	c := resp.Consents[0]
+	if c.Provider != "slack" || len(c.GrantedScopes) != 1 || c.GrantedScopes[0] != "chat:write" {
+		t.Errorf("unexpected consent %+v", c)
+	}
+	if c.GrantedAt == nil || c.ExpiresAt == nil || !c.ExpiresAt.Equal(expires) {
+		t.Errorf("expected grant and expiry timestamps, got %v / %v", c.GrantedAt, c.ExpiresAt)
+	}
+	for _, s := range c.RequestableScopes {
+		if s == "chat:write" {
+			t.Error("granted scope should not be listed as requestable")
+		}
+	}
+	if len(c.RequestableScopes) == 0 {
+		t.Error("expected additional requestable scopes for slack")
+	}
+}
+
+func TestExecuteIntegrationAction_ConsentRevoked_Returns403WithAuthURL(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil))
+	c, err := h.consentManager.Grant(context.Background(), userID, "slack", "integration")
+	if err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	if err := h.consentManager.Revoke(context.Background(), c.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	body := `{"provider":"slack","action":"send_message","token":{"access_token":"xoxb"}}`
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, httptest.NewRequest(http.MethodPost, "/api/integration/execute", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d body=%s", rr.Code, rr.Body.String())
+	}
+
+	var resp struct {
+		Provider       string   `json:"provider"`
+		AuthURL        string   `json:"auth_url"`
+		State          string   `json:"state"`
+		RequiredScopes []string `json:"required_scopes"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Provider != "slack" || resp.State == "" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	u, err := url.Parse(resp.AuthURL)
+	if err != nil || u.Scheme != "https" || u.Query().Get("state") != resp.State {
+		t.Errorf("auth_url %q is not usable with state %q", resp.AuthURL, resp.State)
+	}
+	if len(resp.RequiredScopes) == 0 {
+		t.Error("expected required scopes for slack")
+	}
+
+	// Each consent-required response carries a fresh state.
+	rr2 := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr2, httptest.NewRequest(http.MethodPost, "/api/integration/execute", bytes.NewBufferString(body)))
+	if strings.Contains(rr2.Body.String(), resp.State) {
+		t.Error("expected a new state on each response")
+	}
+}
+
+func TestExecuteIntegrationAction_Help_ListsActionsWithoutToken(t *testing.T) {
+	h := newHandler()
+	integrations.Providers["jira"] = &integrations.JiraProvider{}
+	body := "{\"provider\":\"jira\",\"action\":\"help\"}"
+	req := httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), "create_issue") {
+		t.Errorf("help should list create_issue, got %s", rr.Body.String())
+	}
+}
+
+func TestListIntegrationActions_ReturnsSchema(t *testing.T) {
+	h := newHandler()
+	integrations.Providers["slack"] = &integrations.SlackProvider{}
+	integrations.Providers["jira"] = &integrations.JiraProvider{}
+
+	type field struct {
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		Required bool   `json:"required"`
+	}
+	fields := func(provider, action string) map[string]field {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		h.ListIntegrationActions(rr, httptest.NewRequest(http.MethodGet, "/api/integration/actions?provider="+provider, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d body=%s", provider, rr.Code, rr.Body.String())
+		}
+		var out struct {
+			Provider string `json:"provider"`
+			Actions  []struct {
+				Name        string  `json:"name"`
+				Description string  `json:"description"`
+				Fields      []field `json:"fields"`
+			} `json:"actions"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &out); err != nil {
+			t.Fatalf("%s: not valid JSON: %v", provider, err)
+		}
+		if out.Provider != provider {
+			t.Errorf("provider = %q, want %q", out.Provider, provider)
+		}
+		for _, a := range out.Actions {
+			if a.Name == action {
+				if a.Description == "" {
+					t.Errorf("%s %s: missing description", provider, action)
+				}
+				byName := map[string]field{}
+				for _, f := range a.Fields {
+					byName[f.Name] = f
+				}
+				return byName
+			}
+		}
+		t.Fatalf("%s: action %s not listed in %s", provider, action, rr.Body.String())
+		return nil
+	}
+
+	slack := fields("slack", "send_message")
+	if f := slack["channel"]; f.Type != "string" || !f.Required {
+		t.Errorf("slack send_message channel = %+v, want a required string", f)
+	}
+	jira := fields("jira", "create_issue")
+	if f := jira["summary"]; f.Type != "string" || !f.Required {
+		t.Errorf("jira create_issue summary = %+v, want a required string", f)
+	}
+	if f := jira["labels"]; f.Type != "array" || f.Required {
+		t.Errorf("jira create_issue labels = %+v, want an optional array", f)
+	}
+}
+
+func TestListIntegrationActions_Errors(t *testing.T) {
+	h := newHandler()
+	reg("discord")
+	for _, tc := range []struct {
+		query string
+		code  int
+	}{
+		{"", http.StatusBadRequest},
+		{"?provider=nope", http.StatusNotFound},
+		{"?provider=discord", http.StatusOK},
+	} {
+		rr := httptest.NewRecorder()
+		h.ListIntegrationActions(rr, httptest.NewRequest(http.MethodGet, "/api/integration/actions"+tc.query, nil))
+		if rr.Code != tc.code {
+			t.Errorf("%q: expected %d, got %d", tc.query, tc.code, rr.Code)
+		}
+	}
+	// A provider without specs lists no actions rather than null.
+	rr := httptest.NewRecorder()
+	h.ListIntegrationActions(rr, httptest.NewRequest(http.MethodGet, "/api/integration/actions?provider=discord", nil))
+	if !strings.Contains(rr.Body.String(), `"actions":[]`) {
+		t.Errorf("expected an empty action list, got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.ListIntegrationActions(rr, httptest.NewRequest(http.MethodPost, "/api/integration/actions?provider=discord", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: expected 405, got %d", rr.Code)
+	}
+}
+
+func TestExecuteIntegrationAction_UnknownAction_Returns400WithValidActions(t *testing.T) {
+	h := newHandler()
+	integrations.Providers["jira"] = &integrations.JiraProvider{}
+	body := "{\"provider\":\"jira\",\"action\":\"create_isue\",\"token\":{\"access_token\":\"t\"}}"
+	req := httptest.NewRequest(http.MethodPost, "/integrations/execute", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "available actions: create_issue") {
+		t.Errorf("error should list valid actions, got %s", rr.Body.String())
+	}
+}
+
+func TestExecuteIntegrationAction_Async_ReturnsJobIDAndCompletes(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	body := "{\"provider\":\"slack\",\"action\":\"send_message\",\"token\":{\"access_token\":\"xoxb\"},\"payload\":{}}"
+	req := httptest.NewRequest(http.MethodPost, "/integrations/execute?async=true", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var queued struct {
+		JobID  string `json:"job_id"`
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&queued); err != nil || queued.JobID == "" {
+		t.Fatalf("response has no job_id: %v", err)
+	}
+	if queued.Status != "queued" {
+		t.Errorf("status = %q, want queued", queued.Status)
+	}
+	if loc := rr.Header().Get("Location"); loc != "/api/jobs/"+queued.JobID {
+		t.Errorf("Location = %q", loc)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.RunJobs(ctx)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rr = httptest.NewRecorder()
+		h.GetJob(rr, httptest.NewRequest(http.MethodGet, "/api/jobs/"+queued.JobID, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("GetJob: expected 200, got %d body=%s", rr.Code, rr.Body.String())
+		}
+		var job struct {
+			Status string                 `json:"status"`
+			Result map[string]interface{} `json:"result"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&job); err != nil {
+			t.Fatalf("decode job: %v", err)
+		}
+		if job.Status == "succeeded" {
+			if job.Result["ok"] != true {
+				t.Errorf("result = %v, want provider output", job.Result)
+			}
+			break
+		}
+		if job.Status == "failed" || time.Now().After(deadline) {
+			t.Fatalf("job did not succeed, last status %q", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries, _ := h.history.List(context.Background(), extractUserID(req).String(), "", time.Time{}, time.Time{})
+	if len(entries) != 1 || entries[0].Action != "send_message" {
+		t.Errorf("history = %+v, want the async execution recorded", entries)
+	}
+}
+
+func TestGetJob_OtherWorkspace_NotFound(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	body := "{\"provider\":\"slack\",\"action\":\"send_message\",\"token\":{\"access_token\":\"xoxb\"},\"payload\":{}}"
+	req := withWorkspace(httptest.NewRequest(http.MethodPost, "/integrations/execute?async=true", bytes.NewBufferString(body)), "ws-a")
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationAction(rr, req)
+	var queued struct {
+		JobID string `json:"job_id"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&queued); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	for _, path := range []string{"/api/jobs/" + queued.JobID, "/api/jobs/not-a-uuid"} {
+		rr = httptest.NewRecorder()
+		h.GetJob(rr, withWorkspace(httptest.NewRequest(http.MethodGet, path, nil), "ws-b"))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404, got %d", path, rr.Code)
+		}
+	}
+}
+
+// asyncRunStatus polls the status of an async workflow run.
+func asyncRunStatus(t *testing.T, h *Handler, jobID string) map[string]interface{} {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	h.WorkflowStatus(rr, httptest.NewRequest(http.MethodGet, "/api/workflow/status?job_id="+jobID, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("WorkflowStatus: expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var run map[string]interface{}
+	if err := json.NewDecoder(rr.Body).Decode(&run); err != nil {
+		t.Fatalf("decode run: %v", err)
+	}
+	return run
+}
+
+// startAsyncWorkflow posts body to ExecuteWorkflowAsync and returns the job ID.
+func startAsyncWorkflow(t *testing.T, h *Handler, body string) string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	h.ExecuteWorkflowAsync(rr, httptest.NewRequest(http.MethodPost, "/api/workflow/execute/async", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var started struct {
+		JobID  string `json:"job_id"`
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&started); err != nil || started.JobID == "" {
+		t.Fatalf("response has no job_id: %v", err)
+	}
+	if started.Status != "running" {
+		t.Errorf("status = %q, want running", started.Status)
+	}
+	if loc := rr.Header().Get("Location"); loc != "/api/workflow/status?job_id="+started.JobID {
+		t.Errorf("Location = %q", loc)
+	}
+	return started.JobID
+}
+
+func TestExecuteWorkflowAsync_PollUntilDone(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	reg("gmail")
+	body := `{"workflow":{"steps":[{"provider":"slack","action":"send_message","payload":{}},{"provider":"gmail","action":"send_email","payload":{}}]},"tokens":{"slack":{"access_token":"xoxb"},"gmail":{"access_token":"ya29"}}}`
+	jobID := startAsyncWorkflow(t, h, body)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		run := asyncRunStatus(t, h, jobID)
+		if run["status"] == "succeeded" {
+			results, _ := json.Marshal(run["results"])
+			if string(results) != `[{"ok":true},{"ok":true}]` || run["completed"] != float64(2) {
+				t.Errorf("unexpected finished run %v", run)
+			}
+			break
+		}
+		if run["status"] != "running" || time.Now().After(deadline) {
+			t.Fatalf("run did not succeed, last state %v", run)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Another workspace cannot see the run.
+	rr := httptest.NewRecorder()
+	h.WorkflowStatus(rr, withWorkspace(httptest.NewRequest(http.MethodGet, "/api/workflow/status?job_id="+jobID, nil), "ws-b"))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("other workspace: expected 404, got %d", rr.Code)
+	}
+	// Nor can a finished run be cancelled.
+	rr = httptest.NewRecorder()
+	h.CancelWorkflow(rr, httptest.NewRequest(http.MethodPost, "/api/workflow/cancel?job_id="+jobID, nil))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("cancel finished run: expected 409, got %d", rr.Code)
+	}
+}
+
+// blockingProvider's actions run until their context is cancelled, closing
+// started when the first one begins.
+type blockingProvider struct {
+	fakeProvider
+	started chan struct{}
+}
+
+func (p *blockingProvider) Execute(ctx context.Context, _ *integrations.Token, _ string, _ map[string]interface{}) (interface{}, error) {
+	close(p.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestCancelWorkflow_StopsRunningWorkflow(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	jira := &blockingProvider{fakeProvider: fakeProvider{name: "jira"}, started: make(chan struct{})}
+	integrations.Providers["jira"] = jira
+	body := `{"workflow":{"steps":[{"provider":"slack","action":"send_message","payload":{}},{"provider":"jira","action":"create_issue","payload":{}},{"provider":"slack","action":"send_message","payload":{}}]},"tokens":{"slack":{"access_token":"xoxb"},"jira":{"access_token":"jt"}}}`
+	jobID := startAsyncWorkflow(t, h, body)
+
+	select {
+	case <-jira.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("blocking step never started")
+	}
+	run := asyncRunStatus(t, h, jobID)
+	if run["status"] != "running" || run["completed"] != float64(1) {
+		t.Errorf("expected one step done while running, got %v", run)
+	}
+
+	rr := httptest.NewRecorder()
+	h.CancelWorkflow(rr, httptest.NewRequest(http.MethodPost, "/api/workflow/cancel?job_id="+jobID, nil))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("cancel: expected 202, got %d body=%s", rr.Code, rr.Body.String())
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for run["status"] == "running" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		run = asyncRunStatus(t, h, jobID)
+	}
+	if run["status"] != "cancelled" {
+		t.Fatalf("status = %v, want cancelled", run["status"])
+	}
+	results, _ := json.Marshal(run["results"])
+	if string(results) != `[{"ok":true},null,null]` {
+		t.Errorf("results = %s, want the first step's result only", results)
+	}
+}
+
+func TestTestWorkflow_RunsFromFixtures(t *testing.T) {
+	h := newHandler()
+	body := `{"workflow":{"steps":[
+		{"provider":"jira","action":"create_issue","payload":{"summary":"Disk full"}},
+		{"provider":"slack","action":"send_message","payload":{"channel":"#ops","text":"filed"}}]},
+		"fixtures":{"jira.create_issue":{"key":"OPS-7"},"slack.send_message":{"ok":true}}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/workflow/test", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	h.TestWorkflow(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var out struct {
+		Results []map[string]interface{} `json:"results"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(out.Results) != 2 || out.Results[0]["key"] != "OPS-7" || out.Results[1]["ok"] != true {
+		t.Errorf("unexpected results %v", out.Results)
+	}
+	if entries, _ := h.history.List(context.Background(), extractUserID(req).String(), "", time.Time{}, time.Time{}); len(entries) != 0 {
+		t.Errorf("sandboxed run recorded history: %+v", entries)
+	}
+}
+
+func TestTestWorkflow_MissingFixture_Returns422(t *testing.T) {
+	h := newHandler()
+	body := `{"workflow":{"steps":[{"provider":"slack","action":"send_message"}]},"fixtures":{}}`
+	rr := httptest.NewRecorder()
+	h.TestWorkflow(rr, httptest.NewRequest(http.MethodPost, "/api/workflow/test", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "slack.send_message") {
+		t.Errorf("error should name the missing fixture, got %s", rr.Body.String())
+	}
+}
+
+func TestPreviewWorkflow_SubstitutesParams(t *testing.T) {
+	h := newHandler()
+	body := `{"template":{"steps":[
+		{"provider":"jira","action":"create_issue","payload":{"project":"{{ params.project }}","priority":"{{ params.priority }}"}},
+		{"provider":"slack","action":"send_message","payload":{"channel":"#{{ params.team }}","text":"filed {{ steps.0.output.key }}"}}]},
+		"params":{"project":"OPS","priority":2,"team":"ops"}}`
+	rr := httptest.NewRecorder()
+	h.PreviewWorkflow(rr, httptest.NewRequest(http.MethodPost, "/api/workflow/preview", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var out struct {
+		Workflow struct {
+			Steps []struct {
+				Payload map[string]interface{} `json:"payload"`
+			} `json:"steps"`
+		} `json:"workflow"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	steps := out.Workflow.Steps
+	if len(steps) != 2 || steps[0].Payload["project"] != "OPS" || steps[0].Payload["priority"] != float64(2) {
+		t.Fatalf("unexpected workflow %+v", out.Workflow)
+	}
+	if steps[1].Payload["channel"] != "#ops" || steps[1].Payload["text"] != "filed {{ steps.0.output.key }}" {
+		t.Errorf("step 1 payload = %v, want params substituted and step references kept", steps[1].Payload)
+	}
+}
+
+func TestPreviewWorkflow_MissingParam_Returns422(t *testing.T) {
+	h := newHandler()
+	body := `{"template":{"steps":[
+		{"provider":"slack","action":"send_message","payload":{"channel":"{{ params.channel }}","text":"{{ steps.1.output.id }}"}}]},
+		"params":{}}`
+	rr := httptest.NewRecorder()
+	h.PreviewWorkflow(rr, httptest.NewRequest(http.MethodPost, "/api/workflow/preview", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var out struct {
+		Unresolved []string `json:"unresolved"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(out.Unresolved) != 2 || out.Unresolved[0] != "params.channel" || !strings.HasPrefix(out.Unresolved[1], "steps.1.output.id") {
+		t.Errorf("unresolved = %v, want the missing param and the forward step reference", out.Unresolved)
+	}
+}
+
+func TestRequestID_SameInErrorBodyAndLogs(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	h := newHandler()
+	handler := middleware.Chain(http.HandlerFunc(h.ExecuteWorkflow), middleware.RequestID, middleware.Logger)
+	// A transform step with no prior result fails inside the engine.
+	body := `{"workflow":{"steps":[{"type":"transform","payload":{"path":"$.id"}}]}}`
+	req := httptest.NewRequest(http.MethodPost, "/api/workflow/execute", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var resp map[string]string
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	id := resp["request_id"]
+	if id == "" || rr.Header().Get(middleware.RequestIDHeader) != id {
+		t.Fatalf("response %v, header %q: want matching request IDs", resp, rr.Header().Get(middleware.RequestIDHeader))
+	}
+	for _, line := range []string{"Workflow execution error", "[POST] /api/workflow/execute"} {
+		if !strings.Contains(logs.String(), "[req "+id+"] "+line) {
+			t.Errorf("no %q log line tagged with %s in:\n%s", line, id, logs.String())
+		}
+	}
+}
+
+// newAuditHandler returns a handler whose default test user holds perms, with
+// executions by two users in ws-a and one in ws-b.
+func newAuditHandler(t *testing.T, perms ...string) (*Handler, *audit.Signer) {
+	t.Helper()
+	h := newHandler()
+	signer, err := audit.GenerateSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts := auth.NewMemoryAccountStore()
+	userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil)).String()
+	accounts.Put(auth.Account{ID: userID, Permissions: perms})
+	roles := rbac.NewMemoryStore()
+	roles.Put(rbac.Membership{UserID: userID, WorkspaceID: "ws-a", Role: rbac.RoleAdmin})
+	roles.Put(rbac.Membership{UserID: "other-user", WorkspaceID: "ws-a", Role: rbac.RoleDeveloper})
+	h.SetRoleStore(roles)
+	h.SetAuditExport(accounts, auth.NewMemoryAuditLog(), signer)
+
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, e := range []integrations.Execution{
+		{UserID: userID, WorkspaceID: "ws-a", Provider: "slack", Action: "send_message", Status: integrations.ExecutionSucceeded},
+		{UserID: "other-user", WorkspaceID: "ws-a", Provider: "jira", Action: "create_issue", Status: integrations.ExecutionFailed},
+		{UserID: userID, WorkspaceID: "ws-b", Provider: "slack", Action: "send_message", Status: integrations.ExecutionSucceeded},
+	} {
+		e.Timestamp = base.Add(time.Duration(i) * time.Minute)
+		if err := h.history.Record(context.Background(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return h, signer
+}
+
+func TestExportAudit_SignatureVerifies(t *testing.T) {
+	h, signer := newAuditHandler(t, auth.PermissionAuditExport)
+	rr := httptest.NewRecorder()
+	h.ExportAudit(rr, withWorkspace(httptest.NewRequest(http.MethodGet, "/api/admin/audit/export", nil), "ws-a"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+
+	var entries []audit.Entry
+	if err := json.Unmarshal(rr.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("export is not a JSON array: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Action != "send_message" || entries[1].UserID != "other-user" {
+		t.Errorf("expected both ws-a executions, got %+v", entries)
+	}
+	if got := rr.Header().Get(AuditAlgorithmHeader); got != audit.Algorithm {
+		t.Errorf("algorithm = %q", got)
+	}
+	if got := rr.Header().Get(AuditKeyIDHeader); got != signer.KeyID() {
+		t.Errorf("key id = %q, want %q", got, signer.KeyID())
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(rr.Header().Get(AuditSignatureHeader))
+	if err != nil {
+		t.Fatalf("signature header: %v", err)
+	}
+	if err := audit.Verify(signer.PublicKey(), rr.Body.Bytes(), sig); err != nil {
+		t.Errorf("export should verify: %v", err)
+	}
+
+	modified := bytes.Replace(rr.Body.Bytes(), []byte(`"status":"error"`), []byte(`"status":"success"`), 1)
+	if bytes.Equal(modified, rr.Body.Bytes()) {
+		t.Fatal("test did not modify the export")
+	}
+	if err := audit.Verify(signer.PublicKey(), modified, sig); !errors.Is(err, audit.ErrBadSignature) {
+		t.Errorf("modified export should fail verification, got %v", err)
+	}
+}
+
+func TestExportAudit_RequiresPermission(t *testing.T) {
+	h, _ := newAuditHandler(t, auth.PermissionImpersonate)
+	rr := httptest.NewRecorder()
+	h.ExportAudit(rr, withWorkspace(httptest.NewRequest(http.MethodGet, "/api/admin/audit/export", nil), "ws-a"))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rr.Code)
+	}
+	if rr.Header().Get(AuditSignatureHeader) != "" {
+		t.Error("a refused export must not be signed")
+	}
+}
+
+func TestExportAudit_RequiresMembership(t *testing.T) {
+	h, _ := newAuditHandler(t, auth.PermissionAuditExport)
+	rr := httptest.NewRecorder()
+	h.ExportAudit(rr, withWorkspace(httptest.NewRequest(http.MethodGet, "/api/admin/audit/export", nil), "ws-b"))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a workspace the caller is not in, got %d", rr.Code)
+	}
+}
+
+func TestExportAudit_IncludesMemberImpersonations(t *testing.T) {
+	h, signer := newAuditHandler(t, auth.PermissionAuditExport)
+	issued := time.Date(2026, 3, 1, 9, 0, 30, 0, time.UTC)
+	for _, rec := range []auth.ImpersonationRecord{
+		{ID: "imp-1", ActorID: "admin-1", TargetID: "other-user", Reason: "ticket 42", IssuedAt: issued, ExpiresAt: issued.Add(auth.ImpersonationTTL)},
+		{ID: "imp-2", ActorID: "admin-1", TargetID: "outsider", Reason: "ticket 43", IssuedAt: issued, ExpiresAt: issued.Add(auth.ImpersonationTTL)},
+	} {
+		h.auditLog.RecordImpersonation(context.Background(), rec)
+	}
+
+	rr := httptest.NewRecorder()
+	h.ExportAudit(rr, withWorkspace(httptest.NewRequest(http.MethodGet, "/api/admin/audit/export", nil), "ws-a"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var entries []audit.Entry
+	if err := json.Unmarshal(rr.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("export is not a JSON array: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected two executions and one impersonation, got %+v", entries)
+	}
+	if e := entries[1]; e.Action != "impersonate" || e.UserID != "other-user" || e.ActorID != "admin-1" || e.Reason != "ticket 42" {
+		t.Errorf("impersonation entry = %+v", e)
+	}
+	sig, _ := base64.StdEncoding.DecodeString(rr.Header().Get(AuditSignatureHeader))
+	if err := audit.Verify(signer.PublicKey(), rr.Body.Bytes(), sig); err != nil {
+		t.Errorf("export should verify: %v", err)
+	}
+}
+
+func TestAuditKeys_PublishesVerificationKey(t *testing.T) {
+	h, signer := newAuditHandler(t, auth.PermissionAuditExport)
+	rr := httptest.NewRecorder()
+	h.AuditKeys(rr, httptest.NewRequest(http.MethodGet, "/.well-known/audit-keys.json", nil))
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&set); err != nil || len(set.Keys) != 1 {
+		t.Fatalf("unexpected key set %+v (%v)", set, err)
+	}
+	pub, err := base64.RawURLEncoding.DecodeString(set.Keys[0]["x"])
+	if err != nil || !bytes.Equal(pub, signer.PublicKey()) || set.Keys[0]["kid"] != signer.KeyID() {
+		t.Errorf("published key %v does not match the signer", set.Keys[0])
+	}
+}
+
+func TestExportAudit_RequiresWorkspace(t *testing.T) {
+	h, _ := newAuditHandler(t, auth.PermissionAuditExport)
+	rr := httptest.NewRecorder()
+	h.ExportAudit(rr, httptest.NewRequest(http.MethodGet, "/api/admin/audit/export", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestExportAudit_NotConfigured(t *testing.T) {
+	h := newHandler()
+	rr := httptest.NewRecorder()
+	h.ExportAudit(rr, withWorkspace(httptest.NewRequest(http.MethodGet, "/api/admin/audit/export", nil), "ws-a"))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rr.Code)
+	}
+}
+
+func withUser(req *http.Request, userID string) *http.Request {
+	ctx := context.WithValue(req.Context(), middleware.ContextKeyUserID, userID)
+	return req.WithContext(ctx)
+}
+
+const (
+	permAdminID  = "11111111-1111-1111-1111-111111111111"
+	permEditorID = "22222222-2222-2222-2222-222222222222"
+)
+
+// newPermissionsHandler has an admin and a developer (editor) in ws-a; the
+// developer also holds a custom apikey:revoke grant.
+func newPermissionsHandler() *Handler {
+	h := newHandler()
+	roles := rbac.NewMemoryStore()
+	roles.Put(rbac.Membership{UserID: permAdminID, WorkspaceID: "ws-a", Role: rbac.RoleAdmin})
+	roles.Put(rbac.Membership{UserID: permEditorID, WorkspaceID: "ws-a", Role: rbac.RoleDeveloper,
+		Permissions: []rbac.Permission{rbac.PermissionAPIKeyRevoke}})
+	h.SetRoleStore(roles)
+	return h
+}
+
+func getPermissions(h *Handler, userID, path string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	h.WorkspacePermissions(rr, withUser(httptest.NewRequest(http.MethodGet, path, nil), userID))
+	return rr
+}
+
+func TestWorkspacePermissions_EditorIncludesInheritedViewer(t *testing.T) {
+	h := newPermissionsHandler()
+	rr := getPermissions(h, permEditorID, "/api/workspace/ws-a/permissions")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		UserID      string   `json:"user_id"`
+		Role        string   `json:"role"`
+		Permissions []string `json:"permissions"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.UserID != permEditorID || resp.Role != "developer" {
+		t.Errorf("unexpected member %+v", resp)
+	}
+	want := slices.Concat(rbac.RolePermissions[rbac.RoleViewer], []rbac.Permission{rbac.PermissionIntegrationWrite, rbac.PermissionAPIKeyRevoke})
+	for _, p := range want {
+		if !slices.Contains(resp.Permissions, string(p)) {
+			t.Errorf("effective permissions %v are missing %s", resp.Permissions, p)
+		}
+	}
+}
+
+func TestWorkspacePermissions_OtherUserRequiresAdmin(t *testing.T) {
+	h := newPermissionsHandler()
+	if rr := getPermissions(h, permEditorID, "/api/workspace/ws-a/permissions?user_id="+permAdminID); rr.Code != http.StatusForbidden {
+		t.Errorf("editor querying admin: expected 403, got %d", rr.Code)
+	}
+
+	rr := getPermissions(h, permAdminID, "/api/workspace/ws-a/permissions?user_id="+permEditorID)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"user_id":"`+permEditorID+`"`) {
+		t.Errorf("admin querying editor: got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := getPermissions(h, permAdminID, "/api/workspace/ws-a/permissions?user_id=someone-else"); rr.Code != http.StatusNotFound {
+		t.Errorf("admin querying non-member: expected 404, got %d", rr.Code)
+	}
+}
+
+func TestWorkspacePermissions_NotFound(t *testing.T) {
+	h := newPermissionsHandler()
+	for _, path := range []string{
+		"/api/workspace/ws-b/permissions",
+		"/api/workspace/ws-a",
+		"/api/workspace//permissions",
+		"/api/workspace/ws-a/x/permissions",
+	} {
+		if rr := getPermissions(h, permAdminID, path); rr.Code != http.StatusNotFound {
+			t.Errorf("GET %s: expected 404, got %d", path, rr.Code)
+		}
+	}
+}
+
+// heldProvider's actions run until hold is closed or their context is
+// cancelled.
+type heldProvider struct {
+	fakeProvider
+	hold chan struct{}
+}
+
+func (p *heldProvider) Execute(ctx context.Context, _ *integrations.Token, _ string, _ map[string]interface{}) (interface{}, error) {
+	select {
+	case <-p.hold:
+		return map[string]interface{}{"ok": true}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+const heldWorkflow = `{"workflow":{"steps":[{"provider":"jira","action":"create_issue","payload":{}}]},"tokens":{"jira":{"access_token":"jt"}}}`
+
+// newConcurrencyHandler allows two workflows in flight per user, three in
+// ws-pro, and holds jira steps until the test ends. Cleanup waits for every
+// run to release its slot, so none outlives the provider registry.
+func newConcurrencyHandler(t *testing.T) *Handler {
+	h := newHandler()
+	cfg := config.DefaultWorkflowConfig()
+	cfg.MaxConcurrentPerUser = 2
+	cfg.MaxConcurrentByPlan = map[string]int{rbac.PlanPro: 3}
+	h.SetWorkflowConfig(cfg)
+	plans := rbac.NewMemoryPlanStore()
+	plans.Put("ws-pro", rbac.PlanPro)
+	h.SetPlanStore(plans)
+
+	jira := &heldProvider{fakeProvider: fakeProvider{name: "jira"}, hold: make(chan struct{})}
+	integrations.Providers["jira"] = jira
+	t.Cleanup(func() {
+		close(jira.hold)
+		userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil)).String()
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			if _, err := h.workflowGate.Acquire(context.Background(), userID, 1); err == nil {
+				return
+			}
+		}
+		t.Error("workflow runs did not finish")
+	})
+	return h
+}
+
+// startHeldWorkflow submits heldWorkflow as an async run in workspaceID.
+func startHeldWorkflow(h *Handler, workspaceID string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/workflow/execute/async", bytes.NewBufferString(heldWorkflow))
+	h.ExecuteWorkflowAsync(rr, withWorkspace(req, workspaceID))
+	return rr
+}
+
+func TestExecuteWorkflow_RejectsConcurrentWorkflowOverLimit(t *testing.T) {
+	h := newConcurrencyHandler(t)
+	var jobIDs []string
+	for i := 0; i < 2; i++ {
+		rr := startHeldWorkflow(h, "")
+		var run struct {
+			JobID string `json:"job_id"`
+		}
+		if rr.Code != http.StatusAccepted || json.NewDecoder(rr.Body).Decode(&run) != nil {
+			t.Fatalf("workflow %d: expected 202, got %d body=%s", i+1, rr.Code, rr.Body.String())
+		}
+		jobIDs = append(jobIDs, run.JobID)
+	}
+
+	rr := startHeldWorkflow(h, "")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("3rd async workflow: expected 429, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var body map[string]string
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil || !strings.Contains(body["error"], "limit is 2") {
+		t.Errorf("expected an error naming the limit, got %v (%v)", body, err)
+	}
+	rr = httptest.NewRecorder()
+	h.ExecuteWorkflow(rr, httptest.NewRequest(http.MethodPost, "/api/workflow/execute", bytes.NewBufferString(heldWorkflow)))
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("3rd sync workflow: expected 429, got %d body=%s", rr.Code, rr.Body.String())
+	}
+
+	// A finished run frees its slot.
+	rr = httptest.NewRecorder()
+	h.CancelWorkflow(rr, httptest.NewRequest(http.MethodPost, "/api/workflow/cancel?job_id="+jobIDs[0], nil))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("cancel: expected 202, got %d", rr.Code)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for asyncRunStatus(t, h, jobIDs[0])["status"] == "running" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if rr := startHeldWorkflow(h, ""); rr.Code != http.StatusAccepted {
+		t.Fatalf("after a run ended: expected 202, got %d body=%s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestExecuteWorkflow_ConcurrencyLimitFollowsPlan(t *testing.T) {
+	h := newConcurrencyHandler(t)
+	for i := 0; i < 3; i++ {
+		if rr := startHeldWorkflow(h, "ws-pro"); rr.Code != http.StatusAccepted {
+			t.Fatalf("pro workflow %d: expected 202, got %d body=%s", i+1, rr.Code, rr.Body.String())
+		}
+	}
+	if rr := startHeldWorkflow(h, "ws-pro"); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("4th pro workflow: expected 429, got %d", rr.Code)
+	}
+}
+
+// failingGate is a concurrency gate whose backend is unreachable.
+type failingGate struct{}
+
+func (failingGate) Acquire(context.Context, string, int) (func(), error) {
+	return nil, errors.New("dial tcp: connection refused")
+}
+
+func TestExecuteWorkflow_ConcurrencyGateFailurePolicy(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	body := `{"workflow":{"steps":[{"provider":"slack","action":"send_message","payload":{}}]},"tokens":{"slack":{"access_token":"xoxb"}}}`
+	for policy, want := range map[middleware.FailurePolicy]int{
+		middleware.FailOpen:   http.StatusOK,
+		middleware.FailClosed: http.StatusServiceUnavailable,
+	} {
+		h.SetConcurrencyGate(failingGate{}, policy)
+		rr := httptest.NewRecorder()
+		h.ExecuteWorkflow(rr, httptest.NewRequest(http.MethodPost, "/api/workflow/execute", bytes.NewBufferString(body)))
+		if rr.Code != want {
+			t.Errorf("%s: expected %d, got %d body=%s", policy, want, rr.Code, rr.Body.String())
+		}
+	}
+}
+
+func TestExecuteIntegrationBatch_MixedResults(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	integrations.Providers["jira"] = &failingProvider{fakeProvider{name: "jira"}, integrations.ErrNotFound}
+	reg("gmail")
+	userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil))
+	c, err := h.consentManager.Grant(context.Background(), userID, "gmail", "integration")
+	if err != nil {
+		t.Fatalf("grant: %v", err)
+	}
+	if err := h.consentManager.Revoke(context.Background(), c.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+
+	body := `[
+		{"provider":"slack","action":"send_message","token":{"access_token":"xoxb"}},
+		{"provider":"jira","action":"create_issue","token":{"access_token":"jt"}},
+		{"provider":"gmail","action":"send_email","token":{"access_token":"ya29"}},
+		{"provider":"nope","action":"run","token":{"access_token":"x"}},
+		{"provider":"slack"},
+		{"provider":"slack","action":"send_message","token":{"access_token":"xoxb"}}]`
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationBatch(rr, httptest.NewRequest(http.MethodPost, "/api/integrations/batch", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var out struct {
+		Results []struct {
+			Index  int                    `json:"index"`
+			Result map[string]interface{} `json:"result"`
+			Error  string                 `json:"error"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&out); err != nil || len(out.Results) != 6 {
+		t.Fatalf("expected 6 results, got %v (%v)", out.Results, err)
+	}
+	for i, want := range []string{"", "not found", "consent not granted", "provider not found", "provider and action are required", ""} {
+		res := out.Results[i]
+		if res.Index != i {
+			t.Errorf("result %d has index %d", i, res.Index)
+		}
+		if want == "" {
+			if res.Error != "" || res.Result["ok"] != true {
+				t.Errorf("item %d: expected success, got %+v", i, res)
+			}
+			continue
+		}
+		if !strings.Contains(res.Error, want) || res.Result != nil {
+			t.Errorf("item %d: expected an error containing %q, got %+v", i, want, res)
+		}
+	}
+}
+
+func TestExecuteIntegrationBatch_RejectsEmptyAndOversizedBatches(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	item := `{"provider":"slack","action":"send_message","token":{"access_token":"xoxb"}}`
+	for _, tc := range []struct {
+		n    int
+		want int
+	}{
+		{0, http.StatusBadRequest},
+		{maxBatchItems, http.StatusOK},
+		{maxBatchItems + 1, http.StatusRequestEntityTooLarge},
+	} {
+		items := make([]string, tc.n)
+		for i := range items {
+			items[i] = item
+		}
+		body := "[" + strings.Join(items, ",") + "]"
+		rr := httptest.NewRecorder()
+		h.ExecuteIntegrationBatch(rr, httptest.NewRequest(http.MethodPost, "/api/integrations/batch", bytes.NewBufferString(body)))
+		if rr.Code != tc.want {
+			t.Errorf("%d items: expected %d, got %d body=%s", tc.n, tc.want, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	h.ExecuteIntegrationBatch(rr, httptest.NewRequest(http.MethodPost, "/api/integrations/batch", bytes.NewBufferString(item)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("a single object instead of an array: expected 400, got %d", rr.Code)
+	}
+}
+
+func TestExecuteIntegrationBatch_ChargesRateLimitPerItem(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	limited := middleware.RateLimiterWithLimit(5, time.Minute)(http.HandlerFunc(h.ExecuteIntegrationBatch))
+	batch := func(n int) *httptest.ResponseRecorder {
+		items := make([]string, n)
+		for i := range items {
+			items[i] = `{"provider":"slack","action":"send_message","token":{"access_token":"xoxb"}}`
+		}
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/integrations/batch", bytes.NewBufferString("["+strings.Join(items, ",")+"]"))
+		req.RemoteAddr = "203.0.113.9:1234"
+		limited.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := batch(6); rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("6 items with a limit of 5: expected 429 with Retry-After, got %d", rr.Code)
+	}
+	// The rejected batch only used its own request.
+	if rr := batch(4); rr.Code != http.StatusOK {
+		t.Fatalf("4 items: expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if rr := batch(1); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("limit used up: expected 429, got %d", rr.Code)
+	}
+}
+
+func TestEffectiveConfig_AdminOnlyAndRedacted(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	cfg := &config.Config{}
+	cfg.Auth.JWTSecret = "jwt-very-secret"
+	cfg.Database.Password = "pg-secret"
+	cfg.Providers.Slack = config.ProviderConfig{ClientID: "slack-client", ClientSecret: "slack-secret", Enabled: true}
+	cfg.Providers.Jira = config.ProviderConfig{ClientID: "jira-client", Enabled: true}
+	h.SetEffectiveConfig(cfg)
+
+	accounts := auth.NewMemoryAccountStore()
+	userID := extractUserID(httptest.NewRequest(http.MethodGet, "/", nil)).String()
+	accounts.Put(auth.Account{ID: userID, Role: "user", Permissions: []string{auth.PermissionAuditExport}})
+	h.SetAccountStore(accounts)
+
+	rr := httptest.NewRecorder()
+	h.EffectiveConfig(rr, httptest.NewRequest(http.MethodGet, "/api/admin/config", nil))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("non-admin: expected 403, got %d", rr.Code)
+	}
+
+	// Being an admin is not enough without the permission.
+	accounts.Put(auth.Account{ID: userID, Role: auth.RoleAdmin})
+	rr = httptest.NewRecorder()
+	h.EffectiveConfig(rr, httptest.NewRequest(http.MethodGet, "/api/admin/config", nil))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("admin without %s: expected 403, got %d", auth.PermissionConfigRead, rr.Code)
+	}
+
+	accounts.Put(auth.Account{ID: userID, Role: auth.RoleAdmin, Permissions: []string{auth.PermissionConfigRead}})
+	rr = httptest.NewRecorder()
+	h.EffectiveConfig(rr, httptest.NewRequest(http.MethodGet, "/api/admin/config", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("admin: expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	for _, secret := range []string{"jwt-very-secret", "pg-secret", "slack-secret"} {
+		if strings.Contains(rr.Body.String(), secret) {
+			t.Errorf("response contains secret %q", secret)
+		}
+	}
+	var out struct {
+		Config struct {
+			Providers map[string]struct {
+				ClientID     string `json:"client_id"`
+				ClientSecret string `json:"client_secret"`
+				Enabled      bool   `json:"enabled"`
+			} `json:"providers"`
+		} `json:"config"`
+		Registered []string `json:"registered_providers"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	providers := out.Config.Providers
+	if p := providers["slack"]; !p.Enabled || p.ClientID != "slack-client" || p.ClientSecret != "[REDACTED]" {
+		t.Errorf("slack = %+v", p)
+	}
+	if p := providers["jira"]; !p.Enabled || p.ClientSecret != "" {
+		t.Errorf("jira = %+v, want enabled without a client secret", p)
+	}
+	if p := providers["zoom"]; p.Enabled {
+		t.Errorf("zoom should be disabled, got %+v", p)
+	}
+	if !slices.Equal(out.Registered, []string{"slack"}) {
+		t.Errorf("registered_providers = %v, want [slack]", out.Registered)
+	}
+}
+
+func TestExecuteWorkflow_StepFailureReturnsPartialResults(t *testing.T) {
+	h := newHandler()
+	reg("slack")
+	integrations.Providers["jira"] = &failingProvider{fakeProvider{name: "jira"}, integrations.ErrNotFound}
+	body := `{"workflow":{"steps":[
+		{"provider":"slack","action":"send_message","payload":{}},
+		{"provider":"jira","action":"create_issue","payload":{}},
+		{"provider":"slack","action":"send_message","payload":{}}]},
+		"tokens":{"slack":{"access_token":"xoxb"},"jira":{"access_token":"jt"}}}`
+	rr := httptest.NewRecorder()
+	h.ExecuteWorkflow(rr, httptest.NewRequest(http.MethodPost, "/api/workflow/execute", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusMultiStatus {
+		t.Fatalf("expected 207, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var out struct {
+		Results    []map[string]interface{} `json:"results"`
+		Error      string                   `json:"error"`
+		FailedStep *int                     `json:"failed_step"`
+		RunID      string                   `json:"run_id"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(out.Results) != 1 || out.Results[0]["ok"] != true {
+		t.Errorf("results = %v, want the first step's result", out.Results)
+	}
+	if out.FailedStep == nil || *out.FailedStep != 1 || !strings.Contains(out.Error, "not found") || out.RunID == "" {
+		t.Errorf("unexpected failure report %+v", out)
+	}
+
+	// With nothing completed there is nothing partial to report.
+	body = `{"workflow":{"steps":[{"provider":"jira","action":"create_issue","payload":{}}]},"tokens":{"jira":{"access_token":"jt"}}}`
+	rr = httptest.NewRecorder()
+	h.ExecuteWorkflow(rr, httptest.NewRequest(http.MethodPost, "/api/workflow/execute", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("first step failing: expected 404, got %d body=%s", rr.Code, rr.Body.String())
+	}
+}
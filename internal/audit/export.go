@@ -0,0 +1,142 @@
+// Package audit produces tamper-evident exports of a workspace's audit log.
+//
+// An export is a JSON array of entries in canonical form (compact, fields in
+// a fixed order, timestamps in UTC) with a detached Ed25519 signature over
+// those bytes. Auditors holding the public key can check that an export has
+// not been altered; reformatting the JSON does not break verification, but
+// changing, adding or removing anything does.
+package audit
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Algorithm names the signature scheme in exports.
+const Algorithm = "Ed25519"
+
+// ErrBadSignature is returned when an export does not match its signature.
+var ErrBadSignature = errors.New("audit export signature does not verify")
+
+// Entry is one audit log record in an export.
+type Entry struct {
+	Timestamp     time.Time `json:"timestamp"`
+	UserID        string    `json:"user_id"`
+	WorkspaceID   string    `json:"workspace_id"`
+	Provider      string    `json:"provider"`
+	Action        string    `json:"action"`
+	Status        string    `json:"status"`
+	DurationMS    int64     `json:"duration_ms"`
+	WorkflowRunID string    `json:"workflow_run_id,omitempty"`
+	// ActorID is the admin who acted as UserID, when impersonating.
+	ActorID string `json:"actor_id,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Canonicalize encodes entries in the canonical form that is signed. A nil
+// slice encodes as an empty array.
+func Canonicalize(entries []Entry) ([]byte, error) {
+	out := make([]Entry, len(entries))
+	for i, e := range entries {
+		e.Timestamp = e.Timestamp.UTC()
+		out[i] = e
+	}
+	return json.Marshal(out)
+}
+
+// Signer signs exports with an Ed25519 key.
+type Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewSigner creates a signer for key.
+func NewSigner(key ed25519.PrivateKey) *Signer {
+	return &Signer{key: key}
+}
+
+// ParseSigningKey decodes a base64-encoded 32-byte Ed25519 seed.
+func ParseSigningKey(s string) (*Signer, error) {
+	seed, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("audit signing key: %w", err)
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("audit signing key must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+	return NewSigner(ed25519.NewKeyFromSeed(seed)), nil
+}
+
+// GenerateSigner creates a signer with a random key. Exports it signs can
+// only be verified while the process that created it is running, so it is
+// meant for development.
+func GenerateSigner() (*Signer, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigner(key), nil
+}
+
+// PublicKey returns the key auditors verify exports with.
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// KeyID identifies the public key: the first 8 bytes of its SHA-256, in hex.
+func (s *Signer) KeyID() string {
+	sum := sha256.Sum256(s.PublicKey())
+	return hex.EncodeToString(sum[:8])
+}
+
+// JWK returns the public key as an RFC 8037 JSON Web Key, for publishing in
+// a key set that auditors fetch separately from exports.
+func (s *Signer) JWK() map[string]string {
+	return map[string]string{
+		"kty": "OKP",
+		"crv": Algorithm,
+		"alg": "EdDSA",
+		"use": "sig",
+		"kid": s.KeyID(),
+		"x":   base64.RawURLEncoding.EncodeToString(s.PublicKey()),
+	}
+}
+
+// Export returns the canonical encoding of entries and its signature.
+func (s *Signer) Export(entries []Entry) (body, signature []byte, err error) {
+	body, err = Canonicalize(entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, ed25519.Sign(s.key, body), nil
+}
+
+// Verify checks export, a JSON array of entries, against signature. The
+// array is decoded and re-canonicalized first, so whitespace and key order
+// do not matter; unknown fields are rejected rather than ignored.
+func Verify(pub ed25519.PublicKey, export, signature []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(export))
+	dec.DisallowUnknownFields()
+	var entries []Entry
+	if err := dec.Decode(&entries); err != nil {
+		return fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	if dec.More() {
+		return fmt.Errorf("%w: trailing data after export", ErrBadSignature)
+	}
+	body, err := Canonicalize(entries)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, body, signature) {
+		return ErrBadSignature
+	}
+	return nil
+}
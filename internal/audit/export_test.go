@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testEntries() []Entry {
+	ts := time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	return []Entry{
+		{Timestamp: ts, UserID: "u1", WorkspaceID: "ws1", Provider: "slack", Action: "send_message", Status: "success", DurationMS: 120},
+		{Timestamp: ts.Add(time.Minute), UserID: "u2", WorkspaceID: "ws1", Provider: "jira", Action: "create_issue", Status: "error", DurationMS: 80, WorkflowRunID: "run-1"},
+	}
+}
+
+func TestExport_Verifies(t *testing.T) {
+	s, err := GenerateSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, sig, err := s.Export(testEntries())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `"timestamp":"2026-03-01T08:30:00Z"`) {
+		t.Errorf("timestamps should be canonicalized to UTC: %s", body)
+	}
+	if err := Verify(s.PublicKey(), body, sig); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	// Reformatting the JSON does not change the canonical content.
+	indented := bytes.ReplaceAll(body, []byte(","), []byte(",\n  "))
+	if err := Verify(s.PublicKey(), indented, sig); err != nil {
+		t.Errorf("Verify reformatted: %v", err)
+	}
+}
+
+func TestExport_DetectsTampering(t *testing.T) {
+	s, _ := GenerateSigner()
+	body, sig, _ := s.Export(testEntries())
+
+	tampered := map[string][]byte{
+		"changed status": bytes.Replace(body, []byte(`"status":"error"`), []byte(`"status":"success"`), 1),
+		"dropped entry":  append(append([]byte(nil), body[:bytes.Index(body, []byte(`},{`))+1]...), ']'),
+		"added field":    bytes.Replace(body, []byte(`"user_id":"u1"`), []byte(`"user_id":"u1","note":"x"`), 1),
+	}
+	for name, export := range tampered {
+		if err := Verify(s.PublicKey(), export, sig); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("%s: expected ErrBadSignature, got %v", name, err)
+		}
+	}
+
+	other, _ := GenerateSigner()
+	if err := Verify(other.PublicKey(), body, sig); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("wrong key: expected ErrBadSignature, got %v", err)
+	}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	seed := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+	a, err := ParseSigningKey(seed)
+	if err != nil {
+		t.Fatalf("ParseSigningKey: %v", err)
+	}
+	b, _ := ParseSigningKey(seed)
+	if a.KeyID() != b.KeyID() || len(a.KeyID()) != 16 {
+		t.Errorf("key IDs %q and %q should match and be 16 hex digits", a.KeyID(), b.KeyID())
+	}
+	if _, err := ParseSigningKey(base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Error("expected an error for a short seed")
+	}
+	if _, err := ParseSigningKey("not base64!"); err == nil {
+		t.Error("expected an error for invalid base64")
+	}
+}
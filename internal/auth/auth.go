@@ -3,9 +3,11 @@ package auth
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"strings"
@@ -13,8 +15,10 @@ import (
 	"time"
 
 	"neighbourhood/internal/config"
+	"neighbourhood/internal/oauthuser"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	// "neighbourhood/internal/database"
 )
 
@@ -68,74 +72,118 @@ func Middleware(next http.Handler) http.Handler {
 	})
 }
 
-// stateEntry holds a generated OAuth state value and its expiry time.
+// stateEntry holds a generated OAuth state's expiry time and the PKCE code
+// verifier of the login it belongs to.
 type stateEntry struct {
 	expiresAt time.Time
+	verifier  string
 }
 
 // OAuthHandler manages OAuth authentication flows.
 type OAuthHandler struct {
-	cfg    *config.Config
-	mu     sync.RWMutex          // guards states
-	states map[string]stateEntry // CSRF state tokens
+	cfg       *config.Config
+	resolvers map[string]oauthuser.UserInfoResolver // by provider
+	users     UserStore                             // nil in OFFLINE mode
+	mu        sync.RWMutex                          // guards states
+	states    map[string]stateEntry                 // CSRF state tokens
 }
 
-// NewOAuthHandler creates a new OAuthHandler.
+// NewOAuthHandler creates a new OAuthHandler. Run RunStateCleanup in the
+// background to evict expired state entries.
 func NewOAuthHandler(cfg *config.Config) *OAuthHandler {
-	h := &OAuthHandler{
-		cfg:    cfg,
-		states: make(map[string]stateEntry),
+	return &OAuthHandler{
+		cfg:       cfg,
+		resolvers: oauthuser.Default(),
+		states:    make(map[string]stateEntry),
 	}
-	go h.cleanupStates() // background goroutine to evict expired state entries
-	return h
 }
 
-// cleanupStates removes expired state entries every 5 minutes to prevent
-// unbounded memory growth when the callback is never called.
-func (h *OAuthHandler) cleanupStates() {
-	const interval = 5 * time.Minute
-	t := time.NewTicker(interval)
+// SetUserStore makes the callbacks link each login to a stored user and keep
+// the provider token. Without one, logins are not persisted.
+func (h *OAuthHandler) SetUserStore(users UserStore) {
+	h.users = users
+}
+
+// stateCleanupInterval is how often RunStateCleanup evicts expired states.
+var stateCleanupInterval = 5 * time.Minute
+
+// RunStateCleanup removes expired state entries every 5 minutes, until ctx
+// is cancelled, to prevent unbounded memory growth when the callback is
+// never called.
+func (h *OAuthHandler) RunStateCleanup(ctx context.Context) {
+	t := time.NewTicker(stateCleanupInterval)
 	defer t.Stop()
-	for range t.C {
-		now := time.Now()
-		h.mu.Lock()
-		for state, entry := range h.states {
-			if now.After(entry.expiresAt) {
-				delete(h.states, state)
-			}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			h.evictExpiredStates(now)
+		}
+	}
+}
+
+func (h *OAuthHandler) evictExpiredStates(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for state, entry := range h.states {
+		if now.After(entry.expiresAt) {
+			delete(h.states, state)
 		}
-		h.mu.Unlock()
 	}
 }
 
 // generateState creates a cryptographically secure random state string for
-// CSRF protection and stores it with an expiry.
-func (h *OAuthHandler) generateState() (string, error) {
-	b := make([]byte, 32)
-	if _, err := rand.Read(b); err != nil {
-		return "", fmt.Errorf("failed to generate random state: %w", err)
+// CSRF protection and a PKCE code verifier (RFC 7636), and stores both with
+// an expiry.
+func (h *OAuthHandler) generateState() (state, verifier string, err error) {
+	if state, err = randomToken(); err != nil {
+		return "", "", fmt.Errorf("failed to generate random state: %w", err)
+	}
+	if verifier, err = randomToken(); err != nil {
+		return "", "", fmt.Errorf("failed to generate code verifier: %w", err)
 	}
-	state := base64.RawURLEncoding.EncodeToString(b)
 
 	h.mu.Lock()
-	h.states[state] = stateEntry{expiresAt: time.Now().Add(10 * time.Minute)}
+	h.states[state] = stateEntry{expiresAt: time.Now().Add(10 * time.Minute), verifier: verifier}
 	h.mu.Unlock()
 
-	return state, nil
+	return state, verifier, nil
 }
 
-// validateState returns true and deletes the state if it exists and has not expired.
-func (h *OAuthHandler) validateState(state string) bool {
+// validateState deletes the state and, if it existed and had not expired,
+// returns its code verifier and true.
+func (h *OAuthHandler) validateState(state string) (verifier string, ok bool) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	entry, exists := h.states[state]
 	if !exists {
-		return false
+		return "", false
 	}
 	delete(h.states, state) // consume once — replay protection
 
-	return time.Now().Before(entry.expiresAt)
+	if !time.Now().Before(entry.expiresAt) {
+		return "", false
+	}
+	return entry.verifier, true
+}
+
+// randomToken returns 32 random bytes, base64url encoded. As a code verifier
+// it is 43 characters, the shortest RFC 7636 allows.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge derives the S256 PKCE code challenge sent with the
+// authorization request from verifier.
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
 }
 
 // GoogleLoginHandler initiates the Google OAuth flow.
@@ -145,7 +193,7 @@ func (h *OAuthHandler) GoogleLoginHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	state, err := h.generateState()
+	state, verifier, err := h.generateState()
 	if err != nil {
 		http.Error(w, "failed to generate state", http.StatusInternalServerError)
 		return
@@ -157,6 +205,8 @@ func (h *OAuthHandler) GoogleLoginHandler(w http.ResponseWriter, r *http.Request
 	params.Set("response_type", "code")
 	params.Set("scope", "openid profile email")
 	params.Set("state", state)
+	params.Set("code_challenge", codeChallenge(verifier))
+	params.Set("code_challenge_method", "S256")
 	authURL := "https://accounts.google.com/o/oauth2/v2/auth?" + params.Encode()
 
 	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
@@ -167,7 +217,8 @@ func (h *OAuthHandler) GoogleCallbackHandler(w http.ResponseWriter, r *http.Requ
 	code := r.URL.Query().Get("code")
 	state := r.URL.Query().Get("state")
 
-	if !h.validateState(state) {
+	verifier, ok := h.validateState(state)
+	if !ok {
 		http.Error(w, "invalid or expired state parameter", http.StatusBadRequest)
 		return
 	}
@@ -176,29 +227,13 @@ func (h *OAuthHandler) GoogleCallbackHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	token, err := h.exchangeGoogleCode(r.Context(), code)
+	token, err := h.exchangeGoogleCode(r.Context(), code, verifier)
 	if err != nil {
-		http.Error(w, "failed to exchange code: "+err.Error(), http.StatusInternalServerError)
+		h.redirectError(w, r, "google", ErrCodeExchangeFailed, err)
 		return
 	}
 
-	userInfo, err := h.getGoogleUserInfo(r.Context(), token)
-	if err != nil {
-		http.Error(w, "failed to get user info: "+err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	// TODO: Create or update user in database.
-	// user, err := database.CreateOrUpdateGoogleUser(userInfo)
-
-	jwtToken, err := h.generateJWT(userInfo)
-	if err != nil {
-		http.Error(w, "failed to generate token", http.StatusInternalServerError)
-		return
-	}
-
-	redirectURL := fmt.Sprintf("/?token=%s&provider=google", url.QueryEscape(jwtToken))
-	http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
+	h.completeLogin(w, r, "google", token)
 }
 
 // GitHubLoginHandler initiates the GitHub OAuth flow.
@@ -208,7 +243,7 @@ func (h *OAuthHandler) GitHubLoginHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	state, err := h.generateState()
+	state, verifier, err := h.generateState()
 	if err != nil {
 		http.Error(w, "failed to generate state", http.StatusInternalServerError)
 		return
@@ -219,6 +254,8 @@ func (h *OAuthHandler) GitHubLoginHandler(w http.ResponseWriter, r *http.Request
 	params.Set("redirect_uri", h.cfg.Auth.GitHubOAuth.RedirectURL)
 	params.Set("scope", "user:email")
 	params.Set("state", state)
+	params.Set("code_challenge", codeChallenge(verifier))
+	params.Set("code_challenge_method", "S256")
 	authURL := "https://github.com/login/oauth/authorize?" + params.Encode()
 
 	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
@@ -229,7 +266,8 @@ func (h *OAuthHandler) GitHubCallbackHandler(w http.ResponseWriter, r *http.Requ
 	code := r.URL.Query().Get("code")
 	state := r.URL.Query().Get("state")
 
-	if !h.validateState(state) {
+	verifier, ok := h.validateState(state)
+	if !ok {
 		http.Error(w, "invalid or expired state parameter", http.StatusBadRequest)
 		return
 	}
@@ -238,35 +276,112 @@ func (h *OAuthHandler) GitHubCallbackHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	token, err := h.exchangeGitHubCode(r.Context(), code)
+	token, err := h.exchangeGitHubCode(r.Context(), code, verifier)
 	if err != nil {
-		http.Error(w, "failed to exchange code: "+err.Error(), http.StatusInternalServerError)
+		h.redirectError(w, r, "github", ErrCodeExchangeFailed, err)
 		return
 	}
 
-	userInfo, err := h.getGitHubUserInfo(r.Context(), token)
+	h.completeLogin(w, r, "github", token)
+}
+
+// completeLogin identifies the user behind a provider access token, links
+// them to a stored user when a UserStore is set, and redirects to the success
+// page with a session JWT. The JWT subject is the stored user's ID, or in
+// OFFLINE mode a UUID derived from the provider and its user ID (see
+// offlineUserID).
+func (h *OAuthHandler) completeLogin(w http.ResponseWriter, r *http.Request, provider, token string) {
+	providerID, email, name, err := h.resolvers[provider].Resolve(r.Context(), token)
 	if err != nil {
-		http.Error(w, "failed to get user info: "+err.Error(), http.StatusInternalServerError)
+		h.redirectError(w, r, provider, ErrCodeUserInfoFailed, err)
 		return
 	}
 
-	// TODO: Create or update user in database.
-	// user, err := database.CreateOrUpdateGitHubUser(userInfo)
+	sub := offlineUserID(provider, providerID)
+	if h.users != nil {
+		sub, err = h.users.UpsertOAuthUser(r.Context(), OAuthLogin{
+			Provider:       provider,
+			ProviderUserID: providerID,
+			Email:          email,
+			Name:           name,
+			AccessToken:    token,
+		})
+		if err != nil {
+			h.redirectError(w, r, provider, ErrCodeAccountFailed, err)
+			return
+		}
+	} else {
+		log.Printf("OAuth %s login for %s not persisted: no user store (OFFLINE mode)", provider, providerID)
+	}
 
-	jwtToken, err := h.generateJWT(userInfo)
+	jwtToken, err := h.generateJWT(sub, email, name)
 	if err != nil {
-		http.Error(w, "failed to generate token", http.StatusInternalServerError)
+		h.redirectError(w, r, provider, ErrCodeTokenFailed, err)
 		return
 	}
 
-	redirectURL := fmt.Sprintf("/?token=%s&provider=github", url.QueryEscape(jwtToken))
+	redirectURL := withQuery(h.successRedirect(), url.Values{"token": {jwtToken}, "provider": {provider}})
 	http.Redirect(w, r, redirectURL, http.StatusTemporaryRedirect)
 }
 
-// exchangeGoogleCode exchanges an authorization code for a Google access token.
-func (h *OAuthHandler) exchangeGoogleCode(ctx context.Context, code string) (string, error) {
+// offlineUserNamespace is the UUID namespace of offlineUserID.
+var offlineUserNamespace = uuid.MustParse("5b0c7a9e-3f1d-4c8e-9a62-2d4f8e1b7c30")
+
+// offlineUserID returns the user ID for a login without a user store. It is
+// a name-based (version 5) UUID, so the same provider account gets the same
+// ID on every login and different accounts never share one.
+func offlineUserID(provider, providerID string) string {
+	return uuid.NewSHA1(offlineUserNamespace, []byte(provider+":"+providerID)).String()
+}
+
+// Error codes passed to the OAuth error page. They are deliberately generic;
+// the underlying error is only logged.
+const (
+	ErrCodeExchangeFailed = "exchange_failed"
+	ErrCodeUserInfoFailed = "userinfo_failed"
+	ErrCodeAccountFailed  = "account_failed"
+	ErrCodeTokenFailed    = "token_failed"
+)
+
+// successRedirect returns the page users land on after logging in.
+func (h *OAuthHandler) successRedirect() string {
+	if h.cfg.Auth.SuccessRedirectURL != "" {
+		return h.cfg.Auth.SuccessRedirectURL
+	}
+	return "/"
+}
+
+// redirectError logs err and sends the browser to the configured error page
+// with only a generic error code, so the frontend can show a friendly message.
+func (h *OAuthHandler) redirectError(w http.ResponseWriter, r *http.Request, provider, code string, err error) {
+	log.Printf("OAuth %s login failed (%s): %v", provider, code, err)
+	target := h.cfg.Auth.ErrorRedirectURL
+	if target == "" {
+		target = "/"
+	}
+	http.Redirect(w, r, withQuery(target, url.Values{"error": {code}, "provider": {provider}}), http.StatusTemporaryRedirect)
+}
+
+// withQuery adds params to target, keeping any query it already has.
+func withQuery(target string, params url.Values) string {
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	q := u.Query()
+	for k, vs := range params {
+		q[k] = vs
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// exchangeGoogleCode exchanges an authorization code for a Google access token,
+// proving with verifier that this server started the login.
+func (h *OAuthHandler) exchangeGoogleCode(ctx context.Context, code, verifier string) (string, error) {
 	formData := url.Values{}
 	formData.Set("code", code)
+	formData.Set("code_verifier", verifier)
 	formData.Set("client_id", h.cfg.Auth.GoogleOAuth.ClientID)
 	formData.Set("client_secret", h.cfg.Auth.GoogleOAuth.ClientSecret)
 	formData.Set("redirect_uri", h.cfg.Auth.GoogleOAuth.RedirectURL)
@@ -304,39 +419,12 @@ func (h *OAuthHandler) exchangeGoogleCode(ctx context.Context, code string) (str
 	return token, nil
 }
 
-// getGoogleUserInfo fetches user profile information from Google.
-func (h *OAuthHandler) getGoogleUserInfo(ctx context.Context, token string) (map[string]interface{}, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
-		"https://www.googleapis.com/oauth2/v2/userinfo",
-		nil,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("building userinfo request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("fetching user info: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("userinfo endpoint returned %d", resp.StatusCode)
-	}
-
-	var userInfo map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
-		return nil, fmt.Errorf("decoding user info: %w", err)
-	}
-
-	return userInfo, nil
-}
-
-// exchangeGitHubCode exchanges an authorization code for a GitHub access token.
-func (h *OAuthHandler) exchangeGitHubCode(ctx context.Context, code string) (string, error) {
+// exchangeGitHubCode exchanges an authorization code for a GitHub access token,
+// proving with verifier that this server started the login.
+func (h *OAuthHandler) exchangeGitHubCode(ctx context.Context, code, verifier string) (string, error) {
 	formData := url.Values{}
 	formData.Set("code", code)
+	formData.Set("code_verifier", verifier)
 	formData.Set("client_id", h.cfg.Auth.GitHubOAuth.ClientID)
 	formData.Set("client_secret", h.cfg.Auth.GitHubOAuth.ClientSecret)
 	formData.Set("redirect_uri", h.cfg.Auth.GitHubOAuth.RedirectURL)
@@ -378,50 +466,12 @@ func (h *OAuthHandler) exchangeGitHubCode(ctx context.Context, code string) (str
 	return token, nil
 }
 
-// getGitHubUserInfo fetches user profile information from GitHub.
-func (h *OAuthHandler) getGitHubUserInfo(ctx context.Context, token string) (map[string]interface{}, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
-		"https://api.github.com/user",
-		nil,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("building userinfo request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Accept", "application/vnd.github+json")
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("fetching user info: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("user API returned %d", resp.StatusCode)
-	}
-
-	var userInfo map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
-		return nil, fmt.Errorf("decoding user info: %w", err)
-	}
-
-	return userInfo, nil
-}
-
 // generateJWT creates a signed HS256 JWT for the authenticated user.
 // The token contains standard claims (sub, email, iat, exp) signed with the
-// configured JWT secret.
-func (h *OAuthHandler) generateJWT(userInfo map[string]interface{}) (string, error) {
-	email, _ := userInfo["email"].(string)
-	name, _ := userInfo["name"].(string)
-	sub, _ := userInfo["id"].(string)
+// configured JWT secret. An empty subject falls back to the email.
+func (h *OAuthHandler) generateJWT(sub, email, name string) (string, error) {
 	if sub == "" {
-		// GitHub uses a numeric "id" field; fall back to email.
-		if id, ok := userInfo["id"].(float64); ok {
-			sub = fmt.Sprintf("%.0f", id)
-		} else {
-			sub = email
-		}
+		sub = email
 	}
 
 	now := time.Now()
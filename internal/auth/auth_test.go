@@ -2,13 +2,20 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"neighbourhood/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // ──────────────────────────────────────────────────────────────────────────────
@@ -153,11 +160,11 @@ func TestAuthMiddleware_WithHeader_PassesThrough(t *testing.T) {
 func TestGenerateState_Unique(t *testing.T) {
 	h := NewOAuthHandler(newTestConfig(true, true))
 
-	s1, err := h.generateState()
+	s1, _, err := h.generateState()
 	if err != nil {
 		t.Fatalf("generateState error: %v", err)
 	}
-	s2, err := h.generateState()
+	s2, _, err := h.generateState()
 	if err != nil {
 		t.Fatalf("generateState error: %v", err)
 	}
@@ -169,7 +176,7 @@ func TestGenerateState_Unique(t *testing.T) {
 
 func TestGenerateState_NonEmpty(t *testing.T) {
 	h := NewOAuthHandler(newTestConfig(true, true))
-	state, err := h.generateState()
+	state, _, err := h.generateState()
 	if err != nil {
 		t.Fatalf("generateState error: %v", err)
 	}
@@ -183,9 +190,9 @@ func TestGenerateState_NonEmpty(t *testing.T) {
 
 func TestValidateState_ValidState(t *testing.T) {
 	h := NewOAuthHandler(newTestConfig(true, true))
-	state, _ := h.generateState()
+	state, _, _ := h.generateState()
 
-	if !h.validateState(state) {
+	if _, ok := h.validateState(state); !ok {
 		t.Error("valid (fresh) state should pass validation")
 	}
 }
@@ -193,40 +200,40 @@ func TestValidateState_ValidState(t *testing.T) {
 func TestValidateState_UnknownState(t *testing.T) {
 	h := NewOAuthHandler(newTestConfig(true, true))
 
-	if h.validateState("totally-unknown-state") {
+	if _, ok := h.validateState("totally-unknown-state"); ok {
 		t.Error("unknown state should fail validation")
 	}
 }
 
 func TestValidateState_ConsumedOnce(t *testing.T) {
 	h := NewOAuthHandler(newTestConfig(true, true))
-	state, _ := h.generateState()
+	state, _, _ := h.generateState()
 
 	// First use — should succeed
-	if !h.validateState(state) {
+	if _, ok := h.validateState(state); !ok {
 		t.Error("first use of valid state should succeed")
 	}
 	// Second use — should fail (replay protection)
-	if h.validateState(state) {
+	if _, ok := h.validateState(state); ok {
 		t.Error("second use of same state should fail (replay protection)")
 	}
 }
 
 func TestValidateState_ExpiredState(t *testing.T) {
 	h := NewOAuthHandler(newTestConfig(true, true))
-	state, _ := h.generateState()
+	state, _, _ := h.generateState()
 
 	// Manually expire the state
 	h.states[state] = stateEntry{expiresAt: time.Now().Add(-1 * time.Hour)}
 
-	if h.validateState(state) {
+	if _, ok := h.validateState(state); ok {
 		t.Error("expired state should fail validation")
 	}
 }
 
 func TestValidateState_CleanupOnExpiry(t *testing.T) {
 	h := NewOAuthHandler(newTestConfig(true, true))
-	state, _ := h.generateState()
+	state, _, _ := h.generateState()
 	h.states[state] = stateEntry{expiresAt: time.Now().Add(-1 * time.Hour)}
 
 	h.validateState(state) // should delete expired entry
@@ -236,6 +243,39 @@ func TestValidateState_CleanupOnExpiry(t *testing.T) {
 	}
 }
 
+func TestRunStateCleanup_EvictsUntilCancelled(t *testing.T) {
+	defer func(d time.Duration) { stateCleanupInterval = d }(stateCleanupInterval)
+	stateCleanupInterval = time.Millisecond
+
+	h := NewOAuthHandler(newTestConfig(true, true))
+	expired, _, _ := h.generateState()
+	live, _, _ := h.generateState()
+	h.mu.Lock()
+	h.states[expired] = stateEntry{expiresAt: time.Now().Add(-time.Hour)}
+	h.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		h.RunStateCleanup(ctx)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunStateCleanup did not return after cancellation")
+	}
+
+	if _, ok := h.states[expired]; ok {
+		t.Error("expired state was not evicted")
+	}
+	if _, ok := h.states[live]; !ok {
+		t.Error("live state was evicted")
+	}
+}
+
 // ──────────────────────────────────────────────────────────────────────────────
 // Google OAuth flow
 // ──────────────────────────────────────────────────────────────────────────────
@@ -302,13 +342,43 @@ func TestGoogleLoginHandler_RedirectContainsState(t *testing.T) {
 	}
 }
 
+func TestLoginHandlers_RedirectContainsPKCEChallenge(t *testing.T) {
+	h := NewOAuthHandler(newTestConfig(true, true))
+	for provider, login := range map[string]http.HandlerFunc{
+		"google": h.GoogleLoginHandler,
+		"github": h.GitHubLoginHandler,
+	} {
+		rr := httptest.NewRecorder()
+		login(rr, httptest.NewRequest(http.MethodGet, "/auth/"+provider+"/login", nil))
+
+		loc, _ := url.Parse(rr.Header().Get("Location"))
+		q := loc.Query()
+		if q.Get("code_challenge_method") != "S256" {
+			t.Errorf("%s: code_challenge_method = %q, want S256", provider, q.Get("code_challenge_method"))
+		}
+		h.mu.RLock()
+		entry := h.states[q.Get("state")]
+		h.mu.RUnlock()
+		if entry.verifier == "" || q.Get("code_challenge") != codeChallenge(entry.verifier) {
+			t.Errorf("%s: code_challenge %q does not match the stored verifier", provider, q.Get("code_challenge"))
+		}
+	}
+}
+
+func TestCodeChallenge_RFC7636Example(t *testing.T) {
+	// Appendix B of RFC 7636.
+	if got := codeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("codeChallenge = %q", got)
+	}
+}
+
 // ──────────────────────────────────────────────────────────────────────────────
 // Google OAuth callback
 // ──────────────────────────────────────────────────────────────────────────────
 
 func TestGoogleCallbackHandler_NoCode(t *testing.T) {
 	h := NewOAuthHandler(newTestConfig(true, true))
-	state, _ := h.generateState()
+	state, _, _ := h.generateState()
 
 	req := httptest.NewRequest(http.MethodGet, "/auth/google/callback?state="+state, nil)
 	rr := httptest.NewRecorder()
@@ -346,6 +416,211 @@ func TestGoogleCallbackHandler_MissingStateParam(t *testing.T) {
 	}
 }
 
+// roundTripFunc stubs outbound OAuth calls.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// stubProviderStatus makes every outbound OAuth call return status until the
+// test ends.
+func stubProviderStatus(t *testing.T, status int) {
+	t.Helper()
+	orig := httpClient
+	httpClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(`{"error":"invalid_grant"}`)), Header: http.Header{}}, nil
+	})}
+	t.Cleanup(func() { httpClient = orig })
+}
+
+func TestGoogleCallbackHandler_ExchangeFails_RedirectsToErrorPage(t *testing.T) {
+	stubProviderStatus(t, http.StatusBadRequest)
+	cfg := newTestConfig(true, true)
+	cfg.Auth.ErrorRedirectURL = "/login?from=oauth"
+	h := NewOAuthHandler(cfg)
+	state, _, _ := h.generateState()
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/google/callback?code=mycode&state="+state, nil)
+	rr := httptest.NewRecorder()
+	h.GoogleCallbackHandler(rr, req)
+
+	if rr.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("expected 307, got %d", rr.Code)
+	}
+	loc, err := url.Parse(rr.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("bad Location: %v", err)
+	}
+	if loc.Path != "/login" || loc.Query().Get("from") != "oauth" {
+		t.Errorf("expected the configured error page, got %s", loc)
+	}
+	if got := loc.Query().Get("error"); got != ErrCodeExchangeFailed {
+		t.Errorf("expected error=%s, got %q", ErrCodeExchangeFailed, got)
+	}
+	if strings.Contains(loc.RawQuery, "400") || strings.Contains(loc.RawQuery, "token+endpoint") {
+		t.Errorf("internal error details leaked into redirect: %s", loc)
+	}
+}
+
+func TestGitHubCallbackHandler_ExchangeFails_RedirectsWithCode(t *testing.T) {
+	stubProviderStatus(t, http.StatusInternalServerError)
+	h := NewOAuthHandler(newTestConfig(true, true))
+	state, _, _ := h.generateState()
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/github/callback?code=mycode&state="+state, nil)
+	rr := httptest.NewRecorder()
+	h.GitHubCallbackHandler(rr, req)
+
+	if rr.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("expected 307, got %d", rr.Code)
+	}
+	loc, _ := url.Parse(rr.Header().Get("Location"))
+	if loc.Path != "/" || loc.Query().Get("error") != ErrCodeExchangeFailed || loc.Query().Get("provider") != "github" {
+		t.Errorf("unexpected redirect %s", loc)
+	}
+}
+
+// stubResolver reports a fixed provider account.
+type stubResolver struct{ id, email, name string }
+
+func (s stubResolver) Resolve(context.Context, string) (string, string, string, error) {
+	return s.id, s.email, s.name, nil
+}
+
+// fakeUserStore records logins and links them all to one user.
+type fakeUserStore struct {
+	logins []OAuthLogin
+	err    error
+}
+
+func (f *fakeUserStore) UpsertOAuthUser(_ context.Context, login OAuthLogin) (string, error) {
+	f.logins = append(f.logins, login)
+	return "user-42", f.err
+}
+
+// stubTokenExchange makes every outbound OAuth call return the access token
+// "at" until the test ends.
+func stubTokenExchange(t *testing.T) {
+	t.Helper()
+	orig := httpClient
+	httpClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"access_token":"at"}`)), Header: http.Header{}}, nil
+	})}
+	t.Cleanup(func() { httpClient = orig })
+}
+
+// callbackSubject runs provider's callback and returns the JWT subject from
+// the success redirect.
+func callbackSubject(t *testing.T, h *OAuthHandler, provider string) string {
+	t.Helper()
+	state, _, _ := h.generateState()
+	req := httptest.NewRequest(http.MethodGet, "/auth/"+provider+"/callback?code=mycode&state="+state, nil)
+	rr := httptest.NewRecorder()
+	if provider == "google" {
+		h.GoogleCallbackHandler(rr, req)
+	} else {
+		h.GitHubCallbackHandler(rr, req)
+	}
+
+	loc, _ := url.Parse(rr.Header().Get("Location"))
+	if rr.Code != http.StatusTemporaryRedirect || loc.Query().Get("error") != "" {
+		t.Fatalf("expected a success redirect, got %d %s", rr.Code, loc)
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(loc.Query().Get("token"), claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(h.cfg.Auth.JWTSecret), nil
+	}); err != nil {
+		t.Fatalf("parse JWT: %v", err)
+	}
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+func TestCallbackHandlers_UpsertUserAndToken(t *testing.T) {
+	stubTokenExchange(t)
+	cfg := newTestConfig(true, true)
+	cfg.Auth.JWTSecret = "test-secret"
+
+	for _, provider := range []string{"google", "github"} {
+		h := NewOAuthHandler(cfg)
+		h.resolvers[provider] = stubResolver{"1089", "ada@example.com", "Ada Lovelace"}
+		users := &fakeUserStore{}
+		h.SetUserStore(users)
+
+		if sub := callbackSubject(t, h, provider); sub != "user-42" {
+			t.Errorf("%s: JWT sub = %q, want the stored user ID", provider, sub)
+		}
+		want := OAuthLogin{Provider: provider, ProviderUserID: "1089", Email: "ada@example.com", Name: "Ada Lovelace", AccessToken: "at"}
+		if len(users.logins) != 1 || users.logins[0] != want {
+			t.Errorf("%s: upserts = %+v, want one %+v", provider, users.logins, want)
+		}
+	}
+}
+
+func TestCallbackHandlers_SendCodeVerifier(t *testing.T) {
+	var sent url.Values
+	orig := httpClient
+	httpClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r.ParseForm()
+		sent = r.PostForm
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"access_token":"at"}`)), Header: http.Header{}}, nil
+	})}
+	t.Cleanup(func() { httpClient = orig })
+
+	for _, provider := range []string{"google", "github"} {
+		h := NewOAuthHandler(newTestConfig(true, true))
+		h.resolvers[provider] = stubResolver{"1089", "ada@example.com", "Ada"}
+		state, verifier, _ := h.generateState()
+
+		req := httptest.NewRequest(http.MethodGet, "/auth/"+provider+"/callback?code=mycode&state="+state, nil)
+		rr := httptest.NewRecorder()
+		if provider == "google" {
+			h.GoogleCallbackHandler(rr, req)
+		} else {
+			h.GitHubCallbackHandler(rr, req)
+		}
+
+		if got := sent.Get("code_verifier"); got == "" || got != verifier {
+			t.Errorf("%s: code_verifier = %q, want %q", provider, got, verifier)
+		}
+		if len(h.states) != 0 {
+			t.Errorf("%s: state and verifier should be consumed", provider)
+		}
+	}
+}
+
+func TestCallbackHandler_UpsertFails_RedirectsWithCode(t *testing.T) {
+	stubTokenExchange(t)
+	h := NewOAuthHandler(newTestConfig(true, true))
+	h.resolvers["google"] = stubResolver{"1089", "ada@example.com", "Ada"}
+	h.SetUserStore(&fakeUserStore{err: ErrNoEmail})
+	state, _, _ := h.generateState()
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/google/callback?code=mycode&state="+state, nil)
+	rr := httptest.NewRecorder()
+	h.GoogleCallbackHandler(rr, req)
+
+	loc, _ := url.Parse(rr.Header().Get("Location"))
+	if got := loc.Query().Get("error"); got != ErrCodeAccountFailed {
+		t.Errorf("expected error=%s, got %q", ErrCodeAccountFailed, got)
+	}
+}
+
+func TestCallbackHandler_NoUserStore_UsesDerivedUUID(t *testing.T) {
+	stubTokenExchange(t)
+	cfg := newTestConfig(true, true)
+	cfg.Auth.JWTSecret = "test-secret"
+	h := NewOAuthHandler(cfg)
+	h.resolvers["github"] = stubResolver{"583231", "octocat@example.com", "octocat"}
+
+	sub := callbackSubject(t, h, "github")
+	if _, err := uuid.Parse(sub); err != nil || sub != offlineUserID("github", "583231") {
+		t.Errorf("JWT sub = %q, want the UUID derived from the provider user ID", sub)
+	}
+	if sub == offlineUserID("github", "583232") || sub == offlineUserID("google", "583231") {
+		t.Error("different provider accounts should get different user IDs")
+	}
+}
+
 // ──────────────────────────────────────────────────────────────────────────────
 // GitHub OAuth flow
 // ──────────────────────────────────────────────────────────────────────────────
@@ -402,7 +677,7 @@ func TestGitHubCallbackHandler_InvalidState(t *testing.T) {
 
 func TestGitHubCallbackHandler_NoCode(t *testing.T) {
 	h := NewOAuthHandler(newTestConfig(true, true))
-	state, _ := h.generateState()
+	state, _, _ := h.generateState()
 
 	req := httptest.NewRequest(http.MethodGet, "/auth/github/callback?state="+state, nil)
 	rr := httptest.NewRecorder()
@@ -420,11 +695,7 @@ func TestGitHubCallbackHandler_NoCode(t *testing.T) {
 
 func TestGenerateJWT_ContainsEmail(t *testing.T) {
 	h := NewOAuthHandler(newTestConfig(true, true))
-	userInfo := map[string]interface{}{
-		"email": "jane@example.com",
-		"name":  "Jane",
-	}
-	token, err := h.generateJWT(userInfo)
+	token, err := h.generateJWT("1234", "jane@example.com", "Jane")
 	if err != nil {
 		t.Fatalf("generateJWT returned unexpected error: %v", err)
 	}
@@ -436,7 +707,7 @@ func TestGenerateJWT_ContainsEmail(t *testing.T) {
 func TestGenerateJWT_MissingEmail(t *testing.T) {
 	h := NewOAuthHandler(newTestConfig(true, true))
 	// email field absent
-	token, err := h.generateJWT(map[string]interface{}{"name": "NoEmail"})
+	token, err := h.generateJWT("1234", "", "NoEmail")
 	if err != nil {
 		t.Fatalf("generateJWT returned unexpected error: %v", err)
 	}
@@ -0,0 +1,389 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"neighbourhood/internal/middleware"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// RoleAdmin is the users.role value of administrators.
+	RoleAdmin = "admin"
+	// PermissionImpersonate lets an admin act as another user for support.
+	PermissionImpersonate = "support:impersonate"
+	// PermissionAuditExport lets a user download the signed audit export of
+	// a workspace.
+	PermissionAuditExport = "audit:export"
+	// PermissionConfigRead lets an admin read the redacted effective
+	// configuration.
+	PermissionConfigRead = "config:read"
+	// ImpersonationTTL is how long an impersonation token stays valid.
+	ImpersonationTTL = 15 * time.Minute
+)
+
+var (
+	// ErrUserNotFound is returned by an AccountStore for an unknown user.
+	ErrUserNotFound = errors.New("user not found")
+	// ErrImpersonationDenied is returned when the requester may not
+	// impersonate anyone.
+	ErrImpersonationDenied = errors.New("impersonation not permitted")
+	// ErrImpersonateAdmin is returned when the target is an admin; admins can
+	// only be acted as by logging in as themselves.
+	ErrImpersonateAdmin = errors.New("admins cannot be impersonated")
+)
+
+// Account is the part of a user record needed to authorise impersonation.
+type Account struct {
+	ID          string
+	Email       string
+	Role        string
+	Permissions []string
+}
+
+// Can reports whether the account holds permission.
+func (a *Account) Can(permission string) bool {
+	return slices.Contains(a.Permissions, permission)
+}
+
+// AccountStore looks up users by ID.
+type AccountStore interface {
+	// Account returns the user, or an error wrapping ErrUserNotFound.
+	Account(ctx context.Context, id string) (*Account, error)
+}
+
+// MemoryAccountStore is an in-process AccountStore used when no database is
+// configured (development and tests).
+type MemoryAccountStore struct {
+	mu       sync.RWMutex
+	accounts map[string]Account
+}
+
+// NewMemoryAccountStore creates an empty in-memory account store.
+func NewMemoryAccountStore() *MemoryAccountStore {
+	return &MemoryAccountStore{accounts: make(map[string]Account)}
+}
+
+// Put stores or replaces a.
+func (s *MemoryAccountStore) Put(a Account) {
+	s.mu.Lock()
+	s.accounts[a.ID] = a
+	s.mu.Unlock()
+}
+
+// Account returns the stored account with id.
+func (s *MemoryAccountStore) Account(_ context.Context, id string) (*Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.accounts[id]
+	if !ok {
+		return nil, fmt.Errorf("user %s: %w", id, ErrUserNotFound)
+	}
+	return &a, nil
+}
+
+// SQLAccountStore reads accounts from the users table.
+type SQLAccountStore struct {
+	db *sql.DB
+}
+
+// NewSQLAccountStore creates an account store backed by db.
+func NewSQLAccountStore(db *sql.DB) *SQLAccountStore {
+	return &SQLAccountStore{db: db}
+}
+
+// Account returns the user with id.
+func (s *SQLAccountStore) Account(ctx context.Context, id string) (*Account, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("user %s: %w", id, ErrUserNotFound)
+	}
+	var (
+		a     Account
+		role  sql.NullString
+		perms pq.StringArray
+	)
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, email, role, permissions FROM users WHERE id = $1`, id,
+	).Scan(&a.ID, &a.Email, &role, &perms)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user %s: %w", id, ErrUserNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load user: %w", err)
+	}
+	a.Role, a.Permissions = role.String, perms
+	return &a, nil
+}
+
+// ImpersonationRecord is the audit entry written for every impersonation
+// token issued.
+type ImpersonationRecord struct {
+	ID        string    `json:"id"`
+	ActorID   string    `json:"actor_id"`
+	TargetID  string    `json:"target_id"`
+	Reason    string    `json:"reason"`
+	IssuedAt  time.Time `json:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AuditLog records impersonation sessions.
+type AuditLog interface {
+	RecordImpersonation(ctx context.Context, rec ImpersonationRecord) error
+	// Impersonations returns the records issued in [from, to), oldest
+	// first; a zero bound is open.
+	Impersonations(ctx context.Context, from, to time.Time) ([]ImpersonationRecord, error)
+}
+
+// MemoryAuditLog is an in-process AuditLog used when no database is
+// configured (development and tests).
+type MemoryAuditLog struct {
+	mu      sync.RWMutex
+	records []ImpersonationRecord
+}
+
+// NewMemoryAuditLog creates an empty in-memory audit log.
+func NewMemoryAuditLog() *MemoryAuditLog {
+	return &MemoryAuditLog{}
+}
+
+// RecordImpersonation appends rec.
+func (l *MemoryAuditLog) RecordImpersonation(_ context.Context, rec ImpersonationRecord) error {
+	l.mu.Lock()
+	l.records = append(l.records, rec)
+	l.mu.Unlock()
+	return nil
+}
+
+// Records returns the recorded impersonations, oldest first.
+func (l *MemoryAuditLog) Records() []ImpersonationRecord {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]ImpersonationRecord(nil), l.records...)
+}
+
+// Impersonations returns the recorded impersonations issued in [from, to).
+func (l *MemoryAuditLog) Impersonations(_ context.Context, from, to time.Time) ([]ImpersonationRecord, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var out []ImpersonationRecord
+	for _, rec := range l.records {
+		if (from.IsZero() || !rec.IssuedAt.Before(from)) && (to.IsZero() || rec.IssuedAt.Before(to)) {
+			out = append(out, rec)
+		}
+	}
+	return out, nil
+}
+
+// SQLAuditLog writes impersonation records to the impersonation_audit table.
+type SQLAuditLog struct {
+	db *sql.DB
+}
+
+// NewSQLAuditLog creates an audit log backed by db.
+func NewSQLAuditLog(db *sql.DB) *SQLAuditLog {
+	return &SQLAuditLog{db: db}
+}
+
+// RecordImpersonation inserts rec.
+func (l *SQLAuditLog) RecordImpersonation(ctx context.Context, rec ImpersonationRecord) error {
+	_, err := l.db.ExecContext(ctx,
+		`INSERT INTO impersonation_audit (id, actor_id, target_id, reason, issued_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		rec.ID, rec.ActorID, rec.TargetID, rec.Reason, rec.IssuedAt, rec.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("record impersonation: %w", err)
+	}
+	return nil
+}
+
+// Impersonations reads the records issued in [from, to).
+func (l *SQLAuditLog) Impersonations(ctx context.Context, from, to time.Time) ([]ImpersonationRecord, error) {
+	query := `SELECT id, actor_id, target_id, reason, issued_at, expires_at FROM impersonation_audit WHERE TRUE`
+	var args []interface{}
+	if !from.IsZero() {
+		args = append(args, from)
+		query += fmt.Sprintf(" AND issued_at >= $%d", len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		query += fmt.Sprintf(" AND issued_at < $%d", len(args))
+	}
+	rows, err := l.db.QueryContext(ctx, query+" ORDER BY issued_at", args...)
+	if err != nil {
+		return nil, fmt.Errorf("list impersonations: %w", err)
+	}
+	defer rows.Close()
+	var out []ImpersonationRecord
+	for rows.Next() {
+		var rec ImpersonationRecord
+		if err := rows.Scan(&rec.ID, &rec.ActorID, &rec.TargetID, &rec.Reason, &rec.IssuedAt, &rec.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("scan impersonation: %w", err)
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+// Impersonator issues short-lived tokens that let a support admin act as a
+// user. Each token carries an RFC 8693 "act" claim naming the admin, so every
+// action taken with it is attributable, and is audit-logged before it is
+// returned.
+type Impersonator struct {
+	secret   []byte
+	accounts AccountStore
+	audit    AuditLog
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewImpersonator creates an Impersonator signing tokens with the JWT secret.
+func NewImpersonator(secret string, accounts AccountStore, audit AuditLog) *Impersonator {
+	return &Impersonator{
+		secret:   []byte(secret),
+		accounts: accounts,
+		audit:    audit,
+		ttl:      ImpersonationTTL,
+		now:      time.Now,
+	}
+}
+
+// Issue returns a token for targetID on behalf of actorID. The actor must be
+// an admin holding PermissionImpersonate, and the target must not be an
+// admin. The audit record is written before the token is signed, so no token
+// exists without one.
+func (i *Impersonator) Issue(ctx context.Context, actorID, targetID, reason string) (string, ImpersonationRecord, error) {
+	actor, err := i.accounts.Account(ctx, actorID)
+	if errors.Is(err, ErrUserNotFound) {
+		return "", ImpersonationRecord{}, ErrImpersonationDenied
+	}
+	if err != nil {
+		return "", ImpersonationRecord{}, err
+	}
+	if actor.Role != RoleAdmin || !actor.Can(PermissionImpersonate) {
+		return "", ImpersonationRecord{}, ErrImpersonationDenied
+	}
+	target, err := i.accounts.Account(ctx, targetID)
+	if err != nil {
+		return "", ImpersonationRecord{}, err
+	}
+	if target.Role == RoleAdmin {
+		return "", ImpersonationRecord{}, ErrImpersonateAdmin
+	}
+
+	now := i.now()
+	rec := ImpersonationRecord{
+		ID:        uuid.NewString(),
+		ActorID:   actor.ID,
+		TargetID:  target.ID,
+		Reason:    reason,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(i.ttl),
+	}
+	if err := i.audit.RecordImpersonation(ctx, rec); err != nil {
+		return "", ImpersonationRecord{}, err
+	}
+
+	claims := jwt.MapClaims{
+		"sub":   target.ID,
+		"email": target.Email,
+		"jti":   rec.ID,
+		"act":   map[string]string{"sub": actor.ID},
+		"iat":   now.Unix(),
+		"exp":   rec.ExpiresAt.Unix(),
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+	if err != nil {
+		return "", ImpersonationRecord{}, fmt.Errorf("signing JWT: %w", err)
+	}
+	return signed, rec, nil
+}
+
+// requester returns the subject of the bearer token on r, verified exactly
+// as the API's Auth middleware verifies it. Tokens that are themselves
+// impersonation tokens are rejected so impersonation cannot be chained.
+func (i *Impersonator) requester(r *http.Request) (string, error) {
+	raw, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || raw == "" {
+		return "", errors.New("missing bearer token")
+	}
+	sub, actor, err := middleware.VerifyJWT(raw, string(i.secret))
+	if err != nil {
+		return "", err
+	}
+	if actor != "" {
+		return "", ErrImpersonationDenied
+	}
+	return sub, nil
+}
+
+// impersonateRequest is the JSON body expected by ServeHTTP.
+type impersonateRequest struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+// ServeHTTP handles POST /auth/impersonate. The caller authenticates with
+// their own JWT and names the user to act as and why.
+func (i *Impersonator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		middleware.HTTPError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	actorID, err := i.requester(r)
+	if errors.Is(err, ErrImpersonationDenied) {
+		middleware.HTTPError(w, "impersonation tokens cannot impersonate", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		middleware.HTTPError(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1 MiB
+	var req impersonateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.HTTPError(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.UserID == "" || req.Reason == "" {
+		middleware.HTTPError(w, "user_id and reason are required", http.StatusBadRequest)
+		return
+	}
+
+	token, rec, err := i.Issue(r.Context(), actorID, req.UserID, req.Reason)
+	switch {
+	case errors.Is(err, ErrImpersonationDenied), errors.Is(err, ErrImpersonateAdmin):
+		middleware.Logf(r.Context(), "Impersonation of %s by %s refused: %v", req.UserID, actorID, err)
+		middleware.HTTPError(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, ErrUserNotFound):
+		middleware.HTTPError(w, "user not found", http.StatusNotFound)
+		return
+	case err != nil:
+		middleware.Logf(r.Context(), "Impersonation of %s by %s failed: %v", req.UserID, actorID, err)
+		middleware.HTTPError(w, "failed to issue impersonation token", http.StatusInternalServerError)
+		return
+	}
+	middleware.Logf(r.Context(), "Impersonation %s: %s acting as %s until %s", rec.ID, rec.ActorID, rec.TargetID, rec.ExpiresAt.Format(time.RFC3339))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":            token,
+		"impersonation_id": rec.ID,
+		"expires_at":       rec.ExpiresAt,
+	})
+}
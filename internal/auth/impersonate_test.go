@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+// Account IDs are UUIDs, as Auth requires of token subjects.
+const (
+	supportID    = "0b6f9c1e-1a2b-4c3d-8e4f-5a6b7c8d9e01"
+	plainAdminID = "0b6f9c1e-1a2b-4c3d-8e4f-5a6b7c8d9e02"
+	otherAdminID = "0b6f9c1e-1a2b-4c3d-8e4f-5a6b7c8d9e03"
+	aliceID      = "0b6f9c1e-1a2b-4c3d-8e4f-5a6b7c8d9e04"
+)
+
+func newTestImpersonator() (*Impersonator, *MemoryAuditLog) {
+	accounts := NewMemoryAccountStore()
+	accounts.Put(Account{ID: supportID, Email: "support@example.com", Role: RoleAdmin, Permissions: []string{PermissionImpersonate}})
+	accounts.Put(Account{ID: plainAdminID, Email: "admin@example.com", Role: RoleAdmin})
+	accounts.Put(Account{ID: otherAdminID, Email: "other@example.com", Role: RoleAdmin, Permissions: []string{PermissionImpersonate}})
+	accounts.Put(Account{ID: aliceID, Email: "alice@example.com", Role: "developer"})
+	audit := NewMemoryAuditLog()
+	return NewImpersonator(testSecret, accounts, audit), audit
+}
+
+func signTestToken(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+func impersonateRequestFor(t *testing.T, bearer, userID string) *http.Request {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"user_id": userID, "reason": "ticket #123"})
+	req := httptest.NewRequest(http.MethodPost, "/auth/impersonate", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+bearer)
+	return req
+}
+
+func TestImpersonate_IssuesTokenWithActClaim(t *testing.T) {
+	imp, _ := newTestImpersonator()
+	admin := signTestToken(t, jwt.MapClaims{"sub": supportID, "exp": time.Now().Add(time.Hour).Unix()})
+
+	rr := httptest.NewRecorder()
+	imp.ServeHTTP(rr, impersonateRequestFor(t, admin, aliceID))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body)
+	}
+	var resp struct {
+		Token           string    `json:"token"`
+		ImpersonationID string    `json:"impersonation_id"`
+		ExpiresAt       time.Time `json:"expires_at"`
+	}
+	json.NewDecoder(rr.Body).Decode(&resp)
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(resp.Token, claims, func(*jwt.Token) (interface{}, error) { return []byte(testSecret), nil }); err != nil {
+		t.Fatalf("issued token invalid: %v", err)
+	}
+	if claims["sub"] != aliceID || claims["jti"] != resp.ImpersonationID {
+		t.Errorf("unexpected claims %v", claims)
+	}
+	if act, _ := claims["act"].(map[string]interface{}); act["sub"] != supportID {
+		t.Errorf("act claim = %v, want sub=support", claims["act"])
+	}
+	if ttl := time.Until(resp.ExpiresAt); ttl > ImpersonationTTL || ttl < ImpersonationTTL-time.Minute {
+		t.Errorf("token lifetime %v, want about %v", ttl, ImpersonationTTL)
+	}
+}
+
+func TestImpersonate_WritesAuditRecord(t *testing.T) {
+	imp, audit := newTestImpersonator()
+
+	_, rec, err := imp.Issue(context.Background(), supportID, aliceID, "ticket #123")
+	if err != nil {
+		t.Fatalf("Issue error: %v", err)
+	}
+	records := audit.Records()
+	if len(records) != 1 || records[0] != rec {
+		t.Fatalf("audit records = %+v, want [%+v]", records, rec)
+	}
+	if rec.ActorID != supportID || rec.TargetID != aliceID || rec.Reason != "ticket #123" {
+		t.Errorf("unexpected audit record %+v", rec)
+	}
+}
+
+func TestImpersonate_AdminTargetBlocked(t *testing.T) {
+	imp, audit := newTestImpersonator()
+	admin := signTestToken(t, jwt.MapClaims{"sub": supportID, "exp": time.Now().Add(time.Hour).Unix()})
+
+	rr := httptest.NewRecorder()
+	imp.ServeHTTP(rr, impersonateRequestFor(t, admin, otherAdminID))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rr.Code)
+	}
+	if len(audit.Records()) != 0 {
+		t.Error("refused impersonation must not be recorded as issued")
+	}
+}
+
+func TestImpersonate_RequiresPermission(t *testing.T) {
+	imp, _ := newTestImpersonator()
+	for _, actor := range []string{plainAdminID, aliceID, "unknown"} {
+		if _, _, err := imp.Issue(context.Background(), actor, aliceID, "debug"); !errors.Is(err, ErrImpersonationDenied) {
+			t.Errorf("%s: expected ErrImpersonationDenied, got %v", actor, err)
+		}
+	}
+}
+
+func TestImpersonate_CannotChain(t *testing.T) {
+	imp, _ := newTestImpersonator()
+	token, _, err := imp.Issue(context.Background(), supportID, aliceID, "debug")
+	if err != nil {
+		t.Fatalf("Issue error: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	imp.ServeHTTP(rr, impersonateRequestFor(t, token, aliceID))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for an impersonation token, got %d", rr.Code)
+	}
+}
+
+func TestImpersonate_InvalidBearer(t *testing.T) {
+	imp, _ := newTestImpersonator()
+	for name, bearer := range map[string]string{
+		"malformed": "not-a-jwt",
+		"no exp":    signTestToken(t, jwt.MapClaims{"sub": supportID}),
+	} {
+		rr := httptest.NewRecorder()
+		imp.ServeHTTP(rr, impersonateRequestFor(t, bearer, aliceID))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s token: expected 401, got %d", name, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoEmail is returned when a login cannot be linked to a user because it
+// is new and the provider shared no email address to create the user with.
+var ErrNoEmail = errors.New("oauth login has no email address")
+
+// OAuthLogin is a completed OAuth login: who the provider says the user is
+// and the access token it issued.
+type OAuthLogin struct {
+	Provider       string
+	ProviderUserID string
+	Email          string
+	Name           string
+	AccessToken    string
+}
+
+// UserStore links OAuth logins to users.
+type UserStore interface {
+	// UpsertOAuthUser returns the ID of the user linked to the login's
+	// provider and provider user ID. A login seen for the first time is
+	// linked to the user with its email, who is created if need be. The
+	// provider token is stored either way.
+	UpsertOAuthUser(ctx context.Context, login OAuthLogin) (userID string, err error)
+}
+
+// TokenCipher encrypts token strings under a per-user key.
+type TokenCipher interface {
+	EncryptString(ctx context.Context, userID, plaintext string) (string, error)
+}
+
+// SQLUserStore links logins through the oauth_accounts table.
+type SQLUserStore struct {
+	db     *sql.DB
+	cipher TokenCipher
+}
+
+// NewSQLUserStore creates a user store backed by db. With a nil cipher,
+// provider tokens are stored unencrypted.
+func NewSQLUserStore(db *sql.DB, cipher TokenCipher) *SQLUserStore {
+	return &SQLUserStore{db: db, cipher: cipher}
+}
+
+// UpsertOAuthUser links login to a user in one transaction.
+func (s *SQLUserStore) UpsertOAuthUser(ctx context.Context, login OAuthLogin) (string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin oauth upsert: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID string
+	err = tx.QueryRowContext(ctx,
+		`SELECT user_id FROM oauth_accounts WHERE provider = $1 AND provider_user_id = $2`,
+		login.Provider, login.ProviderUserID,
+	).Scan(&userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if login.Email == "" {
+			return "", ErrNoEmail
+		}
+		// OAuth users have no password. Emails match case-insensitively,
+		// through idx_users_email_lower, so rows stored before emails were
+		// lowercased are found too. The no-op update makes RETURNING yield
+		// the existing row, keeping its email as stored.
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO users (email, password_hash) VALUES ($1, '')
+			 ON CONFLICT ((lower(email))) DO UPDATE SET email = users.email
+			 RETURNING id`,
+			strings.ToLower(login.Email),
+		).Scan(&userID)
+		if err != nil {
+			return "", fmt.Errorf("upsert user: %w", err)
+		}
+	case err != nil:
+		return "", fmt.Errorf("load oauth account: %w", err)
+	}
+
+	token := login.AccessToken
+	if s.cipher != nil {
+		if token, err = s.cipher.EncryptString(ctx, userID, token); err != nil {
+			return "", fmt.Errorf("encrypt access token: %w", err)
+		}
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO oauth_accounts (user_id, provider, provider_user_id, email, access_token)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (provider, provider_user_id)
+		 DO UPDATE SET email = EXCLUDED.email, access_token = EXCLUDED.access_token, updated_at = NOW()`,
+		userID, login.Provider, login.ProviderUserID, login.Email, token)
+	if err != nil {
+		return "", fmt.Errorf("store oauth account: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("commit oauth upsert: %w", err)
+	}
+	return userID, nil
+}
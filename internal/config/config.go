@@ -2,213 +2,436 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const defaultJWTSecret = "dev-secret-change-in-production"
 
 // Config holds application configuration
 type Config struct {
-	Server    ServerConfig
-	Database  DatabaseConfig
-	Auth      AuthConfig
-	Providers ProvidersConfig
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Redis     RedisConfig     `yaml:"redis"`
+	Events    EventsConfig    `yaml:"events"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Workflow  WorkflowConfig  `yaml:"workflow"`
+	Providers ProvidersConfig `yaml:"providers"`
+	Features  FeatureFlags    `yaml:"-"`
 }
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	JWTSecret   string
-	GoogleOAuth OAuthConfig
-	GitHubOAuth OAuthConfig
+	JWTSecret string `yaml:"jwt_secret" secret:"true"`
+	// TokenEncryptionKeys lists master keys as "id:base64key" entries, current
+	// key first. Stored provider tokens are encrypted when it is set.
+	TokenEncryptionKeys string `yaml:"token_encryption_keys" secret:"true"`
+	// AuditSigningKey is the base64 32-byte Ed25519 seed audit exports are
+	// signed with.
+	AuditSigningKey string `yaml:"audit_signing_key" secret:"true"`
+	// SuccessRedirectURL and ErrorRedirectURL are where OAuth login callbacks
+	// send the browser; errors carry a generic "error" code query parameter.
+	SuccessRedirectURL string      `yaml:"success_redirect_url"`
+	ErrorRedirectURL   string      `yaml:"error_redirect_url"`
+	GoogleOAuth        OAuthConfig `yaml:"google_oauth"`
+	GitHubOAuth        OAuthConfig `yaml:"github_oauth"`
+
+	// RedirectAllowlist lists the exact OAuth redirect URLs a request may ask
+	// an integration auth URL to use instead of the provider's configured
+	// one, for deployments served on several domains.
+	RedirectAllowlist []string `yaml:"redirect_allowlist"`
 }
 
 // OAuthConfig holds OAuth provider configuration
 type OAuthConfig struct {
-	ClientID     string
-	ClientSecret string
-	RedirectURL  string
-	Enabled      bool
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret" secret:"true"`
+	RedirectURL  string `yaml:"redirect_url"`
+	Enabled      bool   `yaml:"enabled"`
 }
 
 // ServerConfig holds server-specific configuration
 type ServerConfig struct {
-	Port string
-	Env  string // development, staging, production
+	Port string `yaml:"port"`
+	Env  string `yaml:"env"` // development, staging, production
+	// ProviderVerboseLogging logs every outbound provider call, with secrets
+	// redacted. Individual requests can opt in with X-Debug-Provider-Log.
+	ProviderVerboseLogging bool `yaml:"provider_verbose_logging"`
+	// StrictJSON rejects request bodies containing unknown fields.
+	StrictJSON bool `yaml:"strict_json"`
+	// ProviderTimeout bounds each provider action; ProviderTimeouts overrides
+	// it per provider name for slow-but-valid operations.
+	ProviderTimeout  time.Duration            `yaml:"provider_timeout"`
+	ProviderTimeouts map[string]time.Duration `yaml:"provider_timeouts"`
+	// SlowRequestThreshold is the duration above which a request is logged
+	// as slow; zero disables slow-request logging.
+	SlowRequestThreshold time.Duration `yaml:"slow_request_threshold"`
+	// RateLimitRPM is the number of requests each client IP may make per
+	// minute.
+	RateLimitRPM int `yaml:"rate_limit_rpm"`
+	// TrustProxy takes client IPs from X-Forwarded-For. Enable it only behind
+	// a reverse proxy that sets the header.
+	TrustProxy bool `yaml:"trust_proxy"`
+	// CORSMaxAge is how long browsers may cache CORS preflight responses.
+	CORSMaxAge time.Duration `yaml:"cors_max_age"`
+}
+
+// WorkflowConfig tunes the workflow engine.
+type WorkflowConfig struct {
+	// MaxConcurrentRuns is the number of workflows an engine runs at once;
+	// further runs wait for a free slot.
+	MaxConcurrentRuns int `yaml:"max_concurrent_runs"`
+	// MaxStepParallelism caps how many steps of one run execute at once,
+	// whatever max_parallelism the workflow asks for.
+	MaxStepParallelism int `yaml:"max_step_parallelism"`
+	// MaxSteps is the largest workflow the engine accepts.
+	MaxSteps int `yaml:"max_steps"`
+	// DefaultStepTimeout bounds each provider step. Zero leaves steps bounded
+	// only by the provider action timeouts.
+	DefaultStepTimeout time.Duration `yaml:"default_step_timeout"`
+	// DefaultRetry is how many times a provider step is retried after a
+	// transient failure (rate limiting, provider unavailable, step timeout).
+	DefaultRetry int `yaml:"default_retry"`
+	// MaxConcurrentPerUser is how many workflows one user may have in flight
+	// at once; further runs are rejected with 429. MaxConcurrentByPlan
+	// overrides it by the plan of the acting workspace. Zero means no cap.
+	MaxConcurrentPerUser int            `yaml:"max_concurrent_per_user"`
+	MaxConcurrentByPlan  map[string]int `yaml:"max_concurrent_by_plan"`
+}
+
+// DefaultWorkflowConfig returns the workflow settings used when none are
+// configured.
+func DefaultWorkflowConfig() WorkflowConfig {
+	return WorkflowConfig{
+		MaxConcurrentRuns:    10,
+		MaxStepParallelism:   5,
+		MaxSteps:             50,
+		MaxConcurrentPerUser: 5,
+		MaxConcurrentByPlan:  map[string]int{"pro": 20, "enterprise": 100},
+	}
+}
+
+// MaxConcurrentFor returns the concurrent workflow limit of a user acting in
+// a workspace on plan.
+func (c WorkflowConfig) MaxConcurrentFor(plan string) int {
+	if n, ok := c.MaxConcurrentByPlan[plan]; ok {
+		return n
+	}
+	return c.MaxConcurrentPerUser
 }
 
 // DatabaseConfig holds database configuration
 type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password" secret:"true"`
+	DBName   string `yaml:"db_name"`
+	SSLMode  string `yaml:"ssl_mode"`
+}
+
+// RedisConfig holds the connection and failure policies for Redis-backed
+// gateway features. Redis features are disabled when Addr is empty.
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password" secret:"true"`
+	DB       int    `yaml:"db"`
+	// RateLimitPolicy and IdempotencyPolicy are "fail-open" or "fail-closed"
+	// and decide whether requests proceed while Redis is unreachable.
+	RateLimitPolicy   string `yaml:"rate_limit_policy"`
+	IdempotencyPolicy string `yaml:"idempotency_policy"`
+	// WorkflowConcurrencyPolicy decides whether workflows start while the
+	// per-user concurrency counters are unreachable.
+	WorkflowConcurrencyPolicy string `yaml:"workflow_concurrency_policy"`
+}
+
+// EventsConfig holds the destination for events delivered from the outbox.
+// Events are only logged when WebhookURL is empty.
+type EventsConfig struct {
+	WebhookURL    string `yaml:"webhook_url" secret:"true"`
+	WebhookSecret string `yaml:"webhook_secret" secret:"true"`
 }
 
 // ProvidersConfig holds all integration provider configurations
 type ProvidersConfig struct {
 	// Communication & Collaboration
-	Slack          ProviderConfig
-	MicrosoftTeams ProviderConfig
-	Zoom           ProviderConfig
-	Discord        ProviderConfig
+	Slack          ProviderConfig `yaml:"slack"`
+	MicrosoftTeams ProviderConfig `yaml:"microsoft_teams"`
+	Zoom           ProviderConfig `yaml:"zoom"`
+	Discord        ProviderConfig `yaml:"discord"`
 
 	// Email & Marketing
-	Gmail     ProviderConfig
-	SendGrid  ProviderConfig
-	Mailchimp ProviderConfig
-	Twilio    ProviderConfig
+	Gmail     ProviderConfig `yaml:"gmail"`
+	SendGrid  ProviderConfig `yaml:"sendgrid"`
+	Mailchimp ProviderConfig `yaml:"mailchimp"`
+	Twilio    ProviderConfig `yaml:"twilio"`
 
 	// Project Management
-	Jira    ProviderConfig
-	Trello  ProviderConfig
-	Asana   ProviderConfig
-	Monday  ProviderConfig
-	Notion  ProviderConfig
-	ClickUp ProviderConfig
+	Jira    ProviderConfig `yaml:"jira"`
+	Trello  ProviderConfig `yaml:"trello"`
+	Asana   ProviderConfig `yaml:"asana"`
+	Monday  ProviderConfig `yaml:"monday"`
+	Notion  ProviderConfig `yaml:"notion"`
+	ClickUp ProviderConfig `yaml:"clickup"`
 
 	// CRM & Sales
-	Salesforce ProviderConfig
-	HubSpot    ProviderConfig
-	Zendesk    ProviderConfig
-	Intercom   ProviderConfig
-	Pipedrive  ProviderConfig
+	Salesforce ProviderConfig `yaml:"salesforce"`
+	HubSpot    ProviderConfig `yaml:"hubspot"`
+	Zendesk    ProviderConfig `yaml:"zendesk"`
+	Intercom   ProviderConfig `yaml:"intercom"`
+	Pipedrive  ProviderConfig `yaml:"pipedrive"`
 
 	// Development & Code
-	GitHub    ProviderConfig
-	GitLab    ProviderConfig
-	Bitbucket ProviderConfig
+	GitHub    ProviderConfig `yaml:"github"`
+	GitLab    ProviderConfig `yaml:"gitlab"`
+	Bitbucket ProviderConfig `yaml:"bitbucket"`
 
 	// Storage & Documents
-	Dropbox     ProviderConfig
-	GoogleDrive ProviderConfig
-	OneDrive    ProviderConfig
-	Box         ProviderConfig
+	Dropbox     ProviderConfig `yaml:"dropbox"`
+	GoogleDrive ProviderConfig `yaml:"google_drive"`
+	OneDrive    ProviderConfig `yaml:"onedrive"`
+	Box         ProviderConfig `yaml:"box"`
 
 	// Payment & E-commerce
-	Stripe  ProviderConfig
-	Shopify ProviderConfig
-	PayPal  ProviderConfig
-	Square  ProviderConfig
+	Stripe  ProviderConfig `yaml:"stripe"`
+	Shopify ProviderConfig `yaml:"shopify"`
+	PayPal  ProviderConfig `yaml:"paypal"`
+	Square  ProviderConfig `yaml:"square"`
 
 	// Data & Analytics
-	Airtable       ProviderConfig
-	GoogleSheets   ProviderConfig
-	Tableau        ProviderConfig
-	MicrosoftExcel ProviderConfig
+	Airtable       ProviderConfig `yaml:"airtable"`
+	GoogleSheets   ProviderConfig `yaml:"google_sheets"`
+	Tableau        ProviderConfig `yaml:"tableau"`
+	MicrosoftExcel ProviderConfig `yaml:"microsoft_excel"`
 
 	// Social Media
-	Twitter   ProviderConfig
-	LinkedIn  ProviderConfig
-	Facebook  ProviderConfig
-	Instagram ProviderConfig
+	Twitter   ProviderConfig `yaml:"twitter"`
+	LinkedIn  ProviderConfig `yaml:"linkedin"`
+	Facebook  ProviderConfig `yaml:"facebook"`
+	Instagram ProviderConfig `yaml:"instagram"`
+
+	// Automation
+	WebhookForward WebhookForwardConfig `yaml:"webhook_forward"`
+}
+
+// WebhookForwardConfig holds configuration for the webhook forwarding provider,
+// which needs a target and signing secret rather than OAuth credentials.
+type WebhookForwardConfig struct {
+	TargetURL            string `yaml:"target_url" secret:"true"`
+	SigningSecret        string `yaml:"signing_secret" secret:"true"`
+	AllowPrivateNetworks bool   `yaml:"allow_private_networks"`
+	Enabled              bool   `yaml:"enabled"`
 }
 
 // ProviderConfig holds generic provider configuration
 type ProviderConfig struct {
-	ClientID     string
-	ClientSecret string
-	RedirectURL  string
-	Enabled      bool
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret" secret:"true"`
+	RedirectURL  string `yaml:"redirect_url"`
+	Enabled      bool   `yaml:"enabled"`
+	// Scopes overrides the OAuth scopes a provider requests; empty keeps the
+	// provider's defaults. Only providers that support it read it.
+	Scopes []string `yaml:"scopes"`
 }
 
 // Load loads configuration from environment variables.
 // It returns an error when running in production with insecure defaults.
 func Load() (*Config, error) {
+	cfg, err := load(defaultConfig())
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	SetFeatureFlags(cfg.Features)
+	return cfg, nil
+}
+
+// defaultConfig returns the settings used for anything the environment, and
+// the configuration file if any, leaves unset. Every provider is disabled
+// until it is enabled explicitly.
+func defaultConfig() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            "8080",
+			Env:             "development",
+			ProviderTimeout: 30 * time.Second,
+			RateLimitRPM:    100,
+			CORSMaxAge:      24 * time.Hour,
+		},
+		Auth: AuthConfig{
+			JWTSecret:          defaultJWTSecret,
+			SuccessRedirectURL: "/",
+			ErrorRedirectURL:   "/",
+			GoogleOAuth:        OAuthConfig{RedirectURL: "http://localhost:8080/auth/google/callback", Enabled: true},
+			GitHubOAuth:        OAuthConfig{RedirectURL: "http://localhost:8080/auth/github/callback", Enabled: true},
+		},
+		Database: DatabaseConfig{
+			Host:    "localhost",
+			Port:    5432,
+			User:    "postgres",
+			DBName:  "neighbourhood",
+			SSLMode: "disable",
+		},
+		Redis: RedisConfig{
+			RateLimitPolicy:   "fail-open",
+			IdempotencyPolicy: "fail-closed",
+
+			WorkflowConcurrencyPolicy: "fail-open",
+		},
+		Workflow: DefaultWorkflowConfig(),
+	}
+}
+
+// load reads configuration from environment variables, using base for any
+// that are unset.
+func load(base Config) (*Config, error) {
 	cfg := &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port: getEnv("PORT", base.Server.Port),
+			Env:  getEnv("ENV", base.Server.Env),
+
+			ProviderTimeout:  base.Server.ProviderTimeout,
+			ProviderTimeouts: base.Server.ProviderTimeouts,
+
+			ProviderVerboseLogging: getEnvBool("PROVIDER_VERBOSE_LOGGING", base.Server.ProviderVerboseLogging),
+			StrictJSON:             getEnvBool("STRICT_JSON_DECODING", base.Server.StrictJSON),
+			SlowRequestThreshold:   time.Duration(getEnvInt("SLOW_REQUEST_MS", int(base.Server.SlowRequestThreshold.Milliseconds()))) * time.Millisecond,
+			RateLimitRPM:           getEnvInt("RATE_LIMIT_RPM", base.Server.RateLimitRPM),
+			TrustProxy:             getEnvBool("TRUST_PROXY", base.Server.TrustProxy),
 		},
 		Auth: AuthConfig{
-			JWTSecret: getEnv("JWT_SECRET", defaultJWTSecret),
+			JWTSecret:           getEnv("JWT_SECRET", base.Auth.JWTSecret),
+			TokenEncryptionKeys: getEnv("TOKEN_ENCRYPTION_KEYS", base.Auth.TokenEncryptionKeys),
+			AuditSigningKey:     getEnv("AUDIT_SIGNING_KEY", base.Auth.AuditSigningKey),
+			SuccessRedirectURL:  getEnv("OAUTH_SUCCESS_REDIRECT", base.Auth.SuccessRedirectURL),
+			ErrorRedirectURL:    getEnv("OAUTH_ERROR_REDIRECT", base.Auth.ErrorRedirectURL),
+			RedirectAllowlist:   getEnvList("OAUTH_REDIRECT_ALLOWLIST", base.Auth.RedirectAllowlist),
 			GoogleOAuth: OAuthConfig{
-				ClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
-				ClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
-				RedirectURL:  getEnv("GOOGLE_REDIRECT_URL", "http://localhost:8080/auth/google/callback"),
-				Enabled:      getEnvBool("GOOGLE_AUTH_ENABLED", true),
+				ClientID:     getEnv("GOOGLE_CLIENT_ID", base.Auth.GoogleOAuth.ClientID),
+				ClientSecret: getEnv("GOOGLE_CLIENT_SECRET", base.Auth.GoogleOAuth.ClientSecret),
+				RedirectURL:  getEnv("GOOGLE_REDIRECT_URL", base.Auth.GoogleOAuth.RedirectURL),
+				Enabled:      getEnvBool("GOOGLE_AUTH_ENABLED", base.Auth.GoogleOAuth.Enabled),
 			},
 			GitHubOAuth: OAuthConfig{
-				ClientID:     getEnv("GITHUB_CLIENT_ID", ""),
-				ClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
-				RedirectURL:  getEnv("GITHUB_REDIRECT_URL", "http://localhost:8080/auth/github/callback"),
-				Enabled:      getEnvBool("GITHUB_AUTH_ENABLED", true),
+				ClientID:     getEnv("GITHUB_CLIENT_ID", base.Auth.GitHubOAuth.ClientID),
+				ClientSecret: getEnv("GITHUB_CLIENT_SECRET", base.Auth.GitHubOAuth.ClientSecret),
+				RedirectURL:  getEnv("GITHUB_REDIRECT_URL", base.Auth.GitHubOAuth.RedirectURL),
+				Enabled:      getEnvBool("GITHUB_AUTH_ENABLED", base.Auth.GitHubOAuth.Enabled),
 			},
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnvInt("DB_PORT", 5432),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "neighbourhood"),
-			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
+			Host:     getEnv("DB_HOST", base.Database.Host),
+			Port:     getEnvInt("DB_PORT", base.Database.Port),
+			User:     getEnv("DB_USER", base.Database.User),
+			Password: getEnv("DB_PASSWORD", base.Database.Password),
+			DBName:   getEnv("DB_NAME", base.Database.DBName),
+			SSLMode:  getEnv("DB_SSL_MODE", base.Database.SSLMode),
+		},
+		Events: EventsConfig{
+			WebhookURL:    getEnv("EVENTS_WEBHOOK_URL", base.Events.WebhookURL),
+			WebhookSecret: getEnv("EVENTS_WEBHOOK_SECRET", base.Events.WebhookSecret),
+		},
+		Redis: RedisConfig{
+			Addr:              getEnv("REDIS_ADDR", base.Redis.Addr),
+			Password:          getEnv("REDIS_PASSWORD", base.Redis.Password),
+			DB:                getEnvInt("REDIS_DB", base.Redis.DB),
+			RateLimitPolicy:   getEnv("REDIS_RATE_LIMIT_POLICY", base.Redis.RateLimitPolicy),
+			IdempotencyPolicy: getEnv("REDIS_IDEMPOTENCY_POLICY", base.Redis.IdempotencyPolicy),
+
+			WorkflowConcurrencyPolicy: getEnv("REDIS_WORKFLOW_CONCURRENCY_POLICY", base.Redis.WorkflowConcurrencyPolicy),
 		},
 		Providers: ProvidersConfig{
 			// Communication & Collaboration
-			Slack:          loadProvider("SLACK"),
-			MicrosoftTeams: loadProvider("MICROSOFT_TEAMS"),
-			Zoom:           loadProvider("ZOOM"),
-			Discord:        loadProvider("DISCORD"),
+			Slack:          loadProvider("SLACK", base.Providers.Slack),
+			MicrosoftTeams: loadProvider("MICROSOFT_TEAMS", base.Providers.MicrosoftTeams),
+			Zoom:           loadProvider("ZOOM", base.Providers.Zoom),
+			Discord:        loadProvider("DISCORD", base.Providers.Discord),
 
 			// Email & Marketing
-			Gmail:     loadProvider("GMAIL"),
-			SendGrid:  loadProvider("SENDGRID"),
-			Mailchimp: loadProvider("MAILCHIMP"),
-			Twilio:    loadProvider("TWILIO"),
+			Gmail:     loadProvider("GMAIL", base.Providers.Gmail),
+			SendGrid:  loadProvider("SENDGRID", base.Providers.SendGrid),
+			Mailchimp: loadProvider("MAILCHIMP", base.Providers.Mailchimp),
+			Twilio:    loadProvider("TWILIO", base.Providers.Twilio),
 
 			// Project Management
-			Jira:    loadProvider("JIRA"),
-			Trello:  loadProvider("TRELLO"),
-			Asana:   loadProvider("ASANA"),
-			Monday:  loadProvider("MONDAY"),
-			Notion:  loadProvider("NOTION"),
-			ClickUp: loadProvider("CLICKUP"),
+			Jira:    loadProvider("JIRA", base.Providers.Jira),
+			Trello:  loadProvider("TRELLO", base.Providers.Trello),
+			Asana:   loadProvider("ASANA", base.Providers.Asana),
+			Monday:  loadProvider("MONDAY", base.Providers.Monday),
+			Notion:  loadProvider("NOTION", base.Providers.Notion),
+			ClickUp: loadProvider("CLICKUP", base.Providers.ClickUp),
 
 			// CRM & Sales
-			Salesforce: loadProvider("SALESFORCE"),
-			HubSpot:    loadProvider("HUBSPOT"),
-			Zendesk:    loadProvider("ZENDESK"),
-			Intercom:   loadProvider("INTERCOM"),
-			Pipedrive:  loadProvider("PIPEDRIVE"),
+			Salesforce: loadProvider("SALESFORCE", base.Providers.Salesforce),
+			HubSpot:    loadProvider("HUBSPOT", base.Providers.HubSpot),
+			Zendesk:    loadProvider("ZENDESK", base.Providers.Zendesk),
+			Intercom:   loadProvider("INTERCOM", base.Providers.Intercom),
+			Pipedrive:  loadProvider("PIPEDRIVE", base.Providers.Pipedrive),
 
 			// Development & Code
-			GitHub:    loadProvider("GITHUB"),
-			GitLab:    loadProvider("GITLAB"),
-			Bitbucket: loadProvider("BITBUCKET"),
+			GitHub:    loadProvider("GITHUB", base.Providers.GitHub),
+			GitLab:    loadProvider("GITLAB", base.Providers.GitLab),
+			Bitbucket: loadProvider("BITBUCKET", base.Providers.Bitbucket),
 
 			// Storage & Documents
-			Dropbox:     loadProvider("DROPBOX"),
-			GoogleDrive: loadProvider("GOOGLE_DRIVE"),
-			OneDrive:    loadProvider("ONEDRIVE"),
-			Box:         loadProvider("BOX"),
+			Dropbox:     loadProvider("DROPBOX", base.Providers.Dropbox),
+			GoogleDrive: loadProvider("GOOGLE_DRIVE", base.Providers.GoogleDrive),
+			OneDrive:    loadProvider("ONEDRIVE", base.Providers.OneDrive),
+			Box:         loadProvider("BOX", base.Providers.Box),
 
 			// Payment & E-commerce
-			Stripe:  loadProvider("STRIPE"),
-			Shopify: loadProvider("SHOPIFY"),
-			PayPal:  loadProvider("PAYPAL"),
-			Square:  loadProvider("SQUARE"),
+			Stripe:  loadProvider("STRIPE", base.Providers.Stripe),
+			Shopify: loadProvider("SHOPIFY", base.Providers.Shopify),
+			PayPal:  loadProvider("PAYPAL", base.Providers.PayPal),
+			Square:  loadProvider("SQUARE", base.Providers.Square),
 
 			// Data & Analytics
-			Airtable:       loadProvider("AIRTABLE"),
-			GoogleSheets:   loadProvider("GOOGLE_SHEETS"),
-			Tableau:        loadProvider("TABLEAU"),
-			MicrosoftExcel: loadProvider("MICROSOFT_EXCEL"),
+			Airtable:       loadProvider("AIRTABLE", base.Providers.Airtable),
+			GoogleSheets:   loadProvider("GOOGLE_SHEETS", base.Providers.GoogleSheets),
+			Tableau:        loadProvider("TABLEAU", base.Providers.Tableau),
+			MicrosoftExcel: loadProvider("MICROSOFT_EXCEL", base.Providers.MicrosoftExcel),
 
 			// Social Media
-			Twitter:   loadProvider("TWITTER"),
-			LinkedIn:  loadProvider("LINKEDIN"),
-			Facebook:  loadProvider("FACEBOOK"),
-			Instagram: loadProvider("INSTAGRAM"),
+			Twitter:   loadProvider("TWITTER", base.Providers.Twitter),
+			LinkedIn:  loadProvider("LINKEDIN", base.Providers.LinkedIn),
+			Facebook:  loadProvider("FACEBOOK", base.Providers.Facebook),
+			Instagram: loadProvider("INSTAGRAM", base.Providers.Instagram),
+
+			// Automation
+			WebhookForward: WebhookForwardConfig{
+				TargetURL:            getEnv("WEBHOOK_FORWARD_URL", base.Providers.WebhookForward.TargetURL),
+				SigningSecret:        getEnv("WEBHOOK_FORWARD_SECRET", base.Providers.WebhookForward.SigningSecret),
+				AllowPrivateNetworks: getEnvBool("WEBHOOK_FORWARD_ALLOW_PRIVATE", base.Providers.WebhookForward.AllowPrivateNetworks),
+				Enabled:              getEnvBool("WEBHOOK_FORWARD_ENABLED", base.Providers.WebhookForward.Enabled),
+			},
 		},
 	}
 
-	if err := cfg.validate(); err != nil {
+	if err := loadProviderTimeouts(&cfg.Server); err != nil {
 		return nil, err
 	}
+	corsMaxAge, err := getEnvDuration("CORS_MAX_AGE", base.Server.CORSMaxAge)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Server.CORSMaxAge = corsMaxAge
+	workflow, err := loadWorkflowConfig(base.Workflow)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Workflow = workflow
 
+	cfg.Features = loadFeatureFlags()
 	return cfg, nil
 }
 
@@ -218,19 +441,159 @@ func (c *Config) validate() error {
 	if c.Server.Env == "production" && c.Auth.JWTSecret == defaultJWTSecret {
 		return errors.New("JWT_SECRET must be set to a strong secret in production; refusing to start with the default value")
 	}
+	if c.Workflow.MaxSteps < 1 {
+		return fmt.Errorf("WORKFLOW_MAX_STEPS must be at least 1, got %d", c.Workflow.MaxSteps)
+	}
+	if c.Workflow.MaxConcurrentRuns < 1 {
+		return fmt.Errorf("WORKFLOW_MAX_RUNS must be at least 1, got %d", c.Workflow.MaxConcurrentRuns)
+	}
+	if c.Workflow.MaxStepParallelism < 1 {
+		return fmt.Errorf("WORKFLOW_MAX_STEP_PARALLELISM must be at least 1, got %d", c.Workflow.MaxStepParallelism)
+	}
+	if c.Workflow.DefaultRetry < 0 {
+		return fmt.Errorf("WORKFLOW_STEP_RETRIES must not be negative, got %d", c.Workflow.DefaultRetry)
+	}
+	if c.Workflow.MaxConcurrentPerUser < 0 {
+		return fmt.Errorf("WORKFLOW_MAX_CONCURRENT must not be negative, got %d", c.Workflow.MaxConcurrentPerUser)
+	}
+	if c.Server.SlowRequestThreshold < 0 {
+		return fmt.Errorf("SLOW_REQUEST_MS must not be negative, got %d", c.Server.SlowRequestThreshold.Milliseconds())
+	}
+	if c.Server.RateLimitRPM < 1 {
+		return fmt.Errorf("RATE_LIMIT_RPM must be at least 1, got %d", c.Server.RateLimitRPM)
+	}
+	for name, policy := range map[string]string{
+		"REDIS_RATE_LIMIT_POLICY":  c.Redis.RateLimitPolicy,
+		"REDIS_IDEMPOTENCY_POLICY": c.Redis.IdempotencyPolicy,
+
+		"REDIS_WORKFLOW_CONCURRENCY_POLICY": c.Redis.WorkflowConcurrencyPolicy,
+	} {
+		if policy != "fail-open" && policy != "fail-closed" {
+			return fmt.Errorf("%s must be \"fail-open\" or \"fail-closed\", got %q", name, policy)
+		}
+	}
 	if c.Auth.JWTSecret == defaultJWTSecret {
 		log.Println("WARNING: JWT_SECRET is set to the default development value. Set JWT_SECRET in your environment before deploying.")
 	}
 	return nil
 }
 
+// Validate checks that every enabled provider is fully configured: OAuth
+// providers need a client ID, a client secret and an absolute redirect URL,
+// and webhook forwarding needs a target URL. The error lists every
+// misconfigured provider, one per line, so all can be fixed in one pass.
+func (c *Config) Validate() error {
+	var errs []error
+	v := reflect.ValueOf(c.Providers)
+	for i := 0; i < v.NumField(); i++ {
+		provider, ok := v.Field(i).Interface().(ProviderConfig)
+		if !ok || !provider.Enabled {
+			continue
+		}
+		if err := provider.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("providers.%s: %w", v.Type().Field(i).Tag.Get("yaml"), err))
+		}
+	}
+	if wf := c.Providers.WebhookForward; wf.Enabled && wf.TargetURL == "" {
+		errs = append(errs, errors.New("providers.webhook_forward: missing target_url"))
+	}
+	return errors.Join(errs...)
+}
+
+// validate reports the OAuth settings p lacks or has malformed.
+func (p ProviderConfig) validate() error {
+	var missing []string
+	for name, value := range map[string]string{"client_id": p.ClientID, "client_secret": p.ClientSecret, "redirect_url": p.RedirectURL} {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	if u, err := url.Parse(p.RedirectURL); err != nil || !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("redirect_url %q is not an absolute URL", p.RedirectURL)
+	}
+	return nil
+}
+
+// providerTimeoutEnvPrefix marks per-provider timeout overrides such as
+// PROVIDER_TIMEOUT_TABLEAU=5m.
+const providerTimeoutEnvPrefix = "PROVIDER_TIMEOUT_"
+
+// loadProviderTimeouts reads PROVIDER_TIMEOUT and the per-provider overrides
+// over the timeouts already in s.
+func loadProviderTimeouts(s *ServerConfig) error {
+	def, err := getEnvDuration("PROVIDER_TIMEOUT", s.ProviderTimeout)
+	if err != nil {
+		return err
+	}
+	s.ProviderTimeout = def
+	if s.ProviderTimeouts == nil {
+		s.ProviderTimeouts = make(map[string]time.Duration)
+	}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		name, ok := strings.CutPrefix(key, providerTimeoutEnvPrefix)
+		if !ok || name == "" {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%s must be a positive duration such as 2m, got %q", key, value)
+		}
+		s.ProviderTimeouts[strings.ToLower(name)] = d
+	}
+	return nil
+}
+
+// maxConcurrentEnvPrefix marks per-plan concurrent workflow limits such as
+// WORKFLOW_MAX_CONCURRENT_PRO=20.
+const maxConcurrentEnvPrefix = "WORKFLOW_MAX_CONCURRENT_"
+
+// loadWorkflowConfig reads the WORKFLOW_* settings over def.
+func loadWorkflowConfig(def WorkflowConfig) (WorkflowConfig, error) {
+	timeout, err := getEnvDuration("WORKFLOW_STEP_TIMEOUT", def.DefaultStepTimeout)
+	if err != nil {
+		return WorkflowConfig{}, err
+	}
+	byPlan := make(map[string]int, len(def.MaxConcurrentByPlan))
+	for plan, n := range def.MaxConcurrentByPlan {
+		byPlan[plan] = n
+	}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		plan, ok := strings.CutPrefix(key, maxConcurrentEnvPrefix)
+		if !ok || plan == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return WorkflowConfig{}, fmt.Errorf("%s must be a non-negative integer, got %q", key, value)
+		}
+		byPlan[strings.ToLower(plan)] = n
+	}
+	return WorkflowConfig{
+		MaxConcurrentRuns:    getEnvInt("WORKFLOW_MAX_RUNS", def.MaxConcurrentRuns),
+		MaxStepParallelism:   getEnvInt("WORKFLOW_MAX_STEP_PARALLELISM", def.MaxStepParallelism),
+		MaxSteps:             getEnvInt("WORKFLOW_MAX_STEPS", def.MaxSteps),
+		DefaultStepTimeout:   timeout,
+		DefaultRetry:         getEnvInt("WORKFLOW_STEP_RETRIES", def.DefaultRetry),
+		MaxConcurrentPerUser: getEnvInt("WORKFLOW_MAX_CONCURRENT", def.MaxConcurrentPerUser),
+		MaxConcurrentByPlan:  byPlan,
+	}, nil
+}
+
 // loadProvider loads a provider configuration from environment variables
-func loadProvider(prefix string) ProviderConfig {
+// over base.
+func loadProvider(prefix string, base ProviderConfig) ProviderConfig {
 	return ProviderConfig{
-		ClientID:     getEnv(prefix+"_CLIENT_ID", ""),
-		ClientSecret: getEnv(prefix+"_CLIENT_SECRET", ""),
-		RedirectURL:  getEnv(prefix+"_REDIRECT_URL", ""),
-		Enabled:      getEnvBool(prefix+"_ENABLED", true),
+		ClientID:     getEnv(prefix+"_CLIENT_ID", base.ClientID),
+		ClientSecret: getEnv(prefix+"_CLIENT_SECRET", base.ClientSecret),
+		RedirectURL:  getEnv(prefix+"_REDIRECT_URL", base.RedirectURL),
+		Enabled:      getEnvBool(prefix+"_ENABLED", base.Enabled),
+		Scopes:       getEnvList(prefix+"_SCOPES", base.Scopes),
 	}
 }
 
@@ -251,6 +614,33 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration such as 30s, got %q", key, value)
+	}
+	return d, nil
+}
+
+// getEnvList reads a comma-separated list, dropping blank entries.
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadProviderTimeouts(t *testing.T) {
+	t.Setenv("PROVIDER_TIMEOUT", "10s")
+	t.Setenv("PROVIDER_TIMEOUT_TABLEAU", "5m")
+
+	var s ServerConfig
+	if err := loadProviderTimeouts(&s); err != nil {
+		t.Fatalf("loadProviderTimeouts error: %v", err)
+	}
+	if s.ProviderTimeout != 10*time.Second {
+		t.Errorf("ProviderTimeout = %v, want 10s", s.ProviderTimeout)
+	}
+	if got := s.ProviderTimeouts["tableau"]; got != 5*time.Minute {
+		t.Errorf("tableau override = %v, want 5m", got)
+	}
+}
+
+func TestLoadProviderTimeouts_Invalid(t *testing.T) {
+	t.Setenv("PROVIDER_TIMEOUT_TABLEAU", "forever")
+
+	var s ServerConfig
+	if err := loadProviderTimeouts(&s); err == nil {
+		t.Fatal("expected error for invalid override")
+	}
+}
+
+func TestLoadWorkflowConfig(t *testing.T) {
+	t.Setenv("WORKFLOW_MAX_RUNS", "4")
+	t.Setenv("WORKFLOW_MAX_STEP_PARALLELISM", "3")
+	t.Setenv("WORKFLOW_STEP_TIMEOUT", "20s")
+	t.Setenv("WORKFLOW_STEP_RETRIES", "2")
+
+	got, err := loadWorkflowConfig(DefaultWorkflowConfig())
+	if err != nil {
+		t.Fatalf("loadWorkflowConfig error: %v", err)
+	}
+	want := DefaultWorkflowConfig()
+	want.MaxConcurrentRuns, want.MaxStepParallelism, want.DefaultStepTimeout, want.DefaultRetry = 4, 3, 20*time.Second, 2
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loadWorkflowConfig = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadWorkflowConfig_MaxConcurrent(t *testing.T) {
+	t.Setenv("WORKFLOW_MAX_CONCURRENT", "3")
+	t.Setenv("WORKFLOW_MAX_CONCURRENT_PRO", "12")
+	t.Setenv("WORKFLOW_MAX_CONCURRENT_TEAM", "0")
+
+	got, err := loadWorkflowConfig(DefaultWorkflowConfig())
+	if err != nil {
+		t.Fatalf("loadWorkflowConfig error: %v", err)
+	}
+	for plan, want := range map[string]int{"free": 3, "pro": 12, "enterprise": 100, "team": 0} {
+		if n := got.MaxConcurrentFor(plan); n != want {
+			t.Errorf("MaxConcurrentFor(%q) = %d, want %d", plan, n, want)
+		}
+	}
+
+	t.Setenv("WORKFLOW_MAX_CONCURRENT_PRO", "-1")
+	if _, err := loadWorkflowConfig(DefaultWorkflowConfig()); err == nil {
+		t.Error("expected error for a negative plan limit")
+	}
+}
+
+func TestLoad_CORSMaxAge(t *testing.T) {
+	t.Setenv("CORS_MAX_AGE", "10m")
+	cfg, err := load(defaultConfig())
+	if err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if cfg.Server.CORSMaxAge != 10*time.Minute {
+		t.Errorf("CORSMaxAge = %v, want 10m", cfg.Server.CORSMaxAge)
+	}
+
+	t.Setenv("CORS_MAX_AGE", "forever")
+	if _, err := load(defaultConfig()); err == nil {
+		t.Error("expected error for invalid CORS_MAX_AGE")
+	}
+}
+
+func TestLoadProvider_Scopes(t *testing.T) {
+	t.Setenv("SLACK_SCOPES", "chat:write, channels:read,")
+
+	got := loadProvider("SLACK", ProviderConfig{Scopes: []string{"im:write"}})
+	if strings.Join(got.Scopes, "|") != "chat:write|channels:read" {
+		t.Errorf("Scopes = %q, want [chat:write channels:read]", got.Scopes)
+	}
+	if got := loadProvider("JIRA", ProviderConfig{Scopes: []string{"read:jira-work"}}); len(got.Scopes) != 1 {
+		t.Errorf("Scopes without env = %q, want the base value", got.Scopes)
+	}
+}
+
+func TestValidate_ProvidersConfigured(t *testing.T) {
+	cfg := &Config{Providers: ProvidersConfig{
+		Slack: ProviderConfig{ClientID: "id", ClientSecret: "secret", RedirectURL: "https://app.example.com/callback/slack", Enabled: true},
+		// Disabled providers are not checked.
+		Jira:           ProviderConfig{Enabled: false},
+		WebhookForward: WebhookForwardConfig{TargetURL: "https://hooks.example.com", Enabled: true},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate error: %v", err)
+	}
+}
+
+func TestValidate_ListsEveryMisconfiguredProvider(t *testing.T) {
+	cfg := &Config{Providers: ProvidersConfig{
+		Slack:          ProviderConfig{ClientID: "id", Enabled: true},
+		Jira:           ProviderConfig{ClientID: "id", ClientSecret: "secret", RedirectURL: "/callback/jira", Enabled: true},
+		GoogleDrive:    ProviderConfig{ClientID: "id", ClientSecret: "secret", RedirectURL: "https://app.example.com/cb", Enabled: true},
+		Box:            ProviderConfig{ClientID: "id", ClientSecret: "secret", RedirectURL: "http//app.example.com/cb", Enabled: true},
+		WebhookForward: WebhookForwardConfig{Enabled: true},
+	}}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted misconfigured providers")
+	}
+	want := []string{
+		"providers.slack: missing client_secret, redirect_url",
+		`providers.jira: redirect_url "/callback/jira" is not an absolute URL`,
+		`providers.box: redirect_url "http//app.example.com/cb" is not an absolute URL`,
+		"providers.webhook_forward: missing target_url",
+	}
+	for _, w := range want {
+		if !strings.Contains(err.Error(), w) {
+			t.Errorf("error %q does not contain %q", err, w)
+		}
+	}
+	if strings.Contains(err.Error(), "google_drive") {
+		t.Errorf("error %q names a valid provider", err)
+	}
+}
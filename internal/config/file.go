@@ -0,0 +1,56 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileNotFoundError is returned by LoadFromFile when the configuration file
+// does not exist, so callers can fall back to Load.
+type FileNotFoundError struct {
+	Path string
+}
+
+func (e *FileNotFoundError) Error() string {
+	return fmt.Sprintf("config file %s not found", e.Path)
+}
+
+// LoadFromFile loads configuration from the YAML file at path, whose keys
+// follow Config in snake_case, e.g. providers.google_drive.client_id.
+// Environment variables override values from the file. Providers are off
+// unless the file or environment enables them; as with Load, the caller
+// decides what to do when Validate fails. Feature flags come from the
+// environment only.
+func LoadFromFile(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, &FileNotFoundError{Path: path}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	base := defaultConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&base); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	cfg, err := load(base)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	SetFeatureFlags(cfg.Features)
+	return cfg, nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile writes content to a temporary YAML file and returns its
+// path.
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFromFile(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  port: "9090"
+  provider_timeout: 45s
+  provider_timeouts:
+    tableau: 5m
+workflow:
+  max_steps: 20
+providers:
+  slack:
+    client_id: slack-id
+    client_secret: slack-secret
+    redirect_url: https://app.example.com/callback/slack
+    enabled: true
+`)
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile error: %v", err)
+	}
+	if cfg.Server.Port != "9090" || cfg.Server.ProviderTimeout != 45*time.Second || cfg.Server.ProviderTimeouts["tableau"] != 5*time.Minute {
+		t.Errorf("Server = %+v", cfg.Server)
+	}
+	if cfg.Workflow.MaxSteps != 20 || cfg.Workflow.MaxConcurrentRuns != DefaultWorkflowConfig().MaxConcurrentRuns {
+		t.Errorf("Workflow = %+v, want max_steps from the file and defaults elsewhere", cfg.Workflow)
+	}
+	if cfg.Database.Host != "localhost" {
+		t.Errorf("Database.Host = %q, want the default", cfg.Database.Host)
+	}
+	if !cfg.Providers.Slack.Enabled || cfg.Providers.Jira.Enabled || cfg.Providers.WebhookForward.Enabled {
+		t.Errorf("only providers enabled in the file should be on: %+v", cfg.Providers)
+	}
+}
+
+func TestLoadFromFile_EnvOverridesFile(t *testing.T) {
+	t.Setenv("PORT", "7070")
+	t.Setenv("SLACK_CLIENT_SECRET", "from-env")
+	t.Setenv("WORKFLOW_MAX_STEPS", "30")
+	path := writeConfigFile(t, `
+server:
+  port: "9090"
+workflow:
+  max_steps: 20
+providers:
+  slack: {client_id: slack-id, client_secret: slack-secret, redirect_url: "https://app.example.com/callback/slack", enabled: true}
+`)
+	cfg, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile error: %v", err)
+	}
+	if cfg.Server.Port != "7070" || cfg.Workflow.MaxSteps != 30 {
+		t.Errorf("port = %q, max steps = %d, want the environment's", cfg.Server.Port, cfg.Workflow.MaxSteps)
+	}
+	if cfg.Providers.Slack.ClientSecret != "from-env" || cfg.Providers.Slack.ClientID != "slack-id" {
+		t.Errorf("Slack = %+v", cfg.Providers.Slack)
+	}
+}
+
+func TestLoadFromFile_MissingFile(t *testing.T) {
+	_, err := LoadFromFile(filepath.Join(t.TempDir(), "absent.yaml"))
+	var notFound *FileNotFoundError
+	if !errors.As(err, &notFound) {
+		t.Fatalf("error = %v, want *FileNotFoundError", err)
+	}
+}
+
+func TestLoadFromFile_ValidationErrors(t *testing.T) {
+	cases := map[string]struct {
+		yaml string
+		want string
+	}{
+		"unknown key": {
+			yaml: "server:\n  prot: \"9090\"\n",
+			want: "prot",
+		},
+		"invalid workflow setting": {
+			yaml: "workflow:\n  max_step_parallelism: 0\n",
+			want: "WORKFLOW_MAX_STEP_PARALLELISM",
+		},
+	}
+	for name, tc := range cases {
+		_, err := LoadFromFile(writeConfigFile(t, tc.yaml))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error = %v, want one mentioning %q", name, err, tc.want)
+		}
+	}
+}
+
+func TestLoadFromFile_LeavesProviderValidationToCaller(t *testing.T) {
+	cases := map[string]struct {
+		yaml string
+		want string
+	}{
+		"enabled provider without secret": {
+			yaml: "providers:\n  jira: {client_id: jira-id, redirect_url: \"https://app.example.com/cb\", enabled: true}\n",
+			want: "providers.jira: missing client_secret",
+		},
+		"webhook forward without target": {
+			yaml: "providers:\n  webhook_forward: {enabled: true}\n",
+			want: "providers.webhook_forward: missing target_url",
+		},
+	}
+	for name, tc := range cases {
+		cfg, err := LoadFromFile(writeConfigFile(t, tc.yaml))
+		if err != nil {
+			t.Fatalf("%s: LoadFromFile error: %v", name, err)
+		}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: Validate error = %v, want one mentioning %q", name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// featureFlagEnvPrefix marks per-flag overrides such as FEATURE_DRY_RUN=true.
+const featureFlagEnvPrefix = "FEATURE_"
+
+// FlagRateLimiting gates the per-client rate limiter. It is on by default;
+// disabling it relies on an upstream proxy to limit request rates.
+const FlagRateLimiting = "rate_limiting"
+
+// flagDefaults lists flags that are on unless explicitly disabled. Flags not
+// listed here default to off.
+var flagDefaults = map[string]bool{
+	FlagRateLimiting: true,
+}
+
+// FeatureFlags holds named on/off toggles so features can be rolled out or
+// back without code changes.
+type FeatureFlags struct {
+	values map[string]bool
+}
+
+// normalizeFlag lower-cases name and maps '-' to '_' so "Dry-Run" and
+// "dry_run" name the same flag.
+func normalizeFlag(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+}
+
+func validFlagName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseFeatureFlags parses a comma-separated flag list as found in
+// FEATURE_FLAGS. Each entry is "name" (on), "-name" (off) or "name=<bool>".
+func ParseFeatureFlags(spec string) (FeatureFlags, error) {
+	f := FeatureFlags{values: make(map[string]bool)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, on, err := parseFlagEntry(entry)
+		if err != nil {
+			return FeatureFlags{}, err
+		}
+		f.values[name] = on
+	}
+	return f, nil
+}
+
+// parseFlagEntry parses one FEATURE_FLAGS entry into a normalized name and
+// its value.
+func parseFlagEntry(entry string) (string, bool, error) {
+	name, on := entry, true
+	if rest, ok := strings.CutPrefix(entry, "-"); ok {
+		name, on = rest, false
+	} else if n, v, ok := strings.Cut(entry, "="); ok {
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return "", false, fmt.Errorf("feature flag %q: invalid value %q", n, v)
+		}
+		name, on = n, b
+	}
+	name = normalizeFlag(name)
+	if !validFlagName(name) {
+		return "", false, fmt.Errorf("invalid feature flag name %q", entry)
+	}
+	return name, on, nil
+}
+
+// loadFeatureFlags reads FEATURE_FLAGS and then applies FEATURE_<NAME>
+// overrides from the environment. Malformed entries are logged and ignored
+// rather than failing startup, leaving those flags at their defaults.
+func loadFeatureFlags() FeatureFlags {
+	f := FeatureFlags{values: make(map[string]bool)}
+	for _, entry := range strings.Split(getEnv("FEATURE_FLAGS", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, on, err := parseFlagEntry(entry)
+		if err != nil {
+			log.Printf("WARNING: ignoring FEATURE_FLAGS entry: %v", err)
+			continue
+		}
+		f.values[name] = on
+	}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		name, ok := strings.CutPrefix(key, featureFlagEnvPrefix)
+		if !ok || key == "FEATURE_FLAGS" {
+			continue
+		}
+		on, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Printf("WARNING: ignoring %s: must be a boolean, got %q", key, value)
+			continue
+		}
+		f.values[normalizeFlag(name)] = on
+	}
+	return f
+}
+
+// Enabled reports whether the named flag is on, falling back to its default
+// when it was not set.
+func (f FeatureFlags) Enabled(name string) bool {
+	name = normalizeFlag(name)
+	if on, ok := f.values[name]; ok {
+		return on
+	}
+	return flagDefaults[name]
+}
+
+// Active returns the names of all enabled flags, sorted.
+func (f FeatureFlags) Active() []string {
+	seen := make(map[string]bool)
+	for name := range flagDefaults {
+		seen[name] = true
+	}
+	for name := range f.values {
+		seen[name] = true
+	}
+	active := []string{}
+	for name := range seen {
+		if f.Enabled(name) {
+			active = append(active, name)
+		}
+	}
+	sort.Strings(active)
+	return active
+}
+
+var (
+	flagsMu sync.RWMutex
+	flags   FeatureFlags
+)
+
+// SetFeatureFlags installs f as the process-wide flags read by FlagEnabled.
+// Load and LoadFromFile call it; tests may call it directly.
+func SetFeatureFlags(f FeatureFlags) {
+	flagsMu.Lock()
+	flags = f
+	flagsMu.Unlock()
+}
+
+// FlagEnabled reports whether the named process-wide feature flag is on,
+// falling back to its default before flags are loaded or when it is unset.
+func FlagEnabled(name string) bool {
+	flagsMu.RLock()
+	defer flagsMu.RUnlock()
+	return flags.Enabled(name)
+}
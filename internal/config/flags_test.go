@@ -0,0 +1,86 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFeatureFlags(t *testing.T) {
+	f, err := ParseFeatureFlags(" tracing, -dry_run, Rate-Limiting=true, sandbox=false ")
+	if err != nil {
+		t.Fatalf("ParseFeatureFlags error: %v", err)
+	}
+	for name, want := range map[string]bool{
+		"tracing":       true,
+		"dry_run":       false,
+		"rate_limiting": true,
+		"RATE-LIMITING": true,
+		"sandbox":       false,
+	} {
+		if got := f.Enabled(name); got != want {
+			t.Errorf("Enabled(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if got := f.Active(); !reflect.DeepEqual(got, []string{"rate_limiting", "tracing"}) {
+		t.Errorf("Active() = %v", got)
+	}
+
+	for _, bad := range []string{"bad name", "flag=maybe", "-"} {
+		if _, err := ParseFeatureFlags(bad); err == nil {
+			t.Errorf("ParseFeatureFlags(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestLoadFeatureFlags_EnvOverride(t *testing.T) {
+	t.Setenv("FEATURE_FLAGS", "tracing,dry_run")
+	t.Setenv("FEATURE_DRY_RUN", "false")
+	f := loadFeatureFlags()
+	if !f.Enabled("tracing") || f.Enabled("dry_run") {
+		t.Errorf("expected tracing on and dry_run overridden off, got %v", f.Active())
+	}
+}
+
+func TestLoadFeatureFlags_IgnoresMalformedValues(t *testing.T) {
+	t.Setenv("FEATURE_FLAGS", "tracing,bad name,sandbox=maybe")
+	t.Setenv("FEATURE_DRY_RUN", "sometimes")
+	f := loadFeatureFlags()
+	if got := f.Active(); !reflect.DeepEqual(got, []string{"rate_limiting", "tracing"}) {
+		t.Errorf("Active() = %v, want only tracing and the default rate_limiting", got)
+	}
+}
+
+func TestFeatureFlags_DefaultsWhenUnset(t *testing.T) {
+	var f FeatureFlags
+	if f.Enabled("never_set") {
+		t.Error("unset flag without a default should be off")
+	}
+
+	flagDefaults["default_on"] = true
+	t.Cleanup(func() { delete(flagDefaults, "default_on") })
+	if !f.Enabled("default_on") {
+		t.Error("unset flag should fall back to its default")
+	}
+
+	f, _ = ParseFeatureFlags("-default_on")
+	if f.Enabled("default_on") {
+		t.Error("explicit setting should override the default")
+	}
+}
+
+func TestFlagEnabled(t *testing.T) {
+	t.Cleanup(func() { SetFeatureFlags(FeatureFlags{}) })
+
+	SetFeatureFlags(FeatureFlags{})
+	if !FlagEnabled(FlagRateLimiting) || FlagEnabled("never_set") {
+		t.Errorf("unset flags: rate_limiting=%v never_set=%v, want their defaults true and false",
+			FlagEnabled(FlagRateLimiting), FlagEnabled("never_set"))
+	}
+
+	f, _ := ParseFeatureFlags("-rate-limiting,tracing")
+	SetFeatureFlags(f)
+	if FlagEnabled(FlagRateLimiting) || !FlagEnabled("tracing") {
+		t.Errorf("FlagEnabled after SetFeatureFlags: rate_limiting=%v tracing=%v, want false and true",
+			FlagEnabled(FlagRateLimiting), FlagEnabled("tracing"))
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// redactedValue replaces a configured secret in Redacted output.
+const redactedValue = "[REDACTED]"
+
+// Redacted returns c as nested maps keyed by yaml setting names, for
+// diagnostics. Settings tagged `secret:"true"` read "[REDACTED]" when set and
+// stay empty when not, so it still shows which are configured. Webhook URLs
+// are tagged too, since many services embed the credential in the URL. Durations are formatted as
+// strings and "features" lists the enabled feature flags.
+func (c *Config) Redacted() map[string]interface{} {
+	out := redactValue(reflect.ValueOf(*c)).(map[string]interface{})
+	out["features"] = c.Features.Active()
+	return out
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// redactValue converts v for Redacted, masking the secret fields of structs.
+func redactValue(v reflect.Value) interface{} {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if !field.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			switch {
+			case field.Tag.Get("secret") != "true":
+				out[name] = redactValue(v.Field(i))
+			case v.Field(i).IsZero():
+				out[name] = ""
+			default:
+				out[name] = redactedValue
+			}
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = redactValue(iter.Value())
+		}
+		return out
+	case reflect.Slice:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = redactValue(v.Index(i))
+		}
+		return out
+	}
+	return v.Interface()
+}
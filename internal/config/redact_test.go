@@ -0,0 +1,57 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigRedacted_MasksSecrets(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Auth.JWTSecret = "jwt-very-secret"
+	cfg.Auth.GitHubOAuth = OAuthConfig{ClientID: "gh-client", ClientSecret: "gh-oauth-secret", Enabled: true}
+	cfg.Database.Password = "pg-secret"
+	cfg.Redis.Password = "redis-secret"
+	cfg.Events.WebhookURL = "https://hooks.example.com/services/T0/B0/abc"
+	cfg.Providers.Slack.ClientID = "slack-client"
+	cfg.Providers.Slack.ClientSecret = "slack-secret"
+	cfg.Providers.Slack.Enabled = true
+	cfg.Providers.Jira.Enabled = false
+	cfg.Providers.WebhookForward.SigningSecret = "whsec-secret"
+	cfg.Providers.WebhookForward.TargetURL = "https://forward.example.com/hook?token=tgt-secret"
+	cfg.Server.ProviderTimeouts = map[string]time.Duration{"tableau": 5 * time.Minute}
+	flags, err := ParseFeatureFlags("tracing,-dry_run")
+	if err != nil {
+		t.Fatalf("ParseFeatureFlags error: %v", err)
+	}
+	cfg.Features = flags
+
+	out := cfg.Redacted()
+	data, err := json.Marshal(out)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	for _, secret := range []string{"jwt-very-secret", "gh-oauth-secret", "pg-secret", "redis-secret", "hooks.example.com", "slack-secret", "whsec-secret", "tgt-secret"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("redacted config contains %q: %s", secret, data)
+		}
+	}
+
+	providers := out["providers"].(map[string]interface{})
+	slack := providers["slack"].(map[string]interface{})
+	if slack["client_id"] != "slack-client" || slack["client_secret"] != redactedValue || slack["enabled"] != true {
+		t.Errorf("slack = %v", slack)
+	}
+	if jira := providers["jira"].(map[string]interface{}); jira["enabled"] != false || jira["client_secret"] != "" {
+		t.Errorf("jira = %v, want disabled with no secret set", jira)
+	}
+	server := out["server"].(map[string]interface{})
+	if timeouts := server["provider_timeouts"].(map[string]interface{}); timeouts["tableau"] != "5m0s" {
+		t.Errorf("provider_timeouts = %v", timeouts)
+	}
+	if got := out["features"]; !reflect.DeepEqual(got, []string{"rate_limiting", "tracing"}) {
+		t.Errorf("features = %v, want [rate_limiting tracing]", got)
+	}
+}
@@ -3,6 +3,8 @@ package consent
 import (
 	"context"
 	"errors"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,6 +25,7 @@ type Consent struct {
 	UserID    uuid.UUID     `json:"user_id" db:"user_id"`
 	Provider  string        `json:"provider" db:"provider"`
 	Purpose   string        `json:"purpose" db:"purpose"`
+	Scopes    []string      `json:"scopes" db:"scopes"`
 	Status    ConsentStatus `json:"status" db:"status"`
 	GrantedAt *time.Time    `json:"granted_at,omitempty" db:"granted_at"`
 	RevokedAt *time.Time    `json:"revoked_at,omitempty" db:"revoked_at"`
@@ -34,48 +37,132 @@ type Consent struct {
 // Manager handles consent operations
 type Manager struct {
 	// In production, add database connection here
+	mu       sync.RWMutex
+	consents map[uuid.UUID]*Consent
 }
 
 // NewManager creates a new consent manager
 func NewManager() *Manager {
-	return &Manager{}
+	return &Manager{consents: make(map[uuid.UUID]*Consent)}
 }
 
 // Grant grants consent for a user to share data with a provider
 func (m *Manager) Grant(ctx context.Context, userID uuid.UUID, provider, purpose string) (*Consent, error) {
+	return m.GrantScopes(ctx, userID, provider, purpose, nil, nil)
+}
+
+// GrantScopes grants consent for the given provider scopes, optionally
+// expiring at expiresAt. A new grant replaces the user's previous consent for
+// the same provider.
+func (m *Manager) GrantScopes(ctx context.Context, userID uuid.UUID, provider, purpose string, scopes []string, expiresAt *time.Time) (*Consent, error) {
 	now := time.Now()
 	consent := &Consent{
 		ID:        uuid.New(),
 		UserID:    userID,
 		Provider:  provider,
 		Purpose:   purpose,
+		Scopes:    append([]string(nil), scopes...),
 		Status:    ConsentGranted,
 		GrantedAt: &now,
+		ExpiresAt: expiresAt,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
 
 	// TODO: Store in database
-	return consent, nil
+	m.mu.Lock()
+	for id, c := range m.consents {
+		if c.UserID == userID && c.Provider == provider {
+			delete(m.consents, id)
+		}
+	}
+	m.consents[consent.ID] = consent
+	m.mu.Unlock()
+
+	out := *consent
+	return &out, nil
 }
 
 // Revoke revokes a user's consent
 func (m *Manager) Revoke(ctx context.Context, consentID uuid.UUID) error {
 	// TODO: Update database
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.consents[consentID]; ok {
+		now := time.Now()
+		c.Status = ConsentRevoked
+		c.RevokedAt = &now
+		c.UpdatedAt = now
+	}
 	return nil
 }
 
+// ErrConsentNotGranted is returned when a user has not granted, or has
+// revoked, consent for a provider.
+var ErrConsentNotGranted = errors.New("consent not granted for this provider")
+
 // Check checks if consent is valid for a user and provider
 func (m *Manager) Check(ctx context.Context, userID uuid.UUID, provider string) (bool, error) {
+	// A recorded consent that was revoked or has expired is always honoured.
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, c := range m.consents {
+		if c.UserID != userID || c.Provider != provider {
+			continue
+		}
+		if c.Status != ConsentGranted || (c.ExpiresAt != nil && time.Now().After(*c.ExpiresAt)) {
+			return false, nil
+		}
+		return true, nil
+	}
 	// TODO: Query database for active consent
-	// For now, return true as mock
+	// For now, users with no recorded consent are allowed as mock
 	return true, nil
 }
 
-// List lists all consents for a user
+// List lists all consents for a user, ordered by provider
 func (m *Manager) List(ctx context.Context, userID uuid.UUID) ([]Consent, error) {
 	// TODO: Query database
-	return []Consent{}, nil
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	consents := []Consent{}
+	for _, c := range m.consents {
+		if c.UserID == userID {
+			consents = append(consents, *c)
+		}
+	}
+	sort.Slice(consents, func(i, j int) bool { return consents[i].Provider < consents[j].Provider })
+	return consents, nil
+}
+
+// providerScopes lists the OAuth scopes the platform can request per provider.
+var providerScopes = map[string][]string{
+	"slack":  {"chat:write", "users:read", "users:read.email", "im:write", "channels:read"},
+	"gmail":  {"https://www.googleapis.com/auth/gmail.send", "https://www.googleapis.com/auth/gmail.readonly"},
+	"jira":   {"read:jira-work", "write:jira-work"},
+	"github": {"repo", "read:user"},
+}
+
+// RequestableScopes returns the scopes the platform may request for provider
+// that are not already in granted.
+func RequestableScopes(provider string, granted []string) []string {
+	have := make(map[string]bool, len(granted))
+	for _, s := range granted {
+		have[s] = true
+	}
+	requestable := []string{}
+	for _, s := range providerScopes[provider] {
+		if !have[s] {
+			requestable = append(requestable, s)
+		}
+	}
+	return requestable
+}
+
+// ProviderScopes returns the scopes the platform requests for provider.
+func ProviderScopes(provider string) []string {
+	return RequestableScopes(provider, nil)
 }
 
 // IntegrationConsentRequired checks if an integration requires consent before execution
@@ -101,7 +188,7 @@ func (m *Manager) ValidateConsent(ctx context.Context, userID uuid.UUID, provide
 	}
 
 	if !valid {
-		return errors.New("consent not granted for this provider")
+		return ErrConsentNotGranted
 	}
 
 	return nil
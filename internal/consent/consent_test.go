@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/google/uuid"
@@ -107,6 +108,19 @@ func TestCheck_AnyProvider_ReturnsTrue(t *testing.T) {
 	}
 }
 
+func TestValidateConsent_Revoked_ReturnsErrConsentNotGranted(t *testing.T) {
+	m := NewManager()
+	userID := uuid.New()
+	c, _ := m.Grant(context.Background(), userID, "slack", "integration")
+	if err := m.ValidateConsent(context.Background(), userID, "slack"); err != nil {
+		t.Fatalf("granted consent should pass: %v", err)
+	}
+	_ = m.Revoke(context.Background(), c.ID)
+	if err := m.ValidateConsent(context.Background(), userID, "slack"); !errors.Is(err, ErrConsentNotGranted) {
+		t.Errorf("expected ErrConsentNotGranted after revoke, got %v", err)
+	}
+}
+
 func TestList_EmptyByDefault(t *testing.T) {
 	m := NewManager()
 	consents, err := m.List(context.Background(), uuid.New())
@@ -172,3 +186,40 @@ func TestConsentStatusValues(t *testing.T) {
 		t.Error("Granted and Pending must differ")
 	}
 }
+
+func TestGrantScopes_ReplacesPreviousGrantForProvider(t *testing.T) {
+	m := NewManager()
+	uid := uuid.New()
+	_, _ = m.GrantScopes(context.Background(), uid, "slack", "integration", []string{"chat:write"}, nil)
+	_, _ = m.GrantScopes(context.Background(), uid, "slack", "integration", []string{"chat:write", "im:write"}, nil)
+
+	consents, _ := m.List(context.Background(), uid)
+	if len(consents) != 1 {
+		t.Fatalf("expected one consent per provider, got %d", len(consents))
+	}
+	if len(consents[0].Scopes) != 2 {
+		t.Errorf("expected latest scopes, got %v", consents[0].Scopes)
+	}
+}
+
+func TestRevoke_MarksConsentRevoked(t *testing.T) {
+	m := NewManager()
+	uid := uuid.New()
+	c, _ := m.Grant(context.Background(), uid, "slack", "integration")
+	_ = m.Revoke(context.Background(), c.ID)
+
+	consents, _ := m.List(context.Background(), uid)
+	if len(consents) != 1 || consents[0].Status != ConsentRevoked || consents[0].RevokedAt == nil {
+		t.Errorf("expected revoked consent, got %+v", consents)
+	}
+}
+
+func TestRequestableScopes_ExcludesGranted(t *testing.T) {
+	got := RequestableScopes("jira", []string{"read:jira-work"})
+	if len(got) != 1 || got[0] != "write:jira-work" {
+		t.Errorf("expected [write:jira-work], got %v", got)
+	}
+	if got := RequestableScopes("unknown", nil); len(got) != 0 {
+		t.Errorf("unknown provider should have no requestable scopes, got %v", got)
+	}
+}
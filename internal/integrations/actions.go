@@ -0,0 +1,144 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FieldType is the expected JSON type of an action payload field.
+type FieldType string
+
+const (
+	FieldString  FieldType = "string"
+	FieldNumber  FieldType = "number"
+	FieldBoolean FieldType = "boolean"
+	FieldObject  FieldType = "object"
+	FieldArray   FieldType = "array"
+)
+
+// validFieldTypes is the set of FieldType values accepted in an ActionSpec.
+var validFieldTypes = map[FieldType]bool{
+	FieldString:  true,
+	FieldNumber:  true,
+	FieldBoolean: true,
+	FieldObject:  true,
+	FieldArray:   true,
+}
+
+// ActionField describes a single payload field accepted by an action.
+type ActionField struct {
+	Name     string    `json:"name"`
+	Type     FieldType `json:"type"`
+	Required bool      `json:"required"`
+}
+
+// ActionSpec describes an action a provider supports and the payload it expects.
+type ActionSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Idempotent marks actions that are safe to repeat, such as reads, so a
+	// call that timed out may be retried; see RetrySafe.
+	Idempotent bool          `json:"idempotent,omitempty"`
+	Fields     []ActionField `json:"fields,omitempty"`
+}
+
+// ActionLister is implemented by providers that describe their supported actions.
+type ActionLister interface {
+	ListActions() []ActionSpec
+}
+
+// ValidateActions checks that every action a provider advertises has a
+// non-empty, unique name and that each of its fields has a name and a known
+// type. Providers that do not implement ActionLister are always valid.
+func ValidateActions(p Provider) error {
+	lister, ok := p.(ActionLister)
+	if !ok {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for i, action := range lister.ListActions() {
+		if action.Name == "" {
+			return fmt.Errorf("provider %s: action %d has an empty name", p.Name(), i)
+		}
+		if seen[action.Name] {
+			return fmt.Errorf("provider %s: duplicate action %q", p.Name(), action.Name)
+		}
+		seen[action.Name] = true
+
+		for j, field := range action.Fields {
+			if field.Name == "" {
+				return fmt.Errorf("provider %s: action %q field %d has an empty name", p.Name(), action.Name, j)
+			}
+			if !validFieldTypes[field.Type] {
+				return fmt.Errorf("provider %s: action %q field %q has invalid type %q", p.Name(), action.Name, field.Name, field.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// HelpAction is a built-in action every provider answers with its action list.
+const HelpAction = "help"
+
+// ErrUnknownAction is wrapped by errors for actions a provider does not support.
+var ErrUnknownAction = errors.New("unknown action")
+
+// ActionsOf returns the actions p advertises, or nil if it does not list them.
+func ActionsOf(p Provider) []ActionSpec {
+	if lister, ok := p.(ActionLister); ok {
+		return lister.ListActions()
+	}
+	return nil
+}
+
+// RetrySafe reports whether action may be repeated after an attempt whose
+// outcome is unknown, such as a timeout: the action is marked Idempotent, or
+// p sends an idempotency key with it so the provider drops the duplicate.
+func RetrySafe(ctx context.Context, p Provider, action string, payload map[string]interface{}) bool {
+	for _, spec := range ActionsOf(p) {
+		if spec.Name == action && spec.Idempotent {
+			return true
+		}
+	}
+	if _, ok := p.(IdempotencyKeySender); !ok {
+		return false
+	}
+	key, _ := payload["idempotency_key"].(string)
+	return key != "" || IdempotencyKey(ctx) != ""
+}
+
+// unknownAction builds the error for an unsupported action, naming the
+// provider's valid actions so clients can self-correct.
+func unknownAction(p Provider, action string) error {
+	specs := ActionsOf(p)
+	if len(specs) == 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownAction, action)
+	}
+	names := make([]string, len(specs))
+	for i, s := range specs {
+		names[i] = s.Name
+	}
+	return fmt.Errorf("%w: %s (available actions: %s)", ErrUnknownAction, action, strings.Join(names, ", "))
+}
+
+// ExecuteAction runs action on p, bounded by the provider's ActionTimeout,
+// answering the built-in HelpAction with the provider's action list instead
+// of calling the provider.
+func ExecuteAction(ctx context.Context, p Provider, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
+	if action == HelpAction {
+		actions := ActionsOf(p)
+		if actions == nil {
+			actions = []ActionSpec{}
+		}
+		return map[string]interface{}{
+			"provider": p.Name(),
+			"actions":  actions,
+		}, nil
+	}
+	ctx, cancel := withActionTimeout(ctx, p.Name())
+	defer cancel()
+	return p.Execute(ctx, token, action, payload)
+}
@@ -0,0 +1,122 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// specProvider is a minimal provider that advertises a fixed action list.
+type specProvider struct {
+	actions []ActionSpec
+}
+
+func (p *specProvider) Name() string                                         { return "spec_test" }
+func (p *specProvider) GetAuthURL(state string) string                       { return "" }
+func (p *specProvider) ExchangeCode(context.Context, string) (*Token, error) { return nil, nil }
+func (p *specProvider) Execute(context.Context, *Token, string, map[string]interface{}) (interface{}, error) {
+	return nil, nil
+}
+func (p *specProvider) ListActions() []ActionSpec { return p.actions }
+
+func TestRegisterProvider_ValidSpec_Registered(t *testing.T) {
+	resetRegistry()
+	p := &specProvider{actions: []ActionSpec{
+		{Name: "do", Fields: []ActionField{{Name: "id", Type: FieldString, Required: true}}},
+	}}
+	if err := RegisterProvider(p); err != nil {
+		t.Fatalf("RegisterProvider error: %v", err)
+	}
+	if _, err := GetProvider("spec_test"); err != nil {
+		t.Errorf("provider should be registered: %v", err)
+	}
+}
+
+func TestRegisterProvider_MalformedSpec_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions []ActionSpec
+		wantErr string
+	}{
+		{"empty action name", []ActionSpec{{Name: ""}}, "empty name"},
+		{"duplicate action", []ActionSpec{{Name: "do"}, {Name: "do"}}, "duplicate action"},
+		{"empty field name", []ActionSpec{{Name: "do", Fields: []ActionField{{Type: FieldString}}}}, "field 0 has an empty name"},
+		{"unknown field type", []ActionSpec{{Name: "do", Fields: []ActionField{{Name: "id", Type: "uuid"}}}}, `invalid type "uuid"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetRegistry()
+			err := RegisterProvider(&specProvider{actions: tt.actions})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if _, err := GetProvider("spec_test"); err == nil {
+				t.Error("misconfigured provider must not be registered")
+			}
+		})
+	}
+}
+
+func TestRegisterProvider_BuiltinSpecsValid(t *testing.T) {
+	for _, p := range []Provider{&SlackProvider{}, &GmailProvider{}, &GitHubProvider{}, &JiraProvider{}, &LinkedInProvider{}} {
+		if err := ValidateActions(p); err != nil {
+			t.Errorf("%s: %v", p.Name(), err)
+		}
+	}
+}
+
+func TestUnknownAction_ErrorListsValidActions(t *testing.T) {
+	_, err := (&SlackProvider{}).Execute(context.Background(), &Token{AccessToken: "x"}, "send_mesage", nil)
+	if !errors.Is(err, ErrUnknownAction) {
+		t.Fatalf("expected ErrUnknownAction, got %v", err)
+	}
+	for _, name := range []string{"send_message", "lookup_user_by_email", "open_dm"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error should list %q, got %q", name, err.Error())
+		}
+	}
+}
+
+func TestExecuteAction_Help_ReturnsActionList(t *testing.T) {
+	res, err := ExecuteAction(context.Background(), &JiraProvider{}, nil, HelpAction, nil)
+	if err != nil {
+		t.Fatalf("help error: %v", err)
+	}
+	out := res.(map[string]interface{})
+	actions, ok := out["actions"].([]ActionSpec)
+	if !ok || len(actions) != 1 || actions[0].Name != "create_issue" {
+		t.Errorf("expected jira action list, got %v", out["actions"])
+	}
+	if out["provider"] != "jira" {
+		t.Errorf("expected provider jira, got %v", out["provider"])
+	}
+}
+
+func TestExecuteAction_Help_ProviderWithoutSpecs_EmptyList(t *testing.T) {
+	res, err := ExecuteAction(context.Background(), &DiscordProvider{}, nil, HelpAction, nil)
+	if err != nil {
+		t.Fatalf("help error: %v", err)
+	}
+	if actions := res.(map[string]interface{})["actions"].([]ActionSpec); len(actions) != 0 {
+		t.Errorf("expected empty list, got %v", actions)
+	}
+}
+
+func TestRetrySafe(t *testing.T) {
+	p := &specProvider{actions: []ActionSpec{{Name: "list", Idempotent: true}, {Name: "create"}}}
+	keyed := WithIdempotencyKey(context.Background(), "run-1/step-0")
+	if !RetrySafe(context.Background(), p, "list", nil) {
+		t.Error("an idempotent action should be retry-safe")
+	}
+	if RetrySafe(keyed, p, "create", nil) {
+		t.Error("a write should not be retry-safe when the provider ignores idempotency keys")
+	}
+	stripe := &StripeProvider{}
+	if RetrySafe(context.Background(), stripe, "create_refund", nil) {
+		t.Error("a write without an idempotency key should not be retry-safe")
+	}
+	if !RetrySafe(keyed, stripe, "create_refund", nil) {
+		t.Error("a write sent with an idempotency key should be retry-safe")
+	}
+}
@@ -0,0 +1,169 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// airtableHTTPClient is shared by Airtable API calls. Requests are bounded by
+// the provider's ActionTimeout.
+var airtableHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+type (
+	userIDKey      struct{}
+	workspaceIDKey struct{}
+)
+
+// WithUserID records the authenticated user a provider call is made for, so
+// providers that keep per-user state, such as Airtable change watermarks,
+// can key it.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+func userIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(userIDKey{}).(string)
+	return id
+}
+
+// WithWorkspaceID records the workspace a provider call is made in, alongside
+// the user from WithUserID.
+func WithWorkspaceID(ctx context.Context, workspaceID string) context.Context {
+	return context.WithValue(ctx, workspaceIDKey{}, workspaceID)
+}
+
+func workspaceIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(workspaceIDKey{}).(string)
+	return id
+}
+
+// airtableRecord is a table record as returned by list-records.
+type airtableRecord struct {
+	ID          string                 `json:"id"`
+	CreatedTime string                 `json:"createdTime"`
+	Fields      map[string]interface{} `json:"fields"`
+}
+
+// airtableChanges is the reply to list_changes: the usual list envelope of
+// changed records, all in one page, plus Watermark, where the next poll of
+// the table starts.
+type airtableChanges struct {
+	ListPage
+	Watermark time.Time `json:"watermark"`
+}
+
+// airtableClockSlack is subtracted from Airtable's Date header, which has
+// whole-second precision and is stamped after the records were read, so no
+// change in between is skipped. Records modified within it may be returned
+// by two polls.
+const airtableClockSlack = time.Second
+
+// airtableWatermarkKey keys the list_changes mark of a table for a user in
+// one of their workspaces. The user comes first: the workspace is taken from
+// a client header, so on its own it would let callers share, or move, each
+// other's marks.
+func airtableWatermarkKey(userID, workspaceID, baseID, table string) string {
+	return strings.Join([]string{string(IntegrationAirtable), userID, workspaceID, baseID, table}, "/")
+}
+
+// airtableModifiedSince is a filterByFormula matching records modified after
+// since.
+func airtableModifiedSince(since time.Time) string {
+	return fmt.Sprintf("IS_AFTER(LAST_MODIFIED_TIME(), '%s')", since.UTC().Format("2006-01-02T15:04:05.000Z"))
+}
+
+// listChanges returns the records of table modified since the user's last
+// poll of it in the acting workspace, or since the given time when set. The first poll of a
+// table returns every record. The watermark is taken from Airtable's clock,
+// not ours, and advances only when the poll succeeds.
+func (p *AirtableProvider) listChanges(ctx context.Context, token *Token, baseID, table string, since time.Time) (*airtableChanges, error) {
+	key := airtableWatermarkKey(userIDFrom(ctx), workspaceIDFrom(ctx), baseID, table)
+	marks := p.watermarkStore()
+	if since.IsZero() {
+		mark, err := marks.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		since = mark
+	}
+
+	base := p.APIBaseURL
+	if base == "" {
+		base = "https://api.airtable.com"
+	}
+	endpoint := base + "/v0/" + url.PathEscape(baseID) + "/" + url.PathEscape(table)
+	var snapshot time.Time
+	records, err := fetchPages(ctx, func(ctx context.Context, offset string) ([]airtableRecord, string, error) {
+		query := url.Values{}
+		if !since.IsZero() {
+			query.Set("filterByFormula", airtableModifiedSince(since))
+		}
+		if offset != "" {
+			query.Set("offset", offset)
+		}
+		var page struct {
+			Records []airtableRecord `json:"records"`
+			Offset  string           `json:"offset"`
+		}
+		header, err := p.airtableGet(ctx, token, endpoint+"?"+query.Encode(), &page)
+		if err != nil {
+			return nil, "", err
+		}
+		if offset == "" {
+			snapshot, err = http.ParseTime(header.Get("Date"))
+			if err != nil {
+				return nil, "", fmt.Errorf("airtable list records: no usable Date header: %w", err)
+			}
+		}
+		return page.Records, page.Offset, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	mark := snapshot.Add(-airtableClockSlack).UTC()
+	if err := marks.Advance(ctx, key, mark); err != nil {
+		return nil, err
+	}
+	return &airtableChanges{ListPage: *newListPage(records, ""), Watermark: mark}, nil
+}
+
+// watermarkStore returns p.Watermarks, or the provider's own in-memory store
+// when none is set.
+func (p *AirtableProvider) watermarkStore() WatermarkStore {
+	if p.Watermarks != nil {
+		return p.Watermarks
+	}
+	p.memoryMarksOnce.Do(func() { p.memoryMarks = NewMemoryWatermarkStore() })
+	return p.memoryMarks
+}
+
+// airtableGet sends a GET to the Airtable API, decodes the reply into out and
+// returns the response headers.
+func (p *AirtableProvider) airtableGet(ctx context.Context, token *Token, endpoint string, out interface{}) (http.Header, error) {
+	ctx, cancel := withActionTimeout(ctx, p.Name())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+	resp, err := airtableHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("airtable list records: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(IntegrationAirtable, resp); err != nil {
+		return nil, fmt.Errorf("airtable list records: %w", err)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("failed to decode Airtable response: %w", err)
+	}
+	return resp.Header, nil
+}
@@ -0,0 +1,208 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAirtable serves a table whose records carry a last-modified time,
+// honouring the IS_AFTER(LAST_MODIFIED_TIME(), ...) filter list_changes sends.
+// Like Airtable, it keeps modification times to the millisecond and stamps
+// each reply with a Date header from its own clock, now.
+type fakeAirtable struct {
+	mu       sync.Mutex
+	now      time.Time
+	modified map[string]time.Time
+}
+
+func newFakeAirtable() *fakeAirtable {
+	return &fakeAirtable{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), modified: map[string]time.Time{}}
+}
+
+func (f *fakeAirtable) touch(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.modified[id] = f.now
+}
+
+func (f *fakeAirtable) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func (f *fakeAirtable) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var since time.Time
+	if formula := r.URL.Query().Get("filterByFormula"); formula != "" {
+		start := strings.Index(formula, "'")
+		end := strings.LastIndex(formula, "'")
+		parsed, err := time.Parse(time.RFC3339, formula[start+1:end])
+		if err != nil {
+			http.Error(w, "bad formula", http.StatusUnprocessableEntity)
+			return
+		}
+		since = parsed
+	}
+	records := []airtableRecord{}
+	for id, at := range f.modified {
+		if at.After(since) {
+			records = append(records, airtableRecord{ID: id, Fields: map[string]interface{}{}})
+		}
+	}
+	w.Header().Set("Date", f.now.Format(http.TimeFormat))
+	json.NewEncoder(w).Encode(map[string]interface{}{"records": records})
+}
+
+func listChanges(t *testing.T, p *AirtableProvider, userID, workspaceID string) *airtableChanges {
+	t.Helper()
+	ctx := WithWorkspaceID(WithUserID(context.Background(), userID), workspaceID)
+	res, err := p.Execute(ctx, &Token{AccessToken: "at-token"}, "list_changes", map[string]interface{}{"base_id": "app1", "table": "Tasks"})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	return res.(*airtableChanges)
+}
+
+func listChangeIDs(t *testing.T, p *AirtableProvider, userID, workspaceID string) []string {
+	t.Helper()
+	var ids []string
+	for _, r := range listChanges(t, p, userID, workspaceID).Items.([]airtableRecord) {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestAirtable_ListChanges_ReturnsOnlyChangedRecords(t *testing.T) {
+	fake := newFakeAirtable()
+	fake.touch("rec1")
+	fake.touch("rec2")
+	fake.advance(10 * time.Second)
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	p := &AirtableProvider{APIBaseURL: srv.URL}
+
+	first := listChanges(t, p, "u1", "ws1")
+	if records := first.Items.([]airtableRecord); len(records) != 2 || first.HasMore {
+		t.Fatalf("first poll = %v (has_more %v), want every record in one page", records, first.HasMore)
+	}
+	var body map[string]interface{}
+	raw, _ := json.Marshal(first)
+	json.Unmarshal(raw, &body)
+	for _, key := range []string{"items", "has_more", "watermark"} {
+		if _, ok := body[key]; !ok {
+			t.Errorf("list_changes JSON %s has no %q", raw, key)
+		}
+	}
+	// The mark comes from Airtable's clock, not the local one.
+	if want := fake.now.Add(-airtableClockSlack); !first.Watermark.Equal(want) {
+		t.Errorf("watermark = %v, want %v", first.Watermark, want)
+	}
+	fake.advance(10 * time.Second)
+	fake.touch("rec2")
+	fake.advance(10 * time.Second)
+	if ids := listChangeIDs(t, p, "u1", "ws1"); len(ids) != 1 || ids[0] != "rec2" {
+		t.Errorf("second poll = %v, want [rec2]", ids)
+	}
+	if ids := listChangeIDs(t, p, "u1", "ws1"); len(ids) != 0 {
+		t.Errorf("third poll = %v, want no changes", ids)
+	}
+}
+
+func TestAirtable_ListChanges_WatermarkPerWorkspace(t *testing.T) {
+	fake := newFakeAirtable()
+	fake.touch("rec1")
+	fake.advance(10 * time.Second)
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	p := &AirtableProvider{APIBaseURL: srv.URL}
+
+	listChangeIDs(t, p, "u1", "ws1")
+	if ids := listChangeIDs(t, p, "u1", "ws2"); len(ids) != 1 {
+		t.Errorf("another workspace's first poll = %v, want every record", ids)
+	}
+}
+
+func TestAirtable_ListChanges_WatermarkPerUser(t *testing.T) {
+	fake := newFakeAirtable()
+	fake.touch("rec1")
+	fake.advance(10 * time.Second)
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	p := &AirtableProvider{APIBaseURL: srv.URL}
+
+	// Neither user names a workspace; one's poll must not advance the other's.
+	listChangeIDs(t, p, "u1", "")
+	if ids := listChangeIDs(t, p, "u2", ""); len(ids) != 1 {
+		t.Errorf("another user's first poll = %v, want every record", ids)
+	}
+	if ids := listChangeIDs(t, p, "u1", ""); len(ids) != 0 {
+		t.Errorf("repeat poll = %v, want no changes", ids)
+	}
+}
+
+func TestAirtable_ListChanges_ResumesFromStore(t *testing.T) {
+	fake := newFakeAirtable()
+	fake.touch("rec1")
+	fake.advance(10 * time.Second)
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	marks := NewMemoryWatermarkStore()
+
+	listChangeIDs(t, &AirtableProvider{APIBaseURL: srv.URL, Watermarks: marks}, "u1", "ws1")
+	// A new provider, as after a restart, carries on from the stored mark.
+	if ids := listChangeIDs(t, &AirtableProvider{APIBaseURL: srv.URL, Watermarks: marks}, "u1", "ws1"); len(ids) != 0 {
+		t.Errorf("poll after restart = %v, want no changes", ids)
+	}
+}
+
+func TestAirtable_ListChanges_FailedPollKeepsWatermark(t *testing.T) {
+	fake := newFakeAirtable()
+	fake.touch("rec1")
+	fake.advance(10 * time.Second)
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	p := &AirtableProvider{APIBaseURL: srv.URL}
+
+	ctx := WithWorkspaceID(WithUserID(context.Background(), "u1"), "ws1")
+	if _, err := p.Execute(ctx, &Token{AccessToken: "at-token"}, "list_changes", map[string]interface{}{"base_id": "app1", "table": "Tasks"}); err == nil {
+		t.Fatal("expected an error from a failed poll")
+	}
+	fail = false
+	if ids := listChangeIDs(t, p, "u1", "ws1"); len(ids) != 1 {
+		t.Errorf("poll after failure = %v, want every record", ids)
+	}
+}
+
+func TestAirtable_ListChanges_InvalidSince(t *testing.T) {
+	p := &AirtableProvider{}
+	payload := map[string]interface{}{"base_id": "app1", "table": "Tasks", "since": "yesterday"}
+	if _, err := p.Execute(context.Background(), &Token{AccessToken: "at-token"}, "list_changes", payload); err == nil {
+		t.Error("expected an error for a non-RFC 3339 since")
+	}
+}
+
+func TestMemoryWatermarkStore_NeverMovesBack(t *testing.T) {
+	s := NewMemoryWatermarkStore()
+	ctx := context.Background()
+	later := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.Advance(ctx, "k", later)
+	s.Advance(ctx, "k", later.Add(-time.Hour))
+	if got, _ := s.Get(ctx, "k"); !got.Equal(later) {
+		t.Errorf("mark = %v, want %v", got, later)
+	}
+}
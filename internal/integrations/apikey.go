@@ -0,0 +1,125 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrInvalidCredentials is returned when a provider rejects a token or API key.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Identity is the account a token authenticates as. Username is the
+// provider's handle (a GitHub login, a Slack user name) where it has one.
+type Identity struct {
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// ConnectionTester is implemented by providers that can verify a token, such
+// as a pasted static API key, by calling the provider's identity endpoint.
+type ConnectionTester interface {
+	TestConnection(ctx context.Context, token *Token) (*Identity, error)
+}
+
+// apiKeyHTTPClient is shared by identity checks for API-key providers.
+// Requests are bounded by the provider's ActionTimeout.
+var apiKeyHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// getIdentityJSON sends an authenticated GET to endpoint and decodes the JSON
+// reply into out. 401 and 403 replies are reported as ErrInvalidCredentials.
+func getIdentityJSON(ctx context.Context, provider, endpoint string, authorize func(*http.Request), out interface{}) error {
+	ctx, cancel := withActionTimeout(ctx, provider)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	authorize(req)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := apiKeyHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s identity check: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("%s rejected the key: %w", provider, ErrInvalidCredentials)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return rateLimitedFromResponse(provider, resp)
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("%s identity check: %w", provider, MapUpstreamError(IntegrationType(provider), resp.StatusCode, nil))
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("%s identity check returned status %d", provider, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s identity: %w", provider, err)
+	}
+	return nil
+}
+
+func bearer(key string) func(*http.Request) {
+	return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+key) }
+}
+
+// TestConnection verifies a SendGrid API key via GET /v3/user/email.
+func (p *SendGridProvider) TestConnection(ctx context.Context, token *Token) (*Identity, error) {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultSendGridAPIBaseURL
+	}
+	var out struct {
+		Email string `json:"email"`
+	}
+	if err := getIdentityJSON(ctx, p.Name(), base+"/v3/user/email", bearer(token.AccessToken), &out); err != nil {
+		return nil, err
+	}
+	return &Identity{Email: out.Email}, nil
+}
+
+// TestConnection verifies an Airtable personal access token via
+// GET /v0/meta/whoami.
+func (p *AirtableProvider) TestConnection(ctx context.Context, token *Token) (*Identity, error) {
+	base := p.APIBaseURL
+	if base == "" {
+		base = "https://api.airtable.com"
+	}
+	var out struct {
+		ID    string `json:"id"`
+		Email string `json:"email"`
+	}
+	if err := getIdentityJSON(ctx, p.Name(), base+"/v0/meta/whoami", bearer(token.AccessToken), &out); err != nil {
+		return nil, err
+	}
+	return &Identity{ID: out.ID, Email: out.Email}, nil
+}
+
+// TestConnection verifies Twilio credentials, pasted as
+// "<account SID>:<auth token>", by fetching the account.
+func (p *TwilioProvider) TestConnection(ctx context.Context, token *Token) (*Identity, error) {
+	sid, secret, err := twilioCredentials(token)
+	if err != nil {
+		return nil, err
+	}
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultTwilioAPIBaseURL
+	}
+	var out struct {
+		SID          string `json:"sid"`
+		FriendlyName string `json:"friendly_name"`
+	}
+	endpoint := base + "/2010-04-01/Accounts/" + url.PathEscape(sid) + ".json"
+	authorize := func(req *http.Request) { req.SetBasicAuth(sid, secret) }
+	if err := getIdentityJSON(ctx, p.Name(), endpoint, authorize, &out); err != nil {
+		return nil, err
+	}
+	return &Identity{ID: out.SID, Name: out.FriendlyName}, nil
+}
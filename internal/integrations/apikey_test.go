@@ -0,0 +1,59 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendGridTestConnection_ValidKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v3/user/email" || r.Header.Get("Authorization") != "Bearer SG.good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"email":"ops@example.com"}`))
+	}))
+	defer srv.Close()
+
+	p := &SendGridProvider{APIBaseURL: srv.URL}
+	id, err := p.TestConnection(context.Background(), &Token{AccessToken: "SG.good"})
+	if err != nil {
+		t.Fatalf("TestConnection error: %v", err)
+	}
+	if id.Email != "ops@example.com" {
+		t.Errorf("expected ops@example.com, got %+v", id)
+	}
+
+	_, err = p.TestConnection(context.Background(), &Token{AccessToken: "SG.bad"})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestTwilioTestConnection_UsesBasicAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "AC123" || pass != "secret" || r.URL.Path != "/2010-04-01/Accounts/AC123.json" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"sid":"AC123","friendly_name":"Ops"}`))
+	}))
+	defer srv.Close()
+
+	p := &TwilioProvider{APIBaseURL: srv.URL}
+	id, err := p.TestConnection(context.Background(), &Token{AccessToken: "AC123:secret"})
+	if err != nil {
+		t.Fatalf("TestConnection error: %v", err)
+	}
+	if id.ID != "AC123" || id.Name != "Ops" {
+		t.Errorf("unexpected identity %+v", id)
+	}
+
+	if _, err := p.TestConnection(context.Background(), &Token{AccessToken: "no-separator"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials for malformed key, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultAsanaAPIBaseURL is the Asana API root used when an AsanaProvider
+// has no APIBaseURL override.
+const defaultAsanaAPIBaseURL = "https://app.asana.com/api/1.0"
+
+// asanaHTTPClient is shared by Asana API calls. Requests are bounded by the
+// provider's ActionTimeout.
+var asanaHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// asanaGID reads a required Asana object ID from payload[key]. GIDs are
+// numeric strings; anything else would only fail upstream as a 404.
+func asanaGID(payload map[string]interface{}, key string) (string, error) {
+	gid, err := getString(payload, key)
+	if err != nil {
+		return "", err
+	}
+	if gid == "" || strings.Trim(gid, "0123456789") != "" {
+		return "", invalidField("field '%s' must be an Asana GID, got %q", key, gid)
+	}
+	return gid, nil
+}
+
+// createSubtask adds a subtask named name under the parent task and returns
+// its GID.
+func (p *AsanaProvider) createSubtask(ctx context.Context, token *Token, parent, name, notes string) (map[string]interface{}, error) {
+	body := map[string]string{"name": name}
+	if notes != "" {
+		body["notes"] = notes
+	}
+	var task struct {
+		GID string `json:"gid"`
+	}
+	if err := p.asanaCall(ctx, token, "/tasks/"+url.PathEscape(parent)+"/subtasks", body, &task); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "subtask_gid": task.GID, "parent_gid": parent}, nil
+}
+
+// addComment posts text as a comment story on a task and returns the story
+// GID.
+func (p *AsanaProvider) addComment(ctx context.Context, token *Token, taskGID, text string) (map[string]interface{}, error) {
+	var story struct {
+		GID string `json:"gid"`
+	}
+	if err := p.asanaCall(ctx, token, "/tasks/"+url.PathEscape(taskGID)+"/stories", map[string]string{"text": text}, &story); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "story_gid": story.GID, "task_gid": taskGID}, nil
+}
+
+// addToProject adds a task to a project, optionally in one of its sections.
+// A task already in the project is moved to the section.
+func (p *AsanaProvider) addToProject(ctx context.Context, token *Token, taskGID, projectGID, sectionGID string) (map[string]interface{}, error) {
+	body := map[string]string{"project": projectGID}
+	if sectionGID != "" {
+		body["section"] = sectionGID
+	}
+	if err := p.asanaCall(ctx, token, "/tasks/"+url.PathEscape(taskGID)+"/addProject", body, nil); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "task_gid": taskGID, "project_gid": projectGID}, nil
+}
+
+// asanaCall POSTs data to an Asana API path inside Asana's {"data": ...}
+// envelope and decodes the reply's data into out, if out is not nil. A 429
+// is returned as *ErrRateLimited carrying Asana's Retry-After.
+func (p *AsanaProvider) asanaCall(ctx context.Context, token *Token, path string, data, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultAsanaAPIBaseURL
+	}
+	if out == nil {
+		return doJSON(ctx, asanaHTTPClient, IntegrationAsana, http.MethodPost, base+path, token, map[string]interface{}{"data": data}, nil)
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := doJSON(ctx, asanaHTTPClient, IntegrationAsana, http.MethodPost, base+path, token, map[string]interface{}{"data": data}, &envelope); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to decode Asana response: %w", err)
+	}
+	return nil
+}
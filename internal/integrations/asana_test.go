@@ -0,0 +1,117 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newAsanaServer answers POST path with status and reply, recording the
+// request's data envelope.
+func newAsanaServer(t *testing.T, path string, status int, reply string, data *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != path || r.Header.Get("Authorization") != "Bearer asana-token" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		*data = body.Data
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "30")
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAsana_CreateSubtask(t *testing.T) {
+	var data map[string]interface{}
+	srv := newAsanaServer(t, "/tasks/1200/subtasks", http.StatusCreated,
+		`{"data":{"gid":"1201","resource_type":"task","name":"Write tests"}}`, &data)
+	p := &AsanaProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "asana-token"}, "create_subtask", map[string]interface{}{
+		"parent_gid": "1200", "name": "Write tests", "notes": "Cover the edge cases",
+	})
+	if err != nil {
+		t.Fatalf("create_subtask: %v", err)
+	}
+	want := map[string]interface{}{"status": "success", "subtask_gid": "1201", "parent_gid": "1200"}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("result = %v, want %v", res, want)
+	}
+	if !reflect.DeepEqual(data, map[string]interface{}{"name": "Write tests", "notes": "Cover the edge cases"}) {
+		t.Errorf("data = %v", data)
+	}
+
+	for _, payload := range []map[string]interface{}{
+		{"name": "x"},
+		{"parent_gid": "", "name": "x"},
+		{"parent_gid": "task-12", "name": "x"},
+		{"parent_gid": "1200"},
+	} {
+		if _, err := p.Execute(context.Background(), &Token{AccessToken: "asana-token"}, "create_subtask", payload); err == nil {
+			t.Errorf("expected an error for payload %v", payload)
+		}
+	}
+}
+
+func TestAsana_AddComment(t *testing.T) {
+	var data map[string]interface{}
+	srv := newAsanaServer(t, "/tasks/1200/stories", http.StatusCreated,
+		`{"data":{"gid":"3300","resource_type":"story","resource_subtype":"comment_added"}}`, &data)
+	p := &AsanaProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "asana-token"}, "add_comment", map[string]interface{}{
+		"task_gid": "1200", "text": "Shipped",
+	})
+	if err != nil {
+		t.Fatalf("add_comment: %v", err)
+	}
+	if got := res.(map[string]interface{})["story_gid"]; got != "3300" {
+		t.Errorf("story_gid = %v, want 3300", got)
+	}
+	if !reflect.DeepEqual(data, map[string]interface{}{"text": "Shipped"}) {
+		t.Errorf("data = %v", data)
+	}
+}
+
+func TestAsana_AddToProject(t *testing.T) {
+	var data map[string]interface{}
+	srv := newAsanaServer(t, "/tasks/1200/addProject", http.StatusOK, `{"data":{}}`, &data)
+	p := &AsanaProvider{APIBaseURL: srv.URL}
+
+	if _, err := p.Execute(context.Background(), &Token{AccessToken: "asana-token"}, "add_to_project", map[string]interface{}{
+		"task_gid": "1200", "project_gid": "4400", "section_gid": "4401",
+	}); err != nil {
+		t.Fatalf("add_to_project: %v", err)
+	}
+	if !reflect.DeepEqual(data, map[string]interface{}{"project": "4400", "section": "4401"}) {
+		t.Errorf("data = %v", data)
+	}
+}
+
+func TestAsana_RateLimitedCarriesRetryAfter(t *testing.T) {
+	var data map[string]interface{}
+	srv := newAsanaServer(t, "/tasks/1200/stories", http.StatusTooManyRequests,
+		`{"errors":[{"message":"You have made too many requests recently."}]}`, &data)
+	p := &AsanaProvider{APIBaseURL: srv.URL}
+
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "asana-token"}, "add_comment", map[string]interface{}{
+		"task_gid": "1200", "text": "x",
+	})
+	var rl *ErrRateLimited
+	if !errors.As(err, &rl) || rl.RetryAfter != 30*time.Second {
+		t.Fatalf("expected ErrRateLimited with a 30s Retry-After, got %v", err)
+	}
+}
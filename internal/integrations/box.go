@@ -0,0 +1,86 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// defaultBoxAPIBaseURL is the Box API root used when a BoxProvider has no
+// APIBaseURL override.
+const defaultBoxAPIBaseURL = "https://api.box.com"
+
+// boxHTTPClient is shared by Box API calls. Requests are bounded by the
+// provider's ActionTimeout.
+var boxHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// boxRootFolderID is the ID Box gives every user's root folder.
+const boxRootFolderID = "0"
+
+// boxCollaborationRoles are the roles a collaborator can be given.
+var boxCollaborationRoles = map[string]bool{
+	"editor": true, "viewer": true, "previewer": true, "uploader": true,
+	"previewer uploader": true, "viewer uploader": true, "co-owner": true,
+}
+
+// errBoxCollaborator is returned when add_collaboration names neither or both
+// of user_id and email.
+var errBoxCollaborator = invalidField("exactly one of user_id or email is required")
+
+// createFolder creates a folder named name inside parentID and returns its ID.
+func (p *BoxProvider) createFolder(ctx context.Context, token *Token, name, parentID string) (map[string]interface{}, error) {
+	body := map[string]interface{}{"name": name, "parent": map[string]string{"id": parentID}}
+	var folder struct {
+		ID string `json:"id"`
+	}
+	if err := p.boxPost(ctx, token, "/2.0/folders", body, &folder); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "folder_id": folder.ID}, nil
+}
+
+// addCollaboration shares folderID at role with a Box user, identified by
+// userID, or with anyone by email. Box invites an email with no account.
+func (p *BoxProvider) addCollaboration(ctx context.Context, token *Token, folderID, userID, email, role string) (map[string]interface{}, error) {
+	accessibleBy := map[string]string{"type": "user"}
+	if userID != "" {
+		accessibleBy["id"] = userID
+	} else {
+		accessibleBy["login"] = email
+	}
+	body := map[string]interface{}{
+		"item":          map[string]string{"type": "folder", "id": folderID},
+		"accessible_by": accessibleBy,
+		"role":          role,
+	}
+	var collab struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+	if err := p.boxPost(ctx, token, "/2.0/collaborations", body, &collab); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "collaboration_id": collab.ID, "collaboration_status": collab.Status}, nil
+}
+
+// boxPost sends body as JSON to a Box API path and decodes the reply into out.
+func (p *BoxProvider) boxPost(ctx context.Context, token *Token, path string, body, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultBoxAPIBaseURL
+	}
+	return doJSON(ctx, boxHTTPClient, IntegrationBox, http.MethodPost, base+path, token, body, out)
+}
+
+// boxFolderID reads a required folder ID from payload[key]. Box folder IDs
+// are numeric strings; "0" is the root folder.
+func boxFolderID(payload map[string]interface{}, key string) (string, error) {
+	id, err := getString(payload, key)
+	if err != nil {
+		return "", err
+	}
+	if id == "" || strings.Trim(id, "0123456789") != "" {
+		return "", invalidField("%s must be a Box folder ID such as %q, got %q", key, boxRootFolderID, id)
+	}
+	return id, nil
+}
@@ -0,0 +1,107 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newBoxServer answers POST path with status and reply, recording the decoded
+// request body.
+func newBoxServer(t *testing.T, path string, status int, reply string, body *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != path || r.Header.Get("Authorization") != "Bearer box-token" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(body)
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestBox_CreateFolder(t *testing.T) {
+	var body map[string]interface{}
+	srv := newBoxServer(t, "/2.0/folders", http.StatusCreated, `{"type":"folder","id":"12345","name":"Contracts"}`, &body)
+	p := &BoxProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "box-token"}, "create_folder", map[string]interface{}{
+		"name": "Contracts", "parent_id": "0",
+	})
+	if err != nil {
+		t.Fatalf("create_folder: %v", err)
+	}
+	if got := res.(map[string]interface{})["folder_id"]; got != "12345" {
+		t.Errorf("folder_id = %v, want 12345", got)
+	}
+	want := map[string]interface{}{"name": "Contracts", "parent": map[string]interface{}{"id": "0"}}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("body = %v, want %v", body, want)
+	}
+
+	// The parent folder is required.
+	if _, err := p.Execute(context.Background(), &Token{AccessToken: "box-token"}, "create_folder", map[string]interface{}{"name": "x"}); err == nil {
+		t.Error("expected an error without parent_id")
+	}
+}
+
+func TestBox_CreateFolderNameInUse(t *testing.T) {
+	var body map[string]interface{}
+	srv := newBoxServer(t, "/2.0/folders", http.StatusConflict,
+		`{"type":"error","status":409,"code":"item_name_in_use","message":"Item with the same name already exists"}`, &body)
+	p := &BoxProvider{APIBaseURL: srv.URL}
+
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "box-token"}, "create_folder", map[string]interface{}{
+		"name": "Contracts", "parent_id": "0",
+	})
+	var upstream *UpstreamError
+	if !errors.As(err, &upstream) || upstream.Code != "item_name_in_use" {
+		t.Errorf("expected item_name_in_use, got %v", err)
+	}
+}
+
+func TestBox_AddCollaboration(t *testing.T) {
+	var body map[string]interface{}
+	srv := newBoxServer(t, "/2.0/collaborations", http.StatusCreated, `{"type":"collaboration","id":"987","status":"pending"}`, &body)
+	p := &BoxProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "box-token"}, "add_collaboration", map[string]interface{}{
+		"folder_id": "12345", "email": "auditor@example.com", "role": "viewer",
+	})
+	if err != nil {
+		t.Fatalf("add_collaboration: %v", err)
+	}
+	want := map[string]interface{}{"status": "success", "collaboration_id": "987", "collaboration_status": "pending"}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("result = %v, want %v", res, want)
+	}
+	wantBody := map[string]interface{}{
+		"item":          map[string]interface{}{"type": "folder", "id": "12345"},
+		"accessible_by": map[string]interface{}{"type": "user", "login": "auditor@example.com"},
+		"role":          "viewer",
+	}
+	if !reflect.DeepEqual(body, wantBody) {
+		t.Errorf("body = %v, want %v", body, wantBody)
+	}
+}
+
+func TestBox_AddCollaborationValidation(t *testing.T) {
+	p := &BoxProvider{APIBaseURL: "http://127.0.0.1:0"}
+	tok := &Token{AccessToken: "box-token"}
+	for _, payload := range []map[string]interface{}{
+		{"folder_id": "12345", "role": "viewer"},
+		{"folder_id": "12345", "role": "viewer", "user_id": "42", "email": "a@example.com"},
+		{"folder_id": "12345", "role": "owner", "user_id": "42"},
+		{"folder_id": "shared", "role": "viewer", "user_id": "42"},
+	} {
+		if _, err := p.Execute(context.Background(), tok, "add_collaboration", payload); err == nil {
+			t.Errorf("%v: expected a validation error", payload)
+		}
+	}
+}
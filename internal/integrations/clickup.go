@@ -0,0 +1,73 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultClickUpAPIBaseURL is the ClickUp API root used when a
+// ClickUpProvider has no APIBaseURL override.
+const defaultClickUpAPIBaseURL = "https://api.clickup.com"
+
+// clickupHTTPClient is shared by ClickUp API calls. Requests are bounded by
+// the provider's ActionTimeout.
+var clickupHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// clickupTaskID reads a required task ID from payload["task_id"].
+func clickupTaskID(payload map[string]interface{}) (string, error) {
+	id, err := getString(payload, "task_id")
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(id) == "" {
+		return "", invalidField("field 'task_id' must not be empty")
+	}
+	return id, nil
+}
+
+// addComment posts text as a comment on a task and returns the comment ID.
+// With notifyAll, everyone following the task is notified, not only
+// assignees.
+func (p *ClickUpProvider) addComment(ctx context.Context, token *Token, taskID, text string, notifyAll bool) (map[string]interface{}, error) {
+	body := map[string]interface{}{"comment_text": text, "notify_all": notifyAll}
+	var comment struct {
+		ID json.Number `json:"id"`
+	}
+	if err := p.clickupCall(ctx, token, http.MethodPost, "/api/v2/task/"+url.PathEscape(taskID)+"/comment", body, &comment); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "comment_id": comment.ID.String()}, nil
+}
+
+// updateTaskStatus moves a task to status, which must be one of the
+// statuses of the task's list.
+func (p *ClickUpProvider) updateTaskStatus(ctx context.Context, token *Token, taskID, status string) (map[string]interface{}, error) {
+	var task struct {
+		ID     string `json:"id"`
+		Status struct {
+			Status string `json:"status"`
+		} `json:"status"`
+	}
+	if err := p.clickupCall(ctx, token, http.MethodPut, "/api/v2/task/"+url.PathEscape(taskID), map[string]string{"status": status}, &task); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "task_id": task.ID, "task_status": task.Status.Status}, nil
+}
+
+// clickupCall sends body as JSON to a ClickUp API path and decodes the reply
+// into out. Personal API tokens (pk_...) are sent as they are; OAuth access
+// tokens as a bearer token.
+func (p *ClickUpProvider) clickupCall(ctx context.Context, token *Token, method, path string, body, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultClickUpAPIBaseURL
+	}
+	auth := token.AccessToken
+	if !strings.HasPrefix(auth, "pk_") {
+		auth = "Bearer " + auth
+	}
+	return doJSON(ctx, clickupHTTPClient, IntegrationClickUp, method, base+path, token, body, out, withHeader("Authorization", auth))
+}
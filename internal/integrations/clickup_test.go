@@ -0,0 +1,120 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newClickUpServer answers method path with status and reply, recording the
+// decoded request body and the Authorization header.
+func newClickUpServer(t *testing.T, method, path string, status int, reply string, body *map[string]interface{}, auth *string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method || r.URL.Path != path {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		*auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(body)
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClickUp_AddComment(t *testing.T) {
+	var body map[string]interface{}
+	var auth string
+	srv := newClickUpServer(t, http.MethodPost, "/api/v2/task/86a1b2c/comment", http.StatusOK,
+		`{"id":90160045237,"hist_id":"26508","date":1700000000000}`, &body, &auth)
+	p := &ClickUpProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "oauth-token"}, "add_comment", map[string]interface{}{
+		"task_id": "86a1b2c", "comment_text": "Deployed to staging", "notify_all": true,
+	})
+	if err != nil {
+		t.Fatalf("add_comment: %v", err)
+	}
+	if got := res.(map[string]interface{})["comment_id"]; got != "90160045237" {
+		t.Errorf("comment_id = %v, want 90160045237", got)
+	}
+	want := map[string]interface{}{"comment_text": "Deployed to staging", "notify_all": true}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("body = %v, want %v", body, want)
+	}
+	if auth != "Bearer oauth-token" {
+		t.Errorf("Authorization = %q, want a bearer token", auth)
+	}
+
+	// Personal API tokens are sent without the Bearer prefix.
+	if _, err := p.Execute(context.Background(), &Token{AccessToken: "pk_123_ABC"}, "add_comment", map[string]interface{}{
+		"task_id": "86a1b2c", "comment_text": "x",
+	}); err != nil {
+		t.Fatalf("add_comment with a personal token: %v", err)
+	}
+	if auth != "pk_123_ABC" {
+		t.Errorf("Authorization = %q, want the personal token", auth)
+	}
+
+	for _, payload := range []map[string]interface{}{
+		{"comment_text": "x"},
+		{"task_id": " ", "comment_text": "x"},
+		{"task_id": "86a1b2c"},
+	} {
+		if _, err := p.Execute(context.Background(), &Token{AccessToken: "oauth-token"}, "add_comment", payload); err == nil {
+			t.Errorf("expected an error for payload %v", payload)
+		}
+	}
+}
+
+func TestClickUp_UpdateTaskStatus(t *testing.T) {
+	var body map[string]interface{}
+	var auth string
+	srv := newClickUpServer(t, http.MethodPut, "/api/v2/task/86a1b2c", http.StatusOK,
+		`{"id":"86a1b2c","name":"Ship it","status":{"status":"in review","type":"custom"}}`, &body, &auth)
+	p := &ClickUpProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "oauth-token"}, "update_task_status", map[string]interface{}{
+		"task_id": "86a1b2c", "status": "in review",
+	})
+	if err != nil {
+		t.Fatalf("update_task_status: %v", err)
+	}
+	got := res.(map[string]interface{})
+	if got["task_id"] != "86a1b2c" || got["task_status"] != "in review" {
+		t.Errorf("result = %v", got)
+	}
+	if !reflect.DeepEqual(body, map[string]interface{}{"status": "in review"}) {
+		t.Errorf("body = %v, want only the status", body)
+	}
+
+	if _, err := p.Execute(context.Background(), &Token{AccessToken: "oauth-token"}, "update_task_status", map[string]interface{}{"task_id": "86a1b2c"}); err == nil {
+		t.Error("expected an error without status")
+	}
+}
+
+func TestClickUp_UnauthorizedExplainsScope(t *testing.T) {
+	var body map[string]interface{}
+	var auth string
+	srv := newClickUpServer(t, http.MethodPost, "/api/v2/task/86a1b2c/comment", http.StatusUnauthorized,
+		`{"err":"Team not authorized","ECODE":"OAUTH_027"}`, &body, &auth)
+	p := &ClickUpProvider{APIBaseURL: srv.URL}
+
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "oauth-token"}, "add_comment", map[string]interface{}{
+		"task_id": "86a1b2c", "comment_text": "x",
+	})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+	for _, want := range []string{"OAUTH_027", "Team not authorized", "grant access to that workspace"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrConnectionNotFound is returned when a user has no stored integration for
+// a provider in the requested workspace.
+var ErrConnectionNotFound = errors.New("integration not connected")
+
+// Connection is a user's stored provider token. Connections are scoped to a
+// workspace so a token granted while acting in one workspace cannot be used
+// from another.
+type Connection struct {
+	UserID      string
+	WorkspaceID string
+	Provider    IntegrationType
+	Token       Token
+	CreatedAt   time.Time
+}
+
+// ConnectionStore persists provider tokens per user, workspace and provider.
+// Implementations must be safe for concurrent use.
+type ConnectionStore interface {
+	// Save stores or replaces the connection for its user, workspace and provider.
+	Save(ctx context.Context, c *Connection) error
+	// Get returns the connection for the given user, workspace and provider,
+	// or an error wrapping ErrConnectionNotFound.
+	Get(ctx context.Context, userID, workspaceID string, provider IntegrationType) (*Connection, error)
+	// Delete removes the connection for the given user, workspace and
+	// provider, or returns an error wrapping ErrConnectionNotFound.
+	Delete(ctx context.Context, userID, workspaceID string, provider IntegrationType) error
+}
+
+// connectionKey identifies a single stored connection.
+type connectionKey struct {
+	userID      string
+	workspaceID string
+	provider    IntegrationType
+}
+
+// MemoryConnectionStore is an in-process ConnectionStore used when no database
+// is configured (development, tests and OFFLINE mode).
+type MemoryConnectionStore struct {
+	mu    sync.RWMutex
+	conns map[connectionKey]Connection
+}
+
+// NewMemoryConnectionStore creates an empty in-memory connection store.
+func NewMemoryConnectionStore() *MemoryConnectionStore {
+	return &MemoryConnectionStore{conns: make(map[connectionKey]Connection)}
+}
+
+// Save stores a copy of c, replacing any existing connection with the same key.
+func (s *MemoryConnectionStore) Save(_ context.Context, c *Connection) error {
+	if c == nil || c.UserID == "" || c.Provider == "" {
+		return errors.New("connection requires a user and provider")
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+
+	s.mu.Lock()
+	s.conns[connectionKey{c.UserID, c.WorkspaceID, c.Provider}] = *c
+	s.mu.Unlock()
+	return nil
+}
+
+// Get returns a copy of the stored connection for the given key.
+func (s *MemoryConnectionStore) Get(_ context.Context, userID, workspaceID string, provider IntegrationType) (*Connection, error) {
+	s.mu.RLock()
+	c, ok := s.conns[connectionKey{userID, workspaceID, provider}]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no %s integration for workspace %q: %w", provider, workspaceID, ErrConnectionNotFound)
+	}
+	return &c, nil
+}
+
+// Delete removes the stored connection for the given key.
+func (s *MemoryConnectionStore) Delete(_ context.Context, userID, workspaceID string, provider IntegrationType) error {
+	key := connectionKey{userID, workspaceID, provider}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.conns[key]; !ok {
+		return fmt.Errorf("no %s integration for workspace %q: %w", provider, workspaceID, ErrConnectionNotFound)
+	}
+	delete(s.conns, key)
+	return nil
+}
+
+// TokenCipher encrypts token strings under a per-user key.
+type TokenCipher interface {
+	EncryptString(ctx context.Context, userID, plaintext string) (string, error)
+	DecryptString(ctx context.Context, userID, ciphertext string) (string, error)
+}
+
+// EncryptedConnectionStore wraps a ConnectionStore so access and refresh
+// tokens are encrypted before they reach the underlying store.
+type EncryptedConnectionStore struct {
+	next   ConnectionStore
+	cipher TokenCipher
+}
+
+// NewEncryptedConnectionStore encrypts tokens saved to next with cipher.
+func NewEncryptedConnectionStore(next ConnectionStore, cipher TokenCipher) *EncryptedConnectionStore {
+	return &EncryptedConnectionStore{next: next, cipher: cipher}
+}
+
+// Save encrypts c's tokens and stores the result. c itself is not modified.
+func (s *EncryptedConnectionStore) Save(ctx context.Context, c *Connection) error {
+	if c == nil {
+		return errors.New("connection requires a user and provider")
+	}
+	enc := *c
+	var err error
+	if enc.Token.AccessToken, err = s.cipher.EncryptString(ctx, c.UserID, c.Token.AccessToken); err != nil {
+		return fmt.Errorf("encrypt access token: %w", err)
+	}
+	if enc.Token.RefreshToken, err = s.cipher.EncryptString(ctx, c.UserID, c.Token.RefreshToken); err != nil {
+		return fmt.Errorf("encrypt refresh token: %w", err)
+	}
+	if err := s.next.Save(ctx, &enc); err != nil {
+		return err
+	}
+	c.CreatedAt = enc.CreatedAt
+	return nil
+}
+
+// Get loads the connection and decrypts its tokens.
+func (s *EncryptedConnectionStore) Get(ctx context.Context, userID, workspaceID string, provider IntegrationType) (*Connection, error) {
+	c, err := s.next.Get(ctx, userID, workspaceID, provider)
+	if err != nil {
+		return nil, err
+	}
+	if c.Token.AccessToken, err = s.cipher.DecryptString(ctx, userID, c.Token.AccessToken); err != nil {
+		return nil, fmt.Errorf("decrypt access token: %w", err)
+	}
+	if c.Token.RefreshToken, err = s.cipher.DecryptString(ctx, userID, c.Token.RefreshToken); err != nil {
+		return nil, fmt.Errorf("decrypt refresh token: %w", err)
+	}
+	return c, nil
+}
+
+// Delete removes the connection from the underlying store.
+func (s *EncryptedConnectionStore) Delete(ctx context.Context, userID, workspaceID string, provider IntegrationType) error {
+	return s.next.Delete(ctx, userID, workspaceID, provider)
+}
@@ -0,0 +1,100 @@
+package integrations
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SQLConnectionStore is the PostgreSQL-backed ConnectionStore, kept in the
+// integrations table with one row per user, workspace and provider. Wrap it
+// in an EncryptedConnectionStore to keep tokens encrypted at rest.
+type SQLConnectionStore struct {
+	db *sql.DB
+}
+
+// NewSQLConnectionStore creates a connection store backed by db.
+func NewSQLConnectionStore(db *sql.DB) *SQLConnectionStore {
+	return &SQLConnectionStore{db: db}
+}
+
+// connectionMetadata holds the token fields without a column of their own,
+// stored in the integrations.metadata JSONB column.
+type connectionMetadata struct {
+	TokenType   string `json:"token_type,omitempty"`
+	Expiry      int64  `json:"expiry,omitempty"`
+	InstanceURL string `json:"instance_url,omitempty"`
+}
+
+// Save stores or replaces the connection for c's user, workspace and provider.
+func (s *SQLConnectionStore) Save(ctx context.Context, c *Connection) error {
+	if c == nil || c.UserID == "" || c.Provider == "" {
+		return errors.New("connection requires a user and provider")
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	metadata, err := json.Marshal(connectionMetadata{TokenType: c.Token.TokenType, Expiry: c.Token.Expiry, InstanceURL: c.Token.InstanceURL})
+	if err != nil {
+		return fmt.Errorf("encode connection metadata: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO integrations (user_id, workspace_id, provider, access_token, refresh_token, expires_at, metadata, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8)
+		ON CONFLICT (user_id, workspace_id, provider) DO UPDATE
+		SET access_token = EXCLUDED.access_token, refresh_token = EXCLUDED.refresh_token,
+			expires_at = EXCLUDED.expires_at, metadata = EXCLUDED.metadata, created_at = EXCLUDED.created_at`,
+		c.UserID, c.WorkspaceID, string(c.Provider), c.Token.AccessToken, c.Token.RefreshToken,
+		nullTime(c.Token.ExpiresAt), metadata, c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("save %s integration: %w", c.Provider, err)
+	}
+	return nil
+}
+
+// Get returns the stored connection for the given user, workspace and provider.
+func (s *SQLConnectionStore) Get(ctx context.Context, userID, workspaceID string, provider IntegrationType) (*Connection, error) {
+	c := &Connection{UserID: userID, WorkspaceID: workspaceID, Provider: provider}
+	var (
+		expiresAt sql.NullTime
+		metadata  []byte
+	)
+	err := s.db.QueryRowContext(ctx, `
+		SELECT access_token, COALESCE(refresh_token, ''), expires_at, COALESCE(metadata, '{}'), created_at
+		FROM integrations WHERE user_id = $1 AND workspace_id = $2 AND provider = $3`,
+		userID, workspaceID, string(provider)).
+		Scan(&c.Token.AccessToken, &c.Token.RefreshToken, &expiresAt, &metadata, &c.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no %s integration for workspace %q: %w", provider, workspaceID, ErrConnectionNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load %s integration: %w", provider, err)
+	}
+	var meta connectionMetadata
+	if err := json.Unmarshal(metadata, &meta); err != nil {
+		return nil, fmt.Errorf("decode %s integration metadata: %w", provider, err)
+	}
+	c.Token.ExpiresAt = expiresAt.Time
+	c.Token.TokenType, c.Token.Expiry, c.Token.InstanceURL = meta.TokenType, meta.Expiry, meta.InstanceURL
+	return c, nil
+}
+
+// Delete removes the stored connection for the given user, workspace and
+// provider.
+func (s *SQLConnectionStore) Delete(ctx context.Context, userID, workspaceID string, provider IntegrationType) error {
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM integrations WHERE user_id = $1 AND workspace_id = $2 AND provider = $3`,
+		userID, workspaceID, string(provider))
+	if err != nil {
+		return fmt.Errorf("delete %s integration: %w", provider, err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("delete %s integration: %w", provider, err)
+	} else if n == 0 {
+		return fmt.Errorf("no %s integration for workspace %q: %w", provider, workspaceID, ErrConnectionNotFound)
+	}
+	return nil
+}
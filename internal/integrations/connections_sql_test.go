@@ -0,0 +1,79 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"neighbourhood/internal/database"
+
+	"github.com/google/uuid"
+)
+
+// testSQLConnectionStore returns a store on the database at TEST_DATABASE_URL,
+// migrated to the current schema, and a user to save connections for. The
+// test is skipped when no database is configured.
+func testSQLConnectionStore(t *testing.T) (*SQLConnectionStore, string) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	t.Setenv("DATABASE_URL", dsn)
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { database.DB.Close() })
+	if err := database.RunMigrations(); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+	userID := uuid.NewString()
+	if _, err := database.DB.Exec(`INSERT INTO users (id, email, password_hash) VALUES ($1, $2, '')`, userID, userID+"@example.com"); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	t.Cleanup(func() { database.DB.Exec(`DELETE FROM users WHERE id = $1`, userID) })
+	return NewSQLConnectionStore(database.DB), userID
+}
+
+func TestSQLConnectionStore_SaveGetDeletePerWorkspace(t *testing.T) {
+	s, userID := testSQLConnectionStore(t)
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	for ws, token := range map[string]string{"": "xoxb-default", "ws-a": "xoxb-a"} {
+		c := &Connection{UserID: userID, WorkspaceID: ws, Provider: IntegrationSlack, Token: Token{AccessToken: token, RefreshToken: "r", ExpiresAt: expires, TokenType: "bearer"}}
+		if err := s.Save(ctx, c); err != nil {
+			t.Fatalf("Save(%q) error: %v", ws, err)
+		}
+	}
+	// Saving again replaces the workspace's connection rather than adding one.
+	if err := s.Save(ctx, &Connection{UserID: userID, WorkspaceID: "ws-a", Provider: IntegrationSlack, Token: Token{AccessToken: "xoxb-a2"}}); err != nil {
+		t.Fatalf("re-Save error: %v", err)
+	}
+
+	c, err := s.Get(ctx, userID, "", IntegrationSlack)
+	if err != nil || c.Token.AccessToken != "xoxb-default" || c.Token.RefreshToken != "r" || !c.Token.ExpiresAt.Equal(expires) || c.Token.TokenType != "bearer" {
+		t.Errorf("default workspace Get = %+v, %v", c, err)
+	}
+	if c, err := s.Get(ctx, userID, "ws-a", IntegrationSlack); err != nil || c.Token.AccessToken != "xoxb-a2" || c.Token.RefreshToken != "" {
+		t.Errorf("ws-a Get = %+v, %v, want the replacement token", c, err)
+	}
+	if _, err := s.Get(ctx, userID, "ws-b", IntegrationSlack); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("ws-b Get error = %v, want ErrConnectionNotFound", err)
+	}
+
+	if err := s.Delete(ctx, userID, "ws-a", IntegrationSlack); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if _, err := s.Get(ctx, userID, "ws-a", IntegrationSlack); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrConnectionNotFound", err)
+	}
+	if _, err := s.Get(ctx, userID, "", IntegrationSlack); err != nil {
+		t.Errorf("Delete in ws-a removed the default workspace's connection: %v", err)
+	}
+	if err := s.Delete(ctx, userID, "ws-a", IntegrationSlack); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("second Delete error = %v, want ErrConnectionNotFound", err)
+	}
+}
@@ -0,0 +1,117 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryConnectionStore_SaveAndGet(t *testing.T) {
+	s := NewMemoryConnectionStore()
+	err := s.Save(context.Background(), &Connection{UserID: "u1", WorkspaceID: "ws-a", Provider: IntegrationSlack, Token: Token{AccessToken: "xoxb-a"}})
+	if err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	c, err := s.Get(context.Background(), "u1", "ws-a", IntegrationSlack)
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if c.Token.AccessToken != "xoxb-a" {
+		t.Errorf("expected xoxb-a, got %q", c.Token.AccessToken)
+	}
+	if c.CreatedAt.IsZero() {
+		t.Error("CreatedAt should be set on save")
+	}
+}
+
+func TestMemoryConnectionStore_CrossWorkspace_NotFound(t *testing.T) {
+	s := NewMemoryConnectionStore()
+	_ = s.Save(context.Background(), &Connection{UserID: "u1", WorkspaceID: "ws-a", Provider: IntegrationSlack, Token: Token{AccessToken: "xoxb-a"}})
+
+	_, err := s.Get(context.Background(), "u1", "ws-b", IntegrationSlack)
+	if !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("expected ErrConnectionNotFound for other workspace, got %v", err)
+	}
+}
+
+func TestMemoryConnectionStore_OtherUser_NotFound(t *testing.T) {
+	s := NewMemoryConnectionStore()
+	_ = s.Save(context.Background(), &Connection{UserID: "u1", WorkspaceID: "ws-a", Provider: IntegrationSlack})
+
+	_, err := s.Get(context.Background(), "u2", "ws-a", IntegrationSlack)
+	if !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("expected ErrConnectionNotFound for other user, got %v", err)
+	}
+}
+
+func TestMemoryConnectionStore_Save_RequiresUserAndProvider(t *testing.T) {
+	s := NewMemoryConnectionStore()
+	if err := s.Save(context.Background(), &Connection{WorkspaceID: "ws-a"}); err == nil {
+		t.Error("expected error for connection without user and provider")
+	}
+}
+
+// reverseCipher is a reversible stand-in for a per-user TokenCipher.
+type reverseCipher struct{}
+
+func (reverseCipher) EncryptString(_ context.Context, userID, s string) (string, error) {
+	return userID + ":" + reverse(s), nil
+}
+
+func (reverseCipher) DecryptString(_ context.Context, userID, s string) (string, error) {
+	return reverse(s[len(userID)+1:]), nil
+}
+
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+func TestEncryptedConnectionStore_EncryptsAtRest(t *testing.T) {
+	inner := NewMemoryConnectionStore()
+	s := NewEncryptedConnectionStore(inner, reverseCipher{})
+	ctx := context.Background()
+
+	conn := &Connection{UserID: "u1", Provider: IntegrationSlack, Token: Token{AccessToken: "xoxb-a", RefreshToken: "r-1"}}
+	if err := s.Save(ctx, conn); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	if conn.Token.AccessToken != "xoxb-a" {
+		t.Errorf("Save modified the caller's token: %q", conn.Token.AccessToken)
+	}
+
+	raw, _ := inner.Get(ctx, "u1", "", IntegrationSlack)
+	if raw.Token.AccessToken == "xoxb-a" || raw.Token.RefreshToken == "r-1" {
+		t.Fatalf("tokens stored in plaintext: %+v", raw.Token)
+	}
+
+	c, err := s.Get(ctx, "u1", "", IntegrationSlack)
+	if err != nil {
+		t.Fatalf("Get error: %v", err)
+	}
+	if c.Token.AccessToken != "xoxb-a" || c.Token.RefreshToken != "r-1" {
+		t.Errorf("decrypted token = %+v", c.Token)
+	}
+}
+
+func TestMemoryConnectionStore_Delete(t *testing.T) {
+	s := NewMemoryConnectionStore()
+	_ = s.Save(context.Background(), &Connection{UserID: "u1", WorkspaceID: "ws-a", Provider: IntegrationSlack})
+	_ = s.Save(context.Background(), &Connection{UserID: "u1", WorkspaceID: "ws-b", Provider: IntegrationSlack})
+
+	if err := s.Delete(context.Background(), "u1", "ws-a", IntegrationSlack); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if _, err := s.Get(context.Background(), "u1", "ws-a", IntegrationSlack); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("expected the connection to be gone, got %v", err)
+	}
+	if _, err := s.Get(context.Background(), "u1", "ws-b", IntegrationSlack); err != nil {
+		t.Errorf("other workspace's connection should remain: %v", err)
+	}
+	if err := s.Delete(context.Background(), "u1", "ws-a", IntegrationSlack); !errors.Is(err, ErrConnectionNotFound) {
+		t.Errorf("expected ErrConnectionNotFound deleting twice, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"neighbourhood/internal/middleware"
+)
+
+// VerboseLogging enables provider request/response logging for every call.
+// It is set from configuration at startup; individual requests can opt in
+// with WithVerboseLogging instead.
+var VerboseLogging bool
+
+// providerLogf is the sink for verbose provider logs, tagged with the request
+// ID from ctx; tests replace it.
+var providerLogf = middleware.Logf
+
+// maxLoggedBody caps how much of a request or response body is logged.
+const maxLoggedBody = 4096
+
+const redacted = "[REDACTED]"
+
+type verboseKey struct{}
+
+// WithVerboseLogging marks ctx so provider calls made with it are logged.
+func WithVerboseLogging(ctx context.Context) context.Context {
+	return context.WithValue(ctx, verboseKey{}, true)
+}
+
+func verboseEnabled(ctx context.Context) bool {
+	on, _ := ctx.Value(verboseKey{}).(bool)
+	return VerboseLogging || on
+}
+
+// sensitiveHeaderMarkers mask any logged request or response header whose
+// name contains one of them, so vendor headers such as X-Shopify-Access-Token
+// or Api-Token are covered without listing each one.
+var sensitiveHeaderMarkers = []string{"token", "key", "secret", "auth", "cookie", "signature", "password", "session"}
+
+// isSensitiveHeader reports whether a header is likely to carry a credential.
+func isSensitiveHeader(name string) bool {
+	n := strings.ToLower(name)
+	for _, marker := range sensitiveHeaderMarkers {
+		if strings.Contains(n, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSensitiveField reports whether a query parameter, form field or JSON key
+// is likely to hold a credential.
+func isSensitiveField(name string) bool {
+	n := strings.ToLower(name)
+	for _, marker := range []string{"token", "secret", "password", "api_key", "apikey", "signature", "authorization"} {
+		if strings.Contains(n, marker) {
+			return true
+		}
+	}
+	return n == "code" || n == "key"
+}
+
+// loggingTransport logs outbound provider calls, with secrets redacted, when
+// verbose logging is enabled for the request.
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func newLoggingTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &loggingTransport{next: next}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !verboseEnabled(req.Context()) {
+		return t.next.RoundTrip(req)
+	}
+
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(body, maxLoggedBody))
+			body.Close()
+		}
+	}
+	providerLogf(req.Context(), "provider request: %s %s headers=%v body=%s",
+		req.Method, redactURL(req.URL), redactHeaders(req.Header),
+		redactBody(req.Header.Get("Content-Type"), reqBody))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		providerLogf(req.Context(), "provider response: %s %s error=%v", req.Method, redactURL(req.URL), err)
+		return nil, err
+	}
+
+	// Buffer the logged prefix and hand the full body back to the caller.
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBody))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(respBody), resp.Body), resp.Body}
+	providerLogf(req.Context(), "provider response: %s %s status=%d headers=%v body=%s",
+		req.Method, redactURL(req.URL), resp.StatusCode, redactHeaders(resp.Header),
+		redactBody(resp.Header.Get("Content-Type"), respBody))
+	return resp, nil
+}
+
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for name := range out {
+		if isSensitiveHeader(name) {
+			out[name] = []string{redacted}
+		}
+	}
+	return out
+}
+
+func redactURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	q := c.Query()
+	for name := range q {
+		if isSensitiveField(name) {
+			q.Set(name, redacted)
+		}
+	}
+	c.RawQuery = q.Encode()
+	return c.String()
+}
+
+// redactBody masks credential fields in JSON and form-encoded bodies. Other
+// content types are omitted, since secrets in them cannot be located.
+func redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	switch {
+	case strings.Contains(contentType, "json"):
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return "[unparseable json]"
+		}
+		out, _ := json.Marshal(redactValue(v))
+		return string(out)
+	case strings.Contains(contentType, "application/x-www-form-urlencoded"):
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return "[unparseable form]"
+		}
+		for name := range form {
+			if isSensitiveField(name) {
+				form.Set(name, redacted)
+			}
+		}
+		return form.Encode()
+	default:
+		return "[" + http.DetectContentType(body) + " body omitted]"
+	}
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range t {
+			if isSensitiveField(k) {
+				t[k] = redacted
+			} else {
+				t[k] = redactValue(inner)
+			}
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+	}
+	return v
+}
@@ -0,0 +1,84 @@
+package integrations
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Errors for requests rejected before any provider call. The upstream
+// equivalents are in upstream.go.
+var (
+	// ErrProviderNotFound is returned by GetProvider for an unregistered
+	// provider.
+	ErrProviderNotFound = errors.New("provider not found")
+	// ErrMissingToken is returned by Execute when an action that calls the
+	// provider is given no token.
+	ErrMissingToken = errors.New("missing token")
+	// ErrMissingField is wrapped by errors for a required payload field that
+	// is absent.
+	ErrMissingField = errors.New("missing required field")
+	// ErrInvalidField is matched by errors for a payload field of the wrong
+	// type or format.
+	ErrInvalidField = errors.New("invalid field")
+)
+
+// missingField returns an ErrMissingField error naming key.
+func missingField(key string) error {
+	return fmt.Errorf("%w '%s'", ErrMissingField, key)
+}
+
+// fieldError keeps a field-specific message while matching ErrInvalidField
+// and any error the message wraps.
+type fieldError struct {
+	err error
+}
+
+func (e *fieldError) Error() string   { return e.err.Error() }
+func (e *fieldError) Unwrap() []error { return []error{ErrInvalidField, e.err} }
+
+// invalidField formats an ErrInvalidField error like fmt.Errorf.
+func invalidField(format string, args ...interface{}) error {
+	return &fieldError{err: fmt.Errorf(format, args...)}
+}
+
+// ErrRateLimited is returned when a provider rejects a call with 429 Too Many
+// Requests. RetryAfter is the provider's requested delay, or zero if it did
+// not give one.
+type ErrRateLimited struct {
+	Provider   string
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s rate limit exceeded, retry after %s", e.Provider, e.RetryAfter)
+	}
+	return fmt.Sprintf("%s rate limit exceeded", e.Provider)
+}
+
+// rateLimitedFromResponse builds an ErrRateLimited from a 429 response,
+// reading the Retry-After header as delta-seconds or an HTTP date.
+func rateLimitedFromResponse(provider string, resp *http.Response) *ErrRateLimited {
+	return &ErrRateLimited{Provider: provider, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+}
+
+// parseRetryAfter parses a Retry-After header value relative to now. Invalid
+// or past values yield zero.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
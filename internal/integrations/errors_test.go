@@ -0,0 +1,89 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestSlack_429_ReturnsErrRateLimited(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	p := &SlackProvider{APIBaseURL: srv.URL}
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "xoxb"}, "open_dm", map[string]interface{}{"email": "a@b.c", "text": "hi"})
+	var rl *ErrRateLimited
+	if !errors.As(err, &rl) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	if rl.RetryAfter != 7*time.Second {
+		t.Errorf("expected 7s delay, got %v", rl.RetryAfter)
+	}
+}
+
+func TestRequestErrors_MatchWithErrorsIs(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		err  func() error
+		want error
+	}{
+		{"getString missing", func() error { _, err := getString(map[string]interface{}{}, "channel"); return err }, ErrMissingField},
+		{"getString wrong type", func() error { _, err := getString(map[string]interface{}{"channel": 1}, "channel"); return err }, ErrInvalidField},
+		{"provider field check", func() error {
+			_, err := (&AsanaProvider{}).Execute(ctx, &Token{AccessToken: "t"}, "create_subtask", map[string]interface{}{"parent_gid": "task-1", "name": "x"})
+			return err
+		}, ErrInvalidField},
+		{"missing token", func() error {
+			_, err := (&AsanaProvider{}).Execute(ctx, nil, "create_subtask", map[string]interface{}{"parent_gid": "1", "name": "x"})
+			return err
+		}, ErrMissingToken},
+		{"unknown action", func() error { _, err := (&AsanaProvider{}).Execute(ctx, nil, "nope", nil); return err }, ErrUnknownAction},
+		{"unknown provider", func() error { _, err := GetProvider("nope"); return err }, ErrProviderNotFound},
+	}
+	for _, tt := range tests {
+		if err := tt.err(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want errors.Is %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestInvalidField_KeepsMessageAndCause(t *testing.T) {
+	cause := errors.New("bad timestamp")
+	err := invalidField("field 'since' must be RFC 3339: %w", cause)
+	if err.Error() != "field 'since' must be RFC 3339: bad timestamp" {
+		t.Errorf("message = %q", err.Error())
+	}
+	if !errors.Is(err, ErrInvalidField) || !errors.Is(err, cause) {
+		t.Errorf("expected to match ErrInvalidField and the cause, got %v", err)
+	}
+	if got := missingField("text").Error(); got != "missing required field 'text'" {
+		t.Errorf("missingField message = %q", got)
+	}
+}
@@ -0,0 +1,137 @@
+package integrations
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// etagCacheEntries bounds each provider's conditional-request cache.
+const etagCacheEntries = 512
+
+// maxETagBody is the largest response body kept for revalidation; larger
+// responses pass through uncached.
+const maxETagBody = 1 << 20
+
+// etagTransport makes GET requests conditional. A 200 response carrying an
+// ETag is cached; the next GET of the same URL with the same credentials
+// sends If-None-Match, and a 304 reply is answered from the cache as a 200.
+// Providers such as GitHub do not charge 304s against the rate limit, so
+// re-reading unchanged lists is free.
+type etagTransport struct {
+	next http.RoundTripper
+
+	mu      sync.Mutex
+	max     int
+	order   *list.List // of *etagEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+// etagEntry is a cached response and the validator it was served with.
+type etagEntry struct {
+	key    string
+	etag   string
+	header http.Header
+	body   []byte
+}
+
+func newETagTransport(next http.RoundTripper) *etagTransport {
+	return &etagTransport{next: next, max: etagCacheEntries, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// etagKey identifies a cached response. Credentials are part of the key, as
+// a hash, so one user is never served another's data.
+func etagKey(req *http.Request) string {
+	auth := sha256.Sum256([]byte(req.Header.Get("Authorization")))
+	return req.URL.String() + " " + hex.EncodeToString(auth[:])
+}
+
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Callers that set their own validators manage caching themselves.
+	if req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" {
+		return t.next.RoundTrip(req)
+	}
+	key := etagKey(req)
+	cached := t.get(key)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		header := cached.header.Clone()
+		// Headers sent with the 304, such as fresh rate-limit counters,
+		// replace the cached ones.
+		for name, values := range resp.Header {
+			header[name] = values
+		}
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         resp.Proto,
+			ProtoMajor:    resp.ProtoMajor,
+			ProtoMinor:    resp.ProtoMinor,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(cached.body)),
+			ContentLength: int64(len(cached.body)),
+			Request:       resp.Request,
+		}, nil
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxETagBody+1))
+		if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		if len(body) > maxETagBody {
+			resp.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+			return resp, nil
+		}
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		t.put(&etagEntry{key: key, etag: resp.Header.Get("ETag"), header: resp.Header.Clone(), body: body})
+	}
+	return resp, nil
+}
+
+// get returns the entry for key, marking it recently used.
+func (t *etagTransport) get(key string) *etagEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	el, ok := t.entries[key]
+	if !ok {
+		return nil
+	}
+	t.order.MoveToFront(el)
+	return el.Value.(*etagEntry)
+}
+
+// put stores e, evicting the least recently used entry when full.
+func (t *etagTransport) put(e *etagEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if el, ok := t.entries[e.key]; ok {
+		el.Value = e
+		t.order.MoveToFront(el)
+		return
+	}
+	t.entries[e.key] = t.order.PushFront(e)
+	if t.order.Len() > t.max {
+		oldest := t.order.Back()
+		t.order.Remove(oldest)
+		delete(t.entries, oldest.Value.(*etagEntry).key)
+	}
+}
@@ -0,0 +1,78 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// newQuotaServer serves a repo list with an ETag. Like GitHub, it charges one
+// unit of quota for each 200 and none for a 304.
+func newQuotaServer(t *testing.T, remaining *int) *httptest.Server {
+	t.Helper()
+	const etag = `W/"repos-v1"`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(*remaining))
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		*remaining--
+		w.Header().Set("ETag", etag)
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(*remaining))
+		w.Write([]byte(`[{"id":1,"full_name":"octo/a"}]`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestETag_NotModifiedServesCachedList(t *testing.T) {
+	remaining := 100
+	srv := newQuotaServer(t, &remaining)
+	p := &GitHubProvider{APIBaseURL: srv.URL}
+	tok := &Token{AccessToken: "gho_etag"}
+
+	for i := 0; i < 3; i++ {
+		res, err := p.Execute(context.Background(), tok, "list_repos", nil)
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		repos := res.(*ListPage).Items.([]githubRepo)
+		if len(repos) != 1 || repos[0].FullName != "octo/a" {
+			t.Fatalf("call %d: repos = %+v, want the cached list", i, repos)
+		}
+	}
+	if remaining != 99 {
+		t.Errorf("quota remaining = %d, want 99: only the first read should be charged", remaining)
+	}
+}
+
+func TestETag_CacheIsPerCredential(t *testing.T) {
+	remaining := 100
+	srv := newQuotaServer(t, &remaining)
+	p := &GitHubProvider{APIBaseURL: srv.URL}
+
+	for _, tok := range []string{"gho_alice", "gho_bob"} {
+		if _, err := p.Execute(context.Background(), &Token{AccessToken: tok}, "list_repos", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if remaining != 98 {
+		t.Errorf("quota remaining = %d, want 98: a cached response must not be reused across tokens", remaining)
+	}
+}
+
+func TestETagTransport_EvictsLeastRecentlyUsed(t *testing.T) {
+	tr := newETagTransport(http.DefaultTransport)
+	tr.max = 2
+	for _, key := range []string{"a", "b", "a", "c"} {
+		if tr.get(key) == nil {
+			tr.put(&etagEntry{key: key, etag: `"` + key + `"`})
+		}
+	}
+	if tr.get("b") != nil || tr.get("a") == nil || tr.get("c") == nil {
+		t.Error("expected b, the least recently used entry, to be evicted")
+	}
+}
@@ -0,0 +1,78 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// defaultDriveAPIBaseURL is the Google API root used when a
+// GoogleDriveProvider has no APIBaseURL override.
+const defaultDriveAPIBaseURL = "https://www.googleapis.com"
+
+// driveHTTPClient is shared by Google Drive API calls. Requests are bounded
+// by the provider's ActionTimeout.
+var driveHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// driveFile is the subset of a Drive file resource returned by list_files.
+type driveFile struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType"`
+}
+
+// driveGet sends a GET to the Drive API and decodes the reply into out.
+// Shared-drive items are always included via supportsAllDrives.
+func (p *GoogleDriveProvider) driveGet(ctx context.Context, token *Token, path string, query url.Values, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultDriveAPIBaseURL
+	}
+	query.Set("supportsAllDrives", "true")
+	return doJSON(ctx, driveHTTPClient, IntegrationGoogleDrive, http.MethodGet, base+path+"?"+query.Encode(), token, nil, out)
+}
+
+// listFiles returns one page of the files matching the Drive search query q.
+// A non-empty driveID searches that shared drive; otherwise allDrives selects
+// between the user's own files and every drive they can access. The page
+// token is Drive's pageToken.
+func (p *GoogleDriveProvider) listFiles(ctx context.Context, token *Token, q, driveID string, allDrives bool, pageToken string, pageSize int) (*ListPage, error) {
+	query := url.Values{
+		"fields":   {"nextPageToken,files(id,name,mimeType)"},
+		"pageSize": {strconv.Itoa(pageSize)},
+	}
+	if q != "" {
+		query.Set("q", q)
+	}
+	switch {
+	case driveID != "":
+		query.Set("corpora", "drive")
+		query.Set("driveId", driveID)
+		query.Set("includeItemsFromAllDrives", "true")
+	case allDrives:
+		query.Set("corpora", "allDrives")
+		query.Set("includeItemsFromAllDrives", "true")
+	}
+	if pageToken != "" {
+		query.Set("pageToken", pageToken)
+	}
+	var result struct {
+		Files         []driveFile `json:"files"`
+		NextPageToken string      `json:"nextPageToken"`
+	}
+	if err := p.driveGet(ctx, token, "/drive/v3/files", query, &result); err != nil {
+		return nil, err
+	}
+	return newListPage(result.Files, result.NextPageToken), nil
+}
+
+// getFile returns a file's metadata.
+func (p *GoogleDriveProvider) getFile(ctx context.Context, token *Token, fileID string) (map[string]interface{}, error) {
+	var file map[string]interface{}
+	query := url.Values{"fields": {"id,name,mimeType,size,modifiedTime,parents,driveId,webViewLink"}}
+	if err := p.driveGet(ctx, token, "/drive/v3/files/"+url.PathEscape(fileID), query, &file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
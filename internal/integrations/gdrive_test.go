@@ -0,0 +1,84 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGoogleDrive_ListFiles_PaginatedSearch(t *testing.T) {
+	var pageTokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/drive/v3/files" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if q.Get("q") != "name contains 'report'" {
+			t.Errorf("unexpected q %q", q.Get("q"))
+		}
+		if q.Get("supportsAllDrives") != "true" || q.Get("driveId") != "drive-1" || q.Get("corpora") != "drive" {
+			t.Errorf("missing shared drive params: %v", q)
+		}
+		if r.Header.Get("Authorization") != "Bearer ya29.test" {
+			t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+		}
+		pageTokens = append(pageTokens, q.Get("pageToken"))
+		if q.Get("pageToken") == "" {
+			w.Write([]byte(`{"nextPageToken":"p2","files":[{"id":"f1","name":"report-q1","mimeType":"application/pdf"}]}`))
+			return
+		}
+		w.Write([]byte(`{"files":[{"id":"f2","name":"report-q2","mimeType":"application/vnd.google-apps.spreadsheet"}]}`))
+	}))
+	defer srv.Close()
+
+	p := &GoogleDriveProvider{APIBaseURL: srv.URL}
+	token := &Token{AccessToken: "ya29.test"}
+	payload := map[string]interface{}{"q": "name contains 'report'", "drive_id": "drive-1"}
+	res, err := p.Execute(context.Background(), token, "list_files", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	page := res.(*ListPage)
+	if !page.HasMore || page.NextPageToken != "p2" {
+		t.Fatalf("first page = %+v, want more with token p2", page)
+	}
+	want := []driveFile{{ID: "f1", Name: "report-q1", MimeType: "application/pdf"}}
+	if !reflect.DeepEqual(page.Items, want) {
+		t.Errorf("first page items = %v, want %v", page.Items, want)
+	}
+
+	payload["page_token"] = page.NextPageToken
+	res, err = p.Execute(context.Background(), token, "list_files", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	page = res.(*ListPage)
+	want = []driveFile{{ID: "f2", Name: "report-q2", MimeType: "application/vnd.google-apps.spreadsheet"}}
+	if page.HasMore || page.NextPageToken != "" || !reflect.DeepEqual(page.Items, want) {
+		t.Errorf("last page = %+v, want %v with no more", page, want)
+	}
+	if !reflect.DeepEqual(pageTokens, []string{"", "p2"}) {
+		t.Errorf("unexpected page tokens %v", pageTokens)
+	}
+}
+
+func TestGoogleDrive_GetFile(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/drive/v3/files/f1" || r.URL.Query().Get("supportsAllDrives") != "true" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`{"id":"f1","name":"report","mimeType":"application/pdf","size":"2048"}`))
+	}))
+	defer srv.Close()
+
+	p := &GoogleDriveProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "ya29.test"}, "get_file", map[string]interface{}{"file_id": "f1"})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if file := res.(map[string]interface{}); file["name"] != "report" || file["mimeType"] != "application/pdf" {
+		t.Errorf("unexpected file %v", file)
+	}
+}
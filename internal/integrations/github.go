@@ -0,0 +1,167 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"neighbourhood/internal/oauthuser"
+)
+
+// defaultGitHubAPIBaseURL is the GitHub REST API root used when a
+// GitHubProvider has no APIBaseURL override.
+const defaultGitHubAPIBaseURL = "https://api.github.com"
+
+// defaultGitHubTokenURL is GitHub's OAuth token endpoint, used when a
+// GitHubProvider has no TokenURL override.
+const defaultGitHubTokenURL = "https://github.com/login/oauth/access_token"
+
+// githubHTTPClient is shared by GitHub API calls. Requests are bounded by the
+// provider's ActionTimeout; reads are revalidated with ETags, which GitHub
+// does not count against the rate limit.
+var githubHTTPClient = &http.Client{Transport: newETagTransport(newLoggingTransport(nil))}
+
+// githubRepo is the subset of a GitHub repository returned by list_repos.
+type githubRepo struct {
+	ID       int64  `json:"id"`
+	FullName string `json:"full_name"`
+	Private  bool   `json:"private"`
+	HTMLURL  string `json:"html_url"`
+}
+
+// githubIssue is the body of a create-issue request.
+type githubIssue struct {
+	Title     string   `json:"title"`
+	Body      string   `json:"body,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+}
+
+// nextLinkPage returns the page number of the rel="next" entry in a GitHub
+// Link header, or "" when there is no next page.
+func nextLinkPage(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return ""
+		}
+		return u.Query().Get("page")
+	}
+	return ""
+}
+
+// listRepos returns one page of the repositories the user can access. The
+// page token is the page number from GitHub's Link header.
+func (p *GitHubProvider) listRepos(ctx context.Context, token *Token, page string, perPage int) (*ListPage, error) {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultGitHubAPIBaseURL
+	}
+	query := url.Values{"per_page": {strconv.Itoa(perPage)}}
+	if page != "" {
+		query.Set("page", page)
+	}
+	ctx, cancel := withActionTimeout(ctx, p.Name())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, base+"/user/repos?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := githubHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github list repos: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(IntegrationGitHub, resp); err != nil {
+		return nil, fmt.Errorf("github list repos: %w", err)
+	}
+	var repos []githubRepo
+	if err := json.NewDecoder(resp.Body).Decode(&repos); err != nil {
+		return nil, fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
+	return newListPage(repos, nextLinkPage(resp.Header.Get("Link"))), nil
+}
+
+// createIssue opens an issue in repo ("owner/name"). A missing repository, or
+// one the token cannot see, fails with ErrNotFound; a rejected token with
+// ErrInvalidCredentials.
+func (p *GitHubProvider) createIssue(ctx context.Context, token *Token, repo string, issue githubIssue) (map[string]interface{}, error) {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultGitHubAPIBaseURL
+	}
+	data, err := json.Marshal(issue)
+	if err != nil {
+		return nil, fmt.Errorf("encode github issue: %w", err)
+	}
+	owner, name, _ := strings.Cut(repo, "/")
+	endpoint := base + "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(name) + "/issues"
+
+	ctx, cancel := withActionTimeout(ctx, p.Name())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := githubHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("github create issue: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(IntegrationGitHub, resp); err != nil {
+		return nil, fmt.Errorf("github create issue in %s: %w", repo, err)
+	}
+	var created struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
+	return map[string]interface{}{"status": "success", "issue_number": created.Number, "html_url": created.HTMLURL}, nil
+}
+
+// TestConnection identifies the user the token belongs to with the login
+// resolver's GET /user lookup. The email is the one on the public profile,
+// which may be empty.
+func (p *GitHubProvider) TestConnection(ctx context.Context, token *Token) (*Identity, error) {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultGitHubAPIBaseURL
+	}
+	ctx, cancel := withActionTimeout(ctx, p.Name())
+	defer cancel()
+	resolver := &oauthuser.GitHubResolver{APIBaseURL: base, HTTPClient: githubHTTPClient}
+	user, err := resolver.User(ctx, token.AccessToken)
+	var status *oauthuser.StatusError
+	if errors.As(err, &status) {
+		return nil, fmt.Errorf("github get user: %w", MapUpstreamError(IntegrationGitHub, status.StatusCode, nil))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("github get user: %w", err)
+	}
+	name := user.Name
+	if name == "" {
+		name = user.Login
+	}
+	return &Identity{ID: strconv.FormatInt(user.ID, 10), Name: name, Email: user.Email, Username: user.Login}, nil
+}
@@ -0,0 +1,188 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGitHub_ListRepos_LinkHeaderEnvelope(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user/repos" || r.Header.Get("Authorization") != "Bearer gho_test" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", `<`+srv.URL+`/user/repos?per_page=2&page=2>; rel="next", <`+srv.URL+`/user/repos?per_page=2&page=2>; rel="last"`)
+			w.Write([]byte(`[{"id":1,"full_name":"octo/a"},{"id":2,"full_name":"octo/b"}]`))
+			return
+		}
+		w.Header().Set("Link", `<`+srv.URL+`/user/repos?per_page=2&page=1>; rel="prev", <`+srv.URL+`/user/repos?per_page=2&page=1>; rel="first"`)
+		w.Write([]byte(`[{"id":3,"full_name":"octo/c"}]`))
+	}))
+	defer srv.Close()
+
+	p := &GitHubProvider{APIBaseURL: srv.URL}
+	token := &Token{AccessToken: "gho_test"}
+	res, err := p.Execute(context.Background(), token, "list_repos", map[string]interface{}{"page_size": float64(2)})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	page := res.(*ListPage)
+	if !page.HasMore || page.NextPageToken != "2" || len(page.Items.([]githubRepo)) != 2 {
+		t.Fatalf("first page = %+v, want 2 repos and next page 2", page)
+	}
+
+	res, err = p.Execute(context.Background(), token, "list_repos", map[string]interface{}{"page_token": page.NextPageToken})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	page = res.(*ListPage)
+	if page.HasMore || page.NextPageToken != "" {
+		t.Errorf("last page = %+v, want no more pages", page)
+	}
+
+	// The envelope has the same JSON shape as every other list action.
+	data, _ := json.Marshal(page)
+	var envelope map[string]interface{}
+	json.Unmarshal(data, &envelope)
+	if _, ok := envelope["items"].([]interface{}); !ok || envelope["has_more"] != false {
+		t.Errorf("unexpected envelope JSON %s", data)
+	}
+}
+
+func TestNextLinkPage(t *testing.T) {
+	for header, want := range map[string]string{
+		"": "",
+		`<https://api.github.com/user/repos?page=3&per_page=30>; rel="next", <https://api.github.com/user/repos?page=9>; rel="last"`: "3",
+		`<https://api.github.com/user/repos?page=1>; rel="prev"`:                                                                     "",
+	} {
+		if got := nextLinkPage(header); got != want {
+			t.Errorf("nextLinkPage(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestGitHub_CreateIssue(t *testing.T) {
+	var got githubIssue
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/octo/hello/issues" || r.Header.Get("Authorization") != "Bearer gho_test" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"number":1347,"html_url":"https://github.com/octo/hello/issues/1347"}`))
+	}))
+	defer srv.Close()
+
+	p := &GitHubProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "gho_test"}, "create_issue", map[string]interface{}{
+		"repo": "octo/hello", "title": "Found a bug", "body": "Steps to reproduce",
+		"labels": []interface{}{"bug"}, "assignees": []interface{}{"octocat"},
+	})
+	if err != nil {
+		t.Fatalf("create_issue: %v", err)
+	}
+	want := map[string]interface{}{"status": "success", "issue_number": 1347, "html_url": "https://github.com/octo/hello/issues/1347"}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("result = %v, want %v", res, want)
+	}
+	wantIssue := githubIssue{Title: "Found a bug", Body: "Steps to reproduce", Labels: []string{"bug"}, Assignees: []string{"octocat"}}
+	if !reflect.DeepEqual(got, wantIssue) {
+		t.Errorf("issue = %+v, want %+v", got, wantIssue)
+	}
+}
+
+func TestGitHub_CreateIssueErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gho_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"Not Found"}`))
+	}))
+	defer srv.Close()
+	p := &GitHubProvider{APIBaseURL: srv.URL}
+	payload := map[string]interface{}{"repo": "octo/missing", "title": "t"}
+
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "gho_test"}, "create_issue", payload)
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("missing repo: expected ErrNotFound, got %v", err)
+	}
+	_, err = p.Execute(context.Background(), &Token{AccessToken: "revoked"}, "create_issue", payload)
+	if !errors.Is(err, ErrInvalidCredentials) || errors.Is(err, ErrNotFound) {
+		t.Errorf("bad token: expected ErrInvalidCredentials, got %v", err)
+	}
+	_, err = p.Execute(context.Background(), &Token{AccessToken: "gho_test"}, "create_issue", map[string]interface{}{"repo": "hello", "title": "t"})
+	if err == nil {
+		t.Error("expected an error for a repo without an owner")
+	}
+}
+
+func TestGitHub_ExchangeCode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/json" {
+			t.Errorf("Accept = %q, want application/json", r.Header.Get("Accept"))
+		}
+		r.ParseForm()
+		if r.PostForm.Get("client_id") != "cid" || r.PostForm.Get("client_secret") != "secret" {
+			t.Errorf("unexpected form %v", r.PostForm)
+		}
+		// GitHub reports a bad code with 200 and an error field.
+		if r.PostForm.Get("code") != "good" {
+			w.Write([]byte(`{"error":"bad_verification_code","error_description":"The code passed is incorrect or expired."}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"gho_new","token_type":"bearer","scope":"repo,user"}`))
+	}))
+	defer srv.Close()
+	p := &GitHubProvider{ClientID: "cid", ClientSecret: "secret", TokenURL: srv.URL}
+
+	tok, err := p.ExchangeCode(context.Background(), "good")
+	if err != nil {
+		t.Fatalf("ExchangeCode: %v", err)
+	}
+	if tok.AccessToken != "gho_new" || tok.TokenType != "bearer" {
+		t.Errorf("token = %+v", tok)
+	}
+	if _, err := p.ExchangeCode(context.Background(), "stale"); err == nil {
+		t.Error("expected an error for a rejected code")
+	}
+}
+
+func TestGitHub_GetMe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Header.Get("Authorization") != "Bearer gho_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id":583231,"login":"octocat","name":null,"email":"octocat@example.com"}`))
+	}))
+	defer srv.Close()
+
+	p := &GitHubProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "gho_test"}, "get_me", nil)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	want := map[string]interface{}{"status": "success", "id": "583231", "name": "octocat", "email": "octocat@example.com", "username": "octocat"}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("get_me = %v, want %v", res, want)
+	}
+
+	if _, err := p.Execute(context.Background(), nil, "get_me", nil); err == nil {
+		t.Error("expected an error without a token")
+	}
+	if _, err := p.TestConnection(context.Background(), &Token{AccessToken: "revoked"}); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials for a revoked token, got %v", err)
+	}
+}
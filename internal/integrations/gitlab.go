@@ -0,0 +1,120 @@
+package integrations
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// defaultGitLabAPIBaseURL is the GitLab root used when a GitLabProvider has
+// no APIBaseURL override.
+const defaultGitLabAPIBaseURL = "https://gitlab.com"
+
+// gitlabHTTPClient is shared by GitLab API calls. Requests are bounded by the
+// provider's ActionTimeout.
+var gitlabHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// gitlabCommitActions are the file operations a commit action may perform.
+var gitlabCommitActions = map[string]bool{
+	"create": true, "update": true, "delete": true, "move": true, "chmod": true,
+}
+
+// gitlabFile is a repository file as returned by the files API.
+type gitlabFile struct {
+	FileName     string `json:"file_name"`
+	FilePath     string `json:"file_path"`
+	Size         int64  `json:"size"`
+	Encoding     string `json:"encoding"`
+	Content      string `json:"content"`
+	Ref          string `json:"ref"`
+	BlobID       string `json:"blob_id"`
+	CommitID     string `json:"commit_id"`
+	LastCommitID string `json:"last_commit_id"`
+}
+
+// gitlabCommit is the part of a commit GitLab returns on creation.
+type gitlabCommit struct {
+	ID      string `json:"id"`
+	ShortID string `json:"short_id"`
+	WebURL  string `json:"web_url"`
+}
+
+// gitlabProjectPath returns the API path of project, which may be a numeric
+// ID or a "group/project" path.
+func gitlabProjectPath(project string) string {
+	return "/api/v4/projects/" + url.PathEscape(project)
+}
+
+// gitlabCall sends a JSON request to the GitLab API and decodes the reply
+// into out.
+func (p *GitLabProvider) gitlabCall(ctx context.Context, token *Token, method, path string, body, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultGitLabAPIBaseURL
+	}
+	return doJSON(ctx, gitlabHTTPClient, IntegrationGitLab, method, base+path, token, body, out)
+}
+
+// getFile reads filePath at ref and returns it with its content decoded.
+func (p *GitLabProvider) getFile(ctx context.Context, token *Token, project, filePath, ref string) (map[string]interface{}, error) {
+	path := gitlabProjectPath(project) + "/repository/files/" + url.PathEscape(filePath) + "?ref=" + url.QueryEscape(ref)
+	var file gitlabFile
+	if err := p.gitlabCall(ctx, token, http.MethodGet, path, nil, &file); err != nil {
+		return nil, err
+	}
+	content := file.Content
+	if file.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(file.Content)
+		if err != nil {
+			return nil, fmt.Errorf("decode gitlab file content: %w", err)
+		}
+		content = string(decoded)
+	}
+	return map[string]interface{}{
+		"file_path":      file.FilePath,
+		"ref":            file.Ref,
+		"content":        content,
+		"size":           file.Size,
+		"blob_id":        file.BlobID,
+		"last_commit_id": file.LastCommitID,
+	}, nil
+}
+
+// createCommit commits actions to branch and returns the new commit.
+func (p *GitLabProvider) createCommit(ctx context.Context, token *Token, project string, body map[string]interface{}) (*gitlabCommit, error) {
+	var commit gitlabCommit
+	if err := p.gitlabCall(ctx, token, http.MethodPost, gitlabProjectPath(project)+"/repository/commits", body, &commit); err != nil {
+		return nil, err
+	}
+	return &commit, nil
+}
+
+// gitlabCommitActionsFrom validates the "actions" payload field of
+// create_commit.
+func gitlabCommitActionsFrom(payload map[string]interface{}) ([]map[string]interface{}, error) {
+	raw, ok := payload["actions"].([]interface{})
+	if !ok || len(raw) == 0 {
+		return nil, invalidField("field 'actions' must be a non-empty array")
+	}
+	actions := make([]map[string]interface{}, 0, len(raw))
+	for i, item := range raw {
+		action, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, invalidField("actions[%d] must be an object, got %T", i, item)
+		}
+		kind, err := getString(action, "action")
+		if err != nil {
+			return nil, fmt.Errorf("actions[%d]: %w", i, err)
+		}
+		if !gitlabCommitActions[kind] {
+			return nil, invalidField("actions[%d]: unsupported action %q", i, kind)
+		}
+		if _, err := getString(action, "file_path"); err != nil {
+			return nil, fmt.Errorf("actions[%d]: %w", i, err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
@@ -0,0 +1,87 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitLab_GetFile_DecodesContent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.EscapedPath() != "/api/v4/projects/ops%2Fdeploy/repository/files/config%2Fapp.yaml" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+		}
+		if r.URL.Query().Get("ref") != "main" {
+			t.Errorf("ref = %q, want main", r.URL.Query().Get("ref"))
+		}
+		if r.Header.Get("Authorization") != "Bearer gl-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		w.Write([]byte(`{"file_path":"config/app.yaml","ref":"main","encoding":"base64","content":"cmVwbGljYXM6IDMK","size":12,"blob_id":"b1","last_commit_id":"c1"}`))
+	}))
+	defer srv.Close()
+
+	p := &GitLabProvider{APIBaseURL: srv.URL}
+	payload := map[string]interface{}{"project": "ops/deploy", "file_path": "config/app.yaml", "ref": "main"}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "gl-token"}, "get_file", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	file := res.(map[string]interface{})
+	if file["content"] != "replicas: 3\n" || file["last_commit_id"] != "c1" {
+		t.Errorf("unexpected file %v", file)
+	}
+}
+
+func TestGitLab_CreateCommit_ReturnsSHA(t *testing.T) {
+	var sent struct {
+		Branch        string                   `json:"branch"`
+		CommitMessage string                   `json:"commit_message"`
+		Actions       []map[string]interface{} `json:"actions"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.EscapedPath() != "/api/v4/projects/ops%2Fdeploy/repository/commits" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"ed899a2f4b50b4370feeea94676502b42383c746","short_id":"ed899a2f","web_url":"https://gitlab.com/ops/deploy/-/commit/ed899a2f"}`))
+	}))
+	defer srv.Close()
+
+	p := &GitLabProvider{APIBaseURL: srv.URL}
+	payload := map[string]interface{}{
+		"project":        "ops/deploy",
+		"branch":         "main",
+		"commit_message": "Scale to 4 replicas",
+		"actions": []interface{}{
+			map[string]interface{}{"action": "update", "file_path": "config/app.yaml", "content": "replicas: 4\n"},
+		},
+	}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "gl-token"}, "create_commit", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := res.(map[string]string)["commit_sha"]; got != "ed899a2f4b50b4370feeea94676502b42383c746" {
+		t.Errorf("commit_sha = %q", got)
+	}
+	if sent.Branch != "main" || sent.CommitMessage != "Scale to 4 replicas" || len(sent.Actions) != 1 {
+		t.Fatalf("unexpected commit body %+v", sent)
+	}
+	if a := sent.Actions[0]; a["action"] != "update" || a["file_path"] != "config/app.yaml" || a["content"] != "replicas: 4\n" {
+		t.Errorf("unexpected action %v", a)
+	}
+}
+
+func TestGitLab_CreateCommit_InvalidAction(t *testing.T) {
+	p := &GitLabProvider{}
+	payload := map[string]interface{}{
+		"project": "ops/deploy", "branch": "main", "commit_message": "m",
+		"actions": []interface{}{map[string]interface{}{"action": "rename", "file_path": "a"}},
+	}
+	if _, err := p.Execute(context.Background(), &Token{AccessToken: "gl-token"}, "create_commit", payload); err == nil {
+		t.Error("expected an error for an unsupported commit action")
+	}
+}
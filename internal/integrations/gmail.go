@@ -0,0 +1,76 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// defaultGmailAPIBaseURL is the Gmail API root used when a GmailProvider has
+// no APIBaseURL override.
+const defaultGmailAPIBaseURL = "https://gmail.googleapis.com"
+
+// gmailHTTPClient is shared by Gmail API calls. Requests are bounded by the
+// provider's ActionTimeout.
+var gmailHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// gmailMessage is the part of a sent message Gmail returns.
+type gmailMessage struct {
+	ID       string `json:"id"`
+	ThreadID string `json:"threadId"`
+}
+
+// gmailEmail is a plain-text email to send.
+type gmailEmail struct {
+	From, To, Cc, Bcc string
+	Subject, Body     string
+}
+
+// buildGmailMessage renders e as an RFC 2822 message. Header values holding
+// line breaks are rejected so a payload cannot inject extra headers.
+func buildGmailMessage(e gmailEmail) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, h := range []struct{ name, value string }{
+		{"From", e.From},
+		{"To", e.To},
+		{"Cc", e.Cc},
+		{"Bcc", e.Bcc},
+		{"Subject", mime.QEncoding.Encode("utf-8", e.Subject)},
+	} {
+		if h.value == "" {
+			continue
+		}
+		if strings.ContainsAny(h.value, "\r\n") {
+			return nil, invalidField("field '%s' must not contain line breaks", strings.ToLower(h.name))
+		}
+		fmt.Fprintf(&buf, "%s: %s\r\n", h.name, h.value)
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(e.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes(), nil
+}
+
+// sendEmail sends e from the token's mailbox and returns the new message.
+// A rejected token is ErrInvalidCredentials, so callers can refresh it.
+func (p *GmailProvider) sendEmail(ctx context.Context, token *Token, e gmailEmail) (*gmailMessage, error) {
+	raw, err := buildGmailMessage(e)
+	if err != nil {
+		return nil, err
+	}
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultGmailAPIBaseURL
+	}
+	body := map[string]string{"raw": base64.URLEncoding.EncodeToString(raw)}
+	var msg gmailMessage
+	if err := doJSON(ctx, gmailHTTPClient, IntegrationGmail, http.MethodPost, base+"/gmail/v1/users/me/messages/send", token, body, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
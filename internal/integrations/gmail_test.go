@@ -0,0 +1,95 @@
+package integrations
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc stubs outbound provider calls.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// stubGmail routes Gmail API calls to handle until the test ends.
+func stubGmail(t *testing.T, handle func(*http.Request) (int, string)) {
+	t.Helper()
+	orig := gmailHTTPClient
+	gmailHTTPClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		status, body := handle(r)
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}}, nil
+	})}
+	t.Cleanup(func() { gmailHTTPClient = orig })
+}
+
+func TestGmail_SendEmail_EncodesRawMessage(t *testing.T) {
+	var raw string
+	stubGmail(t, func(r *http.Request) (int, string) {
+		if r.URL.String() != "https://gmail.googleapis.com/gmail/v1/users/me/messages/send" {
+			t.Errorf("unexpected URL %s", r.URL)
+		}
+		if r.Header.Get("Authorization") != "Bearer ya29-test" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		var sent struct {
+			Raw string `json:"raw"`
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		decoded, err := base64.URLEncoding.DecodeString(sent.Raw)
+		if err != nil {
+			t.Errorf("raw is not base64url: %v", err)
+		}
+		raw = string(decoded)
+		return http.StatusOK, `{"id":"18c1","threadId":"18c0","labelIds":["SENT"]}`
+	})
+
+	payload := map[string]interface{}{
+		"to": "a@b.com", "cc": "c@d.com", "bcc": "e@f.com", "from": "me@b.com",
+		"subject": "Café hours", "body": "Line one\nLine two",
+	}
+	res, err := (&GmailProvider{}).Execute(context.Background(), &Token{AccessToken: "ya29-test"}, "send_email", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := res.(map[string]string); got["message_id"] != "18c1" || got["thread_id"] != "18c0" {
+		t.Errorf("unexpected result %v", got)
+	}
+	for _, want := range []string{
+		"From: me@b.com\r\n", "To: a@b.com\r\n", "Cc: c@d.com\r\n", "Bcc: e@f.com\r\n",
+		"Subject: =?utf-8?q?Caf=C3=A9_hours?=\r\n",
+		"Content-Type: text/plain; charset=\"UTF-8\"\r\n",
+		"\r\n\r\nLine one\r\nLine two",
+	} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("raw message missing %q:\n%s", want, raw)
+		}
+	}
+}
+
+func TestGmail_SendEmail_RejectedTokenIsInvalidCredentials(t *testing.T) {
+	stubGmail(t, func(*http.Request) (int, string) {
+		return http.StatusUnauthorized, `{"error":{"code":401,"message":"Invalid Credentials","status":"UNAUTHENTICATED"}}`
+	})
+	payload := map[string]interface{}{"to": "a@b.com", "subject": "s", "body": "b"}
+
+	_, err := (&GmailProvider{}).Execute(context.Background(), &Token{AccessToken: "expired"}, "send_email", payload)
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("401 error = %v, want ErrInvalidCredentials", err)
+	}
+	_, err = (&GmailProvider{}).Execute(context.Background(), nil, "send_email", payload)
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("missing token error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestGmail_SendEmail_RejectsHeaderInjection(t *testing.T) {
+	payload := map[string]interface{}{"to": "a@b.com\r\nBcc: victim@x.com", "subject": "s", "body": "b"}
+	if _, err := (&GmailProvider{}).Execute(context.Background(), &Token{AccessToken: "ya29"}, "send_email", payload); err == nil {
+		t.Error("expected an error for a recipient containing a line break")
+	}
+}
@@ -0,0 +1,144 @@
+package integrations
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Execution statuses recorded in the history.
+const (
+	ExecutionSucceeded = "success"
+	ExecutionFailed    = "error"
+)
+
+// Execution is a single recorded provider action invocation.
+type Execution struct {
+	UserID      string
+	WorkspaceID string
+	Provider    IntegrationType
+	Action      string
+	Status      string
+	Duration    time.Duration
+	Timestamp   time.Time
+	// WorkflowRunID identifies the workflow run that made the call; it is
+	// empty for actions executed directly.
+	WorkflowRunID string
+	// ActorID is the admin who made the call while impersonating UserID.
+	ActorID string
+}
+
+// ExecutionHistory records provider action executions for auditing and export.
+// Implementations must be safe for concurrent use.
+type ExecutionHistory interface {
+	// Record appends an execution to the history.
+	Record(ctx context.Context, e Execution) error
+	// List returns the executions for a user and workspace whose timestamp
+	// falls within [from, to), oldest first. A zero from or to leaves that
+	// end of the range open.
+	List(ctx context.Context, userID, workspaceID string, from, to time.Time) ([]Execution, error)
+	// Each calls fn with the executions List would return, in the same
+	// order, as they are read, so a long history is never held in memory at
+	// once. It stops at and returns fn's first error.
+	Each(ctx context.Context, userID, workspaceID string, from, to time.Time, fn func(Execution) error) error
+	// ListRun returns the executions made by a workflow run, oldest first.
+	ListRun(ctx context.Context, runID string) ([]Execution, error)
+	// ListWorkspace returns every user's executions in a workspace, with the
+	// same range semantics as List.
+	ListWorkspace(ctx context.Context, workspaceID string, from, to time.Time) ([]Execution, error)
+}
+
+// defaultHistoryCapacity bounds the in-memory history so a long-running
+// process does not grow without limit.
+const defaultHistoryCapacity = 10000
+
+// MemoryExecutionHistory is an in-process ExecutionHistory that keeps the most
+// recent executions up to a fixed capacity.
+type MemoryExecutionHistory struct {
+	mu       sync.RWMutex
+	entries  []Execution
+	capacity int
+}
+
+// NewMemoryExecutionHistory creates an empty in-memory execution history.
+func NewMemoryExecutionHistory() *MemoryExecutionHistory {
+	return &MemoryExecutionHistory{capacity: defaultHistoryCapacity}
+}
+
+// Record appends e, dropping the oldest entry once capacity is reached.
+func (h *MemoryExecutionHistory) Record(_ context.Context, e Execution) error {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.entries) >= h.capacity {
+		h.entries = h.entries[1:]
+	}
+	h.entries = append(h.entries, e)
+	return nil
+}
+
+// List returns a copy of the matching executions in insertion order.
+func (h *MemoryExecutionHistory) List(_ context.Context, userID, workspaceID string, from, to time.Time) ([]Execution, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var out []Execution
+	for _, e := range h.entries {
+		if e.UserID == userID && e.WorkspaceID == workspaceID && inRange(e, from, to) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// Each calls fn with each matching execution in insertion order. The
+// matches are copied first, so fn runs without holding the history's lock.
+func (h *MemoryExecutionHistory) Each(ctx context.Context, userID, workspaceID string, from, to time.Time, fn func(Execution) error) error {
+	entries, _ := h.List(ctx, userID, workspaceID, from, to)
+	for _, e := range entries {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListWorkspace returns a copy of the workspace's matching executions in
+// insertion order.
+func (h *MemoryExecutionHistory) ListWorkspace(_ context.Context, workspaceID string, from, to time.Time) ([]Execution, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var out []Execution
+	for _, e := range h.entries {
+		if e.WorkspaceID == workspaceID && inRange(e, from, to) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// inRange reports whether e falls within [from, to); a zero bound is open.
+func inRange(e Execution, from, to time.Time) bool {
+	if !from.IsZero() && e.Timestamp.Before(from) {
+		return false
+	}
+	return to.IsZero() || e.Timestamp.Before(to)
+}
+
+// ListRun returns a copy of the executions recorded for runID.
+func (h *MemoryExecutionHistory) ListRun(_ context.Context, runID string) ([]Execution, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var out []Execution
+	for _, e := range h.entries {
+		if runID != "" && e.WorkflowRunID == runID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
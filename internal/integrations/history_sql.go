@@ -0,0 +1,152 @@
+package integrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"neighbourhood/internal/outbox"
+)
+
+// SQLExecutionHistory is the PostgreSQL-backed ExecutionHistory, kept in the
+// integration_executions table.
+type SQLExecutionHistory struct {
+	db *sql.DB
+}
+
+// NewSQLExecutionHistory creates an execution history backed by db.
+func NewSQLExecutionHistory(db *sql.DB) *SQLExecutionHistory {
+	return &SQLExecutionHistory{db: db}
+}
+
+// Record inserts e.
+func (h *SQLExecutionHistory) Record(ctx context.Context, e Execution) error {
+	return h.insert(ctx, h.db, e)
+}
+
+// RecordWithEvent inserts e and enqueues an outbox event about it in one
+// transaction, so the event is delivered if and only if e is recorded.
+func (h *SQLExecutionHistory) RecordWithEvent(ctx context.Context, e Execution, topic string, payload interface{}) error {
+	tx, err := h.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin execution record: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := h.insert(ctx, tx, e); err != nil {
+		return err
+	}
+	if err := outbox.Enqueue(ctx, tx, topic, payload); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit execution record: %w", err)
+	}
+	return nil
+}
+
+// insert writes e using exec, a *sql.DB or the *sql.Tx it belongs to.
+func (h *SQLExecutionHistory) insert(ctx context.Context, exec outbox.Execer, e Execution) error {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	_, err := exec.ExecContext(ctx, `
+		INSERT INTO integration_executions (user_id, workspace_id, workflow_run_id, provider, action, status, duration_ms, actor_id, executed_at)
+		VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, NULLIF($8, ''), $9)`,
+		e.UserID, e.WorkspaceID, e.WorkflowRunID, string(e.Provider), e.Action, e.Status,
+		e.Duration.Milliseconds(), e.ActorID, e.Timestamp)
+	if err != nil {
+		return fmt.Errorf("record execution: %w", err)
+	}
+	return nil
+}
+
+// executionColumns is the select list scanned by scanExecutions.
+const executionColumns = `user_id::text, workspace_id, COALESCE(workflow_run_id::text, ''), provider, action, status, duration_ms, COALESCE(actor_id, ''), executed_at`
+
+// listQuery selects a user's executions in a workspace and time range.
+const listQuery = `
+		SELECT ` + executionColumns + ` FROM integration_executions
+		WHERE user_id = $1 AND workspace_id = $2
+			AND ($3::timestamptz IS NULL OR executed_at >= $3)
+			AND ($4::timestamptz IS NULL OR executed_at < $4)
+		ORDER BY executed_at`
+
+// List returns the matching executions oldest first.
+func (h *SQLExecutionHistory) List(ctx context.Context, userID, workspaceID string, from, to time.Time) ([]Execution, error) {
+	return h.query(ctx, listQuery, userID, workspaceID, nullTime(from), nullTime(to))
+}
+
+// Each calls fn with each matching execution, oldest first, as its row is
+// scanned.
+func (h *SQLExecutionHistory) Each(ctx context.Context, userID, workspaceID string, from, to time.Time, fn func(Execution) error) error {
+	return h.each(ctx, fn, listQuery, userID, workspaceID, nullTime(from), nullTime(to))
+}
+
+// ListWorkspace returns every user's matching executions in workspaceID,
+// oldest first.
+func (h *SQLExecutionHistory) ListWorkspace(ctx context.Context, workspaceID string, from, to time.Time) ([]Execution, error) {
+	return h.query(ctx, `
+		SELECT `+executionColumns+` FROM integration_executions
+		WHERE workspace_id = $1
+			AND ($2::timestamptz IS NULL OR executed_at >= $2)
+			AND ($3::timestamptz IS NULL OR executed_at < $3)
+		ORDER BY executed_at`,
+		workspaceID, nullTime(from), nullTime(to))
+}
+
+// ListRun returns the executions recorded for runID, oldest first.
+func (h *SQLExecutionHistory) ListRun(ctx context.Context, runID string) ([]Execution, error) {
+	if runID == "" {
+		return nil, nil
+	}
+	return h.query(ctx, `
+		SELECT `+executionColumns+` FROM integration_executions
+		WHERE workflow_run_id = $1::uuid
+		ORDER BY executed_at`, runID)
+}
+
+func (h *SQLExecutionHistory) query(ctx context.Context, query string, args ...interface{}) ([]Execution, error) {
+	var out []Execution
+	err := h.each(ctx, func(e Execution) error {
+		out = append(out, e)
+		return nil
+	}, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// each runs query and calls fn with each execution as its row is scanned.
+func (h *SQLExecutionHistory) each(ctx context.Context, fn func(Execution) error, query string, args ...interface{}) error {
+	rows, err := h.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("list executions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Execution
+		var provider string
+		var durationMS int64
+		if err := rows.Scan(&e.UserID, &e.WorkspaceID, &e.WorkflowRunID, &provider, &e.Action, &e.Status, &durationMS, &e.ActorID, &e.Timestamp); err != nil {
+			return fmt.Errorf("scan execution: %w", err)
+		}
+		e.Provider = IntegrationType(provider)
+		e.Duration = time.Duration(durationMS) * time.Millisecond
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// nullTime maps an open range bound to NULL.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
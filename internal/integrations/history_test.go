@@ -0,0 +1,67 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryExecutionHistory_ListFiltersByScopeAndRange(t *testing.T) {
+	h := NewMemoryExecutionHistory()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_ = h.Record(context.Background(), Execution{UserID: "u1", WorkspaceID: "ws-a", Action: "early", Timestamp: base})
+	_ = h.Record(context.Background(), Execution{UserID: "u1", WorkspaceID: "ws-a", Action: "in", Timestamp: base.Add(time.Hour)})
+	_ = h.Record(context.Background(), Execution{UserID: "u1", WorkspaceID: "ws-b", Action: "other-ws", Timestamp: base.Add(time.Hour)})
+	_ = h.Record(context.Background(), Execution{UserID: "u2", WorkspaceID: "ws-a", Action: "other-user", Timestamp: base.Add(time.Hour)})
+
+	got, err := h.List(context.Background(), "u1", "ws-a", base.Add(time.Minute), time.Time{})
+	if err != nil {
+		t.Fatalf("List error: %v", err)
+	}
+	if len(got) != 1 || got[0].Action != "in" {
+		t.Errorf("expected only the in-range entry, got %+v", got)
+	}
+}
+
+func TestMemoryExecutionHistory_DropsOldestAtCapacity(t *testing.T) {
+	h := &MemoryExecutionHistory{capacity: 2}
+	for _, a := range []string{"a", "b", "c"} {
+		_ = h.Record(context.Background(), Execution{UserID: "u1", Action: a})
+	}
+	got, _ := h.List(context.Background(), "u1", "", time.Time{}, time.Time{})
+	if len(got) != 2 || got[0].Action != "b" || got[1].Action != "c" {
+		t.Errorf("expected [b c], got %+v", got)
+	}
+}
+
+func TestMemoryExecutionHistory_ListWorkspaceSpansUsers(t *testing.T) {
+	h := NewMemoryExecutionHistory()
+	_ = h.Record(context.Background(), Execution{UserID: "u1", WorkspaceID: "ws-a", Action: "a"})
+	_ = h.Record(context.Background(), Execution{UserID: "u2", WorkspaceID: "ws-a", Action: "b"})
+	_ = h.Record(context.Background(), Execution{UserID: "u1", WorkspaceID: "ws-b", Action: "other-ws"})
+
+	got, _ := h.ListWorkspace(context.Background(), "ws-a", time.Time{}, time.Time{})
+	if len(got) != 2 || got[0].Action != "a" || got[1].Action != "b" {
+		t.Errorf("expected [a b], got %+v", got)
+	}
+}
+
+func TestMemoryExecutionHistory_EachStopsAtError(t *testing.T) {
+	h := NewMemoryExecutionHistory()
+	for _, action := range []string{"a", "b", "c"} {
+		_ = h.Record(context.Background(), Execution{UserID: "u1", Action: action})
+	}
+	stop := errors.New("stop")
+	var seen []string
+	err := h.Each(context.Background(), "u1", "", time.Time{}, time.Time{}, func(e Execution) error {
+		seen = append(seen, e.Action)
+		if e.Action == "b" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || len(seen) != 2 {
+		t.Errorf("Each = %v after %v, want stop after [a b]", err, seen)
+	}
+}
@@ -0,0 +1,147 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultHubSpotAPIBaseURL is the HubSpot API root used when a
+// HubSpotProvider has no APIBaseURL override.
+const defaultHubSpotAPIBaseURL = "https://api.hubapi.com"
+
+// hubspotHTTPClient is shared by HubSpot API calls. Requests are bounded by
+// the provider's ActionTimeout.
+var hubspotHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// hubspotObjectTypes maps the object type names accepted in payloads to the
+// CRM object type used in API paths.
+var hubspotObjectTypes = map[string]string{
+	"contact":   "contacts",
+	"contacts":  "contacts",
+	"company":   "companies",
+	"companies": "companies",
+	"deal":      "deals",
+	"deals":     "deals",
+	"note":      "notes",
+	"notes":     "notes",
+	"call":      "calls",
+	"calls":     "calls",
+}
+
+// hubspotAssociationTypes holds the HubSpot-defined association type IDs for
+// each supported from→to object pair. Pairs missing here cannot be associated.
+var hubspotAssociationTypes = map[[2]string]int{
+	{"contacts", "deals"}:     4,
+	{"deals", "contacts"}:     3,
+	{"contacts", "companies"}: 279,
+	{"companies", "contacts"}: 280,
+	{"deals", "companies"}:    341,
+	{"companies", "deals"}:    342,
+	{"notes", "contacts"}:     202,
+	{"notes", "deals"}:        214,
+	{"calls", "contacts"}:     194,
+	{"calls", "deals"}:        206,
+}
+
+// hubspotEngagementBodies names the property holding an engagement's text.
+var hubspotEngagementBodies = map[string]string{
+	"notes": "hs_note_body",
+	"calls": "hs_call_body",
+}
+
+// hubspotObject is the part of a CRM object HubSpot returns on creation.
+type hubspotObject struct {
+	ID string `json:"id"`
+}
+
+// hubspotAssociation links a new object to an existing one in a create call.
+type hubspotAssociation struct {
+	To    hubspotObject            `json:"to"`
+	Types []hubspotAssociationType `json:"types"`
+}
+
+// hubspotAssociationType is one label of an association.
+type hubspotAssociationType struct {
+	Category string `json:"associationCategory"`
+	TypeID   int    `json:"associationTypeId"`
+}
+
+// hubspotObjectType resolves a payload object type name.
+func hubspotObjectType(name string) (string, error) {
+	objectType, ok := hubspotObjectTypes[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", invalidField("unsupported object type %q", name)
+	}
+	return objectType, nil
+}
+
+// hubspotAssociationTypeID returns the association type linking from to.
+func hubspotAssociationTypeID(from, to string) (int, error) {
+	id, ok := hubspotAssociationTypes[[2]string{from, to}]
+	if !ok {
+		return 0, invalidField("cannot associate %s with %s", from, to)
+	}
+	return id, nil
+}
+
+// hubspotCall sends a JSON request to the HubSpot API and decodes the reply
+// into out, which may be nil for replies without a body worth reading.
+func (p *HubSpotProvider) hubspotCall(ctx context.Context, token *Token, method, path string, body, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultHubSpotAPIBaseURL
+	}
+	return doJSON(ctx, hubspotHTTPClient, IntegrationHubSpot, method, base+path, token, body, out)
+}
+
+// createObject creates a CRM object of objectType and returns its ID.
+func (p *HubSpotProvider) createObject(ctx context.Context, token *Token, objectType string, properties map[string]string, associations []hubspotAssociation) (string, error) {
+	body := map[string]interface{}{"properties": properties}
+	if len(associations) > 0 {
+		body["associations"] = associations
+	}
+	var created hubspotObject
+	if err := p.hubspotCall(ctx, token, http.MethodPost, "/crm/v3/objects/"+objectType, body, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// associate links two existing objects with a HubSpot-defined association.
+func (p *HubSpotProvider) associate(ctx context.Context, token *Token, fromType, fromID, toType, toID string, typeID int) error {
+	path := fmt.Sprintf("/crm/v4/objects/%s/%s/associations/%s/%s",
+		fromType, url.PathEscape(fromID), toType, url.PathEscape(toID))
+	body := []hubspotAssociationType{{Category: "HUBSPOT_DEFINED", TypeID: typeID}}
+	return p.hubspotCall(ctx, token, http.MethodPut, path, body, nil)
+}
+
+// logEngagement records a note or call, associated with the given contact
+// and deal when their IDs are set, and returns the engagement's ID.
+func (p *HubSpotProvider) logEngagement(ctx context.Context, token *Token, objectType, text, title, contactID, dealID string, at time.Time) (string, error) {
+	properties := map[string]string{
+		"hs_timestamp":                      at.UTC().Format(time.RFC3339),
+		hubspotEngagementBodies[objectType]: text,
+	}
+	if title != "" && objectType == "calls" {
+		properties["hs_call_title"] = title
+	}
+	var associations []hubspotAssociation
+	for _, target := range []struct{ objectType, id string }{{"contacts", contactID}, {"deals", dealID}} {
+		if target.id == "" {
+			continue
+		}
+		typeID, err := hubspotAssociationTypeID(objectType, target.objectType)
+		if err != nil {
+			return "", err
+		}
+		associations = append(associations, hubspotAssociation{
+			To:    hubspotObject{ID: target.id},
+			Types: []hubspotAssociationType{{Category: "HUBSPOT_DEFINED", TypeID: typeID}},
+		})
+	}
+	return p.createObject(ctx, token, objectType, properties, associations)
+}
@@ -0,0 +1,113 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHubSpot_CreateDeal_ReturnsID(t *testing.T) {
+	var sent struct {
+		Properties   map[string]string    `json:"properties"`
+		Associations []hubspotAssociation `json:"associations"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/crm/v3/objects/deals" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer hs-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"9001","properties":{"dealname":"Renewal"}}`))
+	}))
+	defer srv.Close()
+
+	p := &HubSpotProvider{APIBaseURL: srv.URL}
+	payload := map[string]interface{}{"dealname": "Renewal", "amount": 1500.5, "dealstage": "appointmentscheduled", "contact_id": "51"}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "hs-token"}, "create_deal", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := res.(map[string]string)["deal_id"]; got != "9001" {
+		t.Errorf("deal_id = %q, want 9001", got)
+	}
+	if sent.Properties["dealname"] != "Renewal" || sent.Properties["amount"] != "1500.5" || sent.Properties["dealstage"] != "appointmentscheduled" {
+		t.Errorf("unexpected properties %v", sent.Properties)
+	}
+	if len(sent.Associations) != 1 || sent.Associations[0].To.ID != "51" || sent.Associations[0].Types[0].TypeID != 3 {
+		t.Errorf("deal not associated with the contact: %+v", sent.Associations)
+	}
+}
+
+func TestHubSpot_Associate_ContactWithDeal(t *testing.T) {
+	var sent []hubspotAssociationType
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/crm/v4/objects/contacts/51/associations/deals/9001" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"fromObjectTypeId":"0-1","fromObjectId":51,"toObjectTypeId":"0-3","toObjectId":9001}`))
+	}))
+	defer srv.Close()
+
+	p := &HubSpotProvider{APIBaseURL: srv.URL}
+	payload := map[string]interface{}{"from_type": "contact", "from_id": "51", "to_type": "deals", "to_id": "9001"}
+	if _, err := p.Execute(context.Background(), &Token{AccessToken: "hs-token"}, "associate", payload); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if len(sent) != 1 || sent[0].Category != "HUBSPOT_DEFINED" || sent[0].TypeID != 4 {
+		t.Errorf("unexpected association types %+v", sent)
+	}
+}
+
+func TestHubSpot_Associate_UnsupportedPair(t *testing.T) {
+	p := &HubSpotProvider{}
+	for _, payload := range []map[string]interface{}{
+		{"from_type": "deal", "from_id": "1", "to_type": "deal", "to_id": "2"},
+		{"from_type": "ticket", "from_id": "1", "to_type": "contact", "to_id": "2"},
+	} {
+		if _, err := p.Execute(context.Background(), &Token{AccessToken: "hs-token"}, "associate", payload); err == nil {
+			t.Errorf("expected an error associating %v with %v", payload["from_type"], payload["to_type"])
+		}
+	}
+}
+
+func TestHubSpot_LogEngagement_Note(t *testing.T) {
+	var sent struct {
+		Properties   map[string]string    `json:"properties"`
+		Associations []hubspotAssociation `json:"associations"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/crm/v3/objects/notes" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"777"}`))
+	}))
+	defer srv.Close()
+
+	p := &HubSpotProvider{APIBaseURL: srv.URL}
+	payload := map[string]interface{}{"type": "note", "body": "Called about renewal", "deal_id": "9001", "timestamp": "2024-03-01T10:00:00Z"}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "hs-token"}, "log_engagement", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := res.(map[string]string); got["engagement_id"] != "777" || got["type"] != "note" {
+		t.Errorf("unexpected result %v", got)
+	}
+	if sent.Properties["hs_note_body"] != "Called about renewal" || sent.Properties["hs_timestamp"] != "2024-03-01T10:00:00Z" {
+		t.Errorf("unexpected properties %v", sent.Properties)
+	}
+	if len(sent.Associations) != 1 || sent.Associations[0].Types[0].TypeID != 214 {
+		t.Errorf("note not associated with the deal: %+v", sent.Associations)
+	}
+
+	if _, err := p.Execute(context.Background(), &Token{AccessToken: "hs-token"}, "log_engagement", map[string]interface{}{"type": "meeting", "body": "x"}); err == nil {
+		t.Error("expected an error for an unsupported engagement type")
+	}
+}
@@ -0,0 +1,25 @@
+package integrations
+
+import "context"
+
+type idempotencyKeyKey struct{}
+
+// WithIdempotencyKey returns a context whose provider calls identify
+// themselves with key, so providers whose APIs support it (such as Stripe)
+// can make a retried call a no-op. key must be the same for every attempt at
+// one logical call and differ between calls.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// IdempotencyKeySender is implemented by providers that send the
+// IdempotencyKey of ctx with their writes.
+type IdempotencyKeySender interface {
+	SendsIdempotencyKey()
+}
+
+// IdempotencyKey returns the key set by WithIdempotencyKey, or "".
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyKey{}).(string)
+	return key
+}
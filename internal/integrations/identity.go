@@ -0,0 +1,26 @@
+package integrations
+
+import "context"
+
+// getMeSpec advertises the get_me action of providers whose tokens can be
+// checked with a ConnectionTester, so workflows can refer to "me" instead of
+// a hardcoded user ID.
+var getMeSpec = ActionSpec{Name: "get_me", Description: "Identify the account the connection acts as", Idempotent: true}
+
+// getMe runs the get_me action. The result always has id, name and email,
+// empty when the provider does not share them, plus username where the
+// provider has handles.
+func getMe(ctx context.Context, p ConnectionTester, token *Token) (map[string]interface{}, error) {
+	if token == nil {
+		return nil, ErrMissingToken
+	}
+	me, err := p.TestConnection(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	res := map[string]interface{}{"status": "success", "id": me.ID, "name": me.Name, "email": me.Email}
+	if me.Username != "" {
+		res["username"] = me.Username
+	}
+	return res, nil
+}
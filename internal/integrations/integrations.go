@@ -4,6 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -66,6 +70,9 @@ const (
 	IntegrationLinkedIn  IntegrationType = "linkedin"
 	IntegrationFacebook  IntegrationType = "facebook"
 	IntegrationInstagram IntegrationType = "instagram"
+
+	// Automation
+	IntegrationWebhookForward IntegrationType = "webhook_forward"
 )
 
 // Token holds OAuth or API token for a provider
@@ -75,6 +82,9 @@ type Token struct {
 	ExpiresAt    time.Time `json:"expires_at,omitempty"`
 	TokenType    string    `json:"token_type,omitempty"`
 	Expiry       int64     `json:"expiry,omitempty"` // Unix timestamp for backward compatibility
+	// InstanceURL is the API host the token was issued for, for providers
+	// such as Salesforce whose API lives on a per-account domain.
+	InstanceURL string `json:"instance_url,omitempty"`
 }
 
 // Provider is a generic interface for all integrations
@@ -94,16 +104,22 @@ type Provider interface {
 var Providers = map[IntegrationType]Provider{}
 
 // RegisterProvider adds a provider to the registry
-// Call this in your main() or init() for each provider
-func RegisterProvider(p Provider) {
+// Call this in your main() or init() for each provider.
+// Providers advertising a malformed action list are rejected so a
+// misconfigured provider fails at startup rather than on first use.
+func RegisterProvider(p Provider) error {
+	if err := ValidateActions(p); err != nil {
+		return err
+	}
 	Providers[IntegrationType(p.Name())] = p
+	return nil
 }
 
 // GetProvider returns a provider by type
 func GetProvider(t IntegrationType) (Provider, error) {
 	p, ok := Providers[t]
 	if !ok {
-		return nil, errors.New("provider not found")
+		return nil, ErrProviderNotFound
 	}
 	return p, nil
 }
@@ -114,20 +130,47 @@ func GetProvider(t IntegrationType) (Provider, error) {
 func getString(payload map[string]interface{}, key string) (string, error) {
 	val, ok := payload[key]
 	if !ok {
-		return "", fmt.Errorf("missing required field '%s'", key)
+		return "", missingField(key)
 	}
 	str, ok := val.(string)
 	if !ok {
-		return "", fmt.Errorf("field '%s' must be a string, got %T", key, val)
+		return "", invalidField("field '%s' must be a string, got %T", key, val)
 	}
 	return str, nil
 }
 
+// getStringList extracts an optional list of strings from a payload map. An
+// absent key yields nil.
+func getStringList(payload map[string]interface{}, key string) ([]string, error) {
+	val, ok := payload[key]
+	if !ok || val == nil {
+		return nil, nil
+	}
+	raw, ok := val.([]interface{})
+	if !ok {
+		return nil, invalidField("field '%s' must be a list of strings, got %T", key, val)
+	}
+	out := make([]string, 0, len(raw))
+	for _, v := range raw {
+		str, ok := v.(string)
+		if !ok {
+			return nil, invalidField("field '%s' must be a list of strings, got %T element", key, v)
+		}
+		out = append(out, str)
+	}
+	return out, nil
+}
+
 // Example: Slack provider implementation (expand for Gmail, Jira, etc.)
 type SlackProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the Slack Web API root; empty uses https://slack.com/api.
+	APIBaseURL string
+	// Scopes are the bot scopes requested at authorization; empty uses
+	// defaultSlackScopes.
+	Scopes []string
 }
 
 func NewSlackProvider(clientID, clientSecret, redirectURL string) *SlackProvider {
@@ -140,30 +183,58 @@ func NewSlackProvider(clientID, clientSecret, redirectURL string) *SlackProvider
 
 func (p *SlackProvider) Name() string { return string(IntegrationSlack) }
 func (p *SlackProvider) GetAuthURL(state string) string {
-	// In production, add scopes and state validation
-	return fmt.Sprintf("https://slack.com/oauth/v2/authorize?client_id=%s&scope=chat:write&state=%s&redirect_uri=%s", p.ClientID, state, p.RedirectURL)
+	scopes := p.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultSlackScopes
+	}
+	return fmt.Sprintf("https://slack.com/oauth/v2/authorize?client_id=%s&scope=%s&state=%s&redirect_uri=%s",
+		url.QueryEscape(p.ClientID), url.QueryEscape(strings.Join(scopes, ",")), url.QueryEscape(state), url.QueryEscape(p.RedirectURL))
 }
 func (p *SlackProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
-	// TODO: Implement Slack OAuth exchange with HTTP client, handle errors
-	// Mock implementation for now
-	if code == "valid_code" {
-		return &Token{
-			AccessToken: "mock-slack-access-token",
-			TokenType:   "Bearer",
-		}, nil
+	return p.exchangeCode(ctx, code)
+}
+
+func (p *SlackProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "send_message", Description: "Post a message to a channel", Fields: []ActionField{
+			{Name: "channel", Type: FieldString, Required: true},
+			{Name: "text", Type: FieldString, Required: true},
+		}},
+		{Name: "lookup_user_by_email", Description: "Find a workspace member by email", Idempotent: true, Fields: []ActionField{
+			{Name: "email", Type: FieldString, Required: true},
+		}},
+		{Name: "open_dm", Description: "Send a direct message to a member by email", Fields: []ActionField{
+			{Name: "email", Type: FieldString, Required: true},
+			{Name: "text", Type: FieldString, Required: true},
+		}},
+		{Name: "schedule_message", Description: "Post a message to a channel at a future time", Fields: []ActionField{
+			{Name: "channel", Type: FieldString, Required: true},
+			{Name: "text", Type: FieldString, Required: true},
+			{Name: "post_at", Type: FieldNumber, Required: true},
+		}},
+		{Name: "list_scheduled", Description: "List pending scheduled messages", Idempotent: true, Fields: []ActionField{
+			{Name: "channel", Type: FieldString},
+			{Name: "page_token", Type: FieldString},
+			{Name: "page_size", Type: FieldNumber},
+		}},
+		{Name: "delete_scheduled", Description: "Cancel a scheduled message", Fields: []ActionField{
+			{Name: "channel", Type: FieldString, Required: true},
+			{Name: "scheduled_message_id", Type: FieldString, Required: true},
+		}},
+		getMeSpec,
 	}
-	return nil, errors.New("invalid authorization code")
 }
+
 func (p *SlackProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	// TODO: Implement Slack actions, validate token, handle rate limits
 	if action == "send_message" {
 		channel, ok := payload["channel"].(string)
 		if !ok {
-			return nil, errors.New("missing channel")
+			return nil, missingField("channel")
 		}
 		text, ok := payload["text"].(string)
 		if !ok {
-			return nil, errors.New("missing text")
+			return nil, missingField("text")
 		}
 		// TODO: Implement actual Slack API call
 		return map[string]string{
@@ -171,7 +242,94 @@ func (p *SlackProvider) Execute(ctx context.Context, token *Token, action string
 			"message": fmt.Sprintf("Sent '%s' to %s", text, channel),
 		}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "lookup_user_by_email" {
+		email, err := getString(payload, "email")
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		user, err := p.lookupUserByEmail(ctx, token, email)
+		if errors.Is(err, ErrSlackUserNotFound) {
+			// Not an execution failure: let workflows branch on "found".
+			return map[string]interface{}{"found": false, "email": email}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"found":     true,
+			"user_id":   user.ID,
+			"name":      user.Name,
+			"real_name": user.RealName,
+		}, nil
+	}
+	if action == "open_dm" {
+		email, err := getString(payload, "email")
+		if err != nil {
+			return nil, err
+		}
+		text, err := getString(payload, "text")
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.openDM(ctx, token, email, text)
+	}
+	if action == "schedule_message" {
+		channel, err := getString(payload, "channel")
+		if err != nil {
+			return nil, err
+		}
+		text, err := getString(payload, "text")
+		if err != nil {
+			return nil, err
+		}
+		postAt, err := slackPostAt(payload, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.scheduleMessage(ctx, token, channel, text, postAt)
+	}
+	if action == "list_scheduled" {
+		channel, _ := payload["channel"].(string)
+		cursor, err := pageToken(payload)
+		if err != nil {
+			return nil, err
+		}
+		limit, err := pageSize(payload, 100, 1000)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.listScheduled(ctx, token, channel, cursor, limit)
+	}
+	if action == "delete_scheduled" {
+		channel, err := getString(payload, "channel")
+		if err != nil {
+			return nil, err
+		}
+		id, err := getString(payload, "scheduled_message_id")
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.deleteScheduled(ctx, token, channel, id)
+	}
+	if action == "get_me" {
+		return getMe(ctx, p, token)
+	}
+	return nil, unknownAction(p, action)
 }
 
 // GmailProvider implements Provider interface for Gmail
@@ -179,6 +337,12 @@ type GmailProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// TokenURL overrides the OAuth token endpoint; empty uses Google's.
+	TokenURL string
+	// RevokeURL overrides the OAuth revocation endpoint; empty uses Google's.
+	RevokeURL string
+	// APIBaseURL overrides the API root; empty uses https://gmail.googleapis.com.
+	APIBaseURL string
 }
 
 func NewGmailProvider(clientID, clientSecret, redirectURL string) *GmailProvider {
@@ -195,31 +359,54 @@ func (p *GmailProvider) GetAuthURL(state string) string {
 	return fmt.Sprintf("https://accounts.google.com/o/oauth2/v2/auth?client_id=%s&redirect_uri=%s&response_type=code&scope=https://www.googleapis.com/auth/gmail.send&state=%s", p.ClientID, p.RedirectURL, state)
 }
 func (p *GmailProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
-	// TODO: Implement Gmail OAuth exchange
-	return nil, errors.New("gmail oauth exchange not implemented")
+	tokenURL := p.TokenURL
+	if tokenURL == "" {
+		tokenURL = defaultGoogleTokenURL
+	}
+	return exchangeAuthCode(ctx, p.Name(), tokenURL, p.ClientID, p.ClientSecret, p.RedirectURL, code)
+}
+
+func (p *GmailProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "send_email", Description: "Send an email", Fields: []ActionField{
+			{Name: "to", Type: FieldString, Required: true},
+			{Name: "subject", Type: FieldString, Required: true},
+			{Name: "body", Type: FieldString, Required: true},
+			{Name: "from", Type: FieldString},
+			{Name: "cc", Type: FieldString},
+			{Name: "bcc", Type: FieldString},
+		}},
+	}
 }
+
 func (p *GmailProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "send_email" {
-		to, ok := payload["to"].(string)
-		if !ok {
-			return nil, errors.New("missing 'to' field")
+		to, err := getString(payload, "to")
+		if err != nil {
+			return nil, err
 		}
-		subject, ok := payload["subject"].(string)
-		if !ok {
-			return nil, errors.New("missing 'subject' field")
+		subject, err := getString(payload, "subject")
+		if err != nil {
+			return nil, err
 		}
-		emailBody, ok := payload["body"].(string)
-		if !ok {
-			return nil, errors.New("missing 'body' field")
+		emailBody, err := getString(payload, "body")
+		if err != nil {
+			return nil, err
 		}
-		// TODO: Implement actual Gmail API call
-		_ = emailBody // Suppress unused variable warning temporarily
-		return map[string]string{
-			"status":  "success",
-			"message": fmt.Sprintf("Email sent to %s with subject '%s'", to, subject),
-		}, nil
+		email := gmailEmail{To: to, Subject: subject, Body: emailBody}
+		email.From, _ = payload["from"].(string)
+		email.Cc, _ = payload["cc"].(string)
+		email.Bcc, _ = payload["bcc"].(string)
+		if token == nil || token.AccessToken == "" {
+			return nil, fmt.Errorf("gmail: missing token: %w", ErrInvalidCredentials)
+		}
+		msg, err := p.sendEmail(ctx, token, email)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"status": "success", "message_id": msg.ID, "thread_id": msg.ThreadID}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // JiraProvider implements Provider interface for Jira
@@ -227,6 +414,9 @@ type JiraProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	APIBaseURL   string // empty uses https://api.atlassian.com
+
+	sites jiraSiteCache
 }
 
 func NewJiraProvider(clientID, clientSecret, redirectURL string) *JiraProvider {
@@ -246,28 +436,56 @@ func (p *JiraProvider) ExchangeCode(ctx context.Context, code string) (*Token, e
 	// TODO: Implement Jira OAuth exchange
 	return nil, errors.New("jira oauth exchange not implemented")
 }
+
+func (p *JiraProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_issue", Description: "Create an issue in a project", Fields: []ActionField{
+			{Name: "project", Type: FieldString, Required: true},
+			{Name: "summary", Type: FieldString, Required: true},
+			{Name: "issue_type", Type: FieldString},
+			{Name: "description", Type: FieldString},
+			{Name: "priority", Type: FieldString},
+			{Name: "labels", Type: FieldArray},
+			{Name: "cloud_id", Type: FieldString},
+		}},
+	}
+}
+
 func (p *JiraProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "create_issue" {
-		project, ok := payload["project"].(string)
-		if !ok {
-			return nil, errors.New("missing 'project' field")
+		project, err := getString(payload, "project")
+		if err != nil {
+			return nil, err
 		}
-		summary, ok := payload["summary"].(string)
-		if !ok {
-			return nil, errors.New("missing 'summary' field")
+		summary, err := getString(payload, "summary")
+		if err != nil {
+			return nil, err
 		}
 		issueType, ok := payload["issue_type"].(string)
-		if !ok {
+		if !ok || issueType == "" {
 			issueType = "Task"
 		}
-		// TODO: Implement actual Jira API call
-		return map[string]string{
-			"status":    "success",
-			"message":   fmt.Sprintf("Created %s in project %s: %s", issueType, project, summary),
-			"issue_key": "DEMO-123",
-		}, nil
+		fields := jiraIssue{
+			Project:   map[string]string{"key": project},
+			Summary:   summary,
+			IssueType: map[string]string{"name": issueType},
+		}
+		if description, _ := payload["description"].(string); description != "" {
+			fields.Description = jiraDescription(description)
+		}
+		if priority, _ := payload["priority"].(string); priority != "" {
+			fields.Priority = map[string]string{"name": priority}
+		}
+		if fields.Labels, err = jiraLabels(payload); err != nil {
+			return nil, err
+		}
+		cloudID, _ := payload["cloud_id"].(string)
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.createIssue(ctx, token, cloudID, fields)
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // ========== Communication & Collaboration Providers ==========
@@ -302,7 +520,7 @@ func (p *MicrosoftTeamsProvider) Execute(ctx context.Context, token *Token, acti
 		}
 		return map[string]string{"status": "success", "message": fmt.Sprintf("Sent message '%s' to Teams channel %s", msg, channel)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // ZoomProvider implements Provider interface for Zoom
@@ -310,6 +528,8 @@ type ZoomProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses https://api.zoom.us.
+	APIBaseURL string
 }
 
 func NewZoomProvider(clientID, clientSecret, redirectURL string) *ZoomProvider {
@@ -323,6 +543,26 @@ func (p *ZoomProvider) GetAuthURL(state string) string {
 func (p *ZoomProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("zoom oauth exchange not implemented")
 }
+
+func (p *ZoomProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_meeting", Description: "Create a meeting", Fields: []ActionField{
+			{Name: "topic", Type: FieldString, Required: true},
+		}},
+		{Name: "list_recordings", Description: "List cloud recordings with download URLs", Idempotent: true, Fields: []ActionField{
+			{Name: "from", Type: FieldString},
+			{Name: "to", Type: FieldString},
+			{Name: "page_token", Type: FieldString},
+			{Name: "page_size", Type: FieldNumber},
+		}},
+		{Name: "list_participants", Description: "List the participants of a past meeting", Idempotent: true, Fields: []ActionField{
+			{Name: "meeting_id", Type: FieldString, Required: true},
+			{Name: "page_token", Type: FieldString},
+			{Name: "page_size", Type: FieldNumber},
+		}},
+	}
+}
+
 func (p *ZoomProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "create_meeting" {
 		topic, err := getString(payload, "topic")
@@ -331,7 +571,41 @@ func (p *ZoomProvider) Execute(ctx context.Context, token *Token, action string,
 		}
 		return map[string]string{"status": "success", "meeting_url": "https://zoom.us/j/123456789", "topic": topic}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "list_recordings" {
+		from, _ := payload["from"].(string)
+		to, _ := payload["to"].(string)
+		next, err := pageToken(payload)
+		if err != nil {
+			return nil, err
+		}
+		size, err := pageSize(payload, 30, 300)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.listRecordings(ctx, token, from, to, next, size)
+	}
+	if action == "list_participants" {
+		meetingID, err := getString(payload, "meeting_id")
+		if err != nil {
+			return nil, err
+		}
+		next, err := pageToken(payload)
+		if err != nil {
+			return nil, err
+		}
+		size, err := pageSize(payload, 30, 300)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.listParticipants(ctx, token, meetingID, next, size)
+	}
+	return nil, unknownAction(p, action)
 }
 
 // DiscordProvider implements Provider interface for Discord
@@ -364,7 +638,7 @@ func (p *DiscordProvider) Execute(ctx context.Context, token *Token, action stri
 		}
 		return map[string]string{"status": "success", "message": fmt.Sprintf("Sent message '%s' to Discord channel %s", content, channel)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // ========== Email & Marketing Providers ==========
@@ -374,6 +648,8 @@ type SendGridProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses https://api.sendgrid.com.
+	APIBaseURL string
 }
 
 func NewSendGridProvider(clientID, clientSecret, redirectURL string) *SendGridProvider {
@@ -387,6 +663,30 @@ func (p *SendGridProvider) GetAuthURL(state string) string {
 func (p *SendGridProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("sendgrid oauth exchange not implemented")
 }
+
+func (p *SendGridProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "send_email", Description: "Send an email", Fields: []ActionField{
+			{Name: "to", Type: FieldString, Required: true},
+			{Name: "subject", Type: FieldString, Required: true},
+		}},
+		{Name: "add_contact", Description: "Add or update a marketing contact; returns the import job_id, as SendGrid applies it asynchronously", Fields: []ActionField{
+			{Name: "email", Type: FieldString, Required: true},
+			{Name: "first_name", Type: FieldString},
+			{Name: "last_name", Type: FieldString},
+			{Name: "list_ids", Type: FieldArray},
+		}},
+		{Name: "create_list", Description: "Create a marketing contact list", Fields: []ActionField{
+			{Name: "name", Type: FieldString, Required: true},
+		}},
+		{Name: "get_stats", Description: "Get email statistics from start_date (YYYY-MM-DD), aggregated by day, week or month", Idempotent: true, Fields: []ActionField{
+			{Name: "start_date", Type: FieldString, Required: true},
+			{Name: "end_date", Type: FieldString},
+			{Name: "aggregated_by", Type: FieldString},
+		}},
+	}
+}
+
 func (p *SendGridProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "send_email" {
 		to, err := getString(payload, "to")
@@ -399,7 +699,60 @@ func (p *SendGridProvider) Execute(ctx context.Context, token *Token, action str
 		}
 		return map[string]string{"status": "success", "message": fmt.Sprintf("Email sent via SendGrid to %s with subject '%s'", to, subject)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "add_contact" {
+		email, err := getString(payload, "email")
+		if err != nil {
+			return nil, err
+		}
+		if !strings.Contains(email, "@") {
+			return nil, invalidField("field 'email' must be an email address, got %q", email)
+		}
+		listIDs, err := getStringList(payload, "list_ids")
+		if err != nil {
+			return nil, err
+		}
+		firstName, _ := payload["first_name"].(string)
+		lastName, _ := payload["last_name"].(string)
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.addContact(ctx, token, email, firstName, lastName, listIDs)
+	}
+	if action == "create_list" {
+		name, err := getString(payload, "name")
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(name) == "" {
+			return nil, invalidField("field 'name' must not be empty")
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.createList(ctx, token, name)
+	}
+	if action == "get_stats" {
+		if _, ok := payload["start_date"]; !ok {
+			return nil, missingField("start_date")
+		}
+		startDate, err := sendgridDate(payload, "start_date")
+		if err != nil {
+			return nil, err
+		}
+		endDate, err := sendgridDate(payload, "end_date")
+		if err != nil {
+			return nil, err
+		}
+		aggregatedBy, _ := payload["aggregated_by"].(string)
+		if aggregatedBy != "" && !sendgridStatsAggregations[aggregatedBy] {
+			return nil, invalidField("field 'aggregated_by' must be day, week or month, got %q", aggregatedBy)
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.getStats(ctx, token, startDate, endDate, aggregatedBy)
+	}
+	return nil, unknownAction(p, action)
 }
 
 // MailchimpProvider implements Provider interface for Mailchimp
@@ -407,6 +760,8 @@ type MailchimpProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses the account's data center.
+	APIBaseURL string
 }
 
 func NewMailchimpProvider(clientID, clientSecret, redirectURL string) *MailchimpProvider {
@@ -420,6 +775,22 @@ func (p *MailchimpProvider) GetAuthURL(state string) string {
 func (p *MailchimpProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("mailchimp oauth exchange not implemented")
 }
+
+func (p *MailchimpProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "add_subscriber", Description: "Add or update a list member; status_if_new \"pending\" sends a double opt-in email", Fields: []ActionField{
+			{Name: "list_id", Type: FieldString, Required: true},
+			{Name: "email", Type: FieldString, Required: true},
+			{Name: "status_if_new", Type: FieldString},
+			{Name: "status", Type: FieldString},
+		}},
+		{Name: "get_subscriber", Description: "Get a list member's subscription status", Idempotent: true, Fields: []ActionField{
+			{Name: "list_id", Type: FieldString, Required: true},
+			{Name: "email", Type: FieldString, Required: true},
+		}},
+	}
+}
+
 func (p *MailchimpProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "add_subscriber" {
 		email, err := getString(payload, "email")
@@ -430,9 +801,36 @@ func (p *MailchimpProvider) Execute(ctx context.Context, token *Token, action st
 		if err != nil {
 			return nil, err
 		}
-		return map[string]string{"status": "success", "message": fmt.Sprintf("Added %s to list %s", email, listID)}, nil
+		statusIfNew, _ := payload["status_if_new"].(string)
+		if statusIfNew == "" {
+			statusIfNew = "subscribed"
+		}
+		status, _ := payload["status"].(string)
+		for _, s := range []string{statusIfNew, status} {
+			if s != "" && !mailchimpStatuses[s] {
+				return nil, fmt.Errorf("invalid member status %q", s)
+			}
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.addSubscriber(ctx, token, listID, email, statusIfNew, status)
+	}
+	if action == "get_subscriber" {
+		email, err := getString(payload, "email")
+		if err != nil {
+			return nil, err
+		}
+		listID, err := getString(payload, "list_id")
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.getSubscriber(ctx, token, listID, email)
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // TwilioProvider implements Provider interface for Twilio
@@ -440,6 +838,8 @@ type TwilioProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses https://api.twilio.com.
+	APIBaseURL string
 }
 
 func NewTwilioProvider(clientID, clientSecret, redirectURL string) *TwilioProvider {
@@ -465,7 +865,59 @@ func (p *TwilioProvider) Execute(ctx context.Context, token *Token, action strin
 		}
 		return map[string]string{"status": "success", "message": fmt.Sprintf("SMS sent to %s: %s", to, body)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "make_call" {
+		from, err := phoneNumber(payload, "from")
+		if err != nil {
+			return nil, err
+		}
+		to, err := phoneNumber(payload, "to")
+		if err != nil {
+			return nil, err
+		}
+		twimlURL, err := getString(payload, "url")
+		if err != nil {
+			return nil, err
+		}
+		if u, err := url.Parse(twimlURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return nil, invalidField("url must be an absolute http(s) URL serving TwiML, got %q", twimlURL)
+		}
+		return p.makeCall(ctx, token, from, to, twimlURL)
+	}
+	if action == "send_whatsapp" {
+		from, err := whatsAppNumber(payload, "from")
+		if err != nil {
+			return nil, err
+		}
+		to, err := whatsAppNumber(payload, "to")
+		if err != nil {
+			return nil, err
+		}
+		body, err := getString(payload, "body")
+		if err != nil {
+			return nil, err
+		}
+		return p.sendWhatsApp(ctx, token, from, to, body)
+	}
+	return nil, unknownAction(p, action)
+}
+
+func (p *TwilioProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "send_sms", Description: "Send an SMS", Fields: []ActionField{
+			{Name: "to", Type: FieldString, Required: true},
+			{Name: "body", Type: FieldString, Required: true},
+		}},
+		{Name: "make_call", Description: "Place a voice call driven by a TwiML URL", Fields: []ActionField{
+			{Name: "from", Type: FieldString, Required: true},
+			{Name: "to", Type: FieldString, Required: true},
+			{Name: "url", Type: FieldString, Required: true},
+		}},
+		{Name: "send_whatsapp", Description: "Send a WhatsApp message", Fields: []ActionField{
+			{Name: "from", Type: FieldString, Required: true},
+			{Name: "to", Type: FieldString, Required: true},
+			{Name: "body", Type: FieldString, Required: true},
+		}},
+	}
 }
 
 // ========== Project Management Providers ==========
@@ -500,7 +952,7 @@ func (p *TrelloProvider) Execute(ctx context.Context, token *Token, action strin
 		}
 		return map[string]string{"status": "success", "card_id": "abc123", "message": fmt.Sprintf("Created card '%s' in list %s", name, listID)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // AsanaProvider implements Provider interface for Asana
@@ -508,6 +960,8 @@ type AsanaProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses https://app.asana.com/api/1.0.
+	APIBaseURL string
 }
 
 func NewAsanaProvider(clientID, clientSecret, redirectURL string) *AsanaProvider {
@@ -521,6 +975,30 @@ func (p *AsanaProvider) GetAuthURL(state string) string {
 func (p *AsanaProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("asana oauth exchange not implemented")
 }
+
+func (p *AsanaProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_task", Description: "Create a task in a project", Fields: []ActionField{
+			{Name: "project", Type: FieldString, Required: true},
+			{Name: "name", Type: FieldString, Required: true},
+		}},
+		{Name: "create_subtask", Description: "Create a subtask under a task", Fields: []ActionField{
+			{Name: "parent_gid", Type: FieldString, Required: true},
+			{Name: "name", Type: FieldString, Required: true},
+			{Name: "notes", Type: FieldString},
+		}},
+		{Name: "add_comment", Description: "Comment on a task", Fields: []ActionField{
+			{Name: "task_gid", Type: FieldString, Required: true},
+			{Name: "text", Type: FieldString, Required: true},
+		}},
+		{Name: "add_to_project", Description: "Add a task to a project, optionally in a section", Fields: []ActionField{
+			{Name: "task_gid", Type: FieldString, Required: true},
+			{Name: "project_gid", Type: FieldString, Required: true},
+			{Name: "section_gid", Type: FieldString},
+		}},
+	}
+}
+
 func (p *AsanaProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "create_task" {
 		project, err := getString(payload, "project")
@@ -533,7 +1011,56 @@ func (p *AsanaProvider) Execute(ctx context.Context, token *Token, action string
 		}
 		return map[string]string{"status": "success", "task_gid": "1234567890", "message": fmt.Sprintf("Created task '%s' in project %s", name, project)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "create_subtask" {
+		parent, err := asanaGID(payload, "parent_gid")
+		if err != nil {
+			return nil, err
+		}
+		name, err := getString(payload, "name")
+		if err != nil {
+			return nil, err
+		}
+		notes, _ := payload["notes"].(string)
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.createSubtask(ctx, token, parent, name, notes)
+	}
+	if action == "add_comment" {
+		task, err := asanaGID(payload, "task_gid")
+		if err != nil {
+			return nil, err
+		}
+		text, err := getString(payload, "text")
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.addComment(ctx, token, task, text)
+	}
+	if action == "add_to_project" {
+		task, err := asanaGID(payload, "task_gid")
+		if err != nil {
+			return nil, err
+		}
+		project, err := asanaGID(payload, "project_gid")
+		if err != nil {
+			return nil, err
+		}
+		section := ""
+		if _, ok := payload["section_gid"]; ok {
+			if section, err = asanaGID(payload, "section_gid"); err != nil {
+				return nil, err
+			}
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.addToProject(ctx, token, task, project, section)
+	}
+	return nil, unknownAction(p, action)
 }
 
 // MondayProvider implements Provider interface for Monday.com
@@ -566,7 +1093,7 @@ func (p *MondayProvider) Execute(ctx context.Context, token *Token, action strin
 		}
 		return map[string]string{"status": "success", "item_id": "123456", "message": fmt.Sprintf("Created item '%s' in board %s", name, board)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // NotionProvider implements Provider interface for Notion
@@ -574,6 +1101,8 @@ type NotionProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the Notion API root; empty uses https://api.notion.com.
+	APIBaseURL string
 }
 
 func NewNotionProvider(clientID, clientSecret, redirectURL string) *NotionProvider {
@@ -587,6 +1116,28 @@ func (p *NotionProvider) GetAuthURL(state string) string {
 func (p *NotionProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("notion oauth exchange not implemented")
 }
+
+func (p *NotionProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_page", Description: "Create a page under a parent page", Fields: []ActionField{
+			{Name: "parent_id", Type: FieldString, Required: true},
+			{Name: "title", Type: FieldString, Required: true},
+		}},
+		{Name: "append_blocks", Description: "Append content blocks to a page or block", Fields: []ActionField{
+			{Name: "block_id", Type: FieldString, Required: true},
+			{Name: "blocks", Type: FieldArray, Required: true},
+		}},
+		{Name: "update_page", Description: "Update a page's properties", Fields: []ActionField{
+			{Name: "page_id", Type: FieldString, Required: true},
+			{Name: "properties", Type: FieldObject, Required: true},
+		}},
+		{Name: "query_database", Description: "List the pages in a database, optionally filtered", Idempotent: true, Fields: []ActionField{
+			{Name: "database_id", Type: FieldString, Required: true},
+			{Name: "filter", Type: FieldObject},
+		}},
+	}
+}
+
 func (p *NotionProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "create_page" {
 		parent, err := getString(payload, "parent_id")
@@ -599,7 +1150,46 @@ func (p *NotionProvider) Execute(ctx context.Context, token *Token, action strin
 		}
 		return map[string]string{"status": "success", "page_id": "abc-123", "message": fmt.Sprintf("Created page '%s' in %s", title, parent)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "append_blocks" {
+		blockID, err := getString(payload, "block_id")
+		if err != nil {
+			return nil, err
+		}
+		blocks, ok := payload["blocks"].([]interface{})
+		if !ok || len(blocks) == 0 {
+			return nil, invalidField("field 'blocks' must be a non-empty array")
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.appendBlocks(ctx, token, blockID, blocks)
+	}
+	if action == "update_page" {
+		pageID, err := getString(payload, "page_id")
+		if err != nil {
+			return nil, err
+		}
+		properties, ok := payload["properties"].(map[string]interface{})
+		if !ok {
+			return nil, invalidField("field 'properties' must be an object")
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.updatePage(ctx, token, pageID, properties)
+	}
+	if action == "query_database" {
+		databaseID, err := getString(payload, "database_id")
+		if err != nil {
+			return nil, err
+		}
+		filter, _ := payload["filter"].(map[string]interface{})
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.queryDatabase(ctx, token, databaseID, filter)
+	}
+	return nil, unknownAction(p, action)
 }
 
 // ClickUpProvider implements Provider interface for ClickUp
@@ -607,6 +1197,7 @@ type ClickUpProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	APIBaseURL   string // empty uses https://api.clickup.com
 }
 
 func NewClickUpProvider(clientID, clientSecret, redirectURL string) *ClickUpProvider {
@@ -632,7 +1223,54 @@ func (p *ClickUpProvider) Execute(ctx context.Context, token *Token, action stri
 		}
 		return map[string]string{"status": "success", "task_id": "xyz789", "message": fmt.Sprintf("Created task '%s' in list %s", name, listID)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "add_comment" {
+		taskID, err := clickupTaskID(payload)
+		if err != nil {
+			return nil, err
+		}
+		text, err := getString(payload, "comment_text")
+		if err != nil {
+			return nil, err
+		}
+		notifyAll, _ := payload["notify_all"].(bool)
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.addComment(ctx, token, taskID, text, notifyAll)
+	}
+	if action == "update_task_status" {
+		taskID, err := clickupTaskID(payload)
+		if err != nil {
+			return nil, err
+		}
+		status, err := getString(payload, "status")
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.updateTaskStatus(ctx, token, taskID, status)
+	}
+	return nil, unknownAction(p, action)
+}
+
+func (p *ClickUpProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_task", Description: "Create a task in a list", Fields: []ActionField{
+			{Name: "list_id", Type: FieldString, Required: true},
+			{Name: "name", Type: FieldString, Required: true},
+		}},
+		{Name: "add_comment", Description: "Comment on a task", Fields: []ActionField{
+			{Name: "task_id", Type: FieldString, Required: true},
+			{Name: "comment_text", Type: FieldString, Required: true},
+			{Name: "notify_all", Type: FieldBoolean},
+		}},
+		{Name: "update_task_status", Description: "Move a task to one of its list's statuses", Fields: []ActionField{
+			{Name: "task_id", Type: FieldString, Required: true},
+			{Name: "status", Type: FieldString, Required: true},
+		}},
+	}
 }
 
 // ========== CRM & Sales Providers ==========
@@ -642,6 +1280,8 @@ type SalesforceProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses the token's InstanceURL.
+	APIBaseURL string
 }
 
 func NewSalesforceProvider(clientID, clientSecret, redirectURL string) *SalesforceProvider {
@@ -655,7 +1295,49 @@ func (p *SalesforceProvider) GetAuthURL(state string) string {
 func (p *SalesforceProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("salesforce oauth exchange not implemented")
 }
+
+func (p *SalesforceProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_lead", Description: "Create a lead", Fields: []ActionField{
+			{Name: "first_name", Type: FieldString, Required: true},
+			{Name: "last_name", Type: FieldString, Required: true},
+			{Name: "company", Type: FieldString, Required: true},
+		}},
+		{Name: "create_records_bulk", Description: "Create many records of one object type, reporting success or errors per record", Fields: []ActionField{
+			{Name: "object", Type: FieldString, Required: true},
+			{Name: "records", Type: FieldArray, Required: true},
+			{Name: "all_or_none", Type: FieldBoolean},
+		}},
+	}
+}
+
 func (p *SalesforceProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
+	if action == "create_records_bulk" {
+		object, err := getString(payload, "object")
+		if err != nil {
+			return nil, err
+		}
+		items, ok := payload["records"].([]interface{})
+		if !ok || len(items) == 0 {
+			return nil, invalidField("field 'records' must be a non-empty array")
+		}
+		if len(items) > salesforceMaxBulkRecords {
+			return nil, invalidField("field 'records' holds %d records, more than the %d allowed", len(items), salesforceMaxBulkRecords)
+		}
+		records := make([]map[string]interface{}, len(items))
+		for i, item := range items {
+			record, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, invalidField("records[%d] must be an object, got %T", i, item)
+			}
+			records[i] = record
+		}
+		allOrNone, _ := payload["all_or_none"].(bool)
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.createRecordsBulk(ctx, token, object, records, allOrNone)
+	}
 	if action == "create_lead" {
 		firstName, err := getString(payload, "first_name")
 		if err != nil {
@@ -671,7 +1353,7 @@ func (p *SalesforceProvider) Execute(ctx context.Context, token *Token, action s
 		}
 		return map[string]string{"status": "success", "lead_id": "00Q123456", "message": fmt.Sprintf("Created lead for %s %s at %s", firstName, lastName, company)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // HubSpotProvider implements Provider interface for HubSpot
@@ -679,6 +1361,8 @@ type HubSpotProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses https://api.hubapi.com.
+	APIBaseURL string
 }
 
 func NewHubSpotProvider(clientID, clientSecret, redirectURL string) *HubSpotProvider {
@@ -687,11 +1371,43 @@ func NewHubSpotProvider(clientID, clientSecret, redirectURL string) *HubSpotProv
 
 func (p *HubSpotProvider) Name() string { return string(IntegrationHubSpot) }
 func (p *HubSpotProvider) GetAuthURL(state string) string {
-	return fmt.Sprintf("https://app.hubspot.com/oauth/authorize?client_id=%s&redirect_uri=%s&scope=contacts&state=%s", p.ClientID, p.RedirectURL, state)
+	return fmt.Sprintf("https://app.hubspot.com/oauth/authorize?client_id=%s&redirect_uri=%s&scope=crm.objects.contacts.write%%20crm.objects.deals.write&state=%s", p.ClientID, p.RedirectURL, state)
 }
 func (p *HubSpotProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("hubspot oauth exchange not implemented")
 }
+
+func (p *HubSpotProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_contact", Description: "Create a contact", Fields: []ActionField{
+			{Name: "email", Type: FieldString, Required: true},
+			{Name: "first_name", Type: FieldString, Required: true},
+		}},
+		{Name: "create_deal", Description: "Create a deal, optionally associated with a contact", Fields: []ActionField{
+			{Name: "dealname", Type: FieldString, Required: true},
+			{Name: "amount", Type: FieldNumber},
+			{Name: "dealstage", Type: FieldString},
+			{Name: "pipeline", Type: FieldString},
+			{Name: "closedate", Type: FieldString},
+			{Name: "contact_id", Type: FieldString},
+		}},
+		{Name: "associate", Description: "Associate two CRM objects, e.g. a contact with a deal", Fields: []ActionField{
+			{Name: "from_type", Type: FieldString, Required: true},
+			{Name: "from_id", Type: FieldString, Required: true},
+			{Name: "to_type", Type: FieldString, Required: true},
+			{Name: "to_id", Type: FieldString, Required: true},
+		}},
+		{Name: "log_engagement", Description: "Log a note or call on a contact's or deal's timeline", Fields: []ActionField{
+			{Name: "type", Type: FieldString, Required: true},
+			{Name: "body", Type: FieldString, Required: true},
+			{Name: "title", Type: FieldString},
+			{Name: "contact_id", Type: FieldString},
+			{Name: "deal_id", Type: FieldString},
+			{Name: "timestamp", Type: FieldString},
+		}},
+	}
+}
+
 func (p *HubSpotProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "create_contact" {
 		email, err := getString(payload, "email")
@@ -704,7 +1420,103 @@ func (p *HubSpotProvider) Execute(ctx context.Context, token *Token, action stri
 		}
 		return map[string]string{"status": "success", "contact_id": "12345", "message": fmt.Sprintf("Created contact for %s (%s)", firstName, email)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "create_deal" {
+		name, err := getString(payload, "dealname")
+		if err != nil {
+			return nil, err
+		}
+		properties := map[string]string{"dealname": name}
+		for _, key := range []string{"dealstage", "pipeline", "closedate"} {
+			if v, _ := payload[key].(string); v != "" {
+				properties[key] = v
+			}
+		}
+		switch amount := payload["amount"].(type) {
+		case nil:
+		case float64:
+			properties["amount"] = strconv.FormatFloat(amount, 'f', -1, 64)
+		case string:
+			properties["amount"] = amount
+		default:
+			return nil, invalidField("field 'amount' must be a number, got %T", amount)
+		}
+		var associations []hubspotAssociation
+		if contactID, _ := payload["contact_id"].(string); contactID != "" {
+			associations = []hubspotAssociation{{
+				To:    hubspotObject{ID: contactID},
+				Types: []hubspotAssociationType{{Category: "HUBSPOT_DEFINED", TypeID: hubspotAssociationTypes[[2]string{"deals", "contacts"}]}},
+			}}
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		id, err := p.createObject(ctx, token, "deals", properties, associations)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"status": "success", "deal_id": id}, nil
+	}
+	if action == "associate" {
+		var ids [4]string
+		for i, key := range []string{"from_type", "from_id", "to_type", "to_id"} {
+			v, err := getString(payload, key)
+			if err != nil {
+				return nil, err
+			}
+			ids[i] = v
+		}
+		fromType, err := hubspotObjectType(ids[0])
+		if err != nil {
+			return nil, err
+		}
+		toType, err := hubspotObjectType(ids[2])
+		if err != nil {
+			return nil, err
+		}
+		typeID, err := hubspotAssociationTypeID(fromType, toType)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		if err := p.associate(ctx, token, fromType, ids[1], toType, ids[3], typeID); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"status": "success", "from_id": ids[1], "to_id": ids[3], "association_type_id": typeID}, nil
+	}
+	if action == "log_engagement" {
+		kind, err := getString(payload, "type")
+		if err != nil {
+			return nil, err
+		}
+		objectType, _ := hubspotObjectType(kind)
+		if _, ok := hubspotEngagementBodies[objectType]; !ok {
+			return nil, invalidField("invalid engagement type %q: must be note or call", kind)
+		}
+		text, err := getString(payload, "body")
+		if err != nil {
+			return nil, err
+		}
+		at := time.Now()
+		if ts, _ := payload["timestamp"].(string); ts != "" {
+			if at, err = time.Parse(time.RFC3339, ts); err != nil {
+				return nil, invalidField("field 'timestamp' must be RFC 3339: %w", err)
+			}
+		}
+		title, _ := payload["title"].(string)
+		contactID, _ := payload["contact_id"].(string)
+		dealID, _ := payload["deal_id"].(string)
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		id, err := p.logEngagement(ctx, token, objectType, text, title, contactID, dealID, at)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"status": "success", "engagement_id": id, "type": strings.TrimSuffix(objectType, "s")}, nil
+	}
+	return nil, unknownAction(p, action)
 }
 
 // ZendeskProvider implements Provider interface for Zendesk
@@ -712,6 +1524,9 @@ type ZendeskProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses the token's InstanceURL,
+	// the account's https://<subdomain>.zendesk.com.
+	APIBaseURL string
 }
 
 func NewZendeskProvider(clientID, clientSecret, redirectURL string) *ZendeskProvider {
@@ -737,7 +1552,75 @@ func (p *ZendeskProvider) Execute(ctx context.Context, token *Token, action stri
 		}
 		return map[string]string{"status": "success", "ticket_id": "1234", "message": fmt.Sprintf("Created ticket: %s - %s", subject, desc)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "create_user" {
+		name, err := getString(payload, "name")
+		if err != nil {
+			return nil, err
+		}
+		email, _ := payload["email"].(string)
+		externalID, _ := payload["external_id"].(string)
+		orgID, _ := payload["organization_id"].(float64)
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.createUser(ctx, token, name, email, externalID, int64(orgID))
+	}
+	if action == "create_organization" {
+		name, err := getString(payload, "name")
+		if err != nil {
+			return nil, err
+		}
+		externalID, _ := payload["external_id"].(string)
+		domains, err := getStringList(payload, "domain_names")
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.createOrganization(ctx, token, name, externalID, domains)
+	}
+	if action == "search" {
+		query, err := getString(payload, "query")
+		if err != nil {
+			return nil, err
+		}
+		recordType, _ := payload["type"].(string)
+		if recordType != "" && !zendeskSearchTypes[recordType] {
+			return nil, invalidField("type must be user, organization, ticket or group, got %q", recordType)
+		}
+		page, _ := payload["page"].(float64)
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.search(ctx, token, query, recordType, int(page))
+	}
+	return nil, unknownAction(p, action)
+}
+
+func (p *ZendeskProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_ticket", Description: "Create a support ticket", Fields: []ActionField{
+			{Name: "subject", Type: FieldString, Required: true},
+			{Name: "description", Type: FieldString, Required: true},
+		}},
+		{Name: "create_user", Description: "Provision an end user to act as a ticket requester", Fields: []ActionField{
+			{Name: "name", Type: FieldString, Required: true},
+			{Name: "email", Type: FieldString},
+			{Name: "external_id", Type: FieldString},
+			{Name: "organization_id", Type: FieldNumber},
+		}},
+		{Name: "create_organization", Description: "Create an organization", Fields: []ActionField{
+			{Name: "name", Type: FieldString, Required: true},
+			{Name: "external_id", Type: FieldString},
+			{Name: "domain_names", Type: FieldArray},
+		}},
+		{Name: "search", Description: "Search users, organizations, tickets or groups", Fields: []ActionField{
+			{Name: "query", Type: FieldString, Required: true},
+			{Name: "type", Type: FieldString},
+			{Name: "page", Type: FieldNumber},
+		}},
+	}
 }
 
 // IntercomProvider implements Provider interface for Intercom
@@ -745,6 +1628,7 @@ type IntercomProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	APIBaseURL   string // empty uses https://api.intercom.io
 }
 
 func NewIntercomProvider(clientID, clientSecret, redirectURL string) *IntercomProvider {
@@ -758,6 +1642,26 @@ func (p *IntercomProvider) GetAuthURL(state string) string {
 func (p *IntercomProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("intercom oauth exchange not implemented")
 }
+
+func (p *IntercomProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_user", Description: "Create a user", Fields: []ActionField{
+			{Name: "email", Type: FieldString, Required: true},
+			{Name: "name", Type: FieldString, Required: true},
+		}},
+		{Name: "reply_to_conversation", Description: "Reply to a conversation as an admin", Fields: []ActionField{
+			{Name: "conversation_id", Type: FieldString, Required: true},
+			{Name: "admin_id", Type: FieldString, Required: true},
+			{Name: "body", Type: FieldString, Required: true},
+			{Name: "message_type", Type: FieldString},
+		}},
+		{Name: "tag_contact", Description: "Tag a contact", Fields: []ActionField{
+			{Name: "contact_id", Type: FieldString, Required: true},
+			{Name: "tag", Type: FieldString, Required: true},
+		}},
+	}
+}
+
 func (p *IntercomProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "create_user" {
 		email, err := getString(payload, "email")
@@ -770,7 +1674,46 @@ func (p *IntercomProvider) Execute(ctx context.Context, token *Token, action str
 		}
 		return map[string]string{"status": "success", "user_id": "abc123", "message": fmt.Sprintf("Created user %s (%s)", name, email)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "reply_to_conversation" {
+		conversationID, err := intercomID(payload, "conversation_id")
+		if err != nil {
+			return nil, err
+		}
+		adminID, err := intercomID(payload, "admin_id")
+		if err != nil {
+			return nil, err
+		}
+		body, err := getString(payload, "body")
+		if err != nil {
+			return nil, err
+		}
+		messageType, _ := payload["message_type"].(string)
+		if messageType == "" {
+			messageType = "comment"
+		}
+		if !intercomReplyTypes[messageType] {
+			return nil, invalidField("message_type must be comment or note, got %q", messageType)
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.replyToConversation(ctx, token, conversationID, adminID, body, messageType)
+	}
+	if action == "tag_contact" {
+		contactID, err := intercomID(payload, "contact_id")
+		if err != nil {
+			return nil, err
+		}
+		tag, err := getString(payload, "tag")
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.tagContact(ctx, token, contactID, tag)
+	}
+	return nil, unknownAction(p, action)
 }
 
 // PipedriveProvider implements Provider interface for Pipedrive
@@ -800,7 +1743,7 @@ func (p *PipedriveProvider) Execute(ctx context.Context, token *Token, action st
 		value := payload["value"] // numeric — kept as interface{}
 		return map[string]interface{}{"status": "success", "deal_id": 123, "message": fmt.Sprintf("Created deal '%s' worth %v", title, value)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // ========== Development & Code Providers ==========
@@ -810,6 +1753,10 @@ type GitHubProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses https://api.github.com.
+	APIBaseURL string
+	// TokenURL overrides the OAuth token endpoint; empty uses GitHub's.
+	TokenURL string
 }
 
 func NewGitHubProvider(clientID, clientSecret, redirectURL string) *GitHubProvider {
@@ -821,21 +1768,75 @@ func (p *GitHubProvider) GetAuthURL(state string) string {
 	return fmt.Sprintf("https://github.com/login/oauth/authorize?client_id=%s&redirect_uri=%s&scope=repo user&state=%s", p.ClientID, p.RedirectURL, state)
 }
 func (p *GitHubProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
-	return nil, errors.New("github oauth exchange not implemented")
+	tokenURL := p.TokenURL
+	if tokenURL == "" {
+		tokenURL = defaultGitHubTokenURL
+	}
+	return exchangeAuthCode(ctx, p.Name(), tokenURL, p.ClientID, p.ClientSecret, p.RedirectURL, code)
+}
+
+func (p *GitHubProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_issue", Description: "Create an issue in a repository", Fields: []ActionField{
+			{Name: "repo", Type: FieldString, Required: true},
+			{Name: "title", Type: FieldString, Required: true},
+			{Name: "body", Type: FieldString},
+			{Name: "labels", Type: FieldArray},
+			{Name: "assignees", Type: FieldArray},
+		}},
+		{Name: "list_repos", Description: "List repositories the user can access", Idempotent: true, Fields: []ActionField{
+			{Name: "page_token", Type: FieldString},
+			{Name: "page_size", Type: FieldNumber},
+		}},
+		getMeSpec,
+	}
 }
+
 func (p *GitHubProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "create_issue" {
 		repo, err := getString(payload, "repo")
 		if err != nil {
 			return nil, err
 		}
+		if owner, name, ok := strings.Cut(repo, "/"); !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+			return nil, invalidField("repo must be owner/name, got %q", repo)
+		}
 		title, err := getString(payload, "title")
 		if err != nil {
 			return nil, err
 		}
-		return map[string]string{"status": "success", "issue_number": "42", "message": fmt.Sprintf("Created issue in %s: %s", repo, title)}, nil
+		body, _ := payload["body"].(string)
+		labels, err := getStringList(payload, "labels")
+		if err != nil {
+			return nil, err
+		}
+		assignees, err := getStringList(payload, "assignees")
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.createIssue(ctx, token, repo, githubIssue{Title: title, Body: body, Labels: labels, Assignees: assignees})
+	}
+	if action == "list_repos" {
+		page, err := pageToken(payload)
+		if err != nil {
+			return nil, err
+		}
+		perPage, err := pageSize(payload, 30, 100)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.listRepos(ctx, token, page, perPage)
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "get_me" {
+		return getMe(ctx, p, token)
+	}
+	return nil, unknownAction(p, action)
 }
 
 // GitLabProvider implements Provider interface for GitLab
@@ -843,6 +1844,9 @@ type GitLabProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the GitLab root, for self-managed instances and
+	// tests; empty uses https://gitlab.com.
+	APIBaseURL string
 }
 
 func NewGitLabProvider(clientID, clientSecret, redirectURL string) *GitLabProvider {
@@ -856,7 +1860,73 @@ func (p *GitLabProvider) GetAuthURL(state string) string {
 func (p *GitLabProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("gitlab oauth exchange not implemented")
 }
+
+func (p *GitLabProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_issue", Description: "Create an issue in a project", Fields: []ActionField{
+			{Name: "project", Type: FieldString, Required: true},
+			{Name: "title", Type: FieldString, Required: true},
+		}},
+		{Name: "get_file", Description: "Read a repository file; ref defaults to HEAD", Idempotent: true, Fields: []ActionField{
+			{Name: "project", Type: FieldString, Required: true},
+			{Name: "file_path", Type: FieldString, Required: true},
+			{Name: "ref", Type: FieldString},
+		}},
+		{Name: "create_commit", Description: "Commit file actions (create, update, delete, move, chmod) to a branch", Fields: []ActionField{
+			{Name: "project", Type: FieldString, Required: true},
+			{Name: "branch", Type: FieldString, Required: true},
+			{Name: "commit_message", Type: FieldString, Required: true},
+			{Name: "actions", Type: FieldArray, Required: true},
+			{Name: "start_branch", Type: FieldString},
+		}},
+	}
+}
+
 func (p *GitLabProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
+	if action == "get_file" {
+		project, err := getString(payload, "project")
+		if err != nil {
+			return nil, err
+		}
+		filePath, err := getString(payload, "file_path")
+		if err != nil {
+			return nil, err
+		}
+		ref, _ := payload["ref"].(string)
+		if ref == "" {
+			ref = "HEAD"
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.getFile(ctx, token, project, filePath, ref)
+	}
+	if action == "create_commit" {
+		var fields [3]string
+		for i, key := range []string{"project", "branch", "commit_message"} {
+			v, err := getString(payload, key)
+			if err != nil {
+				return nil, err
+			}
+			fields[i] = v
+		}
+		actions, err := gitlabCommitActionsFrom(payload)
+		if err != nil {
+			return nil, err
+		}
+		body := map[string]interface{}{"branch": fields[1], "commit_message": fields[2], "actions": actions}
+		if startBranch, _ := payload["start_branch"].(string); startBranch != "" {
+			body["start_branch"] = startBranch
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		commit, err := p.createCommit(ctx, token, fields[0], body)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"status": "success", "commit_sha": commit.ID, "short_id": commit.ShortID, "web_url": commit.WebURL}, nil
+	}
 	if action == "create_issue" {
 		project, err := getString(payload, "project")
 		if err != nil {
@@ -868,7 +1938,7 @@ func (p *GitLabProvider) Execute(ctx context.Context, token *Token, action strin
 		}
 		return map[string]string{"status": "success", "issue_iid": "123", "message": fmt.Sprintf("Created issue in %s: %s", project, title)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // BitbucketProvider implements Provider interface for Bitbucket
@@ -901,7 +1971,7 @@ func (p *BitbucketProvider) Execute(ctx context.Context, token *Token, action st
 		}
 		return map[string]string{"status": "success", "pr_id": "99", "message": fmt.Sprintf("Created PR in %s: %s", repo, title)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // ========== Storage & Documents Providers ==========
@@ -932,7 +2002,7 @@ func (p *DropboxProvider) Execute(ctx context.Context, token *Token, action stri
 		}
 		return map[string]string{"status": "success", "file_id": "id:abc123", "message": fmt.Sprintf("Uploaded file to %s", path)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // GoogleDriveProvider implements Provider interface for Google Drive
@@ -940,6 +2010,10 @@ type GoogleDriveProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses https://www.googleapis.com.
+	APIBaseURL string
+	// RevokeURL overrides the OAuth revocation endpoint; empty uses Google's.
+	RevokeURL string
 }
 
 func NewGoogleDriveProvider(clientID, clientSecret, redirectURL string) *GoogleDriveProvider {
@@ -948,11 +2022,30 @@ func NewGoogleDriveProvider(clientID, clientSecret, redirectURL string) *GoogleD
 
 func (p *GoogleDriveProvider) Name() string { return string(IntegrationGoogleDrive) }
 func (p *GoogleDriveProvider) GetAuthURL(state string) string {
-	return fmt.Sprintf("https://accounts.google.com/o/oauth2/v2/auth?client_id=%s&redirect_uri=%s&response_type=code&scope=https://www.googleapis.com/auth/drive.file&state=%s", p.ClientID, p.RedirectURL, state)
+	return fmt.Sprintf("https://accounts.google.com/o/oauth2/v2/auth?client_id=%s&redirect_uri=%s&response_type=code&scope=https://www.googleapis.com/auth/drive.file%%20https://www.googleapis.com/auth/drive.metadata.readonly&state=%s", p.ClientID, p.RedirectURL, state)
 }
 func (p *GoogleDriveProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("google drive oauth exchange not implemented")
 }
+
+func (p *GoogleDriveProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_file", Description: "Create a file", Fields: []ActionField{
+			{Name: "name", Type: FieldString, Required: true},
+		}},
+		{Name: "list_files", Description: "List or search files, including shared drives", Idempotent: true, Fields: []ActionField{
+			{Name: "q", Type: FieldString},
+			{Name: "drive_id", Type: FieldString},
+			{Name: "include_all_drives", Type: FieldBoolean},
+			{Name: "page_token", Type: FieldString},
+			{Name: "page_size", Type: FieldNumber},
+		}},
+		{Name: "get_file", Description: "Get a file's metadata", Idempotent: true, Fields: []ActionField{
+			{Name: "file_id", Type: FieldString, Required: true},
+		}},
+	}
+}
+
 func (p *GoogleDriveProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "create_file" {
 		name, err := getString(payload, "name")
@@ -961,7 +2054,34 @@ func (p *GoogleDriveProvider) Execute(ctx context.Context, token *Token, action
 		}
 		return map[string]string{"status": "success", "file_id": "1aBcDeFgHiJkLmN", "message": fmt.Sprintf("Created file '%s'", name)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "list_files" {
+		q, _ := payload["q"].(string)
+		driveID, _ := payload["drive_id"].(string)
+		allDrives, _ := payload["include_all_drives"].(bool)
+		next, err := pageToken(payload)
+		if err != nil {
+			return nil, err
+		}
+		size, err := pageSize(payload, 100, 1000)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.listFiles(ctx, token, q, driveID, allDrives, next, size)
+	}
+	if action == "get_file" {
+		fileID, err := getString(payload, "file_id")
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.getFile(ctx, token, fileID)
+	}
+	return nil, unknownAction(p, action)
 }
 
 // OneDriveProvider implements Provider interface for OneDrive
@@ -969,6 +2089,9 @@ type OneDriveProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the Microsoft Graph root, for tests; empty uses
+	// https://graph.microsoft.com.
+	APIBaseURL string
 }
 
 func NewOneDriveProvider(clientID, clientSecret, redirectURL string) *OneDriveProvider {
@@ -982,15 +2105,44 @@ func (p *OneDriveProvider) GetAuthURL(state string) string {
 func (p *OneDriveProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("onedrive oauth exchange not implemented")
 }
+
+func (p *OneDriveProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "upload_file", Description: "Upload a file to OneDrive, or to a SharePoint drive given drive_id; files over 4 MB use an upload session", Fields: []ActionField{
+			{Name: "file_name", Type: FieldString, Required: true},
+			{Name: "folder", Type: FieldString},
+			{Name: "drive_id", Type: FieldString},
+			{Name: "content", Type: FieldString},
+			{Name: "content_base64", Type: FieldString},
+		}},
+	}
+}
+
 func (p *OneDriveProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "upload_file" {
 		fileName, err := getString(payload, "file_name")
 		if err != nil {
 			return nil, err
 		}
-		return map[string]string{"status": "success", "file_id": "abc-123-def", "message": fmt.Sprintf("Uploaded file '%s'", fileName)}, nil
+		content, err := oneDriveContentFrom(payload)
+		if err != nil {
+			return nil, err
+		}
+		filePath := fileName
+		if folder, _ := payload["folder"].(string); strings.Trim(folder, "/") != "" {
+			filePath = strings.Trim(folder, "/") + "/" + fileName
+		}
+		driveID, _ := payload["drive_id"].(string)
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		item, err := p.uploadFile(ctx, token, driveID, filePath, content)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"item_id": item.ID, "name": item.Name, "size": item.Size, "web_url": item.WebURL}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // BoxProvider implements Provider interface for Box
@@ -998,6 +2150,8 @@ type BoxProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses https://api.box.com.
+	APIBaseURL string
 }
 
 func NewBoxProvider(clientID, clientSecret, redirectURL string) *BoxProvider {
@@ -1023,7 +2177,62 @@ func (p *BoxProvider) Execute(ctx context.Context, token *Token, action string,
 		}
 		return map[string]string{"status": "success", "file_id": "123456789", "message": fmt.Sprintf("Uploaded '%s' to folder %s", fileName, folderID)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "create_folder" {
+		name, err := getString(payload, "name")
+		if err != nil {
+			return nil, err
+		}
+		parentID, err := boxFolderID(payload, "parent_id")
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.createFolder(ctx, token, name, parentID)
+	}
+	if action == "add_collaboration" {
+		folderID, err := boxFolderID(payload, "folder_id")
+		if err != nil {
+			return nil, err
+		}
+		role, err := getString(payload, "role")
+		if err != nil {
+			return nil, err
+		}
+		if !boxCollaborationRoles[role] {
+			return nil, invalidField("unknown Box collaboration role %q", role)
+		}
+		userID, _ := payload["user_id"].(string)
+		email, _ := payload["email"].(string)
+		if (userID == "") == (email == "") {
+			return nil, errBoxCollaborator
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.addCollaboration(ctx, token, folderID, userID, email, role)
+	}
+	return nil, unknownAction(p, action)
+}
+
+func (p *BoxProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "upload_file", Description: "Upload a file to a folder", Fields: []ActionField{
+			{Name: "folder_id", Type: FieldString, Required: true},
+			{Name: "file_name", Type: FieldString, Required: true},
+		}},
+		{Name: "create_folder", Description: "Create a folder; parent_id \"0\" is the root", Fields: []ActionField{
+			{Name: "name", Type: FieldString, Required: true},
+			{Name: "parent_id", Type: FieldString, Required: true},
+		}},
+		{Name: "add_collaboration", Description: "Share a folder with a user or email at a role", Fields: []ActionField{
+			{Name: "folder_id", Type: FieldString, Required: true},
+			{Name: "role", Type: FieldString, Required: true},
+			{Name: "user_id", Type: FieldString},
+			{Name: "email", Type: FieldString},
+		}},
+	}
 }
 
 // ========== Payment & E-commerce Providers ==========
@@ -1033,6 +2242,7 @@ type StripeProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	APIBaseURL   string // empty uses https://api.stripe.com
 }
 
 func NewStripeProvider(clientID, clientSecret, redirectURL string) *StripeProvider {
@@ -1055,7 +2265,64 @@ func (p *StripeProvider) Execute(ctx context.Context, token *Token, action strin
 		}
 		return map[string]interface{}{"status": "success", "payment_intent_id": "pi_123abc", "amount": amount, "currency": currency}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	if action == "create_refund" {
+		paymentIntent, _ := payload["payment_intent"].(string)
+		charge, _ := payload["charge"].(string)
+		if (paymentIntent == "") == (charge == "") {
+			return nil, errStripeRefundTarget
+		}
+		amount, err := stripeAmount(payload)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		key, _ := payload["idempotency_key"].(string)
+		if key == "" {
+			key = IdempotencyKey(ctx)
+		}
+		return p.createRefund(ctx, token, paymentIntent, charge, amount, key)
+	}
+	if action == "list_payouts" {
+		status, _ := payload["status"].(string)
+		if status != "" && !stripePayoutStatuses[status] {
+			return nil, invalidField("field 'status' is not a Stripe payout status: %q", status)
+		}
+		next, err := pageToken(payload)
+		if err != nil {
+			return nil, err
+		}
+		size, err := pageSize(payload, 10, 100)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.listPayouts(ctx, token, status, next, size)
+	}
+	return nil, unknownAction(p, action)
+}
+
+func (p *StripeProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_payment_intent", Description: "Create a payment intent", Fields: []ActionField{
+			{Name: "amount", Type: FieldNumber, Required: true},
+			{Name: "currency", Type: FieldString, Required: true},
+		}},
+		{Name: "create_refund", Description: "Refund a charge or payment intent, in full or by amount (smallest currency unit)", Fields: []ActionField{
+			{Name: "payment_intent", Type: FieldString},
+			{Name: "charge", Type: FieldString},
+			{Name: "amount", Type: FieldNumber},
+			{Name: "idempotency_key", Type: FieldString},
+		}},
+		{Name: "list_payouts", Description: "List payouts to the account's bank, newest first", Idempotent: true, Fields: []ActionField{
+			{Name: "status", Type: FieldString},
+			{Name: "page_token", Type: FieldString},
+			{Name: "page_size", Type: FieldNumber},
+		}},
+	}
 }
 
 // ShopifyProvider implements Provider interface for Shopify
@@ -1084,7 +2351,7 @@ func (p *ShopifyProvider) Execute(ctx context.Context, token *Token, action stri
 		}
 		return map[string]string{"status": "success", "product_id": "1234567890", "message": fmt.Sprintf("Created product '%s'", title)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // PayPalProvider implements Provider interface for PayPal
@@ -1110,7 +2377,7 @@ func (p *PayPalProvider) Execute(ctx context.Context, token *Token, action strin
 		amount := payload["amount"]
 		return map[string]interface{}{"status": "success", "payment_id": "PAY-123ABC", "amount": amount}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // SquareProvider implements Provider interface for Square
@@ -1136,7 +2403,7 @@ func (p *SquareProvider) Execute(ctx context.Context, token *Token, action strin
 		amount := payload["amount"]
 		return map[string]interface{}{"status": "success", "payment_id": "sq0abc123", "amount": amount}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // ========== Data & Analytics Providers ==========
@@ -1146,6 +2413,13 @@ type AirtableProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses https://api.airtable.com.
+	APIBaseURL string
+	// Watermarks persists list_changes marks; nil keeps them in memory.
+	Watermarks WatermarkStore
+
+	memoryMarksOnce sync.Once
+	memoryMarks     *MemoryWatermarkStore
 }
 
 func NewAirtableProvider(clientID, clientSecret, redirectURL string) *AirtableProvider {
@@ -1159,7 +2433,42 @@ func (p *AirtableProvider) GetAuthURL(state string) string {
 func (p *AirtableProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("airtable oauth exchange not implemented")
 }
+
+func (p *AirtableProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "create_record", Description: "Create a record in a table", Fields: []ActionField{
+			{Name: "table", Type: FieldString, Required: true},
+			{Name: "fields", Type: FieldObject},
+		}},
+		{Name: "list_changes", Description: "List records modified since your last poll of the table in the acting workspace; the first poll returns every record", Idempotent: true, Fields: []ActionField{
+			{Name: "base_id", Type: FieldString, Required: true},
+			{Name: "table", Type: FieldString, Required: true},
+			{Name: "since", Type: FieldString},
+		}},
+	}
+}
+
 func (p *AirtableProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
+	if action == "list_changes" {
+		baseID, err := getString(payload, "base_id")
+		if err != nil {
+			return nil, err
+		}
+		table, err := getString(payload, "table")
+		if err != nil {
+			return nil, err
+		}
+		var since time.Time
+		if raw, _ := payload["since"].(string); raw != "" {
+			if since, err = time.Parse(time.RFC3339, raw); err != nil {
+				return nil, invalidField("field 'since' must be RFC 3339: %w", err)
+			}
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.listChanges(ctx, token, baseID, table, since)
+	}
 	if action == "create_record" {
 		table, err := getString(payload, "table")
 		if err != nil {
@@ -1168,7 +2477,7 @@ func (p *AirtableProvider) Execute(ctx context.Context, token *Token, action str
 		fields := payload["fields"] // arbitrary object — kept as interface{}
 		return map[string]interface{}{"status": "success", "record_id": "recABC123", "message": fmt.Sprintf("Created record in table %s", table), "fields": fields}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // GoogleSheetsProvider implements Provider interface for Google Sheets
@@ -1198,7 +2507,7 @@ func (p *GoogleSheetsProvider) Execute(ctx context.Context, token *Token, action
 		values := payload["values"] // array — kept as interface{}
 		return map[string]interface{}{"status": "success", "spreadsheet_id": spreadsheetID, "message": "Row appended successfully", "values": values}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // TableauProvider implements Provider interface for Tableau
@@ -1227,7 +2536,7 @@ func (p *TableauProvider) Execute(ctx context.Context, token *Token, action stri
 		}
 		return map[string]string{"status": "success", "datasource_id": datasourceID, "message": "Datasource refresh initiated"}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // MicrosoftExcelProvider implements Provider interface for Microsoft Excel
@@ -1261,7 +2570,7 @@ func (p *MicrosoftExcelProvider) Execute(ctx context.Context, token *Token, acti
 		value := payload["value"] // may be any scalar — kept as interface{}
 		return map[string]interface{}{"status": "success", "workbook_id": workbookID, "cell": cell, "value": value, "message": "Cell updated successfully"}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // ========== Social Media Providers ==========
@@ -1292,7 +2601,7 @@ func (p *TwitterProvider) Execute(ctx context.Context, token *Token, action stri
 		}
 		return map[string]string{"status": "success", "tweet_id": "1234567890", "message": fmt.Sprintf("Posted tweet: %s", text)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // LinkedInProvider implements Provider interface for LinkedIn
@@ -1300,6 +2609,8 @@ type LinkedInProvider struct {
 	ClientID     string
 	ClientSecret string
 	RedirectURL  string
+	// APIBaseURL overrides the API root; empty uses https://api.linkedin.com.
+	APIBaseURL string
 }
 
 func NewLinkedInProvider(clientID, clientSecret, redirectURL string) *LinkedInProvider {
@@ -1313,15 +2624,36 @@ func (p *LinkedInProvider) GetAuthURL(state string) string {
 func (p *LinkedInProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
 	return nil, errors.New("linkedin oauth exchange not implemented")
 }
+
+func (p *LinkedInProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "share_post", Description: "Share a post as the connected member", Fields: []ActionField{
+			{Name: "text", Type: FieldString, Required: true},
+			{Name: "visibility", Type: FieldString},
+		}},
+		getMeSpec,
+	}
+}
+
 func (p *LinkedInProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
 	if action == "share_post" {
 		text, err := getString(payload, "text")
 		if err != nil {
 			return nil, err
 		}
-		return map[string]string{"status": "success", "post_id": "urn:li:share:123", "message": fmt.Sprintf("Posted to LinkedIn: %s", text)}, nil
+		visibility, err := linkedinVisibility(payload)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			return nil, ErrMissingToken
+		}
+		return p.sharePost(ctx, token, text, visibility)
+	}
+	if action == "get_me" {
+		return getMe(ctx, p, token)
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // FacebookProvider implements Provider interface for Facebook
@@ -1350,7 +2682,7 @@ func (p *FacebookProvider) Execute(ctx context.Context, token *Token, action str
 		}
 		return map[string]string{"status": "success", "post_id": "123456789_987654321", "message": fmt.Sprintf("Published to Facebook: %s", message)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // InstagramProvider implements Provider interface for Instagram
@@ -1383,7 +2715,7 @@ func (p *InstagramProvider) Execute(ctx context.Context, token *Token, action st
 		}
 		return map[string]string{"status": "success", "media_id": "12345_67890", "message": fmt.Sprintf("Published %s to Instagram with caption: %s", imgURL, caption)}, nil
 	}
-	return nil, fmt.Errorf("unknown action: %s", action)
+	return nil, unknownAction(p, action)
 }
 
 // Add similar structs for GmailProvider, JiraProvider, etc.
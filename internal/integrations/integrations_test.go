@@ -1,7 +1,12 @@
-﻿package integrations
+package integrations
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 )
@@ -147,19 +152,55 @@ func TestSlackProvider_GetAuthURL_DifferentStates(t *testing.T) {
 		t.Error("same url for different states")
 	}
 }
+
+// newSlackTokenServer serves Slack's oauth.v2.access, accepting only
+// "valid_code".
+func newSlackTokenServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method != http.MethodPost || r.URL.Path != "/oauth.v2.access" ||
+			r.Form.Get("client_id") != "cid" || r.Form.Get("client_secret") != "secret" ||
+			r.Form.Get("redirect_uri") != "http://localhost/callback/slack" {
+			t.Errorf("unexpected token request %s %s %v", r.Method, r.URL.Path, r.Form)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Form.Get("code") != "valid_code" {
+			w.Write([]byte(`{"ok":false,"error":"invalid_code"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"access_token":"xoxb-new","token_type":"bot","scope":"chat:write","bot_user_id":"U1"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newSlackWithTokenServer(t *testing.T) *SlackProvider {
+	return &SlackProvider{ClientID: "cid", ClientSecret: "secret", RedirectURL: "http://localhost/callback/slack", APIBaseURL: newSlackTokenServer(t).URL}
+}
+
 func TestSlackProvider_ExchangeCode_Valid(t *testing.T) {
-	tok, err := newSlack().ExchangeCode(context.Background(), "valid_code")
+	tok, err := newSlackWithTokenServer(t).ExchangeCode(context.Background(), "valid_code")
 	if err != nil {
-		t.Fatalf("error: %v", err)
+		t.Fatalf("ExchangeCode: %v", err)
 	}
-	if tok == nil || tok.AccessToken == "" {
-		t.Error("nil or empty token")
+	if tok.AccessToken != "xoxb-new" || tok.TokenType != "bot" {
+		t.Errorf("unexpected token %+v", tok)
 	}
 }
 func TestSlackProvider_ExchangeCode_Invalid(t *testing.T) {
-	_, err := newSlack().ExchangeCode(context.Background(), "bad_code")
-	if err == nil {
-		t.Error("expected error for invalid code")
+	_, err := newSlackWithTokenServer(t).ExchangeCode(context.Background(), "bad_code")
+	if !errors.Is(err, errSlackCode("invalid_code")) {
+		t.Errorf("expected invalid_code error, got %v", err)
+	}
+}
+func TestSlackProvider_GetAuthURL_Scopes(t *testing.T) {
+	if u := newSlack().GetAuthURL("s"); !strings.Contains(u, "scope="+url.QueryEscape("chat:write,users:read,users:read.email,im:write")) {
+		t.Errorf("default scopes missing from %s", u)
+	}
+	p := &SlackProvider{Scopes: []string{"chat:write", "channels:read"}}
+	if u := p.GetAuthURL("s"); !strings.Contains(u, "scope="+url.QueryEscape("chat:write,channels:read")+"&") {
+		t.Errorf("configured scopes missing from %s", u)
 	}
 }
 func TestSlackProvider_Execute_SendMessage_Success(t *testing.T) {
@@ -211,23 +252,54 @@ func TestGmailProvider_GetAuthURL_ContainsState(t *testing.T) {
 		t.Error("Gmail auth url should not be empty")
 	}
 }
+
+// newGmailTokenServer serves Google's token endpoint, accepting only
+// "valid_code".
+func newGmailTokenServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method != http.MethodPost || r.Form.Get("grant_type") != "authorization_code" ||
+			r.Form.Get("client_id") != "cid" || r.Form.Get("client_secret") != "secret" ||
+			r.Form.Get("redirect_uri") != "http://localhost/callback/gmail" {
+			t.Errorf("unexpected token request %s %v", r.Method, r.Form)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Form.Get("code") != "valid_code" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"Bad Request"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"ya29.new","refresh_token":"1//refresh","token_type":"Bearer","expires_in":3599}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newGmailWithTokenServer(t *testing.T) *GmailProvider {
+	return &GmailProvider{ClientID: "cid", ClientSecret: "secret", RedirectURL: "http://localhost/callback/gmail", TokenURL: newGmailTokenServer(t).URL}
+}
+
 func TestGmailProvider_ExchangeCode_Valid(t *testing.T) {
-	tok, err := newGmail().ExchangeCode(context.Background(), "valid_code")
+	tok, err := newGmailWithTokenServer(t).ExchangeCode(context.Background(), "valid_code")
 	if err != nil {
-		// Gmail OAuth exchange requires real provider setup — mark as known mock limitation
-		t.Skipf("Gmail ExchangeCode not mocked: %v", err)
+		t.Fatalf("ExchangeCode: %v", err)
 	}
-	if tok == nil || tok.AccessToken == "" {
-		t.Error("nil or empty token")
+	if tok.AccessToken != "ya29.new" || tok.RefreshToken != "1//refresh" || tok.TokenType != "Bearer" {
+		t.Errorf("unexpected token %+v", tok)
+	}
+	if until := time.Until(tok.ExpiresAt); until < 59*time.Minute || until > time.Hour {
+		t.Errorf("ExpiresAt = %v, want about an hour from now", tok.ExpiresAt)
 	}
 }
 func TestGmailProvider_ExchangeCode_Invalid(t *testing.T) {
-	_, err := newGmail().ExchangeCode(context.Background(), "bad_code")
-	if err == nil {
-		t.Error("expected error")
+	_, err := newGmailWithTokenServer(t).ExchangeCode(context.Background(), "bad_code")
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("expected invalid_grant error, got %v", err)
 	}
 }
 func TestGmailProvider_Execute_SendEmail(t *testing.T) {
+	stubGmail(t, func(*http.Request) (int, string) { return http.StatusOK, `{"id":"m1","threadId":"t1"}` })
 	tok := &Token{AccessToken: "ya29-test"}
 	res, err := newGmail().Execute(context.Background(), tok, "send_email", map[string]interface{}{"to": "a@b.com", "subject": "Subj", "body": "Body"})
 	if err != nil {
@@ -0,0 +1,79 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultIntercomAPIBaseURL is the Intercom API root used when an
+// IntercomProvider has no APIBaseURL override.
+const defaultIntercomAPIBaseURL = "https://api.intercom.io"
+
+// intercomHTTPClient is shared by Intercom API calls. Requests are bounded by
+// the provider's ActionTimeout.
+var intercomHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// intercomReplyTypes are the message_type values reply_to_conversation
+// accepts: a comment is sent to the customer, a note is visible to teammates
+// only.
+var intercomReplyTypes = map[string]bool{"comment": true, "note": true}
+
+// intercomID reads a required Intercom object ID from payload[key].
+func intercomID(payload map[string]interface{}, key string) (string, error) {
+	id, err := getString(payload, key)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(id) == "" {
+		return "", invalidField("field '%s' must not be empty", key)
+	}
+	return id, nil
+}
+
+// replyToConversation posts an admin reply to a conversation and returns the
+// conversation's ID and state after the reply.
+func (p *IntercomProvider) replyToConversation(ctx context.Context, token *Token, conversationID, adminID, body, messageType string) (map[string]interface{}, error) {
+	reply := map[string]string{
+		"type":         "admin",
+		"admin_id":     adminID,
+		"message_type": messageType,
+		"body":         body,
+	}
+	var conversation struct {
+		ID    string `json:"id"`
+		State string `json:"state"`
+	}
+	if err := p.intercomCall(ctx, token, "/conversations/"+url.PathEscape(conversationID)+"/reply", reply, &conversation); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "conversation_id": conversation.ID, "state": conversation.State}, nil
+}
+
+// tagContact applies a tag to a contact, creating the tag if no tag has that
+// name yet.
+func (p *IntercomProvider) tagContact(ctx context.Context, token *Token, contactID, tag string) (map[string]interface{}, error) {
+	body := map[string]interface{}{
+		"name":  tag,
+		"users": []map[string]string{{"id": contactID}},
+	}
+	var tagged struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := p.intercomCall(ctx, token, "/tags", body, &tagged); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "tag_id": tagged.ID, "tag": tagged.Name, "contact_id": contactID}, nil
+}
+
+// intercomCall POSTs body as JSON to an Intercom API path and decodes the
+// reply into out.
+func (p *IntercomProvider) intercomCall(ctx context.Context, token *Token, path string, body, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultIntercomAPIBaseURL
+	}
+	return doJSON(ctx, intercomHTTPClient, IntegrationIntercom, http.MethodPost, base+path, token, body, out)
+}
@@ -0,0 +1,104 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestIntercom_ReplyToConversation(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/conversations/147/reply" || r.Header.Get("Authorization") != "Bearer dG9rOmFi" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"type":"conversation","id":"147","state":"open"}`))
+	}))
+	defer srv.Close()
+	p := &IntercomProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "dG9rOmFi"}, "reply_to_conversation", map[string]interface{}{
+		"conversation_id": "147", "admin_id": "991", "body": "Thanks, we're on it.",
+	})
+	if err != nil {
+		t.Fatalf("reply_to_conversation: %v", err)
+	}
+	want := map[string]interface{}{"status": "success", "conversation_id": "147", "state": "open"}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("result = %v, want %v", res, want)
+	}
+	wantBody := map[string]interface{}{"type": "admin", "admin_id": "991", "message_type": "comment", "body": "Thanks, we're on it."}
+	if !reflect.DeepEqual(body, wantBody) {
+		t.Errorf("body = %v, want %v", body, wantBody)
+	}
+
+	for _, payload := range []map[string]interface{}{
+		{"admin_id": "991", "body": "hi"},
+		{"conversation_id": " ", "admin_id": "991", "body": "hi"},
+		{"conversation_id": "147", "body": "hi"},
+		{"conversation_id": "147", "admin_id": "991"},
+		{"conversation_id": "147", "admin_id": "991", "body": "hi", "message_type": "email"},
+	} {
+		_, err := p.Execute(context.Background(), &Token{AccessToken: "dG9rOmFi"}, "reply_to_conversation", payload)
+		if !errors.Is(err, ErrMissingField) && !errors.Is(err, ErrInvalidField) {
+			t.Errorf("expected a field error for payload %v, got %v", payload, err)
+		}
+	}
+}
+
+func TestIntercom_TagContact(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/tags" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"type":"tag","id":"81","name":"vip"}`))
+	}))
+	defer srv.Close()
+	p := &IntercomProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "dG9rOmFi"}, "tag_contact", map[string]interface{}{
+		"contact_id": "63a07ddf05a32042dffac965", "tag": "vip",
+	})
+	if err != nil {
+		t.Fatalf("tag_contact: %v", err)
+	}
+	want := map[string]interface{}{"status": "success", "tag_id": "81", "tag": "vip", "contact_id": "63a07ddf05a32042dffac965"}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("result = %v, want %v", res, want)
+	}
+	wantBody := map[string]interface{}{"name": "vip", "users": []interface{}{map[string]interface{}{"id": "63a07ddf05a32042dffac965"}}}
+	if !reflect.DeepEqual(body, wantBody) {
+		t.Errorf("body = %v, want %v", body, wantBody)
+	}
+
+	if _, err := p.Execute(context.Background(), &Token{AccessToken: "dG9rOmFi"}, "tag_contact", map[string]interface{}{"tag": "vip"}); !errors.Is(err, ErrMissingField) {
+		t.Errorf("expected ErrMissingField without contact_id, got %v", err)
+	}
+	if _, err := p.Execute(context.Background(), nil, "tag_contact", map[string]interface{}{"contact_id": "1", "tag": "vip"}); !errors.Is(err, ErrMissingToken) {
+		t.Errorf("expected ErrMissingToken, got %v", err)
+	}
+}
+
+func TestIntercom_UnknownConversationIsNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"type":"error.list","request_id":"req-1","errors":[{"code":"not_found","message":"Conversation Not Found"}]}`))
+	}))
+	defer srv.Close()
+	p := &IntercomProvider{APIBaseURL: srv.URL}
+
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "dG9rOmFi"}, "reply_to_conversation", map[string]interface{}{
+		"conversation_id": "404", "admin_id": "991", "body": "hi",
+	})
+	var upstream *UpstreamError
+	if !errors.As(err, &upstream) || !errors.Is(err, ErrNotFound) || upstream.Code != "not_found" || upstream.Message != "Conversation Not Found" {
+		t.Fatalf("expected a not found error from the Intercom body, got %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package integrations
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// defaultJiraAPIBaseURL is the Atlassian API gateway used when a JiraProvider
+// has no APIBaseURL override. Jira Cloud sites are reached through it at
+// /ex/jira/{cloudid}.
+const defaultJiraAPIBaseURL = "https://api.atlassian.com"
+
+// jiraHTTPClient is shared by Jira API calls. Requests are bounded by the
+// provider's ActionTimeout.
+var jiraHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// jiraSiteCacheEntries bounds the number of tokens whose sites are cached.
+const jiraSiteCacheEntries = 1024
+
+// jiraSite is a Jira Cloud site a token can reach, as listed by the
+// accessible-resources endpoint.
+type jiraSite struct {
+	ID   string `json:"id"` // the cloud ID
+	URL  string `json:"url"`
+	Name string `json:"name"`
+}
+
+// jiraIssue is the fields object of a create-issue request.
+type jiraIssue struct {
+	Project     map[string]string      `json:"project"`
+	Summary     string                 `json:"summary"`
+	IssueType   map[string]string      `json:"issuetype"`
+	Description map[string]interface{} `json:"description,omitempty"`
+	Priority    map[string]string      `json:"priority,omitempty"`
+	Labels      []string               `json:"labels,omitempty"`
+}
+
+// jiraSiteCache remembers the sites each access token can reach, so the
+// cloud ID is resolved once per token rather than once per action.
+type jiraSiteCache struct {
+	mu    sync.Mutex
+	sites map[string][]jiraSite // by SHA-256 of the access token
+}
+
+func (c *jiraSiteCache) get(key string) ([]jiraSite, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sites, ok := c.sites[key]
+	return sites, ok
+}
+
+// put stores sites for key. The cache is cleared when full; tokens are
+// short-lived, so most entries are stale by then anyway.
+func (c *jiraSiteCache) put(key string, sites []jiraSite) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sites == nil || len(c.sites) >= jiraSiteCacheEntries {
+		c.sites = make(map[string][]jiraSite)
+	}
+	c.sites[key] = sites
+}
+
+// jiraDescription converts plain text into an Atlassian Document Format
+// document, which the v3 API requires. Blank lines separate paragraphs and
+// single newlines become hard breaks.
+func jiraDescription(text string) map[string]interface{} {
+	var paragraphs []interface{}
+	for _, block := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		block = strings.Trim(block, "\n")
+		if strings.TrimSpace(block) == "" {
+			continue
+		}
+		var content []interface{}
+		for i, line := range strings.Split(block, "\n") {
+			if i > 0 {
+				content = append(content, map[string]interface{}{"type": "hardBreak"})
+			}
+			if line != "" {
+				content = append(content, map[string]interface{}{"type": "text", "text": line})
+			}
+		}
+		paragraphs = append(paragraphs, map[string]interface{}{"type": "paragraph", "content": content})
+	}
+	return map[string]interface{}{"type": "doc", "version": 1, "content": paragraphs}
+}
+
+// resolveSite returns the Jira site to call for token: the one whose cloud
+// ID is cloudID, or, when cloudID is empty, the token's only site.
+func (p *JiraProvider) resolveSite(ctx context.Context, token *Token, cloudID string) (jiraSite, error) {
+	sum := sha256.Sum256([]byte(token.AccessToken))
+	key := hex.EncodeToString(sum[:])
+	sites, ok := p.sites.get(key)
+	if !ok {
+		if err := p.jiraCall(ctx, token, http.MethodGet, "/oauth/token/accessible-resources", nil, &sites); err != nil {
+			return jiraSite{}, err
+		}
+		p.sites.put(key, sites)
+	}
+
+	if cloudID != "" {
+		for _, s := range sites {
+			if s.ID == cloudID {
+				return s, nil
+			}
+		}
+		return jiraSite{}, fmt.Errorf("jira: token has no access to cloud_id %s: %w", cloudID, ErrNotFound)
+	}
+	switch len(sites) {
+	case 0:
+		return jiraSite{}, fmt.Errorf("jira: token has no accessible sites: %w", ErrInvalidCredentials)
+	case 1:
+		return sites[0], nil
+	}
+	names := make([]string, len(sites))
+	for i, s := range sites {
+		names[i] = fmt.Sprintf("%s (%s)", s.Name, s.ID)
+	}
+	return jiraSite{}, fmt.Errorf("token can reach several Jira sites, set cloud_id to one of: %s", strings.Join(names, ", "))
+}
+
+// createIssue creates an issue on the token's Jira site and returns its key
+// and browse URL.
+func (p *JiraProvider) createIssue(ctx context.Context, token *Token, cloudID string, fields jiraIssue) (map[string]interface{}, error) {
+	site, err := p.resolveSite(ctx, token, cloudID)
+	if err != nil {
+		return nil, err
+	}
+	var created struct {
+		ID  string `json:"id"`
+		Key string `json:"key"`
+	}
+	path := "/ex/jira/" + site.ID + "/rest/api/3/issue"
+	if err := p.jiraCall(ctx, token, http.MethodPost, path, map[string]interface{}{"fields": fields}, &created); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"status":    "success",
+		"issue_id":  created.ID,
+		"issue_key": created.Key,
+		"url":       strings.TrimSuffix(site.URL, "/") + "/browse/" + created.Key,
+	}, nil
+}
+
+// jiraCall sends a request to the Atlassian API, with body encoded as JSON
+// when it is non-nil, and decodes the reply into out.
+func (p *JiraProvider) jiraCall(ctx context.Context, token *Token, method, path string, body, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultJiraAPIBaseURL
+	}
+	return doJSON(ctx, jiraHTTPClient, IntegrationJira, method, base+path, token, body, out)
+}
+
+// jiraLabels reads the optional "labels" field. Jira labels cannot contain
+// spaces.
+func jiraLabels(payload map[string]interface{}) ([]string, error) {
+	labels, err := getStringList(payload, "labels")
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range labels {
+		if l == "" || strings.ContainsAny(l, " \t\n") {
+			return nil, invalidField("field 'labels' must hold non-empty labels without spaces, got %q", l)
+		}
+	}
+	return labels, nil
+}
@@ -0,0 +1,153 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newJiraServer serves accessible-resources with sites and creates issues on
+// any of them, recording the fields of the last issue and counting site
+// lookups.
+func newJiraServer(t *testing.T, sites string, fields *map[string]interface{}, lookups *int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer jira-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/oauth/token/accessible-resources":
+			atomic.AddInt32(lookups, 1)
+			w.Write([]byte(sites))
+		case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/ex/jira/") && strings.HasSuffix(r.URL.Path, "/rest/api/3/issue"):
+			var body struct {
+				Fields map[string]interface{} `json:"fields"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			*fields = body.Fields
+			if body.Fields["project"].(map[string]interface{})["key"] == "NOPE" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"errorMessages":[],"errors":{"project":"valid project is required"}}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"10042","key":"OPS-7","self":"` + r.URL.Path + `/10042"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestJira_CreateIssue(t *testing.T) {
+	var fields map[string]interface{}
+	var lookups int32
+	srv := newJiraServer(t, `[{"id":"cloud-1","url":"https://acme.atlassian.net","name":"acme","scopes":["write:jira-work"]}]`, &fields, &lookups)
+	p := &JiraProvider{APIBaseURL: srv.URL}
+	tok := &Token{AccessToken: "jira-token"}
+
+	res, err := p.Execute(context.Background(), tok, "create_issue", map[string]interface{}{
+		"project":     "OPS",
+		"summary":     "Disk full on db-1",
+		"issue_type":  "Bug",
+		"description": "Volume at 98%.\nAlerted at 02:00.\n\nRunbook: disk-cleanup",
+		"priority":    "High",
+		"labels":      []interface{}{"ops", "disk"},
+	})
+	if err != nil {
+		t.Fatalf("create_issue: %v", err)
+	}
+	got := res.(map[string]interface{})
+	if got["issue_key"] != "OPS-7" || got["url"] != "https://acme.atlassian.net/browse/OPS-7" {
+		t.Errorf("result = %v", got)
+	}
+
+	var want map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"project": {"key": "OPS"},
+		"summary": "Disk full on db-1",
+		"issuetype": {"name": "Bug"},
+		"priority": {"name": "High"},
+		"labels": ["ops", "disk"],
+		"description": {"type": "doc", "version": 1, "content": [
+			{"type": "paragraph", "content": [
+				{"type": "text", "text": "Volume at 98%."},
+				{"type": "hardBreak"},
+				{"type": "text", "text": "Alerted at 02:00."}
+			]},
+			{"type": "paragraph", "content": [{"type": "text", "text": "Runbook: disk-cleanup"}]}
+		]}
+	}`), &want)
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %v\nwant %v", fields, want)
+	}
+
+	// The cloud ID is resolved once per token.
+	if _, err := p.Execute(context.Background(), tok, "create_issue", map[string]interface{}{"project": "OPS", "summary": "again"}); err != nil {
+		t.Fatalf("second create_issue: %v", err)
+	}
+	if n := atomic.LoadInt32(&lookups); n != 1 {
+		t.Errorf("accessible-resources called %d times, want 1", n)
+	}
+	if fields["issuetype"].(map[string]interface{})["name"] != "Task" {
+		t.Errorf("issue type should default to Task, got %v", fields["issuetype"])
+	}
+	if _, ok := fields["description"]; ok {
+		t.Error("description should be omitted when empty")
+	}
+}
+
+func TestJira_CreateIssueSeveralSites(t *testing.T) {
+	var fields map[string]interface{}
+	var lookups int32
+	srv := newJiraServer(t, `[{"id":"cloud-1","url":"https://acme.atlassian.net","name":"acme"},{"id":"cloud-2","url":"https://acme-eu.atlassian.net","name":"acme-eu"}]`, &fields, &lookups)
+	p := &JiraProvider{APIBaseURL: srv.URL}
+	tok := &Token{AccessToken: "jira-token"}
+
+	_, err := p.Execute(context.Background(), tok, "create_issue", map[string]interface{}{"project": "OPS", "summary": "x"})
+	if err == nil || !strings.Contains(err.Error(), "cloud_id") || !strings.Contains(err.Error(), "acme-eu (cloud-2)") {
+		t.Errorf("expected an error listing the sites, got %v", err)
+	}
+
+	res, err := p.Execute(context.Background(), tok, "create_issue", map[string]interface{}{"project": "OPS", "summary": "x", "cloud_id": "cloud-2"})
+	if err != nil {
+		t.Fatalf("create_issue with cloud_id: %v", err)
+	}
+	if got := res.(map[string]interface{})["url"]; got != "https://acme-eu.atlassian.net/browse/OPS-7" {
+		t.Errorf("url = %v", got)
+	}
+
+	if _, err := p.Execute(context.Background(), tok, "create_issue", map[string]interface{}{"project": "OPS", "summary": "x", "cloud_id": "cloud-9"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for an unknown cloud_id, got %v", err)
+	}
+}
+
+func TestJira_CreateIssueErrors(t *testing.T) {
+	var fields map[string]interface{}
+	var lookups int32
+	srv := newJiraServer(t, `[{"id":"cloud-1","url":"https://acme.atlassian.net","name":"acme"}]`, &fields, &lookups)
+	p := &JiraProvider{APIBaseURL: srv.URL}
+
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "jira-token"}, "create_issue", map[string]interface{}{"project": "NOPE", "summary": "x"})
+	if !errors.Is(err, ErrValidation) || !strings.Contains(err.Error(), "project: valid project is required") {
+		t.Errorf("expected a validation error naming the field, got %v", err)
+	}
+
+	_, err = p.Execute(context.Background(), &Token{AccessToken: "expired"}, "create_issue", map[string]interface{}{"project": "OPS", "summary": "x"})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+
+	_, err = p.Execute(context.Background(), &Token{AccessToken: "jira-token"}, "create_issue", map[string]interface{}{"project": "OPS", "summary": "x", "labels": []interface{}{"two words"}})
+	if !errors.Is(err, ErrInvalidField) {
+		t.Errorf("expected ErrInvalidField for a label with a space, got %v", err)
+	}
+}
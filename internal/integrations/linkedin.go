@@ -0,0 +1,148 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// defaultLinkedInAPIBaseURL is the LinkedIn API root used when a
+// LinkedInProvider has no APIBaseURL override.
+const defaultLinkedInAPIBaseURL = "https://api.linkedin.com"
+
+// linkedinHTTPClient is shared by LinkedIn API calls. Requests are bounded by
+// the provider's ActionTimeout.
+var linkedinHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// ErrLinkedInDuplicatePost is returned when LinkedIn rejects a post because
+// the member recently shared the same content.
+var ErrLinkedInDuplicatePost = errors.New("linkedin post is a duplicate")
+
+// linkedinVisibilities are the member network visibilities share_post
+// accepts.
+var linkedinVisibilities = map[string]bool{"PUBLIC": true, "CONNECTIONS": true}
+
+// linkedinVisibility reads the optional payload["visibility"], defaulting to
+// PUBLIC.
+func linkedinVisibility(payload map[string]interface{}) (string, error) {
+	if _, ok := payload["visibility"]; !ok {
+		return "PUBLIC", nil
+	}
+	v, err := getString(payload, "visibility")
+	if err != nil {
+		return "", err
+	}
+	v = strings.ToUpper(v)
+	if !linkedinVisibilities[v] {
+		return "", invalidField("field 'visibility' must be PUBLIC or CONNECTIONS, got %q", v)
+	}
+	return v, nil
+}
+
+// TestConnection identifies the member the token belongs to via GET /v2/me.
+// The r_liteprofile scope does not include the email address.
+func (p *LinkedInProvider) TestConnection(ctx context.Context, token *Token) (*Identity, error) {
+	var me struct {
+		ID        string `json:"id"`
+		FirstName string `json:"localizedFirstName"`
+		LastName  string `json:"localizedLastName"`
+	}
+	if _, err := p.linkedinCall(ctx, token, http.MethodGet, "/v2/me", nil, &me); err != nil {
+		return nil, err
+	}
+	return &Identity{ID: me.ID, Name: strings.TrimSpace(me.FirstName + " " + me.LastName)}, nil
+}
+
+// sharePost publishes text to the member's feed with the given network
+// visibility and returns the post URN and URL; the URL is omitted when
+// LinkedIn does not report the new post's ID. The author URN is resolved
+// from the token, since LinkedIn only lets members post as themselves. A
+// repeated post fails with ErrLinkedInDuplicatePost.
+func (p *LinkedInProvider) sharePost(ctx context.Context, token *Token, text, visibility string) (map[string]interface{}, error) {
+	me, err := p.TestConnection(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	author := "urn:li:person:" + me.ID
+	body := map[string]interface{}{
+		"author":         author,
+		"lifecycleState": "PUBLISHED",
+		"specificContent": map[string]interface{}{
+			"com.linkedin.ugc.ShareContent": map[string]interface{}{
+				"shareCommentary":    map[string]string{"text": text},
+				"shareMediaCategory": "NONE",
+			},
+		},
+		"visibility": map[string]string{"com.linkedin.ugc.MemberNetworkVisibility": visibility},
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	header, err := p.linkedinCall(ctx, token, http.MethodPost, "/v2/ugcPosts", body, &created)
+	if err != nil {
+		return nil, err
+	}
+	postID := created.ID
+	if postID == "" {
+		postID = header.Get("X-RestLi-Id")
+	}
+	result := map[string]interface{}{
+		"status":  "success",
+		"post_id": postID,
+		"author":  author,
+	}
+	if postID != "" {
+		result["url"] = "https://www.linkedin.com/feed/update/" + postID + "/"
+	}
+	return result, nil
+}
+
+// linkedinCall sends body, if any, as JSON to a LinkedIn API path and
+// decodes the reply into out. It returns the response headers, which carry
+// the ID of created entities.
+func (p *LinkedInProvider) linkedinCall(ctx context.Context, token *Token, method, path string, body, out interface{}) (http.Header, error) {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultLinkedInAPIBaseURL
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encode linkedin request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	ctx, cancel := withActionTimeout(ctx, p.Name())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, base+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	req.Header.Set("X-Restli-Protocol-Version", "2.0.0")
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := linkedinHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("linkedin %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(IntegrationLinkedIn, resp); err != nil {
+		return nil, fmt.Errorf("linkedin %s: %w", path, err)
+	}
+	// ugcPosts may answer 201 with an empty body.
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to decode LinkedIn response: %w", err)
+	}
+	return resp.Header, nil
+}
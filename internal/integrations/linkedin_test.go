@@ -0,0 +1,141 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFakeLinkedIn serves /v2/me for member "abc123" and records the body of
+// a ugcPosts request.
+func newFakeLinkedIn(t *testing.T, posted *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer li-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"serviceErrorCode":65600,"message":"Invalid access token","status":401}`))
+			return
+		}
+		w.Write([]byte(`{"id":"abc123","localizedFirstName":"Ada","localizedLastName":"Lovelace"}`))
+	})
+	mux.HandleFunc("/v2/ugcPosts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("X-Restli-Protocol-Version") != "2.0.0" {
+			t.Errorf("unexpected request %s with protocol %q", r.Method, r.Header.Get("X-Restli-Protocol-Version"))
+		}
+		json.NewDecoder(r.Body).Decode(posted)
+		w.Header().Set("X-RestLi-Id", "urn:li:share:6844785523593134080")
+		w.WriteHeader(http.StatusCreated)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestLinkedIn_GetMe(t *testing.T) {
+	p := &LinkedInProvider{APIBaseURL: newFakeLinkedIn(t, nil).URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "li-token"}, "get_me", nil)
+	if err != nil {
+		t.Fatalf("get_me: %v", err)
+	}
+	out := res.(map[string]interface{})
+	if out["id"] != "abc123" || out["name"] != "Ada Lovelace" || out["email"] != "" {
+		t.Errorf("unexpected result %v", out)
+	}
+
+	_, err = p.Execute(context.Background(), &Token{AccessToken: "expired"}, "get_me", nil)
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestLinkedIn_SharePost_AuthorFromToken(t *testing.T) {
+	var posted map[string]interface{}
+	p := &LinkedInProvider{APIBaseURL: newFakeLinkedIn(t, &posted).URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "li-token"}, "share_post", map[string]interface{}{"text": "Hello"})
+	if err != nil {
+		t.Fatalf("share_post: %v", err)
+	}
+	out := res.(map[string]interface{})
+	if out["post_id"] != "urn:li:share:6844785523593134080" || out["author"] != "urn:li:person:abc123" ||
+		out["url"] != "https://www.linkedin.com/feed/update/urn:li:share:6844785523593134080/" {
+		t.Errorf("unexpected result %v", out)
+	}
+	if posted["author"] != "urn:li:person:abc123" {
+		t.Errorf("post author = %v, want the member's URN", posted["author"])
+	}
+	commentary := posted["specificContent"].(map[string]interface{})["com.linkedin.ugc.ShareContent"].(map[string]interface{})["shareCommentary"]
+	if commentary.(map[string]interface{})["text"] != "Hello" {
+		t.Errorf("post text = %v", commentary)
+	}
+	if posted["lifecycleState"] != "PUBLISHED" {
+		t.Errorf("lifecycleState = %v, want PUBLISHED", posted["lifecycleState"])
+	}
+	if v := posted["visibility"].(map[string]interface{})["com.linkedin.ugc.MemberNetworkVisibility"]; v != "PUBLIC" {
+		t.Errorf("visibility = %v, want PUBLIC by default", v)
+	}
+}
+
+func TestLinkedIn_SharePost_Visibility(t *testing.T) {
+	var posted map[string]interface{}
+	p := &LinkedInProvider{APIBaseURL: newFakeLinkedIn(t, &posted).URL}
+	token := &Token{AccessToken: "li-token"}
+
+	if _, err := p.Execute(context.Background(), token, "share_post", map[string]interface{}{"text": "Hi", "visibility": "connections"}); err != nil {
+		t.Fatalf("share_post: %v", err)
+	}
+	if v := posted["visibility"].(map[string]interface{})["com.linkedin.ugc.MemberNetworkVisibility"]; v != "CONNECTIONS" {
+		t.Errorf("visibility = %v, want CONNECTIONS", v)
+	}
+	if _, err := p.Execute(context.Background(), token, "share_post", map[string]interface{}{"text": "Hi", "visibility": "LOGGED_IN"}); err == nil {
+		t.Error("expected an error for an unsupported visibility")
+	}
+}
+
+func TestLinkedIn_SharePost_DuplicateContent(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"abc123"}`))
+	})
+	mux.HandleFunc("/v2/ugcPosts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"serviceErrorCode":0,"message":"Content is a duplicate of urn:li:share:6844785523593134080","status":422}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	p := &LinkedInProvider{APIBaseURL: srv.URL}
+
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "li-token"}, "share_post", map[string]interface{}{"text": "Hello"})
+	if !errors.Is(err, ErrLinkedInDuplicatePost) {
+		t.Fatalf("expected ErrLinkedInDuplicatePost, got %v", err)
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("a duplicate post should still be a validation error, got %v", err)
+	}
+}
+
+func TestLinkedIn_SharePost_NoIDOmitsURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"abc123"}`))
+	})
+	mux.HandleFunc("/v2/ugcPosts", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	p := &LinkedInProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "li-token"}, "share_post", map[string]interface{}{"text": "Hello"})
+	if err != nil {
+		t.Fatalf("share_post: %v", err)
+	}
+	if url, ok := res.(map[string]interface{})["url"]; ok {
+		t.Errorf("url = %v, want it omitted without a post ID", url)
+	}
+}
@@ -0,0 +1,77 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMailchimp_AddSubscriber_PendingDoubleOptIn(t *testing.T) {
+	var sent map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The member ID is the MD5 of the lowercased address.
+		if r.Method != http.MethodPut || r.URL.Path != "/3.0/lists/list1/members/"+mailchimpSubscriberHash("ada@example.com") {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if _, key, _ := r.BasicAuth(); key != "abc123-us6" {
+			t.Errorf("API key not sent as basic auth password: %q", key)
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"id":"h1","email_address":"ada@example.com","status":"pending","list_id":"list1"}`))
+	}))
+	defer srv.Close()
+
+	p := &MailchimpProvider{APIBaseURL: srv.URL}
+	payload := map[string]interface{}{"list_id": "list1", "email": "Ada@Example.com", "status_if_new": "pending"}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "abc123-us6"}, "add_subscriber", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if sent["status_if_new"] != "pending" || sent["status"] != "" {
+		t.Errorf("unexpected request body %v", sent)
+	}
+	if member := res.(*mailchimpMember); member.Status != "pending" {
+		t.Errorf("status = %q, want pending", member.Status)
+	}
+}
+
+func TestMailchimp_AddSubscriber_InvalidStatus(t *testing.T) {
+	p := &MailchimpProvider{}
+	payload := map[string]interface{}{"list_id": "list1", "email": "ada@example.com", "status_if_new": "confirmed"}
+	if _, err := p.Execute(context.Background(), &Token{AccessToken: "abc123-us6"}, "add_subscriber", payload); err == nil {
+		t.Fatal("expected an error for an unknown member status")
+	}
+}
+
+func TestMailchimp_GetSubscriber_ExistingAndMissing(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer oauth-token" {
+			t.Errorf("OAuth token not sent as bearer: %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Path == "/3.0/lists/list1/members/"+mailchimpSubscriberHash("ada@example.com") {
+			w.Write([]byte(`{"id":"h1","email_address":"ada@example.com","status":"subscribed","list_id":"list1"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"title":"Resource Not Found","status":404,"detail":"The requested resource could not be found."}`))
+	}))
+	defer srv.Close()
+
+	p := &MailchimpProvider{APIBaseURL: srv.URL}
+	token := &Token{AccessToken: "oauth-token"}
+	res, err := p.Execute(context.Background(), token, "get_subscriber", map[string]interface{}{"list_id": "list1", "email": "ada@example.com"})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if member := res.(*mailchimpMember); member.Status != "subscribed" || member.ID != "h1" {
+		t.Errorf("unexpected member %+v", member)
+	}
+
+	_, err = p.Execute(context.Background(), token, "get_subscriber", map[string]interface{}{"list_id": "list1", "email": "bob@example.com"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a non-member, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// defaultNotionAPIBaseURL is the Notion API root used when a NotionProvider
+// has no APIBaseURL override.
+const defaultNotionAPIBaseURL = "https://api.notion.com"
+
+// notionVersion is sent as the Notion-Version header, which every Notion API
+// request must carry.
+const notionVersion = "2022-06-28"
+
+// notionHTTPClient is shared by Notion API calls. Requests are bounded by
+// the provider's ActionTimeout rather than a fixed client timeout. Reads are
+// revalidated with ETags when Notion sends them.
+var notionHTTPClient = &http.Client{Transport: newETagTransport(newLoggingTransport(nil))}
+
+// notionError is the body Notion returns for failed requests.
+type notionError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// notionCall sends a JSON request to the Notion API and decodes the reply
+// into out.
+func (p *NotionProvider) notionCall(ctx context.Context, token *Token, method, path string, body, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultNotionAPIBaseURL
+	}
+	return doJSON(ctx, notionHTTPClient, IntegrationNotion, method, base+path, token, body, out, withHeader("Notion-Version", notionVersion))
+}
+
+// appendBlocks appends child blocks to a block or page and returns the IDs
+// of the blocks created.
+func (p *NotionProvider) appendBlocks(ctx context.Context, token *Token, blockID string, blocks []interface{}) (map[string]interface{}, error) {
+	var result struct {
+		Results []struct {
+			ID string `json:"id"`
+		} `json:"results"`
+	}
+	path := "/v1/blocks/" + url.PathEscape(blockID) + "/children"
+	if err := p.notionCall(ctx, token, http.MethodPatch, path, map[string]interface{}{"children": blocks}, &result); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(result.Results))
+	for _, b := range result.Results {
+		ids = append(ids, b.ID)
+	}
+	return map[string]interface{}{"status": "success", "block_id": blockID, "block_ids": ids}, nil
+}
+
+// updatePage updates a page's properties.
+func (p *NotionProvider) updatePage(ctx context.Context, token *Token, pageID string, properties map[string]interface{}) (map[string]interface{}, error) {
+	var result struct {
+		ID             string `json:"id"`
+		LastEditedTime string `json:"last_edited_time"`
+	}
+	path := "/v1/pages/" + url.PathEscape(pageID)
+	if err := p.notionCall(ctx, token, http.MethodPatch, path, map[string]interface{}{"properties": properties}, &result); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "page_id": result.ID, "last_edited_time": result.LastEditedTime}, nil
+}
+
+// queryDatabase returns the pages in a database matching filter, following
+// Notion's start_cursor across pages. If ctx ends part way, the pages fetched
+// so far are returned with the error.
+func (p *NotionProvider) queryDatabase(ctx context.Context, token *Token, databaseID string, filter map[string]interface{}) (map[string]interface{}, error) {
+	path := "/v1/databases/" + url.PathEscape(databaseID) + "/query"
+	pages, err := fetchPages(ctx, func(ctx context.Context, cursor string) ([]map[string]interface{}, string, error) {
+		body := map[string]interface{}{"page_size": 100}
+		if filter != nil {
+			body["filter"] = filter
+		}
+		if cursor != "" {
+			body["start_cursor"] = cursor
+		}
+		var result struct {
+			Results    []map[string]interface{} `json:"results"`
+			HasMore    bool                     `json:"has_more"`
+			NextCursor string                   `json:"next_cursor"`
+		}
+		if err := p.notionCall(ctx, token, http.MethodPost, path, body, &result); err != nil {
+			return nil, "", err
+		}
+		if !result.HasMore {
+			return result.Results, "", nil
+		}
+		return result.Results, result.NextCursor, nil
+	})
+	return map[string]interface{}{"database_id": databaseID, "results": pages}, err
+}
@@ -0,0 +1,148 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNotion_AppendBlocks_ParagraphsToPage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/v1/blocks/page-1/children" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Notion-Version") == "" {
+			t.Error("missing Notion-Version header")
+		}
+		if r.Header.Get("Authorization") != "Bearer secret_test" {
+			t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+		}
+		var body struct {
+			Children []map[string]interface{} `json:"children"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Children) != 2 || body.Children[0]["type"] != "paragraph" {
+			t.Errorf("unexpected children %v", body.Children)
+		}
+		w.Write([]byte(`{"object":"list","results":[{"id":"b1","type":"paragraph"},{"id":"b2","type":"paragraph"}]}`))
+	}))
+	defer srv.Close()
+
+	paragraph := func(text string) interface{} {
+		return map[string]interface{}{
+			"object": "block",
+			"type":   "paragraph",
+			"paragraph": map[string]interface{}{
+				"rich_text": []interface{}{map[string]interface{}{"type": "text", "text": map[string]interface{}{"content": text}}},
+			},
+		}
+	}
+	p := &NotionProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "secret_test"}, "append_blocks", map[string]interface{}{
+		"block_id": "page-1",
+		"blocks":   []interface{}{paragraph("first"), paragraph("second")},
+	})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if ids := res.(map[string]interface{})["block_ids"]; !reflect.DeepEqual(ids, []string{"b1", "b2"}) {
+		t.Errorf("unexpected block_ids %v", ids)
+	}
+}
+
+func TestNotion_AppendBlocks_RequiresIDAndBlocks(t *testing.T) {
+	p := &NotionProvider{APIBaseURL: "http://unused.invalid"}
+	token := &Token{AccessToken: "secret_test"}
+	for _, payload := range []map[string]interface{}{
+		{"blocks": []interface{}{map[string]interface{}{}}},
+		{"block_id": "page-1"},
+		{"block_id": "page-1", "blocks": []interface{}{}},
+	} {
+		if _, err := p.Execute(context.Background(), token, "append_blocks", payload); err == nil {
+			t.Errorf("expected validation error for %v", payload)
+		}
+	}
+}
+
+func TestNotion_UpdatePage_SendsProperties(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/v1/pages/page-1" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := body["properties"]["Status"]; !ok {
+			t.Errorf("expected Status property, got %v", body)
+		}
+		w.Write([]byte(`{"object":"page","id":"page-1","last_edited_time":"2024-01-01T00:00:00.000Z"}`))
+	}))
+	defer srv.Close()
+
+	p := &NotionProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "secret_test"}, "update_page", map[string]interface{}{
+		"page_id":    "page-1",
+		"properties": map[string]interface{}{"Status": map[string]interface{}{"select": map[string]interface{}{"name": "Done"}}},
+	})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if res.(map[string]interface{})["page_id"] != "page-1" {
+		t.Errorf("unexpected result %v", res)
+	}
+}
+
+func TestNotion_QueryDatabase_FollowsCursor(t *testing.T) {
+	var cursors []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/databases/db-1/query" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		cursor, _ := body["start_cursor"].(string)
+		cursors = append(cursors, cursor)
+		if cursor == "" {
+			w.Write([]byte(`{"results":[{"id":"p1"}],"has_more":true,"next_cursor":"c2"}`))
+			return
+		}
+		w.Write([]byte(`{"results":[{"id":"p2"}],"has_more":false,"next_cursor":null}`))
+	}))
+	defer srv.Close()
+
+	p := &NotionProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "secret_test"}, "query_database", map[string]interface{}{"database_id": "db-1"})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	pages := res.(map[string]interface{})["results"].([]map[string]interface{})
+	if len(pages) != 2 || pages[1]["id"] != "p2" {
+		t.Errorf("unexpected pages %v", pages)
+	}
+	if !reflect.DeepEqual(cursors, []string{"", "c2"}) {
+		t.Errorf("unexpected cursors %v", cursors)
+	}
+}
+
+func TestNotion_QueryDatabase_CancelledContext_NoRequests(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"results":[{"id":"p"}],"has_more":true,"next_cursor":"next"}`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p := &NotionProvider{APIBaseURL: srv.URL}
+	_, err := p.Execute(ctx, &Token{AccessToken: "secret_test"}, "query_database", map[string]interface{}{"database_id": "db-1"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if requests != 0 {
+		t.Errorf("expected no requests after cancellation, got %d", requests)
+	}
+}
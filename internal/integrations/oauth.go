@@ -0,0 +1,83 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultGoogleTokenURL is Google's OAuth token endpoint, used when a
+// GmailProvider has no TokenURL override.
+const defaultGoogleTokenURL = "https://oauth2.googleapis.com/token"
+
+// oauthHTTPClient is shared by OAuth token exchanges.
+var oauthHTTPClient = &http.Client{Timeout: 15 * time.Second, Transport: newLoggingTransport(nil)}
+
+// oauthTokenResponse is the RFC 6749 token response, including its error form.
+type oauthTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	InstanceURL      string `json:"instance_url"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// exchangeAuthCode redeems an authorization code at tokenURL using the
+// authorization_code grant. A redirect URL set on ctx with WithRedirectURL
+// replaces redirectURL.
+func exchangeAuthCode(ctx context.Context, provider, tokenURL, clientID, clientSecret, redirectURL, code string) (*Token, error) {
+	form := url.Values{
+		"code":          {code},
+		"client_id":     {clientID},
+		"client_secret": {clientSecret},
+		"redirect_uri":  {redirectURLFor(ctx, redirectURL)},
+		"grant_type":    {"authorization_code"},
+	}
+	ctx, cancel := withActionTimeout(ctx, provider)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%s token request: %w", provider, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s token exchange: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	var reply oauthTokenResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&reply)
+	if reply.Error != "" {
+		return nil, fmt.Errorf("%s token exchange: %s: %s", provider, reply.Error, reply.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s token endpoint returned %d", provider, resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("decode %s token response: %w", provider, decodeErr)
+	}
+	if reply.AccessToken == "" {
+		return nil, fmt.Errorf("%s token response has no access_token", provider)
+	}
+
+	token := &Token{
+		AccessToken:  reply.AccessToken,
+		RefreshToken: reply.RefreshToken,
+		TokenType:    reply.TokenType,
+		InstanceURL:  reply.InstanceURL,
+	}
+	if reply.ExpiresIn > 0 {
+		token.ExpiresAt = time.Now().Add(time.Duration(reply.ExpiresIn) * time.Second)
+		token.Expiry = token.ExpiresAt.Unix()
+	}
+	return token, nil
+}
@@ -0,0 +1,110 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOneDrive_UploadFile_SmallFileUsesSinglePut(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.EscapedPath() != "/v1.0/me/drive/root:/Reports/q3%20summary.txt:/content" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+		}
+		if r.Header.Get("Authorization") != "Bearer od-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		if body, _ := io.ReadAll(r.Body); string(body) != "revenue up" {
+			t.Errorf("body = %q", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":"01ABC","name":"q3 summary.txt","size":10,"webUrl":"https://onedrive.live.com/?id=01ABC"}`))
+	}))
+	defer srv.Close()
+
+	p := &OneDriveProvider{APIBaseURL: srv.URL}
+	payload := map[string]interface{}{"file_name": "q3 summary.txt", "folder": "/Reports/", "content": "revenue up"}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "od-token"}, "upload_file", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	item := res.(map[string]interface{})
+	if item["item_id"] != "01ABC" || item["web_url"] != "https://onedrive.live.com/?id=01ABC" {
+		t.Errorf("unexpected item %v", item)
+	}
+}
+
+func TestOneDrive_UploadFile_LargeFileUsesUploadSession(t *testing.T) {
+	defer func(n int) { oneDriveChunkSize = n }(oneDriveChunkSize)
+	oneDriveChunkSize = 2 << 20
+
+	content := bytes.Repeat([]byte("x"), oneDriveSimpleUploadLimit+1<<20)
+	var ranges []string
+	var received bytes.Buffer
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1.0/drives/b!site/root:/big.bin:/createUploadSession":
+			if r.Header.Get("Authorization") != "Bearer od-token" {
+				t.Errorf("session Authorization = %q", r.Header.Get("Authorization"))
+			}
+			fmt.Fprintf(w, `{"uploadUrl":%q}`, srv.URL+"/upload/session-1")
+		case r.Method == http.MethodPut && r.URL.Path == "/upload/session-1":
+			if r.Header.Get("Authorization") != "" {
+				t.Errorf("chunk sent Authorization %q", r.Header.Get("Authorization"))
+			}
+			ranges = append(ranges, r.Header.Get("Content-Range"))
+			io.Copy(&received, r.Body)
+			if received.Len() < len(content) {
+				w.WriteHeader(http.StatusAccepted)
+				fmt.Fprintf(w, `{"nextExpectedRanges":["%d-"]}`, received.Len())
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"01BIG","name":"big.bin","size":5242880,"webUrl":"https://contoso.sharepoint.com/big.bin"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	p := &OneDriveProvider{APIBaseURL: srv.URL}
+	payload := map[string]interface{}{"file_name": "big.bin", "drive_id": "b!site", "content": string(content)}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "od-token"}, "upload_file", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	want := []string{"bytes 0-2097151/5242880", "bytes 2097152-4194303/5242880", "bytes 4194304-5242879/5242880"}
+	if fmt.Sprint(ranges) != fmt.Sprint(want) {
+		t.Errorf("Content-Range = %v, want %v", ranges, want)
+	}
+	if !bytes.Equal(received.Bytes(), content) {
+		t.Errorf("received %d bytes, want the %d sent", received.Len(), len(content))
+	}
+	item := res.(map[string]interface{})
+	if item["item_id"] != "01BIG" || item["web_url"] != "https://contoso.sharepoint.com/big.bin" {
+		t.Errorf("unexpected item %v", item)
+	}
+}
+
+func TestOneDrive_UploadFile_RequiresContent(t *testing.T) {
+	p := &OneDriveProvider{}
+	for _, tc := range []struct {
+		payload map[string]interface{}
+		want    error
+	}{
+		{map[string]interface{}{"file_name": "a.txt"}, ErrMissingField},
+		{map[string]interface{}{"file_name": "a.txt", "content": 42.0}, ErrInvalidField},
+		{map[string]interface{}{"file_name": "a.txt", "content_base64": "not base64!"}, ErrInvalidField},
+	} {
+		if _, err := p.Execute(context.Background(), &Token{AccessToken: "t"}, "upload_file", tc.payload); !errors.Is(err, tc.want) {
+			t.Errorf("payload %v: expected %v, got %v", tc.payload, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+)
+
+// ListPage is the pagination envelope every list_* action returns, hiding the
+// provider's own cursors, offsets, page tokens or Link headers. Passing
+// NextPageToken back as the "page_token" payload field fetches the next page.
+type ListPage struct {
+	Items         interface{} `json:"items"`
+	NextPageToken string      `json:"next_page_token,omitempty"`
+	HasMore       bool        `json:"has_more"`
+}
+
+// newListPage builds the envelope for one page of items; an empty next token
+// means there are no more pages.
+func newListPage[T any](items []T, next string) *ListPage {
+	if items == nil {
+		items = []T{}
+	}
+	return &ListPage{Items: items, NextPageToken: next, HasMore: next != ""}
+}
+
+// pageToken reads the optional "page_token" field of a list action payload.
+func pageToken(payload map[string]interface{}) (string, error) {
+	v, ok := payload["page_token"]
+	if !ok || v == nil {
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", invalidField("field 'page_token' must be a string")
+	}
+	return s, nil
+}
+
+// pageSize reads the optional "page_size" field of a list action payload,
+// returning def when it is absent and capping it at max.
+func pageSize(payload map[string]interface{}, def, max int) (int, error) {
+	v, ok := payload["page_size"]
+	if !ok || v == nil {
+		return def, nil
+	}
+	n, ok := v.(float64)
+	if !ok || n < 1 || n != float64(int(n)) {
+		return 0, invalidField("field 'page_size' must be a positive integer")
+	}
+	if int(n) > max {
+		return max, nil
+	}
+	return int(n), nil
+}
+
+// fetchPages calls fetch with successive cursors, starting from "", until it
+// returns an empty next cursor. ctx is checked before every page so a client
+// disconnect or passed deadline stops the loop; the items gathered so far are
+// returned together with the context's error.
+func fetchPages[T any](ctx context.Context, fetch func(ctx context.Context, cursor string) (items []T, next string, err error)) ([]T, error) {
+	all := []T{}
+	cursor := ""
+	for {
+		if err := ctx.Err(); err != nil {
+			return all, fmt.Errorf("pagination stopped after %d items: %w", len(all), err)
+		}
+		items, next, err := fetch(ctx, cursor)
+		all = append(all, items...)
+		if err != nil || next == "" {
+			return all, err
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,47 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestFetchPages_FollowsCursorToEnd(t *testing.T) {
+	items, err := fetchPages(context.Background(), func(_ context.Context, cursor string) ([]int, string, error) {
+		n, _ := strconv.Atoi(cursor)
+		if n == 2 {
+			return []int{n}, "", nil
+		}
+		return []int{n}, strconv.Itoa(n + 1), nil
+	})
+	if err != nil {
+		t.Fatalf("fetchPages error: %v", err)
+	}
+	if len(items) != 3 {
+		t.Errorf("expected 3 items, got %v", items)
+	}
+}
+
+func TestFetchPages_CancelledContext_StopsEarly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	items, err := fetchPages(ctx, func(_ context.Context, cursor string) ([]string, string, error) {
+		calls++
+		if calls == 2 {
+			cancel() // client went away while the second page was in flight
+		}
+		return []string{"page" + strconv.Itoa(calls)}, "more", nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected pagination to stop after 2 requests, made %d", calls)
+	}
+	if len(items) != 2 {
+		t.Errorf("expected the 2 fetched pages back, got %v", items)
+	}
+}
@@ -0,0 +1,47 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// ErrNoRedirectParam is returned by AuthURLWithRedirect when a provider's
+// auth URL has no redirect_uri to override.
+var ErrNoRedirectParam = errors.New("provider auth URL has no redirect_uri")
+
+type redirectURLKey struct{}
+
+// WithRedirectURL returns a context whose code exchanges send redirectURL as
+// redirect_uri instead of the provider's configured RedirectURL. It must be
+// the URL the authorization request used, or the provider rejects the code.
+// Callers are responsible for checking redirectURL against an allowlist.
+func WithRedirectURL(ctx context.Context, redirectURL string) context.Context {
+	return context.WithValue(ctx, redirectURLKey{}, redirectURL)
+}
+
+// redirectURLFor returns the redirect URL set by WithRedirectURL, or
+// configured when there is none.
+func redirectURLFor(ctx context.Context, configured string) string {
+	if u, ok := ctx.Value(redirectURLKey{}).(string); ok && u != "" {
+		return u
+	}
+	return configured
+}
+
+// AuthURLWithRedirect returns p's auth URL for state with its redirect_uri
+// replaced by redirectURL, for deployments served on several domains that
+// each need the callback on their own domain.
+func AuthURLWithRedirect(p Provider, state, redirectURL string) (string, error) {
+	u, err := url.Parse(p.GetAuthURL(state))
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	if !q.Has("redirect_uri") {
+		return "", ErrNoRedirectParam
+	}
+	q.Set("redirect_uri", redirectURL)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,47 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestExchangeCode_UsesRedirectOverride(t *testing.T) {
+	var redirects []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		redirects = append(redirects, r.PostForm.Get("redirect_uri"))
+		w.Write([]byte(`{"access_token":"gho_new","token_type":"bearer"}`))
+	}))
+	defer srv.Close()
+	p := &GitHubProvider{ClientID: "cid", ClientSecret: "secret", RedirectURL: "https://app.example.com/cb", TokenURL: srv.URL}
+
+	if _, err := p.ExchangeCode(context.Background(), "code"); err != nil {
+		t.Fatalf("ExchangeCode: %v", err)
+	}
+	if _, err := p.ExchangeCode(WithRedirectURL(context.Background(), "https://eu.example.com/cb"), "code"); err != nil {
+		t.Fatalf("ExchangeCode with override: %v", err)
+	}
+	if len(redirects) != 2 || redirects[0] != "https://app.example.com/cb" || redirects[1] != "https://eu.example.com/cb" {
+		t.Errorf("redirect_uri sent = %v, want the configured URL then the override", redirects)
+	}
+}
+
+func TestAuthURLWithRedirect(t *testing.T) {
+	p := &GitHubProvider{ClientID: "cid", RedirectURL: "https://app.example.com/cb"}
+	got, err := AuthURLWithRedirect(p, "st", "https://eu.example.com/cb")
+	if err != nil {
+		t.Fatalf("AuthURLWithRedirect: %v", err)
+	}
+	u, _ := url.Parse(got)
+	if q := u.Query(); q.Get("redirect_uri") != "https://eu.example.com/cb" || q.Get("state") != "st" || q.Get("scope") != "repo user" {
+		t.Errorf("auth URL = %s", got)
+	}
+
+	if _, err := AuthURLWithRedirect(&WebhookForwardProvider{}, "st", "https://eu.example.com/cb"); !errors.Is(err, ErrNoRedirectParam) {
+		t.Errorf("expected ErrNoRedirectParam for a provider without redirect_uri, got %v", err)
+	}
+}
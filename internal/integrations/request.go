@@ -0,0 +1,84 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// requestOption adjusts an outgoing provider request, for headers or
+// authorization schemes beyond a bearer token.
+type requestOption func(*http.Request)
+
+// withHeader sets a request header, replacing any default.
+func withHeader(key, value string) requestOption {
+	return func(r *http.Request) { r.Header.Set(key, value) }
+}
+
+// doJSON sends a request to a provider's JSON API with body, when non-nil,
+// JSON-encoded, and decodes the reply into out, when non-nil. See doRequest.
+func doJSON(ctx context.Context, client *http.Client, provider IntegrationType, method, endpoint string, token *Token, body, out interface{}, opts ...requestOption) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode %s request: %w", provider, err)
+		}
+		reader = bytes.NewReader(data)
+		opts = append([]requestOption{withHeader("Content-Type", "application/json")}, opts...)
+	}
+	return doRequest(ctx, client, provider, method, endpoint, token, reader, out, opts...)
+}
+
+// doForm is doJSON for APIs, such as Stripe and Twilio, that take
+// form-encoded request bodies.
+func doForm(ctx context.Context, client *http.Client, provider IntegrationType, method, endpoint string, token *Token, form url.Values, out interface{}, opts ...requestOption) error {
+	var reader io.Reader
+	if form != nil {
+		reader = strings.NewReader(form.Encode())
+		opts = append([]requestOption{withHeader("Content-Type", "application/x-www-form-urlencoded")}, opts...)
+	}
+	return doRequest(ctx, client, provider, method, endpoint, token, reader, out, opts...)
+}
+
+// doRequest sends one provider API call bounded by the provider's action
+// timeout. The token is sent as a bearer token unless an option sets
+// Authorization. A failed response is returned as its typed error from
+// checkResponse; a successful one is decoded into out, when non-nil.
+func doRequest(ctx context.Context, client *http.Client, provider IntegrationType, method, endpoint string, token *Token, body io.Reader, out interface{}, opts ...requestOption) error {
+	ctx, cancel := withActionTimeout(ctx, string(provider))
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if token != nil {
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	}
+	req.Header.Set("Accept", "application/json")
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s %s: %w", provider, method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(provider, resp); err != nil {
+		return fmt.Errorf("%s %s: %w", provider, req.URL.Path, err)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", provider, err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDoJSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]string{
+			"auth":         r.Header.Get("Authorization"),
+			"content_type": r.Header.Get("Content-Type"),
+			"version":      r.Header.Get("X-Version"),
+			"name":         body["name"],
+		})
+	}))
+	defer srv.Close()
+	ctx, token := context.Background(), &Token{AccessToken: "tok"}
+
+	var got map[string]string
+	if err := doJSON(ctx, srv.Client(), "test", http.MethodPost, srv.URL+"/things", token, map[string]string{"name": "a"}, &got, withHeader("X-Version", "2")); err != nil {
+		t.Fatalf("doJSON error: %v", err)
+	}
+	want := map[string]string{"auth": "Bearer tok", "content_type": "application/json", "version": "2", "name": "a"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+
+	if err := doJSON(ctx, srv.Client(), "test", http.MethodGet, srv.URL+"/things", token, nil, &got, withHeader("Authorization", "pk_1")); err != nil || got["auth"] != "pk_1" || got["content_type"] != "" {
+		t.Errorf("GET with an Authorization override = %v, %v", got, err)
+	}
+	if err := doJSON(ctx, srv.Client(), "test", http.MethodGet, srv.URL+"/missing", token, nil, nil); !errors.Is(err, ErrNotFound) {
+		t.Errorf("error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,121 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultGoogleRevokeURL is Google's OAuth revocation endpoint, used when a
+// Google provider has no RevokeURL override.
+const defaultGoogleRevokeURL = "https://oauth2.googleapis.com/revoke"
+
+// TokenRevoker is implemented by providers that can invalidate a token at the
+// provider, so disconnecting an integration also withdraws the grant.
+// Revoking a token that is already invalid succeeds.
+type TokenRevoker interface {
+	RevokeToken(ctx context.Context, token *Token) error
+}
+
+// revokeGoogleToken revokes token at Google's revocation endpoint. Revoking
+// the refresh token also invalidates its access tokens, so it is preferred.
+// Google answers an already revoked or expired token with invalid_token.
+func revokeGoogleToken(ctx context.Context, provider, endpoint string, token *Token) error {
+	if endpoint == "" {
+		endpoint = defaultGoogleRevokeURL
+	}
+	value := token.RefreshToken
+	if value == "" {
+		value = token.AccessToken
+	}
+	form := url.Values{"token": {value}}
+
+	ctx, cancel := withActionTimeout(ctx, provider)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s revoke token: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		var reply oauthTokenResponse
+		if json.NewDecoder(resp.Body).Decode(&reply) == nil && reply.Error == "invalid_token" {
+			return nil
+		}
+		return fmt.Errorf("%s revoke token: %w", provider, MapUpstreamError(IntegrationType(provider), resp.StatusCode, nil))
+	}
+	if err := checkResponse(IntegrationType(provider), resp); err != nil {
+		return fmt.Errorf("%s revoke token: %w", provider, err)
+	}
+	return nil
+}
+
+// RevokeToken revokes the Gmail grant at Google.
+func (p *GmailProvider) RevokeToken(ctx context.Context, token *Token) error {
+	return revokeGoogleToken(ctx, p.Name(), p.RevokeURL, token)
+}
+
+// RevokeToken revokes the Google Drive grant at Google.
+func (p *GoogleDriveProvider) RevokeToken(ctx context.Context, token *Token) error {
+	return revokeGoogleToken(ctx, p.Name(), p.RevokeURL, token)
+}
+
+// RevokeToken deletes the OAuth token via DELETE
+// /applications/{client_id}/token, authenticated as the OAuth app. GitHub
+// answers 404 for a token that no longer exists.
+func (p *GitHubProvider) RevokeToken(ctx context.Context, token *Token) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultGitHubAPIBaseURL
+	}
+	body, err := json.Marshal(map[string]string{"access_token": token.AccessToken})
+	if err != nil {
+		return fmt.Errorf("encode github revoke request: %w", err)
+	}
+
+	ctx, cancel := withActionTimeout(ctx, p.Name())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, base+"/applications/"+url.PathEscape(p.ClientID)+"/token", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(p.ClientID, p.ClientSecret)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := githubHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("github revoke token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err := checkResponse(IntegrationGitHub, resp); err != nil {
+		return fmt.Errorf("github revoke token: %w", err)
+	}
+	return nil
+}
+
+// RevokeToken revokes the token with auth.revoke. Slack rejects a token that
+// is already revoked as invalid_auth or token_revoked.
+func (p *SlackProvider) RevokeToken(ctx context.Context, token *Token) error {
+	err := p.slackCall(ctx, token, "auth.revoke", nil, nil, nil)
+	if errors.Is(err, ErrInvalidCredentials) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGoogleRevokeToken_PrefersRefreshToken(t *testing.T) {
+	var revoked string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		revoked = r.PostForm.Get("token")
+		if revoked == "gone" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_token","error_description":"Token expired or revoked"}`))
+		}
+	}))
+	defer srv.Close()
+
+	p := &GmailProvider{RevokeURL: srv.URL}
+	if err := p.RevokeToken(context.Background(), &Token{AccessToken: "ya29.access", RefreshToken: "1//refresh"}); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+	if revoked != "1//refresh" {
+		t.Errorf("revoked %q, want the refresh token", revoked)
+	}
+	if err := (&GoogleDriveProvider{RevokeURL: srv.URL}).RevokeToken(context.Background(), &Token{AccessToken: "gone"}); err != nil {
+		t.Errorf("an already revoked token should succeed, got %v", err)
+	}
+}
+
+func TestGitHubRevokeToken(t *testing.T) {
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.Method != http.MethodDelete || r.URL.Path != "/applications/client-1/token" || user != "client-1" || pass != "secret" || body["access_token"] != "gho_x" {
+			t.Errorf("unexpected request %s %s user=%q body=%v", r.Method, r.URL.Path, user, body)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	p := &GitHubProvider{ClientID: "client-1", ClientSecret: "secret", APIBaseURL: srv.URL}
+	if err := p.RevokeToken(context.Background(), &Token{AccessToken: "gho_x"}); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+	status = http.StatusNotFound
+	if err := p.RevokeToken(context.Background(), &Token{AccessToken: "gho_x"}); err != nil {
+		t.Errorf("a token GitHub no longer has should succeed, got %v", err)
+	}
+	status = http.StatusUnprocessableEntity
+	if err := p.RevokeToken(context.Background(), &Token{AccessToken: "gho_x"}); err == nil {
+		t.Error("expected an error for a 422")
+	}
+}
+
+func TestSlackRevokeToken(t *testing.T) {
+	reply := `{"ok":true,"revoked":true}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/auth.revoke" || r.Header.Get("Authorization") != "Bearer xoxb" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		w.Write([]byte(reply))
+	}))
+	defer srv.Close()
+
+	p := &SlackProvider{APIBaseURL: srv.URL}
+	if err := p.RevokeToken(context.Background(), &Token{AccessToken: "xoxb"}); err != nil {
+		t.Fatalf("RevokeToken: %v", err)
+	}
+	reply = `{"ok":false,"error":"token_revoked"}`
+	if err := p.RevokeToken(context.Background(), &Token{AccessToken: "xoxb"}); err != nil {
+		t.Errorf("an already revoked token should succeed, got %v", err)
+	}
+}
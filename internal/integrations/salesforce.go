@@ -0,0 +1,142 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// salesforceAPIVersion is the REST API version used for Salesforce calls.
+const salesforceAPIVersion = "v59.0"
+
+// salesforceCollectionLimit is the most records the sObject Collections API
+// accepts in one call; larger bulk creates are split into several calls.
+const salesforceCollectionLimit = 200
+
+// salesforceMaxBulkRecords bounds a single create_records_bulk action.
+const salesforceMaxBulkRecords = 2000
+
+// salesforceHTTPClient is shared by Salesforce API calls. Requests are
+// bounded by the provider's ActionTimeout.
+var salesforceHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// salesforceRecordError is one reason Salesforce rejected a record.
+type salesforceRecordError struct {
+	StatusCode string   `json:"statusCode"`
+	Message    string   `json:"message"`
+	Fields     []string `json:"fields,omitempty"`
+}
+
+// salesforceSaveResult is the outcome of creating one record. Index is the
+// record's position in the request payload.
+type salesforceSaveResult struct {
+	Index   int                     `json:"index"`
+	ID      string                  `json:"id,omitempty"`
+	Success bool                    `json:"success"`
+	Errors  []salesforceRecordError `json:"errors,omitempty"`
+}
+
+// salesforceBulkResult is the reply to create_records_bulk.
+type salesforceBulkResult struct {
+	Results   []salesforceSaveResult `json:"results"`
+	Succeeded int                    `json:"succeeded"`
+	Failed    int                    `json:"failed"`
+}
+
+// salesforceBase returns the API root for token: the APIBaseURL override, or
+// the org instance the token was issued for. The instance must be an https
+// Salesforce host, since the access token is sent to it.
+func (p *SalesforceProvider) salesforceBase(token *Token) (string, error) {
+	if p.APIBaseURL != "" {
+		return p.APIBaseURL, nil
+	}
+	if token.InstanceURL == "" {
+		return "", errors.New("salesforce token has no instance_url")
+	}
+	u, err := url.Parse(token.InstanceURL)
+	if err != nil || u.Scheme != "https" || !salesforceHost(u.Hostname()) {
+		return "", fmt.Errorf("salesforce instance_url %q is not an https Salesforce host", token.InstanceURL)
+	}
+	return strings.TrimSuffix(token.InstanceURL, "/"), nil
+}
+
+// salesforceHost reports whether host is a Salesforce org domain.
+func salesforceHost(host string) bool {
+	host = strings.ToLower(host)
+	return strings.HasSuffix(host, ".salesforce.com") || strings.HasSuffix(host, ".force.com")
+}
+
+// createRecordsBulk creates records of sObject type object through the sObject
+// Collections API, salesforceCollectionLimit records per call. Unless
+// allOrNone is set, valid records are saved even when others are rejected;
+// allOrNone is only accepted for a single call's worth of records, since
+// Salesforce cannot roll back earlier calls.
+//
+// If a call fails after earlier ones saved records, the saved records are
+// still reported and every record from the failed call on is reported as
+// failed with the call's error.
+func (p *SalesforceProvider) createRecordsBulk(ctx context.Context, token *Token, object string, records []map[string]interface{}, allOrNone bool) (*salesforceBulkResult, error) {
+	if allOrNone && len(records) > salesforceCollectionLimit {
+		return nil, invalidField("field 'all_or_none' allows at most %d records, got %d", salesforceCollectionLimit, len(records))
+	}
+	base, err := p.salesforceBase(token)
+	if err != nil {
+		return nil, err
+	}
+	result := &salesforceBulkResult{Results: make([]salesforceSaveResult, 0, len(records))}
+	for start := 0; start < len(records); start += salesforceCollectionLimit {
+		end := start + salesforceCollectionLimit
+		if end > len(records) {
+			end = len(records)
+		}
+		batch := make([]map[string]interface{}, 0, end-start)
+		for _, record := range records[start:end] {
+			withType := map[string]interface{}{"attributes": map[string]string{"type": object}}
+			for k, v := range record {
+				withType[k] = v
+			}
+			batch = append(batch, withType)
+		}
+		saved, err := p.createCollection(ctx, token, base, batch, allOrNone)
+		if err != nil {
+			err = fmt.Errorf("salesforce bulk create records %d-%d: %w", start, end-1, err)
+			if start == 0 {
+				return nil, err
+			}
+			for i := start; i < len(records); i++ {
+				result.Failed++
+				result.Results = append(result.Results, salesforceSaveResult{
+					Index:  i,
+					Errors: []salesforceRecordError{{StatusCode: "REQUEST_FAILED", Message: err.Error()}},
+				})
+			}
+			return result, nil
+		}
+		for i, r := range saved {
+			r.Index = start + i
+			if r.Success {
+				result.Succeeded++
+			} else {
+				result.Failed++
+			}
+			result.Results = append(result.Results, r)
+		}
+	}
+	return result, nil
+}
+
+// createCollection sends one sObject Collections create call.
+func (p *SalesforceProvider) createCollection(ctx context.Context, token *Token, base string, records []map[string]interface{}, allOrNone bool) ([]salesforceSaveResult, error) {
+	body := map[string]interface{}{"allOrNone": allOrNone, "records": records}
+	var saved []salesforceSaveResult
+	if err := doJSON(ctx, salesforceHTTPClient, IntegrationSalesforce, http.MethodPost, base+"/services/data/"+salesforceAPIVersion+"/composite/sobjects", token, body, &saved); err != nil {
+		return nil, err
+	}
+	if len(saved) != len(records) {
+		return nil, fmt.Errorf("salesforce returned %d results for %d records", len(saved), len(records))
+	}
+	return saved, nil
+}
@@ -0,0 +1,169 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSalesforce_CreateRecordsBulk_MixedResults(t *testing.T) {
+	var sent struct {
+		AllOrNone bool                     `json:"allOrNone"`
+		Records   []map[string]interface{} `json:"records"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/services/data/"+salesforceAPIVersion+"/composite/sobjects" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer sf-token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`[
+			{"id":"00Q1","success":true,"errors":[]},
+			{"success":false,"errors":[{"statusCode":"REQUIRED_FIELD_MISSING","message":"Required fields are missing: [LastName]","fields":["LastName"]}]}
+		]`))
+	}))
+	defer srv.Close()
+
+	payload := map[string]interface{}{
+		"object": "Lead",
+		"records": []interface{}{
+			map[string]interface{}{"LastName": "Lovelace", "Company": "Analytical"},
+			map[string]interface{}{"Company": "Nameless"},
+		},
+	}
+	res, err := (&SalesforceProvider{APIBaseURL: srv.URL}).Execute(context.Background(), &Token{AccessToken: "sf-token"}, "create_records_bulk", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if sent.AllOrNone || len(sent.Records) != 2 {
+		t.Fatalf("unexpected request %+v", sent)
+	}
+	if attrs, _ := sent.Records[0]["attributes"].(map[string]interface{}); attrs["type"] != "Lead" {
+		t.Errorf("record not typed as Lead: %v", sent.Records[0])
+	}
+	result := res.(*salesforceBulkResult)
+	if result.Succeeded != 1 || result.Failed != 1 {
+		t.Errorf("succeeded/failed = %d/%d, want 1/1", result.Succeeded, result.Failed)
+	}
+	if r := result.Results[0]; !r.Success || r.ID != "00Q1" {
+		t.Errorf("first result = %+v", r)
+	}
+	if r := result.Results[1]; r.Success || r.Index != 1 || r.Errors[0].StatusCode != "REQUIRED_FIELD_MISSING" {
+		t.Errorf("second result = %+v", r)
+	}
+}
+
+func TestSalesforce_CreateRecordsBulk_SplitsAtCollectionLimit(t *testing.T) {
+	var batches []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var sent struct {
+			Records []json.RawMessage `json:"records"`
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		batches = append(batches, len(sent.Records))
+		results := make([]string, len(sent.Records))
+		for i := range results {
+			results[i] = fmt.Sprintf(`{"id":"00Q%d","success":true}`, i)
+		}
+		w.Write([]byte("[" + strings.Join(results, ",") + "]"))
+	}))
+	defer srv.Close()
+
+	records := make([]interface{}, 450)
+	for i := range records {
+		records[i] = map[string]interface{}{"LastName": fmt.Sprint("L", i)}
+	}
+	p := &SalesforceProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "sf-token"}, "create_records_bulk", map[string]interface{}{"object": "Lead", "records": records})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if fmt.Sprint(batches) != "[200 200 50]" {
+		t.Errorf("batches = %v, want [200 200 50]", batches)
+	}
+	result := res.(*salesforceBulkResult)
+	if result.Succeeded != 450 || result.Results[449].Index != 449 {
+		t.Errorf("unexpected result: %d succeeded, last index %d", result.Succeeded, result.Results[449].Index)
+	}
+}
+
+func TestSalesforce_CreateRecordsBulk_InvalidPayload(t *testing.T) {
+	p := &SalesforceProvider{APIBaseURL: "http://unused"}
+	for _, payload := range []map[string]interface{}{
+		{"object": "Lead"},
+		{"object": "Lead", "records": []interface{}{}},
+		{"object": "Lead", "records": []interface{}{"not an object"}},
+		{"object": "Lead", "records": make([]interface{}, salesforceMaxBulkRecords+1)},
+	} {
+		if _, err := p.Execute(context.Background(), &Token{AccessToken: "sf-token"}, "create_records_bulk", payload); err == nil {
+			t.Errorf("expected an error for payload with %T records", payload["records"])
+		}
+	}
+}
+
+func TestSalesforce_CreateRecordsBulk_PartialOnLaterFailure(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		results := make([]string, salesforceCollectionLimit)
+		for i := range results {
+			results[i] = fmt.Sprintf(`{"id":"00Q%d","success":true}`, i)
+		}
+		w.Write([]byte("[" + strings.Join(results, ",") + "]"))
+	}))
+	defer srv.Close()
+
+	records := make([]interface{}, 250)
+	for i := range records {
+		records[i] = map[string]interface{}{"LastName": fmt.Sprint("L", i)}
+	}
+	p := &SalesforceProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "sf-token"}, "create_records_bulk", map[string]interface{}{"object": "Lead", "records": records})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	result := res.(*salesforceBulkResult)
+	if result.Succeeded != 200 || result.Failed != 50 || len(result.Results) != 250 {
+		t.Fatalf("succeeded/failed = %d/%d over %d results, want 200/50 over 250", result.Succeeded, result.Failed, len(result.Results))
+	}
+	if r := result.Results[200]; r.Success || r.Index != 200 || r.Errors[0].StatusCode != "REQUEST_FAILED" {
+		t.Errorf("first unsaved result = %+v", r)
+	}
+}
+
+func TestSalesforce_AllOrNoneLimitedToOneCall(t *testing.T) {
+	records := make([]interface{}, salesforceCollectionLimit+1)
+	for i := range records {
+		records[i] = map[string]interface{}{"LastName": "L"}
+	}
+	p := &SalesforceProvider{APIBaseURL: "http://unused"}
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "sf-token"}, "create_records_bulk", map[string]interface{}{"object": "Lead", "records": records, "all_or_none": true})
+	if !errors.Is(err, ErrInvalidField) {
+		t.Errorf("expected ErrInvalidField, got %v", err)
+	}
+}
+
+func TestSalesforce_InstanceURLMustBeSalesforce(t *testing.T) {
+	p := &SalesforceProvider{}
+	for _, instance := range []string{"http://acme.my.salesforce.com", "https://evil.example.com", "https://salesforce.com.evil.example", "https://acme.my.salesforce.com.evil.example"} {
+		if _, err := p.salesforceBase(&Token{InstanceURL: instance}); err == nil {
+			t.Errorf("instance_url %q accepted", instance)
+		}
+	}
+	for _, instance := range []string{"https://acme.my.salesforce.com/", "https://acme--dev.sandbox.my.salesforce.com", "https://acme.lightning.force.com"} {
+		if _, err := p.salesforceBase(&Token{InstanceURL: instance}); err != nil {
+			t.Errorf("instance_url %q rejected: %v", instance, err)
+		}
+	}
+}
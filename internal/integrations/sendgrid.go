@@ -0,0 +1,111 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultSendGridAPIBaseURL is the SendGrid API root used when a
+// SendGridProvider has no APIBaseURL override.
+const defaultSendGridAPIBaseURL = "https://api.sendgrid.com"
+
+// sendgridHTTPClient is shared by SendGrid API calls. Requests are bounded by
+// the provider's ActionTimeout.
+var sendgridHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// sendgridStatsAggregations are the aggregated_by values get_stats accepts.
+var sendgridStatsAggregations = map[string]bool{"day": true, "week": true, "month": true}
+
+// sendgridDate reads an optional YYYY-MM-DD date from payload[key].
+func sendgridDate(payload map[string]interface{}, key string) (string, error) {
+	if _, ok := payload[key]; !ok {
+		return "", nil
+	}
+	d, err := getString(payload, key)
+	if err != nil {
+		return "", err
+	}
+	if _, err := time.Parse(time.DateOnly, d); err != nil {
+		return "", invalidField("field '%s' must be a YYYY-MM-DD date, got %q", key, d)
+	}
+	return d, nil
+}
+
+// addContact upserts a marketing contact, optionally adding it to lists.
+// SendGrid imports contacts asynchronously, so the result carries the import
+// job_id rather than the contact ID.
+func (p *SendGridProvider) addContact(ctx context.Context, token *Token, email, firstName, lastName string, listIDs []string) (map[string]interface{}, error) {
+	contact := map[string]string{"email": email}
+	if firstName != "" {
+		contact["first_name"] = firstName
+	}
+	if lastName != "" {
+		contact["last_name"] = lastName
+	}
+	body := map[string]interface{}{"contacts": []map[string]string{contact}}
+	if len(listIDs) > 0 {
+		body["list_ids"] = listIDs
+	}
+	var job struct {
+		JobID string `json:"job_id"`
+	}
+	if err := p.sendgridCall(ctx, token, http.MethodPut, "/v3/marketing/contacts", body, &job); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "job_id": job.JobID, "email": email}, nil
+}
+
+// createList creates a marketing contact list and returns its ID.
+func (p *SendGridProvider) createList(ctx context.Context, token *Token, name string) (map[string]interface{}, error) {
+	var list struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := p.sendgridCall(ctx, token, http.MethodPost, "/v3/marketing/lists", map[string]string{"name": name}, &list); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "list_id": list.ID, "name": list.Name}, nil
+}
+
+// getStats returns global email statistics from startDate, one entry per
+// day, week or month.
+func (p *SendGridProvider) getStats(ctx context.Context, token *Token, startDate, endDate, aggregatedBy string) (map[string]interface{}, error) {
+	q := url.Values{"start_date": {startDate}}
+	if endDate != "" {
+		q.Set("end_date", endDate)
+	}
+	if aggregatedBy != "" {
+		q.Set("aggregated_by", aggregatedBy)
+	}
+	var days []struct {
+		Date  string `json:"date"`
+		Stats []struct {
+			Metrics map[string]int `json:"metrics"`
+		} `json:"stats"`
+	}
+	if err := p.sendgridCall(ctx, token, http.MethodGet, "/v3/stats?"+q.Encode(), nil, &days); err != nil {
+		return nil, err
+	}
+	stats := make([]map[string]interface{}, 0, len(days))
+	for _, d := range days {
+		// Global stats have a single, unnamed entry per date.
+		metrics := map[string]int{}
+		if len(d.Stats) > 0 {
+			metrics = d.Stats[0].Metrics
+		}
+		stats = append(stats, map[string]interface{}{"date": d.Date, "metrics": metrics})
+	}
+	return map[string]interface{}{"status": "success", "stats": stats}, nil
+}
+
+// sendgridCall sends body, if any, as JSON to a SendGrid API path and decodes
+// the reply into out.
+func (p *SendGridProvider) sendgridCall(ctx context.Context, token *Token, method, path string, body, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultSendGridAPIBaseURL
+	}
+	return doJSON(ctx, sendgridHTTPClient, IntegrationSendGrid, method, base+path, token, body, out)
+}
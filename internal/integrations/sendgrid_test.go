@@ -0,0 +1,111 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSendGrid_AddContactReturnsJobID(t *testing.T) {
+	var body map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/v3/marketing/contacts" || r.Header.Get("Authorization") != "Bearer SG.key" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"job_id":"2387e363-4104-4225-8960-4a5758492351"}`))
+	}))
+	defer srv.Close()
+	p := &SendGridProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "SG.key"}, "add_contact", map[string]interface{}{
+		"email": "ada@example.com", "first_name": "Ada", "list_ids": []interface{}{"list-1"},
+	})
+	if err != nil {
+		t.Fatalf("add_contact: %v", err)
+	}
+	want := map[string]interface{}{"status": "success", "job_id": "2387e363-4104-4225-8960-4a5758492351", "email": "ada@example.com"}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("result = %v, want %v", res, want)
+	}
+	wantBody := map[string]interface{}{
+		"contacts": []interface{}{map[string]interface{}{"email": "ada@example.com", "first_name": "Ada"}},
+		"list_ids": []interface{}{"list-1"},
+	}
+	if !reflect.DeepEqual(body, wantBody) {
+		t.Errorf("body = %v, want %v", body, wantBody)
+	}
+
+	for _, payload := range []map[string]interface{}{
+		{},
+		{"email": "not-an-email"},
+		{"email": "ada@example.com", "list_ids": "list-1"},
+	} {
+		if _, err := p.Execute(context.Background(), &Token{AccessToken: "SG.key"}, "add_contact", payload); err == nil {
+			t.Errorf("expected an error for payload %v", payload)
+		}
+	}
+}
+
+func TestSendGrid_GetStats(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/v3/stats" || q.Get("start_date") != "2026-01-01" || q.Get("aggregated_by") != "week" || q.Has("end_date") {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`[
+			{"date":"2026-01-01","stats":[{"metrics":{"requests":120,"delivered":118,"opens":64,"bounces":2}}]},
+			{"date":"2026-01-08","stats":[]}
+		]`))
+	}))
+	defer srv.Close()
+	p := &SendGridProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "SG.key"}, "get_stats", map[string]interface{}{
+		"start_date": "2026-01-01", "aggregated_by": "week",
+	})
+	if err != nil {
+		t.Fatalf("get_stats: %v", err)
+	}
+	stats := res.(map[string]interface{})["stats"].([]map[string]interface{})
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 periods, got %v", stats)
+	}
+	if m := stats[0]["metrics"].(map[string]int); m["delivered"] != 118 || m["bounces"] != 2 {
+		t.Errorf("first period metrics = %v", m)
+	}
+	if m := stats[1]["metrics"].(map[string]int); len(m) != 0 {
+		t.Errorf("a period without stats should have empty metrics, got %v", m)
+	}
+
+	for _, payload := range []map[string]interface{}{
+		{},
+		{"start_date": "01/01/2026"},
+		{"start_date": "2026-01-01", "aggregated_by": "year"},
+	} {
+		_, err := p.Execute(context.Background(), &Token{AccessToken: "SG.key"}, "get_stats", payload)
+		if !errors.Is(err, ErrMissingField) && !errors.Is(err, ErrInvalidField) {
+			t.Errorf("expected a field error for payload %v, got %v", payload, err)
+		}
+	}
+}
+
+func TestSendGrid_CreateListErrorNamesField(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"errors":[{"field":"name","message":"list name already exists"}]}`))
+	}))
+	defer srv.Close()
+	p := &SendGridProvider{APIBaseURL: srv.URL}
+
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "SG.key"}, "create_list", map[string]interface{}{"name": "Newsletter"})
+	var upstream *UpstreamError
+	if !errors.As(err, &upstream) || !errors.Is(err, ErrValidation) || upstream.Message != "name: list name already exists" {
+		t.Fatalf("expected a validation error naming the field, got %v", err)
+	}
+}
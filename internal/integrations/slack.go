@@ -0,0 +1,331 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// defaultSlackAPIBaseURL is the Slack Web API root used when a SlackProvider
+// has no APIBaseURL override.
+const defaultSlackAPIBaseURL = "https://slack.com/api"
+
+// ErrSlackUserNotFound is returned when no Slack user matches an email address.
+var ErrSlackUserNotFound = errors.New("slack user not found")
+
+// slackHTTPClient is shared by Slack Web API calls. Requests are bounded by
+// the provider's ActionTimeout rather than a fixed client timeout.
+var slackHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// slackResponse holds the envelope fields common to every Slack Web API reply.
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// slackCall invokes a Slack Web API method and decodes the reply into out.
+// A GET is sent when body is nil, otherwise a JSON POST. A reply with
+// ok=false is returned as an error carrying Slack's error code.
+func (p *SlackProvider) slackCall(ctx context.Context, token *Token, method string, query url.Values, body interface{}, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultSlackAPIBaseURL
+	}
+	endpoint := base + "/" + method
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	httpMethod := http.MethodGet
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encode %s request: %w", method, err)
+		}
+		httpMethod = http.MethodPost
+		reader = bytes.NewReader(data)
+	}
+
+	ctx, cancel := withActionTimeout(ctx, p.Name())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, httpMethod, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	}
+
+	resp, err := slackHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return rateLimitedFromResponse(p.Name(), resp)
+	}
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("slack %s: %w", method, MapUpstreamError(IntegrationSlack, resp.StatusCode, nil))
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("failed to decode Slack response: %w", err)
+	}
+	var envelope slackResponse
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return fmt.Errorf("failed to decode Slack response: %w", err)
+	}
+	if !envelope.OK {
+		return fmt.Errorf("slack %s: %w", method, MapUpstreamError(IntegrationSlack, resp.StatusCode, raw))
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			return fmt.Errorf("failed to decode Slack response: %w", err)
+		}
+	}
+	return nil
+}
+
+// defaultSlackScopes are the bot scopes requested when a SlackProvider sets
+// no Scopes: enough to post messages and open direct messages by email.
+var defaultSlackScopes = []string{"chat:write", "users:read", "users:read.email", "im:write"}
+
+// exchangeCode redeems an authorization code at oauth.v2.access. Slack
+// answers a bad code with HTTP 200 and ok=false, so the error code, such as
+// invalid_code, is matched with errSlackCode like any other Web API error.
+func (p *SlackProvider) exchangeCode(ctx context.Context, code string) (*Token, error) {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultSlackAPIBaseURL
+	}
+	form := url.Values{
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {redirectURLFor(ctx, p.RedirectURL)},
+	}
+	ctx, cancel := withActionTimeout(ctx, p.Name())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, base+"/oauth.v2.access", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("slack token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("slack oauth.v2.access: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, rateLimitedFromResponse(p.Name(), resp)
+	}
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("slack oauth.v2.access: %w", MapUpstreamError(IntegrationSlack, resp.StatusCode, nil))
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to decode Slack token response: %w", err)
+	}
+	var result struct {
+		slackResponse
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode Slack token response: %w", err)
+	}
+	if !result.OK {
+		return nil, fmt.Errorf("slack oauth.v2.access: %w", MapUpstreamError(IntegrationSlack, resp.StatusCode, raw))
+	}
+	if result.AccessToken == "" {
+		return nil, errors.New("slack token response has no access_token")
+	}
+	return &Token{AccessToken: result.AccessToken, TokenType: result.TokenType}, nil
+}
+
+// slackUser is the subset of a Slack user object returned by lookups.
+type slackUser struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+}
+
+// lookupUserByEmail resolves an email address to a Slack user via
+// users.lookupByEmail, returning ErrSlackUserNotFound when none matches.
+func (p *SlackProvider) lookupUserByEmail(ctx context.Context, token *Token, email string) (*slackUser, error) {
+	var result struct {
+		User slackUser `json:"user"`
+	}
+	err := p.slackCall(ctx, token, "users.lookupByEmail", url.Values{"email": {email}}, nil, &result)
+	if err != nil {
+		if errors.Is(err, errSlackCode("users_not_found")) {
+			return nil, fmt.Errorf("%s: %w", email, ErrSlackUserNotFound)
+		}
+		return nil, err
+	}
+	return &result.User, nil
+}
+
+// openDM looks up a user by email, opens (or reuses) a direct message
+// conversation with them and posts text into it.
+func (p *SlackProvider) openDM(ctx context.Context, token *Token, email, text string) (map[string]interface{}, error) {
+	user, err := p.lookupUserByEmail(ctx, token, email)
+	if err != nil {
+		return nil, err
+	}
+
+	var opened struct {
+		Channel struct {
+			ID string `json:"id"`
+		} `json:"channel"`
+	}
+	if err := p.slackCall(ctx, token, "conversations.open", nil, map[string]string{"users": user.ID}, &opened); err != nil {
+		return nil, err
+	}
+
+	var posted struct {
+		TS string `json:"ts"`
+	}
+	err = p.slackCall(ctx, token, "chat.postMessage", nil, map[string]string{
+		"channel": opened.Channel.ID,
+		"text":    text,
+	}, &posted)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"status":  "success",
+		"user_id": user.ID,
+		"channel": opened.Channel.ID,
+		"ts":      posted.TS,
+	}, nil
+}
+
+// errSlackCode is the Slack error code from a reply with ok=false. It is
+// comparable, so callers match specific codes with errors.Is.
+type errSlackCode string
+
+func (e errSlackCode) Error() string { return string(e) }
+
+// slackMaxScheduleAhead is how far in the future chat.scheduleMessage accepts.
+const slackMaxScheduleAhead = 120 * 24 * time.Hour
+
+// slackPostAt reads post_at, a Unix timestamp in seconds, from payload and
+// checks it is in the future and within Slack's 120-day scheduling window.
+func slackPostAt(payload map[string]interface{}, now time.Time) (int64, error) {
+	var postAt int64
+	switch v := payload["post_at"].(type) {
+	case float64:
+		postAt = int64(v)
+	case int:
+		postAt = int64(v)
+	case int64:
+		postAt = v
+	case nil:
+		return 0, missingField("post_at")
+	default:
+		return 0, invalidField("field 'post_at' must be a Unix timestamp, got %T", v)
+	}
+
+	at := time.Unix(postAt, 0)
+	if !at.After(now) {
+		return 0, errors.New("post_at must be in the future")
+	}
+	if at.Sub(now) > slackMaxScheduleAhead {
+		return 0, errors.New("post_at must be within 120 days")
+	}
+	return postAt, nil
+}
+
+// scheduleMessage schedules text for channel at postAt via chat.scheduleMessage.
+func (p *SlackProvider) scheduleMessage(ctx context.Context, token *Token, channel, text string, postAt int64) (map[string]interface{}, error) {
+	var result struct {
+		ScheduledMessageID string `json:"scheduled_message_id"`
+		Channel            string `json:"channel"`
+		PostAt             int64  `json:"post_at"`
+	}
+	err := p.slackCall(ctx, token, "chat.scheduleMessage", nil, map[string]interface{}{
+		"channel": channel,
+		"text":    text,
+		"post_at": postAt,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"status":               "success",
+		"scheduled_message_id": result.ScheduledMessageID,
+		"channel":              result.Channel,
+		"post_at":              result.PostAt,
+	}, nil
+}
+
+// slackScheduledMessage is an entry from chat.scheduledMessages.list.
+type slackScheduledMessage struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	PostAt    int64  `json:"post_at"`
+	Text      string `json:"text"`
+}
+
+// listScheduled returns one page of pending scheduled messages, optionally
+// for one channel. The page token is Slack's cursor.
+func (p *SlackProvider) listScheduled(ctx context.Context, token *Token, channel, cursor string, limit int) (*ListPage, error) {
+	body := map[string]interface{}{"limit": limit}
+	if channel != "" {
+		body["channel"] = channel
+	}
+	if cursor != "" {
+		body["cursor"] = cursor
+	}
+	var result struct {
+		ScheduledMessages []slackScheduledMessage `json:"scheduled_messages"`
+		ResponseMetadata  struct {
+			NextCursor string `json:"next_cursor"`
+		} `json:"response_metadata"`
+	}
+	if err := p.slackCall(ctx, token, "chat.scheduledMessages.list", nil, body, &result); err != nil {
+		return nil, err
+	}
+	return newListPage(result.ScheduledMessages, result.ResponseMetadata.NextCursor), nil
+}
+
+// deleteScheduled cancels a scheduled message before it is posted.
+func (p *SlackProvider) deleteScheduled(ctx context.Context, token *Token, channel, id string) (map[string]interface{}, error) {
+	err := p.slackCall(ctx, token, "chat.deleteScheduledMessage", nil, map[string]string{
+		"channel":              channel,
+		"scheduled_message_id": id,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "scheduled_message_id": id}, nil
+}
+
+// TestConnection identifies the user or bot the token belongs to via
+// auth.test, which reports no email address.
+func (p *SlackProvider) TestConnection(ctx context.Context, token *Token) (*Identity, error) {
+	var result struct {
+		UserID string `json:"user_id"`
+		User   string `json:"user"`
+	}
+	if err := p.slackCall(ctx, token, "auth.test", nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &Identity{ID: result.UserID, Name: result.User, Username: result.User}, nil
+}
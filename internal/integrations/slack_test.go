@@ -0,0 +1,262 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newFakeSlack serves the Slack Web API methods used by the DM actions and
+// records the order in which they were called.
+func newFakeSlack(t *testing.T, calls *[]string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users.lookupByEmail", func(w http.ResponseWriter, r *http.Request) {
+		*calls = append(*calls, "users.lookupByEmail")
+		if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("missing bearer token, got %q", r.Header.Get("Authorization"))
+		}
+		if r.URL.Query().Get("email") != "ada@example.com" {
+			w.Write([]byte(`{"ok":false,"error":"users_not_found"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"user":{"id":"U1","name":"ada","real_name":"Ada Lovelace"}}`))
+	})
+	mux.HandleFunc("/conversations.open", func(w http.ResponseWriter, r *http.Request) {
+		*calls = append(*calls, "conversations.open")
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["users"] != "U1" {
+			t.Errorf("expected users=U1, got %v", body)
+		}
+		w.Write([]byte(`{"ok":true,"channel":{"id":"D1"}}`))
+	})
+	mux.HandleFunc("/chat.postMessage", func(w http.ResponseWriter, r *http.Request) {
+		*calls = append(*calls, "chat.postMessage")
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["channel"] != "D1" || body["text"] != "hello" {
+			t.Errorf("unexpected postMessage body %v", body)
+		}
+		w.Write([]byte(`{"ok":true,"ts":"1700000000.000100"}`))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestSlack_LookupUserByEmail_Found(t *testing.T) {
+	var calls []string
+	srv := newFakeSlack(t, &calls)
+	defer srv.Close()
+
+	p := &SlackProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "xoxb-test"}, "lookup_user_by_email", map[string]interface{}{"email": "ada@example.com"})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	out := res.(map[string]interface{})
+	if out["found"] != true || out["user_id"] != "U1" {
+		t.Errorf("unexpected result %v", out)
+	}
+}
+
+func TestSlack_LookupUserByEmail_NotFound_Graceful(t *testing.T) {
+	var calls []string
+	srv := newFakeSlack(t, &calls)
+	defer srv.Close()
+
+	p := &SlackProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "xoxb-test"}, "lookup_user_by_email", map[string]interface{}{"email": "nobody@example.com"})
+	if err != nil {
+		t.Fatalf("users_not_found should not be an execution error: %v", err)
+	}
+	if out := res.(map[string]interface{}); out["found"] != false {
+		t.Errorf("expected found=false, got %v", out)
+	}
+}
+
+func TestSlack_OpenDM_ChainsLookupOpenPost(t *testing.T) {
+	var calls []string
+	srv := newFakeSlack(t, &calls)
+	defer srv.Close()
+
+	p := &SlackProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "xoxb-test"}, "open_dm", map[string]interface{}{"email": "ada@example.com", "text": "hello"})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	want := []string{"users.lookupByEmail", "conversations.open", "chat.postMessage"}
+	if len(calls) != len(want) {
+		t.Fatalf("expected calls %v, got %v", want, calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d: expected %s, got %s", i, want[i], calls[i])
+		}
+	}
+	if out := res.(map[string]interface{}); out["channel"] != "D1" || out["ts"] != "1700000000.000100" {
+		t.Errorf("unexpected result %v", out)
+	}
+}
+
+func TestSlack_OpenDM_UnknownEmail_ReturnsUserNotFound(t *testing.T) {
+	var calls []string
+	srv := newFakeSlack(t, &calls)
+	defer srv.Close()
+
+	p := &SlackProvider{APIBaseURL: srv.URL}
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "xoxb-test"}, "open_dm", map[string]interface{}{"email": "nobody@example.com", "text": "hello"})
+	if !errors.Is(err, ErrSlackUserNotFound) {
+		t.Fatalf("expected ErrSlackUserNotFound, got %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("no DM should be opened for an unknown user, got calls %v", calls)
+	}
+}
+
+// newFakeSlackScheduler serves the scheduled-message methods, keeping
+// scheduled messages in memory.
+func newFakeSlackScheduler(t *testing.T) *httptest.Server {
+	t.Helper()
+	scheduled := map[string]slackScheduledMessage{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/chat.scheduleMessage", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Channel string `json:"channel"`
+			Text    string `json:"text"`
+			PostAt  int64  `json:"post_at"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		scheduled["Q1"] = slackScheduledMessage{ID: "Q1", ChannelID: body.Channel, PostAt: body.PostAt, Text: body.Text}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "scheduled_message_id": "Q1", "channel": body.Channel, "post_at": body.PostAt})
+	})
+	mux.HandleFunc("/chat.scheduledMessages.list", func(w http.ResponseWriter, r *http.Request) {
+		list := []slackScheduledMessage{}
+		for _, m := range scheduled {
+			list = append(list, m)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "scheduled_messages": list})
+	})
+	mux.HandleFunc("/chat.deleteScheduledMessage", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := scheduled[body["scheduled_message_id"]]; !ok {
+			w.Write([]byte(`{"ok":false,"error":"invalid_scheduled_message_id"}`))
+			return
+		}
+		delete(scheduled, body["scheduled_message_id"])
+		w.Write([]byte(`{"ok":true}`))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestSlack_ScheduleListDelete(t *testing.T) {
+	srv := newFakeSlackScheduler(t)
+	defer srv.Close()
+	p := &SlackProvider{APIBaseURL: srv.URL}
+	token := &Token{AccessToken: "xoxb-test"}
+	ctx := context.Background()
+
+	postAt := time.Now().Add(time.Hour).Unix()
+	res, err := p.Execute(ctx, token, "schedule_message", map[string]interface{}{"channel": "C1", "text": "later", "post_at": float64(postAt)})
+	if err != nil {
+		t.Fatalf("schedule_message error: %v", err)
+	}
+	out := res.(map[string]interface{})
+	if out["scheduled_message_id"] != "Q1" || out["post_at"] != postAt {
+		t.Fatalf("unexpected schedule result %v", out)
+	}
+
+	res, err = p.Execute(ctx, token, "list_scheduled", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("list_scheduled error: %v", err)
+	}
+	if list := res.(*ListPage).Items.([]slackScheduledMessage); len(list) != 1 || list[0].Text != "later" {
+		t.Fatalf("unexpected scheduled messages %v", list)
+	}
+
+	if _, err := p.Execute(ctx, token, "delete_scheduled", map[string]interface{}{"channel": "C1", "scheduled_message_id": "Q1"}); err != nil {
+		t.Fatalf("delete_scheduled error: %v", err)
+	}
+	_, err = p.Execute(ctx, token, "delete_scheduled", map[string]interface{}{"channel": "C1", "scheduled_message_id": "Q1"})
+	if !errors.Is(err, errSlackCode("invalid_scheduled_message_id")) {
+		t.Errorf("expected invalid_scheduled_message_id deleting twice, got %v", err)
+	}
+}
+
+func TestSlack_ScheduleMessage_RejectsPostAtOutsideWindow(t *testing.T) {
+	p := &SlackProvider{APIBaseURL: "http://unused.invalid"}
+	now := time.Now()
+	for name, postAt := range map[string]time.Time{
+		"past":        now.Add(-time.Minute),
+		"beyond 120d": now.Add(121 * 24 * time.Hour),
+	} {
+		_, err := p.Execute(context.Background(), &Token{AccessToken: "xoxb-test"}, "schedule_message",
+			map[string]interface{}{"channel": "C1", "text": "x", "post_at": float64(postAt.Unix())})
+		if err == nil {
+			t.Errorf("%s: expected post_at to be rejected", name)
+		}
+	}
+}
+
+func TestSlack_ListScheduled_CursorEnvelope(t *testing.T) {
+	var cursors []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		cursor, _ := body["cursor"].(string)
+		cursors = append(cursors, cursor)
+		if cursor == "" {
+			w.Write([]byte(`{"ok":true,"scheduled_messages":[{"id":"Q1","text":"a"}],"response_metadata":{"next_cursor":"dGVhbTpDMQ=="}}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"scheduled_messages":[{"id":"Q2","text":"b"}],"response_metadata":{"next_cursor":""}}`))
+	}))
+	defer srv.Close()
+
+	p := &SlackProvider{APIBaseURL: srv.URL}
+	token := &Token{AccessToken: "xoxb-test"}
+	res, err := p.Execute(context.Background(), token, "list_scheduled", map[string]interface{}{"page_size": float64(1)})
+	if err != nil {
+		t.Fatalf("list_scheduled error: %v", err)
+	}
+	page := res.(*ListPage)
+	if !page.HasMore || page.NextPageToken != "dGVhbTpDMQ==" {
+		t.Fatalf("first page = %+v, want more with Slack's cursor", page)
+	}
+
+	res, err = p.Execute(context.Background(), token, "list_scheduled", map[string]interface{}{"page_token": page.NextPageToken})
+	if err != nil {
+		t.Fatalf("list_scheduled error: %v", err)
+	}
+	page = res.(*ListPage)
+	if items := page.Items.([]slackScheduledMessage); page.HasMore || len(items) != 1 || items[0].ID != "Q2" {
+		t.Errorf("last page = %+v", page)
+	}
+	if len(cursors) != 2 || cursors[1] != "dGVhbTpDMQ==" {
+		t.Errorf("unexpected cursors %v", cursors)
+	}
+}
+
+func TestSlack_GetMe_AuthTest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/auth.test" || r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(`{"ok":true,"url":"https://acme.slack.com/","team":"Acme","user":"neighbourhood","team_id":"T1","user_id":"U0BOT"}`))
+	}))
+	defer srv.Close()
+
+	p := &SlackProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "xoxb-test"}, "get_me", nil)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	out := res.(map[string]interface{})
+	if out["id"] != "U0BOT" || out["name"] != "neighbourhood" || out["email"] != "" || out["username"] != "neighbourhood" {
+		t.Errorf("unexpected result %v", out)
+	}
+}
@@ -0,0 +1,145 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// defaultStripeAPIBaseURL is the Stripe API root used when a StripeProvider
+// has no APIBaseURL override.
+const defaultStripeAPIBaseURL = "https://api.stripe.com"
+
+// stripeHTTPClient is shared by Stripe API calls. Requests are bounded by the
+// provider's ActionTimeout.
+var stripeHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// ErrStripeChargeAlreadyRefunded is returned when a refund targets a charge
+// that has already been refunded in full. The error also matches
+// ErrValidation.
+var ErrStripeChargeAlreadyRefunded = errors.New("stripe charge already refunded")
+
+// errStripeRefundTarget is returned when create_refund names neither or both
+// of payment_intent and charge.
+var errStripeRefundTarget = invalidField("exactly one of payment_intent or charge is required")
+
+// stripePayoutStatuses are the statuses list_payouts can filter by.
+var stripePayoutStatuses = map[string]bool{"pending": true, "in_transit": true, "paid": true, "failed": true, "canceled": true}
+
+// stripePayout is one payout as list_payouts returns it. Amount is in the
+// currency's smallest unit; ArrivalDate is a Unix timestamp.
+type stripePayout struct {
+	ID          string `json:"id"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Status      string `json:"status"`
+	ArrivalDate int64  `json:"arrival_date"`
+	Method      string `json:"method"`
+	Description string `json:"description,omitempty"`
+}
+
+// createRefund refunds a charge, or the charge behind a payment intent. A
+// zero amount refunds whatever is left of the charge. The target is looked up
+// first so a mistyped ID fails with ErrNotFound before anything is refunded.
+// A non-empty idempotencyKey is sent as Stripe's Idempotency-Key, so a retry
+// returns the first refund instead of refunding again.
+func (p *StripeProvider) createRefund(ctx context.Context, token *Token, paymentIntent, charge string, amount int64, idempotencyKey string) (map[string]interface{}, error) {
+	form := url.Values{}
+	if paymentIntent != "" {
+		var intent struct {
+			LatestCharge string `json:"latest_charge"`
+		}
+		if err := p.stripeCall(ctx, token, http.MethodGet, "/v1/payment_intents/"+url.PathEscape(paymentIntent), nil, &intent); err != nil {
+			return nil, err
+		}
+		if intent.LatestCharge == "" {
+			return nil, fmt.Errorf("payment intent %s has no charge to refund: %w", paymentIntent, ErrValidation)
+		}
+		form.Set("payment_intent", paymentIntent)
+	} else {
+		var target struct {
+			ID string `json:"id"`
+		}
+		if err := p.stripeCall(ctx, token, http.MethodGet, "/v1/charges/"+url.PathEscape(charge), nil, &target); err != nil {
+			return nil, err
+		}
+		form.Set("charge", charge)
+	}
+	if amount > 0 {
+		form.Set("amount", strconv.FormatInt(amount, 10))
+	}
+
+	var refund struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+		Amount int64  `json:"amount"`
+	}
+	if idempotencyKey != "" {
+		ctx = WithIdempotencyKey(ctx, idempotencyKey)
+	}
+	if err := p.stripeCall(ctx, token, http.MethodPost, "/v1/refunds", form, &refund); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "refund_id": refund.ID, "refund_status": refund.Status, "amount": refund.Amount}, nil
+}
+
+// listPayouts returns one page of payouts to the account's bank, newest
+// first, optionally only those with payoutStatus. The page token is the ID
+// of the last payout on the previous page.
+func (p *StripeProvider) listPayouts(ctx context.Context, token *Token, payoutStatus, startingAfter string, limit int) (*ListPage, error) {
+	params := url.Values{"limit": {strconv.Itoa(limit)}}
+	if payoutStatus != "" {
+		params.Set("status", payoutStatus)
+	}
+	if startingAfter != "" {
+		params.Set("starting_after", startingAfter)
+	}
+	var list struct {
+		Data    []stripePayout `json:"data"`
+		HasMore bool           `json:"has_more"`
+	}
+	if err := p.stripeCall(ctx, token, http.MethodGet, "/v1/payouts?"+params.Encode(), nil, &list); err != nil {
+		return nil, err
+	}
+	next := ""
+	if list.HasMore && len(list.Data) > 0 {
+		next = list.Data[len(list.Data)-1].ID
+	}
+	return newListPage(list.Data, next), nil
+}
+
+// SendsIdempotencyKey marks Stripe as an IdempotencyKeySender: stripeCall
+// sends the key with every POST.
+func (p *StripeProvider) SendsIdempotencyKey() {}
+
+// stripeCall sends a request to the Stripe API, with form as the
+// form-encoded body when it is non-nil, and decodes the reply into out. POSTs
+// carry the IdempotencyKey of ctx, if any.
+func (p *StripeProvider) stripeCall(ctx context.Context, token *Token, method, path string, form url.Values, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultStripeAPIBaseURL
+	}
+	var opts []requestOption
+	if key := IdempotencyKey(ctx); key != "" && method == http.MethodPost {
+		opts = append(opts, withHeader("Idempotency-Key", key))
+	}
+	return doForm(ctx, stripeHTTPClient, IntegrationStripe, method, base+path, token, form, out, opts...)
+}
+
+// stripeAmount reads the optional "amount" field, a positive whole number in
+// the currency's smallest unit (cents for USD). It returns 0 when absent.
+func stripeAmount(payload map[string]interface{}) (int64, error) {
+	v, ok := payload["amount"]
+	if !ok || v == nil {
+		return 0, nil
+	}
+	n, ok := v.(float64)
+	if !ok || n < 1 || n != float64(int64(n)) {
+		return 0, invalidField("field 'amount' must be a positive integer in the currency's smallest unit")
+	}
+	return int64(n), nil
+}
@@ -0,0 +1,180 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// newStripeServer serves a charge ch_1 and a payment intent pi_1 paid by it,
+// answering refunds with refundStatus and refundReply. Refund forms are
+// recorded in *refunds.
+func newStripeServer(t *testing.T, refundStatus int, refundReply string, refunds *[]url.Values) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer sk_test" {
+			t.Errorf("unexpected Authorization %q", r.Header.Get("Authorization"))
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/charges/ch_1":
+			w.Write([]byte(`{"id":"ch_1","object":"charge","amount":5000,"refunded":false}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/payment_intents/pi_1":
+			w.Write([]byte(`{"id":"pi_1","object":"payment_intent","latest_charge":"ch_1"}`))
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"resource_missing","message":"No such charge","type":"invalid_request_error"}}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/refunds":
+			r.ParseForm()
+			*refunds = append(*refunds, r.PostForm)
+			w.WriteHeader(refundStatus)
+			w.Write([]byte(refundReply))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestStripe_FullRefund(t *testing.T) {
+	var refunds []url.Values
+	srv := newStripeServer(t, http.StatusOK, `{"id":"re_1","object":"refund","amount":5000,"status":"succeeded"}`, &refunds)
+	p := &StripeProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "sk_test"}, "create_refund", map[string]interface{}{"payment_intent": "pi_1"})
+	if err != nil {
+		t.Fatalf("create_refund: %v", err)
+	}
+	got := res.(map[string]interface{})
+	if got["refund_id"] != "re_1" || got["refund_status"] != "succeeded" {
+		t.Errorf("result = %v", got)
+	}
+	if len(refunds) != 1 || refunds[0].Get("payment_intent") != "pi_1" || refunds[0].Has("amount") {
+		t.Errorf("refund form = %v, want payment_intent pi_1 and no amount", refunds)
+	}
+}
+
+func TestStripe_PartialRefund(t *testing.T) {
+	var refunds []url.Values
+	srv := newStripeServer(t, http.StatusOK, `{"id":"re_2","object":"refund","amount":1250,"status":"pending"}`, &refunds)
+	p := &StripeProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "sk_test"}, "create_refund", map[string]interface{}{"charge": "ch_1", "amount": float64(1250)})
+	if err != nil {
+		t.Fatalf("create_refund: %v", err)
+	}
+	if got := res.(map[string]interface{}); got["refund_id"] != "re_2" || got["refund_status"] != "pending" || got["amount"] != int64(1250) {
+		t.Errorf("result = %v", got)
+	}
+	if len(refunds) != 1 || refunds[0].Get("charge") != "ch_1" || refunds[0].Get("amount") != "1250" {
+		t.Errorf("refund form = %v, want charge ch_1 and amount 1250", refunds)
+	}
+
+	for _, amount := range []interface{}{12.5, float64(0), "1250"} {
+		if _, err := p.Execute(context.Background(), &Token{AccessToken: "sk_test"}, "create_refund", map[string]interface{}{"charge": "ch_1", "amount": amount}); err == nil {
+			t.Errorf("expected an error for amount %v", amount)
+		}
+	}
+}
+
+func TestStripe_RefundErrors(t *testing.T) {
+	var refunds []url.Values
+	srv := newStripeServer(t, http.StatusBadRequest,
+		`{"error":{"code":"charge_already_refunded","message":"Charge ch_1 has already been refunded.","type":"invalid_request_error"}}`, &refunds)
+	p := &StripeProvider{APIBaseURL: srv.URL}
+	tok := &Token{AccessToken: "sk_test"}
+
+	_, err := p.Execute(context.Background(), tok, "create_refund", map[string]interface{}{"charge": "ch_1"})
+	if !errors.Is(err, ErrStripeChargeAlreadyRefunded) || !errors.Is(err, ErrValidation) {
+		t.Errorf("expected ErrStripeChargeAlreadyRefunded and ErrValidation, got %v", err)
+	}
+
+	// An unknown charge is rejected before a refund is attempted.
+	refunds = nil
+	if _, err := p.Execute(context.Background(), tok, "create_refund", map[string]interface{}{"charge": "ch_missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if len(refunds) != 0 {
+		t.Errorf("refund attempted for a missing charge: %v", refunds)
+	}
+
+	for _, payload := range []map[string]interface{}{{}, {"charge": "ch_1", "payment_intent": "pi_1"}} {
+		if _, err := p.Execute(context.Background(), tok, "create_refund", payload); !errors.Is(err, errStripeRefundTarget) || !errors.Is(err, ErrInvalidField) {
+			t.Errorf("payload %v: expected errStripeRefundTarget, got %v", payload, err)
+		}
+	}
+}
+
+func TestStripe_ListPayouts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/payouts" || r.URL.Query().Get("status") != "paid" || r.URL.Query().Get("limit") != "2" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.URL.Query().Get("starting_after") == "po_2" {
+			w.Write([]byte(`{"object":"list","data":[{"id":"po_3","amount":300,"currency":"usd","status":"paid"}],"has_more":false}`))
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[{"id":"po_1","amount":100,"currency":"usd","status":"paid"},{"id":"po_2","amount":200,"currency":"usd","status":"paid"}],"has_more":true}`))
+	}))
+	defer srv.Close()
+	p := &StripeProvider{APIBaseURL: srv.URL}
+	tok := &Token{AccessToken: "sk_test"}
+
+	res, err := p.Execute(context.Background(), tok, "list_payouts", map[string]interface{}{"status": "paid", "page_size": float64(2)})
+	if err != nil {
+		t.Fatalf("list_payouts: %v", err)
+	}
+	page := res.(*ListPage)
+	if payouts := page.Items.([]stripePayout); len(payouts) != 2 || page.NextPageToken != "po_2" || !page.HasMore {
+		t.Fatalf("first page = %+v", page)
+	}
+	res, err = p.Execute(context.Background(), tok, "list_payouts", map[string]interface{}{"status": "paid", "page_size": float64(2), "page_token": "po_2"})
+	if err != nil {
+		t.Fatalf("list_payouts page 2: %v", err)
+	}
+	page = res.(*ListPage)
+	if payouts := page.Items.([]stripePayout); len(payouts) != 1 || payouts[0].Amount != 300 || page.HasMore {
+		t.Errorf("second page = %+v", page)
+	}
+
+	if _, err := p.Execute(context.Background(), tok, "list_payouts", map[string]interface{}{"status": "lost"}); err == nil {
+		t.Error("expected an error for an unknown status")
+	}
+}
+
+func TestStripe_RefundIdempotencyKey(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			if key := r.Header.Get("Idempotency-Key"); key != "" {
+				t.Errorf("lookup sent Idempotency-Key %q", key)
+			}
+			w.Write([]byte(`{"id":"ch_1","object":"charge","amount":5000}`))
+		case http.MethodPost:
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			w.Write([]byte(`{"id":"re_1","object":"refund","amount":5000,"status":"succeeded"}`))
+		}
+	}))
+	defer srv.Close()
+	p := &StripeProvider{APIBaseURL: srv.URL}
+	tok := &Token{AccessToken: "sk_test"}
+
+	ctx := WithIdempotencyKey(context.Background(), "run/1/step-0")
+	if _, err := p.Execute(ctx, tok, "create_refund", map[string]interface{}{"charge": "ch_1"}); err != nil {
+		t.Fatalf("create_refund: %v", err)
+	}
+	// An explicit key in the payload wins over the one from the run.
+	if _, err := p.Execute(ctx, tok, "create_refund", map[string]interface{}{"charge": "ch_1", "idempotency_key": "order-42"}); err != nil {
+		t.Fatalf("create_refund: %v", err)
+	}
+	if _, err := p.Execute(context.Background(), tok, "create_refund", map[string]interface{}{"charge": "ch_1"}); err != nil {
+		t.Fatalf("create_refund: %v", err)
+	}
+	if want := []string{"run/1/step-0", "order-42", ""}; len(keys) != 3 || keys[0] != want[0] || keys[1] != want[1] || keys[2] != want[2] {
+		t.Errorf("Idempotency-Key headers = %q, want %q", keys, want)
+	}
+}
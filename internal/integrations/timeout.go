@@ -0,0 +1,62 @@
+package integrations
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultActionTimeout bounds a provider action when no timeout is configured.
+const DefaultActionTimeout = 30 * time.Second
+
+var (
+	timeoutsMu       sync.RWMutex
+	actionTimeout    = DefaultActionTimeout
+	providerTimeouts = map[IntegrationType]time.Duration{}
+)
+
+// SetActionTimeouts sets the default provider action timeout and per-provider
+// overrides for providers whose valid operations take longer, such as extract
+// refreshes or large uploads. It is called from configuration at startup.
+func SetActionTimeouts(def time.Duration, overrides map[IntegrationType]time.Duration) {
+	if def <= 0 {
+		def = DefaultActionTimeout
+	}
+	o := make(map[IntegrationType]time.Duration, len(overrides))
+	for name, d := range overrides {
+		if d > 0 {
+			o[name] = d
+		}
+	}
+	timeoutsMu.Lock()
+	actionTimeout, providerTimeouts = def, o
+	timeoutsMu.Unlock()
+}
+
+// ActionTimeout returns how long an action on provider may run.
+func ActionTimeout(provider IntegrationType) time.Duration {
+	timeoutsMu.RLock()
+	defer timeoutsMu.RUnlock()
+	if d, ok := providerTimeouts[provider]; ok {
+		return d
+	}
+	return actionTimeout
+}
+
+// MaxActionTimeout returns the longest time any provider action may run, so
+// the HTTP server can keep connections open for it.
+func MaxActionTimeout() time.Duration {
+	timeoutsMu.RLock()
+	defer timeoutsMu.RUnlock()
+	longest := actionTimeout
+	for _, d := range providerTimeouts {
+		longest = max(longest, d)
+	}
+	return longest
+}
+
+// withActionTimeout bounds ctx by provider's action timeout. An earlier
+// deadline already on ctx still applies.
+func withActionTimeout(ctx context.Context, provider string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, ActionTimeout(IntegrationType(provider)))
+}
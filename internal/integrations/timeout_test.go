@@ -0,0 +1,88 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// slowProvider takes delay to complete an action, honouring cancellation.
+type slowProvider struct {
+	name  string
+	delay time.Duration
+}
+
+func (p *slowProvider) Name() string                                         { return p.name }
+func (p *slowProvider) GetAuthURL(state string) string                       { return "" }
+func (p *slowProvider) ExchangeCode(context.Context, string) (*Token, error) { return nil, nil }
+func (p *slowProvider) Execute(ctx context.Context, _ *Token, _ string, _ map[string]interface{}) (interface{}, error) {
+	select {
+	case <-time.After(p.delay):
+		return "done", nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestMaxActionTimeout(t *testing.T) {
+	t.Cleanup(func() { SetActionTimeouts(DefaultActionTimeout, nil) })
+	SetActionTimeouts(0, nil)
+	if got := MaxActionTimeout(); got != DefaultActionTimeout {
+		t.Errorf("MaxActionTimeout() = %v, want the default %v", got, DefaultActionTimeout)
+	}
+	SetActionTimeouts(time.Minute, map[IntegrationType]time.Duration{"tableau": 5 * time.Minute, "slack": 10 * time.Second})
+	if got := MaxActionTimeout(); got != 5*time.Minute {
+		t.Errorf("MaxActionTimeout() = %v, want the longest override", got)
+	}
+}
+
+func TestExecuteAction_ProviderTimeoutOverride(t *testing.T) {
+	t.Cleanup(func() { SetActionTimeouts(DefaultActionTimeout, nil) })
+	SetActionTimeouts(20*time.Millisecond, map[IntegrationType]time.Duration{"slow_export": time.Second})
+
+	got, err := ExecuteAction(context.Background(), &slowProvider{name: "slow_export", delay: 100 * time.Millisecond}, &Token{}, "export", nil)
+	if err != nil || got != "done" {
+		t.Fatalf("overridden provider = %v, %v; want done", got, err)
+	}
+
+	_, err = ExecuteAction(context.Background(), &slowProvider{name: "other", delay: 100 * time.Millisecond}, &Token{}, "export", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("default provider err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestActionTimeout_Fallback(t *testing.T) {
+	t.Cleanup(func() { SetActionTimeouts(DefaultActionTimeout, nil) })
+	SetActionTimeouts(0, map[IntegrationType]time.Duration{"tableau": 5 * time.Minute, "bad": -time.Second})
+
+	if got := ActionTimeout("tableau"); got != 5*time.Minute {
+		t.Errorf("tableau timeout = %v, want 5m", got)
+	}
+	if got := ActionTimeout("bad"); got != DefaultActionTimeout {
+		t.Errorf("non-positive override = %v, want default", got)
+	}
+	if got := ActionTimeout("slack"); got != DefaultActionTimeout {
+		t.Errorf("slack timeout = %v, want default", got)
+	}
+}
+
+func TestSlackExchangeCode_ActionTimeout(t *testing.T) {
+	t.Cleanup(func() { SetActionTimeouts(DefaultActionTimeout, nil) })
+	SetActionTimeouts(20*time.Millisecond, nil)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	p := &SlackProvider{APIBaseURL: srv.URL}
+	start := time.Now()
+	if _, err := p.ExchangeCode(context.Background(), "code"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("exchange took %v, want it bounded by the action timeout", elapsed)
+	}
+}
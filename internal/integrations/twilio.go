@@ -0,0 +1,112 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// defaultTwilioAPIBaseURL is the Twilio REST API root used when a
+// TwilioProvider has no APIBaseURL override.
+const defaultTwilioAPIBaseURL = "https://api.twilio.com"
+
+// twilioHTTPClient is shared by Twilio API calls. Requests are bounded by the
+// provider's ActionTimeout.
+var twilioHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// e164 matches a phone number in E.164 form, such as +14155550100.
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// whatsAppPrefix marks a Twilio address as a WhatsApp number.
+const whatsAppPrefix = "whatsapp:"
+
+// twilioError is the body Twilio returns for failed requests.
+type twilioError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// twilioCredentials splits a Twilio token, stored as
+// "<account SID>:<auth token>", into its parts.
+func twilioCredentials(token *Token) (sid, secret string, err error) {
+	if token == nil {
+		return "", "", fmt.Errorf("twilio: missing token: %w", ErrInvalidCredentials)
+	}
+	sid, secret, ok := strings.Cut(token.AccessToken, ":")
+	if !ok || sid == "" || secret == "" {
+		return "", "", fmt.Errorf("twilio key must be ACCOUNT_SID:AUTH_TOKEN: %w", ErrInvalidCredentials)
+	}
+	return sid, secret, nil
+}
+
+// phoneNumber reads an E.164 phone number from payload[key].
+func phoneNumber(payload map[string]interface{}, key string) (string, error) {
+	v, err := getString(payload, key)
+	if err != nil {
+		return "", err
+	}
+	if !e164.MatchString(v) {
+		return "", fmt.Errorf("%s must be an E.164 phone number such as +14155550100, got %q", key, v)
+	}
+	return v, nil
+}
+
+// whatsAppNumber reads a WhatsApp address from payload[key]. Both
+// "whatsapp:+14155550100" and a bare E.164 number are accepted; the result
+// always carries the whatsapp: prefix Twilio requires.
+func whatsAppNumber(payload map[string]interface{}, key string) (string, error) {
+	v, err := getString(payload, key)
+	if err != nil {
+		return "", err
+	}
+	if number := strings.TrimPrefix(v, whatsAppPrefix); e164.MatchString(number) {
+		return whatsAppPrefix + number, nil
+	}
+	return "", fmt.Errorf("%s must be a WhatsApp number such as whatsapp:+14155550100, got %q", key, v)
+}
+
+// makeCall places a voice call from one number to another. Twilio fetches the
+// TwiML at twimlURL to decide what the call does once answered.
+func (p *TwilioProvider) makeCall(ctx context.Context, token *Token, from, to, twimlURL string) (map[string]string, error) {
+	var call struct {
+		SID    string `json:"sid"`
+		Status string `json:"status"`
+	}
+	form := url.Values{"From": {from}, "To": {to}, "Url": {twimlURL}}
+	if err := p.twilioPost(ctx, token, "Calls.json", form, &call); err != nil {
+		return nil, err
+	}
+	return map[string]string{"status": "success", "call_sid": call.SID, "call_status": call.Status}, nil
+}
+
+// sendWhatsApp sends a WhatsApp message through the Messages resource; from
+// and to carry the whatsapp: prefix.
+func (p *TwilioProvider) sendWhatsApp(ctx context.Context, token *Token, from, to, body string) (map[string]string, error) {
+	var msg struct {
+		SID    string `json:"sid"`
+		Status string `json:"status"`
+	}
+	form := url.Values{"From": {from}, "To": {to}, "Body": {body}}
+	if err := p.twilioPost(ctx, token, "Messages.json", form, &msg); err != nil {
+		return nil, err
+	}
+	return map[string]string{"status": "success", "message_sid": msg.SID, "message_status": msg.Status}, nil
+}
+
+// twilioPost sends a form POST to a resource of the token's account and
+// decodes the reply into out.
+func (p *TwilioProvider) twilioPost(ctx context.Context, token *Token, resource string, form url.Values, out interface{}) error {
+	sid, secret, err := twilioCredentials(token)
+	if err != nil {
+		return err
+	}
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultTwilioAPIBaseURL
+	}
+	endpoint := base + "/2010-04-01/Accounts/" + url.PathEscape(sid) + "/" + resource
+	return doForm(ctx, twilioHTTPClient, IntegrationTwilio, http.MethodPost, endpoint, nil, form, out, func(r *http.Request) { r.SetBasicAuth(sid, secret) })
+}
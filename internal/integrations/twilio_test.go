@@ -0,0 +1,123 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// newTwilioServer serves one Twilio account resource, checking basic auth and
+// recording the posted form.
+func newTwilioServer(t *testing.T, resource, reply string, form *url.Values) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "AC123" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/2010-04-01/Accounts/AC123/"+resource {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		r.ParseForm()
+		*form = r.PostForm
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTwilio_MakeCall(t *testing.T) {
+	var form url.Values
+	srv := newTwilioServer(t, "Calls.json", `{"sid":"CA42","status":"queued"}`, &form)
+	p := &TwilioProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "AC123:secret"}, "make_call", map[string]interface{}{
+		"from": "+14155550100", "to": "+442071838750", "url": "https://example.com/twiml/greeting.xml",
+	})
+	if err != nil {
+		t.Fatalf("make_call: %v", err)
+	}
+	want := map[string]string{"status": "success", "call_sid": "CA42", "call_status": "queued"}
+	if !reflect.DeepEqual(res, want) {
+		t.Errorf("result = %v, want %v", res, want)
+	}
+	if form.Get("From") != "+14155550100" || form.Get("To") != "+442071838750" || form.Get("Url") != "https://example.com/twiml/greeting.xml" {
+		t.Errorf("unexpected form %v", form)
+	}
+}
+
+func TestTwilio_SendWhatsApp(t *testing.T) {
+	var form url.Values
+	srv := newTwilioServer(t, "Messages.json", `{"sid":"SM7","status":"queued"}`, &form)
+	p := &TwilioProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "AC123:secret"}, "send_whatsapp", map[string]interface{}{
+		"from": "whatsapp:+14155238886", "to": "+15005550006", "body": "Your order has shipped",
+	})
+	if err != nil {
+		t.Fatalf("send_whatsapp: %v", err)
+	}
+	if got := res.(map[string]string)["message_sid"]; got != "SM7" {
+		t.Errorf("message_sid = %q, want SM7", got)
+	}
+	// A bare number is sent with the whatsapp: prefix.
+	if form.Get("From") != "whatsapp:+14155238886" || form.Get("To") != "whatsapp:+15005550006" || form.Get("Body") != "Your order has shipped" {
+		t.Errorf("unexpected form %v", form)
+	}
+}
+
+func TestTwilio_InvalidNumbers(t *testing.T) {
+	p := &TwilioProvider{APIBaseURL: "http://127.0.0.1:0"}
+	tok := &Token{AccessToken: "AC123:secret"}
+	cases := []struct {
+		action  string
+		payload map[string]interface{}
+	}{
+		{"make_call", map[string]interface{}{"from": "4155550100", "to": "+442071838750", "url": "https://example.com/t.xml"}},
+		{"make_call", map[string]interface{}{"from": "whatsapp:+14155550100", "to": "+442071838750", "url": "https://example.com/t.xml"}},
+		{"make_call", map[string]interface{}{"from": "+14155550100", "to": "+442071838750", "url": "/twiml.xml"}},
+		{"send_whatsapp", map[string]interface{}{"from": "whatsapp:14155238886", "to": "+15005550006", "body": "hi"}},
+		{"send_whatsapp", map[string]interface{}{"from": "sms:+14155238886", "to": "+15005550006", "body": "hi"}},
+	}
+	for _, tc := range cases {
+		if _, err := p.Execute(context.Background(), tok, tc.action, tc.payload); err == nil {
+			t.Errorf("%s %v: expected validation error", tc.action, tc.payload)
+		}
+	}
+}
+
+func TestTwilio_RejectedCredentials(t *testing.T) {
+	var form url.Values
+	srv := newTwilioServer(t, "Calls.json", `{}`, &form)
+	p := &TwilioProvider{APIBaseURL: srv.URL}
+
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "AC123:wrong"}, "make_call", map[string]interface{}{
+		"from": "+14155550100", "to": "+442071838750", "url": "https://example.com/t.xml",
+	})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestTwilio_ErrorBodyMapped(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":21211,"message":"The 'To' number is not a valid phone number.","status":400}`))
+	}))
+	defer srv.Close()
+	p := &TwilioProvider{APIBaseURL: srv.URL}
+
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "AC123:secret"}, "make_call", map[string]interface{}{
+		"from": "+14155550100", "to": "+442071838750", "url": "https://example.com/t.xml",
+	})
+	var upstream *UpstreamError
+	if !errors.Is(err, ErrValidation) || !errors.As(err, &upstream) || upstream.Code != "21211" {
+		t.Errorf("error = %v, want ErrValidation with Twilio code 21211", err)
+	}
+}
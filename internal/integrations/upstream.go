@@ -0,0 +1,465 @@
+package integrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNotFound is returned when the provider reports that the requested
+	// resource (channel, file, repository, customer...) does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrValidation is returned when the provider rejects the request payload.
+	ErrValidation = errors.New("invalid request")
+	// ErrProviderUnavailable is returned when the provider fails with a 5xx
+	// response. The UpstreamError carrying it records the upstream status.
+	ErrProviderUnavailable = errors.New("provider unavailable")
+)
+
+// UpstreamError is a provider's error response translated into one of the
+// typed errors: ErrInvalidCredentials, ErrNotFound, ErrValidation,
+// ErrProviderUnavailable or *ErrRateLimited. errors.Is and errors.As match Kind, and also Cause when the
+// provider integration keeps its own error value.
+type UpstreamError struct {
+	Provider string
+	Status   int
+	Code     string // the provider's error code, when it sends one
+	Message  string
+	Kind     error // nil when the error is not one the gateway recognises
+	Cause    error
+}
+
+func (e *UpstreamError) Error() string {
+	msg := e.Provider + " error"
+	if e.Status != 0 && e.Status != http.StatusOK {
+		msg = fmt.Sprintf("%s returned %d", e.Provider, e.Status)
+	}
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Message != "" && e.Message != e.Code {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *UpstreamError) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Cause != nil {
+		errs = append(errs, e.Cause)
+	}
+	return errs
+}
+
+// errorMapper translates a provider's failed response into an UpstreamError.
+type errorMapper func(status int, body []byte) *UpstreamError
+
+// errorMappers holds the providers whose error bodies are understood beyond
+// their HTTP status.
+var errorMappers = map[IntegrationType]errorMapper{
+	IntegrationSlack:        mapSlackError,
+	IntegrationGitHub:       mapGitHubError,
+	IntegrationGmail:        mapGoogleError,
+	IntegrationGoogleDrive:  mapGoogleError,
+	IntegrationGoogleSheets: mapGoogleError,
+	IntegrationStripe:       mapStripeError,
+	IntegrationMailchimp:    mapMailchimpError,
+	IntegrationZendesk:      mapZendeskError,
+	IntegrationBox:          mapBoxError,
+	IntegrationJira:         mapJiraError,
+	IntegrationClickUp:      mapClickUpError,
+	IntegrationAsana:        mapAsanaError,
+	IntegrationLinkedIn:     mapLinkedInError,
+	IntegrationSendGrid:     mapSendGridError,
+	IntegrationIntercom:     mapIntercomError,
+	IntegrationTwilio:       mapTwilioError,
+	IntegrationNotion:       mapNotionError,
+	IntegrationZoom:         mapZoomError,
+}
+
+// MapUpstreamError translates a failed provider response into an
+// *UpstreamError wrapping the matching typed error, so the gateway can answer
+// with the right HTTP status whichever provider failed. Providers without a
+// mapper are classified by status code alone. A 5xx is always
+// ErrProviderUnavailable and its body, often an HTML error page, is ignored.
+func MapUpstreamError(provider IntegrationType, status int, body []byte) error {
+	if status >= 500 {
+		return &UpstreamError{
+			Provider: string(provider),
+			Status:   status,
+			Message:  http.StatusText(status),
+			Kind:     ErrProviderUnavailable,
+		}
+	}
+	mapper, ok := errorMappers[provider]
+	if !ok {
+		mapper = mapStatusError
+	}
+	e := mapper(status, body)
+	e.Provider, e.Status = string(provider), status
+	if rl, ok := e.Kind.(*ErrRateLimited); ok {
+		rl.Provider = string(provider)
+	}
+	return e
+}
+
+// checkResponse returns nil for a 2xx response and otherwise its typed error:
+// *ErrRateLimited for 429, ErrProviderUnavailable for 5xx without reading the
+// body, or the provider's mapped error.
+func checkResponse(provider IntegrationType, resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return rateLimitedFromResponse(string(provider), resp)
+	case resp.StatusCode >= 500:
+		return MapUpstreamError(provider, resp.StatusCode, nil)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return MapUpstreamError(provider, resp.StatusCode, body)
+	}
+	return nil
+}
+
+// kindForStatus classifies an HTTP status code.
+func kindForStatus(status int) error {
+	switch status {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrInvalidCredentials
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ErrValidation
+	case http.StatusTooManyRequests:
+		return &ErrRateLimited{}
+	}
+	return nil
+}
+
+// mapStatusError classifies a response by status alone.
+func mapStatusError(status int, body []byte) *UpstreamError {
+	return &UpstreamError{Message: strings.TrimSpace(string(body)), Kind: kindForStatus(status)}
+}
+
+// slackErrorKinds maps Slack Web API error codes, which arrive with HTTP 200
+// and ok=false, to typed errors.
+var slackErrorKinds = map[string]error{
+	"not_authed":                   ErrInvalidCredentials,
+	"invalid_auth":                 ErrInvalidCredentials,
+	"token_revoked":                ErrInvalidCredentials,
+	"token_expired":                ErrInvalidCredentials,
+	"account_inactive":             ErrInvalidCredentials,
+	"missing_scope":                ErrInvalidCredentials,
+	"channel_not_found":            ErrNotFound,
+	"user_not_found":               ErrNotFound,
+	"users_not_found":              ErrNotFound,
+	"message_not_found":            ErrNotFound,
+	"invalid_scheduled_message_id": ErrNotFound,
+	"invalid_arguments":            ErrValidation,
+	"invalid_blocks":               ErrValidation,
+	"no_text":                      ErrValidation,
+	"msg_too_long":                 ErrValidation,
+	"time_in_past":                 ErrValidation,
+	"time_too_far":                 ErrValidation,
+	"invalid_time":                 ErrValidation,
+}
+
+func mapSlackError(status int, body []byte) *UpstreamError {
+	var reply slackResponse
+	json.Unmarshal(body, &reply)
+	if reply.Error == "ratelimited" {
+		return &UpstreamError{Code: reply.Error, Kind: &ErrRateLimited{}, Cause: errSlackCode(reply.Error)}
+	}
+	kind, ok := slackErrorKinds[reply.Error]
+	if !ok {
+		kind = kindForStatus(status)
+	}
+	e := &UpstreamError{Code: reply.Error, Kind: kind}
+	if reply.Error != "" {
+		e.Cause = errSlackCode(reply.Error)
+	}
+	return e
+}
+
+// mapGitHubError handles GitHub's {"message": ...} bodies. GitHub reports an
+// exhausted rate limit as 403, so the message is checked before the status.
+func mapGitHubError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		Message string `json:"message"`
+	}
+	json.Unmarshal(body, &reply)
+	e := &UpstreamError{Message: reply.Message, Kind: kindForStatus(status)}
+	if (status == http.StatusForbidden || status == http.StatusTooManyRequests) &&
+		strings.Contains(strings.ToLower(reply.Message), "rate limit") {
+		e.Kind = &ErrRateLimited{}
+	}
+	return e
+}
+
+// mapGoogleError handles the {"error": {"status": ..., "errors": [...]}}
+// bodies shared by Google APIs. Quota errors arrive as 403 with a
+// rateLimitExceeded reason.
+func mapGoogleError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+			Errors  []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+	json.Unmarshal(body, &reply)
+	e := &UpstreamError{Code: reply.Error.Status, Message: reply.Error.Message, Kind: kindForStatus(status)}
+	for _, detail := range reply.Error.Errors {
+		switch detail.Reason {
+		case "rateLimitExceeded", "userRateLimitExceeded":
+			e.Code, e.Kind = detail.Reason, &ErrRateLimited{}
+			return e
+		}
+	}
+	switch reply.Error.Status {
+	case "UNAUTHENTICATED", "PERMISSION_DENIED":
+		e.Kind = ErrInvalidCredentials
+	case "NOT_FOUND":
+		e.Kind = ErrNotFound
+	case "INVALID_ARGUMENT", "FAILED_PRECONDITION", "OUT_OF_RANGE":
+		e.Kind = ErrValidation
+	case "RESOURCE_EXHAUSTED":
+		e.Kind = &ErrRateLimited{}
+	}
+	return e
+}
+
+// mapStripeError handles Stripe's {"error": {"type": ..., "code": ...}}
+// bodies. An invalid API key comes back as a 401 invalid_request_error, so the
+// status is checked before the error type.
+func mapStripeError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		Error struct {
+			Type    string `json:"type"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	json.Unmarshal(body, &reply)
+	code := reply.Error.Code
+	if code == "" {
+		code = reply.Error.Type
+	}
+	e := &UpstreamError{Code: code, Message: reply.Error.Message, Kind: kindForStatus(status)}
+	switch {
+	case status == http.StatusUnauthorized, reply.Error.Type == "authentication_error":
+		e.Kind = ErrInvalidCredentials
+	case reply.Error.Type == "rate_limit_error":
+		e.Kind = &ErrRateLimited{}
+	case reply.Error.Code == "resource_missing":
+		e.Kind = ErrNotFound
+	case reply.Error.Type == "card_error", reply.Error.Type == "invalid_request_error":
+		e.Kind = ErrValidation
+	}
+	if reply.Error.Code == "charge_already_refunded" {
+		e.Cause = ErrStripeChargeAlreadyRefunded
+	}
+	return e
+}
+
+// mapMailchimpError handles Mailchimp's problem-detail bodies, whose title
+// names the error ("Member Exists", "Resource Not Found"...).
+func mapMailchimpError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+	}
+	json.Unmarshal(body, &reply)
+	e := &UpstreamError{Code: reply.Title, Message: reply.Detail, Kind: kindForStatus(status)}
+	if reply.Title == "API Key Invalid" {
+		e.Kind = ErrInvalidCredentials
+	}
+	return e
+}
+
+// mapZendeskError handles Zendesk's {"error": ..., "description": ...,
+// "details": {field: [{"error": ...}]}} bodies. A 422 rejecting external_id
+// as a DuplicateValue carries ErrZendeskDuplicateExternalID.
+func mapZendeskError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		Error       string `json:"error"`
+		Description string `json:"description"`
+		Details     map[string][]struct {
+			Error       string `json:"error"`
+			Description string `json:"description"`
+		} `json:"details"`
+	}
+	json.Unmarshal(body, &reply)
+	e := &UpstreamError{Code: reply.Error, Message: reply.Description, Kind: kindForStatus(status)}
+	for _, d := range reply.Details["external_id"] {
+		if d.Error == "DuplicateValue" {
+			e.Message = d.Description
+			e.Cause = ErrZendeskDuplicateExternalID
+		}
+	}
+	return e
+}
+
+// mapBoxError handles Box's {"type": "error", "code": ..., "message": ...}
+// bodies.
+func mapBoxError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	json.Unmarshal(body, &reply)
+	return &UpstreamError{Code: reply.Code, Message: reply.Message, Kind: kindForStatus(status)}
+}
+
+// mapJiraError handles Jira's {"errorMessages": [...], "errors": {field:
+// message}} bodies, folding both into the message so a rejected field is
+// named in the error.
+func mapJiraError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+	json.Unmarshal(body, &reply)
+	msgs := append([]string(nil), reply.ErrorMessages...)
+	fields := make([]string, 0, len(reply.Errors))
+	for field := range reply.Errors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		msgs = append(msgs, field+": "+reply.Errors[field])
+	}
+	return &UpstreamError{Message: strings.Join(msgs, "; "), Kind: kindForStatus(status)}
+}
+
+// mapClickUpError handles ClickUp's {"err": ..., "ECODE": ...} bodies. ClickUp
+// OAuth tokens are granted per workspace, so a 401 usually means the task is
+// in a workspace the user did not authorize; the message says so.
+func mapClickUpError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		Err   string `json:"err"`
+		ECode string `json:"ECODE"`
+	}
+	json.Unmarshal(body, &reply)
+	e := &UpstreamError{Code: reply.ECode, Message: reply.Err, Kind: kindForStatus(status)}
+	if status == http.StatusUnauthorized {
+		e.Message = strings.TrimPrefix(reply.Err+"; ", "; ") +
+			"the token is not authorized for this task's workspace; reconnect ClickUp and grant access to that workspace"
+	}
+	return e
+}
+
+// mapAsanaError handles Asana's {"errors": [{"message": ...}]} bodies.
+func mapAsanaError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	json.Unmarshal(body, &reply)
+	msgs := make([]string, 0, len(reply.Errors))
+	for _, e := range reply.Errors {
+		msgs = append(msgs, e.Message)
+	}
+	return &UpstreamError{Message: strings.Join(msgs, "; "), Kind: kindForStatus(status)}
+}
+
+// mapLinkedInError handles LinkedIn's {"message": ..., "serviceErrorCode":
+// ...} bodies. LinkedIn rejects repeated content with a 422 whose message
+// names the duplicate.
+func mapLinkedInError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		Message          string `json:"message"`
+		ServiceErrorCode int    `json:"serviceErrorCode"`
+	}
+	json.Unmarshal(body, &reply)
+	e := &UpstreamError{Message: reply.Message, Kind: kindForStatus(status)}
+	if reply.ServiceErrorCode != 0 {
+		e.Code = strconv.Itoa(reply.ServiceErrorCode)
+	}
+	if status == http.StatusUnprocessableEntity && strings.Contains(strings.ToLower(reply.Message), "duplicate") {
+		e.Cause = ErrLinkedInDuplicatePost
+	}
+	return e
+}
+
+// mapSendGridError handles SendGrid's {"errors": [{"field": ..., "message":
+// ...}]} bodies, naming the offending field where SendGrid gives one.
+func mapSendGridError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		Errors []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	json.Unmarshal(body, &reply)
+	msgs := make([]string, 0, len(reply.Errors))
+	for _, e := range reply.Errors {
+		if e.Field != "" {
+			msgs = append(msgs, e.Field+": "+e.Message)
+			continue
+		}
+		msgs = append(msgs, e.Message)
+	}
+	return &UpstreamError{Message: strings.Join(msgs, "; "), Kind: kindForStatus(status)}
+}
+
+// mapIntercomError handles Intercom's {"type": "error.list", "errors":
+// [{"code": ..., "message": ...}]} bodies. The first error is reported.
+func mapIntercomError(status int, body []byte) *UpstreamError {
+	var reply struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	json.Unmarshal(body, &reply)
+	e := &UpstreamError{Kind: kindForStatus(status)}
+	if len(reply.Errors) > 0 {
+		e.Code, e.Message = reply.Errors[0].Code, reply.Errors[0].Message
+	}
+	return e
+}
+
+// mapTwilioError handles Twilio's {"code": 21211, "message": ...} bodies.
+func mapTwilioError(status int, body []byte) *UpstreamError {
+	var reply twilioError
+	json.Unmarshal(body, &reply)
+	e := &UpstreamError{Message: reply.Message, Kind: kindForStatus(status)}
+	if reply.Code != 0 {
+		e.Code = strconv.Itoa(reply.Code)
+	}
+	return e
+}
+
+// mapNotionError handles Notion's {"code": "validation_error", "message":
+// ...} bodies. Notion reports a rejected token as code unauthorized.
+func mapNotionError(status int, body []byte) *UpstreamError {
+	var reply notionError
+	json.Unmarshal(body, &reply)
+	e := &UpstreamError{Code: reply.Code, Message: reply.Message, Kind: kindForStatus(status)}
+	if reply.Code == "unauthorized" {
+		e.Kind = ErrInvalidCredentials
+	}
+	return e
+}
+
+// mapZoomError handles Zoom's {"code": 124, "message": ...} bodies.
+func mapZoomError(status int, body []byte) *UpstreamError {
+	var reply zoomError
+	json.Unmarshal(body, &reply)
+	e := &UpstreamError{Message: reply.Message, Kind: kindForStatus(status)}
+	if reply.Code != 0 {
+		e.Code = strconv.Itoa(reply.Code)
+	}
+	return e
+}
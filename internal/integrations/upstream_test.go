@@ -0,0 +1,143 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// upstreamCase is a provider's real error response and the typed error it
+// must map to; a nil want means the error is left unclassified.
+type upstreamCase struct {
+	name   string
+	status int
+	body   string
+	want   error
+}
+
+func assertUpstreamKinds(t *testing.T, provider IntegrationType, cases []upstreamCase) {
+	t.Helper()
+	for _, tc := range cases {
+		err := MapUpstreamError(provider, tc.status, []byte(tc.body))
+		var upstream *UpstreamError
+		if !errors.As(err, &upstream) {
+			t.Fatalf("%s/%s: expected *UpstreamError, got %T", provider, tc.name, err)
+		}
+		if _, wantRL := tc.want.(*ErrRateLimited); wantRL {
+			var rl *ErrRateLimited
+			if !errors.As(err, &rl) || rl.Provider != string(provider) {
+				t.Errorf("%s/%s: expected ErrRateLimited for %s, got %v", provider, tc.name, provider, err)
+			}
+			continue
+		}
+		if tc.want == nil {
+			if upstream.Kind != nil {
+				t.Errorf("%s/%s: expected no typed error, got %v", provider, tc.name, upstream.Kind)
+			}
+			continue
+		}
+		if !errors.Is(err, tc.want) {
+			t.Errorf("%s/%s: expected %v, got %v", provider, tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestMapUpstreamError_Slack(t *testing.T) {
+	assertUpstreamKinds(t, IntegrationSlack, []upstreamCase{
+		{"invalid_auth", http.StatusOK, `{"ok":false,"error":"invalid_auth"}`, ErrInvalidCredentials},
+		{"token_revoked", http.StatusOK, `{"ok":false,"error":"token_revoked"}`, ErrInvalidCredentials},
+		{"channel_not_found", http.StatusOK, `{"ok":false,"error":"channel_not_found"}`, ErrNotFound},
+		{"no_text", http.StatusOK, `{"ok":false,"error":"no_text"}`, ErrValidation},
+		{"ratelimited", http.StatusOK, `{"ok":false,"error":"ratelimited"}`, &ErrRateLimited{}},
+		{"unknown", http.StatusOK, `{"ok":false,"error":"fatal_error"}`, nil},
+	})
+
+	// The Slack code itself stays matchable for callers that branch on it.
+	err := MapUpstreamError(IntegrationSlack, http.StatusOK, []byte(`{"ok":false,"error":"users_not_found"}`))
+	if !errors.Is(err, errSlackCode("users_not_found")) {
+		t.Errorf("Slack code lost: %v", err)
+	}
+}
+
+func TestMapUpstreamError_GitHub(t *testing.T) {
+	assertUpstreamKinds(t, IntegrationGitHub, []upstreamCase{
+		{"bad credentials", http.StatusUnauthorized, `{"message":"Bad credentials","documentation_url":"https://docs.github.com/rest"}`, ErrInvalidCredentials},
+		{"not found", http.StatusNotFound, `{"message":"Not Found","documentation_url":"https://docs.github.com/rest/repos/repos#get-a-repository"}`, ErrNotFound},
+		{"validation", http.StatusUnprocessableEntity, `{"message":"Validation Failed","errors":[{"resource":"Issue","code":"missing_field","field":"title"}]}`, ErrValidation},
+		{"rate limit as 403", http.StatusForbidden, `{"message":"API rate limit exceeded for user ID 1.","documentation_url":"https://docs.github.com/rest/overview/resources-in-the-rest-api#rate-limiting"}`, &ErrRateLimited{}},
+		{"forbidden", http.StatusForbidden, `{"message":"Resource not accessible by integration"}`, ErrInvalidCredentials},
+	})
+}
+
+func TestMapUpstreamError_Google(t *testing.T) {
+	assertUpstreamKinds(t, IntegrationGoogleDrive, []upstreamCase{
+		{"unauthenticated", http.StatusUnauthorized, `{"error":{"code":401,"message":"Request had invalid authentication credentials.","status":"UNAUTHENTICATED"}}`, ErrInvalidCredentials},
+		{"not found", http.StatusNotFound, `{"error":{"code":404,"message":"File not found: 1abc.","errors":[{"domain":"global","reason":"notFound","message":"File not found: 1abc."}]}}`, ErrNotFound},
+		{"invalid argument", http.StatusBadRequest, `{"error":{"code":400,"message":"Invalid Value","status":"INVALID_ARGUMENT"}}`, ErrValidation},
+		{"quota as 403", http.StatusForbidden, `{"error":{"code":403,"message":"User Rate Limit Exceeded","errors":[{"domain":"usageLimits","reason":"userRateLimitExceeded"}]}}`, &ErrRateLimited{}},
+		{"server", http.StatusInternalServerError, `{"error":{"code":500,"message":"Internal Error","status":"INTERNAL"}}`, ErrProviderUnavailable},
+	})
+	assertUpstreamKinds(t, IntegrationGmail, []upstreamCase{
+		{"permission denied", http.StatusForbidden, `{"error":{"code":403,"message":"Request had insufficient authentication scopes.","status":"PERMISSION_DENIED"}}`, ErrInvalidCredentials},
+	})
+}
+
+func TestMapUpstreamError_Stripe(t *testing.T) {
+	assertUpstreamKinds(t, IntegrationStripe, []upstreamCase{
+		{"authentication", http.StatusUnauthorized, `{"error":{"type":"invalid_request_error","message":"Invalid API Key provided: sk_test_****"}}`, ErrInvalidCredentials},
+		{"resource missing", http.StatusNotFound, `{"error":{"code":"resource_missing","doc_url":"https://stripe.com/docs/error-codes/resource-missing","message":"No such customer: 'cus_123'","param":"id","type":"invalid_request_error"}}`, ErrNotFound},
+		{"card declined", http.StatusPaymentRequired, `{"error":{"code":"card_declined","decline_code":"generic_decline","message":"Your card was declined.","type":"card_error"}}`, ErrValidation},
+		{"missing param", http.StatusBadRequest, `{"error":{"code":"parameter_missing","message":"Missing required param: amount.","param":"amount","type":"invalid_request_error"}}`, ErrValidation},
+		{"rate limit", http.StatusTooManyRequests, `{"error":{"message":"Too many requests made to the API too quickly","type":"rate_limit_error"}}`, &ErrRateLimited{}},
+	})
+}
+
+func TestMapUpstreamError_Mailchimp(t *testing.T) {
+	assertUpstreamKinds(t, IntegrationMailchimp, []upstreamCase{
+		{"api key invalid", http.StatusUnauthorized, `{"type":"https://mailchimp.com/developer/marketing/docs/errors/","title":"API Key Invalid","status":401,"detail":"Your API key may be invalid, or you've attempted to access the wrong datacenter."}`, ErrInvalidCredentials},
+		{"member not found", http.StatusNotFound, `{"title":"Resource Not Found","status":404,"detail":"The requested resource could not be found."}`, ErrNotFound},
+		{"forgotten email", http.StatusBadRequest, `{"title":"Forgotten Email Not Subscribed","status":400,"detail":"a@example.com was permanently deleted and cannot be re-imported."}`, ErrValidation},
+	})
+}
+
+func TestMapUpstreamError_UnmappedProviderUsesStatus(t *testing.T) {
+	assertUpstreamKinds(t, IntegrationTrello, []upstreamCase{
+		{"401", http.StatusUnauthorized, `invalid token`, ErrInvalidCredentials},
+		{"404", http.StatusNotFound, `model not found`, ErrNotFound},
+		{"409", http.StatusConflict, `conflict`, nil},
+		{"502", http.StatusBadGateway, `<html><body>Bad Gateway</body></html>`, ErrProviderUnavailable},
+	})
+}
+
+// badGatewayPage is the HTML error page a provider's load balancer serves.
+const badGatewayPage = "<html><head><title>502 Bad Gateway</title></head><body><center><h1>502 Bad Gateway</h1></center></body></html>"
+
+func TestProvider5xx_HTMLPageIsProviderUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(badGatewayPage))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	token := &Token{AccessToken: "t"}
+	_, ghErr := (&GitHubProvider{APIBaseURL: srv.URL}).Execute(ctx, token, "list_repos", nil)
+	_, slackErr := (&SlackProvider{APIBaseURL: srv.URL}).Execute(ctx, token, "lookup_user_by_email", map[string]interface{}{"email": "ada@example.com"})
+	for name, err := range map[string]error{"github": ghErr, "slack": slackErr} {
+		if !errors.Is(err, ErrProviderUnavailable) {
+			t.Errorf("%s: expected ErrProviderUnavailable, got %v", name, err)
+			continue
+		}
+		var upstream *UpstreamError
+		if !errors.As(err, &upstream) || upstream.Status != http.StatusBadGateway {
+			t.Errorf("%s: upstream status not recorded: %v", name, err)
+		}
+		if msg := err.Error(); strings.Contains(msg, "decode") || strings.Contains(msg, "<html>") {
+			t.Errorf("%s: error leaks the HTML body or a decode failure: %q", name, msg)
+		}
+	}
+}
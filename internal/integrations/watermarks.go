@@ -0,0 +1,88 @@
+package integrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WatermarkStore persists the high-water marks of change polls, such as
+// Airtable list_changes, so that a restart or another replica carries on
+// where the last poll stopped. Implementations must be safe for concurrent
+// use.
+type WatermarkStore interface {
+	// Get returns the mark stored under key, or the zero time.
+	Get(ctx context.Context, key string) (time.Time, error)
+	// Advance moves key's mark forward to at; it never moves a mark back.
+	Advance(ctx context.Context, key string, at time.Time) error
+}
+
+// MemoryWatermarkStore keeps marks in process. It is used when no database
+// is configured, where marks are lost on restart.
+type MemoryWatermarkStore struct {
+	mu    sync.Mutex
+	marks map[string]time.Time
+}
+
+// NewMemoryWatermarkStore creates an empty in-memory watermark store.
+func NewMemoryWatermarkStore() *MemoryWatermarkStore {
+	return &MemoryWatermarkStore{marks: make(map[string]time.Time)}
+}
+
+// Get returns the mark stored under key.
+func (s *MemoryWatermarkStore) Get(_ context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.marks[key], nil
+}
+
+// Advance moves key's mark forward to at.
+func (s *MemoryWatermarkStore) Advance(_ context.Context, key string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if at.After(s.marks[key]) {
+		s.marks[key] = at
+	}
+	return nil
+}
+
+// SQLWatermarkStore is the PostgreSQL-backed WatermarkStore, kept in the
+// poll_watermarks table.
+type SQLWatermarkStore struct {
+	db *sql.DB
+}
+
+// NewSQLWatermarkStore creates a watermark store backed by db.
+func NewSQLWatermarkStore(db *sql.DB) *SQLWatermarkStore {
+	return &SQLWatermarkStore{db: db}
+}
+
+// Get returns the mark stored under key.
+func (s *SQLWatermarkStore) Get(ctx context.Context, key string) (time.Time, error) {
+	var mark time.Time
+	err := s.db.QueryRowContext(ctx, `SELECT mark FROM poll_watermarks WHERE key = $1`, key).Scan(&mark)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("loading watermark: %w", err)
+	}
+	return mark, nil
+}
+
+// Advance moves key's mark forward to at in a single statement, so
+// concurrent polls cannot move it back.
+func (s *SQLWatermarkStore) Advance(ctx context.Context, key string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO poll_watermarks (key, mark, updated_at) VALUES ($1, $2, NOW())
+		ON CONFLICT (key) DO UPDATE
+		SET mark = GREATEST(poll_watermarks.mark, EXCLUDED.mark), updated_at = NOW()`,
+		key, at)
+	if err != nil {
+		return fmt.Errorf("saving watermark: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the forwarded body,
+// prefixed with "sha256=", when the provider has a signing secret.
+const WebhookSignatureHeader = "X-Signature-256"
+
+// ErrBlockedDestination is returned when a forward target resolves to an
+// address the provider is not permitted to reach.
+var ErrBlockedDestination = errors.New("destination address not allowed")
+
+// WebhookForwardProvider POSTs arbitrary JSON to an HTTP endpoint so workflows
+// can push data anywhere as a terminal step. It needs no OAuth connection.
+type WebhookForwardProvider struct {
+	// TargetURL is used when the action payload does not specify a url.
+	TargetURL string
+	// SigningSecret, when set, is used to HMAC-sign every forwarded body.
+	SigningSecret string
+	// AllowPrivateNetworks permits loopback, private and link-local targets.
+	// Leave false in production to prevent SSRF against internal services.
+	AllowPrivateNetworks bool
+
+	client *http.Client
+}
+
+func NewWebhookForwardProvider(targetURL, signingSecret string, allowPrivateNetworks bool) *WebhookForwardProvider {
+	p := &WebhookForwardProvider{
+		TargetURL:            targetURL,
+		SigningSecret:        signingSecret,
+		AllowPrivateNetworks: allowPrivateNetworks,
+	}
+	// Requests are bounded by the provider's ActionTimeout.
+	p.client = &http.Client{
+		Transport: newLoggingTransport(&http.Transport{
+			Proxy: nil,
+			DialContext: (&net.Dialer{
+				Timeout: 5 * time.Second,
+				// Checking the resolved address at dial time also covers
+				// hostnames and DNS rebinding, not just literal IPs.
+				Control: p.checkDialAddress,
+			}).DialContext,
+		}),
+		// Redirects could bounce the request to an internal address; the
+		// dial check still applies, but the downstream status is what callers
+		// asked for, so do not follow them.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return p
+}
+
+func (p *WebhookForwardProvider) Name() string { return string(IntegrationWebhookForward) }
+func (p *WebhookForwardProvider) GetAuthURL(state string) string {
+	// Webhook forwarding has no OAuth consent step.
+	return ""
+}
+func (p *WebhookForwardProvider) ExchangeCode(ctx context.Context, code string) (*Token, error) {
+	return nil, errors.New("webhook forwarding does not use oauth")
+}
+
+func (p *WebhookForwardProvider) ListActions() []ActionSpec {
+	return []ActionSpec{
+		{Name: "forward", Description: "POST a JSON payload to an HTTP endpoint", Fields: []ActionField{
+			{Name: "url", Type: FieldString},
+			{Name: "data", Type: FieldObject, Required: true},
+			{Name: "headers", Type: FieldObject},
+		}},
+	}
+}
+
+func (p *WebhookForwardProvider) Execute(ctx context.Context, token *Token, action string, payload map[string]interface{}) (interface{}, error) {
+	if action != "forward" {
+		return nil, unknownAction(p, action)
+	}
+
+	target := p.TargetURL
+	if raw, ok := payload["url"]; ok {
+		s, ok := raw.(string)
+		if !ok {
+			return nil, invalidField("field 'url' must be a string, got %T", raw)
+		}
+		target = s
+	}
+	if target == "" {
+		return nil, invalidField("missing 'url' field and no target URL configured")
+	}
+	if err := p.validateURL(target); err != nil {
+		return nil, err
+	}
+
+	data, ok := payload["data"]
+	if !ok {
+		return nil, invalidField("missing 'data' field")
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("encode data: %w", err)
+	}
+
+	ctx, cancel := withActionTimeout(ctx, p.Name())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	if headers, ok := payload["headers"].(map[string]interface{}); ok {
+		for k, v := range headers {
+			if s, ok := v.(string); ok {
+				req.Header.Set(k, s)
+			}
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.SigningSecret != "" {
+		req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookBody(p.SigningSecret, body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("forward webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+
+	status := "success"
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		status = "failed"
+	}
+	return map[string]interface{}{
+		"status":      status,
+		"status_code": resp.StatusCode,
+	}, nil
+}
+
+// SignWebhookBody returns the hex-encoded HMAC-SHA256 of body under secret.
+// Receivers recompute it to verify a forwarded request.
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateURL rejects non-HTTP schemes and literal IP targets that are not
+// allowed. Hostnames are checked again once resolved, in checkDialAddress.
+func (p *WebhookForwardProvider) validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url scheme must be http or https, got %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return errors.New("url must include a host")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !p.allowedIP(ip) {
+		return fmt.Errorf("%s: %w", u.Hostname(), ErrBlockedDestination)
+	}
+	return nil
+}
+
+// checkDialAddress is a net.Dialer Control hook that refuses connections to
+// disallowed addresses after DNS resolution.
+func (p *WebhookForwardProvider) checkDialAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !p.allowedIP(ip) {
+		return fmt.Errorf("%s: %w", host, ErrBlockedDestination)
+	}
+	return nil
+}
+
+// blockedPrefixes are the non-global ranges not covered by the net.IP
+// checks in allowedIP: shared and reserved IPv4 space, which is often routed
+// to internal services, and IPv6 ranges that embed an IPv4 address.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // TEST-NET-1
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // TEST-NET-2
+	netip.MustParsePrefix("203.0.113.0/24"),  // TEST-NET-3
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // discard-only
+	netip.MustParsePrefix("2001::/23"),       // IETF protocol assignments, incl. Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+}
+
+// allowedIP reports whether the provider may connect to ip.
+func (p *WebhookForwardProvider) allowedIP(ip net.IP) bool {
+	if p.AllowPrivateNetworks {
+		return true
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return false
+	}
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookForward_Success_SignsAndReturnsStatus(t *testing.T) {
+	var gotBody []byte
+	var gotSig, gotCustom string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSig = r.Header.Get(WebhookSignatureHeader)
+		gotCustom = r.Header.Get("X-Custom")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	// The test server listens on loopback, so private networks must be allowed.
+	p := NewWebhookForwardProvider(srv.URL, "s3cret", true)
+	res, err := p.Execute(context.Background(), nil, "forward", map[string]interface{}{
+		"data":    map[string]interface{}{"event": "signup"},
+		"headers": map[string]interface{}{"X-Custom": "yes"},
+	})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+
+	out := res.(map[string]interface{})
+	if out["status_code"] != http.StatusAccepted {
+		t.Errorf("expected downstream status 202, got %v", out["status_code"])
+	}
+	var sent map[string]interface{}
+	if err := json.Unmarshal(gotBody, &sent); err != nil || sent["event"] != "signup" {
+		t.Errorf("unexpected forwarded body %s", gotBody)
+	}
+	if gotSig != "sha256="+SignWebhookBody("s3cret", gotBody) {
+		t.Errorf("signature mismatch: %q", gotSig)
+	}
+	if gotCustom != "yes" {
+		t.Errorf("custom header not forwarded, got %q", gotCustom)
+	}
+}
+
+func TestWebhookForward_InternalURL_Blocked(t *testing.T) {
+	hit := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer srv.Close()
+
+	p := NewWebhookForwardProvider("", "", false)
+	for _, target := range []string{srv.URL, "http://169.254.169.254/latest/meta-data", "http://localhost:1/"} {
+		_, err := p.Execute(context.Background(), nil, "forward", map[string]interface{}{
+			"url":  target,
+			"data": map[string]interface{}{},
+		})
+		if !errors.Is(err, ErrBlockedDestination) {
+			t.Errorf("%s: expected ErrBlockedDestination, got %v", target, err)
+		}
+	}
+	if hit {
+		t.Error("blocked request must not reach the internal server")
+	}
+}
+
+func TestWebhookForward_AllowedIP(t *testing.T) {
+	p := NewWebhookForwardProvider("", "", false)
+	for ip, want := range map[string]bool{
+		"93.184.216.34":     true,
+		"2606:4700::1111":   true,
+		"100.64.0.1":        false,
+		"100.127.255.254":   false,
+		"::ffff:100.64.0.1": false,
+		"198.18.0.1":        false,
+		"240.0.0.1":         false,
+		"0.1.2.3":           false,
+		"64:ff9b::a00:1":    false,
+		"2002:a00:1::":      false,
+		"fd00::1":           false,
+	} {
+		if got := p.allowedIP(net.ParseIP(ip)); got != want {
+			t.Errorf("allowedIP(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
+
+func TestWebhookForward_MissingFields_AreInvalid(t *testing.T) {
+	p := NewWebhookForwardProvider("", "", false)
+	for name, payload := range map[string]map[string]interface{}{
+		"url":  {"data": map[string]interface{}{}},
+		"data": {"url": "https://example.com/hook"},
+	} {
+		if _, err := p.Execute(context.Background(), nil, "forward", payload); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("missing %s: error = %v, want ErrInvalidField", name, err)
+		}
+	}
+}
+
+func TestWebhookForward_RejectsNonHTTPScheme(t *testing.T) {
+	p := NewWebhookForwardProvider("", "", false)
+	_, err := p.Execute(context.Background(), nil, "forward", map[string]interface{}{
+		"url":  "file:///etc/passwd",
+		"data": map[string]interface{}{},
+	})
+	if err == nil {
+		t.Error("expected error for non-http scheme")
+	}
+}
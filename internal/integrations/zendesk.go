@@ -0,0 +1,123 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// zendeskHTTPClient is shared by Zendesk API calls. Requests are bounded by
+// the provider's ActionTimeout.
+var zendeskHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// ErrZendeskDuplicateExternalID is returned when creating a user or
+// organization whose external_id another record already has. The error also
+// matches ErrValidation.
+var ErrZendeskDuplicateExternalID = errors.New("zendesk external_id already in use")
+
+// zendeskSearchTypes are the record types the search action can narrow to.
+var zendeskSearchTypes = map[string]bool{"user": true, "organization": true, "ticket": true, "group": true}
+
+// zendeskBase returns the API root for token: the APIBaseURL override, or the
+// Zendesk subdomain the token was issued for. The instance must be an https
+// *.zendesk.com host, since the access token is sent to it.
+func (p *ZendeskProvider) zendeskBase(token *Token) (string, error) {
+	if p.APIBaseURL != "" {
+		return p.APIBaseURL, nil
+	}
+	if token.InstanceURL == "" {
+		return "", errors.New("zendesk token has no instance_url")
+	}
+	u, err := url.Parse(token.InstanceURL)
+	if err != nil || u.Scheme != "https" || !strings.HasSuffix(strings.ToLower(u.Hostname()), ".zendesk.com") {
+		return "", fmt.Errorf("zendesk instance_url %q is not an https Zendesk host", token.InstanceURL)
+	}
+	return strings.TrimSuffix(token.InstanceURL, "/"), nil
+}
+
+// createUser creates an end user, the requester of support tickets.
+// organizationID, when non-zero, places the user in that organization.
+func (p *ZendeskProvider) createUser(ctx context.Context, token *Token, name, email, externalID string, organizationID int64) (map[string]interface{}, error) {
+	user := map[string]interface{}{"name": name, "role": "end-user"}
+	if email != "" {
+		user["email"] = email
+	}
+	if externalID != "" {
+		user["external_id"] = externalID
+	}
+	if organizationID != 0 {
+		user["organization_id"] = organizationID
+	}
+	var created struct {
+		User struct {
+			ID int64 `json:"id"`
+		} `json:"user"`
+	}
+	if err := p.zendeskCall(ctx, token, http.MethodPost, "/api/v2/users.json", map[string]interface{}{"user": user}, &created); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "user_id": created.User.ID}, nil
+}
+
+// createOrganization creates an organization. Users whose email domain is
+// in domainNames are added to it automatically by Zendesk.
+func (p *ZendeskProvider) createOrganization(ctx context.Context, token *Token, name, externalID string, domainNames []string) (map[string]interface{}, error) {
+	org := map[string]interface{}{"name": name}
+	if externalID != "" {
+		org["external_id"] = externalID
+	}
+	if len(domainNames) > 0 {
+		org["domain_names"] = domainNames
+	}
+	var created struct {
+		Organization struct {
+			ID int64 `json:"id"`
+		} `json:"organization"`
+	}
+	if err := p.zendeskCall(ctx, token, http.MethodPost, "/api/v2/organizations.json", map[string]interface{}{"organization": org}, &created); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"status": "success", "organization_id": created.Organization.ID}, nil
+}
+
+// search runs a query through the search API, narrowed to recordType when it
+// is set. It returns one page of results; next_page is the page number to
+// ask for next, or absent on the last page.
+func (p *ZendeskProvider) search(ctx context.Context, token *Token, query, recordType string, page int) (map[string]interface{}, error) {
+	if recordType != "" {
+		query = "type:" + recordType + " " + query
+	}
+	params := url.Values{"query": {query}}
+	if page > 1 {
+		params.Set("page", fmt.Sprint(page))
+	}
+	var found struct {
+		Results  []map[string]interface{} `json:"results"`
+		Count    int                      `json:"count"`
+		NextPage string                   `json:"next_page"`
+	}
+	if err := p.zendeskCall(ctx, token, http.MethodGet, "/api/v2/search.json?"+params.Encode(), nil, &found); err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{"results": found.Results, "count": found.Count}
+	if found.NextPage != "" {
+		if page < 1 {
+			page = 1
+		}
+		result["next_page"] = page + 1
+	}
+	return result, nil
+}
+
+// zendeskCall sends a request to the Zendesk API, with body encoded as JSON
+// when it is non-nil, and decodes the reply into out.
+func (p *ZendeskProvider) zendeskCall(ctx context.Context, token *Token, method, path string, body, out interface{}) error {
+	base, err := p.zendeskBase(token)
+	if err != nil {
+		return err
+	}
+	return doJSON(ctx, zendeskHTTPClient, IntegrationZendesk, method, base+path, token, body, out)
+}
@@ -0,0 +1,91 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newZendeskServer answers POST /api/v2/users.json with status and reply,
+// recording the decoded request body.
+func newZendeskServer(t *testing.T, status int, reply string, body *map[string]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer zd-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v2/users.json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(body)
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestZendesk_CreateUser(t *testing.T) {
+	var body map[string]map[string]interface{}
+	srv := newZendeskServer(t, http.StatusCreated, `{"user":{"id":9001,"name":"Ada Lovelace"}}`, &body)
+	p := &ZendeskProvider{APIBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "zd-token"}, "create_user", map[string]interface{}{
+		"name": "Ada Lovelace", "email": "ada@example.com", "external_id": "crm-42", "organization_id": float64(77),
+	})
+	if err != nil {
+		t.Fatalf("create_user: %v", err)
+	}
+	if got := res.(map[string]interface{})["user_id"]; got != int64(9001) {
+		t.Errorf("user_id = %v, want 9001", got)
+	}
+	user := body["user"]
+	if user["name"] != "Ada Lovelace" || user["email"] != "ada@example.com" || user["external_id"] != "crm-42" ||
+		user["organization_id"] != float64(77) || user["role"] != "end-user" {
+		t.Errorf("unexpected user %v", user)
+	}
+}
+
+func TestZendesk_CreateUserDuplicateExternalID(t *testing.T) {
+	var body map[string]map[string]interface{}
+	srv := newZendeskServer(t, http.StatusUnprocessableEntity, `{
+		"error": "RecordInvalid",
+		"description": "Record validation errors",
+		"details": {"external_id": [{"error": "DuplicateValue", "description": "External ID: crm-42 is already being used by another user"}]}
+	}`, &body)
+	p := &ZendeskProvider{APIBaseURL: srv.URL}
+
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "zd-token"}, "create_user", map[string]interface{}{
+		"name": "Ada Lovelace", "external_id": "crm-42",
+	})
+	if !errors.Is(err, ErrZendeskDuplicateExternalID) {
+		t.Errorf("expected ErrZendeskDuplicateExternalID, got %v", err)
+	}
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("expected ErrValidation, got %v", err)
+	}
+}
+
+func TestZendesk_NoInstanceURL(t *testing.T) {
+	p := &ZendeskProvider{}
+	_, err := p.Execute(context.Background(), &Token{AccessToken: "zd-token"}, "create_organization", map[string]interface{}{"name": "Acme"})
+	if err == nil {
+		t.Fatal("expected an error without an instance URL")
+	}
+}
+
+func TestZendesk_InstanceURLMustBeZendesk(t *testing.T) {
+	p := &ZendeskProvider{}
+	for _, instance := range []string{"http://acme.zendesk.com", "https://evil.example.com", "https://acme.zendesk.com.evil.example", "https://zendesk.com"} {
+		if _, err := p.zendeskBase(&Token{InstanceURL: instance}); err == nil {
+			t.Errorf("instance_url %q accepted", instance)
+		}
+	}
+	if base, err := p.zendeskBase(&Token{InstanceURL: "https://acme.zendesk.com/"}); err != nil || base != "https://acme.zendesk.com" {
+		t.Errorf("zendeskBase = %q, %v", base, err)
+	}
+}
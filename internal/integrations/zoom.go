@@ -0,0 +1,111 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultZoomAPIBaseURL is the Zoom API root used when a ZoomProvider has no
+// APIBaseURL override.
+const defaultZoomAPIBaseURL = "https://api.zoom.us"
+
+// zoomHTTPClient is shared by Zoom API calls. Requests are bounded by the
+// provider's ActionTimeout.
+var zoomHTTPClient = &http.Client{Transport: newLoggingTransport(nil)}
+
+// zoomError is the body Zoom returns for failed requests.
+type zoomError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// zoomGet sends a GET to the Zoom API and decodes the reply into out.
+func (p *ZoomProvider) zoomGet(ctx context.Context, token *Token, path string, query url.Values, out interface{}) error {
+	base := p.APIBaseURL
+	if base == "" {
+		base = defaultZoomAPIBaseURL
+	}
+	return doJSON(ctx, zoomHTTPClient, IntegrationZoom, http.MethodGet, base+path+"?"+query.Encode(), token, nil, out)
+}
+
+// zoomPageQuery returns the page_size and next_page_token query parameters.
+func zoomPageQuery(pageToken string, pageSize int) url.Values {
+	query := url.Values{"page_size": {strconv.Itoa(pageSize)}}
+	if pageToken != "" {
+		query.Set("next_page_token", pageToken)
+	}
+	return query
+}
+
+// zoomRecordingFile is one file of a cloud recording.
+type zoomRecordingFile struct {
+	ID            string `json:"id"`
+	FileType      string `json:"file_type"`
+	RecordingType string `json:"recording_type"`
+	FileSize      int64  `json:"file_size"`
+	DownloadURL   string `json:"download_url"`
+}
+
+// zoomRecording is a meeting's cloud recording as returned by list_recordings.
+type zoomRecording struct {
+	MeetingID int64               `json:"id"`
+	UUID      string              `json:"uuid"`
+	Topic     string              `json:"topic"`
+	StartTime string              `json:"start_time"`
+	Files     []zoomRecordingFile `json:"recording_files"`
+}
+
+// listRecordings returns one page of the user's cloud recordings, optionally
+// limited to meetings between from and to (yyyy-mm-dd).
+func (p *ZoomProvider) listRecordings(ctx context.Context, token *Token, from, to, pageToken string, pageSize int) (*ListPage, error) {
+	query := zoomPageQuery(pageToken, pageSize)
+	if from != "" {
+		query.Set("from", from)
+	}
+	if to != "" {
+		query.Set("to", to)
+	}
+	var result struct {
+		Meetings      []zoomRecording `json:"meetings"`
+		NextPageToken string          `json:"next_page_token"`
+	}
+	if err := p.zoomGet(ctx, token, "/v2/users/me/recordings", query, &result); err != nil {
+		return nil, err
+	}
+	return newListPage(result.Meetings, result.NextPageToken), nil
+}
+
+// zoomParticipant is a past meeting participant as returned by
+// list_participants.
+type zoomParticipant struct {
+	Name      string `json:"name"`
+	Email     string `json:"user_email"`
+	JoinTime  string `json:"join_time"`
+	LeaveTime string `json:"leave_time"`
+	Duration  int    `json:"duration"`
+}
+
+// zoomMeetingPath escapes a meeting ID or UUID for a path. Zoom requires
+// UUIDs that begin with "/" or contain "//" to be encoded twice.
+func zoomMeetingPath(id string) string {
+	if strings.HasPrefix(id, "/") || strings.Contains(id, "//") {
+		return url.PathEscape(url.PathEscape(id))
+	}
+	return url.PathEscape(id)
+}
+
+// listParticipants returns one page of the participants of a past meeting.
+func (p *ZoomProvider) listParticipants(ctx context.Context, token *Token, meetingID, pageToken string, pageSize int) (*ListPage, error) {
+	var result struct {
+		Participants  []zoomParticipant `json:"participants"`
+		NextPageToken string            `json:"next_page_token"`
+	}
+	path := "/v2/report/meetings/" + zoomMeetingPath(meetingID) + "/participants"
+	if err := p.zoomGet(ctx, token, path, zoomPageQuery(pageToken, pageSize), &result); err != nil {
+		return nil, err
+	}
+	return newListPage(result.Participants, result.NextPageToken), nil
+}
@@ -0,0 +1,76 @@
+package integrations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestZoom_ListRecordings_Paginated(t *testing.T) {
+	var tokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/users/me/recordings" || r.Header.Get("Authorization") != "Bearer zoom-test" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.URL.Query().Get("from") != "2024-01-01" {
+			t.Errorf("from not forwarded: %s", r.URL.RawQuery)
+		}
+		next := r.URL.Query().Get("next_page_token")
+		tokens = append(tokens, next)
+		if next == "" {
+			w.Write([]byte(`{"next_page_token":"tok2","meetings":[{"id":111,"uuid":"u1","topic":"Standup","recording_files":[{"id":"r1","file_type":"MP4","download_url":"https://zoom.us/rec/download/r1"}]}]}`))
+			return
+		}
+		w.Write([]byte(`{"next_page_token":"","meetings":[{"id":222,"uuid":"u2","topic":"Retro","recording_files":[{"id":"r2","file_type":"M4A","download_url":"https://zoom.us/rec/download/r2"}]}]}`))
+	}))
+	defer srv.Close()
+
+	p := &ZoomProvider{APIBaseURL: srv.URL}
+	token := &Token{AccessToken: "zoom-test"}
+	payload := map[string]interface{}{"from": "2024-01-01"}
+	res, err := p.Execute(context.Background(), token, "list_recordings", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	page := res.(*ListPage)
+	recs := page.Items.([]zoomRecording)
+	if !page.HasMore || page.NextPageToken != "tok2" || len(recs) != 1 || recs[0].Files[0].DownloadURL != "https://zoom.us/rec/download/r1" {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+
+	payload["page_token"] = page.NextPageToken
+	res, err = p.Execute(context.Background(), token, "list_recordings", payload)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	page = res.(*ListPage)
+	recs = page.Items.([]zoomRecording)
+	if page.HasMore || len(recs) != 1 || recs[0].Topic != "Retro" {
+		t.Errorf("unexpected last page %+v", page)
+	}
+	if !reflect.DeepEqual(tokens, []string{"", "tok2"}) {
+		t.Errorf("unexpected page tokens %v", tokens)
+	}
+}
+
+func TestZoom_ListParticipants_Emails(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/v2/report/meetings/%252Fabc==/participants" {
+			t.Errorf("UUID not double-encoded: %s", r.URL.EscapedPath())
+		}
+		w.Write([]byte(`{"participants":[{"name":"Ada","user_email":"ada@example.com","duration":1800}]}`))
+	}))
+	defer srv.Close()
+
+	p := &ZoomProvider{APIBaseURL: srv.URL}
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "zoom-test"}, "list_participants", map[string]interface{}{"meeting_id": "/abc=="})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	page := res.(*ListPage)
+	if people := page.Items.([]zoomParticipant); page.HasMore || len(people) != 1 || people[0].Email != "ada@example.com" {
+		t.Errorf("unexpected participants %+v", page)
+	}
+}
@@ -0,0 +1,167 @@
+// Package jobs runs integration actions asynchronously. A job is persisted
+// when it is enqueued and executed later by a pool of in-process workers, so
+// the caller gets a job ID at once and polls for the result. Running jobs are
+// leased like outbox events: a job whose worker dies with the process is
+// picked up again once its lease expires.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status is the lifecycle state of a job.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// ErrNotFound is returned when a job does not exist.
+var ErrNotFound = errors.New("job not found")
+
+// Job is a unit of asynchronous work and, once finished, its outcome.
+type Job struct {
+	ID          uuid.UUID       `json:"id"`
+	UserID      string          `json:"user_id"`
+	WorkspaceID string          `json:"workspace_id"`
+	Request     json.RawMessage `json:"request"`
+	Status      Status          `json:"status"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	Attempts    int             `json:"attempts"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// Done reports whether the job has finished, successfully or not.
+func (j *Job) Done() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// Store persists jobs for the queue.
+type Store interface {
+	// Create saves a new queued job.
+	Create(ctx context.Context, job *Job) error
+	// Get returns the job with id, or ErrNotFound.
+	Get(ctx context.Context, id uuid.UUID) (*Job, error)
+	// Claim leases up to limit jobs that are queued, or running with an
+	// expired lease, and marks them running.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Job, error)
+	// Finish records a job's outcome. A non-empty errMsg marks it failed.
+	Finish(ctx context.Context, id uuid.UUID, result json.RawMessage, errMsg string) error
+}
+
+// newJob validates and encodes request into a queued job.
+func newJob(userID, workspaceID string, request interface{}) (*Job, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("encode job request: %w", err)
+	}
+	now := time.Now()
+	return &Job{
+		ID:          uuid.New(),
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Request:     data,
+		Status:      StatusQueued,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// memoryEntry is a job plus its lease in a MemoryStore.
+type memoryEntry struct {
+	job       Job
+	available time.Time // not claimable before this time
+}
+
+// MemoryStore keeps jobs in process. It is used when no database is
+// configured (development, tests and OFFLINE mode); jobs do not survive a
+// restart.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]*memoryEntry
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory job store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[uuid.UUID]*memoryEntry), now: time.Now}
+}
+
+// Create saves job, immediately claimable.
+func (s *MemoryStore) Create(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	s.entries[job.ID] = &memoryEntry{job: *job, available: s.now()}
+	s.mu.Unlock()
+	return nil
+}
+
+// Get returns a copy of the job with id.
+func (s *MemoryStore) Get(_ context.Context, id uuid.UUID) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	job := entry.job
+	return &job, nil
+}
+
+// Claim returns claimable jobs oldest first and leases them.
+func (s *MemoryStore) Claim(_ context.Context, limit int, lease time.Duration) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var due []*memoryEntry
+	for _, entry := range s.entries {
+		if !entry.job.Done() && !entry.available.After(now) {
+			due = append(due, entry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].job.CreatedAt.Before(due[j].job.CreatedAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]Job, 0, len(due))
+	for _, entry := range due {
+		entry.available = now.Add(lease)
+		entry.job.Status = StatusRunning
+		entry.job.Attempts++
+		entry.job.UpdatedAt = now
+		claimed = append(claimed, entry.job)
+	}
+	return claimed, nil
+}
+
+// Finish records the outcome of id.
+func (s *MemoryStore) Finish(_ context.Context, id uuid.UUID, result json.RawMessage, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	entry.job.Status = StatusSucceeded
+	if errMsg != "" {
+		entry.job.Status = StatusFailed
+	}
+	entry.job.Result = result
+	entry.job.Error = errMsg
+	entry.job.UpdatedAt = s.now()
+	return nil
+}
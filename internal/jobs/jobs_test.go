@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a controllable time source for MemoryStore.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestQueue(run Runner) (*Queue, *MemoryStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.now
+	return NewQueue(store, run), store, clock
+}
+
+func TestEnqueue_ReturnsQueuedJob(t *testing.T) {
+	q, _, _ := newTestQueue(nil)
+	ctx := context.Background()
+	job, err := q.Enqueue(ctx, "user-1", "ws-a", map[string]string{"action": "send_message"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	got, err := q.Get(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != StatusQueued || got.UserID != "user-1" || got.WorkspaceID != "ws-a" {
+		t.Errorf("job = %+v", got)
+	}
+	if string(got.Request) != `{"action":"send_message"}` {
+		t.Errorf("Request = %s", got.Request)
+	}
+}
+
+func TestRunOnce_RecordsResultAndError(t *testing.T) {
+	q, _, _ := newTestQueue(func(_ context.Context, job Job) (interface{}, error) {
+		if job.UserID == "bad" {
+			return nil, errors.New("provider down")
+		}
+		return map[string]bool{"ok": true}, nil
+	})
+	ctx := context.Background()
+	good, _ := q.Enqueue(ctx, "good", "", nil)
+	bad, _ := q.Enqueue(ctx, "bad", "", nil)
+
+	for i := 0; i < 2; i++ {
+		if ran, err := q.RunOnce(ctx); !ran || err != nil {
+			t.Fatalf("RunOnce = %v, %v; want true, nil", ran, err)
+		}
+	}
+	if ran, _ := q.RunOnce(ctx); ran {
+		t.Error("RunOnce ran a job with none queued")
+	}
+
+	got, _ := q.Get(ctx, good.ID)
+	if got.Status != StatusSucceeded || string(got.Result) != `{"ok":true}` {
+		t.Errorf("good job = %+v", got)
+	}
+	got, _ = q.Get(ctx, bad.ID)
+	if got.Status != StatusFailed || got.Error != "provider down" {
+		t.Errorf("bad job = %+v", got)
+	}
+}
+
+func TestClaim_ReclaimsAfterLeaseExpires(t *testing.T) {
+	q, store, clock := newTestQueue(nil)
+	ctx := context.Background()
+	job, _ := q.Enqueue(ctx, "user-1", "", nil)
+
+	// Claim without finishing, as if the worker died mid-run.
+	if claimed, _ := store.Claim(ctx, 1, time.Minute); len(claimed) != 1 {
+		t.Fatalf("claimed %d jobs, want 1", len(claimed))
+	}
+	if claimed, _ := store.Claim(ctx, 1, time.Minute); len(claimed) != 0 {
+		t.Fatalf("leased job claimed again before expiry")
+	}
+
+	clock.t = clock.t.Add(2 * time.Minute)
+	claimed, _ := store.Claim(ctx, 1, time.Minute)
+	if len(claimed) != 1 || claimed[0].ID != job.ID || claimed[0].Attempts != 2 {
+		t.Fatalf("claimed %+v after lease expiry, want the job on attempt 2", claimed)
+	}
+}
+
+func TestRun_CompletesEnqueuedJob(t *testing.T) {
+	q, _, _ := newTestQueue(func(context.Context, Job) (interface{}, error) { return "done", nil })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx, time.Hour)
+
+	job, err := q.Enqueue(ctx, "user-1", "", nil)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, _ := q.Get(ctx, job.ID)
+		if got.Done() {
+			if got.Status != StatusSucceeded {
+				t.Fatalf("job = %+v", got)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("enqueued job was not run by the workers")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Runner executes one job and returns its result, which must encode to JSON.
+type Runner func(ctx context.Context, job Job) (interface{}, error)
+
+// Queue hands persisted jobs to a pool of workers.
+type Queue struct {
+	store Store
+	run   Runner
+	// Workers is the number of jobs executed concurrently by Run.
+	Workers int
+	// Lease is how long a claimed job is hidden from other workers. A job
+	// whose worker dies mid-run is run again once it expires, so it should
+	// comfortably exceed the slowest action.
+	Lease time.Duration
+
+	wake chan struct{}
+}
+
+// NewQueue creates a queue that executes jobs from store with run.
+func NewQueue(store Store, run Runner) *Queue {
+	return &Queue{
+		store:   store,
+		run:     run,
+		Workers: 4,
+		Lease:   10 * time.Minute,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Enqueue persists request as a new job owned by userID in workspaceID and
+// wakes an idle worker.
+func (q *Queue) Enqueue(ctx context.Context, userID, workspaceID string, request interface{}) (*Job, error) {
+	job, err := newJob(userID, workspaceID, request)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.store.Create(ctx, job); err != nil {
+		return nil, err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Get returns the job with id, or ErrNotFound.
+func (q *Queue) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	return q.store.Get(ctx, id)
+}
+
+// RunOnce claims and executes a single job, reporting whether one was due.
+func (q *Queue) RunOnce(ctx context.Context) (bool, error) {
+	claimed, err := q.store.Claim(ctx, 1, q.Lease)
+	if err != nil || len(claimed) == 0 {
+		return false, err
+	}
+	job := claimed[0]
+
+	var result json.RawMessage
+	var errMsg string
+	out, err := q.run(ctx, job)
+	if err == nil {
+		result, err = json.Marshal(out)
+		if err != nil {
+			err = fmt.Errorf("encode job result: %w", err)
+		}
+	}
+	if err != nil {
+		errMsg = err.Error()
+	}
+	// If this fails the lease expires and the job runs again.
+	if err := q.store.Finish(ctx, job.ID, result, errMsg); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// Run starts Workers workers that execute jobs as they are enqueued, polling
+// the store every interval for jobs left by a previous process, until ctx is
+// cancelled. Jobs already running when ctx is cancelled are run to
+// completion before Run returns.
+func (q *Queue) Run(ctx context.Context, interval time.Duration) {
+	var wg sync.WaitGroup
+	for i := 0; i < q.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, interval)
+		}()
+	}
+	wg.Wait()
+}
+
+// work runs jobs back to back while any are due, then sleeps until the next
+// tick or enqueue.
+func (q *Queue) work(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for ctx.Err() == nil {
+		ran, err := q.RunOnce(context.WithoutCancel(ctx))
+		if err != nil {
+			log.Printf("Job queue: %v", err)
+		}
+		if ran && ctx.Err() == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-q.wake:
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrSecretNotFound is returned by SecretStore.Take when nothing is stored
+// under a reference, because it was never put, already taken or expired.
+var ErrSecretNotFound = errors.New("job secret not found")
+
+// SecretStore holds secrets a queued job needs, such as an inline provider
+// token, outside the job store so they are never persisted with the job's
+// request. Each secret expires after its TTL and is returned at most once.
+// Implementations must be safe for concurrent use.
+type SecretStore interface {
+	Put(ctx context.Context, ref string, secret []byte, ttl time.Duration) error
+	Take(ctx context.Context, ref string) ([]byte, error)
+}
+
+type memorySecret struct {
+	value   []byte
+	expires time.Time
+}
+
+// MemorySecretStore keeps secrets in process. It is used without Redis, where
+// a job's secret is lost if the replica that queued it restarts.
+type MemorySecretStore struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	secrets map[string]memorySecret
+}
+
+// NewMemorySecretStore creates an empty in-process secret store.
+func NewMemorySecretStore() *MemorySecretStore {
+	return &MemorySecretStore{now: time.Now, secrets: make(map[string]memorySecret)}
+}
+
+// Put stores secret under ref for ttl, dropping any secrets that expired.
+func (s *MemorySecretStore) Put(_ context.Context, ref string, secret []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for k, e := range s.secrets {
+		if now.After(e.expires) {
+			delete(s.secrets, k)
+		}
+	}
+	s.secrets[ref] = memorySecret{value: append([]byte(nil), secret...), expires: now.Add(ttl)}
+	return nil
+}
+
+// Take removes and returns the secret stored under ref.
+func (s *MemorySecretStore) Take(_ context.Context, ref string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.secrets[ref]
+	delete(s.secrets, ref)
+	if !ok || s.now().After(e.expires) {
+		return nil, ErrSecretNotFound
+	}
+	return e.value, nil
+}
+
+// redisSecretPrefix namespaces job secrets in Redis.
+const redisSecretPrefix = "job_secret:"
+
+// RedisSecretStore keeps secrets in Redis, so a job queued on one replica can
+// run on another or after a restart. Redis expires them after their TTL.
+type RedisSecretStore struct {
+	client redis.Cmdable
+}
+
+// NewRedisSecretStore creates a secret store backed by client.
+func NewRedisSecretStore(client redis.Cmdable) *RedisSecretStore {
+	return &RedisSecretStore{client: client}
+}
+
+// Put stores secret under ref for ttl.
+func (s *RedisSecretStore) Put(ctx context.Context, ref string, secret []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, redisSecretPrefix+ref, secret, ttl).Err()
+}
+
+// Take atomically removes and returns the secret stored under ref.
+func (s *RedisSecretStore) Take(ctx context.Context, ref string) ([]byte, error) {
+	secret, err := s.client.GetDel(ctx, redisSecretPrefix+ref).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrSecretNotFound
+	}
+	return secret, err
+}
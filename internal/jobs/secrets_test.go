@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemorySecretStore_TakeOnceAndExpire(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	s := NewMemorySecretStore()
+	s.now = clock.now
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "ref-1", []byte("xoxb-inline"), time.Minute); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got, err := s.Take(ctx, "ref-1"); err != nil || string(got) != "xoxb-inline" {
+		t.Fatalf("Take = %q, %v", got, err)
+	}
+	if _, err := s.Take(ctx, "ref-1"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("second Take err = %v, want ErrSecretNotFound", err)
+	}
+
+	s.Put(ctx, "ref-2", []byte("stale"), time.Minute)
+	clock.t = clock.t.Add(2 * time.Minute)
+	if _, err := s.Take(ctx, "ref-2"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expired Take err = %v, want ErrSecretNotFound", err)
+	}
+
+	// Expired secrets nobody takes are dropped on the next Put.
+	s.Put(ctx, "ref-3", []byte("abandoned"), time.Minute)
+	clock.t = clock.t.Add(2 * time.Minute)
+	s.Put(ctx, "ref-4", []byte("fresh"), time.Minute)
+	if _, ok := s.secrets["ref-3"]; ok || len(s.secrets) != 1 {
+		t.Errorf("secrets = %v, want only ref-4", s.secrets)
+	}
+}
@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SQLStore is the PostgreSQL-backed job store, kept in the jobs table.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates a job store backed by db.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// Create inserts job as queued.
+func (s *SQLStore) Create(ctx context.Context, job *Job) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO jobs (id, user_id, workspace_id, request, status, created_at, updated_at, available_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, $6)`,
+		job.ID, job.UserID, job.WorkspaceID, []byte(job.Request), job.Status, job.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("create job: %w", err)
+	}
+	return nil
+}
+
+// Get loads the job with id.
+func (s *SQLStore) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, workspace_id, request, status, result, COALESCE(error, ''), attempts, created_at, updated_at
+		FROM jobs WHERE id = $1`, id)
+	job, err := scanJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get job: %w", err)
+	}
+	return job, nil
+}
+
+// Claim leases claimable jobs by pushing their available_at past the lease.
+// SKIP LOCKED lets several workers claim concurrently without overlap.
+func (s *SQLStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]Job, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE jobs SET status = 'running', attempts = attempts + 1, updated_at = NOW(),
+			available_at = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE status IN ('queued', 'running') AND available_at <= NOW()
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, workspace_id, request, status, result, COALESCE(error, ''), attempts, created_at, updated_at`,
+		limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan job: %w", err)
+		}
+		jobs = append(jobs, *job)
+	}
+	return jobs, rows.Err()
+}
+
+// Finish records the outcome of id.
+func (s *SQLStore) Finish(ctx context.Context, id uuid.UUID, result json.RawMessage, errMsg string) error {
+	status := StatusSucceeded
+	if errMsg != "" {
+		status = StatusFailed
+	}
+	var resultArg interface{}
+	if result != nil {
+		resultArg = []byte(result)
+	}
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE jobs SET status = $2, result = $3, error = NULLIF($4, ''), updated_at = NOW() WHERE id = $1`,
+		id, status, resultArg, errMsg)
+	if err != nil {
+		return fmt.Errorf("finish job: %w", err)
+	}
+	return nil
+}
+
+// scanJob reads one jobs row selected in the column order used above.
+func scanJob(row interface{ Scan(...interface{}) error }) (*Job, error) {
+	var job Job
+	var request, result []byte
+	err := row.Scan(&job.ID, &job.UserID, &job.WorkspaceID, &request, &job.Status,
+		&result, &job.Error, &job.Attempts, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	job.Request = request
+	if result != nil {
+		job.Result = result
+	}
+	return &job, nil
+}
@@ -0,0 +1,220 @@
+// Package keys implements envelope encryption for stored provider tokens.
+//
+// Every user gets a random data key that encrypts that user's tokens. Data
+// keys are never stored in the clear: they are wrapped (encrypted) by a
+// master key and the wrapped form is stored with the user. A leaked
+// ciphertext therefore only exposes one user, and rotating the master key
+// only requires re-wrapping the data keys, not re-encrypting every token.
+package keys
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dataKeySize is the length of per-user data keys (AES-256).
+const dataKeySize = 32
+
+var (
+	// ErrUnknownMasterKey is returned when a wrapped key names a master key
+	// that is not in the keyring.
+	ErrUnknownMasterKey = errors.New("unknown master key")
+	// ErrDecrypt is returned when a ciphertext or wrapped key fails to decrypt.
+	ErrDecrypt = errors.New("decryption failed")
+)
+
+// WrappedKey is a data key encrypted under the master key MasterKeyID.
+type WrappedKey struct {
+	MasterKeyID string
+	Ciphertext  []byte
+}
+
+// String encodes w as "<master key id>:<base64 ciphertext>" for storage.
+func (w WrappedKey) String() string {
+	return w.MasterKeyID + ":" + base64.StdEncoding.EncodeToString(w.Ciphertext)
+}
+
+// ParseWrappedKey decodes the format produced by WrappedKey.String.
+func ParseWrappedKey(s string) (WrappedKey, error) {
+	id, enc, ok := strings.Cut(s, ":")
+	if !ok || id == "" {
+		return WrappedKey{}, errors.New("malformed wrapped key")
+	}
+	ct, err := base64.StdEncoding.DecodeString(enc)
+	if err != nil {
+		return WrappedKey{}, fmt.Errorf("malformed wrapped key: %w", err)
+	}
+	return WrappedKey{MasterKeyID: id, Ciphertext: ct}, nil
+}
+
+// Keyring holds the master keys. New data keys are wrapped with the current
+// key; older keys are kept so existing data keys can still be unwrapped until
+// they are re-wrapped.
+type Keyring struct {
+	current string
+	masters map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring whose current master key is current. Every
+// key must be 32 bytes.
+func NewKeyring(current string, masters map[string][]byte) (*Keyring, error) {
+	if _, ok := masters[current]; !ok {
+		return nil, fmt.Errorf("current master key %q: %w", current, ErrUnknownMasterKey)
+	}
+	k := &Keyring{current: current, masters: make(map[string]cipher.AEAD, len(masters))}
+	for id, key := range masters {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid master key id %q", id)
+		}
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("master key %q must be %d bytes, got %d", id, dataKeySize, len(key))
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.masters[id] = aead
+	}
+	return k, nil
+}
+
+// ParseKeyring parses a comma-separated list of "id:base64key" entries, as
+// found in TOKEN_ENCRYPTION_KEYS. The first entry is the current key.
+func ParseKeyring(spec string) (*Keyring, error) {
+	masters := make(map[string][]byte)
+	current := ""
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, enc, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("master key entry %q must be id:base64key", id)
+		}
+		key, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q: %w", id, err)
+		}
+		if _, dup := masters[id]; dup {
+			return nil, fmt.Errorf("duplicate master key %q", id)
+		}
+		if current == "" {
+			current = id
+		}
+		masters[id] = key
+	}
+	if current == "" {
+		return nil, errors.New("no master keys configured")
+	}
+	return NewKeyring(current, masters)
+}
+
+// CurrentID returns the ID of the master key used to wrap new data keys.
+func (k *Keyring) CurrentID() string {
+	return k.current
+}
+
+// NewDataKey generates a random data key wrapped by the current master key.
+func (k *Keyring) NewDataKey() (WrappedKey, error) {
+	key := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return WrappedKey{}, fmt.Errorf("generate data key: %w", err)
+	}
+	return k.wrap(key)
+}
+
+// Rewrap re-encrypts w's data key under the current master key. The data key
+// itself is unchanged, so ciphertexts made with it stay valid. It reports
+// false when w is already wrapped by the current key.
+func (k *Keyring) Rewrap(w WrappedKey) (WrappedKey, bool, error) {
+	if w.MasterKeyID == k.current {
+		return w, false, nil
+	}
+	key, err := k.unwrap(w)
+	if err != nil {
+		return WrappedKey{}, false, err
+	}
+	rewrapped, err := k.wrap(key)
+	if err != nil {
+		return WrappedKey{}, false, err
+	}
+	return rewrapped, true, nil
+}
+
+// Encrypt encrypts plaintext with the data key in w.
+func (k *Keyring) Encrypt(w WrappedKey, plaintext []byte) ([]byte, error) {
+	key, err := k.unwrap(w)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return seal(aead, plaintext)
+}
+
+// Decrypt decrypts a ciphertext produced by Encrypt with the same data key.
+func (k *Keyring) Decrypt(w WrappedKey, ciphertext []byte) ([]byte, error) {
+	key, err := k.unwrap(w)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return open(aead, ciphertext)
+}
+
+func (k *Keyring) wrap(key []byte) (WrappedKey, error) {
+	ct, err := seal(k.masters[k.current], key)
+	if err != nil {
+		return WrappedKey{}, err
+	}
+	return WrappedKey{MasterKeyID: k.current, Ciphertext: ct}, nil
+}
+
+func (k *Keyring) unwrap(w WrappedKey) ([]byte, error) {
+	aead, ok := k.masters[w.MasterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownMasterKey, w.MasterKeyID)
+	}
+	return open(aead, w.Ciphertext)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext, prefixing the random nonce to the result.
+func seal(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, ct := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ct, nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
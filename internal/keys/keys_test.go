@@ -0,0 +1,123 @@
+package keys
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, dataKeySize)
+}
+
+func TestUserEncryptor_RoundTrip(t *testing.T) {
+	ring, err := NewKeyring("v1", map[string][]byte{"v1": testKey(1)})
+	if err != nil {
+		t.Fatalf("NewKeyring: %v", err)
+	}
+	store := NewMemoryKeyStore()
+	enc := NewUserEncryptor(ring, store)
+	ctx := context.Background()
+
+	ct, err := enc.EncryptString(ctx, "alice", "xoxb-secret")
+	if err != nil {
+		t.Fatalf("EncryptString: %v", err)
+	}
+	if ct == "xoxb-secret" {
+		t.Fatal("ciphertext equals plaintext")
+	}
+	pt, err := enc.DecryptString(ctx, "alice", ct)
+	if err != nil || pt != "xoxb-secret" {
+		t.Fatalf("DecryptString = %q, %v; want xoxb-secret", pt, err)
+	}
+
+	// Another user's key cannot decrypt alice's ciphertext.
+	if _, err := enc.EncryptString(ctx, "bob", "other"); err != nil {
+		t.Fatalf("EncryptString bob: %v", err)
+	}
+	if _, err := enc.DecryptString(ctx, "bob", ct); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("decrypt with bob's key: err = %v, want ErrDecrypt", err)
+	}
+
+	alice, _ := store.Get(ctx, "alice")
+	bob, _ := store.Get(ctx, "bob")
+	if bytes.Equal(alice.Ciphertext, bob.Ciphertext) {
+		t.Error("users share a wrapped data key")
+	}
+
+	if got, err := enc.EncryptString(ctx, "alice", ""); err != nil || got != "" {
+		t.Errorf("EncryptString(\"\") = %q, %v; want empty", got, err)
+	}
+}
+
+func TestUserEncryptor_RotationRewraps(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryKeyStore()
+
+	oldRing, _ := NewKeyring("v1", map[string][]byte{"v1": testKey(1)})
+	ct, err := NewUserEncryptor(oldRing, store).EncryptString(ctx, "alice", "refresh-token")
+	if err != nil {
+		t.Fatalf("EncryptString: %v", err)
+	}
+
+	// Rotate: v2 becomes current, v1 is kept until keys are re-wrapped.
+	newRing, _ := NewKeyring("v2", map[string][]byte{"v1": testKey(1), "v2": testKey(2)})
+	enc := NewUserEncryptor(newRing, store)
+	n, err := enc.RewrapAll(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("RewrapAll = %d, %v; want 1", n, err)
+	}
+	w, _ := store.Get(ctx, "alice")
+	if w.MasterKeyID != "v2" {
+		t.Fatalf("wrapped key uses %q, want v2", w.MasterKeyID)
+	}
+	if n, _ := enc.RewrapAll(ctx); n != 0 {
+		t.Errorf("second RewrapAll updated %d keys, want 0", n)
+	}
+
+	// The existing ciphertext still decrypts, even with v1 removed.
+	retired, _ := NewKeyring("v2", map[string][]byte{"v2": testKey(2)})
+	pt, err := NewUserEncryptor(retired, store).DecryptString(ctx, "alice", ct)
+	if err != nil || pt != "refresh-token" {
+		t.Fatalf("DecryptString after rotation = %q, %v", pt, err)
+	}
+
+	// A key wrapped by a removed master key is reported, not silently lost.
+	_ = store.Put(ctx, "bob", WrappedKey{MasterKeyID: "v0", Ciphertext: []byte("x")})
+	if _, err := NewUserEncryptor(retired, store).RewrapAll(ctx); !errors.Is(err, ErrUnknownMasterKey) {
+		t.Errorf("RewrapAll with unknown master: err = %v, want ErrUnknownMasterKey", err)
+	}
+}
+
+func TestParseKeyring(t *testing.T) {
+	spec := "v2:" + base64.StdEncoding.EncodeToString(testKey(2)) + ", v1:" + base64.StdEncoding.EncodeToString(testKey(1))
+	ring, err := ParseKeyring(spec)
+	if err != nil {
+		t.Fatalf("ParseKeyring: %v", err)
+	}
+	if ring.CurrentID() != "v2" {
+		t.Errorf("CurrentID = %q, want v2", ring.CurrentID())
+	}
+
+	for _, bad := range []string{
+		"",
+		"v1",
+		"v1:not-base64!",
+		"v1:" + base64.StdEncoding.EncodeToString([]byte("short")),
+		"v1:" + base64.StdEncoding.EncodeToString(testKey(1)) + ",v1:" + base64.StdEncoding.EncodeToString(testKey(2)),
+	} {
+		if _, err := ParseKeyring(bad); err == nil {
+			t.Errorf("ParseKeyring(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestWrappedKey_StringRoundTrip(t *testing.T) {
+	w := WrappedKey{MasterKeyID: "v1", Ciphertext: []byte{1, 2, 3}}
+	got, err := ParseWrappedKey(w.String())
+	if err != nil || got.MasterKeyID != "v1" || !bytes.Equal(got.Ciphertext, w.Ciphertext) {
+		t.Fatalf("ParseWrappedKey = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,221 @@
+package keys
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNoUserKey is returned by a KeyStore when a user has no data key yet.
+var ErrNoUserKey = errors.New("user has no data key")
+
+// KeyStore persists each user's wrapped data key.
+type KeyStore interface {
+	// Get returns the user's wrapped key, or an error wrapping ErrNoUserKey.
+	Get(ctx context.Context, userID string) (WrappedKey, error)
+	// Put stores or replaces the user's wrapped key.
+	Put(ctx context.Context, userID string, w WrappedKey) error
+	// All returns every stored wrapped key by user ID.
+	All(ctx context.Context) (map[string]WrappedKey, error)
+}
+
+// UserEncryptor encrypts values under per-user data keys, creating a user's
+// key on first use.
+type UserEncryptor struct {
+	ring  *Keyring
+	store KeyStore
+	mu    sync.Mutex // serialises key creation so a user never gets two keys
+}
+
+// NewUserEncryptor creates an encryptor using ring for master keys and store
+// for users' wrapped data keys.
+func NewUserEncryptor(ring *Keyring, store KeyStore) *UserEncryptor {
+	return &UserEncryptor{ring: ring, store: store}
+}
+
+// userKey returns userID's wrapped data key, creating one if needed.
+func (e *UserEncryptor) userKey(ctx context.Context, userID string) (WrappedKey, error) {
+	w, err := e.store.Get(ctx, userID)
+	if err == nil || !errors.Is(err, ErrNoUserKey) {
+		return w, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if w, err := e.store.Get(ctx, userID); !errors.Is(err, ErrNoUserKey) {
+		return w, err
+	}
+	w, err = e.ring.NewDataKey()
+	if err != nil {
+		return WrappedKey{}, err
+	}
+	if err := e.store.Put(ctx, userID, w); err != nil {
+		return WrappedKey{}, err
+	}
+	return w, nil
+}
+
+// EncryptString encrypts plaintext for userID and returns it base64 encoded.
+// Empty strings are returned unchanged so optional fields stay empty.
+func (e *UserEncryptor) EncryptString(ctx context.Context, userID, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	w, err := e.userKey(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	ct, err := e.ring.Encrypt(w, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ct), nil
+}
+
+// DecryptString reverses EncryptString.
+func (e *UserEncryptor) DecryptString(ctx context.Context, userID, ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	ct, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	w, err := e.store.Get(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	pt, err := e.ring.Decrypt(w, ct)
+	if err != nil {
+		return "", err
+	}
+	return string(pt), nil
+}
+
+// RewrapAll re-wraps every user's data key that is not under the current
+// master key and returns how many were updated. Run it after rotating the
+// master key; the old key can be removed once it returns without error.
+func (e *UserEncryptor) RewrapAll(ctx context.Context) (int, error) {
+	all, err := e.store.All(ctx)
+	if err != nil {
+		return 0, err
+	}
+	updated := 0
+	for userID, w := range all {
+		rewrapped, changed, err := e.ring.Rewrap(w)
+		if err != nil {
+			return updated, fmt.Errorf("rewrap key for user %s: %w", userID, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := e.store.Put(ctx, userID, rewrapped); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// MemoryKeyStore is an in-process KeyStore used when no database is
+// configured (development, tests and OFFLINE mode).
+type MemoryKeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]WrappedKey
+}
+
+// NewMemoryKeyStore creates an empty in-memory key store.
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{keys: make(map[string]WrappedKey)}
+}
+
+// Get returns the stored key for userID.
+func (s *MemoryKeyStore) Get(_ context.Context, userID string) (WrappedKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, ok := s.keys[userID]
+	if !ok {
+		return WrappedKey{}, fmt.Errorf("user %s: %w", userID, ErrNoUserKey)
+	}
+	return w, nil
+}
+
+// Put stores w for userID.
+func (s *MemoryKeyStore) Put(_ context.Context, userID string, w WrappedKey) error {
+	s.mu.Lock()
+	s.keys[userID] = w
+	s.mu.Unlock()
+	return nil
+}
+
+// All returns a copy of every stored key.
+func (s *MemoryKeyStore) All(_ context.Context) (map[string]WrappedKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]WrappedKey, len(s.keys))
+	for id, w := range s.keys {
+		out[id] = w
+	}
+	return out, nil
+}
+
+// SQLKeyStore stores wrapped keys in the users.data_key column.
+type SQLKeyStore struct {
+	db *sql.DB
+}
+
+// NewSQLKeyStore creates a key store backed by db.
+func NewSQLKeyStore(db *sql.DB) *SQLKeyStore {
+	return &SQLKeyStore{db: db}
+}
+
+// Get returns the wrapped key stored with the user.
+func (s *SQLKeyStore) Get(ctx context.Context, userID string) (WrappedKey, error) {
+	var enc sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT data_key FROM users WHERE id = $1`, userID).Scan(&enc)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && !enc.Valid) {
+		return WrappedKey{}, fmt.Errorf("user %s: %w", userID, ErrNoUserKey)
+	}
+	if err != nil {
+		return WrappedKey{}, fmt.Errorf("load data key: %w", err)
+	}
+	return ParseWrappedKey(enc.String)
+}
+
+// Put stores w with the user.
+func (s *SQLKeyStore) Put(ctx context.Context, userID string, w WrappedKey) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE users SET data_key = $2 WHERE id = $1`, userID, w.String())
+	if err != nil {
+		return fmt.Errorf("store data key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("store data key: user %s not found", userID)
+	}
+	return nil
+}
+
+// All returns the wrapped keys of every user that has one.
+func (s *SQLKeyStore) All(ctx context.Context) (map[string]WrappedKey, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, data_key FROM users WHERE data_key IS NOT NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("list data keys: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]WrappedKey)
+	for rows.Next() {
+		var id, enc string
+		if err := rows.Scan(&id, &enc); err != nil {
+			return nil, fmt.Errorf("scan data key: %w", err)
+		}
+		w, err := ParseWrappedKey(enc)
+		if err != nil {
+			return nil, fmt.Errorf("user %s: %w", id, err)
+		}
+		out[id] = w
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,85 @@
+// Package lifecycle runs the gateway's background workers under one context,
+// so shutdown can signal every worker and wait for it to finish its current
+// piece of work instead of killing it mid-way.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Manager starts background workers and drains them on Shutdown.
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	stopped bool
+	running map[string]int // worker name to live goroutine count
+	wg      sync.WaitGroup
+}
+
+// New returns a Manager whose workers run until Shutdown is called.
+func New() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{ctx: ctx, cancel: cancel, running: make(map[string]int)}
+}
+
+// Go runs fn in its own goroutine with a context that is cancelled on
+// Shutdown. fn should return promptly once the context is done, finishing
+// any work already in hand first. name identifies the worker in Shutdown
+// errors. Go does nothing once Shutdown has been called.
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return
+	}
+	m.running[name]++
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer m.finished(name)
+		fn(m.ctx)
+	}()
+}
+
+func (m *Manager) finished(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.running[name]--; m.running[name] == 0 {
+		delete(m.running, name)
+	}
+}
+
+// Shutdown cancels the workers' context and waits for all of them to
+// return. If ctx ends first it returns an error naming the workers still
+// running.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.stopped = true
+	m.mu.Unlock()
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		m.mu.Lock()
+		names := make([]string, 0, len(m.running))
+		for name := range m.running {
+			names = append(names, name)
+		}
+		m.mu.Unlock()
+		sort.Strings(names)
+		return fmt.Errorf("background workers still running: %s: %w", strings.Join(names, ", "), ctx.Err())
+	}
+}
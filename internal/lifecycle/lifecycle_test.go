@@ -0,0 +1,82 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShutdown_StopsWorkers(t *testing.T) {
+	m := New()
+	var stopped atomic.Int32
+	for _, name := range []string{"outbox", "jobs", "cleanup"} {
+		m.Go(name, func(ctx context.Context) {
+			<-ctx.Done()
+			stopped.Add(1)
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown error: %v", err)
+	}
+	if got := stopped.Load(); got != 3 {
+		t.Errorf("%d workers stopped, want 3", got)
+	}
+}
+
+func TestShutdown_WaitsForWorkInHand(t *testing.T) {
+	m := New()
+	started := make(chan struct{})
+	var finished atomic.Bool
+	m.Go("dispatcher", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		// Work already claimed is finished after the signal.
+		time.Sleep(20 * time.Millisecond)
+		finished.Store(true)
+	})
+	<-started
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown error: %v", err)
+	}
+	if !finished.Load() {
+		t.Error("Shutdown returned before the worker finished")
+	}
+}
+
+func TestShutdown_TimeoutNamesStuckWorkers(t *testing.T) {
+	m := New()
+	release := make(chan struct{})
+	defer close(release)
+	m.Go("stuck", func(context.Context) { <-release })
+	m.Go("prompt", func(ctx context.Context) { <-ctx.Done() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := m.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if !strings.Contains(err.Error(), "stuck") || strings.Contains(err.Error(), "prompt") {
+		t.Errorf("error = %q, want only the stuck worker named", err)
+	}
+}
+
+func TestGo_AfterShutdownDoesNotRun(t *testing.T) {
+	m := New()
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown error: %v", err)
+	}
+	var ran atomic.Bool
+	m.Go("late", func(context.Context) { ran.Store(true) })
+	time.Sleep(10 * time.Millisecond)
+	if ran.Load() {
+		t.Error("worker started after Shutdown")
+	}
+}
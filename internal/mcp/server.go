@@ -2,8 +2,9 @@ package mcp
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
+
+	"neighbourhood/internal/middleware"
 )
 
 // JSONRPCRequest is a JSON-RPC 2.0 request envelope.
@@ -22,26 +23,26 @@ type JSONRPCResponse struct {
 	ID      interface{} `json:"id"`
 }
 
-// writeJSON encodes v as JSON and writes it to w.
+// writeJSON encodes v as JSON and writes it to w in reply to r.
 // Encoding errors are logged but cannot be surfaced to the client because
 // the HTTP status has already been committed.
-func writeJSON(w http.ResponseWriter, v interface{}) {
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
 	if err := json.NewEncoder(w).Encode(v); err != nil {
-		log.Printf("mcp: failed to encode JSON response: %v", err)
+		middleware.Logf(r.Context(), "mcp: failed to encode JSON response: %v", err)
 	}
 }
 
 // Handler is the HTTP handler for the MCP JSON-RPC endpoint.
 func Handler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		middleware.HTTPError(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	r.Body = http.MaxBytesReader(w, r.Body, 1<<20) // 1 MiB
 	var req JSONRPCRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		middleware.HTTPError(w, "invalid JSON", http.StatusBadRequest)
 		return
 	}
 
@@ -49,7 +50,7 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 
 	switch req.Method {
 	case "tools/list":
-		writeJSON(w, JSONRPCResponse{
+		writeJSON(w, r, JSONRPCResponse{
 			JSONRPC: "2.0",
 			Result:  map[string]interface{}{"tools": Tools},
 			ID:      req.ID,
@@ -58,7 +59,7 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	case "tools/call":
 		var callReq CallToolRequest
 		if err := json.Unmarshal(req.Params, &callReq); err != nil {
-			writeJSON(w, JSONRPCResponse{
+			writeJSON(w, r, JSONRPCResponse{
 				JSONRPC: "2.0",
 				Error:   map[string]interface{}{"code": -32602, "message": "invalid params: " + err.Error()},
 				ID:      req.ID,
@@ -68,21 +69,21 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 
 		result, err := HandleToolCall(r.Context(), callReq)
 		if err != nil {
-			writeJSON(w, JSONRPCResponse{
+			writeJSON(w, r, JSONRPCResponse{
 				JSONRPC: "2.0",
 				Error:   map[string]interface{}{"code": -32603, "message": err.Error()},
 				ID:      req.ID,
 			})
 			return
 		}
-		writeJSON(w, JSONRPCResponse{
+		writeJSON(w, r, JSONRPCResponse{
 			JSONRPC: "2.0",
 			Result:  result,
 			ID:      req.ID,
 		})
 
 	default:
-		writeJSON(w, JSONRPCResponse{
+		writeJSON(w, r, JSONRPCResponse{
 			JSONRPC: "2.0",
 			Error:   map[string]interface{}{"code": -32601, "message": "method not found"},
 			ID:      req.ID,
@@ -103,7 +103,7 @@ func HandleToolCall(ctx context.Context, req CallToolRequest) (*CallToolResult,
 			}
 		}
 
-		result, err := provider.Execute(ctx, &token, action, payload)
+		result, err := integrations.ExecuteAction(ctx, provider, &token, action, payload)
 		if err != nil {
 			return &CallToolResult{
 				IsError: true,
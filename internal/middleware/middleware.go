@@ -2,12 +2,18 @@ package middleware
 
 import (
 	"context"
-	"log"
+	"errors"
+	"math"
+	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // contextKey is an unexported type for context keys in this package.
@@ -17,19 +23,42 @@ type contextKey string
 const (
 	// ContextKeyUserID is the context key used to store the authenticated user's ID.
 	ContextKeyUserID contextKey = "user_id"
+	// ContextKeyWorkspaceID is the context key used to store the acting workspace ID.
+	ContextKeyWorkspaceID contextKey = "workspace_id"
+	// ContextKeyRequestID is the context key used to store the request ID; see RequestID.
+	ContextKeyRequestID contextKey = "request_id"
+	// ContextKeyActorID is the context key used to store the admin acting as
+	// the user, from an impersonation token's act claim.
+	ContextKeyActorID contextKey = "actor_id"
 )
 
+// WorkspaceHeader is the request header that selects the acting workspace.
+const WorkspaceHeader = "X-Workspace-ID"
+
 // Logger middleware logs HTTP requests with method, path, status, and duration.
 func Logger(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
+	return LoggerWithSlowThreshold(0)(next)
+}
 
-		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
-		next.ServeHTTP(wrapped, r)
+// LoggerWithSlowThreshold returns a Logger that escalates requests taking
+// longer than slow to a WARNING tagged [slow], so latency outliers stand out
+// from normal request logging. A threshold of zero disables the escalation.
+func LoggerWithSlowThreshold(slow time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
 
-		duration := time.Since(start)
-		log.Printf("[%s] %s %s - %d (%v)", r.Method, r.URL.Path, r.RemoteAddr, wrapped.statusCode, duration)
-	})
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapped, r)
+
+			duration := time.Since(start)
+			if slow > 0 && duration > slow {
+				Logf(r.Context(), "WARNING: [slow] [%s] %s %s - %d (%v, threshold %v)", r.Method, r.URL.Path, r.RemoteAddr, wrapped.statusCode, duration, slow)
+				return
+			}
+			Logf(r.Context(), "[%s] %s %s - %d (%v)", r.Method, r.URL.Path, r.RemoteAddr, wrapped.statusCode, duration)
+		})
+	}
 }
 
 // responseWriter wraps http.ResponseWriter to capture the written status code.
@@ -43,157 +72,334 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// Auth middleware validates Bearer JWT tokens.
-// In development it accepts any non-empty token to ease local testing.
-// Set ENV=production to enforce strict validation.
+// Auth middleware validates Bearer JWT tokens signed with the JWT_SECRET
+// environment variable. Outside production-like environments (see
+// ProfileForEnv) it falls back to accepting any non-empty token to ease local
+// testing; see AuthWithSecret.
 func Auth(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "missing authorization header", http.StatusUnauthorized)
-			return
-		}
+	devBypass := ProfileForEnv(os.Getenv("ENV")).Name == "development"
+	return AuthWithSecret(os.Getenv("JWT_SECRET"), devBypass)(next)
+}
 
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
-			http.Error(w, "invalid authorization header format, expected: Bearer <token>", http.StatusUnauthorized)
-			return
-		}
+// AuthWithSecret returns an Auth middleware that verifies HS256 Bearer tokens
+// against secret, requires an unexpired exp claim, and stores the token's sub
+// claim in the context under ContextKeyUserID and the subject of its act
+// claim, if any, under ContextKeyActorID. Malformed, expired or wrongly
+// signed tokens are rejected with 401 unless devBypass is set, in which case
+// the raw token is stored as the user ID instead, as before tokens were real.
+func AuthWithSecret(secret string, devBypass bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				HTTPError(w, "missing authorization header", http.StatusUnauthorized)
+				return
+			}
 
-		token := strings.TrimSpace(parts[1])
-		if token == "" {
-			http.Error(w, "empty bearer token", http.StatusUnauthorized)
-			return
-		}
+			parts := strings.SplitN(authHeader, " ", 2)
+			if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+				HTTPError(w, "invalid authorization header format, expected: Bearer <token>", http.StatusUnauthorized)
+				return
+			}
 
-		// TODO: replace with real JWT validation using golang-jwt/jwt/v5 and the
-		// configured JWT secret once auth.generateJWT generates real tokens.
-		// Example:
-		//   claims, err := jwt.ParseWithClaims(token, &jwt.RegisteredClaims{}, keyFunc)
-		//   userID := claims.Subject
+			token := strings.TrimSpace(parts[1])
+			if token == "" {
+				HTTPError(w, "empty bearer token", http.StatusUnauthorized)
+				return
+			}
 
-		// Placeholder: store a resolved user ID in context.
-		ctx := context.WithValue(r.Context(), ContextKeyUserID, token)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+			userID, actorID, err := VerifyJWT(token, secret)
+			if err != nil {
+				if !devBypass {
+					HTTPError(w, "invalid or expired token", http.StatusUnauthorized)
+					return
+				}
+				userID = token
+			}
+
+			ctx := context.WithValue(r.Context(), ContextKeyUserID, userID)
+			if actorID != "" {
+				ctx = context.WithValue(ctx, ContextKeyActorID, actorID)
+				Logf(ctx, "%s %s by %s impersonating %s", r.Method, r.URL.Path, actorID, userID)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
 }
 
-// CORS middleware adds Cross-Origin Resource Sharing headers.
-// The allowed origin is read from the CORS_ALLOW_ORIGIN environment variable
-// (defaults to "*" for development; set a specific origin in production).
-func CORS(next http.Handler) http.Handler {
-	allowedOrigin := os.Getenv("CORS_ALLOW_ORIGIN")
-	if allowedOrigin == "" {
-		allowedOrigin = "*"
+// AuthPaths returns an AuthWithSecret middleware that only guards requests
+// whose path starts with one of prefixes; other requests pass through
+// unauthenticated.
+func AuthPaths(secret string, devBypass bool, prefixes ...string) func(http.Handler) http.Handler {
+	auth := AuthWithSecret(secret, devBypass)
+	return func(next http.Handler) http.Handler {
+		guarded := auth(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range prefixes {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					guarded.ServeHTTP(w, r)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
 	}
+}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Max-Age", "86400") // 24 h preflight cache
+// tokenClaims are the JWT claims Auth reads. Act is the RFC 8693 actor claim
+// of impersonation tokens.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Act *struct {
+		Subject string `json:"sub"`
+	} `json:"act,omitempty"`
+}
 
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
+// VerifyJWT checks token's HS256 signature and expiry and returns its subject
+// and, for impersonation tokens, the acting admin. Endpoints outside Auth
+// that read a session token verify it here, so none accepts a token Auth
+// would reject.
+func VerifyJWT(token, secret string) (subject, actor string, err error) {
+	if secret == "" {
+		return "", "", errors.New("no JWT secret configured")
+	}
+	claims := &tokenClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return "", "", err
+	}
+	if claims.Subject == "" {
+		return "", "", errors.New("token has no sub claim")
+	}
+	// Handlers scope data by user UUID; a subject that is not one must not
+	// fall through to the shared development user.
+	if _, err := uuid.Parse(claims.Subject); err != nil {
+		return "", "", errors.New("token sub claim is not a user ID")
+	}
+	if claims.Act != nil {
+		if claims.Act.Subject == "" {
+			return "", "", errors.New("token act claim has no sub")
 		}
+		actor = claims.Act.Subject
+	}
+	return claims.Subject, actor, nil
+}
 
-		next.ServeHTTP(w, r)
+// ActorIDFromContext returns the admin acting as the user, stored in ctx by
+// Auth for impersonation tokens, or "".
+func ActorIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ContextKeyActorID).(string)
+	return id
+}
+
+// Workspace middleware stores the acting workspace, taken from the
+// X-Workspace-ID header, in the request context. Requests without the header
+// act in the user's default (empty) workspace.
+func Workspace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		workspaceID := strings.TrimSpace(r.Header.Get(WorkspaceHeader))
+		if workspaceID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := context.WithValue(r.Context(), ContextKeyWorkspaceID, workspaceID)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// CORS middleware adds Cross-Origin Resource Sharing headers using the
+// security profile for the ENV environment variable (see LoadSecurityProfile).
+// Development allows any origin; set CORS_ALLOW_ORIGIN to "*" or a
+// comma-separated allowlist of origins to override.
+func CORS(next http.Handler) http.Handler {
+	return CORSWithProfile(LoadSecurityProfile(os.Getenv("ENV")))(next)
+}
+
 // SecurityHeaders adds defensive HTTP security headers to every response.
-// These protect against MIME-sniffing, click-jacking, and reflected XSS.
+// These protect against MIME-sniffing, click-jacking, and reflected XSS; HSTS
+// and CSP are added according to the ENV security profile.
 func SecurityHeaders(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("X-Content-Type-Options", "nosniff")
-		w.Header().Set("X-Frame-Options", "DENY")
-		w.Header().Set("X-XSS-Protection", "1; mode=block")
-		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
-		w.Header().Set("Permissions-Policy", "geolocation=(), microphone=(), camera=()")
-		next.ServeHTTP(w, r)
-	})
+	return SecurityHeadersWithProfile(LoadSecurityProfile(os.Getenv("ENV")))(next)
 }
 
 // ipEntry tracks per-IP request counts in a fixed time window.
 type ipEntry struct {
-	mu        sync.Mutex
 	count     int
 	windowEnd time.Time
 }
 
-var (
-	rateMu        sync.Mutex
-	rateTable     = map[string]*ipEntry{}
-	rateLimitOnce sync.Once
-)
+// rateTableCleanupInterval is how often a rateTable evicts stale entries.
+const rateTableCleanupInterval = 5 * time.Minute
 
-// startRateTableCleanup evicts stale per-IP entries every 5 minutes to
-// prevent unbounded memory growth on long-running, high-traffic servers.
-func startRateTableCleanup() {
-	go func() {
-		ticker := time.NewTicker(5 * time.Minute)
-		defer ticker.Stop()
-		for range ticker.C {
-			now := time.Now()
-			rateMu.Lock()
-			for ip, e := range rateTable {
-				e.mu.Lock()
-				stale := now.After(e.windowEnd.Add(5 * time.Minute))
-				e.mu.Unlock()
-				if stale {
-					delete(rateTable, ip)
-				}
-			}
-			rateMu.Unlock()
-		}
-	}()
+// rateTable counts requests per client IP in fixed windows of window, allowing
+// limit requests in each. Entries are evicted once their window has passed,
+// so memory stays bounded by the number of recently active clients.
+type rateTable struct {
+	mu          sync.Mutex
+	entries     map[string]*ipEntry
+	limit       int
+	window      time.Duration
+	cleanupOnce sync.Once
 }
 
-// RateLimiter middleware limits each IP to maxReqPerWindow requests per window.
-// Defaults: 100 requests / 60 seconds (overridable at startup via env vars).
-func RateLimiter(next http.Handler) http.Handler {
-	// Start the background cleanup goroutine exactly once per process.
-	rateLimitOnce.Do(startRateTableCleanup)
+func newRateTable(limit int, window time.Duration) *rateTable {
+	return &rateTable{entries: map[string]*ipEntry{}, limit: limit, window: window}
+}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		const (
-			maxRequests    = 100
-			windowDuration = 60 * time.Second
-		)
-
-		ip := r.RemoteAddr
-		// Strip port if present.
-		if idx := strings.LastIndex(ip, ":"); idx != -1 {
-			ip = ip[:idx]
-		}
+// allow counts a request from ip at now. When ip is over its limit it
+// returns false and how long until its window resets.
+func (t *rateTable) allow(ip string, now time.Time) (bool, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[ip]
+	if !ok || !now.Before(entry.windowEnd) {
+		entry = &ipEntry{windowEnd: now.Add(t.window)}
+		t.entries[ip] = entry
+	}
+	entry.count++
+	if entry.count > t.limit {
+		return false, entry.windowEnd.Sub(now)
+	}
+	return true, 0
+}
 
-		rateMu.Lock()
-		entry, ok := rateTable[ip]
-		if !ok {
-			entry = &ipEntry{}
-			rateTable[ip] = entry
-		}
-		rateMu.Unlock()
+// charge counts n more requests from ip at now, unless that would take ip
+// over its limit; then it counts nothing and returns false and how long until
+// its window resets.
+func (t *rateTable) charge(ip string, n int, now time.Time) (bool, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[ip]
+	if !ok || !now.Before(entry.windowEnd) {
+		entry = &ipEntry{windowEnd: now.Add(t.window)}
+		t.entries[ip] = entry
+	}
+	if entry.count+n > t.limit {
+		return false, entry.windowEnd.Sub(now)
+	}
+	entry.count += n
+	return true, 0
+}
 
-		entry.mu.Lock()
-		now := time.Now()
-		if now.After(entry.windowEnd) {
-			entry.count = 0
-			entry.windowEnd = now.Add(windowDuration)
+// evictStale removes entries whose window ended before now.
+func (t *rateTable) evictStale(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for ip, e := range t.entries {
+		if !now.Before(e.windowEnd) {
+			delete(t.entries, ip)
 		}
-		entry.count++
-		count := entry.count
-		entry.mu.Unlock()
+	}
+}
+
+// Spawner starts a named background worker that runs until its context is
+// cancelled, such as lifecycle.Manager.Go.
+type Spawner func(name string, fn func(ctx context.Context))
+
+// spawnForever runs fn for the life of the process.
+func spawnForever(_ string, fn func(ctx context.Context)) {
+	go fn(context.Background())
+}
 
-		if count > maxRequests {
-			w.Header().Set("Retry-After", "60")
-			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+// startCleanup starts runCleanup through spawn, at most once per table.
+func (t *rateTable) startCleanup(spawn Spawner) {
+	t.cleanupOnce.Do(func() {
+		spawn("rate limit cleanup", t.runCleanup)
+	})
+}
+
+// runCleanup evicts stale entries every rateTableCleanupInterval until ctx
+// is cancelled.
+func (t *rateTable) runCleanup(ctx context.Context) {
+	ticker := time.NewTicker(rateTableCleanupInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
 			return
+		case now := <-ticker.C:
+			t.evictStale(now)
 		}
+	}
+}
 
-		next.ServeHTTP(w, r)
-	})
+// RateLimiter middleware limits each IP to 100 requests per minute. Use
+// RateLimiterWithLimit for a configured limit.
+func RateLimiter(next http.Handler) http.Handler {
+	return RateLimiterWithLimit(100, time.Minute)(next)
+}
+
+// RateLimiterWithLimit limits each client IP to limit requests per fixed
+// window, answering excess requests with 429 and a Retry-After header giving
+// the seconds until the window resets. Counts are kept in memory, so each
+// gateway replica enforces the limit separately; see RedisRateLimiter.
+// Stale counts are evicted for the life of the process; use
+// RateLimiterWithCleanup to stop eviction at shutdown.
+func RateLimiterWithLimit(limit int, window time.Duration) func(http.Handler) http.Handler {
+	return RateLimiterWithCleanup(limit, window, spawnForever)
+}
+
+// RateLimiterWithCleanup is RateLimiterWithLimit with the eviction of stale
+// counts started through spawn.
+func RateLimiterWithCleanup(limit int, window time.Duration, spawn Spawner) func(http.Handler) http.Handler {
+	table := newRateTable(limit, window)
+	table.startCleanup(spawn)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := clientIP(r)
+			if ok, retryAfter := table.allow(ip, time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				HTTPError(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			charge := rateCharger(func(n int) (bool, time.Duration) { return table.charge(ip, n, time.Now()) })
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), rateChargerKey{}, charge)))
+		})
+	}
+}
+
+// rateCharger counts n more requests against the caller's rate limit; see
+// ChargeRateLimit.
+type rateCharger func(n int) (ok bool, retryAfter time.Duration)
+
+type rateChargerKey struct{}
+
+// ChargeRateLimit counts n requests more than the one r was counted as against
+// its client's rate limit, for handlers such as batches that do the work of
+// several requests. When that would exceed the limit nothing is counted and it
+// returns false and how long until the window resets. Without a rate limiter
+// in the chain it always succeeds.
+func ChargeRateLimit(r *http.Request, n int) (bool, time.Duration) {
+	charge, ok := r.Context().Value(rateChargerKey{}).(rateCharger)
+	if !ok || n <= 0 {
+		return true, 0
+	}
+	return charge(n)
+}
+
+// TrustForwardedFor makes clientIP use the X-Forwarded-For header. Enable it
+// only behind a reverse proxy that sets the header, since clients can forge
+// it. It is set from configuration at startup.
+var TrustForwardedFor bool
+
+// clientIP returns the address of the client that sent r. With
+// TrustForwardedFor set it is the last X-Forwarded-For entry, the address
+// the nearest proxy saw; otherwise the connection's remote address.
+func clientIP(r *http.Request) string {
+	if TrustForwardedFor {
+		if fwd := r.Header.Values("X-Forwarded-For"); len(fwd) > 0 {
+			hops := strings.Split(fwd[len(fwd)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); net.ParseIP(ip) != nil {
+				return ip
+			}
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
 }
 
 // Chain combines multiple middleware, applying them in the order given.
@@ -1,10 +1,20 @@
 package middleware
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 // ──────────────────────────────────────────────────────────────────────────────
@@ -72,6 +82,50 @@ func TestLogger_ContainsRequestContext(t *testing.T) {
 	}
 }
 
+// captureLog redirects the standard logger into a buffer for the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return &buf
+}
+
+func TestLoggerWithSlowThreshold_TagsSlowRequest(t *testing.T) {
+	buf := captureLog(t)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/api/reports", nil)
+	rr := httptest.NewRecorder()
+
+	LoggerWithSlowThreshold(5*time.Millisecond)(next).ServeHTTP(rr, req)
+
+	out := buf.String()
+	if !strings.Contains(out, "WARNING: [slow]") {
+		t.Errorf("slow request not tagged: %q", out)
+	}
+	if !strings.Contains(out, "/api/reports") || !strings.Contains(out, "202") {
+		t.Errorf("slow log should name the route and status: %q", out)
+	}
+}
+
+func TestLoggerWithSlowThreshold_FastRequestLoggedNormally(t *testing.T) {
+	buf := captureLog(t)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/fast", nil)
+	rr := httptest.NewRecorder()
+
+	LoggerWithSlowThreshold(time.Second)(next).ServeHTTP(rr, req)
+
+	out := buf.String()
+	if strings.Contains(out, "[slow]") || !strings.Contains(out, "/fast") {
+		t.Errorf("fast request log = %q", out)
+	}
+}
+
 // ──────────────────────────────────────────────────────────────────────────────
 // Auth middleware
 // ──────────────────────────────────────────────────────────────────────────────
@@ -105,55 +159,169 @@ func TestAuth_EmptyAuthorizationHeader_Returns401(t *testing.T) {
 	}
 }
 
-func TestAuth_BearerToken_Returns200(t *testing.T) {
+const (
+	testJWTSecret = "test-secret"
+	testUserID    = "7d1e2f3a-4b5c-4d6e-8f90-a1b2c3d4e5f6"
+)
+
+// signTestJWT returns a token for sub signed with method and key, expiring
+// after ttl.
+func signTestJWT(t *testing.T, method jwt.SigningMethod, key interface{}, sub string, ttl time.Duration) string {
+	t.Helper()
+	claims := jwt.RegisteredClaims{Subject: sub, ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl))}
+	signed, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign token: %v", err)
+	}
+	return signed
+}
+
+// serveAuth runs a request bearing token through AuthWithSecret and returns
+// the response and the user ID the next handler saw.
+func serveAuth(token string, devBypass bool) (*httptest.ResponseRecorder, string) {
+	userID := ""
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ = r.Context().Value(ContextKeyUserID).(string)
 		w.WriteHeader(http.StatusOK)
 	})
 	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
-	req.Header.Set("Authorization", "Bearer some-valid-token")
+	req.Header.Set("Authorization", "Bearer "+token)
 	rr := httptest.NewRecorder()
+	AuthWithSecret(testJWTSecret, devBypass)(next).ServeHTTP(rr, req)
+	return rr, userID
+}
 
-	Auth(next).ServeHTTP(rr, req)
+func TestAuth_ValidToken_Returns200(t *testing.T) {
+	token := signTestJWT(t, jwt.SigningMethodHS256, []byte(testJWTSecret), testUserID, time.Hour)
+	rr, _ := serveAuth(token, false)
 
 	if rr.Code != http.StatusOK {
-		t.Errorf("expected 200 with Bearer token, got %d", rr.Code)
+		t.Errorf("expected 200 with a valid token, got %d", rr.Code)
 	}
 }
 
-func TestAuth_WrongScheme_Returns401(t *testing.T) {
+func TestAuth_RejectsInvalidTokens(t *testing.T) {
+	_, edKey, _ := ed25519.GenerateKey(nil)
+	cases := map[string]string{
+		"malformed":    "some-token",
+		"expired":      signTestJWT(t, jwt.SigningMethodHS256, []byte(testJWTSecret), testUserID, -time.Minute),
+		"wrong secret": signTestJWT(t, jwt.SigningMethodHS256, []byte("other-secret"), testUserID, time.Hour),
+		"HS512":        signTestJWT(t, jwt.SigningMethodHS512, []byte(testJWTSecret), testUserID, time.Hour),
+		"EdDSA":        signTestJWT(t, jwt.SigningMethodEdDSA, edKey, testUserID, time.Hour),
+		"alg none":     signTestJWT(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, testUserID, time.Hour),
+		"no sub":       signTestJWT(t, jwt.SigningMethodHS256, []byte(testJWTSecret), "", time.Hour),
+		"non-UUID sub": signTestJWT(t, jwt.SigningMethodHS256, []byte(testJWTSecret), "583231", time.Hour),
+	}
+	for name, token := range cases {
+		if rr, _ := serveAuth(token, false); rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s token: expected 401, got %d", name, rr.Code)
+		}
+	}
+}
+
+func TestAuth_RejectsTokenWithoutExpiry(t *testing.T) {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: testUserID}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr, _ := serveAuth(token, false); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a token without exp, got %d", rr.Code)
+	}
+}
+
+func TestAuth_StoresImpersonatingActor(t *testing.T) {
+	claims := jwt.MapClaims{
+		"sub": testUserID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"act": map[string]string{"sub": "admin-1"},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var userID, actorID string
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
+		userID, _ = r.Context().Value(ContextKeyUserID).(string)
+		actorID = ActorIDFromContext(r.Context())
 	})
 	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
-	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz") // Basic auth
-	rr := httptest.NewRecorder()
+	req.Header.Set("Authorization", "Bearer "+token)
+	AuthWithSecret(testJWTSecret, false)(next).ServeHTTP(httptest.NewRecorder(), req)
+	if userID != testUserID || actorID != "admin-1" {
+		t.Errorf("user/actor = %q/%q, want %s/admin-1", userID, actorID, testUserID)
+	}
+}
 
-	Auth(next).ServeHTTP(rr, req)
+func TestAuth_DevBypassAcceptsUnverifiedToken(t *testing.T) {
+	rr, userID := serveAuth("some-token", true)
 
-	if rr.Code != http.StatusUnauthorized {
-		t.Errorf("expected 401 for Basic auth scheme, got %d", rr.Code)
+	if rr.Code != http.StatusOK || userID != "some-token" {
+		t.Errorf("dev bypass: got %d with user %q", rr.Code, userID)
 	}
 }
 
-func TestAuth_InjectsUserIDIntoContext(t *testing.T) {
-	userIDFound := ""
+func TestAuth_DevBypassStillUsesVerifiedSubject(t *testing.T) {
+	token := signTestJWT(t, jwt.SigningMethodHS256, []byte(testJWTSecret), testUserID, time.Hour)
+	if _, userID := serveAuth(token, true); userID != testUserID {
+		t.Errorf("expected %s, got %q", testUserID, userID)
+	}
+}
+
+func TestAuthPaths_GuardsOnlyPrefixes(t *testing.T) {
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if uid, ok := r.Context().Value(ContextKeyUserID).(string); ok {
-			userIDFound = uid
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := AuthPaths(testJWTSecret, false, "/api/", "/mcp")(next)
+	token := signTestJWT(t, jwt.SigningMethodHS256, []byte(testJWTSecret), testUserID, time.Hour)
+
+	cases := []struct {
+		path, token string
+		want        int
+	}{
+		{"/api/integrations", "", http.StatusUnauthorized},
+		{"/api/workflow/execute", "some-token", http.StatusUnauthorized},
+		{"/mcp", "", http.StatusUnauthorized},
+		{"/api/integrations", token, http.StatusOK},
+		{"/health", "", http.StatusOK},
+		{"/auth/login", "", http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
 		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		if rr.Code != tc.want {
+			t.Errorf("%s with token %q: expected %d, got %d", tc.path, tc.token, tc.want, rr.Code)
+		}
+	}
+}
+
+func TestAuth_WrongScheme_Returns401(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
 	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
-	req.Header.Set("Authorization", "Bearer some-token")
+	req.Header.Set("Authorization", "Basic dXNlcjpwYXNz") // Basic auth
 	rr := httptest.NewRecorder()
 
 	Auth(next).ServeHTTP(rr, req)
 
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for Basic auth scheme, got %d", rr.Code)
+	}
+}
+
+func TestAuth_InjectsUserIDIntoContext(t *testing.T) {
+	token := signTestJWT(t, jwt.SigningMethodHS256, []byte(testJWTSecret), "5f0c3b1e-8d2a-4c6b-9e7f-1a2b3c4d5e6f", time.Hour)
+	_, userIDFound := serveAuth(token, false)
+
 	if userIDFound == "" {
 		t.Error("Auth middleware should inject user_id into context")
 	}
-	if userIDFound != "some-token" {
-		t.Errorf("expected some-token, got %q", userIDFound)
+	if userIDFound != "5f0c3b1e-8d2a-4c6b-9e7f-1a2b3c4d5e6f" {
+		t.Errorf("expected the token's sub claim, got %q", userIDFound)
 	}
 }
 
@@ -223,6 +391,7 @@ func TestCORS_OPTIONS_Returns200(t *testing.T) {
 		w.WriteHeader(http.StatusTeapot) // should never be reached
 	})
 	req := httptest.NewRequest(http.MethodOptions, "/api/data", nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
 	rr := httptest.NewRecorder()
 
 	CORS(next).ServeHTTP(rr, req)
@@ -240,6 +409,7 @@ func TestCORS_OPTIONS_DoesNotCallNext(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 	})
 	req := httptest.NewRequest(http.MethodOptions, "/api/data", nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
 	rr := httptest.NewRecorder()
 
 	CORS(next).ServeHTTP(rr, req)
@@ -278,7 +448,125 @@ func TestRateLimiter_PassesThrough(t *testing.T) {
 	RateLimiter(next).ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusOK {
-		t.Errorf("RateLimiter (placeholder) should pass through, got %d", rr.Code)
+		t.Errorf("RateLimiter should pass through a first request, got %d", rr.Code)
+	}
+}
+
+func TestRateLimiterWithLimit_RejectsOverLimitPerIP(t *testing.T) {
+	const limit = 5
+	handler := RateLimiterWithLimit(limit, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/endpoint", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < limit; i++ {
+		if rr := serve("203.0.113.7:5000"); rr.Code != http.StatusOK {
+			t.Fatalf("request %d got %d, want 200", i+1, rr.Code)
+		}
+	}
+	rr := serve("203.0.113.7:5001")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("request %d got %d, want 429", limit+1, rr.Code)
+	}
+	if secs, err := strconv.Atoi(rr.Header().Get("Retry-After")); err != nil || secs < 1 || secs > 60 {
+		t.Errorf("Retry-After = %q, want 1-60 seconds", rr.Header().Get("Retry-After"))
+	}
+	if rr := serve("198.51.100.2:5000"); rr.Code != http.StatusOK {
+		t.Errorf("a different IP got %d, want 200", rr.Code)
+	}
+}
+
+func TestRateLimiterWithLimit_ConcurrentRequests(t *testing.T) {
+	const limit, total = 50, 200
+	handler := RateLimiterWithLimit(limit, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	passed := 0
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rr.Code == http.StatusOK {
+				mu.Lock()
+				passed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if passed != limit {
+		t.Errorf("%d concurrent requests passed, want exactly %d", passed, limit)
+	}
+}
+
+func TestRateLimiterWithCleanup_StopsWhenCancelled(t *testing.T) {
+	var name string
+	var cleanup func(context.Context)
+	RateLimiterWithCleanup(1, time.Minute, func(n string, fn func(context.Context)) {
+		name, cleanup = n, fn
+	})
+	if cleanup == nil {
+		t.Fatal("cleanup worker was not spawned")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		cleanup(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s worker did not stop after cancellation", name)
+	}
+}
+
+func TestRateTable_EvictsStaleEntries(t *testing.T) {
+	table := newRateTable(1, time.Minute)
+	now := time.Now()
+	table.allow("203.0.113.7", now)
+	table.allow("198.51.100.2", now.Add(30*time.Second))
+
+	table.evictStale(now.Add(70 * time.Second))
+
+	if _, ok := table.entries["203.0.113.7"]; ok {
+		t.Error("entry whose window ended should be evicted")
+	}
+	if _, ok := table.entries["198.51.100.2"]; !ok {
+		t.Error("entry in its window should be kept")
+	}
+}
+
+func TestClientIP_ForwardedFor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.5:443"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 203.0.113.7")
+
+	if got := clientIP(req); got != "10.0.0.5" {
+		t.Errorf("untrusted X-Forwarded-For used: got %q", got)
+	}
+
+	TrustForwardedFor = true
+	t.Cleanup(func() { TrustForwardedFor = false })
+	if got := clientIP(req); got != "203.0.113.7" {
+		t.Errorf("clientIP = %q, want the address the proxy saw", got)
+	}
+	req.Header.Set("X-Forwarded-For", "not-an-ip")
+	if got := clientIP(req); got != "10.0.0.5" {
+		t.Errorf("malformed X-Forwarded-For should fall back to RemoteAddr, got %q", got)
 	}
 }
 
@@ -380,6 +668,7 @@ func TestChain_CORS_Then_Auth_OPTIONS_Bypasses(t *testing.T) {
 		w.WriteHeader(http.StatusOK)
 	})
 	req := httptest.NewRequest(http.MethodOptions, "/api/protected", nil)
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
 	rr := httptest.NewRecorder()
 
 	// CORS should short-circuit before Auth sees the request
@@ -418,3 +707,59 @@ func TestAuth_401_ContainsJSONBody(t *testing.T) {
 		t.Errorf("401 body should describe the problem, got: %q", body)
 	}
 }
+
+// ──────────────────────────────────────────────────────────────────────────────
+// Workspace middleware
+// ──────────────────────────────────────────────────────────────────────────────
+
+func TestWorkspace_HeaderStoredInContext(t *testing.T) {
+	var got interface{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Context().Value(ContextKeyWorkspaceID)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(WorkspaceHeader, "ws-123")
+	rr := httptest.NewRecorder()
+
+	Workspace(next).ServeHTTP(rr, req)
+
+	if got != "ws-123" {
+		t.Errorf("expected workspace ws-123 in context, got %v", got)
+	}
+}
+
+func TestWorkspace_NoHeader_NoContextValue(t *testing.T) {
+	var got interface{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Context().Value(ContextKeyWorkspaceID)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+
+	Workspace(next).ServeHTTP(rr, req)
+
+	if got != nil {
+		t.Errorf("expected no workspace in context, got %v", got)
+	}
+}
+
+func TestAuth_ProductionRejectsUnverifiedToken(t *testing.T) {
+	t.Setenv("ENV", "production")
+	t.Setenv("JWT_SECRET", testJWTSecret)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for token, want := range map[string]int{
+		"some-token": http.StatusUnauthorized,
+		signTestJWT(t, jwt.SigningMethodHS256, []byte(testJWTSecret), testUserID, time.Hour): http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		Auth(next).ServeHTTP(rr, req)
+		if rr.Code != want {
+			t.Errorf("expected %d, got %d", want, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SecurityProfile holds the environment-dependent CORS and security header
+// settings. Development is permissive; staging and production lock down
+// cross-origin access and enable HSTS and a Content-Security-Policy.
+type SecurityProfile struct {
+	Name string
+	// CORSAllowOrigin is "*" to allow any origin, or a comma-separated
+	// allowlist of origins such as "https://app.example.com". Empty disables
+	// cross-origin access entirely.
+	CORSAllowOrigin string
+	// CORSMaxAge is how long browsers may cache a preflight response. Zero
+	// omits Access-Control-Max-Age, leaving the browser's default.
+	CORSMaxAge time.Duration
+	// HSTS is the Strict-Transport-Security value. Empty omits the header.
+	HSTS string
+	// CSP is the Content-Security-Policy value. Empty omits the header.
+	CSP string
+}
+
+// defaultCSP allows the portal's own assets, its inline scripts and styles,
+// and Google Fonts, and forbids framing.
+const defaultCSP = "default-src 'self'; script-src 'self' 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline' https://fonts.googleapis.com; " +
+	"font-src 'self' https://fonts.gstatic.com; img-src 'self' data:; frame-ancestors 'none'"
+
+// DefaultCORSMaxAge is the preflight cache lifetime of the built-in profiles.
+const DefaultCORSMaxAge = 24 * time.Hour
+
+// ProfileForEnv returns the built-in profile for a deployment environment.
+// Unknown environments get the production profile so a typo never ships
+// development-permissive settings.
+func ProfileForEnv(env string) SecurityProfile {
+	switch strings.ToLower(env) {
+	case "", "dev", "development", "local", "test":
+		return SecurityProfile{Name: "development", CORSAllowOrigin: "*", CORSMaxAge: DefaultCORSMaxAge}
+	case "staging":
+		return SecurityProfile{Name: "staging", CORSMaxAge: DefaultCORSMaxAge, HSTS: "max-age=86400", CSP: defaultCSP}
+	default:
+		return SecurityProfile{Name: "production", CORSMaxAge: DefaultCORSMaxAge, HSTS: "max-age=63072000; includeSubDomains", CSP: defaultCSP}
+	}
+}
+
+// LoadSecurityProfile returns the profile for env with explicit overrides
+// applied from CORS_ALLOW_ORIGIN, SECURITY_HSTS and SECURITY_CSP. Setting an
+// override to "off" disables that setting.
+func LoadSecurityProfile(env string) SecurityProfile {
+	p := ProfileForEnv(env)
+	override := func(key string, field *string) {
+		switch v := os.Getenv(key); v {
+		case "":
+		case "off":
+			*field = ""
+		default:
+			*field = v
+		}
+	}
+	override("CORS_ALLOW_ORIGIN", &p.CORSAllowOrigin)
+	override("SECURITY_HSTS", &p.HSTS)
+	override("SECURITY_CSP", &p.CSP)
+	return p
+}
+
+// Validate reports settings that cannot mean what they say: a CORS allowlist
+// mixing "*" with explicit origins, which would otherwise silently allow
+// every origin.
+func (p SecurityProfile) Validate() error {
+	origins := parseOrigins(p.CORSAllowOrigin)
+	if len(origins) > 1 && slices.Contains(origins, "*") {
+		return fmt.Errorf("CORS allowlist %q mixes \"*\" with explicit origins; use either \"*\" or a list of origins", p.CORSAllowOrigin)
+	}
+	return nil
+}
+
+// CORSWithProfile returns CORS middleware configured by p. When the profile
+// allows no origin, no CORS headers are sent and preflights are rejected by
+// the browser.
+func CORSWithProfile(p SecurityProfile) func(http.Handler) http.Handler {
+	return CORSWithRoutes(p, nil)
+}
+
+// CORSWithRoutes is CORSWithProfile advertising, for each request, only the
+// methods its route was registered with in routes. Paths that match no
+// registered route, or all paths when routes is nil, advertise GET, POST, PUT
+// and DELETE.
+//
+// With a wildcard profile every response allows any origin, without
+// credentials. A "*" mixed into an allowlist, which Validate rejects, is
+// ignored rather than widening the list. With an allowlist, a listed request
+// Origin is echoed back and credentials are allowed; other origins get no
+// CORS headers. Allowlist responses carry Vary: Origin so caches keep them
+// apart. Preflights are answered with 204 here; plain OPTIONS requests are
+// passed on.
+func CORSWithRoutes(p SecurityProfile, routes *Routes) func(http.Handler) http.Handler {
+	origins := parseOrigins(p.CORSAllowOrigin)
+	wildcard := len(origins) == 1 && origins[0] == "*"
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowOrigin := ""
+			switch {
+			case wildcard:
+				allowOrigin = "*"
+			case len(origins) > 0:
+				w.Header().Add("Vary", "Origin")
+				if origin := r.Header.Get("Origin"); origin != "" && slices.Contains(origins, strings.TrimSuffix(origin, "/")) {
+					allowOrigin = origin
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+			if allowOrigin != "" {
+				methods := defaultCORSMethods
+				if routes != nil {
+					methods = routes.Methods(r)
+				}
+				w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+				w.Header().Set("Access-Control-Allow-Methods", allowMethods(methods))
+				w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+WorkspaceHeader+", "+RequestIDHeader)
+				w.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
+				if p.CORSMaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(p.CORSMaxAge.Seconds())))
+				}
+			}
+
+			if isPreflight(r) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isPreflight reports whether r is a CORS preflight. Other OPTIONS requests
+// reach their route, which answers with its own Allow header.
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+}
+
+// parseOrigins splits a comma-separated origin list, dropping blanks and
+// trailing slashes.
+func parseOrigins(list string) []string {
+	var origins []string
+	for _, o := range strings.Split(list, ",") {
+		if o = strings.TrimSuffix(strings.TrimSpace(o), "/"); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
+
+// SecurityHeadersWithProfile returns middleware setting the baseline security
+// headers plus the HSTS and CSP headers configured by p.
+func SecurityHeadersWithProfile(p SecurityProfile) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("X-Frame-Options", "DENY")
+			w.Header().Set("X-XSS-Protection", "1; mode=block")
+			w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+			w.Header().Set("Permissions-Policy", "geolocation=(), microphone=(), camera=()")
+			if p.HSTS != "" {
+				w.Header().Set("Strict-Transport-Security", p.HSTS)
+			}
+			if p.CSP != "" {
+				w.Header().Set("Content-Security-Policy", p.CSP)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func serveProfile(p SecurityProfile, method string) *httptest.ResponseRecorder {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest(method, "/api/integrations", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rr := httptest.NewRecorder()
+	Chain(next, SecurityHeadersWithProfile(p), CORSWithProfile(p)).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestProfile_Production_EnablesHSTSAndRestrictsCORS(t *testing.T) {
+	t.Setenv("CORS_ALLOW_ORIGIN", "")
+	t.Setenv("SECURITY_HSTS", "")
+	t.Setenv("SECURITY_CSP", "")
+
+	rr := serveProfile(LoadSecurityProfile("production"), http.MethodGet)
+	if rr.Header().Get("Strict-Transport-Security") == "" {
+		t.Error("production profile should set Strict-Transport-Security")
+	}
+	if rr.Header().Get("Content-Security-Policy") == "" {
+		t.Error("production profile should set Content-Security-Policy")
+	}
+	if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+		t.Errorf("production profile should not allow cross-origin access by default, got %q", origin)
+	}
+}
+
+func TestProfile_Development_PermissiveWithoutHSTS(t *testing.T) {
+	t.Setenv("CORS_ALLOW_ORIGIN", "")
+	t.Setenv("SECURITY_HSTS", "")
+
+	rr := serveProfile(LoadSecurityProfile("development"), http.MethodGet)
+	if rr.Header().Get("Strict-Transport-Security") != "" {
+		t.Error("development profile should not set HSTS")
+	}
+	if origin := rr.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+		t.Errorf("development profile should allow any origin, got %q", origin)
+	}
+}
+
+func TestProfile_EnvOverrides(t *testing.T) {
+	t.Setenv("CORS_ALLOW_ORIGIN", "https://app.example.com")
+	t.Setenv("SECURITY_HSTS", "off")
+	t.Setenv("SECURITY_CSP", "default-src 'none'")
+
+	p := LoadSecurityProfile("production")
+	if p.CORSAllowOrigin != "https://app.example.com" {
+		t.Errorf("expected overridden origin, got %q", p.CORSAllowOrigin)
+	}
+	if p.HSTS != "" {
+		t.Errorf("SECURITY_HSTS=off should disable HSTS, got %q", p.HSTS)
+	}
+	if p.CSP != "default-src 'none'" {
+		t.Errorf("expected overridden CSP, got %q", p.CSP)
+	}
+}
+
+func TestProfileForEnv_UnknownEnvIsProduction(t *testing.T) {
+	if p := ProfileForEnv("prdo"); p.Name != "production" {
+		t.Errorf("unknown environment should fall back to production, got %s", p.Name)
+	}
+}
+
+// serveCORS sends a request from origin through CORS middleware allowing
+// allowOrigin.
+func serveCORS(allowOrigin, method, origin string) *httptest.ResponseRecorder {
+	p := SecurityProfile{Name: "test", CORSAllowOrigin: allowOrigin}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest(method, "/api/integrations", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "Authorization")
+	}
+	rr := httptest.NewRecorder()
+	CORSWithProfile(p)(next).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCORS_WildcardMode(t *testing.T) {
+	rr := serveCORS("*", http.MethodGet, "https://anywhere.example")
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	// Browsers reject credentials with a wildcard origin, so none are offered.
+	if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("wildcard mode should not allow credentials, got %q", got)
+	}
+}
+
+func TestCORS_AllowlistMatch(t *testing.T) {
+	rr := serveCORS("https://app.example.com, https://admin.example.com/", http.MethodGet, "https://admin.example.com")
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://admin.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the request origin", got)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want true", got)
+	}
+	if got := rr.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q, want Origin", got)
+	}
+}
+
+func TestCORS_AllowlistMiss(t *testing.T) {
+	for _, origin := range []string{"https://evil.example", "https://app.example.com.evil.example", ""} {
+		rr := serveCORS("https://app.example.com", http.MethodGet, origin)
+		for _, h := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials", "Access-Control-Allow-Methods"} {
+			if got := rr.Header().Get(h); got != "" {
+				t.Errorf("origin %q: %s = %q, want none", origin, h, got)
+			}
+		}
+		if rr.Code != http.StatusOK {
+			t.Errorf("origin %q: status = %d; the request itself is still served", origin, rr.Code)
+		}
+		if got := rr.Header().Get("Vary"); got != "Origin" {
+			t.Errorf("origin %q: Vary = %q, want Origin", origin, got)
+		}
+	}
+}
+
+func TestCORS_PreflightWithCredentials(t *testing.T) {
+	rr := serveCORS("https://app.example.com", http.MethodOptions, "https://app.example.com")
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("preflight status = %d, want 204", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Access-Control-Allow-Credentials = %q, want true", got)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(got, "Authorization") {
+		t.Errorf("Access-Control-Allow-Headers = %q, want Authorization allowed", got)
+	}
+
+	rr = serveCORS("https://app.example.com", http.MethodOptions, "https://evil.example")
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("preflight from a disallowed origin got Access-Control-Allow-Origin %q", got)
+	}
+}
+
+func TestCORS_PlainOptionsReachesRoute(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodOptions, "/api/integrations", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rr := httptest.NewRecorder()
+	CORSWithProfile(SecurityProfile{CORSAllowOrigin: "https://app.example.com"})(next).ServeHTTP(rr, req)
+
+	if got := rr.Header().Get("Allow"); got != "GET, HEAD, OPTIONS" {
+		t.Errorf("Allow = %q, want the route's methods", got)
+	}
+}
+
+func TestProfile_MixedWildcardRejected(t *testing.T) {
+	p := SecurityProfile{CORSAllowOrigin: "*, https://app.example.com"}
+	if err := p.Validate(); err == nil {
+		t.Error("expected Validate to reject \"*\" mixed with explicit origins")
+	}
+	for _, ok := range []string{"*", "https://app.example.com,https://admin.example.com", ""} {
+		if err := (SecurityProfile{CORSAllowOrigin: ok}).Validate(); err != nil {
+			t.Errorf("Validate(%q) = %v", ok, err)
+		}
+	}
+
+	// Unvalidated, the mixed list still does not become a full wildcard.
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	CORSWithProfile(p)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(rr, req)
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none for an unlisted origin", got)
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recovery middleware turns a panicking handler into a 500 JSON response
+// naming the request ID instead of a dropped connection, logging the panic and its stack with the
+// request method and path. It should be the outermost middleware so panics
+// in other middleware are caught too. http.ErrAbortHandler is re-panicked so
+// net/http still aborts the response silently.
+func Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoveryWriter{ResponseWriter: w}
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(rec)
+			}
+			// RequestID normally runs inside Recovery, so its ID is on the
+			// response rather than in this request's context.
+			id := RequestIDFromContext(r.Context())
+			if id == "" {
+				id = w.Header().Get(RequestIDHeader)
+			}
+			prefix := ""
+			if id != "" {
+				prefix = "[req " + id + "] "
+			}
+			log.Printf("%spanic serving %s %s: %v\n%s", prefix, r.Method, r.URL.Path, rec, debug.Stack())
+			if rw.wroteHeader {
+				// Too late to change the status; the client gets a truncated response.
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			body := map[string]string{"error": "internal server error"}
+			if id != "" {
+				body["request_id"] = id
+			}
+			json.NewEncoder(w).Encode(body)
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// recoveryWriter records whether the response has started, after which a
+// panic can no longer be reported with a status code.
+type recoveryWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (rw *recoveryWriter) WriteHeader(code int) {
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recoveryWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rw *recoveryWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecovery_PanicBecomes500JSON(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]int
+		m["boom"]++ // assignment to entry in nil map
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("still here"))
+	})
+	srv := httptest.NewServer(Chain(mux, Recovery, RequestID))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/panic")
+	if err != nil {
+		t.Fatalf("panicking request failed at the transport level: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var body map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body["error"] != "internal server error" {
+		t.Errorf("body = %v (%v), want {\"error\":\"internal server error\"}", body, err)
+	}
+	if id := resp.Header.Get(RequestIDHeader); id == "" || body["request_id"] != id {
+		t.Errorf("request_id = %q, header = %q; the 500 should name the request ID", body["request_id"], id)
+	}
+
+	// The server keeps serving after the panic.
+	resp, err = http.Get(srv.URL + "/ok")
+	if err != nil {
+		t.Fatalf("request after panic: %v", err)
+	}
+	defer resp.Body.Close()
+	if got, _ := io.ReadAll(resp.Body); resp.StatusCode != http.StatusOK || string(got) != "still here" {
+		t.Errorf("after panic: %d %q", resp.StatusCode, got)
+	}
+}
+
+func TestRecovery_KeepsStartedResponse(t *testing.T) {
+	rr := httptest.NewRecorder()
+	Recovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("late")
+	})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rr.Code != http.StatusAccepted || rr.Body.Len() != 0 {
+		t.Errorf("got %d %q, want the handler's 202 left untouched", rr.Code, rr.Body.String())
+	}
+}
+
+func TestRecovery_RepanicsErrAbortHandler(t *testing.T) {
+	defer func() {
+		if rec := recover(); rec != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", rec)
+		}
+	}()
+	Recovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	t.Error("ErrAbortHandler should propagate")
+}
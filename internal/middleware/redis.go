@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+)
+
+// FailurePolicy decides how a Redis-backed feature behaves while Redis is
+// unreachable: fail open to stay available, or fail closed to stay strict.
+type FailurePolicy string
+
+const (
+	// FailOpen lets the request through without the feature's protection.
+	FailOpen FailurePolicy = "fail-open"
+	// FailClosed rejects the request with 503 Service Unavailable.
+	FailClosed FailurePolicy = "fail-closed"
+)
+
+// ParseFailurePolicy converts a configuration value into a FailurePolicy.
+func ParseFailurePolicy(s string) (FailurePolicy, error) {
+	switch p := FailurePolicy(s); p {
+	case FailOpen, FailClosed:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown failure policy %q", s)
+	}
+}
+
+// redisDegraded counts requests handled while a Redis-backed feature could
+// not reach Redis, labelled by feature and the policy that was applied.
+var redisDegraded = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "gateway_redis_degraded_total",
+		Help: "Requests handled while a Redis-backed feature could not reach Redis",
+	},
+	[]string{"feature", "policy"},
+)
+
+// degrade applies policy after a Redis error in feature. It returns true when
+// the request may proceed; otherwise it has already written a 503 response.
+func degrade(w http.ResponseWriter, r *http.Request, feature string, policy FailurePolicy, err error) bool {
+	redisDegraded.WithLabelValues(feature, string(policy)).Inc()
+	if policy == FailOpen {
+		Logf(r.Context(), "WARNING: %s unavailable, failing open: %v", feature, err)
+		return true
+	}
+	Logf(r.Context(), "ERROR: %s unavailable, failing closed: %v", feature, err)
+	HTTPError(w, "service temporarily unavailable", http.StatusServiceUnavailable)
+	return false
+}
+
+// RedisRateLimiter limits each client IP to limit requests per window using a
+// shared Redis counter, so the limit holds across gateway replicas.
+func RedisRateLimiter(client redis.Cmdable, limit int, window time.Duration, policy FailurePolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := "ratelimit:" + clientIP(r)
+
+			count, retryAfter, err := redisRateCount(r.Context(), client, key, 1, window)
+			if err != nil {
+				if degrade(w, r, "rate_limit", policy, err) {
+					next.ServeHTTP(w, r)
+				}
+				return
+			}
+			if count > int64(limit) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				HTTPError(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+
+			charge := rateCharger(func(n int) (bool, time.Duration) {
+				ctx := r.Context()
+				count, retryAfter, err := redisRateCount(ctx, client, key, n, window)
+				if err != nil {
+					// The request itself was admitted, so the failure
+					// policy already allowed this caller through.
+					return policy == FailOpen, window
+				}
+				if count > int64(limit) {
+					if err := client.DecrBy(ctx, key, int64(n)).Err(); err != nil {
+						Logf(ctx, "Failed to return rate limit charge for %s: %v", key, err)
+					}
+					return false, retryAfter
+				}
+				return true, 0
+			})
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), rateChargerKey{}, charge)))
+		})
+	}
+}
+
+// rateLimitScript adds ARGV[1] to the counter KEYS[1] and returns the new
+// count and the milliseconds left in its window. A counter without a TTL,
+// whether new or left behind by an older gateway, starts a window of ARGV[2]
+// milliseconds in the same step, so no counter can outlive its window.
+var rateLimitScript = redis.NewScript(`
+local count = redis.call("INCRBY", KEYS[1], ARGV[1])
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	ttl = tonumber(ARGV[2])
+	redis.call("PEXPIRE", KEYS[1], ttl)
+end
+return {count, ttl}
+`)
+
+// redisRateCount counts n more requests against key and returns the new
+// count and the time left in key's window.
+func redisRateCount(ctx context.Context, client redis.Cmdable, key string, n int, window time.Duration) (int64, time.Duration, error) {
+	res, err := rateLimitScript.Run(ctx, client, []string{key}, n, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(res) != 2 {
+		return 0, 0, fmt.Errorf("rate limit script returned %d values", len(res))
+	}
+	return res[0], time.Duration(res[1]) * time.Millisecond, nil
+}
+
+// IdempotencyHeader is the request header carrying a client-chosen key that
+// identifies retries of the same logical request.
+const IdempotencyHeader = "Idempotency-Key"
+
+// idempotencyPending marks a key whose first request is still being served.
+const idempotencyPending = "pending"
+
+// idempotentResponse is the stored outcome of the first request with a key.
+type idempotentResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body"`
+}
+
+// Idempotency makes non-GET requests carrying an Idempotency-Key safe to
+// retry. The first request with a key is served and its status and body are
+// stored for ttl; retries within ttl get that response replayed instead of
+// running again, and a retry while the first is still running gets 409
+// Conflict. Keys are scoped to the user (or client IP), method and path, so
+// clients cannot collide with each other. A 5xx response or a panic releases
+// the key so the request can be retried. Requests without the header are not
+// affected.
+func Idempotency(client redis.Cmdable, ttl time.Duration, policy FailurePolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyHeader)
+			if key == "" || r.Method == http.MethodGet || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key = idempotencyKey(r, key)
+
+			first, err := client.SetNX(r.Context(), key, idempotencyPending, ttl).Result()
+			if err != nil {
+				if degrade(w, r, "idempotency", policy, err) {
+					next.ServeHTTP(w, r)
+				}
+				return
+			}
+			if !first {
+				replayIdempotent(w, r, client, key)
+				return
+			}
+
+			rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				// Release the key when the handler panicked or failed, so the
+				// client's retry runs the request again.
+				if !completed || rec.status >= http.StatusInternalServerError {
+					if err := client.Del(context.WithoutCancel(r.Context()), key).Err(); err != nil {
+						Logf(r.Context(), "Failed to release idempotency key: %v", err)
+					}
+				}
+			}()
+			next.ServeHTTP(rec, r)
+			completed = true
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+
+			stored, err := json.Marshal(idempotentResponse{Status: rec.status, ContentType: rec.Header().Get("Content-Type"), Body: rec.body.Bytes()})
+			if err == nil {
+				err = client.Set(context.WithoutCancel(r.Context()), key, stored, ttl).Err()
+			}
+			if err != nil {
+				Logf(r.Context(), "Failed to store idempotent response: %v", err)
+			}
+		})
+	}
+}
+
+// idempotencyKey is the Redis key for a request's Idempotency-Key, scoped to
+// the authenticated user, or the client IP without one, and the route.
+func idempotencyKey(r *http.Request, key string) string {
+	scope, _ := r.Context().Value(ContextKeyUserID).(string)
+	if scope == "" {
+		scope = "ip:" + clientIP(r)
+	}
+	sum := sha256.Sum256([]byte(scope + "\x00" + r.Method + "\x00" + r.URL.Path + "\x00" + key))
+	return "idempotency:" + hex.EncodeToString(sum[:])
+}
+
+// replayIdempotent answers a repeated request with the response stored under
+// key, or 409 while the first request is still running.
+func replayIdempotent(w http.ResponseWriter, r *http.Request, client redis.Cmdable, key string) {
+	stored, err := client.Get(r.Context(), key).Bytes()
+	if errors.Is(err, redis.Nil) || (err == nil && string(stored) == idempotencyPending) {
+		HTTPError(w, "a request with this Idempotency-Key is still in progress", http.StatusConflict)
+		return
+	}
+	var resp idempotentResponse
+	if err == nil {
+		err = json.Unmarshal(stored, &resp)
+	}
+	if err != nil {
+		Logf(r.Context(), "Failed to load idempotent response: %v", err)
+		HTTPError(w, "duplicate request", http.StatusConflict)
+		return
+	}
+	if resp.ContentType != "" {
+		w.Header().Set("Content-Type", resp.ContentType)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// recordingWriter passes a response through while keeping a copy of its
+// status and body.
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
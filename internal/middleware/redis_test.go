@@ -0,0 +1,277 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// brokenRedis simulates an unreachable Redis: every command it implements
+// returns an error. Other commands panic via the nil embedded interface.
+type brokenRedis struct {
+	redis.Cmdable
+}
+
+var errRedisDown = errors.New("dial tcp: connection refused")
+
+func (brokenRedis) Incr(context.Context, string) *redis.IntCmd {
+	return redis.NewIntResult(0, errRedisDown)
+}
+
+func (brokenRedis) EvalSha(context.Context, string, []string, ...interface{}) *redis.Cmd {
+	return redis.NewCmdResult(nil, errRedisDown)
+}
+
+func (brokenRedis) SetNX(context.Context, string, interface{}, time.Duration) *redis.BoolCmd {
+	return redis.NewBoolResult(false, errRedisDown)
+}
+
+func serveWith(mw func(http.Handler) http.Handler, req *http.Request) (*httptest.ResponseRecorder, bool) {
+	called := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})
+	rr := httptest.NewRecorder()
+	mw(next).ServeHTTP(rr, req)
+	return rr, called
+}
+
+func TestRedisRateLimiter_RedisDown_FailOpen_PassesThrough(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/integrations", nil)
+	rr, called := serveWith(RedisRateLimiter(brokenRedis{}, 10, time.Minute, FailOpen), req)
+	if !called || rr.Code != http.StatusOK {
+		t.Errorf("fail-open rate limiter should pass through, got %d (called=%v)", rr.Code, called)
+	}
+}
+
+func TestRedisRateLimiter_RedisDown_FailClosed_Returns503(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/integrations", nil)
+	rr, called := serveWith(RedisRateLimiter(brokenRedis{}, 10, time.Minute, FailClosed), req)
+	if called || rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("fail-closed rate limiter should return 503, got %d (called=%v)", rr.Code, called)
+	}
+}
+
+// windowRedis runs rateLimitScript against in-memory counters with a fake
+// clock, expiring each counter when its TTL runs out.
+type windowRedis struct {
+	redis.Cmdable
+	now     time.Time
+	counts  map[string]int64
+	expires map[string]time.Time
+}
+
+func (m *windowRedis) EvalSha(_ context.Context, sha string, keys []string, args ...interface{}) *redis.Cmd {
+	if sha != rateLimitScript.Hash() {
+		return redis.NewCmdResult(nil, errors.New("NOSCRIPT"))
+	}
+	key := keys[0]
+	if exp, ok := m.expires[key]; ok && !m.now.Before(exp) {
+		delete(m.counts, key)
+		delete(m.expires, key)
+	}
+	m.counts[key] += int64(args[0].(int))
+	if _, ok := m.expires[key]; !ok {
+		m.expires[key] = m.now.Add(time.Duration(args[1].(int64)) * time.Millisecond)
+	}
+	return redis.NewCmdResult([]interface{}{m.counts[key], m.expires[key].Sub(m.now).Milliseconds()}, nil)
+}
+
+func TestRedisRateLimiter_RetryAfterIsTimeLeftInWindow(t *testing.T) {
+	rdb := &windowRedis{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), counts: map[string]int64{}, expires: map[string]time.Time{}}
+	mw := RedisRateLimiter(rdb, 2, time.Minute, FailClosed)
+	serve := func() *httptest.ResponseRecorder {
+		rr, _ := serveWith(mw, httptest.NewRequest(http.MethodGet, "/api/integrations", nil))
+		return rr
+	}
+
+	serve()
+	serve()
+	if rr := serve(); rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "60" {
+		t.Fatalf("third request: %d, Retry-After %q; want 429, 60", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	rdb.now = rdb.now.Add(45 * time.Second)
+	if rr := serve(); rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") != "15" {
+		t.Errorf("45s later: %d, Retry-After %q; want 429, 15", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	rdb.now = rdb.now.Add(15 * time.Second)
+	if rr := serve(); rr.Code != http.StatusOK {
+		t.Errorf("after the window: %d, want 200", rr.Code)
+	}
+}
+
+func TestIdempotency_RedisDown_FailClosed_Returns503(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/integration/execute", nil)
+	req.Header.Set(IdempotencyHeader, "abc")
+	rr, called := serveWith(Idempotency(brokenRedis{}, time.Hour, FailClosed), req)
+	if called || rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("fail-closed idempotency should return 503, got %d (called=%v)", rr.Code, called)
+	}
+}
+
+func TestIdempotency_RedisDown_FailOpen_PassesThrough(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/integration/execute", nil)
+	req.Header.Set(IdempotencyHeader, "abc")
+	rr, called := serveWith(Idempotency(brokenRedis{}, time.Hour, FailOpen), req)
+	if !called || rr.Code != http.StatusOK {
+		t.Errorf("fail-open idempotency should pass through, got %d (called=%v)", rr.Code, called)
+	}
+}
+
+func TestIdempotency_NoKey_SkipsRedis(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/integration/execute", nil)
+	rr, called := serveWith(Idempotency(brokenRedis{}, time.Hour, FailClosed), req)
+	if !called || rr.Code != http.StatusOK {
+		t.Errorf("requests without an idempotency key should not touch Redis, got %d", rr.Code)
+	}
+}
+
+// memoryRedis is an in-memory stand-in for the key commands Idempotency
+// uses. Expiry is not simulated.
+type memoryRedis struct {
+	redis.Cmdable
+	mu   sync.Mutex
+	keys map[string]string
+}
+
+func newMemoryRedis() *memoryRedis { return &memoryRedis{keys: make(map[string]string)} }
+
+func (m *memoryRedis) SetNX(_ context.Context, key string, value interface{}, _ time.Duration) *redis.BoolCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.keys[key]; ok {
+		return redis.NewBoolResult(false, nil)
+	}
+	m.keys[key] = fmt.Sprint(value)
+	return redis.NewBoolResult(true, nil)
+}
+
+func (m *memoryRedis) Set(_ context.Context, key string, value interface{}, _ time.Duration) *redis.StatusCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if b, ok := value.([]byte); ok {
+		m.keys[key] = string(b)
+	} else {
+		m.keys[key] = fmt.Sprint(value)
+	}
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (m *memoryRedis) Get(_ context.Context, key string) *redis.StringCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.keys[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(v, nil)
+}
+
+func (m *memoryRedis) Del(_ context.Context, keys ...string) *redis.IntCmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range keys {
+		delete(m.keys, k)
+	}
+	return redis.NewIntResult(int64(len(keys)), nil)
+}
+
+// idempotentRequest serves a POST to path with key as user through mw.
+func idempotentRequest(mw http.Handler, user, path, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	req.Header.Set(IdempotencyHeader, key)
+	req = req.WithContext(context.WithValue(req.Context(), ContextKeyUserID, user))
+	rr := httptest.NewRecorder()
+	mw.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestIdempotency_ReplaysFirstResponse(t *testing.T) {
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"call":%d}`, calls)
+	})
+	mw := Idempotency(newMemoryRedis(), time.Hour, FailClosed)(next)
+
+	first := idempotentRequest(mw, "user-1", "/api/integration/execute", "abc")
+	retry := idempotentRequest(mw, "user-1", "/api/integration/execute", "abc")
+	if calls != 1 {
+		t.Fatalf("handler ran %d times, want 1", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("retry = %d %q, want the first response %d %q", retry.Code, retry.Body.String(), first.Code, first.Body.String())
+	}
+
+	// The same key from another user or on another route is a new request.
+	idempotentRequest(mw, "user-2", "/api/integration/execute", "abc")
+	idempotentRequest(mw, "user-1", "/api/workflow/execute", "abc")
+	if calls != 3 {
+		t.Errorf("handler ran %d times, want 3 once scoped by user and path", calls)
+	}
+}
+
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	status := http.StatusBadGateway
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	})
+	mw := Idempotency(newMemoryRedis(), time.Hour, FailClosed)(next)
+
+	idempotentRequest(mw, "user-1", "/api/integration/execute", "abc")
+	status = http.StatusOK
+	if rr := idempotentRequest(mw, "user-1", "/api/integration/execute", "abc"); rr.Code != http.StatusOK || calls != 2 {
+		t.Errorf("retry after a 5xx should run again, got %d after %d calls", rr.Code, calls)
+	}
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	client := newMemoryRedis()
+	mw := Idempotency(client, time.Hour, FailClosed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	func() {
+		defer func() { recover() }()
+		idempotentRequest(mw, "user-1", "/api/integration/execute", "abc")
+	}()
+	if len(client.keys) != 0 {
+		t.Errorf("panicking request left keys %v", client.keys)
+	}
+}
+
+func TestIdempotency_InProgressConflicts(t *testing.T) {
+	client := newMemoryRedis()
+	var mw http.Handler
+	var inner *httptest.ResponseRecorder
+	mw = Idempotency(client, time.Hour, FailClosed)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if inner == nil {
+			inner = idempotentRequest(mw, "user-1", "/api/integration/execute", "abc")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	idempotentRequest(mw, "user-1", "/api/integration/execute", "abc")
+	if inner.Code != http.StatusConflict {
+		t.Errorf("a retry while the first request runs should get 409, got %d", inner.Code)
+	}
+}
+
+func TestParseFailurePolicy(t *testing.T) {
+	if p, err := ParseFailurePolicy("fail-open"); err != nil || p != FailOpen {
+		t.Errorf("expected FailOpen, got %q, %v", p, err)
+	}
+	if _, err := ParseFailurePolicy("sometimes"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID. A valid ID sent by the client or an
+// upstream proxy is kept; otherwise one is generated. It is echoed on every
+// response.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits accepted IDs to characters that are safe to log and
+// echo in headers.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID assigns each request an ID, stores it in the context under
+// ContextKeyRequestID and sets it on the response, so that error responses
+// and log lines for the request can be tied together. It should run inside
+// Recovery and before every other middleware.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), ContextKeyRequestID, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// newRequestID returns a random (version 4) UUID.
+func newRequestID() string {
+	return uuid.NewString()
+}
+
+// RequestIDFromContext returns the request ID stored in ctx by RequestID, or "".
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ContextKeyRequestID).(string)
+	return id
+}
+
+// Logf logs like log.Printf, prefixing the line with the request ID and, for
+// impersonated requests, the acting admin from ctx.
+func Logf(ctx context.Context, format string, args ...interface{}) {
+	if actor := ActorIDFromContext(ctx); actor != "" {
+		format = "[act " + actor + "] " + format
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		format = "[req " + id + "] " + format
+	}
+	log.Printf(format, args...)
+}
+
+// HTTPError writes a plain-text error like http.Error, naming the request ID
+// set on the response by RequestID so a reported error can be traced.
+func HTTPError(w http.ResponseWriter, message string, status int) {
+	if id := w.Header().Get(RequestIDHeader); id != "" {
+		message += " (request_id " + id + ")"
+	}
+	http.Error(w, message, status)
+}
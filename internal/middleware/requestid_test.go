@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRequestID_GeneratesID(t *testing.T) {
+	var seen string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	})
+	rr := httptest.NewRecorder()
+	RequestID(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if _, err := uuid.Parse(seen); err != nil || rr.Header().Get(RequestIDHeader) != seen {
+		t.Errorf("context ID %q, response header %q", seen, rr.Header().Get(RequestIDHeader))
+	}
+}
+
+func TestRequestID_KeepsValidIncomingID(t *testing.T) {
+	for incoming, keep := range map[string]bool{
+		"edge-7f3a.42":          true,
+		"bad id with spaces":    false,
+		"%s%s%s":                false,
+		strings.Repeat("a", 65): false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, incoming)
+		rr := httptest.NewRecorder()
+		RequestID(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})).ServeHTTP(rr, req)
+
+		if got := rr.Header().Get(RequestIDHeader); (got == incoming) != keep {
+			t.Errorf("incoming %q: response ID %q, keep = %v", incoming, got, keep)
+		}
+	}
+}
+
+func TestRequestID_InErrorResponseAndLog(t *testing.T) {
+	buf := captureLog(t)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set(RequestIDHeader, "trace-123")
+	rr := httptest.NewRecorder()
+
+	Chain(next, RequestID, Logger, AuthWithSecret(testJWTSecret, false)).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized || !strings.Contains(rr.Body.String(), "request_id trace-123") {
+		t.Errorf("got %d %q, want a 401 naming the request ID", rr.Code, rr.Body.String())
+	}
+	if out := buf.String(); !strings.Contains(out, "[req trace-123] [GET] /protected") {
+		t.Errorf("log = %q, want the request ID", out)
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// defaultCORSMethods is advertised for paths no registered route matches.
+var defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}
+
+// Routes registers handlers on a ServeMux together with the methods each
+// accepts, so CORS preflights can advertise only the methods a route
+// actually serves.
+type Routes struct {
+	mux     *http.ServeMux
+	methods map[string][]string // by pattern
+}
+
+// NewRoutes returns a registry that adds routes to mux.
+func NewRoutes(mux *http.ServeMux) *Routes {
+	return &Routes{mux: mux, methods: make(map[string][]string)}
+}
+
+// Handle registers handler for pattern, accepting methods. With no methods
+// the route advertises the default set.
+func (rt *Routes) Handle(pattern string, handler http.Handler, methods ...string) {
+	rt.mux.Handle(pattern, handler)
+	if len(methods) > 0 {
+		rt.methods[pattern] = methods
+	}
+}
+
+// HandleFunc registers handler for pattern, accepting methods; see Handle.
+func (rt *Routes) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request), methods ...string) {
+	rt.Handle(pattern, http.HandlerFunc(handler), methods...)
+}
+
+// Methods returns the methods accepted by the route that serves r, matched
+// the way the ServeMux matches it.
+func (rt *Routes) Methods(r *http.Request) []string {
+	_, pattern := rt.mux.Handler(r)
+	if methods, ok := rt.methods[pattern]; ok {
+		return methods
+	}
+	return defaultCORSMethods
+}
+
+// allowMethods is the Access-Control-Allow-Methods value for methods.
+func allowMethods(methods []string) string {
+	return strings.Join(append(methods[:len(methods):len(methods)], http.MethodOptions), ", ")
+}
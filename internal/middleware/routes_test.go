@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func servePreflight(p SecurityProfile, routes *Routes, path string) *httptest.ResponseRecorder {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	rr := httptest.NewRecorder()
+	CORSWithRoutes(p, routes)(next).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCORSWithRoutes_AdvertisesRouteMethods(t *testing.T) {
+	routes := NewRoutes(http.NewServeMux())
+	noop := func(http.ResponseWriter, *http.Request) {}
+	routes.HandleFunc("/api/integrations", noop, http.MethodGet)
+	routes.HandleFunc("/api/workflow/execute", noop, http.MethodPost)
+	routes.HandleFunc("/api/jobs/", noop, http.MethodGet)
+	routes.HandleFunc("/legacy", noop)
+	p := ProfileForEnv("development")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/integrations", "GET, OPTIONS"},
+		{"/api/workflow/execute", "POST, OPTIONS"},
+		// Subtree patterns match like the ServeMux.
+		{"/api/jobs/4b0c", "GET, OPTIONS"},
+		// Routes without methods, and unknown paths, get the default set.
+		{"/legacy", "GET, POST, PUT, DELETE, OPTIONS"},
+		{"/nowhere", "GET, POST, PUT, DELETE, OPTIONS"},
+	}
+	for _, tt := range tests {
+		rr := servePreflight(p, routes, tt.path)
+		if rr.Code != http.StatusNoContent {
+			t.Errorf("%s: preflight status = %d, want 204", tt.path, rr.Code)
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Methods"); got != tt.want {
+			t.Errorf("%s: Access-Control-Allow-Methods = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestCORSWithProfile_MaxAge(t *testing.T) {
+	p := ProfileForEnv("development")
+	if got := servePreflight(p, nil, "/").Header().Get("Access-Control-Max-Age"); got != "86400" {
+		t.Errorf("default Max-Age = %q, want 86400", got)
+	}
+
+	p.CORSMaxAge = 10 * time.Minute
+	if got := servePreflight(p, nil, "/").Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Max-Age = %q, want 600", got)
+	}
+
+	p.CORSMaxAge = 0
+	if got := servePreflight(p, nil, "/").Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Errorf("zero CORSMaxAge should omit the header, got %q", got)
+	}
+}
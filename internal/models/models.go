@@ -12,12 +12,15 @@ type User struct {
 	Email        string    `json:"email" db:"email"`
 	PasswordHash string    `json:"-" db:"password_hash"`
 	Role         string    `json:"role" db:"role"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	// Permissions grants extra abilities, such as support:impersonate.
+	Permissions pq.StringArray `json:"permissions" db:"permissions"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
 }
 
 type Integration struct {
 	ID           uuid.UUID `json:"id" db:"id"`
 	UserID       uuid.UUID `json:"user_id" db:"user_id"`
+	WorkspaceID  string    `json:"workspace_id" db:"workspace_id"`
 	Provider     string    `json:"provider" db:"provider"`
 	AccessToken  string    `json:"-" db:"access_token"`
 	RefreshToken string    `json:"-" db:"refresh_token"`
@@ -26,6 +29,18 @@ type Integration struct {
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 }
 
+// OAuthAccount links a user to their account with an OAuth login provider.
+type OAuthAccount struct {
+	ID             uuid.UUID `json:"id" db:"id"`
+	UserID         uuid.UUID `json:"user_id" db:"user_id"`
+	Provider       string    `json:"provider" db:"provider"`
+	ProviderUserID string    `json:"provider_user_id" db:"provider_user_id"`
+	Email          string    `json:"email" db:"email"`
+	AccessToken    string    `json:"-" db:"access_token"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
 type APIKey struct {
 	ID        uuid.UUID      `json:"id" db:"id"`
 	UserID    uuid.UUID      `json:"user_id" db:"user_id"`
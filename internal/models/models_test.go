@@ -191,6 +191,23 @@ func TestIntegration_AccessTokenNotInJSON(t *testing.T) {
 	}
 }
 
+func TestOAuthAccount_AccessTokenNotInJSON(t *testing.T) {
+	a := OAuthAccount{
+		ID:             uuid.New(),
+		Provider:       "google",
+		ProviderUserID: "1089",
+		AccessToken:    "ya29.secret-token",
+	}
+
+	data, _ := json.Marshal(a)
+	if strings.Contains(string(data), "ya29.secret-token") {
+		t.Error("AccessToken should NOT be serialized in JSON output (security)")
+	}
+	if !strings.Contains(string(data), `"provider_user_id":"1089"`) {
+		t.Errorf("provider_user_id missing from %s", data)
+	}
+}
+
 func TestIntegration_JSONRoundTrip_Provider(t *testing.T) {
 	orig := Integration{
 		ID:       uuid.New(),
@@ -9,6 +9,7 @@ CREATE TABLE IF NOT EXISTS users (
 CREATE TABLE IF NOT EXISTS integrations (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id VARCHAR(255) NOT NULL DEFAULT '',
     provider VARCHAR(50) NOT NULL,
     access_token TEXT NOT NULL,
     refresh_token TEXT,
@@ -33,6 +34,7 @@ CREATE TABLE IF NOT EXISTS consents (
     user_id UUID REFERENCES users(id) ON DELETE CASCADE,
     provider VARCHAR(50) NOT NULL,
     purpose TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
     status VARCHAR(20) NOT NULL DEFAULT 'pending',
     granted_at TIMESTAMP WITH TIME ZONE,
     revoked_at TIMESTAMP WITH TIME ZONE,
@@ -66,7 +68,115 @@ CREATE TABLE IF NOT EXISTS workflow_executions (
     completed_at TIMESTAMP WITH TIME ZONE
 );
 
+-- Integration Executions: each provider call, linked to the workflow run
+-- that made it (NULL for actions executed directly). Runs are tracked by the
+-- gateway rather than in workflow_executions, so the link is not a foreign key.
+CREATE TABLE IF NOT EXISTS integration_executions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id VARCHAR(255) NOT NULL DEFAULT '',
+    workflow_run_id UUID,
+    provider VARCHAR(50) NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    actor_id VARCHAR(255),
+    executed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Transactional outbox: events are inserted in the same transaction as the
+-- state change and delivered afterwards by the outbox dispatcher
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY,
+    topic VARCHAR(255) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Async integration actions queued with ?async=true and run by the job workers
+CREATE TABLE IF NOT EXISTS jobs (
+    id UUID PRIMARY KEY,
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    workspace_id VARCHAR(255) NOT NULL DEFAULT '',
+    request JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    result JSONB,
+    error TEXT,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    available_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Audit trail of support impersonation tokens
+CREATE TABLE IF NOT EXISTS impersonation_audit (
+    id UUID PRIMARY KEY,
+    actor_id UUID NOT NULL REFERENCES users(id),
+    target_id UUID NOT NULL REFERENCES users(id),
+    reason TEXT NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- OAuth logins, linked to users by the provider's own user ID. access_token
+-- is encrypted under the user's data key when token encryption is configured.
+CREATE TABLE IF NOT EXISTS oauth_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    provider_user_id VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    access_token TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT unique_oauth_provider_user UNIQUE(provider, provider_user_id)
+);
+
+-- High-water marks of change polls such as Airtable list_changes, keyed by
+-- provider, user, workspace and polled resource.
+CREATE TABLE IF NOT EXISTS poll_watermarks (
+    key TEXT PRIMARY KEY,
+    mark TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Integrations created before workspace scoping belong to the default workspace
+ALTER TABLE integrations ADD COLUMN IF NOT EXISTS workspace_id VARCHAR(255) NOT NULL DEFAULT '';
+
+-- Each user keeps one connection per workspace and provider; older duplicates
+-- are dropped before the unique index replaces the plain lookup index
+DELETE FROM integrations a USING integrations b
+WHERE a.user_id = b.user_id AND a.workspace_id = b.workspace_id AND a.provider = b.provider
+    AND (COALESCE(a.created_at, 'epoch'), a.id) < (COALESCE(b.created_at, 'epoch'), b.id);
+DROP INDEX IF EXISTS idx_integrations_user_workspace_provider;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_integrations_user_workspace_provider ON integrations(user_id, workspace_id, provider);
+
+-- Users created before per-user encryption and impersonation have no data
+-- key and no extra permissions
+ALTER TABLE users ADD COLUMN IF NOT EXISTS data_key TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS permissions TEXT[] NOT NULL DEFAULT '{}';
+
+-- Consents granted before scope tracking have no recorded scopes
+ALTER TABLE consents ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL DEFAULT '{}';
+
+-- Executions recorded before impersonation tracking have no actor
+ALTER TABLE integration_executions ADD COLUMN IF NOT EXISTS actor_id VARCHAR(255);
+ALTER TABLE integration_executions DROP CONSTRAINT IF EXISTS integration_executions_workflow_run_id_fkey;
+
+-- Emails are unique regardless of case; OAuth logins match on lower(email)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_lower ON users(lower(email));
+
 -- Indexes for performance
+CREATE INDEX IF NOT EXISTS idx_integration_executions_user ON integration_executions(user_id, workspace_id, executed_at);
+CREATE INDEX IF NOT EXISTS idx_integration_executions_run ON integration_executions(workflow_run_id);
+CREATE INDEX IF NOT EXISTS idx_impersonation_audit_target ON impersonation_audit(target_id, issued_at);
+CREATE INDEX IF NOT EXISTS idx_oauth_accounts_user_id ON oauth_accounts(user_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(next_attempt_at) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs(available_at) WHERE status IN ('queued', 'running');
 CREATE INDEX IF NOT EXISTS idx_integrations_user_id ON integrations(user_id);
 CREATE INDEX IF NOT EXISTS idx_integrations_provider ON integrations(provider);
 CREATE INDEX IF NOT EXISTS idx_consents_user_id ON consents(user_id);
@@ -0,0 +1,223 @@
+// Package oauthuser identifies the user behind an OAuth access token. Each
+// login provider has a UserInfoResolver that calls the provider's profile
+// endpoint; supporting a new login provider means writing a resolver and
+// adding it to Default.
+package oauthuser
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// httpClient is shared by profile lookups. Like the token exchange, it must
+// not wait on a provider forever.
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// ErrUnverifiedEmail is returned when the provider has not verified the
+// user's email address, which therefore cannot be trusted to link accounts.
+var ErrUnverifiedEmail = errors.New("oauth provider has not verified the email address")
+
+// UserInfoResolver looks up the account an access token belongs to.
+// providerID is the provider's stable identifier for the user; email and
+// name may be empty when the provider does not share them.
+type UserInfoResolver interface {
+	Resolve(ctx context.Context, accessToken string) (providerID, email, name string, err error)
+}
+
+// VerifiesEmail reports whether r only returns email addresses the provider
+// has verified. Only those may link a login to an existing account by email;
+// resolvers opt out by implementing EmailUnverified.
+func VerifiesEmail(r UserInfoResolver) bool {
+	u, ok := r.(interface{ EmailUnverified() bool })
+	return !ok || !u.EmailUnverified()
+}
+
+// Default returns a resolver for every supported login provider, keyed by
+// provider name. Callers get their own map and may replace entries.
+func Default() map[string]UserInfoResolver {
+	return map[string]UserInfoResolver{
+		"google":    &GoogleResolver{},
+		"github":    &GitHubResolver{},
+		"microsoft": &MicrosoftResolver{},
+	}
+}
+
+// GoogleResolver reads the OpenID profile from Google's userinfo endpoint.
+type GoogleResolver struct {
+	UserInfoURL string // empty uses https://www.googleapis.com/oauth2/v2/userinfo
+}
+
+func (g *GoogleResolver) Resolve(ctx context.Context, accessToken string) (string, string, string, error) {
+	endpoint := g.UserInfoURL
+	if endpoint == "" {
+		endpoint = "https://www.googleapis.com/oauth2/v2/userinfo"
+	}
+	var profile struct {
+		ID            string `json:"id"`
+		Email         string `json:"email"`
+		VerifiedEmail bool   `json:"verified_email"`
+		Name          string `json:"name"`
+	}
+	if err := getJSON(ctx, nil, endpoint, accessToken, "", &profile); err != nil {
+		return "", "", "", err
+	}
+	if profile.ID == "" {
+		return "", "", "", errors.New("google userinfo has no id")
+	}
+	if profile.Email != "" && !profile.VerifiedEmail {
+		return "", "", "", ErrUnverifiedEmail
+	}
+	return profile.ID, profile.Email, profile.Name, nil
+}
+
+// GitHubResolver reads the user from GitHub's REST API. Users who keep
+// their email private have none on their profile, so the primary verified
+// address is read from /user/emails, which the user:email scope allows.
+type GitHubResolver struct {
+	APIBaseURL string       // empty uses https://api.github.com
+	HTTPClient *http.Client // nil uses a client with a 15 second timeout
+}
+
+// githubAccept is the media type GitHub recommends for its REST API.
+const githubAccept = "application/vnd.github+json"
+
+// GitHubUser is the profile GitHub returns for the owner of a token.
+type GitHubUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (g *GitHubResolver) base() string {
+	if g.APIBaseURL == "" {
+		return "https://api.github.com"
+	}
+	return g.APIBaseURL
+}
+
+// User reads the token owner's profile from GET /user. Email is the address
+// on the public profile, which may be empty.
+func (g *GitHubResolver) User(ctx context.Context, accessToken string) (*GitHubUser, error) {
+	var user GitHubUser
+	if err := getJSON(ctx, g.HTTPClient, g.base()+"/user", accessToken, githubAccept, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, errors.New("github user has no id")
+	}
+	return &user, nil
+}
+
+func (g *GitHubResolver) Resolve(ctx context.Context, accessToken string) (string, string, string, error) {
+	user, err := g.User(ctx, accessToken)
+	if err != nil {
+		return "", "", "", err
+	}
+	name := user.Name
+	if name == "" {
+		name = user.Login
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, g.HTTPClient, g.base()+"/user/emails", accessToken, githubAccept, &emails); err != nil {
+		return "", "", "", err
+	}
+	email := ""
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			email = e.Email
+		}
+	}
+	if email == "" {
+		// GitHub only shows verified addresses on a public profile.
+		email = user.Email
+	}
+	return strconv.FormatInt(user.ID, 10), email, name, nil
+}
+
+// MicrosoftResolver reads the signed-in user from Microsoft Graph. Work
+// accounts without a mailbox have no mail, so the user principal name, which
+// is email-shaped, stands in.
+type MicrosoftResolver struct {
+	GraphURL string // empty uses https://graph.microsoft.com/v1.0/me
+}
+
+// EmailUnverified reports true: mail and userPrincipalName are set by the
+// tenant's admin and Graph does not say whether anyone verified them, so
+// any tenant could claim another user's address.
+func (m *MicrosoftResolver) EmailUnverified() bool { return true }
+
+func (m *MicrosoftResolver) Resolve(ctx context.Context, accessToken string) (string, string, string, error) {
+	endpoint := m.GraphURL
+	if endpoint == "" {
+		endpoint = "https://graph.microsoft.com/v1.0/me"
+	}
+	var me struct {
+		ID                string `json:"id"`
+		DisplayName       string `json:"displayName"`
+		Mail              string `json:"mail"`
+		UserPrincipalName string `json:"userPrincipalName"`
+	}
+	if err := getJSON(ctx, nil, endpoint, accessToken, "", &me); err != nil {
+		return "", "", "", err
+	}
+	if me.ID == "" {
+		return "", "", "", errors.New("microsoft graph user has no id")
+	}
+	email := me.Mail
+	if email == "" {
+		email = me.UserPrincipalName
+	}
+	return me.ID, email, me.DisplayName, nil
+}
+
+// StatusError is returned when a profile endpoint answers with a status other
+// than 200 OK.
+type StatusError struct {
+	Path       string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("userinfo endpoint %s returned %d", e.Path, e.StatusCode)
+}
+
+// getJSON fetches endpoint with a bearer token through client, or httpClient
+// when nil, and decodes the reply into out.
+func getJSON(ctx context.Context, client *http.Client, endpoint, accessToken, accept string, out interface{}) error {
+	if client == nil {
+		client = httpClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("building userinfo request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetching user info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{Path: req.URL.Path, StatusCode: resp.StatusCode}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding user info: %w", err)
+	}
+	return nil
+}
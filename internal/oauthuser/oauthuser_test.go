@@ -0,0 +1,109 @@
+package oauthuser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newProfileServer answers each path with its JSON body, requiring the
+// bearer token "at".
+func newProfileServer(t *testing.T, replies map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer at" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reply, ok := replies[r.URL.Path]
+		if !ok {
+			t.Errorf("unexpected request %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(reply))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGoogleResolver(t *testing.T) {
+	srv := newProfileServer(t, map[string]string{
+		"/userinfo": `{"id":"1089","email":"ada@example.com","verified_email":true,"name":"Ada Lovelace","picture":"https://x"}`,
+	})
+	id, email, name, err := (&GoogleResolver{UserInfoURL: srv.URL + "/userinfo"}).Resolve(context.Background(), "at")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if id != "1089" || email != "ada@example.com" || name != "Ada Lovelace" {
+		t.Errorf("got %q %q %q", id, email, name)
+	}
+
+	if _, _, _, err := (&GoogleResolver{UserInfoURL: srv.URL + "/userinfo"}).Resolve(context.Background(), "expired"); err == nil {
+		t.Error("expected an error for a rejected token")
+	}
+}
+
+func TestGoogleResolver_UnverifiedEmail(t *testing.T) {
+	srv := newProfileServer(t, map[string]string{
+		"/userinfo": `{"id":"1089","email":"ada@example.com","verified_email":false}`,
+	})
+	_, _, _, err := (&GoogleResolver{UserInfoURL: srv.URL + "/userinfo"}).Resolve(context.Background(), "at")
+	if !errors.Is(err, ErrUnverifiedEmail) {
+		t.Errorf("expected ErrUnverifiedEmail, got %v", err)
+	}
+}
+
+func TestGitHubResolver_PrivateEmail(t *testing.T) {
+	srv := newProfileServer(t, map[string]string{
+		"/user": `{"id":583231,"login":"octocat","name":null,"email":null}`,
+		"/user/emails": `[{"email":"old@example.com","primary":false,"verified":true},
+			{"email":"octocat@example.com","primary":true,"verified":true}]`,
+	})
+	id, email, name, err := (&GitHubResolver{APIBaseURL: srv.URL}).Resolve(context.Background(), "at")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if id != "583231" || email != "octocat@example.com" || name != "octocat" {
+		t.Errorf("got %q %q %q", id, email, name)
+	}
+}
+
+func TestGitHubResolver_UnverifiedPrimaryIgnored(t *testing.T) {
+	srv := newProfileServer(t, map[string]string{
+		"/user":        `{"id":7,"login":"mona","name":"Mona Lisa","email":null}`,
+		"/user/emails": `[{"email":"mona@example.com","primary":true,"verified":false}]`,
+	})
+	id, email, name, err := (&GitHubResolver{APIBaseURL: srv.URL}).Resolve(context.Background(), "at")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if id != "7" || email != "" || name != "Mona Lisa" {
+		t.Errorf("got %q %q %q, want no email", id, email, name)
+	}
+}
+
+func TestVerifiesEmail(t *testing.T) {
+	if !VerifiesEmail(&GoogleResolver{}) || !VerifiesEmail(&GitHubResolver{}) {
+		t.Error("Google and GitHub only return verified emails")
+	}
+	if VerifiesEmail(&MicrosoftResolver{}) {
+		t.Error("Microsoft Graph mail and userPrincipalName are not verified")
+	}
+}
+
+func TestDefault_CoversLoginProviders(t *testing.T) {
+	r := Default()
+	for _, provider := range []string{"google", "github", "microsoft"} {
+		if r[provider] == nil {
+			t.Errorf("no resolver for %s", provider)
+		}
+	}
+	// Each call returns a fresh map.
+	delete(r, "google")
+	if Default()["google"] == nil {
+		t.Error("Default shares its map between callers")
+	}
+}
@@ -0,0 +1,165 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Deliverer sends one event to its destination. Delivery may be repeated, so
+// receivers should deduplicate on Event.ID.
+type Deliverer interface {
+	Deliver(ctx context.Context, e Event) error
+}
+
+// DelivererFunc adapts a function to the Deliverer interface.
+type DelivererFunc func(ctx context.Context, e Event) error
+
+// Deliver calls f(ctx, e).
+func (f DelivererFunc) Deliver(ctx context.Context, e Event) error { return f(ctx, e) }
+
+// LogDeliverer "delivers" events by logging them. It is used when no event
+// destination is configured so the outbox still drains.
+var LogDeliverer = DelivererFunc(func(_ context.Context, e Event) error {
+	log.Printf("Outbox event %s (%s): %s", e.ID, e.Topic, e.Payload)
+	return nil
+})
+
+// HTTPDeliverer POSTs each event as JSON to URL, signing the body with an
+// HMAC-SHA256 X-Signature-256 header when Secret is set.
+type HTTPDeliverer struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+// Deliver posts e and treats any non-2xx response as a failure.
+func (d *HTTPDeliverer) Deliver(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(d.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := d.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("event endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Dispatcher delivers outbox events in the background.
+type Dispatcher struct {
+	store     Store
+	deliverer Deliverer
+	// BatchSize is the maximum number of events claimed per pass.
+	BatchSize int
+	// Lease is how long a claimed event is hidden from other passes. An event
+	// whose dispatcher crashes mid-delivery is redelivered once it expires.
+	Lease time.Duration
+	// BaseBackoff is the retry delay after the first failure; it doubles per
+	// attempt up to MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	now func() time.Time
+}
+
+// NewDispatcher creates a dispatcher with default batching and backoff.
+func NewDispatcher(store Store, deliverer Deliverer) *Dispatcher {
+	return &Dispatcher{
+		store:       store,
+		deliverer:   deliverer,
+		BatchSize:   50,
+		Lease:       time.Minute,
+		BaseBackoff: 5 * time.Second,
+		MaxBackoff:  10 * time.Minute,
+		now:         time.Now,
+	}
+}
+
+// DispatchOnce claims one batch of due events and attempts each, returning the
+// number delivered.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	events, err := d.store.Claim(ctx, d.BatchSize, d.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, e := range events {
+		if err := d.deliverer.Deliver(ctx, e); err != nil {
+			retryAt := d.now().Add(d.backoff(e.Attempts))
+			log.Printf("Outbox delivery of %s (%s) failed, retrying at %s: %v", e.ID, e.Topic, retryAt.Format(time.RFC3339), err)
+			if err := d.store.MarkFailed(ctx, e.ID, err.Error(), retryAt); err != nil {
+				log.Printf("Failed to reschedule outbox event %s: %v", e.ID, err)
+			}
+			continue
+		}
+		// If this fails the lease expires and the event is delivered again,
+		// which at-least-once receivers must tolerate.
+		if err := d.store.MarkSent(ctx, e.ID); err != nil {
+			log.Printf("Failed to mark outbox event %s sent: %v", e.ID, err)
+			continue
+		}
+		delivered++
+	}
+	return delivered, nil
+}
+
+// backoff returns the retry delay after attempts previous failures.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.BaseBackoff
+	for i := 0; i < attempts && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxBackoff {
+		delay = d.MaxBackoff
+	}
+	return delay
+}
+
+// Run dispatches on every interval tick until ctx is cancelled. A batch
+// already claimed when ctx is cancelled is delivered before Run returns.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if ctx.Err() != nil {
+				return
+			}
+			if _, err := d.DispatchOnce(context.WithoutCancel(ctx)); err != nil {
+				log.Printf("Outbox dispatch failed: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package outbox implements a transactional outbox: events are written in the
+// same database transaction as the state change that produced them, and a
+// background Dispatcher delivers them afterwards with retries. An event is
+// only marked sent once delivery succeeds, so delivery is at-least-once even
+// if the process crashes between commit and delivery.
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is a message waiting in, or delivered from, the outbox.
+type Event struct {
+	ID        uuid.UUID       `json:"id"`
+	Topic     string          `json:"topic"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	SentAt    *time.Time      `json:"sent_at,omitempty"`
+}
+
+// Execer is satisfied by *sql.DB and *sql.Tx. Passing the transaction that
+// performs a state change makes the event part of that transaction.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Publisher accepts events for later delivery.
+type Publisher interface {
+	Publish(ctx context.Context, topic string, payload interface{}) error
+}
+
+// Store is the dispatcher's view of the outbox.
+type Store interface {
+	// Claim leases up to limit pending events that are due for delivery.
+	// A claimed event is not returned again until lease has passed, so an
+	// event claimed by a dispatcher that crashes is redelivered later.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Event, error)
+	// MarkSent records successful delivery; the event is never claimed again.
+	MarkSent(ctx context.Context, id uuid.UUID) error
+	// MarkFailed records a failed attempt and schedules the next one.
+	MarkFailed(ctx context.Context, id uuid.UUID, errMsg string, retryAt time.Time) error
+}
+
+// newEvent validates topic and encodes payload into a pending event.
+func newEvent(topic string, payload interface{}) (Event, error) {
+	if topic == "" {
+		return Event{}, errors.New("event topic is required")
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("encode event payload: %w", err)
+	}
+	return Event{ID: uuid.New(), Topic: topic, Payload: data, CreatedAt: time.Now()}, nil
+}
+
+// Enqueue writes an event using exec. Call it with the *sql.Tx that performs
+// the related state change so both commit or roll back together.
+func Enqueue(ctx context.Context, exec Execer, topic string, payload interface{}) error {
+	e, err := newEvent(topic, payload)
+	if err != nil {
+		return err
+	}
+	_, err = exec.ExecContext(ctx,
+		`INSERT INTO outbox_events (id, topic, payload, created_at, next_attempt_at) VALUES ($1, $2, $3, $4, $4)`,
+		e.ID, e.Topic, []byte(e.Payload), e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("enqueue outbox event: %w", err)
+	}
+	return nil
+}
+
+// memoryEntry is an event plus its scheduling state in a MemoryStore.
+type memoryEntry struct {
+	event     Event
+	available time.Time // not claimable before this time
+}
+
+// MemoryStore is an in-process outbox used when no database is configured
+// (development, tests and OFFLINE mode). Events do not survive a restart, and
+// delivered events are dropped so the store only grows with the backlog.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]*memoryEntry
+	now     func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory outbox.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[uuid.UUID]*memoryEntry), now: time.Now}
+}
+
+// Publish adds an event that is immediately due for delivery.
+func (s *MemoryStore) Publish(_ context.Context, topic string, payload interface{}) error {
+	e, err := newEvent(topic, payload)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.entries[e.ID] = &memoryEntry{event: e, available: s.now()}
+	s.mu.Unlock()
+	return nil
+}
+
+// Claim returns due, unsent events oldest first and leases them.
+func (s *MemoryStore) Claim(_ context.Context, limit int, lease time.Duration) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var due []*memoryEntry
+	for _, entry := range s.entries {
+		if !entry.available.After(now) {
+			due = append(due, entry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].event.CreatedAt.Before(due[j].event.CreatedAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	claimed := make([]Event, 0, len(due))
+	for _, entry := range due {
+		entry.available = now.Add(lease)
+		claimed = append(claimed, entry.event)
+	}
+	return claimed, nil
+}
+
+// MarkSent removes id, which has been delivered.
+func (s *MemoryStore) MarkSent(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, id)
+	return nil
+}
+
+// MarkFailed records a failed attempt and reschedules id for retryAt.
+func (s *MemoryStore) MarkFailed(_ context.Context, id uuid.UUID, errMsg string, retryAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[id]; ok {
+		entry.event.Attempts++
+		entry.event.LastError = errMsg
+		entry.available = retryAt
+	}
+	return nil
+}
+
+// Pending returns the number of events not yet delivered.
+func (s *MemoryStore) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
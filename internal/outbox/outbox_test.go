@@ -0,0 +1,140 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock is a controllable time source for MemoryStore and Dispatcher.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestOutbox() (*MemoryStore, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryStore()
+	store.now = clock.now
+	return store, clock
+}
+
+func TestDispatchOnce_DeliversAndMarksSent(t *testing.T) {
+	store, clock := newTestOutbox()
+	ctx := context.Background()
+	if err := store.Publish(ctx, "integration.executed", map[string]string{"provider": "slack"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	var got []Event
+	d := NewDispatcher(store, DelivererFunc(func(_ context.Context, e Event) error {
+		got = append(got, e)
+		return nil
+	}))
+	d.now = clock.now
+
+	n, err := d.DispatchOnce(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("DispatchOnce = %d, %v; want 1, nil", n, err)
+	}
+	if len(got) != 1 || got[0].Topic != "integration.executed" || string(got[0].Payload) != `{"provider":"slack"}` {
+		t.Fatalf("delivered %+v", got)
+	}
+	if store.Pending() != 0 {
+		t.Errorf("Pending = %d, want 0", store.Pending())
+	}
+
+	// A sent event is never delivered again.
+	clock.t = clock.t.Add(time.Hour)
+	if n, _ := d.DispatchOnce(ctx); n != 0 {
+		t.Errorf("second DispatchOnce delivered %d events, want 0", n)
+	}
+}
+
+func TestDispatchOnce_RedeliversAfterCrash(t *testing.T) {
+	store, clock := newTestOutbox()
+	ctx := context.Background()
+	if err := store.Publish(ctx, "integration.executed", map[string]string{"provider": "jira"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	// Simulate a dispatcher that claimed the event and crashed before
+	// marking it sent.
+	claimed, err := store.Claim(ctx, 10, time.Minute)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("Claim = %d events, %v", len(claimed), err)
+	}
+
+	deliveries := 0
+	d := NewDispatcher(store, DelivererFunc(func(_ context.Context, e Event) error {
+		if e.ID != claimed[0].ID {
+			t.Errorf("delivered %s, want %s", e.ID, claimed[0].ID)
+		}
+		deliveries++
+		return nil
+	}))
+	d.now = clock.now
+
+	// Still leased: nothing is delivered yet.
+	if n, _ := d.DispatchOnce(ctx); n != 0 {
+		t.Fatalf("delivered %d events while leased, want 0", n)
+	}
+
+	clock.t = clock.t.Add(time.Minute + time.Second)
+	n, err := d.DispatchOnce(ctx)
+	if err != nil || n != 1 || deliveries != 1 {
+		t.Fatalf("DispatchOnce after lease = %d, %v (deliveries %d); want 1", n, err, deliveries)
+	}
+	if store.Pending() != 0 {
+		t.Errorf("Pending = %d, want 0", store.Pending())
+	}
+}
+
+func TestDispatchOnce_RetriesWithBackoff(t *testing.T) {
+	store, clock := newTestOutbox()
+	ctx := context.Background()
+	if err := store.Publish(ctx, "integration.executed", nil); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	fail := true
+	d := NewDispatcher(store, DelivererFunc(func(context.Context, Event) error {
+		if fail {
+			return errors.New("endpoint down")
+		}
+		return nil
+	}))
+	d.now = clock.now
+
+	if n, _ := d.DispatchOnce(ctx); n != 0 {
+		t.Fatalf("delivered %d events on failure, want 0", n)
+	}
+
+	// Not retried before the backoff elapses.
+	clock.t = clock.t.Add(d.BaseBackoff - time.Second)
+	if n, _ := d.DispatchOnce(ctx); n != 0 {
+		t.Fatalf("retried before backoff elapsed")
+	}
+
+	fail = false
+	clock.t = clock.t.Add(2 * time.Second)
+	if n, _ := d.DispatchOnce(ctx); n != 1 {
+		t.Fatalf("retry delivered %d events, want 1", n)
+	}
+	if store.Pending() != 0 {
+		t.Errorf("Pending = %d, want 0", store.Pending())
+	}
+}
+
+func TestBackoff_Caps(t *testing.T) {
+	d := NewDispatcher(NewMemoryStore(), LogDeliverer)
+	if got := d.backoff(0); got != d.BaseBackoff {
+		t.Errorf("backoff(0) = %s, want %s", got, d.BaseBackoff)
+	}
+	if got := d.backoff(2); got != 4*d.BaseBackoff {
+		t.Errorf("backoff(2) = %s, want %s", got, 4*d.BaseBackoff)
+	}
+	if got := d.backoff(50); got != d.MaxBackoff {
+		t.Errorf("backoff(50) = %s, want %s", got, d.MaxBackoff)
+	}
+}
@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SQLStore is the PostgreSQL-backed outbox, stored in the outbox_events table.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates an outbox backed by db.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// Publish enqueues an event on its own. Callers changing state in a
+// transaction should use Enqueue with that transaction instead.
+func (s *SQLStore) Publish(ctx context.Context, topic string, payload interface{}) error {
+	return Enqueue(ctx, s.db, topic, payload)
+}
+
+// Claim leases due events by pushing their next_attempt_at past the lease.
+// SKIP LOCKED lets several dispatchers claim concurrently without overlap.
+func (s *SQLStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE outbox_events SET next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE sent_at IS NULL AND next_attempt_at <= NOW()
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, topic, payload, attempts, COALESCE(last_error, ''), created_at`,
+		limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.Topic, &payload, &e.Attempts, &e.LastError, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan outbox event: %w", err)
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// MarkSent records successful delivery of id.
+func (s *SQLStore) MarkSent(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE outbox_events SET sent_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("mark outbox event sent: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed attempt and reschedules id for retryAt.
+func (s *SQLStore) MarkFailed(ctx context.Context, id uuid.UUID, errMsg string, retryAt time.Time) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE outbox_events SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1`,
+		id, errMsg, retryAt)
+	if err != nil {
+		return fmt.Errorf("mark outbox event failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Workspace plans, as stored in workspaces.plan.
+const (
+	PlanFree       = "free"
+	PlanPro        = "pro"
+	PlanEnterprise = "enterprise"
+)
+
+// PlanStore looks up the plan of a workspace. Unknown workspaces, including
+// a user's default workspace "", are on PlanFree.
+type PlanStore interface {
+	Plan(ctx context.Context, workspaceID string) (string, error)
+}
+
+// MemoryPlanStore is an in-process PlanStore used when no database is
+// configured (development and tests).
+type MemoryPlanStore struct {
+	mu    sync.RWMutex
+	plans map[string]string
+}
+
+// NewMemoryPlanStore creates an in-memory plan store with every workspace on
+// PlanFree.
+func NewMemoryPlanStore() *MemoryPlanStore {
+	return &MemoryPlanStore{plans: make(map[string]string)}
+}
+
+// Put sets the plan of workspaceID.
+func (s *MemoryPlanStore) Put(workspaceID, plan string) {
+	s.mu.Lock()
+	s.plans[workspaceID] = plan
+	s.mu.Unlock()
+}
+
+// Plan returns the plan set for workspaceID.
+func (s *MemoryPlanStore) Plan(_ context.Context, workspaceID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if plan, ok := s.plans[workspaceID]; ok {
+		return plan, nil
+	}
+	return PlanFree, nil
+}
+
+// SQLPlanStore reads plans from the workspaces table.
+type SQLPlanStore struct {
+	db *sql.DB
+}
+
+// NewSQLPlanStore creates a plan store backed by db.
+func NewSQLPlanStore(db *sql.DB) *SQLPlanStore {
+	return &SQLPlanStore{db: db}
+}
+
+// Plan returns the plan of workspaceID.
+func (s *SQLPlanStore) Plan(ctx context.Context, workspaceID string) (string, error) {
+	if workspaceID == "" {
+		return PlanFree, nil
+	}
+	var plan sql.NullString
+	err := s.db.QueryRowContext(ctx, `SELECT plan FROM workspaces WHERE id = $1`, workspaceID).Scan(&plan)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && plan.String == "") {
+		return PlanFree, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("load workspace plan: %w", err)
+	}
+	return plan.String, nil
+}
@@ -0,0 +1,215 @@
+// Package rbac defines workspace roles, their permissions and the role
+// hierarchy. It is shared by the gateway and the auth service so both agree
+// on what a role grants.
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Role represents a role in the RBAC system
+type Role string
+
+const (
+	RoleAdmin     Role = "admin"
+	RoleDeveloper Role = "developer"
+	RoleUser      Role = "user"
+	RoleViewer    Role = "viewer"
+)
+
+// Permission represents a specific permission
+type Permission string
+
+const (
+	// Integration permissions
+	PermissionIntegrationRead    Permission = "integration:read"
+	PermissionIntegrationWrite   Permission = "integration:write"
+	PermissionIntegrationDelete  Permission = "integration:delete"
+	PermissionIntegrationExecute Permission = "integration:execute"
+
+	// API Key permissions
+	PermissionAPIKeyRead   Permission = "apikey:read"
+	PermissionAPIKeyCreate Permission = "apikey:create"
+	PermissionAPIKeyRevoke Permission = "apikey:revoke"
+
+	// User management permissions
+	PermissionUserRead   Permission = "user:read"
+	PermissionUserWrite  Permission = "user:write"
+	PermissionUserDelete Permission = "user:delete"
+
+	// Workspace permissions
+	PermissionWorkspaceRead   Permission = "workspace:read"
+	PermissionWorkspaceWrite  Permission = "workspace:write"
+	PermissionWorkspaceDelete Permission = "workspace:delete"
+)
+
+// RolePermissions defines default permissions for each role
+var RolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermissionIntegrationRead,
+		PermissionIntegrationWrite,
+		PermissionIntegrationDelete,
+		PermissionIntegrationExecute,
+		PermissionAPIKeyRead,
+		PermissionAPIKeyCreate,
+		PermissionAPIKeyRevoke,
+		PermissionUserRead,
+		PermissionUserWrite,
+		PermissionUserDelete,
+		PermissionWorkspaceRead,
+		PermissionWorkspaceWrite,
+		PermissionWorkspaceDelete,
+	},
+	RoleDeveloper: {
+		PermissionIntegrationRead,
+		PermissionIntegrationWrite,
+		PermissionIntegrationExecute,
+		PermissionAPIKeyRead,
+		PermissionAPIKeyCreate,
+		PermissionUserRead,
+		PermissionWorkspaceRead,
+	},
+	RoleUser: {
+		PermissionIntegrationRead,
+		PermissionIntegrationExecute,
+		PermissionUserRead,
+		PermissionWorkspaceRead,
+	},
+	RoleViewer: {
+		PermissionIntegrationRead,
+		PermissionUserRead,
+		PermissionWorkspaceRead,
+	},
+}
+
+// RoleInherits maps each role to the role directly below it. A role holds
+// every permission of the roles it inherits from, so a permission added to
+// viewer's defaults reaches every other role too.
+var RoleInherits = map[Role]Role{
+	RoleAdmin:     RoleDeveloper,
+	RoleDeveloper: RoleUser,
+	RoleUser:      RoleViewer,
+}
+
+// EffectivePermissions returns the sorted, deduplicated permissions a member
+// with role and custom permissions holds: the role's defaults, those of every
+// role it inherits from, and the custom grants. An unknown role grants only
+// the custom permissions.
+func EffectivePermissions(role Role, custom []Permission) []Permission {
+	set := make(map[Permission]bool)
+	for _, p := range custom {
+		set[p] = true
+	}
+	// The hierarchy is a chain; seen guards against a misconfigured cycle.
+	seen := make(map[Role]bool)
+	for r, ok := role, true; ok && !seen[r]; r, ok = RoleInherits[r] {
+		seen[r] = true
+		for _, p := range RolePermissions[r] {
+			set[p] = true
+		}
+	}
+
+	perms := make([]Permission, 0, len(set))
+	for p := range set {
+		perms = append(perms, p)
+	}
+	slices.Sort(perms)
+	return perms
+}
+
+// HasPermission reports whether a member with role and custom permissions
+// holds permission.
+func HasPermission(role Role, custom []Permission, permission Permission) bool {
+	return slices.Contains(EffectivePermissions(role, custom), permission)
+}
+
+// ErrNotMember is returned by a Store when the user has no role in the
+// workspace.
+var ErrNotMember = errors.New("user is not a member of the workspace")
+
+// Membership is a user's role and custom permissions in one workspace.
+type Membership struct {
+	UserID      string
+	WorkspaceID string
+	Role        Role
+	Permissions []Permission
+}
+
+// Effective returns the member's effective permissions.
+func (m *Membership) Effective() []Permission {
+	return EffectivePermissions(m.Role, m.Permissions)
+}
+
+// Store looks up workspace memberships.
+type Store interface {
+	// Membership returns the user's role in the workspace, or an error
+	// wrapping ErrNotMember.
+	Membership(ctx context.Context, userID, workspaceID string) (*Membership, error)
+}
+
+// MemoryStore is an in-process Store used when no database is configured
+// (development and tests).
+type MemoryStore struct {
+	mu      sync.RWMutex
+	members map[[2]string]Membership
+}
+
+// NewMemoryStore creates an empty in-memory membership store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{members: make(map[[2]string]Membership)}
+}
+
+// Put stores or replaces m.
+func (s *MemoryStore) Put(m Membership) {
+	s.mu.Lock()
+	s.members[[2]string{m.UserID, m.WorkspaceID}] = m
+	s.mu.Unlock()
+}
+
+// Membership returns the stored membership of userID in workspaceID.
+func (s *MemoryStore) Membership(_ context.Context, userID, workspaceID string) (*Membership, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	m, ok := s.members[[2]string{userID, workspaceID}]
+	if !ok {
+		return nil, fmt.Errorf("user %s in workspace %s: %w", userID, workspaceID, ErrNotMember)
+	}
+	return &m, nil
+}
+
+// SQLStore reads memberships from the user_roles table.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates a membership store backed by db.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// Membership returns the role of userID in workspaceID.
+func (s *SQLStore) Membership(ctx context.Context, userID, workspaceID string) (*Membership, error) {
+	m := Membership{UserID: userID, WorkspaceID: workspaceID}
+	var perms []byte
+	err := s.db.QueryRowContext(ctx,
+		`SELECT role, permissions FROM user_roles WHERE user_id = $1 AND workspace_id = $2`, userID, workspaceID,
+	).Scan(&m.Role, &perms)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user %s in workspace %s: %w", userID, workspaceID, ErrNotMember)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load user role: %w", err)
+	}
+	if len(perms) > 0 {
+		if err := json.Unmarshal(perms, &m.Permissions); err != nil {
+			return nil, fmt.Errorf("decode user role permissions: %w", err)
+		}
+	}
+	return &m, nil
+}
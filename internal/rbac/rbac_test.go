@@ -0,0 +1,59 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestEffectivePermissions_DeveloperInheritsViewer(t *testing.T) {
+	effective := EffectivePermissions(RoleDeveloper, nil)
+	for _, p := range RolePermissions[RoleViewer] {
+		if !slices.Contains(effective, p) {
+			t.Errorf("developer is missing inherited viewer permission %s", p)
+		}
+	}
+	if slices.Contains(effective, PermissionWorkspaceDelete) {
+		t.Error("developer must not hold admin-only workspace:delete")
+	}
+	if !slices.IsSorted(effective) {
+		t.Errorf("permissions are not sorted: %v", effective)
+	}
+}
+
+func TestEffectivePermissions_InheritsAdditionsBelow(t *testing.T) {
+	const extra Permission = "report:read"
+	orig := RolePermissions[RoleViewer]
+	RolePermissions[RoleViewer] = append(slices.Clone(orig), extra)
+	t.Cleanup(func() { RolePermissions[RoleViewer] = orig })
+
+	for _, role := range []Role{RoleAdmin, RoleDeveloper, RoleUser, RoleViewer} {
+		if !HasPermission(role, nil, extra) {
+			t.Errorf("%s did not inherit %s from viewer", role, extra)
+		}
+	}
+}
+
+func TestEffectivePermissions_CustomAndUnknownRole(t *testing.T) {
+	got := EffectivePermissions("auditor", []Permission{PermissionAPIKeyRead, PermissionAPIKeyRead})
+	if !slices.Equal(got, []Permission{PermissionAPIKeyRead}) {
+		t.Errorf("unknown role should grant only custom permissions once, got %v", got)
+	}
+	if !HasPermission(RoleViewer, []Permission{PermissionAPIKeyCreate}, PermissionAPIKeyCreate) {
+		t.Error("custom permission not granted")
+	}
+}
+
+func TestMemoryStore_Membership(t *testing.T) {
+	s := NewMemoryStore()
+	s.Put(Membership{UserID: "u1", WorkspaceID: "ws-a", Role: RoleUser})
+
+	m, err := s.Membership(context.Background(), "u1", "ws-a")
+	if err != nil || m.Role != RoleUser {
+		t.Fatalf("Membership = %+v, %v", m, err)
+	}
+	if _, err := s.Membership(context.Background(), "u1", "ws-b"); !errors.Is(err, ErrNotMember) {
+		t.Errorf("expected ErrNotMember, got %v", err)
+	}
+}
@@ -0,0 +1,245 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"neighbourhood/internal/integrations"
+	"neighbourhood/internal/middleware"
+
+	"github.com/google/uuid"
+)
+
+// RunStatus is the lifecycle state of an async run.
+type RunStatus string
+
+const (
+	RunRunning   RunStatus = "running"
+	RunSucceeded RunStatus = "succeeded"
+	RunFailed    RunStatus = "failed"
+	RunCancelled RunStatus = "cancelled"
+)
+
+var (
+	// ErrRunNotFound is returned when an async run does not exist.
+	ErrRunNotFound = errors.New("workflow run not found")
+	// ErrRunFinished is returned when cancelling a run that has already
+	// finished.
+	ErrRunFinished = errors.New("workflow run already finished")
+)
+
+// AsyncRun is a workflow run started in the background and, as its steps
+// finish, its progress. Results is indexed by step, with nil for steps that
+// have not finished.
+type AsyncRun struct {
+	ID          string        `json:"job_id"`
+	UserID      string        `json:"-"`
+	WorkspaceID string        `json:"-"`
+	Status      RunStatus     `json:"status"`
+	Steps       int           `json:"steps"`
+	Completed   int           `json:"completed"`
+	Results     []interface{} `json:"results"`
+	Error       string        `json:"error,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// Done reports whether the run has finished, however it ended.
+func (r *AsyncRun) Done() bool {
+	return r.Status != RunRunning
+}
+
+// RunStore keeps the state of async runs so it can be polled. Implementations
+// must be safe for concurrent use.
+type RunStore interface {
+	// Save creates or replaces the run with run.ID.
+	Save(ctx context.Context, run *AsyncRun) error
+	// Get returns the run with id, or ErrRunNotFound.
+	Get(ctx context.Context, id string) (*AsyncRun, error)
+}
+
+// MemoryRunStore keeps async runs in process; they do not survive a restart.
+// Finished runs are dropped once they are older than the store's TTL.
+type MemoryRunStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu   sync.Mutex
+	runs map[string]AsyncRun
+}
+
+// NewMemoryRunStore creates an empty store that keeps finished runs for ttl.
+func NewMemoryRunStore(ttl time.Duration) *MemoryRunStore {
+	return &MemoryRunStore{ttl: ttl, now: time.Now, runs: make(map[string]AsyncRun)}
+}
+
+// Save stores a copy of run and drops expired finished runs.
+func (s *MemoryRunStore) Save(_ context.Context, run *AsyncRun) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cutoff := s.now().Add(-s.ttl)
+	for id, r := range s.runs {
+		if r.Done() && r.UpdatedAt.Before(cutoff) {
+			delete(s.runs, id)
+		}
+	}
+	saved := *run
+	saved.Results = append([]interface{}(nil), run.Results...)
+	s.runs[run.ID] = saved
+	return nil
+}
+
+// Get returns a copy of the run with id.
+func (s *MemoryRunStore) Get(_ context.Context, id string) (*AsyncRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.runs[id]
+	if !ok {
+		return nil, ErrRunNotFound
+	}
+	run.Results = append([]interface{}(nil), run.Results...)
+	return &run, nil
+}
+
+// errRunInterrupted is recorded for runs stopped by shutdown.
+const errRunInterrupted = "workflow run interrupted by shutdown"
+
+// AsyncRunner executes workflows in the background, recording each run's
+// progress in a RunStore as its steps finish. Runs can be cancelled only by
+// the runner that started them.
+type AsyncRunner struct {
+	store RunStore
+	spawn func(name string, fn func(ctx context.Context))
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// NewAsyncRunner creates a runner that records runs in store. Runs are plain
+// goroutines until SetWorkers is called.
+func NewAsyncRunner(store RunStore) *AsyncRunner {
+	return &AsyncRunner{
+		store:   store,
+		spawn:   func(_ string, fn func(context.Context)) { go fn(context.Background()) },
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// SetWorkers starts runs with spawn, such as lifecycle.Manager.Go. When the
+// context spawn passes is cancelled, runs in flight are interrupted and
+// recorded as failed, and spawn's owner can wait for that to be saved.
+func (a *AsyncRunner) SetWorkers(spawn func(name string, fn func(ctx context.Context))) {
+	a.spawn = spawn
+}
+
+// Start saves a new run of wf owned by userID in workspaceID and executes it
+// with a copy of engine in the background. The run keeps ctx's values but not
+// its cancellation, so it outlives the request that started it.
+func (a *AsyncRunner) Start(ctx context.Context, engine *WorkflowEngine, wf Workflow, tokens map[integrations.IntegrationType]*integrations.Token, userID, workspaceID string) (*AsyncRun, error) {
+	now := time.Now()
+	run := &AsyncRun{
+		ID:          uuid.NewString(),
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Status:      RunRunning,
+		Steps:       len(wf.Steps),
+		Results:     make([]interface{}, len(wf.Steps)),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := a.store.Save(ctx, run); err != nil {
+		return nil, err
+	}
+	started := *run
+	started.Results = append([]interface{}(nil), run.Results...)
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	a.mu.Lock()
+	a.cancels[run.ID] = cancel
+	a.mu.Unlock()
+
+	runEngine := *engine
+	a.spawn("workflow run", func(workers context.Context) {
+		var interrupted atomic.Bool
+		stop := context.AfterFunc(workers, func() {
+			interrupted.Store(true)
+			cancel()
+		})
+		defer stop()
+		a.execute(ctx, cancel, &interrupted, runEngine, wf, tokens, run)
+	})
+	return &started, nil
+}
+
+// execute runs wf and records its progress and outcome in run. interrupted
+// tells a run stopped by shutdown from one cancelled by its owner.
+func (a *AsyncRunner) execute(ctx context.Context, cancel context.CancelFunc, interrupted *atomic.Bool, engine WorkflowEngine, wf Workflow, tokens map[integrations.IntegrationType]*integrations.Token, run *AsyncRun) {
+	defer cancel()
+
+	var mu sync.Mutex
+	save := func() {
+		run.UpdatedAt = time.Now()
+		if err := a.store.Save(context.WithoutCancel(ctx), run); err != nil {
+			middleware.Logf(ctx, "Failed to save workflow run %s: %v", run.ID, err)
+		}
+	}
+	engine.OnResult = func(i int, res interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		run.Results[i] = res
+		run.Completed++
+		save()
+	}
+
+	_, err := engine.Execute(ctx, wf, tokens)
+	mu.Lock()
+	defer mu.Unlock()
+	switch {
+	case err == nil:
+		run.Status = RunSucceeded
+	case interrupted.Load():
+		run.Status = RunFailed
+		run.Error = errRunInterrupted
+	case ctx.Err() != nil:
+		run.Status = RunCancelled
+		run.Error = "workflow run cancelled"
+	default:
+		run.Status = RunFailed
+		run.Error = err.Error()
+	}
+	save()
+	// Only now that the outcome is saved does Cancel report ErrRunFinished.
+	a.mu.Lock()
+	delete(a.cancels, run.ID)
+	a.mu.Unlock()
+}
+
+// Get returns the run with id, or ErrRunNotFound.
+func (a *AsyncRunner) Get(ctx context.Context, id string) (*AsyncRun, error) {
+	return a.store.Get(ctx, id)
+}
+
+// Cancel stops the run with id. Steps already running are interrupted through
+// their context; the run is then recorded as cancelled. It returns
+// ErrRunFinished when the run has already finished.
+func (a *AsyncRunner) Cancel(ctx context.Context, id string) error {
+	a.mu.Lock()
+	cancel, ok := a.cancels[id]
+	a.mu.Unlock()
+	if ok {
+		cancel()
+		return nil
+	}
+	run, err := a.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if run.Done() {
+		return ErrRunFinished
+	}
+	// Running, but started by another runner.
+	return ErrRunNotFound
+}
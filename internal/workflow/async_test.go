@@ -0,0 +1,80 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"neighbourhood/internal/config"
+	"neighbourhood/internal/integrations"
+	"neighbourhood/internal/lifecycle"
+)
+
+func TestMemoryRunStore_DropsExpiredFinishedRuns(t *testing.T) {
+	s := NewMemoryRunStore(time.Hour)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	s.Save(ctx, &AsyncRun{ID: "done", Status: RunSucceeded, UpdatedAt: now})
+	s.Save(ctx, &AsyncRun{ID: "running", Status: RunRunning, UpdatedAt: now})
+
+	now = now.Add(2 * time.Hour)
+	s.Save(ctx, &AsyncRun{ID: "new", Status: RunRunning, UpdatedAt: now})
+
+	if _, err := s.Get(ctx, "done"); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("expired finished run: err = %v, want ErrRunNotFound", err)
+	}
+	if _, err := s.Get(ctx, "running"); err != nil {
+		t.Errorf("a run still in progress was dropped: %v", err)
+	}
+}
+
+func TestMemoryRunStore_GetReturnsCopy(t *testing.T) {
+	s := NewMemoryRunStore(time.Hour)
+	ctx := context.Background()
+	s.Save(ctx, &AsyncRun{ID: "r", Status: RunRunning, Results: []interface{}{nil}})
+
+	run, _ := s.Get(ctx, "r")
+	run.Results[0] = "changed"
+	if again, _ := s.Get(ctx, "r"); again.Results[0] != nil {
+		t.Errorf("stored results were modified through a returned run: %v", again.Results)
+	}
+}
+
+func TestAsyncRunner_CancelUnknownRun(t *testing.T) {
+	a := NewAsyncRunner(NewMemoryRunStore(time.Hour))
+	if err := a.Cancel(context.Background(), "missing"); !errors.Is(err, ErrRunNotFound) {
+		t.Errorf("err = %v, want ErrRunNotFound", err)
+	}
+}
+
+func TestAsyncRunner_ShutdownFailsRunsInFlight(t *testing.T) {
+	integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
+	reg("fake-A", &slowProvider{fakeProvider{name: "fake-A"}})
+	workers := lifecycle.New()
+	a := NewAsyncRunner(NewMemoryRunStore(time.Hour))
+	a.SetWorkers(workers.Go)
+
+	engine := NewWorkflowEngine(config.DefaultWorkflowConfig())
+	wf := Workflow{Steps: []WorkflowStep{{Provider: "fake-A", Action: "a"}}}
+	run, err := a.Start(context.Background(), engine, wf, map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "t"}}, "user-1", "")
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := workers.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	// Shutdown waits for the outcome to be saved.
+	got, err := a.Get(context.Background(), run.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != RunFailed || got.Error != errRunInterrupted {
+		t.Errorf("run = %s %q, want failed as interrupted", got.Status, got.Error)
+	}
+}
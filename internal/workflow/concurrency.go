@@ -0,0 +1,126 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrTooManyConcurrent is returned by a ConcurrencyGate when the user
+// already has as many workflows in flight as their limit allows.
+var ErrTooManyConcurrent = errors.New("too many concurrent workflows")
+
+// ConcurrencyGate caps the number of workflows each user has in flight, so
+// one user cannot saturate the engine. Implementations must be safe for
+// concurrent use.
+type ConcurrencyGate interface {
+	// Acquire takes one of userID's limit slots and returns the func that
+	// frees it when the run ends, or ErrTooManyConcurrent. A limit below 1
+	// means no cap. Release may be called more than once.
+	Acquire(ctx context.Context, userID string, limit int) (release func(), err error)
+}
+
+// noRelease is returned when no slot was taken.
+func noRelease() {}
+
+// MemoryConcurrencyGate counts in-flight workflows in process. It is used
+// without Redis, where each gateway replica enforces the limit separately.
+type MemoryConcurrencyGate struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+}
+
+// NewMemoryConcurrencyGate creates an in-process concurrency gate.
+func NewMemoryConcurrencyGate() *MemoryConcurrencyGate {
+	return &MemoryConcurrencyGate{inFlight: make(map[string]int)}
+}
+
+// Acquire takes a slot if userID has fewer than limit workflows in flight.
+func (g *MemoryConcurrencyGate) Acquire(_ context.Context, userID string, limit int) (func(), error) {
+	if limit < 1 {
+		return noRelease, nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.inFlight[userID] >= limit {
+		return nil, ErrTooManyConcurrent
+	}
+	g.inFlight[userID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			if g.inFlight[userID]--; g.inFlight[userID] <= 0 {
+				delete(g.inFlight, userID)
+			}
+		})
+	}, nil
+}
+
+// RedisConcurrencyGate tracks in-flight workflows in Redis so the limit holds
+// across gateway replicas. Each user's runs are members of a sorted set scored
+// by start time; members older than ttl are dropped on the next acquire, so a
+// slot leaked by a replica that died mid-run is freed after ttl on its own.
+// ttl should exceed the longest run.
+type RedisConcurrencyGate struct {
+	client redis.Cmdable
+	ttl    time.Duration
+}
+
+// NewRedisConcurrencyGate creates a concurrency gate backed by client.
+func NewRedisConcurrencyGate(client redis.Cmdable, ttl time.Duration) *RedisConcurrencyGate {
+	return &RedisConcurrencyGate{client: client, ttl: ttl}
+}
+
+// acquireScript drops expired runs from the set KEYS[1], then adds ARGV[4]
+// scored ARGV[1] if fewer than ARGV[3] remain, in one step so concurrent
+// acquires cannot both take the last slot. ARGV[2] is the oldest live score
+// and ARGV[5] the set's expiry in milliseconds. It returns 1 when the slot is
+// taken.
+var acquireScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", "(" .. ARGV[2])
+if redis.call("ZCARD", KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+redis.call("ZADD", KEYS[1], ARGV[1], ARGV[4])
+redis.call("PEXPIRE", KEYS[1], ARGV[5])
+return 1
+`)
+
+// Acquire adds a run to userID's set if it holds fewer than limit live runs.
+// A Redis error is returned as is, for the caller's failure policy to decide.
+func (g *RedisConcurrencyGate) Acquire(ctx context.Context, userID string, limit int) (func(), error) {
+	if limit < 1 {
+		return noRelease, nil
+	}
+	key := "workflows:inflight:" + userID
+	member := uuid.NewString()
+	now := time.Now()
+	ok, err := acquireScript.Run(ctx, g.client, []string{key},
+		now.UnixMilli(), now.Add(-g.ttl).UnixMilli(), limit, member, g.ttl.Milliseconds()).Int()
+	if err != nil {
+		return nil, err
+	}
+	if ok != 1 {
+		return nil, ErrTooManyConcurrent
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { g.remove(key, member) }) }, nil
+}
+
+// remove frees a slot. Removing a run that already expired is a no-op, so a
+// late release cannot free another run's slot. It runs after the request may
+// have ended, so it does not use the request's context.
+func (g *RedisConcurrencyGate) remove(key, member string) {
+	if err := g.client.ZRem(context.Background(), key, member).Err(); err != nil {
+		log.Printf("Failed to release workflow slot %s: %v", key, err)
+	}
+}
@@ -0,0 +1,159 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// zsetRedis is an in-memory stand-in for the commands the Redis gate uses,
+// running its acquire script in Go. Other commands panic via the nil embedded
+// interface.
+type zsetRedis struct {
+	redis.Cmdable
+	mu   sync.Mutex
+	sets map[string]map[string]int64
+	ttls map[string]time.Duration
+}
+
+func newZSetRedis() *zsetRedis {
+	return &zsetRedis{sets: make(map[string]map[string]int64), ttls: make(map[string]time.Duration)}
+}
+
+func (c *zsetRedis) EvalSha(_ context.Context, _ string, keys []string, args ...interface{}) *redis.Cmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now, oldest, limit := args[0].(int64), args[1].(int64), args[2].(int)
+	set := c.sets[keys[0]]
+	if set == nil {
+		set = make(map[string]int64)
+		c.sets[keys[0]] = set
+	}
+	for member, score := range set {
+		if score < oldest {
+			delete(set, member)
+		}
+	}
+	if len(set) >= limit {
+		return redis.NewCmdResult(int64(0), nil)
+	}
+	set[args[3].(string)] = now
+	c.ttls[keys[0]] = time.Duration(args[4].(int64)) * time.Millisecond
+	return redis.NewCmdResult(int64(1), nil)
+}
+
+func (c *zsetRedis) ZRem(_ context.Context, key string, members ...interface{}) *redis.IntCmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int64
+	for _, m := range members {
+		if _, ok := c.sets[key][m.(string)]; ok {
+			delete(c.sets[key], m.(string))
+			n++
+		}
+	}
+	return redis.NewIntResult(n, nil)
+}
+
+func TestConcurrencyGates_RejectOneOverLimit(t *testing.T) {
+	gates := map[string]ConcurrencyGate{
+		"memory": NewMemoryConcurrencyGate(),
+		"redis":  NewRedisConcurrencyGate(newZSetRedis(), time.Hour),
+	}
+	for name, gate := range gates {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			var releases []func()
+			for i := 0; i < 3; i++ {
+				release, err := gate.Acquire(ctx, "user-1", 3)
+				if err != nil {
+					t.Fatalf("acquire %d: %v", i+1, err)
+				}
+				releases = append(releases, release)
+			}
+			if _, err := gate.Acquire(ctx, "user-1", 3); !errors.Is(err, ErrTooManyConcurrent) {
+				t.Fatalf("4th acquire: expected ErrTooManyConcurrent, got %v", err)
+			}
+			if release, err := gate.Acquire(ctx, "user-2", 3); err != nil {
+				t.Fatalf("another user should have their own limit, got %v", err)
+			} else {
+				release()
+			}
+
+			// Releasing twice frees only one slot.
+			releases[0]()
+			releases[0]()
+			if _, err := gate.Acquire(ctx, "user-1", 3); err != nil {
+				t.Fatalf("acquire after release: %v", err)
+			}
+			if _, err := gate.Acquire(ctx, "user-1", 3); !errors.Is(err, ErrTooManyConcurrent) {
+				t.Fatalf("a double release should not free a second slot, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConcurrencyGates_ZeroLimitIsUnlimited(t *testing.T) {
+	for name, gate := range map[string]ConcurrencyGate{
+		"memory": NewMemoryConcurrencyGate(),
+		"redis":  NewRedisConcurrencyGate(nil, time.Hour),
+	} {
+		for i := 0; i < 10; i++ {
+			if _, err := gate.Acquire(context.Background(), "user-1", 0); err != nil {
+				t.Fatalf("%s: acquire %d with no limit: %v", name, i+1, err)
+			}
+		}
+	}
+}
+
+func TestRedisConcurrencyGate_ExpiresLeakedSlots(t *testing.T) {
+	client := newZSetRedis()
+	gate := NewRedisConcurrencyGate(client, 30*time.Minute)
+	key := "workflows:inflight:user-1"
+	if _, err := gate.Acquire(context.Background(), "user-1", 2); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if ttl := client.ttls[key]; ttl != 30*time.Minute {
+		t.Errorf("set expiry = %v, want 30m", ttl)
+	}
+
+	// A run whose replica died holds its slot only until it is ttl old, even
+	// while the user keeps starting runs.
+	client.sets[key]["leaked"] = time.Now().Add(-time.Hour).UnixMilli()
+	release, err := gate.Acquire(context.Background(), "user-1", 2)
+	if err != nil {
+		t.Fatalf("acquire with a leaked slot: %v", err)
+	}
+	if _, ok := client.sets[key]["leaked"]; ok {
+		t.Error("expired run was not dropped")
+	}
+
+	// Releasing after the run has expired does not free another run's slot.
+	delete(client.sets[key], "leaked")
+	for member := range client.sets[key] {
+		client.sets[key][member] = time.Now().Add(-time.Hour).UnixMilli()
+	}
+	if _, err := gate.Acquire(context.Background(), "user-1", 2); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	release()
+	if n := len(client.sets[key]); n != 1 {
+		t.Errorf("%d runs in flight after a late release, want 1", n)
+	}
+}
+
+func TestEngine_OnDoneCalledAfterFailure(t *testing.T) {
+	done := 0
+	engine := &WorkflowEngine{MaxSteps: 1, OnDone: func() { done++ }}
+	wf := Workflow{Steps: []WorkflowStep{{Provider: "slack"}, {Provider: "slack"}}}
+	if _, err := engine.Execute(context.Background(), wf, nil); !errors.Is(err, ErrTooManySteps) {
+		t.Fatalf("expected ErrTooManySteps, got %v", err)
+	}
+	if done != 1 {
+		t.Errorf("OnDone called %d times, want 1", done)
+	}
+}
@@ -0,0 +1,149 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// conditionPattern matches a step condition: a reference to an earlier
+// step's output, an operator and a JSON literal, e.g.
+// `steps.0.output.labels contains "urgent"` or `steps.1.output.count >= 3`.
+var conditionPattern = regexp.MustCompile(`^\s*(steps\.\S+)\s+(==|!=|>=|<=|>|<|contains)\s+(.+?)\s*$`)
+
+// condition is a parsed WorkflowStep.Condition.
+type condition struct {
+	ref   string
+	step  int
+	op    string
+	value interface{}
+}
+
+// skippedResult is recorded in place of the result of a step whose
+// condition was false.
+func skippedResult() map[string]interface{} {
+	return map[string]interface{}{"skipped": true}
+}
+
+// isSkipped reports whether result is the marker recorded by skippedResult.
+func isSkipped(result interface{}) bool {
+	m, ok := result.(map[string]interface{})
+	return ok && len(m) == 1 && m["skipped"] == true
+}
+
+// parseCondition parses the condition of the step at index. The reference
+// must name an earlier step and the value must be a string, number, bool or
+// null literal.
+func parseCondition(expr string, index int) (*condition, error) {
+	m := conditionPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("condition %q must have the form `steps.<n>.output.<path> <op> <value>`", expr)
+	}
+	n, _, err := parseRef(m[1])
+	if err != nil {
+		return nil, err
+	}
+	if n >= index {
+		return nil, fmt.Errorf("condition %q must refer to an earlier step", expr)
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(m[3]), &value); err != nil {
+		return nil, fmt.Errorf("condition %q: value %s is not a JSON literal", expr, m[3])
+	}
+	switch value.(type) {
+	case string, float64, bool, nil:
+	default:
+		return nil, fmt.Errorf("condition %q: value must be a string, number, bool or null", expr)
+	}
+	return &condition{ref: m[1], step: n, op: m[2], value: value}, nil
+}
+
+// parseConditions parses the condition of every step in wf, so a malformed
+// condition is reported before any step runs. Steps without a condition get
+// a nil entry.
+func parseConditions(wf Workflow) ([]*condition, error) {
+	conds := make([]*condition, len(wf.Steps))
+	for i, step := range wf.Steps {
+		if step.Condition == "" {
+			continue
+		}
+		cond, err := parseCondition(step.Condition, i)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		conds[i] = cond
+	}
+	return conds, nil
+}
+
+// eval reports whether the condition holds against the results so far. A
+// condition on a step that was itself skipped does not hold, so the steps
+// guarded by it are skipped too.
+func (c *condition) eval(results []interface{}) (bool, error) {
+	if c.step < len(results) && isSkipped(results[c.step]) {
+		return false, nil
+	}
+	v, err := resolveRef(results, c.ref)
+	if err != nil {
+		return false, err
+	}
+	switch c.op {
+	case "==":
+		return reflect.DeepEqual(v, c.value), nil
+	case "!=":
+		return !reflect.DeepEqual(v, c.value), nil
+	case "contains":
+		switch v := v.(type) {
+		case []interface{}:
+			for _, item := range v {
+				if reflect.DeepEqual(item, c.value) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case string:
+			if s, ok := c.value.(string); ok {
+				return strings.Contains(v, s), nil
+			}
+		}
+		return false, fmt.Errorf("reference %q: cannot check whether %T contains %T", c.ref, v, c.value)
+	}
+
+	cmp, err := compare(v, c.value)
+	if err != nil {
+		return false, fmt.Errorf("reference %q: %w", c.ref, err)
+	}
+	switch c.op {
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	case "<":
+		return cmp < 0, nil
+	default: // "<="
+		return cmp <= 0, nil
+	}
+}
+
+// compare orders two numbers or two strings, returning -1, 0 or 1.
+func compare(a, b interface{}) (int, error) {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			switch {
+			case a < b:
+				return -1, nil
+			case a > b:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), nil
+		}
+	}
+	return 0, fmt.Errorf("can only order two numbers or two strings, got %T and %T", a, b)
+}
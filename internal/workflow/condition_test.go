@@ -0,0 +1,131 @@
+package workflow
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func issueResult() map[string]interface{} {
+	return map[string]interface{}{
+		"number": float64(42),
+		"labels": []interface{}{"bug", "urgent"},
+		"state":  "open",
+	}
+}
+
+// runConditional runs a GitHub-like step followed by an SMS step guarded by
+// cond, returning the results and how many SMS calls were made.
+func runConditional(t *testing.T, cond string) ([]interface{}, int, error) {
+	t.Helper()
+	e := setupEngine()
+	reg("fake-A", &fakeProvider{name: "fake-A", execResult: issueResult()})
+	sms := &payloadRecorder{fakeProvider: fakeProvider{name: "fake-B"}}
+	reg("fake-B", sms)
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "get_issue"},
+		{Provider: "fake-B", Action: "send_sms", Condition: cond},
+	}}
+	results, err := e.Execute(context.Background(), wf, chainTokens())
+	return results, len(sms.payloads), err
+}
+
+func TestExecute_Condition_TrueRunsStep(t *testing.T) {
+	for _, cond := range []string{
+		`steps.0.output.labels contains "urgent"`,
+		`steps.0.output.state == "open"`,
+		`steps.0.output.number >= 42`,
+		`steps.0.output.labels[0] != "feature"`,
+	} {
+		results, calls, err := runConditional(t, cond)
+		if err != nil {
+			t.Fatalf("%s: Execute error: %v", cond, err)
+		}
+		if calls != 1 || len(results) != 2 {
+			t.Errorf("%s: step should have run, got %d calls", cond, calls)
+		}
+	}
+}
+
+func TestExecute_Condition_FalseSkipsStep(t *testing.T) {
+	for _, cond := range []string{
+		`steps.0.output.labels contains "wontfix"`,
+		`steps.0.output.state == "closed"`,
+		`steps.0.output.number < 10`,
+	} {
+		results, calls, err := runConditional(t, cond)
+		if err != nil {
+			t.Fatalf("%s: Execute error: %v", cond, err)
+		}
+		if calls != 0 {
+			t.Errorf("%s: step should have been skipped", cond)
+		}
+		if len(results) != 2 || !reflect.DeepEqual(results[1], map[string]interface{}{"skipped": true}) {
+			t.Errorf("%s: results = %v, want a skipped marker for step 1", cond, results)
+		}
+	}
+}
+
+func TestExecute_Condition_OnSkippedStepSkips(t *testing.T) {
+	e := setupEngine()
+	reg("fake-A", &fakeProvider{name: "fake-A", execResult: issueResult()})
+	sms := &payloadRecorder{fakeProvider: fakeProvider{name: "fake-B"}}
+	reg("fake-B", sms)
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "get_issue"},
+		{Provider: "fake-B", Action: "send_sms", Condition: `steps.0.output.state == "closed"`},
+		{Provider: "fake-B", Action: "send_sms", Condition: `steps.1.output.sid != ""`},
+		{Provider: "fake-B", Action: "send_sms"},
+	}}
+	results, err := e.Execute(context.Background(), wf, chainTokens())
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if len(results) != 4 || !isSkipped(results[1]) || !isSkipped(results[2]) {
+		t.Errorf("results = %v, want steps 1 and 2 skipped", results)
+	}
+	if len(sms.payloads) != 1 {
+		t.Errorf("sms calls = %d, want only the unconditional step", len(sms.payloads))
+	}
+}
+
+func TestExecute_Condition_MalformedFailsBeforeAnyStep(t *testing.T) {
+	for _, cond := range []string{
+		`labels contains "urgent"`,
+		`steps.0.output.state ~= "open"`,
+		`steps.0.output.state == open`,
+		`steps.1.output.ok == true`,
+		`steps.0.output.labels == ["urgent"]`,
+	} {
+		results, calls, err := runConditional(t, cond)
+		if err == nil || !strings.HasPrefix(err.Error(), "step 1: ") {
+			t.Errorf("%s: error = %v, want a step 1 condition error", cond, err)
+		}
+		if len(results) != 0 || calls != 0 {
+			t.Errorf("%s: no step should run, got %d results", cond, len(results))
+		}
+	}
+}
+
+func TestExecute_Condition_TypeMismatchFails(t *testing.T) {
+	_, calls, err := runConditional(t, `steps.0.output.state > 3`)
+	if err == nil || !strings.Contains(err.Error(), "step 1 condition") {
+		t.Errorf("error = %v, want a step 1 condition error", err)
+	}
+	if calls != 0 {
+		t.Error("step should not run when its condition fails to evaluate")
+	}
+}
+
+func TestValidate_RejectsMalformedCondition(t *testing.T) {
+	wf := Workflow{Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "a"},
+		{Provider: "fake-B", Action: "b", Condition: "always"},
+	}}
+	if err := Validate(wf, 0); err == nil {
+		t.Error("expected Validate to reject a malformed condition")
+	}
+}
@@ -2,55 +2,274 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"neighbourhood/internal/config"
 	"neighbourhood/internal/integrations"
 
 	"github.com/google/uuid"
 )
 
-// WorkflowStep defines a single step in a workflow
+// WorkflowStep defines a single step in a workflow.
+// Type is empty for provider steps or names a built-in step such as
+// StepTypeTransform, which does not use Provider or Action.
+// Condition, when set, compares an earlier step's output with a literal,
+// e.g. `steps.0.output.labels contains "urgent"`; the step runs only when it
+// holds, and is otherwise recorded as {"skipped": true}. Retry overrides the
+// engine's retry settings for a provider step.
 type WorkflowStep struct {
-	Provider integrations.IntegrationType
-	Action   string
-	Payload  map[string]interface{}
+	Type      string                       `json:"type,omitempty"`
+	Provider  integrations.IntegrationType `json:"provider,omitempty"`
+	Action    string                       `json:"action,omitempty"`
+	Payload   map[string]interface{}       `json:"payload,omitempty"`
+	Condition string                       `json:"condition,omitempty"`
+	Retry     *RetryPolicy                 `json:"retry,omitempty"`
 }
 
-// Workflow defines a sequence of steps
+// Workflow defines a sequence of steps.
+// MaxParallelism above 1 lets steps that do not read each other's output run
+// concurrently, at most that many at a time, capped by the engine's
+// MaxStepParallelism; zero or 1 runs them in order.
 type Workflow struct {
-	ID    uuid.UUID
-	Name  string
-	Steps []WorkflowStep
+	ID             uuid.UUID      `json:"id"`
+	Name           string         `json:"name,omitempty"`
+	Steps          []WorkflowStep `json:"steps"`
+	MaxParallelism int            `json:"max_parallelism,omitempty"`
+}
+
+// DefaultMaxSteps is the step limit applied when none is configured.
+const DefaultMaxSteps = 50
+
+// DefaultMaxStepParallelism is the per-run step concurrency limit applied
+// when none is configured.
+const DefaultMaxStepParallelism = 5
+
+// ErrTooManySteps is returned for workflows longer than the step limit.
+var ErrTooManySteps = errors.New("workflow has too many steps")
+
+// StepError is returned by Execute when the workflow stopped because the step
+// at index Step failed. Its message is that of Err.
+type StepError struct {
+	Step int
+	Err  error
+}
+
+func (e *StepError) Error() string { return e.Err.Error() }
+
+func (e *StepError) Unwrap() error { return e.Err }
+
+// Validate checks that wf has at least one step and no more than maxSteps,
+// and that every step condition and retry policy is well formed. A maxSteps
+// of zero or less means DefaultMaxSteps.
+func Validate(wf Workflow, maxSteps int) error {
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxSteps
+	}
+	if len(wf.Steps) == 0 {
+		return errors.New("workflow must have at least one step")
+	}
+	if len(wf.Steps) > maxSteps {
+		return fmt.Errorf("%w: %d exceeds the limit of %d", ErrTooManySteps, len(wf.Steps), maxSteps)
+	}
+	if _, err := parseConditions(wf); err != nil {
+		return err
+	}
+	return checkRetryPolicies(wf)
 }
 
 // WorkflowEngine executes workflows
 // In production, add logging, metrics, distributed tracing, and error handling.
 type WorkflowEngine struct {
-	// Add logger, metrics, etc. here
+	// MaxSteps caps the number of steps Execute will run; see Validate.
+	MaxSteps int
+	// MaxStepParallelism caps the steps of one run executing at once, however
+	// high the workflow's MaxParallelism; zero means
+	// DefaultMaxStepParallelism.
+	MaxStepParallelism int
+	// StepTimeout bounds each provider step attempt; zero means no bound
+	// beyond the provider's own action timeout.
+	StepTimeout time.Duration
+	// Retries is how many times a provider step without its own Retry
+	// policy is retried after a transient failure; see retryable.
+	Retries int
+	// OnStep, if set, is called after each provider step with its start
+	// time and outcome, so callers can record the calls a run made. It may be
+	// called concurrently when the workflow sets MaxParallelism.
+	OnStep func(ctx context.Context, step WorkflowStep, start time.Time, err error)
+	// OnResult, if set, is called with the index and result of every step
+	// that finishes successfully, including skipped and transform steps, so
+	// callers can report partial progress. With MaxParallelism it is called
+	// from several goroutines, one call at a time.
+	OnResult func(index int, result interface{})
+	// OnDone, if set, is called once Execute returns, however it ends, so
+	// callers can release what they hold for the run.
+	OnDone func()
+
+	// fixtures, when set, answers provider steps in place of the providers;
+	// see NewSandboxEngine.
+	fixtures Fixtures
+	// slots holds one token per running workflow, capping concurrent runs at
+	// its capacity. Copies of an engine share it. Nil means no cap.
+	slots chan struct{}
 }
 
-func NewWorkflowEngine() *WorkflowEngine {
-	return &WorkflowEngine{}
+// NewWorkflowEngine returns an engine tuned by cfg. Zero MaxSteps means
+// DefaultMaxSteps and zero MaxConcurrentRuns means no cap on concurrent runs.
+func NewWorkflowEngine(cfg config.WorkflowConfig) *WorkflowEngine {
+	e := &WorkflowEngine{
+		MaxSteps:           cfg.MaxSteps,
+		MaxStepParallelism: cfg.MaxStepParallelism,
+		StepTimeout:        cfg.DefaultStepTimeout,
+		Retries:            cfg.DefaultRetry,
+	}
+	if e.MaxSteps <= 0 {
+		e.MaxSteps = DefaultMaxSteps
+	}
+	if cfg.MaxConcurrentRuns > 0 {
+		e.slots = make(chan struct{}, cfg.MaxConcurrentRuns)
+	}
+	return e
 }
 
-// Execute runs the workflow steps in order
+// Execute runs the workflow steps in order. Malformed step conditions and
+// retry policies are reported before any step runs. A step whose condition
+// is false is skipped. Before each step runs, references in its payload such
+// as "{{ steps.0.output.issue_key }}" are replaced with values from earlier
+// steps' results; a reference that cannot be resolved stops the workflow.
+// When wf.MaxParallelism is above 1 independent steps run concurrently; see
+// executeParallel. When a step fails the results of the steps that finished
+// are returned with a *StepError, so callers can report what already
+// happened.
 func (e *WorkflowEngine) Execute(ctx context.Context, wf Workflow, tokens map[integrations.IntegrationType]*integrations.Token) ([]interface{}, error) {
+	if e.OnDone != nil {
+		defer e.OnDone()
+	}
+	if e.MaxSteps > 0 && len(wf.Steps) > e.MaxSteps {
+		return nil, fmt.Errorf("%w: %d exceeds the limit of %d", ErrTooManySteps, len(wf.Steps), e.MaxSteps)
+	}
+	conds, err := parseConditions(wf)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRetryPolicies(wf); err != nil {
+		return nil, err
+	}
+	if e.slots != nil {
+		select {
+		case e.slots <- struct{}{}:
+			defer func() { <-e.slots }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if wf.MaxParallelism > 1 {
+		return e.executeParallel(ctx, wf, conds, tokens)
+	}
+
 	var results []interface{}
 	for i, step := range wf.Steps {
-		provider, err := integrations.GetProvider(step.Provider)
+		res, err := e.executeStep(ctx, i, step, conds[i], results, tokens)
 		if err != nil {
-			return results, fmt.Errorf("provider %s not found at step %d: %w", step.Provider, i, err)
+			return results, &StepError{Step: i, Err: err}
+		}
+		results = append(results, res)
+		if e.OnResult != nil {
+			e.OnResult(i, res)
+		}
+	}
+	return results, nil
+}
+
+// executeStep runs the step at index i given the results of the steps before
+// it, checking its condition and resolving its payload first.
+func (e *WorkflowEngine) executeStep(ctx context.Context, i int, step WorkflowStep, cond *condition, results []interface{}, tokens map[integrations.IntegrationType]*integrations.Token) (interface{}, error) {
+	if cond != nil {
+		ok, err := cond.eval(results)
+		if err != nil {
+			return nil, fmt.Errorf("step %d condition: %w", i, err)
 		}
-		token, ok := tokens[step.Provider]
 		if !ok {
-			return results, fmt.Errorf("token for provider %s not found at step %d", step.Provider, i)
+			return skippedResult(), nil
 		}
-		res, err := provider.Execute(ctx, token, step.Action, step.Payload)
+	}
+
+	payload, err := resolvePayload(results, step.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("step %d: %w", i, err)
+	}
+	step.Payload = payload
+
+	if step.Type == StepTypeTransform {
+		res, err := applyTransform(results, step.Payload)
 		if err != nil {
-			// In production, log error, maybe continue or rollback
-			return results, fmt.Errorf("step %d failed: %w", i, err)
+			return nil, fmt.Errorf("step %d transform failed: %w", i, err)
 		}
-		results = append(results, res)
+		return res, nil
 	}
-	return results, nil
+	if step.Type != "" {
+		return nil, fmt.Errorf("unknown step type %q at step %d", step.Type, i)
+	}
+	if e.fixtures != nil {
+		res, err := e.fixtures.fixtureFor(step)
+		if err != nil {
+			return nil, fmt.Errorf("step %d failed: %w", i, err)
+		}
+		return res, nil
+	}
+
+	provider, err := integrations.GetProvider(step.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("provider %s not found at step %d: %w", step.Provider, i, err)
+	}
+	token, ok := tokens[step.Provider]
+	if !ok {
+		return nil, fmt.Errorf("token for provider %s not found at step %d", step.Provider, i)
+	}
+	// Every attempt at the step shares one key, so a retried call the
+	// provider already completed is not repeated.
+	if key := integrations.IdempotencyKey(ctx); key != "" {
+		ctx = integrations.WithIdempotencyKey(ctx, fmt.Sprintf("%s/step-%d", key, i))
+	}
+	res, err := e.runStep(ctx, provider, token, step)
+	if err != nil {
+		// In production, log error, maybe continue or rollback
+		return nil, fmt.Errorf("step %d failed: %w", i, err)
+	}
+	return res, nil
+}
+
+// runStep executes a provider step, applying StepTimeout to each attempt and
+// retrying transient failures as the step's retry policy allows.
+func (e *WorkflowEngine) runStep(ctx context.Context, provider integrations.Provider, token *integrations.Token, step WorkflowStep) (interface{}, error) {
+	policy := e.retryPolicy(step)
+	retrySafe := integrations.RetrySafe(ctx, provider, step.Action, step.Payload)
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		res, err := e.attempt(ctx, provider, token, step)
+		if e.OnStep != nil {
+			e.OnStep(ctx, step, start, err)
+		}
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(ctx, err, retrySafe) {
+			return res, err
+		}
+
+		select {
+		case <-time.After(policy.wait(attempt, err)):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// attempt makes one call for a provider step, bounded by StepTimeout.
+func (e *WorkflowEngine) attempt(ctx context.Context, provider integrations.Provider, token *integrations.Token, step WorkflowStep) (interface{}, error) {
+	if e.StepTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.StepTimeout)
+		defer cancel()
+	}
+	return integrations.ExecuteAction(ctx, provider, token, step.Action, step.Payload)
 }
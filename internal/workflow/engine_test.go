@@ -3,9 +3,14 @@
 import (
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"neighbourhood/internal/config"
 	"neighbourhood/internal/integrations"
 
 	"github.com/google/uuid"
@@ -34,14 +39,14 @@ func (f *fakeProvider) Execute(_ context.Context, _ *integrations.Token, _ strin
 
 func setupEngine() *WorkflowEngine {
 	integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
-	return NewWorkflowEngine()
+	return NewWorkflowEngine(config.DefaultWorkflowConfig())
 }
 func reg(name integrations.IntegrationType, p integrations.Provider) {
 	integrations.Providers[name] = p
 }
 
 func TestNewWorkflowEngine_NotNil(t *testing.T) {
-	if NewWorkflowEngine() == nil {
+	if NewWorkflowEngine(config.DefaultWorkflowConfig()) == nil {
 		t.Fatal("NewWorkflowEngine nil")
 	}
 }
@@ -67,6 +72,43 @@ func TestExecute_NilSteps_NoError(t *testing.T) {
 	}
 }
 
+func TestExecute_OverMaxSteps_ReturnsError(t *testing.T) {
+	e := setupEngine()
+	reg("fake", &fakeProvider{name: "fake"})
+	e.MaxSteps = 1
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+		{Provider: "fake", Action: "a"},
+		{Provider: "fake", Action: "b"},
+	}}
+	tokens := map[integrations.IntegrationType]*integrations.Token{"fake": {AccessToken: "t"}}
+	if _, err := e.Execute(context.Background(), wf, tokens); !errors.Is(err, ErrTooManySteps) {
+		t.Errorf("expected ErrTooManySteps, got %v", err)
+	}
+}
+
+func TestExecute_OnStep_CalledPerProviderStep(t *testing.T) {
+	e := setupEngine()
+	reg("fake", &fakeProvider{name: "fake"})
+	reg("bad", &fakeProvider{name: "bad", execError: errors.New("boom")})
+	var seen []string
+	e.OnStep = func(_ context.Context, step WorkflowStep, start time.Time, err error) {
+		if start.IsZero() {
+			t.Error("expected a start time")
+		}
+		seen = append(seen, step.Action+":"+fmt.Sprint(err != nil))
+	}
+	wf := Workflow{Steps: []WorkflowStep{
+		{Provider: "fake", Action: "a"},
+		{Type: StepTypeTransform, Payload: map[string]interface{}{"path": "$.ok"}},
+		{Provider: "bad", Action: "b"},
+	}}
+	tokens := map[integrations.IntegrationType]*integrations.Token{"fake": {AccessToken: "t"}, "bad": {AccessToken: "t"}}
+	e.Execute(context.Background(), wf, tokens)
+	if strings.Join(seen, ",") != "a:false,b:true" {
+		t.Errorf("unexpected OnStep calls %v", seen)
+	}
+}
+
 func TestExecute_SingleStep_Success(t *testing.T) {
 	e := setupEngine()
 	reg("fake-slack", &fakeProvider{name: "fake-slack"})
@@ -144,6 +186,31 @@ func TestExecute_StepFailure_ReturnsPartialResults(t *testing.T) {
 	}
 }
 
+func TestExecute_StepFailure_ReturnsPriorResultsWithStepError(t *testing.T) {
+	e := setupEngine()
+	reg("fake-ok", &fakeProvider{name: "ok", execResult: map[string]interface{}{"key": "OPS-7"}})
+	reg("fake-fail", &fakeProvider{name: "fail", execError: errors.New("boom")})
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+		{Provider: "fake-ok", Action: "a"},
+		{Provider: "fake-ok", Action: "b"},
+		{Provider: "fake-fail", Action: "c"},
+		{Provider: "fake-ok", Action: "d"},
+	}}
+	tokens := map[integrations.IntegrationType]*integrations.Token{"fake-ok": {AccessToken: "t"}, "fake-fail": {AccessToken: "t"}}
+	results, err := e.Execute(context.Background(), wf, tokens)
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) || stepErr.Step != 2 {
+		t.Fatalf("expected a StepError for step 2, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "boom") {
+		t.Errorf("error should keep the step's message, got %v", err)
+	}
+	want := []interface{}{map[string]interface{}{"key": "OPS-7"}, map[string]interface{}{"key": "OPS-7"}}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results = %v, want the two earlier steps' results", results)
+	}
+}
+
 func TestWorkflowStep_Fields(t *testing.T) {
 	s := WorkflowStep{Provider: "slack", Action: "send", Payload: map[string]interface{}{"ch": "#g"}}
 	if s.Provider != "slack" {
@@ -162,3 +229,151 @@ func TestWorkflow_Fields(t *testing.T) {
 		t.Error("should have 1 step")
 	}
 }
+
+// blockingProvider tracks how many of its calls run at once, holding each
+// call until release is closed.
+type blockingProvider struct {
+	fakeProvider
+	release chan struct{}
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (p *blockingProvider) Execute(ctx context.Context, token *integrations.Token, action string, payload map[string]interface{}) (interface{}, error) {
+	p.mu.Lock()
+	p.running++
+	if p.running > p.peak {
+		p.peak = p.running
+	}
+	p.mu.Unlock()
+	<-p.release
+	p.mu.Lock()
+	p.running--
+	p.mu.Unlock()
+	return p.fakeProvider.Execute(ctx, token, action, payload)
+}
+
+func TestExecute_HonorsMaxConcurrentRuns(t *testing.T) {
+	integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
+	e := NewWorkflowEngine(config.WorkflowConfig{MaxConcurrentRuns: 2})
+	p := &blockingProvider{fakeProvider: fakeProvider{name: "fake-A"}, release: make(chan struct{})}
+	reg("fake-A", p)
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{{Provider: "fake-A", Action: "a"}}}
+	tokens := map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "t"}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		// Copies share the limit, as handlers use them.
+		copied := *e
+		go func() {
+			defer wg.Done()
+			if _, err := copied.Execute(context.Background(), wf, tokens); err != nil {
+				t.Errorf("Execute error: %v", err)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(p.release)
+	wg.Wait()
+
+	if p.peak != 2 {
+		t.Errorf("peak concurrent runs = %d, want 2", p.peak)
+	}
+}
+
+func TestExecute_WaitingForSlotHonorsContext(t *testing.T) {
+	integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
+	e := NewWorkflowEngine(config.WorkflowConfig{MaxConcurrentRuns: 1})
+	p := &blockingProvider{fakeProvider: fakeProvider{name: "fake-A"}, release: make(chan struct{})}
+	reg("fake-A", p)
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{{Provider: "fake-A", Action: "a"}}}
+	tokens := map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "t"}}
+
+	done := make(chan struct{})
+	go func() {
+		e.Execute(context.Background(), wf, tokens)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := e.Execute(ctx, wf, tokens); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+	close(p.release)
+	<-done
+}
+
+// flakyProvider fails with err on its first failures calls.
+type flakyProvider struct {
+	fakeProvider
+	err      error
+	failures int
+	calls    int
+}
+
+func (p *flakyProvider) Execute(ctx context.Context, token *integrations.Token, action string, payload map[string]interface{}) (interface{}, error) {
+	p.calls++
+	if p.calls <= p.failures {
+		return nil, p.err
+	}
+	return p.fakeProvider.Execute(ctx, token, action, payload)
+}
+
+func TestExecute_RetriesTransientFailures(t *testing.T) {
+	defer func(d time.Duration) { retryBackoff = d }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	unavailable := &integrations.UpstreamError{Provider: "fake-A", Status: 503, Kind: integrations.ErrProviderUnavailable}
+	cases := map[string]struct {
+		err       error
+		retries   int
+		wantCalls int
+		wantErr   bool
+	}{
+		"retried until success":       {unavailable, 2, 3, false},
+		"retries exhausted":           {unavailable, 1, 2, true},
+		"rate limited":                {&integrations.ErrRateLimited{Provider: "fake-A", RetryAfter: time.Millisecond}, 2, 3, false},
+		"permanent error not retried": {integrations.ErrValidation, 2, 1, true},
+	}
+	for name, tc := range cases {
+		integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
+		e := NewWorkflowEngine(config.WorkflowConfig{DefaultRetry: tc.retries})
+		p := &flakyProvider{fakeProvider: fakeProvider{name: "fake-A"}, err: tc.err, failures: 2}
+		reg("fake-A", p)
+		attempts := 0
+		e.OnStep = func(context.Context, WorkflowStep, time.Time, error) { attempts++ }
+		wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{{Provider: "fake-A", Action: "a"}}}
+
+		_, err := e.Execute(context.Background(), wf, map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "t"}})
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: error = %v, want error %v", name, err, tc.wantErr)
+		}
+		if p.calls != tc.wantCalls || attempts != tc.wantCalls {
+			t.Errorf("%s: %d calls, %d recorded, want %d", name, p.calls, attempts, tc.wantCalls)
+		}
+	}
+}
+
+// slowProvider blocks until its context ends.
+type slowProvider struct{ fakeProvider }
+
+func (p *slowProvider) Execute(ctx context.Context, _ *integrations.Token, _ string, _ map[string]interface{}) (interface{}, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestExecute_StepTimeout(t *testing.T) {
+	integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
+	e := NewWorkflowEngine(config.WorkflowConfig{DefaultStepTimeout: 10 * time.Millisecond})
+	reg("fake-A", &slowProvider{fakeProvider{name: "fake-A"}})
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{{Provider: "fake-A", Action: "a"}}}
+
+	_, err := e.Execute(context.Background(), wf, map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "t"}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
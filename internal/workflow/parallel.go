@@ -0,0 +1,141 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"neighbourhood/internal/integrations"
+)
+
+// executeParallel runs the steps of wf concurrently, at most
+// wf.MaxParallelism at a time and never more than the engine's
+// MaxStepParallelism. A step starts once every step it depends on
+// (see stepDeps) has finished, and sees the results of all steps before it
+// that have finished by then. The first failure cancels the steps still
+// running and stops any from starting; the failures are returned joined, in
+// step order. Results are indexed by step, with nil for steps that did not
+// finish.
+func (e *WorkflowEngine) executeParallel(ctx context.Context, wf Workflow, conds []*condition, tokens map[integrations.IntegrationType]*integrations.Token) ([]interface{}, error) {
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]interface{}, len(wf.Steps))
+	errs := make([]error, len(wf.Steps))
+	done := make([]chan struct{}, len(wf.Steps))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	workers := make(chan struct{}, e.stepParallelism(wf))
+
+	var (
+		mu     sync.Mutex
+		failed bool
+		wg     sync.WaitGroup
+	)
+	for i, step := range wf.Steps {
+		wg.Add(1)
+		go func(i int, step WorkflowStep) {
+			defer wg.Done()
+			defer close(done[i])
+
+			for dep := range stepDeps(i, step, conds[i]) {
+				select {
+				case <-done[dep]:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case workers <- struct{}{}:
+				defer func() { <-workers }()
+			case <-ctx.Done():
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			mu.Lock()
+			prior := append([]interface{}(nil), results[:i]...)
+			mu.Unlock()
+			res, err := e.executeStep(ctx, i, step, conds[i], prior, tokens)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				results[i] = res
+				if e.OnResult != nil {
+					e.OnResult(i, res)
+				}
+				return
+			}
+			// Once a step has failed, siblings stopped by the cancellation
+			// are not failures of their own.
+			if !failed || !errors.Is(err, context.Canceled) {
+				errs[i] = &StepError{Step: i, Err: err}
+			}
+			failed = true
+			cancel()
+		}(i, step)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return results, err
+	}
+	return results, parent.Err()
+}
+
+// stepDeps returns the indices of the earlier steps whose output step i
+// reads: those named by its condition and payload references, and the source
+// of a transform.
+func stepDeps(i int, step WorkflowStep, cond *condition) map[int]struct{} {
+	deps := map[int]struct{}{}
+	add := func(ref string) {
+		if n, _, err := parseRef(ref); err == nil && n < i {
+			deps[n] = struct{}{}
+		}
+	}
+	if cond != nil {
+		add(cond.ref)
+	}
+	addPayloadRefs(step.Payload, add)
+	if step.Type == StepTypeTransform && i > 0 {
+		source := i - 1
+		if n, ok := step.Payload["source"].(float64); ok && n >= 0 && int(n) < i {
+			source = int(n)
+		}
+		deps[source] = struct{}{}
+	}
+	return deps
+}
+
+// addPayloadRefs calls add with every step reference in v.
+func addPayloadRefs(v interface{}, add func(ref string)) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, item := range v {
+			addPayloadRefs(item, add)
+		}
+	case []interface{}:
+		for _, item := range v {
+			addPayloadRefs(item, add)
+		}
+	case string:
+		for _, m := range stepRefPattern.FindAllStringSubmatch(v, -1) {
+			add(m[1])
+		}
+	}
+}
+
+// stepParallelism returns how many of wf's steps may run at once: its
+// MaxParallelism, clamped to the engine's limit.
+func (e *WorkflowEngine) stepParallelism(wf Workflow) int {
+	limit := e.MaxStepParallelism
+	if limit <= 0 {
+		limit = DefaultMaxStepParallelism
+	}
+	return min(wf.MaxParallelism, limit)
+}
@@ -0,0 +1,197 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"neighbourhood/internal/config"
+	"neighbourhood/internal/integrations"
+
+	"github.com/google/uuid"
+)
+
+// barrierProvider holds each call until n calls have arrived, so a workflow
+// only finishes if n of its steps run at once.
+type barrierProvider struct {
+	fakeProvider
+	n       int
+	mu      sync.Mutex
+	arrived int
+	all     chan struct{}
+}
+
+func (p *barrierProvider) Execute(ctx context.Context, token *integrations.Token, action string, payload map[string]interface{}) (interface{}, error) {
+	p.mu.Lock()
+	p.arrived++
+	if p.arrived == p.n {
+		close(p.all)
+	}
+	p.mu.Unlock()
+	select {
+	case <-p.all:
+		return map[string]interface{}{"action": action}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// cancelRecorder marks started for each call, then blocks until its context
+// ends and counts the calls that saw it cancelled.
+type cancelRecorder struct {
+	fakeProvider
+	started   sync.WaitGroup
+	mu        sync.Mutex
+	cancelled int
+}
+
+func (p *cancelRecorder) Execute(ctx context.Context, _ *integrations.Token, _ string, _ map[string]interface{}) (interface{}, error) {
+	p.started.Done()
+	<-ctx.Done()
+	p.mu.Lock()
+	p.cancelled++
+	p.mu.Unlock()
+	return nil, ctx.Err()
+}
+
+// gatedFailure fails with err once wait returns.
+type gatedFailure struct {
+	fakeProvider
+	wait func()
+	err  error
+}
+
+func (p *gatedFailure) Execute(context.Context, *integrations.Token, string, map[string]interface{}) (interface{}, error) {
+	p.wait()
+	return nil, p.err
+}
+
+func TestExecuteParallel_RunsIndependentStepsConcurrently(t *testing.T) {
+	integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
+	reg("fake-A", &barrierProvider{fakeProvider: fakeProvider{name: "fake-A"}, n: 3, all: make(chan struct{})})
+	e := NewWorkflowEngine(config.DefaultWorkflowConfig())
+	wf := Workflow{ID: uuid.New(), MaxParallelism: 3, Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "slack"},
+		{Provider: "fake-A", Action: "discord"},
+		{Provider: "fake-A", Action: "email"},
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	results, err := e.Execute(ctx, wf, map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "t"}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	for i, want := range []string{"slack", "discord", "email"} {
+		if got := results[i].(map[string]interface{})["action"]; got != want {
+			t.Errorf("results[%d] action = %v, want %s", i, got, want)
+		}
+	}
+}
+
+func TestExecuteParallel_HonorsMaxParallelism(t *testing.T) {
+	for _, limit := range []int{1, 2} {
+		integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
+		p := &blockingProvider{fakeProvider: fakeProvider{name: "fake-A"}, release: make(chan struct{})}
+		reg("fake-A", p)
+		e := NewWorkflowEngine(config.DefaultWorkflowConfig())
+		step := WorkflowStep{Provider: "fake-A", Action: "a"}
+		wf := Workflow{ID: uuid.New(), MaxParallelism: limit, Steps: []WorkflowStep{step, step, step, step}}
+
+		done := make(chan error)
+		go func() {
+			_, err := e.Execute(context.Background(), wf, map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "t"}})
+			done <- err
+		}()
+		time.Sleep(50 * time.Millisecond)
+		close(p.release)
+		if err := <-done; err != nil {
+			t.Fatalf("MaxParallelism %d: Execute error: %v", limit, err)
+		}
+		if p.peak != limit {
+			t.Errorf("MaxParallelism %d: peak concurrent steps = %d", limit, p.peak)
+		}
+	}
+}
+
+func TestExecuteParallel_ClampsToEngineLimit(t *testing.T) {
+	integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
+	p := &blockingProvider{fakeProvider: fakeProvider{name: "fake-A"}, release: make(chan struct{})}
+	reg("fake-A", p)
+	cfg := config.DefaultWorkflowConfig()
+	cfg.MaxStepParallelism = 2
+	e := NewWorkflowEngine(cfg)
+	step := WorkflowStep{Provider: "fake-A", Action: "a"}
+	wf := Workflow{ID: uuid.New(), MaxParallelism: 1000, Steps: []WorkflowStep{step, step, step, step}}
+
+	done := make(chan error)
+	go func() {
+		_, err := e.Execute(context.Background(), wf, map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "t"}})
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(p.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if p.peak != 2 {
+		t.Errorf("peak concurrent steps = %d, want the engine limit of 2", p.peak)
+	}
+}
+
+func TestExecuteParallel_DependentStepWaits(t *testing.T) {
+	integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
+	reg("fake-A", &fakeProvider{name: "fake-A", execResult: map[string]interface{}{"id": "42"}})
+	rec := &payloadRecorder{fakeProvider: fakeProvider{name: "fake-B"}}
+	reg("fake-B", rec)
+	e := NewWorkflowEngine(config.DefaultWorkflowConfig())
+	wf := Workflow{ID: uuid.New(), MaxParallelism: 4, Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "create"},
+		{Provider: "fake-B", Action: "notify", Payload: map[string]interface{}{"text": "created {{ steps.0.output.id }}"}},
+	}}
+
+	if _, err := e.Execute(context.Background(), wf, chainTokens()); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := rec.payloads[0]["text"]; got != "created 42" {
+		t.Errorf("text = %v, want %q", got, "created 42")
+	}
+}
+
+func TestExecuteParallel_FailureCancelsSiblings(t *testing.T) {
+	integrations.Providers = map[integrations.IntegrationType]integrations.Provider{}
+	failure := errors.New("discord down")
+	slow := &cancelRecorder{fakeProvider: fakeProvider{name: "fake-B"}}
+	slow.started.Add(2)
+	reg("fake-B", slow)
+	// The failing step waits for its siblings to start before it fails.
+	reg("fake-A", &gatedFailure{fakeProvider: fakeProvider{name: "fake-A"}, wait: slow.started.Wait, err: failure})
+	e := NewWorkflowEngine(config.DefaultWorkflowConfig())
+	wf := Workflow{ID: uuid.New(), MaxParallelism: 3, Steps: []WorkflowStep{
+		{Provider: "fake-B", Action: "slack"},
+		{Provider: "fake-A", Action: "discord"},
+		{Provider: "fake-B", Action: "email"},
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	results, err := e.Execute(ctx, wf, chainTokens())
+	if !errors.Is(err, failure) {
+		t.Fatalf("error = %v, want the failing step's error", err)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want cancelled siblings left out", err)
+	}
+	var stepErr *StepError
+	if !errors.As(err, &stepErr) || stepErr.Step != 1 {
+		t.Errorf("error = %v, want a StepError for step 1", err)
+	}
+	if slow.cancelled != 2 {
+		t.Errorf("%d sibling steps saw cancellation, want 2", slow.cancelled)
+	}
+	if len(results) != 3 || results[0] != nil || results[2] != nil {
+		t.Errorf("results = %v, want no results from cancelled steps", results)
+	}
+}
@@ -0,0 +1,165 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// paramRefPattern matches a template parameter inside a payload string or
+// condition, e.g. "{{ params.channel }}".
+var paramRefPattern = regexp.MustCompile(`\{\{\s*params\.([A-Za-z0-9_]+)\s*\}\}`)
+
+// UnresolvedError lists the references in a workflow template that can never
+// be resolved: parameters missing from the supplied params, and step
+// references that do not name an earlier step.
+type UnresolvedError struct {
+	Refs []string
+}
+
+func (e *UnresolvedError) Error() string {
+	return "unresolved references: " + strings.Join(e.Refs, ", ")
+}
+
+// Preview returns the workflow template wf with params substituted, as
+// Execute would receive it. Step references are left in place, since they
+// resolve only when the workflow runs, but a reference to a step that does
+// not come before the referring one is reported along with any missing
+// parameters in an *UnresolvedError.
+func Preview(wf Workflow, params map[string]interface{}) (Workflow, error) {
+	resolved, missing := applyParams(wf, params)
+	refs := make([]string, 0, len(missing))
+	for name := range missing {
+		refs = append(refs, "params."+name)
+	}
+	sort.Strings(refs)
+	refs = append(refs, forwardStepRefs(resolved)...)
+	if len(refs) > 0 {
+		return resolved, &UnresolvedError{Refs: refs}
+	}
+	return resolved, nil
+}
+
+// applyParams returns a copy of wf with parameter placeholders in step
+// payloads and conditions replaced by values from params, and the names of
+// the parameters that params lacks. In payloads, a string that is exactly one
+// placeholder takes the value as-is, keeping its type; inside longer text a
+// string is inserted as-is and any other value as JSON. Conditions get JSON
+// instead; see substituteCondition.
+func applyParams(wf Workflow, params map[string]interface{}) (Workflow, map[string]bool) {
+	missing := map[string]bool{}
+	steps := make([]WorkflowStep, len(wf.Steps))
+	for i, step := range wf.Steps {
+		if step.Payload != nil {
+			step.Payload = substituteParams(step.Payload, params, missing).(map[string]interface{})
+		}
+		step.Condition = substituteCondition(step.Condition, params, missing)
+		steps[i] = step
+	}
+	wf.Steps = steps
+	return wf, missing
+}
+
+func substituteParams(v interface{}, params map[string]interface{}, missing map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = substituteParams(item, params, missing)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = substituteParams(item, params, missing)
+		}
+		return out
+	case string:
+		if m := paramRefPattern.FindStringSubmatchIndex(v); m != nil && m[0] == 0 && m[1] == len(v) {
+			value, ok := params[v[m[2]:m[3]]]
+			if !ok {
+				missing[v[m[2]:m[3]]] = true
+				return v
+			}
+			return value
+		}
+		return paramRefPattern.ReplaceAllStringFunc(v, func(match string) string {
+			name := paramRefPattern.FindStringSubmatch(match)[1]
+			value, ok := params[name]
+			if !ok {
+				missing[name] = true
+				return match
+			}
+			if s, ok := value.(string); ok {
+				return s
+			}
+			b, _ := json.Marshal(value)
+			return string(b)
+		})
+	default:
+		return v
+	}
+}
+
+// substituteCondition replaces the placeholders in the condition expr. A
+// placeholder standing for the condition's value becomes the parameter as a
+// JSON literal, so a string is quoted; one inside a quoted literal becomes
+// the parameter's text escaped for that literal. Either way a parameter
+// cannot end the literal early and change what the condition compares.
+func substituteCondition(expr string, params map[string]interface{}, missing map[string]bool) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range paramRefPattern.FindAllStringSubmatchIndex(expr, -1) {
+		b.WriteString(expr[last:m[0]])
+		last = m[1]
+		name := expr[m[2]:m[3]]
+		value, ok := params[name]
+		if !ok {
+			missing[name] = true
+			b.WriteString(expr[m[0]:m[1]])
+			continue
+		}
+		literal, _ := json.Marshal(value)
+		if inJSONString(expr[:m[0]]) {
+			text, isString := value.(string)
+			if !isString {
+				text = string(literal)
+			}
+			quoted, _ := json.Marshal(text)
+			literal = quoted[1 : len(quoted)-1]
+		}
+		b.Write(literal)
+	}
+	b.WriteString(expr[last:])
+	return b.String()
+}
+
+// inJSONString reports whether prefix ends inside a double-quoted string.
+func inJSONString(prefix string) bool {
+	in := false
+	for i := 0; i < len(prefix); i++ {
+		switch {
+		case in && prefix[i] == '\\':
+			i++
+		case prefix[i] == '"':
+			in = !in
+		}
+	}
+	return in
+}
+
+// forwardStepRefs returns the step references in wf's payloads that name the
+// referring step or a later one, and so can never resolve.
+func forwardStepRefs(wf Workflow) []string {
+	var refs []string
+	for i, step := range wf.Steps {
+		addPayloadRefs(step.Payload, func(ref string) {
+			if n, _, err := parseRef(ref); err != nil || n >= i {
+				refs = append(refs, fmt.Sprintf("%s (step %d)", ref, i))
+			}
+		})
+	}
+	return refs
+}
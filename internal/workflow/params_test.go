@@ -0,0 +1,82 @@
+package workflow
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPreview_SubstitutesParams(t *testing.T) {
+	wf := Workflow{Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "a", Payload: map[string]interface{}{
+			"labels": "{{ params.labels }}",
+			"text":   "{{params.count}} new, urgent={{ params.urgent }}",
+			"nested": []interface{}{map[string]interface{}{"to": "{{ params.owner }}"}},
+		}},
+		{Provider: "fake-A", Action: "b", Condition: "steps.0.output.count > {{ params.count }}"},
+	}}
+	params := map[string]interface{}{"labels": []interface{}{"ops"}, "count": float64(3), "urgent": true, "owner": "ada"}
+
+	got, err := Preview(wf, params)
+	if err != nil {
+		t.Fatalf("Preview error: %v", err)
+	}
+	want := map[string]interface{}{
+		"labels": []interface{}{"ops"},
+		"text":   "3 new, urgent=true",
+		"nested": []interface{}{map[string]interface{}{"to": "ada"}},
+	}
+	if !reflect.DeepEqual(got.Steps[0].Payload, want) {
+		t.Errorf("payload = %v, want %v", got.Steps[0].Payload, want)
+	}
+	if got.Steps[1].Condition != "steps.0.output.count > 3" {
+		t.Errorf("condition = %q", got.Steps[1].Condition)
+	}
+	if wf.Steps[0].Payload["labels"] != "{{ params.labels }}" {
+		t.Error("Preview modified the template")
+	}
+}
+
+func TestPreview_ReportsUnresolved(t *testing.T) {
+	wf := Workflow{Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "a", Payload: map[string]interface{}{"to": "{{ params.owner }}", "self": "{{ steps.0.output.id }}"}},
+		{Provider: "fake-A", Action: "b", Payload: map[string]interface{}{"id": "{{ steps.0.output.id }}", "c": "{{ params.channel }}"}},
+	}}
+
+	_, err := Preview(wf, nil)
+	var unresolved *UnresolvedError
+	if !errors.As(err, &unresolved) {
+		t.Fatalf("error = %v, want *UnresolvedError", err)
+	}
+	want := []string{"params.channel", "params.owner", "steps.0.output.id (step 0)"}
+	if !reflect.DeepEqual(unresolved.Refs, want) {
+		t.Errorf("Refs = %v, want %v", unresolved.Refs, want)
+	}
+}
+
+func TestPreview_QuotesParamsInConditions(t *testing.T) {
+	wf := Workflow{Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "a"},
+		{Provider: "fake-A", Action: "b", Condition: "steps.0.output.label == {{ params.label }}"},
+		{Provider: "fake-A", Action: "c", Condition: `steps.0.output.label == "{{ params.label }}"`},
+		{Provider: "fake-A", Action: "d", Condition: `steps.0.output.title contains "v{{ params.count }}"`},
+	}}
+	params := map[string]interface{}{"label": `x" || true`, "count": float64(2)}
+
+	got, err := Preview(wf, params)
+	if err != nil {
+		t.Fatalf("Preview error: %v", err)
+	}
+	for i, want := range []string{
+		`steps.0.output.label == "x\" || true"`,
+		`steps.0.output.label == "x\" || true"`,
+		`steps.0.output.title contains "v2"`,
+	} {
+		if got.Steps[i+1].Condition != want {
+			t.Errorf("step %d condition = %s, want %s", i+1, got.Steps[i+1].Condition, want)
+		}
+		if _, err := parseCondition(got.Steps[i+1].Condition, i+1); err != nil {
+			t.Errorf("step %d: %v", i+1, err)
+		}
+	}
+}
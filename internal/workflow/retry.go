@@ -0,0 +1,113 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"neighbourhood/internal/integrations"
+)
+
+// retryBackoff is the base delay of the engine's default retry policy.
+var retryBackoff = 500 * time.Millisecond
+
+// defaultMaxRetryDelay caps the backoff of a policy with no MaxDelayMS.
+const defaultMaxRetryDelay = 30 * time.Second
+
+// maxRetryAttempts bounds RetryPolicy.MaxAttempts.
+const maxRetryAttempts = 10
+
+// RetryPolicy controls how a failing provider step is retried. Only transient
+// failures are retried: rate limiting, provider 5xx responses and, for
+// actions that are safe to repeat, timeouts. The wait before retry n is drawn
+// from [d/2, d], where d is BaseDelayMS doubled n-1 times and capped at
+// MaxDelayMS; a longer Retry-After sent by a rate-limiting provider takes
+// precedence, up to MaxDelayMS.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int `json:"max_attempts"`
+	BaseDelayMS int `json:"base_delay_ms"`
+	// MaxDelayMS caps the backoff; zero means 30 seconds.
+	MaxDelayMS int `json:"max_delay_ms"`
+}
+
+// validate checks that the policy's values are usable.
+func (p RetryPolicy) validate() error {
+	if p.MaxAttempts < 1 || p.MaxAttempts > maxRetryAttempts {
+		return fmt.Errorf("retry max_attempts must be between 1 and %d, got %d", maxRetryAttempts, p.MaxAttempts)
+	}
+	if p.BaseDelayMS < 0 || p.MaxDelayMS < 0 {
+		return errors.New("retry delays must not be negative")
+	}
+	if p.MaxDelayMS > 0 && p.MaxDelayMS < p.BaseDelayMS {
+		return fmt.Errorf("retry max_delay_ms %d is below base_delay_ms %d", p.MaxDelayMS, p.BaseDelayMS)
+	}
+	return nil
+}
+
+// checkRetryPolicies validates the retry policy of every step in wf.
+func checkRetryPolicies(wf Workflow) error {
+	for i, step := range wf.Steps {
+		if step.Retry == nil {
+			continue
+		}
+		if err := step.Retry.validate(); err != nil {
+			return fmt.Errorf("step %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// retryPolicy returns the policy for step: its own, or one built from the
+// engine's Retries.
+func (e *WorkflowEngine) retryPolicy(step WorkflowStep) RetryPolicy {
+	if step.Retry != nil {
+		return *step.Retry
+	}
+	return RetryPolicy{MaxAttempts: e.Retries + 1, BaseDelayMS: int(retryBackoff / time.Millisecond)}
+}
+
+// wait returns how long to wait after failed attempt number attempt.
+func (p RetryPolicy) wait(attempt int, err error) time.Duration {
+	maxDelay := defaultMaxRetryDelay
+	if p.MaxDelayMS > 0 {
+		maxDelay = time.Duration(p.MaxDelayMS) * time.Millisecond
+	}
+	d := time.Duration(p.BaseDelayMS) * time.Millisecond
+	for i := 1; i < attempt && d < maxDelay; i++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+	if d > 0 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+
+	var rl *integrations.ErrRateLimited
+	if errors.As(err, &rl) && rl.RetryAfter > d {
+		d = min(rl.RetryAfter, maxDelay)
+	}
+	return d
+}
+
+// retryable reports whether a step that failed with err may succeed if
+// retried: the provider rate limited it or was unavailable, or the attempt
+// timed out while the workflow itself still has time. A timed-out call may
+// have taken effect, so it is retried only when retrySafe, see
+// integrations.RetrySafe. Validation errors, missing fields and other client
+// errors are permanent.
+func retryable(ctx context.Context, err error, retrySafe bool) bool {
+	var rl *integrations.ErrRateLimited
+	var netErr net.Error
+	switch {
+	case errors.As(err, &rl), errors.Is(err, integrations.ErrProviderUnavailable):
+		return true
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return retrySafe && ctx.Err() == nil
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"neighbourhood/internal/integrations"
+
+	"github.com/google/uuid"
+)
+
+func flakyWorkflow(retry *RetryPolicy) Workflow {
+	return Workflow{ID: uuid.New(), Steps: []WorkflowStep{{Provider: "fake-A", Action: "a", Retry: retry}}}
+}
+
+func TestExecute_StepRetry_SucceedsAfterTwoFailures(t *testing.T) {
+	e := setupEngine()
+	unavailable := &integrations.UpstreamError{Provider: "fake-A", Status: http.StatusBadGateway, Kind: integrations.ErrProviderUnavailable}
+	p := &flakyProvider{fakeProvider: fakeProvider{name: "fake-A", execResult: map[string]interface{}{"id": "42"}}, err: unavailable, failures: 2}
+	reg("fake-A", p)
+
+	results, err := e.Execute(context.Background(), flakyWorkflow(&RetryPolicy{MaxAttempts: 3, BaseDelayMS: 1}), chainTokens())
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if p.calls != 3 {
+		t.Errorf("attempts = %d, want 3", p.calls)
+	}
+	if got := results[0].(map[string]interface{})["id"]; got != "42" {
+		t.Errorf("result id = %v, want 42", got)
+	}
+}
+
+func TestExecute_StepRetry_PermanentErrorNotRetried(t *testing.T) {
+	e := setupEngine()
+	invalid := &integrations.UpstreamError{Provider: "fake-A", Status: http.StatusBadRequest, Kind: integrations.ErrValidation}
+	p := &flakyProvider{fakeProvider: fakeProvider{name: "fake-A"}, err: invalid, failures: 2}
+	reg("fake-A", p)
+
+	if _, err := e.Execute(context.Background(), flakyWorkflow(&RetryPolicy{MaxAttempts: 5, BaseDelayMS: 1}), chainTokens()); !errors.Is(err, integrations.ErrValidation) {
+		t.Errorf("error = %v, want ErrValidation", err)
+	}
+	if p.calls != 1 {
+		t.Errorf("attempts = %d, want 1", p.calls)
+	}
+}
+
+func TestExecute_StepRetry_StopsWhenContextEnds(t *testing.T) {
+	e := setupEngine()
+	p := &flakyProvider{fakeProvider: fakeProvider{name: "fake-A"}, err: &integrations.ErrRateLimited{Provider: "fake-A"}, failures: 2}
+	reg("fake-A", p)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := e.Execute(ctx, flakyWorkflow(&RetryPolicy{MaxAttempts: 3, BaseDelayMS: 10000}), chainTokens()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if p.calls != 1 || time.Since(start) > time.Second {
+		t.Errorf("retry should stop on cancellation: %d attempts in %v", p.calls, time.Since(start))
+	}
+}
+
+func TestExecute_StepRetry_InvalidPolicyFailsBeforeAnyStep(t *testing.T) {
+	e := setupEngine()
+	p := &flakyProvider{fakeProvider: fakeProvider{name: "fake-A"}}
+	reg("fake-A", p)
+
+	for _, policy := range []*RetryPolicy{{MaxAttempts: 0}, {MaxAttempts: 3, BaseDelayMS: -1}, {MaxAttempts: 3, BaseDelayMS: 100, MaxDelayMS: 10}} {
+		if _, err := e.Execute(context.Background(), flakyWorkflow(policy), chainTokens()); err == nil {
+			t.Errorf("%+v: expected an error", *policy)
+		}
+	}
+	if p.calls != 0 {
+		t.Errorf("no step should run, got %d calls", p.calls)
+	}
+}
+
+func TestRetryPolicy_WaitBackoffWithJitter(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelayMS: 100, MaxDelayMS: 300}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond, 4: 300 * time.Millisecond} {
+		for i := 0; i < 20; i++ {
+			if got := p.wait(attempt, errors.New("boom")); got < want/2 || got > want {
+				t.Errorf("wait(%d) = %v, want within [%v, %v]", attempt, got, want/2, want)
+			}
+		}
+	}
+	if got := p.wait(1, &integrations.ErrRateLimited{RetryAfter: 200 * time.Millisecond}); got != 200*time.Millisecond {
+		t.Errorf("wait after Retry-After = %v, want 200ms", got)
+	}
+	if got := p.wait(1, &integrations.ErrRateLimited{RetryAfter: time.Hour}); got != 300*time.Millisecond {
+		t.Errorf("wait after a long Retry-After = %v, want it capped at 300ms", got)
+	}
+}
+
+// listingProvider is a flakyProvider that advertises its actions.
+type listingProvider struct {
+	flakyProvider
+	actions []integrations.ActionSpec
+}
+
+func (p *listingProvider) ListActions() []integrations.ActionSpec { return p.actions }
+
+func TestExecute_StepRetry_TimeoutRetriedOnlyWhenSafe(t *testing.T) {
+	for _, idempotent := range []bool{false, true} {
+		e := setupEngine()
+		p := &listingProvider{
+			flakyProvider: flakyProvider{fakeProvider: fakeProvider{name: "fake-A"}, err: context.DeadlineExceeded, failures: 1},
+			actions:       []integrations.ActionSpec{{Name: "a", Idempotent: idempotent}},
+		}
+		reg("fake-A", p)
+
+		_, err := e.Execute(context.Background(), flakyWorkflow(&RetryPolicy{MaxAttempts: 3, BaseDelayMS: 1}), chainTokens())
+		if wantCalls := map[bool]int{false: 1, true: 2}[idempotent]; p.calls != wantCalls {
+			t.Errorf("idempotent=%v: attempts = %d, want %d", idempotent, p.calls, wantCalls)
+		}
+		if (err == nil) != idempotent {
+			t.Errorf("idempotent=%v: error = %v", idempotent, err)
+		}
+	}
+}
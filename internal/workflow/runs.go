@@ -0,0 +1,68 @@
+package workflow
+
+import (
+	"sync"
+	"time"
+)
+
+// RunCache deduplicates workflow runs by a client-supplied run key, so a
+// client retrying a request after a network error does not repeat the
+// workflow's side effects.
+type RunCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu   sync.Mutex
+	runs map[string]*cachedRun
+}
+
+// cachedRun is a run that is in flight (done open) or finished successfully.
+type cachedRun struct {
+	done    chan struct{}
+	results []interface{}
+	err     error
+	expires time.Time
+}
+
+// NewRunCache creates a cache that remembers successful runs for ttl.
+func NewRunCache(ttl time.Duration) *RunCache {
+	return &RunCache{ttl: ttl, now: time.Now, runs: make(map[string]*cachedRun)}
+}
+
+// Do calls fn unless a run with key already succeeded within the TTL, in which
+// case its results are returned with replayed set. A concurrent call with the
+// same key waits for the first to finish. Failed runs are not remembered, so
+// the client can retry them.
+func (c *RunCache) Do(key string, fn func() ([]interface{}, error)) (results []interface{}, replayed bool, err error) {
+	c.mu.Lock()
+	now := c.now()
+	for k, run := range c.runs {
+		if !run.expires.IsZero() && now.After(run.expires) {
+			delete(c.runs, k)
+		}
+	}
+	if run, ok := c.runs[key]; ok {
+		c.mu.Unlock()
+		<-run.done
+		if run.err != nil {
+			// The run we waited on failed; try again ourselves.
+			return c.Do(key, fn)
+		}
+		return run.results, true, nil
+	}
+	run := &cachedRun{done: make(chan struct{})}
+	c.runs[key] = run
+	c.mu.Unlock()
+
+	run.results, run.err = fn()
+
+	c.mu.Lock()
+	if run.err != nil {
+		delete(c.runs, key)
+	} else {
+		run.expires = c.now().Add(c.ttl)
+	}
+	c.mu.Unlock()
+	close(run.done)
+	return run.results, false, run.err
+}
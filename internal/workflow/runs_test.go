@@ -0,0 +1,61 @@
+package workflow
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunCache_SameKeyReplaysResults(t *testing.T) {
+	c := NewRunCache(time.Hour)
+	calls := 0
+	fn := func() ([]interface{}, error) {
+		calls++
+		return []interface{}{calls}, nil
+	}
+
+	first, replayed, err := c.Do("k", fn)
+	if err != nil || replayed {
+		t.Fatalf("first Do = %v, replayed=%v, err=%v", first, replayed, err)
+	}
+	second, replayed, err := c.Do("k", fn)
+	if err != nil || !replayed {
+		t.Fatalf("second Do: replayed=%v, err=%v", replayed, err)
+	}
+	if calls != 1 || second[0] != first[0] {
+		t.Errorf("expected one execution and identical results, got calls=%d %v vs %v", calls, first, second)
+	}
+
+	if _, replayed, _ := c.Do("other", fn); replayed || calls != 2 {
+		t.Errorf("a different key should execute, got replayed=%v calls=%d", replayed, calls)
+	}
+}
+
+func TestRunCache_FailedRunNotRemembered(t *testing.T) {
+	c := NewRunCache(time.Hour)
+	calls := 0
+	fail := func() ([]interface{}, error) { calls++; return nil, errors.New("boom") }
+	ok := func() ([]interface{}, error) { calls++; return []interface{}{"ok"}, nil }
+
+	if _, _, err := c.Do("k", fail); err == nil {
+		t.Fatal("expected error")
+	}
+	res, replayed, err := c.Do("k", ok)
+	if err != nil || replayed || res[0] != "ok" || calls != 2 {
+		t.Errorf("retry after failure: res=%v replayed=%v err=%v calls=%d", res, replayed, err, calls)
+	}
+}
+
+func TestRunCache_ExpiresAfterTTL(t *testing.T) {
+	c := NewRunCache(time.Minute)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	calls := 0
+	fn := func() ([]interface{}, error) { calls++; return nil, nil }
+
+	c.Do("k", fn)
+	now = now.Add(2 * time.Minute)
+	if _, replayed, _ := c.Do("k", fn); replayed || calls != 2 {
+		t.Errorf("expected re-execution after TTL, got replayed=%v calls=%d", replayed, calls)
+	}
+}
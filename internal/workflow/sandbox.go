@@ -0,0 +1,38 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+
+	"neighbourhood/internal/config"
+)
+
+// Fixtures maps "provider.action" to the canned response a sandboxed
+// provider step returns, e.g. "slack.send_message".
+type Fixtures map[string]interface{}
+
+// ErrNoFixture is returned when a sandboxed step has no matching fixture.
+var ErrNoFixture = errors.New("no fixture for step")
+
+// NewSandboxEngine returns an engine that answers every provider step from
+// fixtures instead of calling the provider, so a workflow definition can be
+// tested deterministically. No tokens are needed; transform steps run as
+// usual.
+func NewSandboxEngine(fixtures Fixtures) *WorkflowEngine {
+	e := NewWorkflowEngine(config.DefaultWorkflowConfig())
+	if fixtures == nil {
+		fixtures = Fixtures{}
+	}
+	e.fixtures = fixtures
+	return e
+}
+
+// fixtureFor returns the canned response for step.
+func (f Fixtures) fixtureFor(step WorkflowStep) (interface{}, error) {
+	key := string(step.Provider) + "." + step.Action
+	res, ok := f[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoFixture, key)
+	}
+	return res, nil
+}
@@ -0,0 +1,51 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSandboxEngine_TwoStepWorkflowFromFixtures(t *testing.T) {
+	// No providers are registered, so any real call would fail.
+	setupEngine()
+	e := NewSandboxEngine(Fixtures{
+		"jira.create_issue":  map[string]interface{}{"key": "OPS-7"},
+		"slack.send_message": map[string]interface{}{"ok": true, "ts": "1700000000.000100"},
+	})
+	wf := Workflow{Name: "Ticket and notify", Steps: []WorkflowStep{
+		{Provider: "jira", Action: "create_issue", Payload: map[string]interface{}{"summary": "Disk full"}},
+		{Provider: "slack", Action: "send_message", Payload: map[string]interface{}{"channel": "#ops", "text": "filed"}},
+		{Type: StepTypeTransform, Payload: map[string]interface{}{"source": float64(0), "path": "$.key"}},
+	}}
+
+	results, err := e.Execute(context.Background(), wf, nil)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	want := []interface{}{
+		map[string]interface{}{"key": "OPS-7"},
+		map[string]interface{}{"ok": true, "ts": "1700000000.000100"},
+		"OPS-7",
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("results = %v, want %v", results, want)
+	}
+}
+
+func TestSandboxEngine_MissingFixture(t *testing.T) {
+	e := NewSandboxEngine(Fixtures{"jira.create_issue": map[string]interface{}{"key": "OPS-7"}})
+	wf := Workflow{Steps: []WorkflowStep{
+		{Provider: "jira", Action: "create_issue"},
+		{Provider: "slack", Action: "send_message"},
+	}}
+
+	results, err := e.Execute(context.Background(), wf, nil)
+	if !errors.Is(err, ErrNoFixture) {
+		t.Fatalf("expected ErrNoFixture, got %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected the first step's result before the failure, got %v", results)
+	}
+}
@@ -0,0 +1,135 @@
+package workflow
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// stepRefPattern matches a reference to a prior step's result inside a
+// payload string, e.g. "{{ steps.0.output.issue_key }}". Text in braces that
+// does not start with "steps." is left alone.
+var stepRefPattern = regexp.MustCompile(`\{\{\s*(steps\.[^{}]*?)\s*\}\}`)
+
+// resolvePayload returns a copy of payload with step references replaced by
+// values from results. A string that is exactly one reference takes the
+// referenced value as-is, keeping its type; a reference inside a longer
+// string must resolve to a string, number, bool or null. Nested maps and
+// arrays are resolved recursively, and payload itself is never modified.
+func resolvePayload(results []interface{}, payload map[string]interface{}) (map[string]interface{}, error) {
+	if payload == nil {
+		return nil, nil
+	}
+	resolved, err := resolveValue(results, payload)
+	if err != nil {
+		return nil, err
+	}
+	return resolved.(map[string]interface{}), nil
+}
+
+func resolveValue(results []interface{}, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			resolved, err := resolveValue(results, item)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := resolveValue(results, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	case string:
+		return resolveString(results, v)
+	default:
+		return v, nil
+	}
+}
+
+func resolveString(results []interface{}, s string) (interface{}, error) {
+	if m := stepRefPattern.FindStringSubmatchIndex(s); m != nil && m[0] == 0 && m[1] == len(s) {
+		return resolveRef(results, s[m[2]:m[3]])
+	}
+
+	var firstErr error
+	out := stepRefPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if firstErr != nil {
+			return match
+		}
+		ref := stepRefPattern.FindStringSubmatch(match)[1]
+		v, err := resolveRef(results, ref)
+		if err != nil {
+			firstErr = err
+			return match
+		}
+		switch v := v.(type) {
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			return strconv.FormatBool(v)
+		case nil:
+			return ""
+		default:
+			firstErr = fmt.Errorf("reference %q: cannot insert %T into text", ref, v)
+			return match
+		}
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return out, nil
+}
+
+// resolveRef looks up a reference of the form "steps.<index>.output[.path]",
+// where path is as accepted by extractPath.
+func resolveRef(results []interface{}, ref string) (interface{}, error) {
+	n, path, err := parseRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	if n >= len(results) {
+		return nil, fmt.Errorf("reference %q: step %d has no output yet", ref, n)
+	}
+
+	output, err := normalize(results[n])
+	if err != nil {
+		return nil, fmt.Errorf("reference %q: %w", ref, err)
+	}
+	v, err := extractPath(output, path)
+	if err != nil {
+		return nil, fmt.Errorf("reference %q: %w", ref, err)
+	}
+	return v, nil
+}
+
+// parseRef splits a reference into the step index and the path within that
+// step's output.
+func parseRef(ref string) (int, string, error) {
+	rest, ok := strings.CutPrefix(ref, "steps.")
+	if !ok {
+		return 0, "", fmt.Errorf("reference %q must start with steps.", ref)
+	}
+	index, rest, _ := strings.Cut(rest, ".")
+	n, err := strconv.Atoi(index)
+	if err != nil || n < 0 {
+		return 0, "", fmt.Errorf("reference %q: %q is not a step index", ref, index)
+	}
+	field, path, _ := strings.Cut(rest, ".")
+	if field != "output" {
+		return 0, "", fmt.Errorf("reference %q: expected steps.%d.output", ref, n)
+	}
+	return n, path, nil
+}
@@ -0,0 +1,133 @@
+package workflow
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"neighbourhood/internal/integrations"
+
+	"github.com/google/uuid"
+)
+
+// payloadRecorder is a provider that records the payload of each call.
+type payloadRecorder struct {
+	fakeProvider
+	payloads []map[string]interface{}
+}
+
+func (p *payloadRecorder) Execute(ctx context.Context, token *integrations.Token, action string, payload map[string]interface{}) (interface{}, error) {
+	p.payloads = append(p.payloads, payload)
+	return p.fakeProvider.Execute(ctx, token, action, payload)
+}
+
+func chainTokens() map[integrations.IntegrationType]*integrations.Token {
+	return map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "a"}, "fake-B": {AccessToken: "b"}}
+}
+
+func TestExecute_StepReference_PassesOutputToNextStep(t *testing.T) {
+	e := setupEngine()
+	reg("fake-A", &fakeProvider{name: "fake-A", execResult: map[string]interface{}{"issue_key": "OPS-7", "id": 10042}})
+	slack := &payloadRecorder{fakeProvider: fakeProvider{name: "fake-B"}}
+	reg("fake-B", slack)
+
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "create_issue"},
+		{Provider: "fake-B", Action: "send_message", Payload: map[string]interface{}{
+			"text":     "Filed {{ steps.0.output.issue_key }} (#{{steps.0.output.id}})",
+			"issue_id": "{{ steps.0.output.id }}",
+		}},
+	}}
+	if _, err := e.Execute(context.Background(), wf, chainTokens()); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	got := slack.payloads[0]
+	if got["text"] != "Filed OPS-7 (#10042)" {
+		t.Errorf("text = %q", got["text"])
+	}
+	if got["issue_id"] != float64(10042) {
+		t.Errorf("issue_id = %#v, want the number 10042", got["issue_id"])
+	}
+	if wf.Steps[1].Payload["issue_id"] != "{{ steps.0.output.id }}" {
+		t.Error("workflow payload should not be modified")
+	}
+}
+
+func TestExecute_StepReference_NestedMapAndArray(t *testing.T) {
+	e := setupEngine()
+	reg("fake-A", &fakeProvider{name: "fake-A", execResult: userResult()})
+	rec := &payloadRecorder{fakeProvider: fakeProvider{name: "fake-B"}}
+	reg("fake-B", rec)
+
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "get_user"},
+		{Provider: "fake-B", Action: "invite", Payload: map[string]interface{}{
+			"recipients": []interface{}{"{{ steps.0.output.user.profile.email }}"},
+			"meta":       map[string]interface{}{"team": "{{ steps.0.output.user.teams[0].name }}"},
+			"teams":      "{{ steps.0.output.user.teams }}",
+		}},
+	}}
+	if _, err := e.Execute(context.Background(), wf, chainTokens()); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	got := rec.payloads[0]
+	if r := got["recipients"].([]interface{}); r[0] != "ada@example.com" {
+		t.Errorf("recipients = %v", r)
+	}
+	if team := got["meta"].(map[string]interface{})["team"]; team != "core" {
+		t.Errorf("meta.team = %v", team)
+	}
+	if teams, ok := got["teams"].([]interface{}); !ok || len(teams) != 1 {
+		t.Errorf("teams = %#v, want the array itself", got["teams"])
+	}
+}
+
+func TestExecute_StepReference_ResolutionErrors(t *testing.T) {
+	cases := map[string]struct {
+		text string
+		want string
+	}{
+		"missing key":    {"{{ steps.0.output.issue_key }}", `key "issue_key" not found`},
+		"future step":    {"{{ steps.1.output.ok }}", "step 1 has no output yet"},
+		"bad index":      {"{{ steps.first.output }}", "is not a step index"},
+		"object in text": {"user: {{ steps.0.output.user }}", "cannot insert"},
+	}
+	for name, tc := range cases {
+		e := setupEngine()
+		reg("fake-A", &fakeProvider{name: "fake-A", execResult: userResult()})
+		rec := &payloadRecorder{fakeProvider: fakeProvider{name: "fake-B"}}
+		reg("fake-B", rec)
+
+		wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+			{Provider: "fake-A", Action: "get_user"},
+			{Provider: "fake-B", Action: "send_message", Payload: map[string]interface{}{"text": tc.text}},
+		}}
+		results, err := e.Execute(context.Background(), wf, chainTokens())
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), "step 1: ") || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error = %q, want step 1 and %q", name, err, tc.want)
+		}
+		if len(results) != 1 || len(rec.payloads) != 0 {
+			t.Errorf("%s: workflow should stop before step 1 runs", name)
+		}
+	}
+}
+
+func TestExecute_NonStepBracesLeftAlone(t *testing.T) {
+	e := setupEngine()
+	rec := &payloadRecorder{fakeProvider: fakeProvider{name: "fake-B"}}
+	reg("fake-B", rec)
+
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+		{Provider: "fake-B", Action: "send_message", Payload: map[string]interface{}{"text": "Hi {{ name }}"}},
+	}}
+	if _, err := e.Execute(context.Background(), wf, chainTokens()); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if rec.payloads[0]["text"] != "Hi {{ name }}" {
+		t.Errorf("text = %q", rec.payloads[0]["text"])
+	}
+}
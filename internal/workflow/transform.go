@@ -0,0 +1,129 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StepTypeTransform is a built-in step that reshapes a prior step's result
+// without calling a provider.
+//
+// Its payload selects the input with "source" (a zero-based step index,
+// defaulting to the previous step) and then either:
+//   - "path": a JSONPath-like expression such as "$.user.emails[0]", whose
+//     value becomes the step result; or
+//   - "map": an object of output key to path, producing a new object.
+const StepTypeTransform = "transform"
+
+// applyTransform runs a transform step against the results produced so far.
+func applyTransform(results []interface{}, payload map[string]interface{}) (interface{}, error) {
+	if len(results) == 0 {
+		return nil, errors.New("transform requires a prior step result")
+	}
+
+	source := len(results) - 1
+	if raw, ok := payload["source"]; ok {
+		n, ok := raw.(float64)
+		if !ok || n != float64(int(n)) {
+			return nil, fmt.Errorf("field 'source' must be a step index, got %v", raw)
+		}
+		source = int(n)
+		if source < 0 || source >= len(results) {
+			return nil, fmt.Errorf("source step %d has no result", source)
+		}
+	}
+
+	input, err := normalize(results[source])
+	if err != nil {
+		return nil, err
+	}
+
+	if path, ok := payload["path"].(string); ok {
+		return extractPath(input, path)
+	}
+	if mapping, ok := payload["map"].(map[string]interface{}); ok {
+		out := make(map[string]interface{}, len(mapping))
+		for key, raw := range mapping {
+			path, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("map entry %q must be a path string", key)
+			}
+			v, err := extractPath(input, path)
+			if err != nil {
+				return nil, fmt.Errorf("map entry %q: %w", key, err)
+			}
+			out[key] = v
+		}
+		return out, nil
+	}
+	return nil, errors.New("transform requires a 'path' or 'map' field")
+}
+
+// normalize converts a provider result into plain JSON values (maps, slices,
+// strings, float64s, bools) so paths work regardless of its Go type.
+func normalize(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("encode step result: %w", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, fmt.Errorf("decode step result: %w", err)
+	}
+	return out, nil
+}
+
+// extractPath resolves a dotted path with optional [n] array indices, e.g.
+// "$.items[0].name" or "items[0].name". "$" or "" returns v itself.
+func extractPath(v interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return v, nil
+	}
+
+	cur := v
+	for _, segment := range strings.Split(path, ".") {
+		name := segment
+		var indices []int
+		if i := strings.Index(segment, "["); i != -1 {
+			name = segment[:i]
+			rest := segment[i:]
+			for rest != "" {
+				end := strings.Index(rest, "]")
+				if rest[0] != '[' || end == -1 {
+					return nil, fmt.Errorf("malformed index in %q", segment)
+				}
+				n, err := strconv.Atoi(rest[1:end])
+				if err != nil {
+					return nil, fmt.Errorf("malformed index in %q", segment)
+				}
+				indices = append(indices, n)
+				rest = rest[end+1:]
+			}
+		}
+
+		if name != "" {
+			obj, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot read %q from non-object", name)
+			}
+			if cur, ok = obj[name]; !ok {
+				return nil, fmt.Errorf("key %q not found", name)
+			}
+		}
+		for _, n := range indices {
+			arr, ok := cur.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot index non-array at %q", segment)
+			}
+			if n < 0 || n >= len(arr) {
+				return nil, fmt.Errorf("index %d out of range at %q", n, segment)
+			}
+			cur = arr[n]
+		}
+	}
+	return cur, nil
+}
@@ -0,0 +1,83 @@
+package workflow
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"neighbourhood/internal/integrations"
+
+	"github.com/google/uuid"
+)
+
+func userResult() map[string]interface{} {
+	return map[string]interface{}{
+		"user": map[string]interface{}{
+			"profile": map[string]interface{}{"email": "ada@example.com"},
+			"teams":   []interface{}{map[string]interface{}{"name": "core"}},
+		},
+		"id": "U123",
+	}
+}
+
+func TestExecute_TransformStep_ExtractsNestedField(t *testing.T) {
+	e := setupEngine()
+	reg("fake-A", &fakeProvider{name: "fake-A", execResult: userResult()})
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "get_user"},
+		{Type: StepTypeTransform, Payload: map[string]interface{}{"path": "$.user.teams[0].name"}},
+	}}
+	results, err := e.Execute(context.Background(), wf, map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "t"}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if len(results) != 2 || results[1] != "core" {
+		t.Errorf("expected extracted \"core\", got %v", results)
+	}
+}
+
+func TestExecute_TransformStep_RemapsKeys(t *testing.T) {
+	e := setupEngine()
+	reg("fake-A", &fakeProvider{name: "fake-A", execResult: userResult()})
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "get_user"},
+		{Type: StepTypeTransform, Payload: map[string]interface{}{
+			"source": float64(0),
+			"map":    map[string]interface{}{"email": "user.profile.email", "user_id": "id"},
+		}},
+	}}
+	results, err := e.Execute(context.Background(), wf, map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "t"}})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	want := map[string]interface{}{"email": "ada@example.com", "user_id": "U123"}
+	if !reflect.DeepEqual(results[1], want) {
+		t.Errorf("expected %v, got %v", want, results[1])
+	}
+}
+
+func TestExecute_TransformStep_MissingKey_Errors(t *testing.T) {
+	e := setupEngine()
+	reg("fake-A", &fakeProvider{name: "fake-A", execResult: userResult()})
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+		{Provider: "fake-A", Action: "get_user"},
+		{Type: StepTypeTransform, Payload: map[string]interface{}{"path": "user.phone"}},
+	}}
+	results, err := e.Execute(context.Background(), wf, map[integrations.IntegrationType]*integrations.Token{"fake-A": {AccessToken: "t"}})
+	if err == nil {
+		t.Fatal("expected error for missing key")
+	}
+	if len(results) != 1 {
+		t.Errorf("expected partial results from the provider step, got %d", len(results))
+	}
+}
+
+func TestExecute_TransformStep_NoPriorResult_Errors(t *testing.T) {
+	e := setupEngine()
+	wf := Workflow{ID: uuid.New(), Steps: []WorkflowStep{
+		{Type: StepTypeTransform, Payload: map[string]interface{}{"path": "$"}},
+	}}
+	if _, err := e.Execute(context.Background(), wf, nil); err == nil {
+		t.Error("expected error when transform is the first step")
+	}
+}
@@ -87,6 +87,12 @@ func main() {
 		cfg.Security,
 		log,
 	)
+	authUseCase.SetRBAC(usecase.NewRBACUseCase(postgres.NewRBACRepository(pgRepo.DB()), log))
+
+	// Reap dangling session IDs until shutdown
+	reaperCtx, stopReaper := context.WithCancel(context.Background())
+	defer stopReaper()
+	go authUseCase.RunSessionReaper(reaperCtx, cfg.Security.SessionReapInterval)
 
 	// Initialize gRPC server
 	grpcServer := grpc.NewServer(
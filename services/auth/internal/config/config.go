@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -11,6 +12,8 @@ import (
 
 var envVarPattern = regexp.MustCompile(`\$\{([^}:]+)(?::([^}]*))?\}`)
 
+var pepperIDPattern = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
 type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
@@ -82,13 +85,80 @@ type OAuthProvider struct {
 }
 
 type SecurityConfig struct {
-	BCryptCost         int
-	MaxLoginAttempts   int
-	LockoutDuration    time.Duration
-	PasswordMinLength  int
-	RequireSpecialChar bool
-	RequireNumber      bool
-	RequireUppercase   bool
+	BCryptCost          int
+	MaxLoginAttempts    int
+	LockoutDuration     time.Duration
+	PasswordMinLength   int
+	RequireSpecialChar  bool
+	RequireNumber       bool
+	RequireUppercase    bool
+	SessionReapInterval time.Duration
+	// SessionIdleTimeout invalidates sessions that have not authenticated a
+	// request for this long, before their absolute expiry. Zero disables it.
+	SessionIdleTimeout time.Duration
+	// PasswordPeppers lists server-side password peppers as
+	// "id:secret,id:secret"; the first is used for new hashes.
+	PasswordPeppers string
+	// AllowedEmailDomains and DeniedEmailDomains are comma-separated domain
+	// lists restricting who may register. A domain also covers its
+	// subdomains. An empty allowlist admits every domain not denied.
+	AllowedEmailDomains string
+	DeniedEmailDomains  string
+	// CreateDefaultWorkspace gives every newly registered user a personal
+	// workspace that they own, so RBAC-gated features work from the start.
+	CreateDefaultWorkspace bool
+}
+
+// Pepper is a versioned server-side secret mixed into password hashes.
+type Pepper struct {
+	ID     string
+	Secret string
+}
+
+// ParseEmailDomains parses an AllowedEmailDomains or DeniedEmailDomains
+// list, lowercasing entries and dropping any leading "@".
+func ParseEmailDomains(spec string) ([]string, error) {
+	var domains []string
+	for _, entry := range strings.Split(spec, ",") {
+		domain := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(entry), "@"))
+		if domain == "" {
+			continue
+		}
+		if strings.ContainsAny(domain, "@ /") || !strings.Contains(domain, ".") {
+			return nil, fmt.Errorf("email domain %q is not a domain name", entry)
+		}
+		domains = append(domains, domain)
+	}
+	return domains, nil
+}
+
+// minPepperLength is the shortest pepper secret accepted.
+const minPepperLength = 16
+
+// ParsePeppers parses a PasswordPeppers list. Keeping retired peppers after
+// the current one lets existing hashes verify until users next log in.
+func ParsePeppers(spec string) ([]Pepper, error) {
+	var peppers []Pepper
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(entry, ":")
+		if !ok || !pepperIDPattern.MatchString(id) {
+			return nil, fmt.Errorf("password pepper %q: want id:secret with an alphanumeric id", id)
+		}
+		if len(secret) < minPepperLength {
+			return nil, fmt.Errorf("password pepper %q must be at least %d characters", id, minPepperLength)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate password pepper id %q", id)
+		}
+		seen[id] = true
+		peppers = append(peppers, Pepper{ID: id, Secret: secret})
+	}
+	return peppers, nil
 }
 
 type LoggingConfig struct {
@@ -199,6 +269,16 @@ func validate(cfg *Config) error {
 		cfg.JWT.Secret = os.Getenv("JWT_SECRET")
 	}
 
+	if _, err := ParsePeppers(cfg.Security.PasswordPeppers); err != nil {
+		return err
+	}
+
+	for _, spec := range []string{cfg.Security.AllowedEmailDomains, cfg.Security.DeniedEmailDomains} {
+		if _, err := ParseEmailDomains(spec); err != nil {
+			return err
+		}
+	}
+
 	// Set defaults
 	if cfg.Server.ShutdownTimeout == 0 {
 		cfg.Server.ShutdownTimeout = 30 * time.Second
@@ -232,5 +312,9 @@ func validate(cfg *Config) error {
 		cfg.Security.BCryptCost = 12
 	}
 
+	if cfg.Security.SessionReapInterval == 0 {
+		cfg.Security.SessionReapInterval = 10 * time.Minute
+	}
+
 	return nil
 }
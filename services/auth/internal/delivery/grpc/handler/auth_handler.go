@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -37,6 +38,9 @@ func (h *AuthHandler) Register(ctx context.Context, req *pb.RegisterRequest) (*p
 	user, err := h.useCase.Register(ctx, req.Email, req.Password, req.FirstName, req.LastName)
 	if err != nil {
 		h.logger.Error("Registration failed", "error", err, "email", req.Email)
+		if errors.Is(err, usecase.ErrEmailDomainBlocked) {
+			return nil, status.Error(codes.PermissionDenied, err.Error())
+		}
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
@@ -101,6 +105,9 @@ func (h *AuthHandler) Login(ctx context.Context, req *pb.LoginRequest) (*pb.Logi
 func (h *AuthHandler) ValidateToken(ctx context.Context, req *pb.ValidateTokenRequest) (*pb.ValidateTokenResponse, error) {
 	userID, err := h.useCase.ValidateToken(ctx, req.Token)
 	if err != nil {
+		if err == usecase.ErrSessionIdle {
+			return nil, status.Error(codes.Unauthenticated, "Session expired due to inactivity")
+		}
 		return nil, status.Error(codes.Unauthenticated, "Invalid token")
 	}
 
@@ -122,6 +129,8 @@ func (h *AuthHandler) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequ
 			return nil, status.Error(codes.Unauthenticated, "Invalid refresh token")
 		case usecase.ErrTokenExpired:
 			return nil, status.Error(codes.Unauthenticated, "Refresh token expired")
+		case usecase.ErrSessionIdle:
+			return nil, status.Error(codes.Unauthenticated, "Session expired due to inactivity")
 		default:
 			return nil, status.Error(codes.Internal, "Token refresh failed")
 		}
@@ -161,10 +170,14 @@ func (h *AuthHandler) CompleteOAuth(ctx context.Context, req *pb.OAuthCallbackRe
 	user, accessToken, refreshToken, isNew, err := h.useCase.CompleteOAuth(ctx, req.Provider, req.Code, req.State)
 	if err != nil {
 		h.logger.Error("OAuth completion failed", "error", err, "provider", req.Provider)
+		code := "OAUTH_COMPLETION_FAILED"
+		if errors.Is(err, usecase.ErrEmailDomainBlocked) {
+			code = "EMAIL_DOMAIN_NOT_ALLOWED"
+		}
 		return &pb.OAuthCallbackResponse{
 			Success: false,
 			Error: &commonpb.Error{
-				Code:    "OAUTH_COMPLETION_FAILED",
+				Code:    code,
 				Message: err.Error(),
 			},
 		}, nil
@@ -1,41 +1,46 @@
 package domain
 
-import "time"
+import (
+	"time"
 
-// Role represents a role in the RBAC system
-type Role string
+	"neighbourhood/internal/rbac"
+)
 
-const (
-	RoleAdmin     Role = "admin"
-	RoleDeveloper Role = "developer"
-	RoleUser      Role = "user"
-	RoleViewer    Role = "viewer"
+// Role and Permission are defined in the shared rbac package so the gateway
+// computes the same effective permissions as this service.
+type (
+	Role       = rbac.Role
+	Permission = rbac.Permission
 )
 
-// Permission represents a specific permission
-type Permission string
+const (
+	RoleAdmin     = rbac.RoleAdmin
+	RoleDeveloper = rbac.RoleDeveloper
+	RoleUser      = rbac.RoleUser
+	RoleViewer    = rbac.RoleViewer
+)
 
 const (
 	// Integration permissions
-	PermissionIntegrationRead    Permission = "integration:read"
-	PermissionIntegrationWrite   Permission = "integration:write"
-	PermissionIntegrationDelete  Permission = "integration:delete"
-	PermissionIntegrationExecute Permission = "integration:execute"
+	PermissionIntegrationRead    = rbac.PermissionIntegrationRead
+	PermissionIntegrationWrite   = rbac.PermissionIntegrationWrite
+	PermissionIntegrationDelete  = rbac.PermissionIntegrationDelete
+	PermissionIntegrationExecute = rbac.PermissionIntegrationExecute
 
 	// API Key permissions
-	PermissionAPIKeyRead   Permission = "apikey:read"
-	PermissionAPIKeyCreate Permission = "apikey:create"
-	PermissionAPIKeyRevoke Permission = "apikey:revoke"
+	PermissionAPIKeyRead   = rbac.PermissionAPIKeyRead
+	PermissionAPIKeyCreate = rbac.PermissionAPIKeyCreate
+	PermissionAPIKeyRevoke = rbac.PermissionAPIKeyRevoke
 
 	// User management permissions
-	PermissionUserRead   Permission = "user:read"
-	PermissionUserWrite  Permission = "user:write"
-	PermissionUserDelete Permission = "user:delete"
+	PermissionUserRead   = rbac.PermissionUserRead
+	PermissionUserWrite  = rbac.PermissionUserWrite
+	PermissionUserDelete = rbac.PermissionUserDelete
 
 	// Workspace permissions
-	PermissionWorkspaceRead   Permission = "workspace:read"
-	PermissionWorkspaceWrite  Permission = "workspace:write"
-	PermissionWorkspaceDelete Permission = "workspace:delete"
+	PermissionWorkspaceRead   = rbac.PermissionWorkspaceRead
+	PermissionWorkspaceWrite  = rbac.PermissionWorkspaceWrite
+	PermissionWorkspaceDelete = rbac.PermissionWorkspaceDelete
 )
 
 // UserRole represents a user's role within a workspace
@@ -50,67 +55,21 @@ type UserRole struct {
 	CreatedBy   string
 }
 
-// RolePermission defines default permissions for each role
-var RolePermissions = map[Role][]Permission{
-	RoleAdmin: {
-		PermissionIntegrationRead,
-		PermissionIntegrationWrite,
-		PermissionIntegrationDelete,
-		PermissionIntegrationExecute,
-		PermissionAPIKeyRead,
-		PermissionAPIKeyCreate,
-		PermissionAPIKeyRevoke,
-		PermissionUserRead,
-		PermissionUserWrite,
-		PermissionUserDelete,
-		PermissionWorkspaceRead,
-		PermissionWorkspaceWrite,
-		PermissionWorkspaceDelete,
-	},
-	RoleDeveloper: {
-		PermissionIntegrationRead,
-		PermissionIntegrationWrite,
-		PermissionIntegrationExecute,
-		PermissionAPIKeyRead,
-		PermissionAPIKeyCreate,
-		PermissionUserRead,
-		PermissionWorkspaceRead,
-	},
-	RoleUser: {
-		PermissionIntegrationRead,
-		PermissionIntegrationExecute,
-		PermissionUserRead,
-		PermissionWorkspaceRead,
-	},
-	RoleViewer: {
-		PermissionIntegrationRead,
-		PermissionUserRead,
-		PermissionWorkspaceRead,
-	},
+// PermissionCheck asks whether a user holds Permission in WorkspaceID
+type PermissionCheck struct {
+	WorkspaceID string
+	Permission  Permission
+}
+
+// EffectivePermissions returns the role's defaults, those it inherits and the
+// custom permissions, sorted
+func (ur *UserRole) EffectivePermissions() []Permission {
+	return rbac.EffectivePermissions(ur.Role, ur.Permissions)
 }
 
 // HasPermission checks if a role has a specific permission
 func (ur *UserRole) HasPermission(permission Permission) bool {
-	// Check custom permissions first
-	for _, p := range ur.Permissions {
-		if p == permission {
-			return true
-		}
-	}
-
-	// Check default role permissions
-	defaultPerms, exists := RolePermissions[ur.Role]
-	if !exists {
-		return false
-	}
-
-	for _, p := range defaultPerms {
-		if p == permission {
-			return true
-		}
-	}
-
-	return false
+	return rbac.HasPermission(ur.Role, ur.Permissions, permission)
 }
 
 // Workspace represents a developer workspace
@@ -149,6 +108,7 @@ type RBACRepository interface {
 	CreateUserRole(userRole *UserRole) error
 	GetUserRole(userID, workspaceID string) (*UserRole, error)
 	GetUserRoles(userID string) ([]*UserRole, error)
+	GetUserRolesInWorkspaces(userID string, workspaceIDs []string) ([]*UserRole, error)
 	UpdateUserRole(userRole *UserRole) error
 	DeleteUserRole(userID, workspaceID string) error
 
@@ -157,6 +117,9 @@ type RBACRepository interface {
 
 	// Workspace operations
 	CreateWorkspace(workspace *Workspace) error
+	// CreateWorkspaceWithOwner stores workspace and its owner's role
+	// atomically: either both are stored or neither is.
+	CreateWorkspaceWithOwner(workspace *Workspace, owner *UserRole) error
 	GetWorkspace(workspaceID string) (*Workspace, error)
 	GetUserWorkspaces(userID string) ([]*Workspace, error)
 	UpdateWorkspace(workspace *Workspace) error
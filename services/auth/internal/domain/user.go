@@ -42,6 +42,8 @@ type Session struct {
 	UserAgent    string
 	IPAddress    string
 	CreatedAt    time.Time
+	// LastActivity is when the session last authenticated a request.
+	LastActivity time.Time
 }
 
 // LoginAttempt tracks failed login attempts for rate limiting
@@ -79,7 +81,9 @@ type SessionRepository interface {
 	GetByRefreshToken(token string) (*Session, error)
 	Delete(id string) error
 	DeleteByUserID(userID string) error
-	DeleteExpired() error
+	DeleteExpired() (int, error)
+	// Touch records activity on a session at the given time.
+	Touch(id string, at time.Time) error
 }
 
 // LoginAttemptRepository defines the interface for login attempt tracking
@@ -55,6 +55,11 @@ func New(cfg config.DatabaseConfig) (*PostgresRepository, error) {
 	return repo, nil
 }
 
+// DB returns the underlying connection pool, for repositories sharing it.
+func (r *PostgresRepository) DB() *sql.DB {
+	return r.db
+}
+
 func (r *PostgresRepository) Close() error {
 	return r.db.Close()
 }
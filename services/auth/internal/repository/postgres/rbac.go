@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
+
 	"neighbourhood/services/auth/internal/domain"
 )
 
@@ -26,6 +28,15 @@ func (r *RBACRepository) CreateUserRole(userRole *domain.UserRole) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	return insertUserRole(ctx, r.db, userRole)
+}
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func insertUserRole(ctx context.Context, db execer, userRole *domain.UserRole) error {
 	permJSON, err := json.Marshal(userRole.Permissions)
 	if err != nil {
 		return fmt.Errorf("failed to marshal permissions: %w", err)
@@ -36,7 +47,7 @@ func (r *RBACRepository) CreateUserRole(userRole *domain.UserRole) error {
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err = r.db.ExecContext(ctx, query,
+	_, err = db.ExecContext(ctx, query,
 		userRole.ID,
 		userRole.UserID,
 		userRole.WorkspaceID,
@@ -74,31 +85,13 @@ func (r *RBACRepository) HasPermission(userID, workspaceID string, permission do
 		return false, err
 	}
 
-	// Check custom permissions - O(n) where n is small (< 20 typically)
+	// Custom permissions plus the role's defaults and those it inherits
 	var customPerms []domain.Permission
 	if len(permJSON) > 0 {
-		if err := json.Unmarshal(permJSON, &customPerms); err == nil {
-			for _, p := range customPerms {
-				if p == permission {
-					return true, nil
-				}
-			}
-		}
-	}
-
-	// Check default role permissions - O(n) where n is small
-	defaultPerms, exists := domain.RolePermissions[role]
-	if !exists {
-		return false, nil
-	}
-
-	for _, p := range defaultPerms {
-		if p == permission {
-			return true, nil
-		}
+		json.Unmarshal(permJSON, &customPerms)
 	}
-
-	return false, nil
+	ur := domain.UserRole{Role: role, Permissions: customPerms}
+	return ur.HasPermission(permission), nil
 }
 
 // GetUserRole - O(1) with composite index
@@ -153,6 +146,31 @@ func (r *RBACRepository) GetUserRoles(userID string) ([]*domain.UserRole, error)
 	if err != nil {
 		return nil, err
 	}
+	return scanUserRoles(rows)
+}
+
+// GetUserRolesInWorkspaces - single query over the composite index for all
+// of workspaceIDs
+func (r *RBACRepository) GetUserRolesInWorkspaces(userID string, workspaceIDs []string) ([]*domain.UserRole, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, workspace_id, role, permissions, created_at, updated_at, created_by
+		FROM user_roles
+		WHERE user_id = $1 AND workspace_id = ANY($2)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, pq.Array(workspaceIDs))
+	if err != nil {
+		return nil, err
+	}
+	return scanUserRoles(rows)
+}
+
+// scanUserRoles reads user_roles rows selected in GetUserRole's column order
+// and closes rows.
+func scanUserRoles(rows *sql.Rows) ([]*domain.UserRole, error) {
 	defer rows.Close()
 
 	var roles []*domain.UserRole
@@ -226,6 +244,30 @@ func (r *RBACRepository) CreateWorkspace(workspace *domain.Workspace) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	return insertWorkspace(ctx, r.db, workspace)
+}
+
+// CreateWorkspaceWithOwner - two inserts in one transaction
+func (r *RBACRepository) CreateWorkspaceWithOwner(workspace *domain.Workspace, owner *domain.UserRole) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertWorkspace(ctx, tx, workspace); err != nil {
+		return err
+	}
+	if err := insertUserRole(ctx, tx, owner); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertWorkspace(ctx context.Context, db execer, workspace *domain.Workspace) error {
 	settingsJSON, _ := json.Marshal(workspace.Settings)
 
 	query := `
@@ -233,7 +275,7 @@ func (r *RBACRepository) CreateWorkspace(workspace *domain.Workspace) error {
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := db.ExecContext(ctx, query,
 		workspace.ID,
 		workspace.Name,
 		workspace.OwnerID,
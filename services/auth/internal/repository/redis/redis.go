@@ -13,7 +13,7 @@ import (
 )
 
 type RedisRepository struct {
-	client *redis.Client
+	client redis.UniversalClient
 	ctx    context.Context
 }
 
@@ -165,6 +165,30 @@ func (r *RedisRepository) Delete(id string) error {
 	return nil
 }
 
+// Touch sets a session's LastActivity, keeping its remaining TTL. The write
+// only succeeds while the session still exists, so a Touch racing a Delete
+// cannot bring a logged-out session back.
+func (r *RedisRepository) Touch(id string, at time.Time) error {
+	session, err := r.GetByID(id)
+	if err != nil {
+		return err
+	}
+	session.LastActivity = at
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+	err = r.client.SetArgs(r.ctx, sessionKey(id), data, redis.SetArgs{Mode: "XX", KeepTTL: true}).Err()
+	if err == redis.Nil {
+		return fmt.Errorf("session not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
 func (r *RedisRepository) DeleteByUserID(userID string) error {
 	sessionIDs, err := r.client.SMembers(r.ctx, userSessionsKey(userID)).Result()
 	if err != nil {
@@ -186,9 +210,49 @@ func (r *RedisRepository) DeleteByUserID(userID string) error {
 	return nil
 }
 
-func (r *RedisRepository) DeleteExpired() error {
-	// Redis handles expiration automatically via TTL
-	return nil
+// DeleteExpired removes dangling session IDs from user_sessions sets.
+// Session keys expire via TTL, but the per-user set has no TTL of its own and
+// keeps IDs of sessions that no longer exist. It returns the number of set
+// members that were reaped.
+func (r *RedisRepository) DeleteExpired() (int, error) {
+	reaped := 0
+	var cursor uint64
+
+	for {
+		keys, next, err := r.client.Scan(r.ctx, cursor, userSessionsKey("*"), 100).Result()
+		if err != nil {
+			return reaped, fmt.Errorf("failed to scan user sessions: %w", err)
+		}
+
+		for _, key := range keys {
+			sessionIDs, err := r.client.SMembers(r.ctx, key).Result()
+			if err != nil {
+				return reaped, fmt.Errorf("failed to get user sessions: %w", err)
+			}
+
+			for _, sessionID := range sessionIDs {
+				exists, err := r.client.Exists(r.ctx, sessionKey(sessionID)).Result()
+				if err != nil {
+					return reaped, fmt.Errorf("failed to check session: %w", err)
+				}
+				if exists > 0 {
+					continue
+				}
+
+				if err := r.client.SRem(r.ctx, key, sessionID).Err(); err != nil {
+					return reaped, fmt.Errorf("failed to remove dangling session: %w", err)
+				}
+				reaped++
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return reaped, nil
 }
 
 // Login attempt methods
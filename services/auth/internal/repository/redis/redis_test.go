@@ -0,0 +1,190 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"neighbourhood/services/auth/internal/domain"
+)
+
+// fakeClient implements the handful of commands the repository needs on top of
+// in-memory maps. Any other command panics via the nil embedded interface.
+type fakeClient struct {
+	redis.UniversalClient
+	keys   map[string]bool
+	sets   map[string]map[string]bool
+	values map[string]string
+	ttls   map[string]time.Duration
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{keys: map[string]bool{}, sets: map[string]map[string]bool{}, values: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (f *fakeClient) Get(_ context.Context, key string) *redis.StringCmd {
+	v, ok := f.values[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(v, nil)
+}
+
+func (f *fakeClient) Set(_ context.Context, key string, value interface{}, ttl time.Duration) *redis.StatusCmd {
+	switch v := value.(type) {
+	case []byte:
+		f.values[key] = string(v)
+	case string:
+		f.values[key] = v
+	}
+	if ttl != redis.KeepTTL {
+		f.ttls[key] = ttl
+	}
+	return redis.NewStatusResult("OK", nil)
+}
+
+// SetArgs supports the XX mode with KeepTTL that Touch uses.
+func (f *fakeClient) SetArgs(ctx context.Context, key string, value interface{}, a redis.SetArgs) *redis.StatusCmd {
+	if _, ok := f.values[key]; a.Mode == "XX" && !ok {
+		return redis.NewStatusResult("", redis.Nil)
+	}
+	ttl := a.TTL
+	if a.KeepTTL {
+		ttl = redis.KeepTTL
+	}
+	return f.Set(ctx, key, value, ttl)
+}
+
+func (f *fakeClient) Scan(_ context.Context, _ uint64, match string, _ int64) *redis.ScanCmd {
+	var keys []string
+	for k := range f.sets {
+		if ok, _ := path.Match(match, k); ok {
+			keys = append(keys, k)
+		}
+	}
+	return redis.NewScanCmdResult(keys, 0, nil)
+}
+
+func (f *fakeClient) SMembers(_ context.Context, key string) *redis.StringSliceCmd {
+	var members []string
+	for m := range f.sets[key] {
+		members = append(members, m)
+	}
+	return redis.NewStringSliceResult(members, nil)
+}
+
+func (f *fakeClient) Exists(_ context.Context, keys ...string) *redis.IntCmd {
+	var n int64
+	for _, k := range keys {
+		if f.keys[k] {
+			n++
+		}
+	}
+	return redis.NewIntResult(n, nil)
+}
+
+func (f *fakeClient) SRem(_ context.Context, key string, members ...interface{}) *redis.IntCmd {
+	var n int64
+	for _, m := range members {
+		if s, ok := m.(string); ok && f.sets[key][s] {
+			delete(f.sets[key], s)
+			n++
+		}
+	}
+	return redis.NewIntResult(n, nil)
+}
+
+func TestDeleteExpired_RemovesDanglingSessionIDs(t *testing.T) {
+	fc := newFakeClient()
+	fc.keys[sessionKey("live")] = true
+	fc.sets[userSessionsKey("user-1")] = map[string]bool{"live": true, "expired": true}
+
+	repo := &RedisRepository{client: fc, ctx: context.Background()}
+
+	reaped, err := repo.DeleteExpired()
+	if err != nil {
+		t.Fatalf("DeleteExpired error: %v", err)
+	}
+	if reaped != 1 {
+		t.Errorf("expected 1 reaped entry, got %d", reaped)
+	}
+
+	members := fc.sets[userSessionsKey("user-1")]
+	if members["expired"] {
+		t.Error("dangling session ID should be removed from user_sessions set")
+	}
+	if !members["live"] {
+		t.Error("live session ID should be kept in user_sessions set")
+	}
+}
+
+func TestDeleteExpired_NoDanglingEntries_ReapsNothing(t *testing.T) {
+	fc := newFakeClient()
+	fc.keys[sessionKey("a")] = true
+	fc.sets[userSessionsKey("user-1")] = map[string]bool{"a": true}
+
+	repo := &RedisRepository{client: fc, ctx: context.Background()}
+
+	reaped, err := repo.DeleteExpired()
+	if err != nil {
+		t.Fatalf("DeleteExpired error: %v", err)
+	}
+	if reaped != 0 {
+		t.Errorf("expected 0 reaped entries, got %d", reaped)
+	}
+}
+
+func TestTouch_UpdatesLastActivityKeepingTTL(t *testing.T) {
+	fc := newFakeClient()
+	repo := &RedisRepository{client: fc, ctx: context.Background()}
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	data, _ := json.Marshal(&domain.Session{ID: "s1", UserID: "user-1", CreatedAt: created, LastActivity: created})
+	fc.Set(context.Background(), sessionKey("s1"), data, 24*time.Hour)
+
+	now := time.Now().Truncate(time.Second)
+	if err := repo.Touch("s1", now); err != nil {
+		t.Fatalf("Touch error: %v", err)
+	}
+	session, err := repo.GetByID("s1")
+	if err != nil {
+		t.Fatalf("GetByID error: %v", err)
+	}
+	if !session.LastActivity.Equal(now) || !session.CreatedAt.Equal(created) {
+		t.Errorf("session = %+v, want LastActivity %v", session, now)
+	}
+	if fc.ttls[sessionKey("s1")] != 24*time.Hour {
+		t.Errorf("TTL changed to %v", fc.ttls[sessionKey("s1")])
+	}
+
+	if err := repo.Touch("missing", now); err == nil {
+		t.Error("expected an error touching a missing session")
+	}
+}
+
+// deletingClient deletes each key right after reading it, as a concurrent
+// logout would.
+type deletingClient struct{ *fakeClient }
+
+func (c deletingClient) Get(ctx context.Context, key string) *redis.StringCmd {
+	cmd := c.fakeClient.Get(ctx, key)
+	delete(c.values, key)
+	return cmd
+}
+
+func TestTouch_DoesNotResurrectDeletedSession(t *testing.T) {
+	fc := newFakeClient()
+	repo := &RedisRepository{client: deletingClient{fc}, ctx: context.Background()}
+	data, _ := json.Marshal(&domain.Session{ID: "s1", UserID: "user-1"})
+	fc.Set(context.Background(), sessionKey("s1"), data, time.Hour)
+
+	if err := repo.Touch("s1", time.Now()); err == nil {
+		t.Error("expected an error touching a session deleted mid-update")
+	}
+	if _, ok := fc.values[sessionKey("s1")]; ok {
+		t.Error("Touch recreated a deleted session")
+	}
+}
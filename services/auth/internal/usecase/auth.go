@@ -4,18 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/github"
 	"golang.org/x/oauth2/google"
 	"golang.org/x/oauth2/microsoft"
 
+	"neighbourhood/internal/oauthuser"
 	"neighbourhood/services/auth/internal/config"
 	"neighbourhood/services/auth/internal/domain"
+	"neighbourhood/services/auth/pkg/metrics"
 )
 
 var (
@@ -25,6 +27,8 @@ var (
 	ErrInvalidToken       = errors.New("invalid token")
 	ErrTokenExpired       = errors.New("token expired")
 	ErrAccountLocked      = errors.New("account locked due to too many failed login attempts")
+	ErrEmailDomainBlocked = errors.New("registration is not open to this email domain")
+	ErrSessionIdle        = errors.New("session expired due to inactivity")
 )
 
 // Logger interface for dependency injection
@@ -44,6 +48,11 @@ type AuthUseCase struct {
 	securityConfig   config.SecurityConfig
 	logger           Logger
 	oauthConfigs     map[string]*oauth2.Config
+	userInfo         map[string]oauthuser.UserInfoResolver
+	passwords        *passwordHasher
+	allowedDomains   []string
+	deniedDomains    []string
+	rbac             *RBACUseCase
 }
 
 func NewAuthUseCase(
@@ -70,14 +79,28 @@ func NewAuthUseCase(
 		securityConfig:   securityConfig,
 		logger:           logger,
 		oauthConfigs:     make(map[string]*oauth2.Config),
+		userInfo:         oauthuser.Default(),
 	}
 
+	// Peppers were validated by config.Load.
+	peppers, _ := config.ParsePeppers(securityConfig.PasswordPeppers)
+	uc.passwords = newPasswordHasher(securityConfig.BCryptCost, peppers)
+	// Domain lists were validated by config.Load.
+	uc.allowedDomains, _ = config.ParseEmailDomains(securityConfig.AllowedEmailDomains)
+	uc.deniedDomains, _ = config.ParseEmailDomains(securityConfig.DeniedEmailDomains)
+
 	// Initialize OAuth configs
 	uc.initOAuthConfigs()
 
 	return uc
 }
 
+// SetRBAC sets the RBAC use case that creates default workspaces when
+// SecurityConfig.CreateDefaultWorkspace is enabled.
+func (uc *AuthUseCase) SetRBAC(rbac *RBACUseCase) {
+	uc.rbac = rbac
+}
+
 func (uc *AuthUseCase) initOAuthConfigs() {
 	for provider, cfg := range uc.oauthConfig.Providers {
 		if !cfg.Enabled {
@@ -108,6 +131,10 @@ func (uc *AuthUseCase) initOAuthConfigs() {
 
 // Register creates a new user account
 func (uc *AuthUseCase) Register(ctx context.Context, email, password, firstName, lastName string) (*domain.User, error) {
+	if err := uc.checkEmailDomain(email); err != nil {
+		return nil, err
+	}
+
 	// Check if user exists
 	existing, err := uc.userRepo.GetByEmail(email)
 	if err == nil && existing != nil {
@@ -120,7 +147,7 @@ func (uc *AuthUseCase) Register(ctx context.Context, email, password, firstName,
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), uc.securityConfig.BCryptCost)
+	hashedPassword, err := uc.passwords.Hash(password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -129,7 +156,7 @@ func (uc *AuthUseCase) Register(ctx context.Context, email, password, firstName,
 	user := &domain.User{
 		ID:           uuid.New().String(),
 		Email:        email,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 		FirstName:    firstName,
 		LastName:     lastName,
 		Active:       true,
@@ -137,8 +164,8 @@ func (uc *AuthUseCase) Register(ctx context.Context, email, password, firstName,
 		UpdatedAt:    time.Now(),
 	}
 
-	if err := uc.userRepo.Create(user); err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+	if err := uc.createUser(ctx, user); err != nil {
+		return nil, err
 	}
 
 	uc.logger.Info("User registered", "user_id", user.ID, "email", email)
@@ -146,6 +173,40 @@ func (uc *AuthUseCase) Register(ctx context.Context, email, password, firstName,
 	return user, nil
 }
 
+// createUser stores a new user and, when CreateDefaultWorkspace is enabled,
+// a personal workspace owned by them. If the workspace cannot be created the
+// user is deleted again, so registration either yields both or neither.
+func (uc *AuthUseCase) createUser(ctx context.Context, user *domain.User) error {
+	if err := uc.userRepo.Create(user); err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	if !uc.securityConfig.CreateDefaultWorkspace {
+		return nil
+	}
+
+	err := errors.New("no RBAC use case configured")
+	if uc.rbac != nil {
+		_, err = uc.rbac.CreateWorkspace(ctx, user.ID, defaultWorkspaceName(user), "Personal workspace")
+	}
+	if err != nil {
+		if delErr := uc.userRepo.Delete(user.ID); delErr != nil {
+			uc.logger.Error("Failed to roll back user creation", "error", delErr, "user_id", user.ID)
+		}
+		return fmt.Errorf("failed to create default workspace: %w", err)
+	}
+	return nil
+}
+
+// defaultWorkspaceName names a user's personal workspace after their first
+// name, or the local part of their email when they have none.
+func defaultWorkspaceName(user *domain.User) string {
+	name := user.FirstName
+	if name == "" {
+		name, _, _ = strings.Cut(user.Email, "@")
+	}
+	return name + "'s workspace"
+}
+
 // Login authenticates a user and creates a session
 func (uc *AuthUseCase) Login(ctx context.Context, email, password, userAgent, ipAddress string) (string, string, error) {
 	// Check if account is locked
@@ -167,13 +228,19 @@ func (uc *AuthUseCase) Login(ctx context.Context, email, password, userAgent, ip
 	}
 
 	// Check password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+	ok, rehash := uc.passwords.Verify(user.PasswordHash, password)
+	if !ok {
 		if recordErr := uc.loginAttemptRepo.Record(email); recordErr != nil {
 			uc.logger.Error("Failed to record login attempt", "error", recordErr)
 		}
 		return "", "", ErrInvalidCredentials
 	}
 
+	// Upgrade hashes made without the current pepper while the plaintext is at hand
+	if rehash {
+		uc.rehashPassword(user, password)
+	}
+
 	// Reset login attempts
 	if resetErr := uc.loginAttemptRepo.Reset(email); resetErr != nil {
 		uc.logger.Error("Failed to reset login attempts", "error", resetErr)
@@ -195,6 +262,7 @@ func (uc *AuthUseCase) Login(ctx context.Context, email, password, userAgent, ip
 		UserAgent:    userAgent,
 		IPAddress:    ipAddress,
 		CreatedAt:    time.Now(),
+		LastActivity: time.Now(),
 	}
 
 	if err := uc.sessionRepo.Create(session); err != nil {
@@ -233,9 +301,42 @@ func (uc *AuthUseCase) ValidateToken(ctx context.Context, tokenString string) (s
 		return "", ErrInvalidToken
 	}
 
+	if uc.securityConfig.SessionIdleTimeout > 0 {
+		session, err := uc.sessionRepo.GetByAccessToken(tokenString)
+		if err != nil {
+			return "", ErrInvalidToken
+		}
+		if err := uc.checkIdle(session); err != nil {
+			return "", err
+		}
+		if err := uc.sessionRepo.Touch(session.ID, time.Now()); err != nil {
+			uc.logger.Error("Failed to record session activity", "error", err, "session_id", session.ID)
+		}
+	}
+
 	return userID, nil
 }
 
+// checkIdle deletes session and returns ErrSessionIdle when it has been
+// inactive for longer than the configured idle timeout.
+func (uc *AuthUseCase) checkIdle(session *domain.Session) error {
+	idle := uc.securityConfig.SessionIdleTimeout
+	if idle <= 0 {
+		return nil
+	}
+	last := session.LastActivity
+	if last.IsZero() {
+		last = session.CreatedAt
+	}
+	if time.Since(last) <= idle {
+		return nil
+	}
+	if err := uc.sessionRepo.Delete(session.ID); err != nil {
+		uc.logger.Error("Failed to delete idle session", "error", err, "session_id", session.ID)
+	}
+	return ErrSessionIdle
+}
+
 // RefreshToken generates a new access token using a refresh token
 func (uc *AuthUseCase) RefreshToken(ctx context.Context, refreshToken string) (string, string, error) {
 	// Get session by refresh token
@@ -249,6 +350,9 @@ func (uc *AuthUseCase) RefreshToken(ctx context.Context, refreshToken string) (s
 		uc.sessionRepo.Delete(session.ID)
 		return "", "", ErrTokenExpired
 	}
+	if err := uc.checkIdle(session); err != nil {
+		return "", "", err
+	}
 
 	// Generate new tokens
 	accessToken, newRefreshToken, err := uc.generateTokens(session.UserID)
@@ -267,6 +371,7 @@ func (uc *AuthUseCase) RefreshToken(ctx context.Context, refreshToken string) (s
 	}
 	session.ID = uuid.New().String()
 	session.CreatedAt = time.Now()
+	session.LastActivity = time.Now()
 
 	if err := uc.sessionRepo.Create(session); err != nil {
 		return "", "", fmt.Errorf("failed to update session: %w", err)
@@ -319,9 +424,17 @@ func (uc *AuthUseCase) CompleteOAuth(ctx context.Context, provider, code, state
 		return nil, "", "", false, fmt.Errorf("failed to exchange code: %w", err)
 	}
 
-	// Get user info from provider (simplified - would need provider-specific implementation)
-	providerID := uuid.New().String()             // Placeholder
-	email := fmt.Sprintf("user@%s.com", provider) // Placeholder
+	resolver, exists := uc.userInfo[provider]
+	if !exists {
+		return nil, "", "", false, fmt.Errorf("provider %s has no user info resolver", provider)
+	}
+	providerID, email, name, err := resolver.Resolve(ctx, token.AccessToken)
+	if err != nil {
+		return nil, "", "", false, fmt.Errorf("failed to get user info: %w", err)
+	}
+	if email == "" {
+		return nil, "", "", false, fmt.Errorf("provider %s did not share an email address", provider)
+	}
 
 	// Check if OAuth account exists
 	oauthAccount, err := uc.oauthRepo.GetByProviderAndID(provider, providerID)
@@ -332,18 +445,29 @@ func (uc *AuthUseCase) CompleteOAuth(ctx context.Context, provider, code, state
 	if err != nil {
 		// OAuth account doesn't exist, check if user exists by email
 		user, err = uc.userRepo.GetByEmail(email)
+		if err == nil && !oauthuser.VerifiesEmail(resolver) {
+			// An unverified address must not take over the account that
+			// owns it; the user signs in the way they first did instead.
+			return nil, "", "", false, fmt.Errorf("provider %s email is unverified: %w", provider, ErrUserExists)
+		}
 		if err != nil {
 			// Create new user
+			if err := uc.checkEmailDomain(email); err != nil {
+				return nil, "", "", false, err
+			}
 			isNewUser = true
+			firstName, lastName, _ := strings.Cut(name, " ")
 			user = &domain.User{
 				ID:        uuid.New().String(),
 				Email:     email,
+				FirstName: firstName,
+				LastName:  lastName,
 				Active:    true,
 				CreatedAt: time.Now(),
 				UpdatedAt: time.Now(),
 			}
-			if err := uc.userRepo.Create(user); err != nil {
-				return nil, "", "", false, fmt.Errorf("failed to create user: %w", err)
+			if err := uc.createUser(ctx, user); err != nil {
+				return nil, "", "", false, err
 			}
 		}
 
@@ -396,6 +520,7 @@ func (uc *AuthUseCase) CompleteOAuth(ctx context.Context, provider, code, state
 		UserAgent:    state, // Using state temporarily
 		IPAddress:    "",
 		CreatedAt:    time.Now(),
+		LastActivity: time.Now(),
 	}
 
 	if err := uc.sessionRepo.Create(session); err != nil {
@@ -436,6 +561,30 @@ func (uc *AuthUseCase) UpdateUserProfile(ctx context.Context, userID, firstName,
 	return user, nil
 }
 
+// RunSessionReaper periodically removes dangling session IDs left behind in
+// per-user session sets after their sessions expired. It blocks until ctx is
+// cancelled, so callers should run it in its own goroutine.
+func (uc *AuthUseCase) RunSessionReaper(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			reaped, err := uc.sessionRepo.DeleteExpired()
+			if reaped > 0 {
+				metrics.RecordSessionsReaped(reaped)
+				uc.logger.Info("Reaped dangling sessions", "count", reaped)
+			}
+			if err != nil {
+				uc.logger.Error("Session reaper failed", "error", err)
+			}
+		}
+	}
+}
+
 // Helper functions
 
 func (uc *AuthUseCase) generateTokens(userID string) (string, string, error) {
@@ -474,6 +623,46 @@ func (uc *AuthUseCase) generateTokens(userID string) (string, string, error) {
 	return accessTokenString, refreshTokenString, nil
 }
 
+// rehashPassword stores a fresh hash of password for user. Failure is logged
+// and the old hash kept, so login still succeeds.
+func (uc *AuthUseCase) rehashPassword(user *domain.User, password string) {
+	hashed, err := uc.passwords.Hash(password)
+	if err != nil {
+		uc.logger.Error("Failed to rehash password", "error", err, "user_id", user.ID)
+		return
+	}
+	user.PasswordHash = hashed
+	user.UpdatedAt = time.Now()
+	if err := uc.userRepo.Update(user); err != nil {
+		uc.logger.Error("Failed to store rehashed password", "error", err, "user_id", user.ID)
+	}
+}
+
+// checkEmailDomain returns ErrEmailDomainBlocked when email's domain is
+// denied, or when an allowlist is configured and does not include it.
+func (uc *AuthUseCase) checkEmailDomain(email string) error {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return fmt.Errorf("invalid email address %q", email)
+	}
+	emailDomain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+	if domainListed(uc.deniedDomains, emailDomain) ||
+		(len(uc.allowedDomains) > 0 && !domainListed(uc.allowedDomains, emailDomain)) {
+		return fmt.Errorf("%w: %s", ErrEmailDomainBlocked, emailDomain)
+	}
+	return nil
+}
+
+// domainListed reports whether d is one of domains or a subdomain of one.
+func domainListed(domains []string, d string) bool {
+	for _, listed := range domains {
+		if d == listed || strings.HasSuffix(d, "."+listed) {
+			return true
+		}
+	}
+	return false
+}
+
 func (uc *AuthUseCase) validatePassword(password string) error {
 	if len(password) < uc.securityConfig.PasswordMinLength {
 		return fmt.Errorf("password must be at least %d characters", uc.securityConfig.PasswordMinLength)
@@ -0,0 +1,313 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/oauth2"
+
+	"neighbourhood/services/auth/internal/config"
+	"neighbourhood/services/auth/internal/domain"
+)
+
+// GetByProviderAndID reports every OAuth account as unknown, so CompleteOAuth
+// takes the account creation path.
+func (f *fakeUsers) GetByProviderAndID(string, string) (*domain.OAuthAccount, error) {
+	return nil, errors.New("not found")
+}
+
+func (f *fakeUsers) CreateOAuth(*domain.OAuthAccount) error { return nil }
+
+// newDomainTestUseCase returns a use case restricted to the given domain
+// lists, with a "google" OAuth provider backed by a fake token endpoint.
+func newDomainTestUseCase(t *testing.T, allowed, denied string) *AuthUseCase {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"at","token_type":"Bearer","expires_in":3600}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	users := &fakeUsers{byEmail: map[string]*domain.User{}}
+	security := config.SecurityConfig{BCryptCost: bcrypt.MinCost, AllowedEmailDomains: allowed, DeniedEmailDomains: denied}
+	uc := NewAuthUseCase(users, fakeSessions{}, config.JWTConfig{Secret: "test-secret"}, config.OAuthConfig{}, security, nopLogger{})
+	uc.oauthConfigs["google"] = &oauth2.Config{ClientID: "cid", Endpoint: oauth2.Endpoint{TokenURL: srv.URL}}
+	uc.userInfo["google"] = stubResolver{id: "g-1", email: "user@google.com", name: "Ada Lovelace"}
+	return uc
+}
+
+// stubResolver reports a fixed user for any access token.
+type stubResolver struct{ id, email, name string }
+
+func (s stubResolver) Resolve(context.Context, string) (string, string, string, error) {
+	return s.id, s.email, s.name, nil
+}
+
+func TestRegister_AllowedDomain(t *testing.T) {
+	uc := newDomainTestUseCase(t, "company.com", "")
+	for _, email := range []string{"ada@company.com", "bob@EU.Company.com"} {
+		if _, err := uc.Register(context.Background(), email, "correct horse", "", ""); err != nil {
+			t.Errorf("Register(%s) error: %v", email, err)
+		}
+	}
+}
+
+func TestRegister_DomainOutsideAllowlistRejected(t *testing.T) {
+	uc := newDomainTestUseCase(t, "company.com", "")
+	for _, email := range []string{"eve@gmail.com", "eve@notcompany.com"} {
+		if _, err := uc.Register(context.Background(), email, "correct horse", "", ""); !errors.Is(err, ErrEmailDomainBlocked) {
+			t.Errorf("Register(%s) error = %v, want ErrEmailDomainBlocked", email, err)
+		}
+	}
+}
+
+func TestRegister_DeniedDomainRejected(t *testing.T) {
+	uc := newDomainTestUseCase(t, "", "@mailinator.com")
+	if _, err := uc.Register(context.Background(), "eve@mailinator.com", "correct horse", "", ""); !errors.Is(err, ErrEmailDomainBlocked) {
+		t.Errorf("error = %v, want ErrEmailDomainBlocked", err)
+	}
+	if _, err := uc.Register(context.Background(), "ada@example.com", "correct horse", "", ""); err != nil {
+		t.Errorf("undenied domain rejected: %v", err)
+	}
+}
+
+func TestCompleteOAuth_AllowedDomainCreatesUser(t *testing.T) {
+	uc := newDomainTestUseCase(t, "google.com", "")
+	user, _, _, isNew, err := uc.CompleteOAuth(context.Background(), "google", "code", "state")
+	if err != nil {
+		t.Fatalf("CompleteOAuth error: %v", err)
+	}
+	if !isNew || user.Email != "user@google.com" || user.FirstName != "Ada" || user.LastName != "Lovelace" {
+		t.Errorf("user = %+v, isNew = %v", user, isNew)
+	}
+}
+
+func TestCompleteOAuth_RequiresEmail(t *testing.T) {
+	uc := newDomainTestUseCase(t, "", "")
+	uc.userInfo["google"] = stubResolver{id: "g-1"}
+	if _, _, _, _, err := uc.CompleteOAuth(context.Background(), "google", "code", "state"); err == nil {
+		t.Error("expected an error when the provider shares no email")
+	}
+}
+
+func TestCompleteOAuth_DeniedDomainRejected(t *testing.T) {
+	uc := newDomainTestUseCase(t, "company.com", "")
+	if _, _, _, _, err := uc.CompleteOAuth(context.Background(), "google", "code", "state"); !errors.Is(err, ErrEmailDomainBlocked) {
+		t.Errorf("error = %v, want ErrEmailDomainBlocked", err)
+	}
+}
+
+// unverifiedResolver is a stubResolver whose provider does not vouch for the
+// email it reports.
+type unverifiedResolver struct{ stubResolver }
+
+func (unverifiedResolver) EmailUnverified() bool { return true }
+
+func TestCompleteOAuth_UnverifiedEmailDoesNotLinkExistingUser(t *testing.T) {
+	uc := newDomainTestUseCase(t, "", "")
+	existing, err := uc.Register(context.Background(), "user@google.com", "correct horse", "", "")
+	if err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+
+	uc.userInfo["google"] = unverifiedResolver{stubResolver{id: "ms-1", email: "user@google.com"}}
+	if _, _, _, _, err := uc.CompleteOAuth(context.Background(), "google", "code", "state"); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("error = %v, want ErrUserExists", err)
+	}
+
+	uc.userInfo["google"] = stubResolver{id: "g-1", email: "user@google.com"}
+	user, _, _, isNew, err := uc.CompleteOAuth(context.Background(), "google", "code", "state")
+	if err != nil || isNew || user.ID != existing.ID {
+		t.Errorf("verified email should link the existing user, got %+v, isNew %v, err %v", user, isNew, err)
+	}
+}
+
+func TestParseEmailDomains_Invalid(t *testing.T) {
+	for _, spec := range []string{"company", "a@company.com", "company.com, bad domain.com"} {
+		if _, err := config.ParseEmailDomains(spec); err == nil {
+			t.Errorf("ParseEmailDomains(%q) accepted an invalid list", spec)
+		}
+	}
+}
+
+// memorySessions is a session store keyed by access token.
+type memorySessions struct {
+	fakeSessions
+	byToken map[string]*domain.Session
+	touched int
+}
+
+func (m *memorySessions) Create(s *domain.Session) error {
+	m.byToken[s.AccessToken] = s
+	return nil
+}
+
+func (m *memorySessions) GetByAccessToken(token string) (*domain.Session, error) {
+	if s, ok := m.byToken[token]; ok {
+		return s, nil
+	}
+	return nil, errors.New("session not found")
+}
+
+func (m *memorySessions) Touch(id string, at time.Time) error {
+	for _, s := range m.byToken {
+		if s.ID == id {
+			s.LastActivity = at
+			m.touched++
+		}
+	}
+	return nil
+}
+
+func (m *memorySessions) Delete(id string) error {
+	for token, s := range m.byToken {
+		if s.ID == id {
+			delete(m.byToken, token)
+		}
+	}
+	return nil
+}
+
+// newIdleTestUseCase returns a use case with a 30-minute idle timeout and a
+// logged-in user, along with that user's access token.
+func newIdleTestUseCase(t *testing.T) (*AuthUseCase, *memorySessions, string) {
+	t.Helper()
+	users := &fakeUsers{byEmail: map[string]*domain.User{}}
+	sessions := &memorySessions{byToken: map[string]*domain.Session{}}
+	security := config.SecurityConfig{BCryptCost: bcrypt.MinCost, SessionIdleTimeout: 30 * time.Minute}
+	jwtConfig := config.JWTConfig{Secret: "test-secret", AccessTokenExpiry: 24 * time.Hour, RefreshTokenExpiry: 48 * time.Hour}
+	uc := NewAuthUseCase(users, sessions, jwtConfig, config.OAuthConfig{}, security, nopLogger{})
+	if _, err := uc.Register(context.Background(), "ada@example.com", "correct horse", "", ""); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	access, _, err := uc.Login(context.Background(), "ada@example.com", "correct horse", "test", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Login error: %v", err)
+	}
+	return uc, sessions, access
+}
+
+func TestValidateToken_ActiveSessionRecordsActivity(t *testing.T) {
+	uc, sessions, access := newIdleTestUseCase(t)
+	sessions.byToken[access].LastActivity = time.Now().Add(-20 * time.Minute)
+
+	if _, err := uc.ValidateToken(context.Background(), access); err != nil {
+		t.Fatalf("ValidateToken error: %v", err)
+	}
+	if sessions.touched != 1 || time.Since(sessions.byToken[access].LastActivity) > time.Minute {
+		t.Errorf("activity not recorded: touched %d, last %v", sessions.touched, sessions.byToken[access].LastActivity)
+	}
+}
+
+func TestValidateToken_IdleSessionRejected(t *testing.T) {
+	uc, sessions, access := newIdleTestUseCase(t)
+	sessions.byToken[access].LastActivity = time.Now().Add(-31 * time.Minute)
+
+	if _, err := uc.ValidateToken(context.Background(), access); !errors.Is(err, ErrSessionIdle) {
+		t.Fatalf("error = %v, want ErrSessionIdle", err)
+	}
+	if _, ok := sessions.byToken[access]; ok {
+		t.Error("idle session should be deleted")
+	}
+	// The JWT itself is still unexpired, but its session is gone.
+	if _, err := uc.ValidateToken(context.Background(), access); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("error after deletion = %v, want ErrInvalidToken", err)
+	}
+}
+
+func (f *fakeUsers) Delete(id string) error {
+	for email, u := range f.byEmail {
+		if u.ID == id {
+			delete(f.byEmail, email)
+		}
+	}
+	return nil
+}
+
+// fakeRBAC records created workspaces and roles; failRole makes the
+// transaction storing them fail.
+type fakeRBAC struct {
+	domain.RBACRepository
+	workspaces map[string]*domain.Workspace
+	roles      []*domain.UserRole
+	failRole   bool
+}
+
+func (f *fakeRBAC) CreateWorkspaceWithOwner(ws *domain.Workspace, owner *domain.UserRole) error {
+	if f.failRole {
+		return errors.New("role store unavailable")
+	}
+	f.workspaces[ws.ID] = ws
+	f.roles = append(f.roles, owner)
+	return nil
+}
+
+// newWorkspaceTestUseCase returns a domain test use case with default
+// workspaces toggled by enabled, and its user and RBAC stores.
+func newWorkspaceTestUseCase(t *testing.T, enabled bool) (*AuthUseCase, *fakeUsers, *fakeRBAC) {
+	t.Helper()
+	uc := newDomainTestUseCase(t, "", "")
+	uc.securityConfig.CreateDefaultWorkspace = enabled
+	rbac := &fakeRBAC{workspaces: map[string]*domain.Workspace{}}
+	uc.SetRBAC(NewRBACUseCase(rbac, nopLogger{}))
+	return uc, uc.userRepo.(*fakeUsers), rbac
+}
+
+func TestRegister_CreatesDefaultWorkspace(t *testing.T) {
+	uc, _, rbac := newWorkspaceTestUseCase(t, true)
+	user, err := uc.Register(context.Background(), "ada@example.com", "correct horse", "Ada", "")
+	if err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if len(rbac.workspaces) != 1 || len(rbac.roles) != 1 {
+		t.Fatalf("workspaces = %d, roles = %d, want 1 each", len(rbac.workspaces), len(rbac.roles))
+	}
+	role := rbac.roles[0]
+	ws := rbac.workspaces[role.WorkspaceID]
+	if ws == nil || ws.OwnerID != user.ID || ws.Name != "Ada's workspace" {
+		t.Errorf("workspace = %+v, want one named \"Ada's workspace\" owned by %s", ws, user.ID)
+	}
+	if role.UserID != user.ID || role.Role != domain.RoleAdmin {
+		t.Errorf("role = %+v, want admin for %s", role, user.ID)
+	}
+}
+
+func TestRegister_DefaultWorkspaceDisabled(t *testing.T) {
+	uc, _, rbac := newWorkspaceTestUseCase(t, false)
+	if _, err := uc.Register(context.Background(), "ada@example.com", "correct horse", "", ""); err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if len(rbac.workspaces) != 0 || len(rbac.roles) != 0 {
+		t.Errorf("workspaces = %d, roles = %d, want none", len(rbac.workspaces), len(rbac.roles))
+	}
+}
+
+func TestRegister_DefaultWorkspaceFailureRemovesUser(t *testing.T) {
+	uc, users, rbac := newWorkspaceTestUseCase(t, true)
+	rbac.failRole = true
+	if _, err := uc.Register(context.Background(), "ada@example.com", "correct horse", "", ""); err == nil {
+		t.Fatal("Register succeeded without a default workspace")
+	}
+	if len(users.byEmail) != 0 || len(rbac.workspaces) != 0 {
+		t.Errorf("users = %d, workspaces = %d, want registration rolled back", len(users.byEmail), len(rbac.workspaces))
+	}
+}
+
+func TestCompleteOAuth_CreatesDefaultWorkspace(t *testing.T) {
+	uc, _, rbac := newWorkspaceTestUseCase(t, true)
+	user, _, _, _, err := uc.CompleteOAuth(context.Background(), "google", "code", "state")
+	if err != nil {
+		t.Fatalf("CompleteOAuth error: %v", err)
+	}
+	if len(rbac.roles) != 1 || rbac.roles[0].UserID != user.ID {
+		t.Fatalf("roles = %+v, want an owner role for %s", rbac.roles, user.ID)
+	}
+	if ws := rbac.workspaces[rbac.roles[0].WorkspaceID]; ws.Name != "Ada's workspace" {
+		t.Errorf("workspace name = %q", ws.Name)
+	}
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"neighbourhood/services/auth/internal/config"
+)
+
+// pepperPrefix marks a peppered hash, stored as "$pepper$<id>$<bcrypt hash>".
+// Hashes without it are plain bcrypt from before peppering was enabled.
+const pepperPrefix = "$pepper$"
+
+// passwordHasher hashes passwords with bcrypt, first HMAC-ing them with the
+// current server-side pepper when one is configured. The pepper ID is stored
+// with the hash so peppers can be rotated.
+type passwordHasher struct {
+	cost    int
+	current string
+	peppers map[string][]byte
+}
+
+func newPasswordHasher(cost int, peppers []config.Pepper) *passwordHasher {
+	h := &passwordHasher{cost: cost, peppers: make(map[string][]byte, len(peppers))}
+	for i, p := range peppers {
+		if i == 0 {
+			h.current = p.ID
+		}
+		h.peppers[p.ID] = []byte(p.Secret)
+	}
+	return h
+}
+
+// pepper returns the HMAC-SHA256 of password under secret, base64 encoded so
+// it stays within bcrypt's 72-byte input limit.
+func pepper(secret []byte, password string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// Hash returns the stored form of password.
+func (h *passwordHasher) Hash(password string) (string, error) {
+	if h.current == "" {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+		return string(hashed), err
+	}
+	hashed, err := bcrypt.GenerateFromPassword(pepper(h.peppers[h.current], password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return pepperPrefix + h.current + "$" + string(hashed), nil
+}
+
+// Verify reports whether password matches stored, and whether stored should
+// be replaced with a fresh Hash because it uses an older pepper or none.
+func (h *passwordHasher) Verify(stored, password string) (ok, rehash bool) {
+	rest, peppered := strings.CutPrefix(stored, pepperPrefix)
+	if !peppered {
+		if bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) != nil {
+			return false, false
+		}
+		return true, h.current != ""
+	}
+	id, hashed, found := strings.Cut(rest, "$")
+	secret, known := h.peppers[id]
+	if !found || !known {
+		return false, false
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hashed), pepper(secret, password)) != nil {
+		return false, false
+	}
+	return true, id != h.current
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"neighbourhood/services/auth/internal/config"
+	"neighbourhood/services/auth/internal/domain"
+)
+
+// fakeUsers keeps users in memory. Methods the tests do not need panic via
+// the nil embedded interfaces.
+type fakeUsers struct {
+	domain.UserRepository
+	domain.OAuthRepository
+	byEmail map[string]*domain.User
+	updates int
+}
+
+func (f *fakeUsers) GetByEmail(email string) (*domain.User, error) {
+	if u, ok := f.byEmail[email]; ok {
+		copied := *u
+		return &copied, nil
+	}
+	return nil, ErrUserNotFound
+}
+
+func (f *fakeUsers) Create(u *domain.User) error {
+	f.byEmail[u.Email] = u
+	return nil
+}
+
+func (f *fakeUsers) Update(u *domain.User) error {
+	f.updates++
+	f.byEmail[u.Email] = u
+	return nil
+}
+
+// fakeSessions accepts sessions and never locks accounts.
+type fakeSessions struct {
+	domain.SessionRepository
+	domain.LoginAttemptRepository
+}
+
+func (fakeSessions) Create(*domain.Session) error  { return nil }
+func (fakeSessions) IsLocked(string) (bool, error) { return false, nil }
+func (fakeSessions) Record(string) error           { return nil }
+func (fakeSessions) Reset(string) error            { return nil }
+
+type nopLogger struct{}
+
+func (nopLogger) Info(...interface{})  {}
+func (nopLogger) Error(...interface{}) {}
+func (nopLogger) Warn(...interface{})  {}
+
+func newTestUseCase(peppers string) (*AuthUseCase, *fakeUsers) {
+	users := &fakeUsers{byEmail: map[string]*domain.User{}}
+	security := config.SecurityConfig{BCryptCost: bcrypt.MinCost, PasswordPeppers: peppers}
+	jwt := config.JWTConfig{Secret: "test-secret"}
+	return NewAuthUseCase(users, fakeSessions{}, jwt, config.OAuthConfig{}, security, nopLogger{}), users
+}
+
+func TestPasswordHasher_PepperedHashVerifies(t *testing.T) {
+	h := newPasswordHasher(bcrypt.MinCost, []config.Pepper{{ID: "v2", Secret: "0123456789abcdef-two"}})
+
+	hash, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash error: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$pepper$v2$") {
+		t.Errorf("hash %q lacks the pepper version prefix", hash)
+	}
+	if ok, rehash := h.Verify(hash, "correct horse"); !ok || rehash {
+		t.Errorf("Verify = %v, %v; want true, false", ok, rehash)
+	}
+	if ok, _ := h.Verify(hash, "wrong"); ok {
+		t.Error("wrong password verified")
+	}
+	// Without the pepper the bcrypt part alone is useless.
+	if bcrypt.CompareHashAndPassword([]byte(strings.TrimPrefix(hash, "$pepper$v2$")), []byte("correct horse")) == nil {
+		t.Error("peppered hash verified without the pepper")
+	}
+}
+
+func TestPasswordHasher_RotatedPepper(t *testing.T) {
+	old := newPasswordHasher(bcrypt.MinCost, []config.Pepper{{ID: "v1", Secret: "0123456789abcdef-one"}})
+	hash, _ := old.Hash("correct horse")
+
+	rotated := newPasswordHasher(bcrypt.MinCost, []config.Pepper{
+		{ID: "v2", Secret: "0123456789abcdef-two"},
+		{ID: "v1", Secret: "0123456789abcdef-one"},
+	})
+	if ok, rehash := rotated.Verify(hash, "correct horse"); !ok || !rehash {
+		t.Errorf("Verify with retired pepper = %v, %v; want true, true", ok, rehash)
+	}
+
+	dropped := newPasswordHasher(bcrypt.MinCost, []config.Pepper{{ID: "v2", Secret: "0123456789abcdef-two"}})
+	if ok, _ := dropped.Verify(hash, "correct horse"); ok {
+		t.Error("hash verified after its pepper was removed")
+	}
+}
+
+func TestLogin_MigratesUnpepperedHash(t *testing.T) {
+	uc, users := newTestUseCase("v1:0123456789abcdef-one")
+	legacy, _ := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	users.byEmail["ada@example.com"] = &domain.User{ID: "u1", Email: "ada@example.com", PasswordHash: string(legacy)}
+
+	if _, _, err := uc.Login(context.Background(), "ada@example.com", "correct horse", "test", "127.0.0.1"); err != nil {
+		t.Fatalf("Login error: %v", err)
+	}
+	stored := users.byEmail["ada@example.com"].PasswordHash
+	if users.updates != 1 || !strings.HasPrefix(stored, "$pepper$v1$") {
+		t.Fatalf("hash not migrated: %d updates, stored %q", users.updates, stored)
+	}
+
+	// The migrated hash still logs in and is not rewritten again.
+	if _, _, err := uc.Login(context.Background(), "ada@example.com", "correct horse", "test", "127.0.0.1"); err != nil {
+		t.Fatalf("Login after migration error: %v", err)
+	}
+	if users.updates != 1 {
+		t.Errorf("expected no further rehash, got %d updates", users.updates)
+	}
+}
+
+func TestRegister_UsesPepper(t *testing.T) {
+	uc, _ := newTestUseCase("v1:0123456789abcdef-one")
+	user, err := uc.Register(context.Background(), "ada@example.com", "correct horse", "Ada", "Lovelace")
+	if err != nil {
+		t.Fatalf("Register error: %v", err)
+	}
+	if !strings.HasPrefix(user.PasswordHash, "$pepper$v1$") {
+		t.Errorf("registered hash %q is not peppered", user.PasswordHash)
+	}
+}
+
+func TestParsePeppers_Invalid(t *testing.T) {
+	for _, spec := range []string{"v1", "v1:short", "v1:0123456789abcdef,v1:0123456789abcdef", "v-1:0123456789abcdef"} {
+		if _, err := config.ParsePeppers(spec); err == nil {
+			t.Errorf("ParsePeppers(%q) should fail", spec)
+		}
+	}
+}
@@ -43,13 +43,7 @@ func (uc *RBACUseCase) CreateWorkspace(ctx context.Context, ownerID, name, descr
 		Settings:    make(map[string]interface{}),
 	}
 
-	// Create workspace - O(1)
-	if err := uc.rbacRepo.CreateWorkspace(workspace); err != nil {
-		uc.logger.Error("Failed to create workspace", "error", err, "owner_id", ownerID)
-		return nil, fmt.Errorf("failed to create workspace: %w", err)
-	}
-
-	// Assign owner as admin - O(1)
+	// Assign owner as admin
 	ownerRole := &domain.UserRole{
 		ID:          uuid.New().String(),
 		UserID:      ownerID,
@@ -61,13 +55,10 @@ func (uc *RBACUseCase) CreateWorkspace(ctx context.Context, ownerID, name, descr
 		CreatedBy:   ownerID,
 	}
 
-	if err := uc.rbacRepo.CreateUserRole(ownerRole); err != nil {
-		uc.logger.Error("Failed to assign owner role", "error", err, "workspace_id", workspaceID)
-		// Rollback workspace creation
-		if delErr := uc.rbacRepo.DeleteWorkspace(workspaceID); delErr != nil {
-			uc.logger.Error("Failed to rollback workspace creation", "error", delErr, "workspace_id", workspaceID)
-		}
-		return nil, fmt.Errorf("failed to assign owner role: %w", err)
+	// Create workspace and owner role in one transaction - O(1)
+	if err := uc.rbacRepo.CreateWorkspaceWithOwner(workspace, ownerRole); err != nil {
+		uc.logger.Error("Failed to create workspace", "error", err, "owner_id", ownerID)
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
 	}
 
 	uc.logger.Info("Workspace created successfully", "workspace_id", workspaceID, "owner_id", ownerID)
@@ -160,6 +151,43 @@ func (uc *RBACUseCase) CheckPermission(ctx context.Context, userID, workspaceID
 	return hasPermission, nil
 }
 
+// BatchCheckPermissions answers many permission checks for one user at once,
+// for UIs that render many permission-gated controls. Checks in workspaces
+// the user is not a member of are false.
+// Time Complexity: O(c) - one indexed query for all workspaces, then c checks
+func (uc *RBACUseCase) BatchCheckPermissions(ctx context.Context, userID string, checks []domain.PermissionCheck) (map[domain.PermissionCheck]bool, error) {
+	results := make(map[domain.PermissionCheck]bool, len(checks))
+	if len(checks) == 0 {
+		return results, nil
+	}
+
+	// Deduplicate workspaces so each is fetched once
+	seen := make(map[string]bool, len(checks))
+	var workspaceIDs []string
+	for _, c := range checks {
+		if !seen[c.WorkspaceID] {
+			seen[c.WorkspaceID] = true
+			workspaceIDs = append(workspaceIDs, c.WorkspaceID)
+		}
+	}
+
+	roles, err := uc.rbacRepo.GetUserRolesInWorkspaces(userID, workspaceIDs)
+	if err != nil {
+		uc.logger.Error("Batch permission check failed", "error", err, "user_id", userID, "workspaces", len(workspaceIDs))
+		return nil, err
+	}
+	byWorkspace := make(map[string]*domain.UserRole, len(roles))
+	for _, r := range roles {
+		byWorkspace[r.WorkspaceID] = r
+	}
+
+	for _, c := range checks {
+		role, ok := byWorkspace[c.WorkspaceID]
+		results[c] = ok && role.HasPermission(c.Permission)
+	}
+	return results, nil
+}
+
 // AssignRole assigns or updates a user's role in a workspace
 // Time Complexity: O(1) - single indexed operation
 func (uc *RBACUseCase) AssignRole(ctx context.Context, adminUserID, targetUserID, workspaceID string, role domain.Role, customPermissions []domain.Permission) error {
@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"neighbourhood/services/auth/internal/domain"
+)
+
+// fakeRoles serves user roles from memory and counts role queries.
+type fakeRoles struct {
+	domain.RBACRepository
+	roles   []*domain.UserRole
+	queries int
+	err     error
+}
+
+func (f *fakeRoles) GetUserRolesInWorkspaces(userID string, workspaceIDs []string) ([]*domain.UserRole, error) {
+	f.queries++
+	if f.err != nil {
+		return nil, f.err
+	}
+	var out []*domain.UserRole
+	for _, r := range f.roles {
+		for _, ws := range workspaceIDs {
+			if r.UserID == userID && r.WorkspaceID == ws {
+				out = append(out, r)
+			}
+		}
+	}
+	return out, nil
+}
+
+func TestBatchCheckPermissions_AcrossWorkspaces(t *testing.T) {
+	repo := &fakeRoles{roles: []*domain.UserRole{
+		{UserID: "u1", WorkspaceID: "ws-admin", Role: domain.RoleAdmin},
+		{UserID: "u1", WorkspaceID: "ws-viewer", Role: domain.RoleViewer},
+		{UserID: "u1", WorkspaceID: "ws-custom", Role: domain.RoleViewer, Permissions: []domain.Permission{domain.PermissionAPIKeyCreate}},
+		{UserID: "u2", WorkspaceID: "ws-other", Role: domain.RoleAdmin},
+	}}
+	uc := NewRBACUseCase(repo, nopLogger{})
+
+	want := map[domain.PermissionCheck]bool{
+		{WorkspaceID: "ws-admin", Permission: domain.PermissionUserDelete}:          true,
+		{WorkspaceID: "ws-admin", Permission: domain.PermissionIntegrationExecute}:  true,
+		{WorkspaceID: "ws-viewer", Permission: domain.PermissionIntegrationRead}:    true,
+		{WorkspaceID: "ws-viewer", Permission: domain.PermissionIntegrationExecute}: false,
+		{WorkspaceID: "ws-custom", Permission: domain.PermissionAPIKeyCreate}:       true,
+		// u1 is not a member of ws-other, even though u2 is its admin.
+		{WorkspaceID: "ws-other", Permission: domain.PermissionWorkspaceRead}:   false,
+		{WorkspaceID: "ws-missing", Permission: domain.PermissionWorkspaceRead}: false,
+	}
+	var checks []domain.PermissionCheck
+	for c := range want {
+		checks = append(checks, c)
+	}
+
+	got, err := uc.BatchCheckPermissions(context.Background(), "u1", checks)
+	if err != nil {
+		t.Fatalf("BatchCheckPermissions: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d results, want %d", len(got), len(want))
+	}
+	for c, w := range want {
+		if got[c] != w {
+			t.Errorf("%s in %s = %v, want %v", c.Permission, c.WorkspaceID, got[c], w)
+		}
+	}
+	if repo.queries != 1 {
+		t.Errorf("role queries = %d, want 1 for all workspaces", repo.queries)
+	}
+}
+
+func TestBatchCheckPermissions_Empty(t *testing.T) {
+	repo := &fakeRoles{}
+	got, err := NewRBACUseCase(repo, nopLogger{}).BatchCheckPermissions(context.Background(), "u1", nil)
+	if err != nil || len(got) != 0 || repo.queries != 0 {
+		t.Errorf("got %v, %v after %d queries; want no results and no query", got, err, repo.queries)
+	}
+}
+
+func TestBatchCheckPermissions_RepositoryError(t *testing.T) {
+	repo := &fakeRoles{err: errors.New("db down")}
+	checks := []domain.PermissionCheck{{WorkspaceID: "ws-admin", Permission: domain.PermissionUserRead}}
+	if _, err := NewRBACUseCase(repo, nopLogger{}).BatchCheckPermissions(context.Background(), "u1", checks); err == nil {
+		t.Error("expected the repository error")
+	}
+}
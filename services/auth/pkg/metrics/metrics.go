@@ -63,6 +63,13 @@ var (
 		},
 	)
 
+	sessionsReapedTotal = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "auth_sessions_reaped_total",
+			Help: "Total number of dangling session IDs removed by the session reaper",
+		},
+	)
+
 	tokenValidationsTotal = promauto.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "auth_token_validations_total",
@@ -159,6 +166,10 @@ func SetActiveSessions(count float64) {
 	activeSessionsGauge.Set(count)
 }
 
+func RecordSessionsReaped(count int) {
+	sessionsReapedTotal.Add(float64(count))
+}
+
 func RecordTokenValidation(valid bool) {
 	status := "valid"
 	if !valid {
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"neighbourhood/services/integration/internal/config"
 )
@@ -14,6 +16,9 @@ import (
 // SlackProvider implements Slack integration
 type SlackProvider struct {
 	config config.ProviderConfig
+	// apiBaseURL overrides the Slack Web API root; empty uses
+	// https://slack.com/api.
+	apiBaseURL string
 }
 
 func NewSlackProvider(cfg config.ProviderConfig) *SlackProvider {
@@ -76,7 +81,7 @@ func (p *SlackProvider) Execute(ctx context.Context, token *Token, action string
 	case "send_message":
 		return p.sendMessage(ctx, token, params)
 	case "list_channels":
-		return p.listChannels(ctx, token)
+		return p.listChannels(ctx, token, params)
 	default:
 		return nil, fmt.Errorf("unsupported action: %s", action)
 	}
@@ -118,26 +123,157 @@ func (p *SlackProvider) sendMessage(ctx context.Context, token *Token, params ma
 	return result, nil
 }
 
-func (p *SlackProvider) listChannels(ctx context.Context, token *Token) (interface{}, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://slack.com/api/conversations.list", nil)
-	if err != nil {
-		return nil, err
-	}
+// slackChannel is a conversation as returned by list_channels.
+type slackChannel struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	IsPrivate bool   `json:"is_private"`
+}
 
-	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+const (
+	// slackChannelPageSize is the page size requested from conversations.list;
+	// Slack recommends no more than 200.
+	slackChannelPageSize = 200
+	// defaultSlackChannelLimit caps list_channels when no limit is given.
+	defaultSlackChannelLimit = 1000
+	// slackMaxRateLimitRetries is how many 429 replies a single page may get
+	// before list_channels gives up.
+	slackMaxRateLimitRetries = 3
+)
 
-	client := &http.Client{Timeout: p.config.Timeout}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+// slackConversationTypes maps the list_channels types param to Slack's
+// conversation types.
+var slackConversationTypes = map[string]string{
+	"public":  "public_channel",
+	"private": "private_channel",
+	"im":      "im",
+}
+
+// listChannels pages through conversations.list, following next_cursor
+// until it is empty or limit channels have been collected. The optional
+// params are limit and types, a comma-separated list of public, private and
+// im (default public). When the limit stops the listing early, next_cursor is
+// returned so the caller can continue.
+func (p *SlackProvider) listChannels(ctx context.Context, token *Token, params map[string]interface{}) (interface{}, error) {
+	limit := defaultSlackChannelLimit
+	if v, ok := params["limit"].(float64); ok {
+		if v < 1 {
+			return nil, fmt.Errorf("limit must be positive")
+		}
+		limit = int(v)
+	}
+	types := "public_channel"
+	if v, ok := params["types"].(string); ok && v != "" {
+		var slackTypes []string
+		for _, t := range strings.Split(v, ",") {
+			st, ok := slackConversationTypes[strings.TrimSpace(t)]
+			if !ok {
+				return nil, fmt.Errorf("unknown channel type %q: want public, private or im", t)
+			}
+			slackTypes = append(slackTypes, st)
+		}
+		types = strings.Join(slackTypes, ",")
+	}
+
+	channels := []slackChannel{}
+	cursor := ""
+	for len(channels) < limit {
+		query := url.Values{
+			"types": {types},
+			"limit": {strconv.Itoa(min(slackChannelPageSize, limit-len(channels)))},
+		}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		var page struct {
+			OK       bool   `json:"ok"`
+			Error    string `json:"error"`
+			Channels []struct {
+				ID        string `json:"id"`
+				Name      string `json:"name"`
+				IsPrivate bool   `json:"is_private"`
+				IsIM      bool   `json:"is_im"`
+			} `json:"channels"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		if err := p.slackGet(ctx, token, "conversations.list", query, &page); err != nil {
+			return nil, err
+		}
+		if !page.OK {
+			return nil, fmt.Errorf("slack conversations.list error: %s", page.Error)
+		}
+		for _, c := range page.Channels {
+			channels = append(channels, slackChannel{ID: c.ID, Name: c.Name, IsPrivate: c.IsPrivate || c.IsIM})
+		}
+		cursor = page.ResponseMetadata.NextCursor
+		if cursor == "" {
+			break
+		}
+	}
+	if len(channels) > limit {
+		channels = channels[:limit]
+	}
+
+	result := map[string]interface{}{"channels": channels}
+	if cursor != "" {
+		result["next_cursor"] = cursor
 	}
-	defer resp.Body.Close()
+	return result, nil
+}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode Slack response: %w", err)
+// slackGet calls a Slack Web API method and decodes the reply into out. A 429
+// is retried after the Retry-After delay, up to slackMaxRateLimitRetries
+// times, and the wait ends early when ctx is done.
+func (p *SlackProvider) slackGet(ctx context.Context, token *Token, method string, query url.Values, out interface{}) error {
+	base := p.apiBaseURL
+	if base == "" {
+		base = "https://slack.com/api"
 	}
-	return result, nil
+	client := &http.Client{Timeout: p.config.Timeout}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", base+"/"+method+"?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			resp.Body.Close()
+			if attempt >= slackMaxRateLimitRetries {
+				return fmt.Errorf("slack %s: rate limited", method)
+			}
+			select {
+			case <-time.After(retryAfter(resp.Header.Get("Retry-After"))):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("slack %s returned %d", method, resp.StatusCode)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode Slack response: %w", err)
+		}
+		return nil
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds, defaulting to one
+// second when it is missing or malformed.
+func retryAfter(header string) time.Duration {
+	secs, err := strconv.Atoi(header)
+	if err != nil || secs < 0 {
+		return time.Second
+	}
+	return time.Duration(secs) * time.Second
 }
 
 // GmailProvider implements Gmail integration
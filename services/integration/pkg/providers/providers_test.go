@@ -0,0 +1,129 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newSlackPagesServer serves conversations.list as two pages joined by the
+// cursor "page2", recording the query of each request.
+func newSlackPagesServer(t *testing.T, queries *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/conversations.list" || r.Header.Get("Authorization") != "Bearer xoxb-test" {
+			t.Errorf("unexpected request %s %v", r.URL.Path, r.Header)
+		}
+		*queries = append(*queries, r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("cursor") == "page2" {
+			w.Write([]byte(`{"ok":true,"channels":[{"id":"C3","name":"secret","is_private":true}],"response_metadata":{"next_cursor":""}}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"channels":[{"id":"C1","name":"general"},{"id":"C2","name":"random"}],"response_metadata":{"next_cursor":"page2"}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSlackListChannels_FollowsCursor(t *testing.T) {
+	var queries []string
+	p := &SlackProvider{apiBaseURL: newSlackPagesServer(t, &queries).URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "xoxb-test"}, "list_channels",
+		map[string]interface{}{"types": "public,private"})
+	if err != nil {
+		t.Fatalf("list_channels: %v", err)
+	}
+	want := []slackChannel{{ID: "C1", Name: "general"}, {ID: "C2", Name: "random"}, {ID: "C3", Name: "secret", IsPrivate: true}}
+	result := res.(map[string]interface{})
+	if got := result["channels"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("channels = %+v, want %+v", got, want)
+	}
+	if _, ok := result["next_cursor"]; ok {
+		t.Error("next_cursor set after the last page")
+	}
+	if len(queries) != 2 || queries[0] != "limit=200&types=public_channel%2Cprivate_channel" {
+		t.Errorf("queries = %q", queries)
+	}
+}
+
+func TestSlackListChannels_Limit(t *testing.T) {
+	var queries []string
+	p := &SlackProvider{apiBaseURL: newSlackPagesServer(t, &queries).URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "xoxb-test"}, "list_channels",
+		map[string]interface{}{"limit": float64(2)})
+	if err != nil {
+		t.Fatalf("list_channels: %v", err)
+	}
+	result := res.(map[string]interface{})
+	if got := result["channels"].([]slackChannel); len(got) != 2 {
+		t.Errorf("got %d channels, want 2", len(got))
+	}
+	if result["next_cursor"] != "page2" {
+		t.Errorf("next_cursor = %v, want page2", result["next_cursor"])
+	}
+	if len(queries) != 1 {
+		t.Errorf("made %d requests, want 1", len(queries))
+	}
+}
+
+func TestSlackListChannels_InvalidParams(t *testing.T) {
+	p := &SlackProvider{apiBaseURL: "http://127.0.0.1:0"}
+	for _, params := range []map[string]interface{}{
+		{"types": "public,group"},
+		{"limit": float64(0)},
+	} {
+		if _, err := p.Execute(context.Background(), &Token{}, "list_channels", params); err == nil {
+			t.Errorf("expected error for %v", params)
+		}
+	}
+}
+
+func TestSlackListChannels_RetriesRateLimit(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"ok":true,"channels":[{"id":"C1","name":"general"}]}`))
+	}))
+	defer srv.Close()
+	p := &SlackProvider{apiBaseURL: srv.URL}
+
+	res, err := p.Execute(context.Background(), &Token{AccessToken: "xoxb-test"}, "list_channels", nil)
+	if err != nil {
+		t.Fatalf("list_channels: %v", err)
+	}
+	if got := res.(map[string]interface{})["channels"].([]slackChannel); len(got) != 1 || calls != 2 {
+		t.Errorf("got %d channels after %d calls, want 1 after 2", len(got), calls)
+	}
+}
+
+func TestSlackListChannels_CancelDuringRetryAfter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	p := &SlackProvider{apiBaseURL: srv.URL}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := p.Execute(ctx, &Token{AccessToken: "xoxb-test"}, "list_channels", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("list_channels waited out Retry-After despite cancellation")
+	}
+}